@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/backup"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/recording"
+	"awesomeProject1/internal/region"
+	"awesomeProject1/internal/repository"
+	"awesomeProject1/internal/service"
+)
+
+func runCommand(ctx context.Context, name string, args []string, backupService *backup.Service, eventStore *repository.EventStoreRepository, recorder *recording.Recorder, repair *service.RepairService, backfill *service.BackfillService, reg *region.Region, logger *slog.Logger) error {
+	switch name {
+	case "backup":
+		return runBackup(ctx, args, backupService, logger)
+	case "restore":
+		return runRestore(ctx, args, backupService, logger)
+	case "export-anonymized":
+		return runExportAnonymized(ctx, args, backupService, logger)
+	case "es-snapshot":
+		return runEventSnapshot(ctx, eventStore, logger)
+	case "es-rebuild":
+		return runEventRebuild(ctx, eventStore, logger)
+	case "replay":
+		return runReplay(ctx, args, recorder, logger)
+	case "repair":
+		return runRepair(ctx, args, repair, logger)
+	case "backfill":
+		return runBackfill(ctx, args, backfill, logger)
+	case "promote":
+		return runPromote(ctx, args, reg, logger)
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+func runBackup(ctx context.Context, args []string, backupService *backup.Service, logger *slog.Logger) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	file := flags.String("file", "-", "output file for the compressed JSONL backup, - for stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return fmt.Errorf("create backup file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	count, err := backupService.Export(ctx, w)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Backup written", slog.String("file", *file), slog.Int("count", count))
+	return nil
+}
+
+func runRestore(ctx context.Context, args []string, backupService *backup.Service, logger *slog.Logger) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	file := flags.String("file", "-", "compressed JSONL backup to restore, - for stdin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return fmt.Errorf("open backup file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	count, err := backupService.Import(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Backup restored", slog.String("file", *file), slog.Int("count", count))
+	return nil
+}
+
+func runExportAnonymized(ctx context.Context, args []string, backupService *backup.Service, logger *slog.Logger) error {
+	flags := flag.NewFlagSet("export-anonymized", flag.ContinueOnError)
+	file := flags.String("file", "-", "output file for the anonymized export, - for stdout")
+	format := flags.String("format", backup.ExportJSONL, "export format: jsonl (gzip-compressed) or parquet")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return fmt.Errorf("create export file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	count, err := backupService.ExportAnonymized(ctx, w, *format)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Anonymized export written", slog.String("file", *file), slog.String("format", *format), slog.Int("count", count))
+	return nil
+}
+
+func runEventSnapshot(ctx context.Context, eventStore *repository.EventStoreRepository, logger *slog.Logger) error {
+	count, err := eventStore.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Event streams seeded", slog.Int64("count", count))
+	return nil
+}
+
+func runEventRebuild(ctx context.Context, eventStore *repository.EventStoreRepository, logger *slog.Logger) error {
+	projected, removed, err := eventStore.Rebuild(ctx)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Subscriptions rebuilt from event streams",
+		slog.Int64("projected", projected),
+		slog.Int64("removed", removed))
+	return nil
+}
+
+func runReplay(ctx context.Context, args []string, recorder *recording.Recorder, logger *slog.Logger) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	target := flags.String("target", "", "scheme and host of the instance to replay against, e.g. https://staging.example.com")
+	captureID := flags.String("capture", "", "replay the requests of this capture only")
+	principal := flags.String("principal", "", "replay the requests of this caller only")
+	since := flags.String("since", "", "replay the requests recorded since this RFC 3339 time only")
+	limit := flags.Int("limit", recording.MaxLimit, "maximum number of requests to replay")
+	adminToken := flags.String("admin-token", os.Getenv("REPLAY_ADMIN_TOKEN"), "admin token of the target, sent with every request")
+	apiKey := flags.String("api-key", os.Getenv("REPLAY_API_KEY"), "API key of the target, sent with every request")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *target == "" {
+		return errors.New("-target is required")
+	}
+
+	filter := models.RecordedRequestFilter{Principal: *principal, Limit: *limit}
+	if *captureID != "" {
+		id, err := uuid.Parse(*captureID)
+		if err != nil {
+			return fmt.Errorf("invalid -capture: %w", err)
+		}
+		filter.CaptureID = &id
+	}
+	if *since != "" {
+		t, err := time.Parse(time.RFC3339, *since)
+		if err != nil {
+			return fmt.Errorf("invalid -since: %w", err)
+		}
+		filter.Since = &t
+	}
+
+	recorded, err := recorder.List(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	result, err := recording.NewReplayer(*target, *adminToken, *apiKey, logger).Replay(ctx, recorded)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Recorded requests replayed",
+		slog.String("target", *target),
+		slog.Int("replayed", result.Replayed),
+		slog.Int("mismatched", result.Mismatched),
+		slog.Int("failed", result.Failed))
+	return nil
+}
+
+func runRepair(ctx context.Context, args []string, repair *service.RepairService, logger *slog.Logger) error {
+	flags := flag.NewFlagSet("repair", flag.ContinueOnError)
+	fixes := flags.String("fix", service.RepairDates+","+service.RepairServiceNames, "comma-separated fixes to apply: dates, service-names")
+	batchSize := flags.Int("batch", 500, "number of subscriptions repaired per batch")
+	pause := flags.Duration("pause", time.Second, "pause between batches")
+	dryRun := flags.Bool("dry-run", false, "log the repairs without saving them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *batchSize <= 0 {
+		return errors.New("-batch must be positive")
+	}
+
+	result, err := repair.Run(ctx, service.RepairOptions{
+		Fixes:     strings.Split(*fixes, ","),
+		BatchSize: *batchSize,
+		Pause:     *pause,
+		DryRun:    *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Subscriptions repaired",
+		slog.Bool("dry_run", *dryRun),
+		slog.Int("scanned", result.Scanned),
+		slog.Int("repaired", result.Repaired))
+	return nil
+}
+
+func runBackfill(ctx context.Context, args []string, backfill *service.BackfillService, logger *slog.Logger) error {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	targets := flags.String("target", service.BackfillEventStreams, "comma-separated derived data to backfill: event-streams, search-index")
+	batchSize := flags.Int("batch", 500, "number of rows backfilled per batch")
+	workers := flags.Int("workers", 4, "number of batches backfilled in parallel")
+	pause := flags.Duration("pause", 0, "pause of each worker between its batches")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *batchSize <= 0 {
+		return errors.New("-batch must be positive")
+	}
+	if *workers <= 0 {
+		return errors.New("-workers must be positive")
+	}
+
+	results, err := backfill.Run(ctx, service.BackfillOptions{
+		Targets:   strings.Split(*targets, ","),
+		BatchSize: *batchSize,
+		Workers:   *workers,
+		Pause:     *pause,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		logger.Info("Derived data backfilled",
+			slog.String("target", result.Target),
+			slog.Int64("scanned", result.Scanned),
+			slog.Int64("backfilled", result.Backfilled))
+	}
+	return nil
+}
+
+func runPromote(ctx context.Context, args []string, reg *region.Region, logger *slog.Logger) error {
+	flags := flag.NewFlagSet("promote", flag.ContinueOnError)
+	activeReadyz := flags.String("active-readyz", "", "readiness URL of an instance of the active region, which must not answer as active")
+	force := flags.Bool("force", false, "promote without checking the active region")
+	wait := flags.Duration("wait", time.Minute, "how long to wait for the database to finish promotion")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := reg.Promote(ctx, region.PromoteOptions{
+		ActiveReadyz: *activeReadyz,
+		Force:        *force,
+		Wait:         *wait,
+	}); err != nil {
+		return err
+	}
+
+	logger.Info("Region promoted; restart its instances with REGION_ROLE=active, and the former active region's with REGION_ROLE=passive before they come back",
+		slog.String("region", reg.Name()))
+	return nil
+}
@@ -2,30 +2,32 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"log/slog"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
-	"github.com/gin-gonic/gin"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	"github.com/shopspring/decimal"
 
+	"awesomeProject1/internal/app"
+	"awesomeProject1/internal/buildinfo"
 	"awesomeProject1/internal/config"
-	"awesomeProject1/internal/handler"
-	"awesomeProject1/internal/repository"
-	"awesomeProject1/internal/service"
 )
 
 func main() {
+	// Amounts have always been JSON numbers, so decimals are written without the quotes
+	// shopspring adds by default, keeping every digit; /v3 writes them as strings
+	// itself. It is set here, once, rather than as a side effect of importing a package.
+	decimal.MarshalJSONWithoutQuotes = true
+
+	info := buildinfo.Current()
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
-	}))
+	})).With(
+		slog.String("version", info.Version),
+		slog.String("commit", info.Commit),
+		slog.String("instance_id", info.InstanceID.String()))
 	slog.SetDefault(logger)
 
 	logger.Info("Starting application")
@@ -44,19 +46,19 @@ func main() {
 		slog.String("dbname", cfg.DBName),
 		slog.String("user", cfg.DBUser))
 
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName,
-	)
-
-	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: NewGormLogger(logger),
-	})
+	application, err := app.New(cfg, app.WithLogger(logger))
 	if err != nil {
-		logger.Error("Failed to connect to PostgreSQL", slog.String("error", err.Error()))
-		log.Fatal("Failed to connect to PostgreSQL:", err)
+		logger.Error("Failed to initialize application", slog.String("error", err.Error()))
+		log.Fatal("Failed to initialize application:", err)
+	}
+
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), os.Args[1], os.Args[2:], application.Backup(), application.EventStore(), application.Recorder(), application.Repair(), application.Backfill(), application.Region(), logger); err != nil {
+			logger.Error("Command failed", slog.String("command", os.Args[1]), slog.String("error", err.Error()))
+			log.Fatal("Command failed:", err)
+		}
+		return
 	}
-	logger.Info("Successfully connected to PostgreSQL")
 
 	//logger.Info("Running database migrations")
 	//if err := gormDB.AutoMigrate(&models.Subscription{}); err != nil {
@@ -67,110 +69,11 @@ func main() {
 
 	//we used traditional migrations
 
-	logger.Info("Initializing repository and service layers")
-	repo := repository.NewSubscriptionRepository(gormDB, logger)
-	service := service.NewSubscriptionService(repo, logger)
-
-	logger.Info("Initializing HTTP server")
-	router := gin.Default()
-
-	router.Use(RequestLoggingMiddleware(logger))
-
-	subHandler := handler.NewSubscriptionHandler(service, logger)
-
-	api := router.Group("/subscriptions")
-	{
-		api.POST("", subHandler.Create)
-		api.GET("/:id", subHandler.GetByID)
-		api.PUT("/:id", subHandler.Update)
-		api.DELETE("/:id", subHandler.Delete)
-		api.GET("", subHandler.List)
-		api.POST("/aggregate", subHandler.Aggregate)
-	}
-
-	srv := &http.Server{
-		Addr:    ":" + cfg.ServerPort,
-		Handler: router,
-	}
-
-	logger.Info("Starting HTTP server", slog.String("port", cfg.ServerPort))
-
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("HTTP server failed", slog.String("error", err.Error()))
-			log.Fatalf("listen: %s\n", err)
-		}
-	}()
-
-	logger.Info("HTTP server started successfully", slog.String("port", cfg.ServerPort))
-
 	// Graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	logger.Info("Received shutdown signal, shutting down server...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Error("Server forced to shutdown", slog.String("error", err.Error()))
-		log.Fatal("Server forced to shutdown:", err)
-	}
-	logger.Info("Server shutdown completed successfully")
-}
-
-func RequestLoggingMiddleware(logger *slog.Logger) gin.HandlerFunc {
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		logger.Info("HTTP Request",
-			slog.String("method", param.Method),
-			slog.String("path", param.Path),
-			slog.Int("status", param.StatusCode),
-			slog.Duration("latency", param.Latency),
-			slog.String("client_ip", param.ClientIP),
-			slog.String("user_agent", param.Request.UserAgent()),
-		)
-		return ""
-	})
-}
-
-func NewGormLogger(logger *slog.Logger) *GormLogger {
-	return &GormLogger{logger: logger}
-}
-
-type GormLogger struct {
-	logger *slog.Logger
-}
-
-func (l *GormLogger) LogMode(logger.LogLevel) logger.Interface {
-	return l
-}
-
-func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
-	l.logger.InfoContext(ctx, msg, slog.Any("data", data))
-}
-
-func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
-	l.logger.WarnContext(ctx, msg, slog.Any("data", data))
-}
-
-func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
-	l.logger.ErrorContext(ctx, msg, slog.Any("data", data))
-}
-
-func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
-	elapsed := time.Since(begin)
-	sql, rows := fc()
-
-	if err != nil {
-		l.logger.ErrorContext(ctx, "Database query error",
-			slog.String("sql", sql),
-			slog.Int64("rows", rows),
-			slog.Duration("elapsed", elapsed),
-			slog.String("error", err.Error()))
-	} else {
-		l.logger.DebugContext(ctx, "Database query",
-			slog.String("sql", sql),
-			slog.Int64("rows", rows),
-			slog.Duration("elapsed", elapsed))
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := application.Run(ctx); err != nil {
+		logger.Error("Application stopped with error", slog.String("error", err.Error()))
+		log.Fatal("Application stopped with error:", err)
 	}
 }
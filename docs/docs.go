@@ -0,0 +1,7 @@
+// Package docs embeds the OpenAPI description of the API so the server can publish it.
+package docs
+
+import _ "embed"
+
+//go:embed swagger.json
+var Swagger []byte
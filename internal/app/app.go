@@ -0,0 +1,283 @@
+// Package app builds the subscription service from its configuration and runs it.
+// cmd/main.go is a thin wrapper around it; integration tests and embedders can boot
+// the same application in-process, replacing parts of it with options.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/audit"
+	"awesomeProject1/internal/backup"
+	"awesomeProject1/internal/cdc"
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/config"
+	"awesomeProject1/internal/handler"
+	"awesomeProject1/internal/leader"
+	"awesomeProject1/internal/listener"
+	"awesomeProject1/internal/lock"
+	"awesomeProject1/internal/metering"
+	"awesomeProject1/internal/readonly"
+	"awesomeProject1/internal/recording"
+	"awesomeProject1/internal/region"
+	"awesomeProject1/internal/repository"
+	"awesomeProject1/internal/scheduler"
+	"awesomeProject1/internal/service"
+	"awesomeProject1/internal/workerpool"
+)
+
+type App struct {
+	cfg    *config.Config
+	logger *slog.Logger
+	clock  clock.Clock
+
+	dsn      string
+	readOnly *readonly.Mode
+	region   *region.Region
+
+	backup     *backup.Service
+	eventStore *repository.EventStoreRepository
+	recorder   *recording.Recorder
+	repair     *service.RepairService
+	backfill   *service.BackfillService
+	audit      *audit.Recorder
+	meter      *metering.Meter
+	jobs       *scheduler.Scheduler
+	deliveries *workerpool.Pool
+	asyncJobs  *service.AsyncJobService
+	locker     *lock.Locker
+	elector    *leader.Elector
+	instances  *service.InstanceService
+	health     *handler.HealthHandler
+
+	routers routers
+}
+
+// options are the dependencies an Option replaces; nil ones are built as configured.
+type options struct {
+	logger        *slog.Logger
+	clock         clock.Clock
+	router        *gin.Engine
+	db            *gorm.DB
+	subscriptions repository.SubscriptionStore
+}
+
+type Option func(*options)
+
+// WithLogger sets the logger, slog.Default() otherwise.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithClock sets the clock time-dependent logic runs on, the system clock otherwise.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithRouter installs the middleware and routes on router instead of a new
+// gin.Default() engine.
+func WithRouter(router *gin.Engine) Option {
+	return func(o *options) {
+		o.router = router
+	}
+}
+
+// WithDatabase uses db instead of connecting to the configured database.
+func WithDatabase(db *gorm.DB) Option {
+	return func(o *options) {
+		o.db = db
+	}
+}
+
+// WithRepository replaces the subscription repository. Calls to it are still logged.
+func WithRepository(repo repository.SubscriptionStore) Option {
+	return func(o *options) {
+		o.subscriptions = repo
+	}
+}
+
+// New builds the application without starting anything.
+func New(cfg *config.Config, opts ...Option) (*App, error) {
+	o := options{
+		logger: slog.Default(),
+		clock:  clock.System{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return initialize(cfg, o.logger, o.clock, o)
+}
+
+// Handler returns the HTTP handler serving the API, for in-process tests.
+func (a *App) Handler() http.Handler {
+	return a.routers.public
+}
+
+// InternalHandler returns the handler serving the operational routes on the internal
+// port, or nil when they are served by Handler because no INTERNAL_PORT is set.
+func (a *App) InternalHandler() http.Handler {
+	if a.routers.internal == nil {
+		return nil
+	}
+	return a.routers.internal
+}
+
+// lead runs the leader-only subsystems until ctx is done. Exclusive scheduled jobs
+// check leadership themselves.
+func (a *App) lead(ctx context.Context) {
+	if a.cfg.CDCEnabled {
+		a.logger.Info("Starting change data capture listener")
+		cdc.NewListener(a.dsn, a.audit, a.locker, a.logger).Run(ctx)
+		return
+	}
+	<-ctx.Done()
+}
+
+func (a *App) Backfill() *service.BackfillService {
+	return a.backfill
+}
+
+func (a *App) Backup() *backup.Service {
+	return a.backup
+}
+
+func (a *App) EventStore() *repository.EventStoreRepository {
+	return a.eventStore
+}
+
+func (a *App) Recorder() *recording.Recorder {
+	return a.recorder
+}
+
+func (a *App) Region() *region.Region {
+	return a.region
+}
+
+func (a *App) Repair() *service.RepairService {
+	return a.repair
+}
+
+// Run starts the background jobs and the HTTP servers and blocks until ctx is done or
+// the server fails, then shuts everything down gracefully.
+func (a *App) Run(ctx context.Context) error {
+	l, addr, err := listener.Listen(listener.Config{
+		Port:       a.cfg.ServerPort,
+		ReusePort:  a.cfg.ReusePort,
+		Socket:     a.cfg.ServerSocket,
+		SocketMode: a.cfg.ServerSocketMode,
+	})
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	servers := []*http.Server{{Handler: a.routers.public}}
+	listeners := []net.Listener{l}
+	addrs := []string{addr}
+
+	if a.routers.internal != nil {
+		internal, err := listener.TCP(a.cfg.InternalPort, a.cfg.ReusePort)
+		if err != nil {
+			l.Close()
+			return fmt.Errorf("listen on internal port: %w", err)
+		}
+		servers = append(servers, &http.Server{Handler: a.routers.internal})
+		listeners = append(listeners, internal)
+		addrs = append(addrs, internal.Addr().String())
+	}
+
+	// Register right away instead of after the first heartbeat interval. The passive
+	// region's standby takes no writes, so its instances neither register nor lead.
+	passive := a.region.Passive()
+	if !passive {
+		if err := a.instances.Heartbeat(ctx); err != nil {
+			a.logger.Error("Failed to register instance", slog.String("error", err.Error()))
+		}
+	}
+
+	a.logger.Info("Starting background scheduler")
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	a.jobs.Start(jobsCtx)
+
+	if !passive {
+		go a.elector.Run(jobsCtx, a.lead)
+	}
+
+	serverErr := make(chan error, len(servers))
+	for i, srv := range servers {
+		a.logger.Info("Starting HTTP server", slog.String("addr", addrs[i]))
+		go func() {
+			if err := srv.Serve(listeners[i]); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+	}
+
+	a.logger.Info("HTTP server started successfully", slog.Any("addrs", addrs))
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		a.logger.Info("Received shutdown signal, shutting down server...")
+	case runErr = <-serverErr:
+		a.logger.Error("HTTP server failed", slog.String("error", runErr.Error()))
+	}
+
+	// Fail readiness first and give load balancers ShutdownDelay to notice, then stop
+	// accepting and let in-flight requests finish within ShutdownTimeout. With
+	// REUSE_PORT a new process already shares the port, so no connection is refused.
+	a.health.Drain()
+	if a.cfg.ShutdownDelay > 0 {
+		a.logger.Info("Draining before shutdown", slog.Duration("delay", a.cfg.ShutdownDelay))
+		time.Sleep(a.cfg.ShutdownDelay)
+	}
+
+	stopJobs()
+	a.jobs.Wait()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			a.logger.Error("Server forced to shutdown", slog.String("error", err.Error()))
+			if runErr == nil {
+				runErr = err
+			}
+		}
+	}
+
+	// Jobs still running past the shutdown timeout are cancelled and later failed by
+	// the sweep of another instance.
+	if err := a.asyncJobs.Close(shutdownCtx); err != nil {
+		a.logger.Error("Async jobs did not finish before shutdown", slog.String("error", err.Error()))
+	}
+
+	// Let queued webhooks and notifications go out; the shutdown timeout bounds the wait.
+	if err := a.deliveries.Close(shutdownCtx); err != nil {
+		a.logger.Error("Outbound deliveries did not finish before shutdown", slog.String("error", err.Error()))
+	}
+
+	if !passive {
+		if err := a.instances.Leave(context.Background()); err != nil {
+			a.logger.Error("Failed to deregister instance", slog.String("error", err.Error()))
+		}
+	}
+
+	if err := a.meter.Flush(context.Background()); err != nil {
+		a.logger.Error("Failed to flush API usage on shutdown", slog.String("error", err.Error()))
+	}
+	a.logger.Info("Server shutdown completed successfully")
+
+	return runErr
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/logger"
+)
+
+func RequestLoggingMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		logger.Info("HTTP Request",
+			slog.String("method", param.Method),
+			slog.String("path", param.Path),
+			slog.Int("status", param.StatusCode),
+			slog.Duration("latency", param.Latency),
+			slog.String("client_ip", param.ClientIP),
+			slog.String("user_agent", param.Request.UserAgent()),
+		)
+		return ""
+	})
+}
+
+func NewGormLogger(logger *slog.Logger) *GormLogger {
+	return &GormLogger{logger: logger}
+}
+
+type GormLogger struct {
+	logger *slog.Logger
+}
+
+func (l *GormLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	l.logger.InfoContext(ctx, msg, slog.Any("data", data))
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	l.logger.WarnContext(ctx, msg, slog.Any("data", data))
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	l.logger.ErrorContext(ctx, msg, slog.Any("data", data))
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	sql, rows := fc()
+
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Database query error",
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Duration("elapsed", elapsed),
+			slog.String("error", err.Error()))
+	} else {
+		l.logger.DebugContext(ctx, "Database query",
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Duration("elapsed", elapsed))
+	}
+}
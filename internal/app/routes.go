@@ -0,0 +1,223 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/docs"
+	"awesomeProject1/internal/cache"
+	"awesomeProject1/internal/config"
+	"awesomeProject1/internal/handler"
+	"awesomeProject1/internal/metrics"
+	"awesomeProject1/internal/middleware"
+	"awesomeProject1/internal/quota"
+	"awesomeProject1/internal/recording"
+	"awesomeProject1/internal/ui"
+)
+
+type routeHandlers struct {
+	subscriptions *handler.SubscriptionHandler
+	quota         *handler.QuotaHandler
+	admin         *handler.AdminHandler
+	analytics     *handler.AnalyticsHandler
+	users         *handler.UserHandler
+	anomalies     *handler.AnomalyHandler
+	preferences   *handler.PreferenceHandler
+	reminders     *handler.ReminderHandler
+	templates     *handler.TemplateHandler
+	customFields  *handler.CustomFieldHandler
+	categories    *handler.CategoryHandler
+	deadLetters   *handler.DeadLetterHandler
+	dataQuality   *handler.DataQualityHandler
+	dbStats       *handler.DatabaseStatsHandler
+	dashboard     *handler.DashboardHandler
+	health        *handler.HealthHandler
+	instances     *handler.InstanceHandler
+	slo           *handler.SLOHandler
+	recordings    *handler.RecordingHandler
+	readOnly      *handler.ReadOnlyHandler
+	soap          *handler.SOAPHandler
+	lakeSync      *handler.LakeSyncHandler
+	tenants       *handler.TenantHandler
+	reconcile     *handler.ReconciliationHandler
+	formulas      *handler.FormulaHandler
+	jobs          *handler.JobHandler
+	search        *handler.SearchHandler
+}
+
+// registerRoutes mounts every route under cfg.BasePath. Links are built from the
+// prefixed paths, so responses stay valid for clients reaching us through a gateway.
+// Operational routes go to internal when it is set, keeping them off the public port.
+func registerRoutes(engine *gin.Engine, internal *gin.Engine, h routeHandlers, registry *metrics.Registry, quotaService *quota.Service, responses *cache.Cache[middleware.CachedResponse], priorities *middleware.RoutePriorities, recorder *recording.Recorder, readOnly middleware.ReadOnlyMode, cfg *config.Config, logger *slog.Logger) error {
+	router := engine.Group(cfg.BasePath)
+	ops := router
+	if internal != nil {
+		ops = internal.Group("")
+	}
+
+	// API routes need a caller once API keys are configured; operational, admin and
+	// documentation routes keep their own access rules.
+	authenticated := middleware.RequireAuth(len(cfg.APIKeys) > 0, logger)
+	readCache := middleware.CacheControl(cfg.ReadCacheMaxAge)
+	aggregateCache := middleware.ResponseCache(responses, logger)
+	// Only API routes are recorded; admin calls carry backups and tokens.
+	record := recording.Middleware(recorder, cfg.RecordingMaxBodyBytes, logger)
+	writes := middleware.ReadOnly(readOnly, logger)
+
+	// Unversioned routes negotiate the payload version from the Accept header; /vN routes pin it.
+	for version, path := range map[int]string{0: "/subscriptions", 1: "/v1/subscriptions", 2: "/v2/subscriptions", 3: "/v3/subscriptions"} {
+		api := router.Group(path, authenticated, record, handler.APIVersion(version, cfg.BasePath+path), middleware.RequestQuota(quotaService, logger), readCache)
+		{
+			api.POST("", writes, middleware.CreateQuota(quotaService, logger), h.subscriptions.Create)
+			api.POST("/batch", writes, middleware.BatchCreateQuota(quotaService, logger), h.subscriptions.CreateBatch)
+			api.GET("/:id", h.subscriptions.GetByID)
+			api.PUT("/:id", writes, h.subscriptions.Update)
+			api.DELETE("/:id", writes, h.subscriptions.Delete)
+			api.POST("/:id/cancel", writes, h.subscriptions.Cancel)
+			api.POST("/:id/undo", writes, h.subscriptions.Undo)
+			api.GET("", h.subscriptions.List)
+			api.POST("/aggregate", h.subscriptions.Aggregate)
+			api.GET("/aggregate", aggregateCache, h.subscriptions.AggregateQuery)
+			api.POST("/simulate", h.subscriptions.Simulate)
+			api.POST("/merge", writes, h.subscriptions.Merge)
+			api.POST("/:id/merge/:other_id", writes, h.subscriptions.MergePair)
+			api.POST("/:id/split", writes, h.subscriptions.Split)
+			api.GET("/stats", h.subscriptions.Stats)
+			api.GET("/search", h.search.Search)
+			api.POST("/:id/reminders", writes, h.reminders.Create)
+			api.GET("/:id/reminders", h.reminders.List)
+			api.DELETE("/:id/reminders/:reminder_id", writes, h.reminders.Delete)
+		}
+	}
+
+	// The SOAP adapter guards its writes itself, as every operation shares one route.
+	if cfg.SOAPEnabled {
+		router.POST("/soap", authenticated, record, middleware.RequestQuota(quotaService, logger), h.soap.Serve)
+	}
+
+	opsIPFilter, err := middleware.IPFilter(cfg.AdminAllowedCIDRs, cfg.AdminDeniedCIDRs, cfg.AdminTrustForwardedFor, logger)
+	if err != nil {
+		return fmt.Errorf("invalid admin IP filter configuration: %w", err)
+	}
+
+	router.GET("/quota", authenticated, h.quota.Current)
+
+	openAPI, err := handler.OpenAPI(docs.Swagger, cfg.BasePath)
+	if err != nil {
+		return err
+	}
+	router.GET("/swagger.json", openAPI)
+	router.GET("/version", handler.Version)
+
+	if err := ui.Register(engine, cfg.BasePath); err != nil {
+		return fmt.Errorf("load web UI: %w", err)
+	}
+
+	analytics := router.Group("/analytics", authenticated, record, middleware.RequestQuota(quotaService, logger), readCache)
+	{
+		analytics.GET("/top-services", h.analytics.TopServices)
+		analytics.GET("/compare", h.analytics.Compare)
+	}
+
+	router.GET("/categories", authenticated, record, middleware.RequestQuota(quotaService, logger), readCache, h.categories.List)
+	router.GET("/services/suggest", authenticated, record, middleware.RequestQuota(quotaService, logger), readCache, h.search.Suggest)
+
+	// Callers poll the jobs they submitted here; the status changes, so it is never
+	// served from the read cache.
+	router.GET("/jobs/:id", authenticated, record, middleware.RequestQuota(quotaService, logger), h.jobs.Get)
+	router.GET("/jobs/:id/result", authenticated, record, middleware.RequestQuota(quotaService, logger), h.jobs.Result)
+
+	// Signed job download URLs carry their own authorization, so they can be handed out.
+	router.GET("/downloads/jobs/:id", h.jobs.Download)
+	priorities.Set(router, middleware.PriorityExport, "/jobs/:id/result", "/downloads/jobs/:id")
+
+	router.GET("/aggregations/:name/run", authenticated, record, middleware.RequestQuota(quotaService, logger), readCache, h.formulas.Run)
+
+	users := router.Group("/users", authenticated, record, middleware.RequestQuota(quotaService, logger), readCache)
+	{
+		users.GET("/:id/timeline", h.users.Timeline)
+		users.GET("/:id/ltv", h.users.LTV)
+		users.GET("/:id/anomalies", h.anomalies.List)
+		users.GET("/:id/preferences", h.preferences.Get)
+		users.PUT("/:id/preferences", writes, h.preferences.Save)
+		users.DELETE("/:id/preferences", writes, h.preferences.Delete)
+	}
+
+	ops.GET("/healthz", h.health.Live)
+	ops.GET("/readyz", h.health.Ready)
+	ops.GET("/metrics", opsIPFilter, registry.Handler())
+
+	admin := ops.Group("/admin", opsIPFilter, middleware.AdminAuth(cfg.AdminToken, logger))
+	{
+		admin.GET("/dashboard", h.dashboard.Get)
+		admin.GET("/instances", h.instances.List)
+		admin.GET("/read-only", h.readOnly.Status)
+		admin.PUT("/read-only", h.readOnly.Switch)
+		admin.GET("/slo", h.slo.Status)
+		admin.GET("/slo/rules", h.slo.Rules)
+		admin.GET("/backup", h.admin.Backup)
+		admin.POST("/restore", h.admin.Restore)
+		admin.GET("/export/anonymized", h.admin.ExportAnonymized)
+		admin.GET("/audit", h.admin.Audit)
+		admin.GET("/usage", h.admin.Usage)
+		admin.GET("/duplicates", h.admin.Duplicates)
+		admin.GET("/data-quality", h.dataQuality.Report)
+		admin.GET("/db-stats", h.dbStats.Get)
+		admin.GET("/mail", h.admin.Mail)
+		admin.POST("/events/replay", h.admin.ReplayEvents)
+		admin.GET("/dead-letters", h.deadLetters.List)
+		admin.GET("/dead-letters/:id", h.deadLetters.Get)
+		admin.POST("/dead-letters/:id/retry", h.deadLetters.Retry)
+		admin.POST("/dead-letters/retry", h.deadLetters.RetryAll)
+		admin.DELETE("/dead-letters/:id", h.deadLetters.Delete)
+		admin.DELETE("/dead-letters", h.deadLetters.Purge)
+		admin.GET("/templates", h.templates.List)
+		admin.GET("/templates/:name", h.templates.Get)
+		admin.PUT("/templates/:name", h.templates.Save)
+		admin.DELETE("/templates/:name", h.templates.Delete)
+		admin.POST("/templates/:name/preview", h.templates.Preview)
+		admin.GET("/custom-fields", h.customFields.List)
+		admin.PUT("/custom-fields/:name", h.customFields.Save)
+		admin.DELETE("/custom-fields/:name", h.customFields.Delete)
+		admin.GET("/aggregations", h.formulas.List)
+		admin.PUT("/aggregations/:name", h.formulas.Save)
+		admin.DELETE("/aggregations/:name", h.formulas.Delete)
+		admin.GET("/categories", h.categories.List)
+		admin.POST("/categories", h.categories.Create)
+		admin.GET("/categories/:id", h.categories.Get)
+		admin.PUT("/categories/:id", h.categories.Update)
+		admin.DELETE("/categories/:id", h.categories.Delete)
+		admin.GET("/catalog", h.categories.ListCatalog)
+		admin.PUT("/catalog/:service_name", h.categories.SaveCatalogEntry)
+		admin.DELETE("/catalog/:service_name", h.categories.DeleteCatalogEntry)
+		admin.GET("/service-aliases", h.categories.ListServiceAliases)
+		admin.PUT("/service-aliases/:alias", h.categories.SaveServiceAlias)
+		admin.DELETE("/service-aliases/:alias", h.categories.DeleteServiceAlias)
+		admin.GET("/recording", h.recordings.CurrentCapture)
+		admin.POST("/recording", h.recordings.StartCapture)
+		admin.DELETE("/recording", h.recordings.StopCapture)
+		admin.GET("/recordings", h.recordings.List)
+		admin.GET("/recordings/:id", h.recordings.Get)
+		admin.DELETE("/recordings", h.recordings.Purge)
+		admin.GET("/lake-sync/runs", h.lakeSync.Runs)
+		admin.GET("/tenants", h.tenants.List)
+		admin.POST("/tenants", h.tenants.Create)
+		admin.POST("/tenants/:name/suspend", h.tenants.Suspend)
+		admin.POST("/tenants/:name/resume", h.tenants.Resume)
+		admin.DELETE("/tenants/:name", h.tenants.Delete)
+		admin.POST("/reconcile", h.reconcile.Reconcile)
+		admin.GET("/jobs/:id", h.jobs.Get)
+		admin.GET("/jobs/:id/result", h.jobs.Result)
+	}
+	// Bulk transfers and full scans are shed first when the public port is overloaded.
+	priorities.Set(admin, middleware.PriorityExport, "/backup", "/restore", "/export/anonymized", "/events/replay", "/data-quality", "/jobs/:id/result")
+
+	debug := ops.Group("/debug", opsIPFilter, middleware.AdminAuth(cfg.AdminToken, logger))
+	{
+		debug.GET("/pprof/*profile", handler.Pprof)
+	}
+
+	return nil
+}
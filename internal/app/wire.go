@@ -0,0 +1,207 @@
+//go:build wireinject
+
+package app
+
+import (
+	"log/slog"
+
+	"github.com/google/wire"
+
+	"awesomeProject1/internal/audit"
+	"awesomeProject1/internal/backup"
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/config"
+	"awesomeProject1/internal/dashboard"
+	"awesomeProject1/internal/events"
+	"awesomeProject1/internal/handler"
+	"awesomeProject1/internal/leader"
+	"awesomeProject1/internal/mail"
+	"awesomeProject1/internal/metering"
+	"awesomeProject1/internal/quota"
+	"awesomeProject1/internal/readonly"
+	"awesomeProject1/internal/recording"
+	"awesomeProject1/internal/region"
+	"awesomeProject1/internal/repository"
+	"awesomeProject1/internal/service"
+	"awesomeProject1/internal/slo"
+	"awesomeProject1/internal/templates"
+)
+
+// databaseSet routes the database through the sandbox, tenant and replica pools.
+var databaseSet = wire.NewSet(
+	provideDSN,
+	provideDatabase,
+	provideSandboxDatabase,
+	provideTenantDatabase,
+	wire.FieldsOf(new(tenantDatabase), "pools"),
+	provideReadOnlyDatabase,
+	wire.FieldsOf(new(readOnlyDatabase), "db", "mode"),
+	provideRegion,
+)
+
+var repositorySet = wire.NewSet(
+	provideSubscriptionRepository,
+	repository.NewAnomalyRepository,
+	repository.NewAsyncJobRepository,
+	repository.NewAuditRepository,
+	repository.NewBusinessRepository,
+	repository.NewCachingCategoryRepository,
+	repository.NewCatalogRepository,
+	repository.NewCategoryRepository,
+	repository.NewCustomFieldRepository,
+	repository.NewDeadLetterRepository,
+	repository.NewEventStoreRepository,
+	repository.NewFormulaRepository,
+	repository.NewHealthRepository,
+	repository.NewInstanceRepository,
+	repository.NewLakeSyncRepository,
+	repository.NewMailRepository,
+	repository.NewPreferenceRepository,
+	repository.NewQuotaRepository,
+	repository.NewRecordingRepository,
+	repository.NewReminderRepository,
+	repository.NewSandboxRepository,
+	repository.NewSearchRepository,
+	repository.NewTemplateRepository,
+	repository.NewTenantRepository,
+	repository.NewUsageRepository,
+)
+
+// serviceSet builds the services on the repositories of repositorySet and the database
+// of databaseSet.
+var serviceSet = wire.NewSet(
+	databaseSet,
+	repositorySet,
+	provideRounding,
+	provideFaults,
+	provideNotFoundCache,
+	provideBackup,
+	provideSearchIndex,
+	provideBackfill,
+	provideSearchService,
+	provideRecorder,
+	provideAlerter,
+	provideDeliveries,
+	provideDispatcher,
+	provideAuditRecorder,
+	provideResponseCache,
+	provideTemplates,
+	provideMailSender,
+	provideMailer,
+	provideNotifier,
+	provideAnalyticsService,
+	provideTimelineService,
+	provideLTVService,
+	provideExchangeRates,
+	providePreferenceService,
+	provideAnomalyService,
+	provideReminderService,
+	provideCustomFieldService,
+	provideCategoryService,
+	provideRepairService,
+	provideSubscriptionService,
+	provideMeter,
+	provideTenantService,
+	provideQuotaService,
+	provideLocker,
+	provideElector,
+	provideBusinessMetrics,
+	provideInstanceService,
+	provideAsyncJobService,
+	provideSandboxResetter,
+	provideMaintenanceService,
+	provideLakeSyncer,
+	provideSpendAlerts,
+	provideSLOTracker,
+	provideReconciliationService,
+	provideEventReplayer,
+	provideDeadLetters,
+	provideDataQualityService,
+	provideDashboard,
+	provideFormulaService,
+	wire.Struct(new(scheduledJobs), "*"),
+	provideScheduler,
+	provideRegistry,
+)
+
+// handlerSet binds the interfaces the handlers take to the services of serviceSet.
+var handlerSet = wire.NewSet(
+	serviceSet,
+	providePageSizes,
+	handler.NewSubscriptionHandler,
+	wire.Bind(new(handler.SubscriptionService), new(*service.LoggingSubscriptionService)),
+	wire.Bind(new(handler.CurrencyPreferences), new(*service.PreferenceService)),
+	handler.NewQuotaHandler,
+	wire.Bind(new(handler.QuotaService), new(*quota.Service)),
+	handler.NewAdminHandler,
+	wire.Bind(new(handler.BackupService), new(*backup.Service)),
+	wire.Bind(new(handler.AuditLog), new(*audit.Recorder)),
+	wire.Bind(new(handler.UsageReporter), new(*metering.Meter)),
+	wire.Bind(new(handler.DuplicateFinder), new(*service.AnalyticsService)),
+	wire.Bind(new(handler.MailLog), new(*mail.Mailer)),
+	wire.Bind(new(handler.EventReplayer), new(*events.Replayer)),
+	wire.Bind(new(handler.AsyncJobRunner), new(*service.AsyncJobService)),
+	handler.NewAnalyticsHandler,
+	wire.Bind(new(handler.AnalyticsService), new(*service.AnalyticsService)),
+	handler.NewUserHandler,
+	wire.Bind(new(handler.TimelineService), new(*service.TimelineService)),
+	wire.Bind(new(handler.LTVService), new(*service.LTVService)),
+	handler.NewAnomalyHandler,
+	wire.Bind(new(handler.AnomalyService), new(*service.AnomalyService)),
+	handler.NewPreferenceHandler,
+	wire.Bind(new(handler.PreferenceService), new(*service.PreferenceService)),
+	handler.NewReminderHandler,
+	wire.Bind(new(handler.ReminderService), new(*service.ReminderService)),
+	handler.NewTemplateHandler,
+	wire.Bind(new(handler.TemplateService), new(*templates.Engine)),
+	handler.NewCustomFieldHandler,
+	wire.Bind(new(handler.CustomFieldService), new(*service.CustomFieldService)),
+	handler.NewCategoryHandler,
+	wire.Bind(new(handler.CategoryService), new(*service.CategoryService)),
+	handler.NewDeadLetterHandler,
+	wire.Bind(new(handler.DeadLetterService), new(*events.DeadLetters)),
+	handler.NewDataQualityHandler,
+	wire.Bind(new(handler.DataQualityService), new(*service.DataQualityService)),
+	handler.NewDatabaseStatsHandler,
+	wire.Bind(new(handler.DatabaseStatsReader), new(*repository.HealthRepository)),
+	handler.NewDashboardHandler,
+	wire.Bind(new(handler.Dashboard), new(*dashboard.Service)),
+	handler.NewHealthHandler,
+	wire.Bind(new(handler.Pinger), new(*repository.HealthRepository)),
+	wire.Bind(new(handler.Leadership), new(*leader.Elector)),
+	wire.Bind(new(handler.RegionStatus), new(*region.Region)),
+	handler.NewInstanceHandler,
+	wire.Bind(new(handler.InstanceService), new(*service.InstanceService)),
+	handler.NewSLOHandler,
+	wire.Bind(new(handler.SLOTracker), new(*slo.Tracker)),
+	handler.NewRecordingHandler,
+	wire.Bind(new(handler.RequestRecorder), new(*recording.Recorder)),
+	handler.NewReadOnlyHandler,
+	wire.Bind(new(handler.ReadOnlySwitch), new(*readonly.Mode)),
+	handler.NewSOAPHandler,
+	wire.Bind(new(handler.SOAPReadOnly), new(*readonly.Mode)),
+	wire.Bind(new(handler.SOAPQuota), new(*quota.Service)),
+	handler.NewLakeSyncHandler,
+	wire.Bind(new(handler.LakeSyncRuns), new(*repository.LakeSyncRepository)),
+	handler.NewTenantHandler,
+	wire.Bind(new(handler.TenantService), new(*service.TenantService)),
+	handler.NewReconciliationHandler,
+	wire.Bind(new(handler.ReconciliationService), new(*service.ReconciliationService)),
+	provideJobHandler,
+	handler.NewSearchHandler,
+	wire.Bind(new(handler.SearchService), new(*service.SearchService)),
+	handler.NewFormulaHandler,
+	wire.Bind(new(handler.FormulaService), new(*service.FormulaService)),
+	wire.Struct(new(routeHandlers), "*"),
+)
+
+// initialize builds the application with the dependencies opts replaces.
+func initialize(cfg *config.Config, logger *slog.Logger, clock clock.Clock, opts options) (*App, error) {
+	wire.Build(
+		handlerSet,
+		provideAuthGuard,
+		provideRouters,
+		wire.Struct(new(App), "*"),
+	)
+	return nil, nil
+}
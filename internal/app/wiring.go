@@ -0,0 +1,1046 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/shopspring/decimal"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/audit"
+	"awesomeProject1/internal/backup"
+	"awesomeProject1/internal/bruteforce"
+	"awesomeProject1/internal/buildinfo"
+	"awesomeProject1/internal/cache"
+	"awesomeProject1/internal/cdc"
+	"awesomeProject1/internal/chaos"
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/config"
+	"awesomeProject1/internal/dashboard"
+	"awesomeProject1/internal/events"
+	"awesomeProject1/internal/handler"
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/lake"
+	"awesomeProject1/internal/leader"
+	"awesomeProject1/internal/lock"
+	"awesomeProject1/internal/mail"
+	"awesomeProject1/internal/metering"
+	"awesomeProject1/internal/metrics"
+	"awesomeProject1/internal/middleware"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/notify"
+	"awesomeProject1/internal/quota"
+	"awesomeProject1/internal/readonly"
+	"awesomeProject1/internal/recording"
+	"awesomeProject1/internal/region"
+	"awesomeProject1/internal/repository"
+	"awesomeProject1/internal/rounding"
+	"awesomeProject1/internal/sandbox"
+	"awesomeProject1/internal/scheduler"
+	"awesomeProject1/internal/search"
+	"awesomeProject1/internal/secretbox"
+	"awesomeProject1/internal/service"
+	"awesomeProject1/internal/sigv4"
+	"awesomeProject1/internal/slo"
+	"awesomeProject1/internal/templates"
+	"awesomeProject1/internal/tenancy"
+	"awesomeProject1/internal/workerpool"
+)
+
+// The providers below build one dependency each from the dependencies they take as
+// arguments and return configuration errors instead of exiting. initialize, in
+// wire_gen.go, is generated from them by google/wire with the sets in wire.go; run
+// go generate ./internal/app after adding a provider or changing its arguments.
+// Decorators and alternative backends are chosen inside the provider of the
+// dependency they replace, so nothing else has to change when one is added; options
+// replace the dependencies they set.
+//
+// Wire tells dependencies apart by type, so each stage of routing the database has a
+// type of its own, and constructors taking interfaces of their own package are
+// wrapped in providers taking the concrete types they are given.
+
+// primaryDatabase is the region's own database, before any routing.
+type primaryDatabase struct {
+	db *gorm.DB
+}
+
+// sandboxDatabase routes the queries of sandbox clients.
+type sandboxDatabase struct {
+	db *gorm.DB
+}
+
+// tenantDatabase routes the queries of isolated tenants through pools, nil unless
+// TENANT_STORAGE is schema.
+type tenantDatabase struct {
+	db    *gorm.DB
+	pools *tenancy.ConnPool
+}
+
+// readOnlyDatabase routes reads to the replica while mode is on. Its db is the one
+// everything else queries.
+type readOnlyDatabase struct {
+	db   *gorm.DB
+	mode *readonly.Mode
+}
+
+// routers are the engines serving the public port and, when INTERNAL_PORT is set, the
+// internal one.
+type routers struct {
+	public   *gin.Engine
+	internal *gin.Engine
+}
+
+// scheduledJobs are the dependencies with scheduled jobs. Optional ones are nil when
+// disabled.
+type scheduledJobs struct {
+	subscriptions *service.LoggingSubscriptionService
+	tenants       *service.TenantService
+	meter         *metering.Meter
+	business      *service.BusinessMetricsService
+	categories    *repository.CachingCategoryRepository
+	instances     *service.InstanceService
+	region        *region.Region
+	reminders     *service.ReminderService
+	anomalies     *service.AnomalyService
+	readOnly      *readonly.Mode
+	mailer        *mail.Mailer
+	mailSender    mail.Sender
+	asyncJobs     *service.AsyncJobService
+	maintenance   *service.MaintenanceService
+	lakeSync      *lake.Syncer
+	spendAlerts   *service.SpendAlertService
+	sandbox       *sandbox.Resetter
+}
+
+// provideScheduler builds the job scheduler with the SCHEDULER_* policy and registers
+// the jobs. Usage is buffered per replica, so every replica flushes its own, and every
+// replica refreshes the business figures its metrics expose and its copy of the
+// categories; the other jobs work on shared rows and run on the leader only.
+func provideScheduler(locker *lock.Locker, elector *leader.Elector, jobs scheduledJobs, clock clock.Clock, cfg *config.Config, logger *slog.Logger) (*scheduler.Scheduler, error) {
+	switch cfg.SchedulerCatchUp {
+	case scheduler.CatchUpSkip, scheduler.CatchUpOnce:
+	default:
+		return nil, fmt.Errorf("invalid SCHEDULER_CATCH_UP %q: expected skip or once", cfg.SchedulerCatchUp)
+	}
+	if cfg.SchedulerJitterPercent < 0 || cfg.SchedulerJitterPercent > 50 {
+		return nil, fmt.Errorf("invalid SCHEDULER_JITTER_PERCENT %d: expected 0 to 50", cfg.SchedulerJitterPercent)
+	}
+	s := scheduler.NewScheduler(clock, locker, elector, scheduler.Policy{
+		JitterPercent: cfg.SchedulerJitterPercent,
+		CatchUp:       cfg.SchedulerCatchUp,
+		MaxRuntime:    cfg.SchedulerMaxRuntime,
+		MaxRuntimes:   cfg.SchedulerMaxRuntimes,
+	}, logger)
+
+	s.RegisterExclusive("purge_trash", cfg.TrashPurgeInterval, jobs.subscriptions.PurgeTrash)
+	s.RegisterExclusive("activate_scheduled", cfg.ActivationInterval, jobs.tenants.EverySchema(jobs.subscriptions.ActivateScheduled))
+	s.Register("flush_usage", cfg.UsageFlushInterval, jobs.meter.Flush)
+	s.Register("refresh_business_metrics", cfg.BusinessMetricsInterval, jobs.business.Refresh)
+	s.Register("refresh_categories", cfg.CategoryRefreshInterval, jobs.categories.Refresh)
+	if !jobs.region.Passive() {
+		s.Register("heartbeat", cfg.InstanceHeartbeatInterval, jobs.instances.Heartbeat)
+	}
+	s.RegisterExclusive("send_reminders", cfg.ReminderInterval, jobs.reminders.SendDue)
+	s.RegisterExclusive("detect_anomalies", cfg.AnomalyScanInterval, jobs.anomalies.Detect)
+	if cfg.DBReplicaHost != "" {
+		s.Register("probe_primary", cfg.ReadOnlyProbeInterval, jobs.readOnly.Probe)
+	}
+	if jobs.maintenance != nil {
+		s.RegisterExclusive("table_maintenance", cfg.MaintenanceInterval, jobs.maintenance.Check)
+	}
+	if jobs.mailSender != nil {
+		s.RegisterExclusive("send_mail", cfg.MailRetryInterval, jobs.mailer.Deliver)
+	}
+	if jobs.lakeSync != nil {
+		s.RegisterExclusive("lake_sync", cfg.LakeSyncInterval, jobs.lakeSync.Sync)
+	}
+	if jobs.spendAlerts != nil {
+		s.RegisterExclusive("spend_alerts", cfg.SpendAlertInterval, jobs.spendAlerts.Evaluate)
+	}
+	s.RegisterExclusive("sweep_async_jobs", cfg.AsyncJobHeartbeat, jobs.asyncJobs.Sweep)
+	if jobs.sandbox != nil {
+		s.RegisterExclusive("reset_sandbox", sandbox.CheckInterval, jobs.sandbox.ResetIfDue)
+	}
+	return s, nil
+}
+
+// provideRegistry exposes the metrics of this process and the business figures. Only
+// the leader pushes the latter to REMOTE_WRITE_URL, so that they arrive once per
+// deployment.
+func provideRegistry(meter *metering.Meter, readOnly *readonly.Mode, business *service.BusinessMetricsService, deliveries *workerpool.Pool, jobs *scheduler.Scheduler, ltv *service.LTVService, responses *cache.Cache[middleware.CachedResponse], missing *cache.Cache[struct{}], objectives *slo.Tracker, cfg *config.Config, logger *slog.Logger) *metrics.Registry {
+	registry := metrics.NewRegistry()
+	registry.GaugeFunc("usage_events_buffered", "API usage events not yet flushed to the database.", func() float64 {
+		return float64(meter.Buffered())
+	})
+	registry.GaugeFunc("read_only_mode", "1 while writes are rejected and reads are served from the replica.", func() float64 {
+		if readOnly.Active() {
+			return 1
+		}
+		return 0
+	})
+	registry.GaugeVecFunc("subscriptions_active", "Subscriptions that have started and not ended, per tenant.", "tenant", business.ActiveSubscriptions)
+	registry.GaugeVecFunc("subscriptions_monthly_recurring_revenue", "Monthly cost of the active recurring subscriptions, per tenant.", "tenant", business.MonthlyRecurringRevenue)
+	if cfg.RemoteWriteURL != "" {
+		jobs.RegisterExclusive("remote_write", cfg.RemoteWriteInterval, provideRemoteWriter(registry, cfg, logger).Push)
+	}
+	registerDeliveryMetrics(registry, deliveries)
+	registerSchedulerMetrics(registry, jobs)
+	caches := map[string]func() cache.Stats{"ltv": ltv.CacheStats}
+	if responses != nil {
+		caches["aggregate"] = responses.Stats
+	}
+	if missing != nil {
+		caches["not_found"] = missing.Stats
+	}
+	registerCacheMetrics(registry, caches)
+	registry.Observe(objectives.Observe)
+	info := buildinfo.Current()
+	registry.ConstGauge("build_info", "Build and instance of this process, always 1.", map[string]string{
+		"version":     info.Version,
+		"commit":      info.Commit,
+		"instance_id": info.InstanceID.String(),
+	}, 1)
+	return registry
+}
+
+// provideRouters installs the middleware and routes on the engine of WithRouter, or a
+// new one, and on the internal engine when INTERNAL_PORT is set.
+func provideRouters(opts options, h routeHandlers, registry *metrics.Registry, meter *metering.Meter, authGuard *bruteforce.Guard, tenants *service.TenantService, quotas *quota.Service, responses *cache.Cache[middleware.CachedResponse], recorder *recording.Recorder, readOnly *readonly.Mode, faults *chaos.Faults, cfg *config.Config, logger *slog.Logger) (routers, error) {
+	r := routers{public: opts.router}
+	if r.public == nil {
+		r.public = gin.Default()
+	}
+	if err := installMiddleware(r.public, cfg, meter, registry, authGuard, tenants, logger); err != nil {
+		return routers{}, err
+	}
+	// Only public traffic is shed; probes and admin calls on the internal port still get through.
+	priorities := middleware.NewRoutePriorities(cfg.PriorityClients)
+	r.public.Use(middleware.ConcurrencyLimit(cfg.MaxInFlightRequests, cfg.LoadShedWait, cfg.LoadShedRetryAfter, priorities.Of, logger))
+	if faults != nil && len(faults.Routes) > 0 {
+		r.public.Use(chaos.Middleware(faults.Routes, logger))
+	}
+	if cfg.InternalPort != "" {
+		r.internal = gin.Default()
+		if err := installMiddleware(r.internal, cfg, meter, registry, authGuard, tenants, logger); err != nil {
+			return routers{}, err
+		}
+	}
+	if err := registerRoutes(r.public, r.internal, h, registry, quotas, responses, priorities, recorder, readOnly, cfg, logger); err != nil {
+		return routers{}, err
+	}
+	return r, nil
+}
+
+// provideMaintenanceService builds the table maintenance job for MAINTENANCE_ACTION;
+// without one there is nothing to maintain and it returns nil.
+func provideMaintenanceService(health *repository.HealthRepository, alerter *notify.Alerter, clock clock.Clock, cfg *config.Config, logger *slog.Logger) (*service.MaintenanceService, error) {
+	switch cfg.MaintenanceAction {
+	case "":
+		return nil, nil
+	case service.MaintenanceAlert, service.MaintenanceAnalyze:
+	default:
+		return nil, fmt.Errorf("invalid MAINTENANCE_ACTION %q: expected alert or analyze", cfg.MaintenanceAction)
+	}
+	return service.NewMaintenanceService(health, alerter, service.MaintenanceRules{
+		Action:           cfg.MaintenanceAction,
+		DeadRatioPercent: cfg.MaintenanceDeadRatioPercent,
+		MinDeadTuples:    cfg.MaintenanceMinDeadTuples,
+		WindowStart:      cfg.MaintenanceWindowStart,
+		WindowEnd:        cfg.MaintenanceWindowEnd,
+	}, clock, logger), nil
+}
+
+func provideDSN(cfg *config.Config) string {
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable application_name=%s",
+		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cdc.ApplicationName,
+	)
+}
+
+func provideReplicaDSN(cfg *config.Config) string {
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable application_name=%s",
+		cfg.DBReplicaHost, cfg.DBReplicaPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cdc.ApplicationName,
+	)
+}
+
+// provideDatabase connects to the configured database unless WithDatabase gave one.
+func provideDatabase(opts options, dsn string, logger *slog.Logger) (primaryDatabase, error) {
+	if opts.db != nil {
+		return primaryDatabase{db: opts.db}, nil
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: NewGormLogger(logger),
+	})
+	if err != nil {
+		return primaryDatabase{}, fmt.Errorf("connect to PostgreSQL: %w", err)
+	}
+	logger.Info("Successfully connected to PostgreSQL")
+	return primaryDatabase{db: db}, nil
+}
+
+// sandboxEnabled reports whether any HMAC client or API key is routed to the sandbox.
+func sandboxEnabled(cfg *config.Config) bool {
+	return len(cfg.SandboxClients) > 0 || len(cfg.SandboxKeys) > 0
+}
+
+// provideSandboxDatabase sends the queries of sandbox clients to connections of their
+// own, whose search_path starts with the sandbox schema, when SANDBOX_CLIENTS or
+// API_KEY_SANDBOX is set.
+func provideSandboxDatabase(primary primaryDatabase, dsn string, cfg *config.Config, logger *slog.Logger) (sandboxDatabase, error) {
+	if !sandboxEnabled(cfg) {
+		return sandboxDatabase{db: primary.db}, nil
+	}
+
+	main, err := primary.db.DB()
+	if err != nil {
+		return sandboxDatabase{}, fmt.Errorf("get database handle: %w", err)
+	}
+	sandboxDB, err := gorm.Open(postgres.Open(sandbox.DSN(dsn)), &gorm.Config{
+		Logger: NewGormLogger(logger),
+	})
+	if err != nil {
+		return sandboxDatabase{}, fmt.Errorf("connect to PostgreSQL sandbox: %w", err)
+	}
+	sandboxSQL, err := sandboxDB.DB()
+	if err != nil {
+		return sandboxDatabase{}, fmt.Errorf("get sandbox database handle: %w", err)
+	}
+
+	routed, err := gorm.Open(postgres.New(postgres.Config{Conn: sandbox.NewConnPool(main, sandboxSQL)}), &gorm.Config{
+		Logger: NewGormLogger(logger),
+	})
+	if err != nil {
+		return sandboxDatabase{}, fmt.Errorf("route sandbox queries: %w", err)
+	}
+	logger.Info("Enabled sandbox", slog.Any("clients", cfg.SandboxClients), slog.Any("api_keys", cfg.SandboxKeys))
+	return sandboxDatabase{db: routed}, nil
+}
+
+// provideTenantDatabase sends the queries of isolated tenants to connections of their
+// own, whose search_path starts with the tenant's schema, when TENANT_STORAGE is schema.
+func provideTenantDatabase(sandboxed sandboxDatabase, dsn string, cfg *config.Config, logger *slog.Logger) (tenantDatabase, error) {
+	db := sandboxed.db
+	switch cfg.TenantStorage {
+	case tenancy.StorageShared:
+		return tenantDatabase{db: db}, nil
+	case tenancy.StorageSchema:
+	default:
+		return tenantDatabase{}, fmt.Errorf("invalid TENANT_STORAGE %q: expected %s or %s", cfg.TenantStorage, tenancy.StorageShared, tenancy.StorageSchema)
+	}
+
+	main, err := db.DB()
+	if err != nil {
+		return tenantDatabase{}, fmt.Errorf("get database handle: %w", err)
+	}
+	next, ok := db.ConnPool.(tenancy.Pool)
+	if !ok {
+		return tenantDatabase{}, fmt.Errorf("database connection pool %T cannot begin transactions", db.ConnPool)
+	}
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return tenantDatabase{}, fmt.Errorf("parse database DSN: %w", err)
+	}
+	pool := tenancy.NewConnPool(next, main, cfg.TenantMaxIdleConns, func(schema string) *sql.DB {
+		schemaConfig := connConfig.Copy()
+		schemaConfig.RuntimeParams["search_path"] = schema + ",public"
+		schemaDB := stdlib.OpenDB(*schemaConfig)
+		schemaDB.SetMaxOpenConns(cfg.TenantPoolSize)
+		return schemaDB
+	})
+
+	routed, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}), &gorm.Config{
+		Logger: NewGormLogger(logger),
+	})
+	if err != nil {
+		return tenantDatabase{}, fmt.Errorf("route tenant queries: %w", err)
+	}
+	logger.Info("Enabled tenant schemas", slog.Any("clients", cfg.TenantClients))
+	return tenantDatabase{db: routed, pools: pool}, nil
+}
+
+// provideReadOnlyDatabase routes reads to the replica at DB_REPLICA_HOST while
+// read-only mode is on. Without a replica, read-only mode can only be switched by hand
+// and reads stay on the primary.
+func provideReadOnlyDatabase(tenants tenantDatabase, clock clock.Clock, cfg *config.Config, logger *slog.Logger) (readOnlyDatabase, error) {
+	db := tenants.db
+	main, err := db.DB()
+	if err != nil {
+		return readOnlyDatabase{}, fmt.Errorf("get database handle: %w", err)
+	}
+	if cfg.DBReplicaHost == "" {
+		return readOnlyDatabase{db: db, mode: readonly.NewMode(main, false, clock, logger)}, nil
+	}
+
+	primary, ok := db.ConnPool.(readonly.Pool)
+	if !ok {
+		return readOnlyDatabase{}, fmt.Errorf("database connection pool %T cannot begin transactions", db.ConnPool)
+	}
+	replicaDB, err := gorm.Open(postgres.Open(provideReplicaDSN(cfg)), &gorm.Config{
+		Logger: NewGormLogger(logger),
+	})
+	if err != nil {
+		return readOnlyDatabase{}, fmt.Errorf("connect to PostgreSQL replica: %w", err)
+	}
+	replica, err := replicaDB.DB()
+	if err != nil {
+		return readOnlyDatabase{}, fmt.Errorf("get replica database handle: %w", err)
+	}
+
+	mode := readonly.NewMode(main, true, clock, logger)
+	routed, err := gorm.Open(postgres.New(postgres.Config{Conn: readonly.NewConnPool(primary, main, replica, mode)}), &gorm.Config{
+		Logger: NewGormLogger(logger),
+	})
+	if err != nil {
+		return readOnlyDatabase{}, fmt.Errorf("route replica queries: %w", err)
+	}
+	logger.Info("Enabled read-only mode with replica", slog.String("host", cfg.DBReplicaHost))
+	return readOnlyDatabase{db: routed, mode: mode}, nil
+}
+
+// provideRegion fences writes off while the region is passive. Replication status and
+// promotion concern the region's own database, never the read-only replica.
+func provideRegion(primary primaryDatabase, readOnly *readonly.Mode, cfg *config.Config, logger *slog.Logger) (*region.Region, error) {
+	switch cfg.RegionRole {
+	case models.RegionActive:
+	case models.RegionPassive:
+		logger.Warn("Running as the passive region", slog.String("region", cfg.Region))
+	default:
+		return nil, fmt.Errorf("invalid REGION_ROLE %q: expected %s or %s", cfg.RegionRole, models.RegionActive, models.RegionPassive)
+	}
+	r := region.New(cfg.Region, cfg.RegionRole, cfg.ReplicationMaxLag, repository.NewHealthRepository(primary.db), logger)
+	if r.Passive() {
+		readOnly.Fence()
+	}
+	return r, nil
+}
+
+func provideRounding(cfg *config.Config) (rounding.Policy, error) {
+	policy, err := rounding.NewPolicy(cfg.RoundingMode, cfg.RoundingPrecision)
+	if err != nil {
+		return rounding.Policy{}, fmt.Errorf("invalid ROUNDING_MODE or ROUNDING_PRECISION: %w", err)
+	}
+	return policy, nil
+}
+
+// provideExchangeRates parses EXCHANGE_RATES, the number of units of each currency a
+// ruble buys.
+func provideExchangeRates(cfg *config.Config) (map[string]decimal.Decimal, error) {
+	rates := make(map[string]decimal.Decimal, len(cfg.ExchangeRates))
+	for currency, value := range cfg.ExchangeRates {
+		rate, err := decimal.NewFromString(value)
+		if err != nil || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid EXCHANGE_RATES rate for %s: %q", currency, value)
+		}
+		rates[strings.ToUpper(currency)] = rate
+	}
+	return rates, nil
+}
+
+func provideSubscriptionService(repo *repository.LoggingSubscriptionRepository, fields *service.CustomFieldService, categories *repository.CachingCategoryRepository, recorder *audit.Recorder, alerter *notify.Alerter, clock clock.Clock, cfg *config.Config, logger *slog.Logger) *service.LoggingSubscriptionService {
+	return service.NewLoggingSubscriptionService(service.NewSubscriptionService(repo, fields, categories, recorder, alerter, clock, logger, cfg.TrashGracePeriod, service.SubscriptionLimits{
+		PerUser: cfg.UserSubscriptionLimit,
+		Tenants: cfg.UserSubscriptionLimitTenants,
+	}), logger)
+}
+
+func provideDeliveries(cfg *config.Config, logger *slog.Logger) *workerpool.Pool {
+	return workerpool.NewPool(cfg.DeliveryWorkers, cfg.DeliveryQueueSize, logger)
+}
+
+func provideBackup(subscriptions *repository.LoggingSubscriptionRepository, cfg *config.Config, logger *slog.Logger) *backup.Service {
+	return backup.NewService(subscriptions, logger, cfg.AnonymizationSalt)
+}
+
+func provideRecorder(repo *repository.RecordingRepository, clock clock.Clock, logger *slog.Logger) *recording.Recorder {
+	return recording.NewRecorder(repo, clock, logger)
+}
+
+func provideAuditRecorder(repo *repository.AuditRepository, dispatcher *events.Dispatcher, logger *slog.Logger) *audit.Recorder {
+	return audit.NewRecorder(repo, dispatcher, logger)
+}
+
+func provideTemplates(repo *repository.TemplateRepository, logger *slog.Logger) *templates.Engine {
+	return templates.NewEngine(repo, logger)
+}
+
+func provideMailer(repo *repository.MailRepository, engine *templates.Engine, sender mail.Sender, pool *workerpool.Pool, cfg *config.Config, logger *slog.Logger) *mail.Mailer {
+	return mail.NewMailer(repo, engine, sender, pool, cfg.MailFrom, cfg.MailMaxAttempts, logger)
+}
+
+func provideAnalyticsService(subscriptions *repository.LoggingSubscriptionRepository, logger *slog.Logger) *service.AnalyticsService {
+	return service.NewAnalyticsService(subscriptions, logger)
+}
+
+func provideTimelineService(subscriptions *repository.LoggingSubscriptionRepository, recorder *audit.Recorder, clock clock.Clock, logger *slog.Logger) *service.TimelineService {
+	return service.NewTimelineService(subscriptions, recorder, clock, logger)
+}
+
+// provideLTVService drops the cached lifetime value of a user on every event of theirs
+// this replica emits.
+func provideLTVService(subscriptions *repository.LoggingSubscriptionRepository, recorder *audit.Recorder, dispatcher *events.Dispatcher, policy rounding.Policy, clock clock.Clock, logger *slog.Logger) *service.LTVService {
+	ltv := service.NewLTVService(subscriptions, recorder, policy, clock, logger)
+	dispatcher.Subscribe(ltv.Invalidate)
+	return ltv
+}
+
+func providePreferenceService(repo *repository.PreferenceRepository, notifier *notify.Notifier, rates map[string]decimal.Decimal, clock clock.Clock, logger *slog.Logger) *service.PreferenceService {
+	return service.NewPreferenceService(repo, notifier, rates, clock, logger)
+}
+
+// provideAnomalyService detects anomalies with the ANOMALY_* rules and notifies users on
+// ANOMALY_NOTIFY_CHANNEL, which must be a registered channel when set.
+func provideAnomalyService(repo *repository.AnomalyRepository, subscriptions *repository.LoggingSubscriptionRepository, history *repository.AuditRepository, dispatcher *events.Dispatcher, notifier *notify.Notifier, engine *templates.Engine, preferences *service.PreferenceService, clock clock.Clock, cfg *config.Config, logger *slog.Logger) (*service.AnomalyService, error) {
+	if cfg.AnomalyNotifyChannel != "" && !notifier.Has(cfg.AnomalyNotifyChannel) {
+		return nil, fmt.Errorf("invalid ANOMALY_NOTIFY_CHANNEL: %w: %s", notify.ErrUnknownChannel, cfg.AnomalyNotifyChannel)
+	}
+	return service.NewAnomalyService(repo, subscriptions, history, dispatcher, notifier, engine, preferences, service.AnomalyRules{
+		Lookback:         cfg.AnomalyLookback,
+		PriceJumpPercent: cfg.AnomalyPriceJumpPercent,
+		ExpensiveFactor:  cfg.AnomalyExpensiveFactor,
+		Channel:          cfg.AnomalyNotifyChannel,
+	}, clock, logger), nil
+}
+
+func provideReminderService(repo *repository.ReminderRepository, subscriptions *repository.LoggingSubscriptionRepository, notifier *notify.Notifier, engine *templates.Engine, preferences *service.PreferenceService, clock clock.Clock, logger *slog.Logger) *service.ReminderService {
+	return service.NewReminderService(repo, subscriptions, notifier, engine, preferences, clock, logger)
+}
+
+func provideCustomFieldService(repo *repository.CustomFieldRepository, clock clock.Clock, logger *slog.Logger) *service.CustomFieldService {
+	return service.NewCustomFieldService(repo, clock, logger)
+}
+
+func provideCategoryService(categories *repository.CachingCategoryRepository, catalog *repository.CatalogRepository, clock clock.Clock, logger *slog.Logger) *service.CategoryService {
+	return service.NewCategoryService(categories, catalog, clock, logger)
+}
+
+func provideRepairService(subscriptions *repository.LoggingSubscriptionRepository, catalog *repository.CatalogRepository, recorder *audit.Recorder, logger *slog.Logger) *service.RepairService {
+	return service.NewRepairService(subscriptions, catalog, recorder, logger)
+}
+
+func provideMeter(repo *repository.UsageRepository, logger *slog.Logger) *metering.Meter {
+	return metering.NewMeter(repo, logger)
+}
+
+func provideLocker(db *gorm.DB, cfg *config.Config, logger *slog.Logger) (*lock.Locker, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("get database handle: %w", err)
+	}
+	return lock.NewLocker(sqlDB, cfg.LockKeepalive, logger), nil
+}
+
+func provideElector(locker *lock.Locker, clock clock.Clock, logger *slog.Logger) *leader.Elector {
+	return leader.NewElector(locker, clock, logger)
+}
+
+func provideBusinessMetrics(repo *repository.BusinessRepository, clock clock.Clock, cfg *config.Config, logger *slog.Logger) *service.BusinessMetricsService {
+	return service.NewBusinessMetricsService(repo, sandboxEnabled(cfg), clock, logger)
+}
+
+func provideInstanceService(repo *repository.InstanceRepository, elector *leader.Elector, clock clock.Clock, cfg *config.Config, logger *slog.Logger) *service.InstanceService {
+	return service.NewInstanceService(repo, elector, cfg.InstanceHeartbeatInterval, clock, logger)
+}
+
+func provideAsyncJobService(repo *repository.AsyncJobRepository, clock clock.Clock, cfg *config.Config, logger *slog.Logger) *service.AsyncJobService {
+	return service.NewAsyncJobService(repo, clock, cfg.AsyncJobHeartbeat, cfg.AsyncJobRetention, service.AsyncJobFiles{
+		Dir:        cfg.AsyncJobFilesDir,
+		LinkSecret: cfg.AsyncJobLinkSecret,
+		LinkTTL:    cfg.AsyncJobLinkTTL,
+	}, logger)
+}
+
+// provideSandboxResetter returns nil when nothing is routed to the sandbox.
+func provideSandboxResetter(repo *repository.SandboxRepository, clock clock.Clock, cfg *config.Config, logger *slog.Logger) *sandbox.Resetter {
+	if !sandboxEnabled(cfg) {
+		return nil
+	}
+	return sandbox.NewResetter(repo, cfg.SandboxResetAt, clock, logger)
+}
+
+func provideEventReplayer(history *repository.AuditRepository, dispatcher *events.Dispatcher, logger *slog.Logger) *events.Replayer {
+	return events.NewReplayer(history, dispatcher, logger)
+}
+
+func provideDeadLetters(repo *repository.DeadLetterRepository, dispatcher *events.Dispatcher, logger *slog.Logger) *events.DeadLetters {
+	return events.NewDeadLetters(repo, dispatcher, logger)
+}
+
+func provideDataQualityService(subscriptions *repository.LoggingSubscriptionRepository, logger *slog.Logger) *service.DataQualityService {
+	return service.NewDataQualityService(subscriptions, logger)
+}
+
+func provideDashboard(health *repository.HealthRepository, deadLetters *repository.DeadLetterRepository, mails *repository.MailRepository, quotas *repository.QuotaRepository, meter *metering.Meter, jobs *scheduler.Scheduler, clock clock.Clock, logger *slog.Logger) *dashboard.Service {
+	return dashboard.NewService(health, deadLetters, mails, quotas, meter, jobs, clock, logger)
+}
+
+func provideFormulaService(repo *repository.FormulaRepository, subscriptions *service.LoggingSubscriptionService, clock clock.Clock, logger *slog.Logger) *service.FormulaService {
+	return service.NewFormulaService(repo, subscriptions, clock, logger)
+}
+
+func providePageSizes(cfg *config.Config) handler.PageSizes {
+	return handler.PageSizes{Default: cfg.ListPageSize, Max: cfg.ListMaxPageSize}
+}
+
+func provideJobHandler(jobs *service.AsyncJobService, cfg *config.Config, logger *slog.Logger) *handler.JobHandler {
+	return handler.NewJobHandler(jobs, cfg.BasePath, logger)
+}
+
+func provideAlerter(cfg *config.Config, logger *slog.Logger) (*notify.Alerter, error) {
+	alerter := notify.NewAlerter(cfg.AlertCooldown, logger)
+	for category, url := range cfg.AlertSlackWebhooks {
+		if err := alerter.Route(category, notify.NewSlackWebhook(url)); err != nil {
+			return nil, fmt.Errorf("invalid Slack alert configuration: %w", err)
+		}
+	}
+	for category, url := range cfg.AlertTeamsWebhooks {
+		if err := alerter.Route(category, notify.NewTeamsWebhook(url)); err != nil {
+			return nil, fmt.Errorf("invalid Teams alert configuration: %w", err)
+		}
+	}
+	return alerter, nil
+}
+
+// provideDispatcher registers the WEBHOOK_URLS sinks and the tenant-webhooks sink,
+// which delivers the events of each tenant to the webhooks it was onboarded with.
+func provideDispatcher(pool *workerpool.Pool, deadLetters *repository.DeadLetterRepository, tenants *repository.TenantRepository, searchIndex *search.Index, alerter *notify.Alerter, cfg *config.Config, logger *slog.Logger) *events.Dispatcher {
+	dispatcher := events.NewDispatcher(pool, deadLetters, alerter, logger)
+	for name, url := range cfg.WebhookURLs {
+		dispatcher.Register(name, events.NewWebhookSink(url, cfg.WebhookSecret))
+	}
+	dispatcher.Register(events.TenantWebhooksSink, events.NewTenantWebhooks(tenants))
+	if searchIndex != nil {
+		dispatcher.Register(search.SinkName, searchIndex)
+	}
+	if len(cfg.WebhookURLs) > 0 {
+		logger.Info("Enabled event webhooks", slog.Any("sinks", dispatcher.Sinks()))
+	}
+	return dispatcher
+}
+
+// provideSearchIndex mirrors subscriptions into the index at SEARCH_URL; without one
+// searches read the database and it returns nil.
+func provideSearchIndex(cfg *config.Config, logger *slog.Logger) *search.Index {
+	if cfg.SearchURL == "" {
+		return nil
+	}
+	logger.Info("Mirroring subscriptions into the search index",
+		slog.String("index", cfg.SearchIndex))
+	return search.NewIndex(search.Config{
+		URL:      cfg.SearchURL,
+		Index:    cfg.SearchIndex,
+		Username: cfg.SearchUsername,
+		Password: cfg.SearchPassword,
+		Timeout:  cfg.SearchTimeout,
+	}, logger)
+}
+
+// provideBackfill and provideSearchService pass a nil interface rather than a nil
+// *search.Index when the index is disabled.
+func provideBackfill(eventStore *repository.EventStoreRepository, searchRepo *repository.SearchRepository, searchIndex *search.Index, logger *slog.Logger) *service.BackfillService {
+	if searchIndex == nil {
+		return service.NewBackfillService(eventStore, searchRepo, nil, logger)
+	}
+	return service.NewBackfillService(eventStore, searchRepo, searchIndex, logger)
+}
+
+func provideSearchService(searchRepo *repository.SearchRepository, searchIndex *search.Index, logger *slog.Logger) *service.SearchService {
+	if searchIndex == nil {
+		return service.NewSearchService(nil, searchRepo, logger)
+	}
+	return service.NewSearchService(searchIndex, searchRepo, logger)
+}
+
+// provideSLOTracker loads the objectives from SLO_OBJECTIVES_FILE; without one the
+// tracker has nothing to track.
+func provideSLOTracker(clock clock.Clock, cfg *config.Config, logger *slog.Logger) (*slo.Tracker, error) {
+	if cfg.SLOObjectivesFile == "" {
+		return slo.NewTracker(nil, clock), nil
+	}
+
+	f, err := os.Open(cfg.SLOObjectivesFile)
+	if err != nil {
+		return nil, fmt.Errorf("open SLO objectives: %w", err)
+	}
+	defer f.Close()
+
+	objectives, err := slo.LoadObjectives(f)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Tracking service level objectives",
+		slog.String("file", cfg.SLOObjectivesFile),
+		slog.Int("objectives", len(objectives)))
+	return slo.NewTracker(objectives, clock), nil
+}
+
+// provideSpendAlerts loads the rules from SPEND_ALERTS_FILE; without one there is
+// nothing to evaluate and it returns nil.
+func provideSpendAlerts(analytics *service.AnalyticsService, tenants *service.TenantService, alerter *notify.Alerter, clock clock.Clock, cfg *config.Config, logger *slog.Logger) (*service.SpendAlertService, error) {
+	if cfg.SpendAlertsFile == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(cfg.SpendAlertsFile)
+	if err != nil {
+		return nil, fmt.Errorf("open spend alert rules: %w", err)
+	}
+	defer f.Close()
+
+	rules, err := service.LoadSpendAlertRules(f)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Evaluating spend alert rules",
+		slog.String("file", cfg.SpendAlertsFile),
+		slog.Int("rules", len(rules)),
+		slog.Duration("interval", cfg.SpendAlertInterval))
+	return service.NewSpendAlertService(rules, analytics, tenants, alerter, clock, logger), nil
+}
+
+// provideLakeSyncer loads the destinations from LAKE_SYNC_FILE; without one there is
+// nothing to sync and it returns nil.
+func provideLakeSyncer(repo *repository.LakeSyncRepository, clock clock.Clock, cfg *config.Config, logger *slog.Logger) (*lake.Syncer, error) {
+	if cfg.LakeSyncFile == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(cfg.LakeSyncFile)
+	if err != nil {
+		return nil, fmt.Errorf("open lake sync destinations: %w", err)
+	}
+	defer f.Close()
+
+	destinations, err := lake.LoadDestinations(f)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Syncing subscriptions to the data lake",
+		slog.String("file", cfg.LakeSyncFile),
+		slog.Int("destinations", len(destinations)),
+		slog.Duration("interval", cfg.LakeSyncInterval))
+	return lake.NewSyncer(repo, destinations, sigv4.Credentials{
+		AccessKeyID:     cfg.AWSAccessKeyID,
+		SecretAccessKey: cfg.AWSSecretAccessKey,
+		SessionToken:    cfg.AWSSessionToken,
+	}, clock, logger), nil
+}
+
+// provideRemoteWriter pushes the business metrics to REMOTE_WRITE_URL.
+func provideRemoteWriter(registry *metrics.Registry, cfg *config.Config, logger *slog.Logger) *metrics.RemoteWriter {
+	logger.Info("Pushing business metrics with remote write",
+		slog.String("url", cfg.RemoteWriteURL),
+		slog.Duration("interval", cfg.RemoteWriteInterval))
+	return metrics.NewRemoteWriter(metrics.RemoteWriteConfig{
+		URL:         cfg.RemoteWriteURL,
+		BearerToken: cfg.RemoteWriteBearerToken,
+		Username:    cfg.RemoteWriteUsername,
+		Password:    cfg.RemoteWritePassword,
+		Labels:      cfg.RemoteWriteLabels,
+	}, registry, "subscriptions_active", "subscriptions_monthly_recurring_revenue")
+}
+
+// provideNotFoundCache returns nil when negative caching of subscription IDs is disabled.
+func provideNotFoundCache(clock clock.Clock, cfg *config.Config, logger *slog.Logger) *cache.Cache[struct{}] {
+	if cfg.NotFoundCacheTTL <= 0 {
+		return nil
+	}
+
+	logger.Info("Enabled not-found cache",
+		slog.Duration("ttl", cfg.NotFoundCacheTTL),
+		slog.Int("max_entries", cfg.NotFoundCacheMaxEntries))
+	return cache.New[struct{}](cfg.NotFoundCacheTTL, cfg.NotFoundCacheMaxEntries, clock)
+}
+
+// provideFaults returns nil unless CHAOS_FAULTS_FILE is set, which fails in builds
+// without fault injection.
+func provideFaults(cfg *config.Config, logger *slog.Logger) (*chaos.Faults, error) {
+	if cfg.ChaosFaultsFile == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(cfg.ChaosFaultsFile)
+	if err != nil {
+		return nil, fmt.Errorf("open fault injection file: %w", err)
+	}
+	defer f.Close()
+
+	faults, err := chaos.LoadFaults(f)
+	if err != nil {
+		return nil, err
+	}
+	logger.Warn("Fault injection enabled, do not use in production",
+		slog.String("file", cfg.ChaosFaultsFile),
+		slog.Int("route_faults", len(faults.Routes)),
+		slog.Int("repository_faults", len(faults.Repository)))
+	return faults, nil
+}
+
+// provideSubscriptionRepository logs the calls to the repository of WithRepository, or
+// the database's, decorated with injected faults, closest to the database, and the
+// not-found cache when they are enabled.
+func provideSubscriptionRepository(opts options, db *gorm.DB, policy rounding.Policy, missing *cache.Cache[struct{}], faults *chaos.Faults, cfg *config.Config, logger *slog.Logger) *repository.LoggingSubscriptionRepository {
+	store := opts.subscriptions
+	if store == nil {
+		store = repository.NewSubscriptionRepository(db, cfg.EventSourcing, policy)
+	}
+	if faults != nil && len(faults.Repository) > 0 {
+		store = repository.NewFaultInjectingSubscriptionRepository(store, chaos.NewInjector(faults.Repository, logger))
+	}
+	if missing != nil {
+		store = repository.NewNotFoundCachingSubscriptionRepository(store, missing)
+	}
+	return repository.NewLoggingSubscriptionRepository(store, logger)
+}
+
+// provideResponseCache returns nil when the aggregate cache is disabled. The cache is
+// purged on every subscription event this replica emits; changes made through other
+// replicas show up once the entries expire.
+func provideResponseCache(dispatcher *events.Dispatcher, clock clock.Clock, cfg *config.Config, logger *slog.Logger) *cache.Cache[middleware.CachedResponse] {
+	if cfg.AggregateCacheTTL <= 0 {
+		return nil
+	}
+
+	responses := cache.New[middleware.CachedResponse](cfg.AggregateCacheTTL, cfg.AggregateCacheMaxEntries, clock)
+	dispatcher.Subscribe(func(context.Context, events.Event) {
+		responses.Purge()
+	})
+	logger.Info("Enabled aggregate response cache",
+		slog.Duration("ttl", cfg.AggregateCacheTTL),
+		slog.Int("max_entries", cfg.AggregateCacheMaxEntries))
+	return responses
+}
+
+// provideNotifier registers the log channel and the Telegram and email channels when
+// they are configured.
+func provideNotifier(pool *workerpool.Pool, mailer *mail.Mailer, mailSender mail.Sender, cfg *config.Config, logger *slog.Logger) (*notify.Notifier, error) {
+	notifier := notify.NewNotifier(pool, logger)
+	notifier.Register("log", notify.NewLogChannel(logger))
+	if cfg.TelegramBotToken != "" {
+		telegram, err := notify.NewTelegramChannel(cfg.TelegramBotToken, cfg.TelegramChats)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Telegram configuration: %w", err)
+		}
+		notifier.Register("telegram", telegram)
+		logger.Info("Enabled Telegram notifications", slog.Int("chats", len(cfg.TelegramChats)))
+	}
+	if err := registerEmailChannel(notifier, mailer, mailSender, cfg, logger); err != nil {
+		return nil, err
+	}
+	return notifier, nil
+}
+
+// provideMailSender returns nil when email is disabled.
+func provideMailSender(cfg *config.Config, logger *slog.Logger) (mail.Sender, error) {
+	switch cfg.MailBackend {
+	case "":
+		return nil, nil
+	case "smtp":
+		return mail.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword), nil
+	case "ses":
+		return mail.NewSESSender(cfg.SESRegion, sigv4.Credentials{
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretAccessKey,
+			SessionToken:    cfg.AWSSessionToken,
+		}), nil
+	}
+	logger.Error("Unknown mail backend", slog.String("backend", cfg.MailBackend))
+	return nil, fmt.Errorf("unknown mail backend %q", cfg.MailBackend)
+}
+
+func registerEmailChannel(notifier *notify.Notifier, mailer *mail.Mailer, sender mail.Sender, cfg *config.Config, logger *slog.Logger) error {
+	if sender == nil {
+		return nil
+	}
+	email, err := notify.NewEmailChannel(mailer, cfg.NotifyEmails)
+	if err != nil {
+		return fmt.Errorf("invalid email notification configuration: %w", err)
+	}
+	notifier.Register("email", email)
+	logger.Info("Enabled email notifications",
+		slog.String("backend", cfg.MailBackend),
+		slog.Int("recipients", len(cfg.NotifyEmails)))
+	return nil
+}
+
+func provideAuthGuard(cfg *config.Config, logger *slog.Logger) *bruteforce.Guard {
+	var store bruteforce.Store = bruteforce.NewMemoryStore()
+	if cfg.AuthRedisAddr != "" {
+		logger.Info("Using Redis for auth failure tracking", slog.String("addr", cfg.AuthRedisAddr))
+		store = bruteforce.NewRedisStore(cfg.AuthRedisAddr, 2*time.Second)
+	}
+	return bruteforce.NewGuard(store, cfg.AuthMaxFailures, cfg.AuthFailureWindow, cfg.AuthLockoutDuration, logger)
+}
+
+func provideQuotaService(repo *repository.QuotaRepository, tenants *service.TenantService, cfg *config.Config, logger *slog.Logger) *quota.Service {
+	return quota.NewService(repo,
+		quota.Limits{Requests: int64(cfg.QuotaKeyMonthlyRequests), Creates: int64(cfg.QuotaKeyMonthlyCreates)},
+		tenantQuotaLimits(cfg),
+		tenants,
+		logger)
+}
+
+func tenantQuotaLimits(cfg *config.Config) quota.Limits {
+	return quota.Limits{Requests: int64(cfg.QuotaTenantMonthlyRequests), Creates: int64(cfg.QuotaTenantMonthlyCreates)}
+}
+
+// provideReconciliationService matches statements with RECONCILE_AMOUNT_TOLERANCE and
+// RECONCILE_DATE_TOLERANCE_DAYS unless a reconciliation asks otherwise.
+func provideReconciliationService(db *gorm.DB, cfg *config.Config, logger *slog.Logger) (*service.ReconciliationService, error) {
+	amount, err := decimal.NewFromString(cfg.ReconcileAmountTolerance)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RECONCILE_AMOUNT_TOLERANCE: %w", err)
+	}
+	reconciliation, err := service.NewReconciliationService(repository.NewChargeRepository(db, logger), models.ReconciliationTolerance{
+		Amount: amount,
+		Days:   cfg.ReconcileDateTolerance,
+	}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reconciliation tolerance: %w", err)
+	}
+	return reconciliation, nil
+}
+
+// provideTenantService onboards tenants with the QUOTA_TENANT_MONTHLY_* limits, and
+// isolated ones only with TENANT_STORAGE=schema, when there are pools. Issued signing
+// keys are encrypted with SECRET_ENCRYPTION_KEY; without it no API keys are issued.
+func provideTenantService(repo *repository.TenantRepository, pools *tenancy.ConnPool, clock clock.Clock, cfg *config.Config, logger *slog.Logger) (*service.TenantService, error) {
+	var box *secretbox.Box
+	if cfg.SecretEncryptionKey != "" {
+		var err error
+		if box, err = secretbox.New(cfg.SecretEncryptionKey); err != nil {
+			return nil, fmt.Errorf("invalid SECRET_ENCRYPTION_KEY: %w", err)
+		}
+	}
+	// A nil *ConnPool must not become a non-nil TenantPools.
+	var tenantPools service.TenantPools
+	if pools != nil {
+		tenantPools = pools
+	}
+	return service.NewTenantService(repo, tenantQuotaLimits(cfg), tenantPools, box, clock, logger), nil
+}
+
+// registerDeliveryMetrics exposes the outbound delivery queues, labelled by destination
+// such as "event:crm", "notify:telegram" or "mail".
+func registerDeliveryMetrics(registry *metrics.Registry, pool *workerpool.Pool) {
+	stat := func(value func(workerpool.Stats) float64) func() map[string]float64 {
+		return func() map[string]float64 {
+			values := make(map[string]float64)
+			for _, s := range pool.Stats() {
+				values[s.Destination] = value(s)
+			}
+			return values
+		}
+	}
+
+	registry.GaugeVecFunc("delivery_queue_depth", "Outbound deliveries waiting for a worker.", "destination",
+		stat(func(s workerpool.Stats) float64 { return float64(s.Queued) }))
+	registry.GaugeVecFunc("delivery_queue_capacity", "Outbound deliveries a destination can queue.", "destination",
+		stat(func(s workerpool.Stats) float64 { return float64(s.Capacity) }))
+	registry.GaugeVecFunc("delivery_in_flight", "Outbound deliveries being sent.", "destination",
+		stat(func(s workerpool.Stats) float64 { return float64(s.InFlight) }))
+	registry.CounterVecFunc("delivery_completed_total", "Outbound deliveries attempted.", "destination",
+		stat(func(s workerpool.Stats) float64 { return float64(s.Completed) }))
+	registry.CounterVecFunc("delivery_rejected_total", "Outbound deliveries rejected because the queue was full.", "destination",
+		stat(func(s workerpool.Stats) float64 { return float64(s.Rejected) }))
+}
+
+// registerSchedulerMetrics exposes the scheduled jobs, labelled by job name.
+func registerSchedulerMetrics(registry *metrics.Registry, jobs *scheduler.Scheduler) {
+	stat := func(value func(models.JobStatus) float64) func() map[string]float64 {
+		return func() map[string]float64 {
+			values := make(map[string]float64)
+			for _, status := range jobs.Status() {
+				values[status.Name] = value(status)
+			}
+			return values
+		}
+	}
+
+	registry.CounterVecFunc("scheduler_job_runs_total", "Scheduled job runs.", "job",
+		stat(func(s models.JobStatus) float64 { return float64(s.Runs) }))
+	registry.CounterVecFunc("scheduler_job_failures_total", "Scheduled job runs that failed, timed out runs included.", "job",
+		stat(func(s models.JobStatus) float64 { return float64(s.Failures) }))
+	registry.CounterVecFunc("scheduler_job_timeouts_total", "Scheduled job runs cancelled at their max runtime.", "job",
+		stat(func(s models.JobStatus) float64 { return float64(s.TimedOut) }))
+	registry.CounterVecFunc("scheduler_job_missed_total", "Scheduled job runs due while the previous run was still going.", "job",
+		stat(func(s models.JobStatus) float64 { return float64(s.Missed) }))
+	registry.CounterVecFunc("scheduler_job_skipped_total", "Scheduled job runs left to the leader or another replica.", "job",
+		stat(func(s models.JobStatus) float64 { return float64(s.Skipped) }))
+	registry.GaugeVecFunc("scheduler_job_running", "1 while a scheduled job is running.", "job",
+		stat(func(s models.JobStatus) float64 {
+			if s.Running {
+				return 1
+			}
+			return 0
+		}))
+	registry.GaugeVecFunc("scheduler_job_last_duration_seconds", "Duration of the last run of a scheduled job.", "job",
+		stat(func(s models.JobStatus) float64 { return float64(s.LastDurationMS) / 1000 }))
+}
+
+// registerCacheMetrics exposes the in-memory caches, labelled by cache such as
+// "aggregate" or "not_found". Disabled caches are nil and left out.
+func registerCacheMetrics(registry *metrics.Registry, caches map[string]func() cache.Stats) {
+	stat := func(value func(cache.Stats) float64) func() map[string]float64 {
+		return func() map[string]float64 {
+			values := make(map[string]float64)
+			for name, stats := range caches {
+				if stats != nil {
+					values[name] = value(stats())
+				}
+			}
+			return values
+		}
+	}
+
+	registry.GaugeVecFunc("cache_entries", "Entries held in an in-memory cache.", "cache",
+		stat(func(s cache.Stats) float64 { return float64(s.Entries) }))
+	registry.CounterVecFunc("cache_hits_total", "Cache lookups answered from memory.", "cache",
+		stat(func(s cache.Stats) float64 { return float64(s.Hits) }))
+	registry.CounterVecFunc("cache_misses_total", "Cache lookups that had to be computed.", "cache",
+		stat(func(s cache.Stats) float64 { return float64(s.Misses) }))
+	registry.CounterVecFunc("cache_purges_total", "Times a cache was cleared because of a write.", "cache",
+		stat(func(s cache.Stats) float64 { return float64(s.Purges) }))
+}
+
+// installMiddleware installs the global middleware on router.
+func installMiddleware(router *gin.Engine, cfg *config.Config, meter *metering.Meter, registry *metrics.Registry, authGuard *bruteforce.Guard, tenants middleware.TenantDirectory, logger *slog.Logger) error {
+	// An empty list makes gin ignore X-Forwarded-For entirely instead of trusting every peer.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies configuration: %w", err)
+	}
+	logger.Info("Configured trusted proxies", slog.Any("trusted_proxies", cfg.TrustedProxies))
+
+	if err := i18n.SetDefaultLanguage(cfg.DefaultLanguage); err != nil {
+		return fmt.Errorf("invalid default language: %w", err)
+	}
+
+	hmacAuth, err := middleware.HMACAuth(cfg.HMACClients, tenants, cfg.HMACMaxSkew, cfg.HMACMaxBodyBytes, authGuard, logger)
+	if err != nil {
+		return fmt.Errorf("invalid HMAC client configuration: %w", err)
+	}
+	apiKeyAuth, err := middleware.APIKeyAuth(cfg.APIKeys, cfg.APIKeyRateLimit, cfg.APIKeyRateLimits, authGuard, logger)
+	if err != nil {
+		return fmt.Errorf("invalid API key configuration: %w", err)
+	}
+
+	router.Use(RequestLoggingMiddleware(logger))
+	router.Use(registry.Middleware())
+	router.Use(i18n.Middleware())
+	router.Use(middleware.Metering(meter))
+	router.Use(middleware.Identity(cfg.AdminToken, authGuard, logger))
+	router.Use(hmacAuth)
+	router.Use(apiKeyAuth)
+	router.Use(middleware.Sandbox(cfg.SandboxClients, cfg.SandboxKeys, logger))
+	router.Use(middleware.Tenants(cfg.TenantClients, tenants, cfg.TenantStorage == tenancy.StorageSchema, logger))
+	return nil
+}
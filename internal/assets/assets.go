@@ -0,0 +1,146 @@
+// Package assets serves embedded static files under fingerprinted names and renders
+// HTML templates that link to them. A fingerprinted URL changes whenever the file
+// does, so it can be cached forever; the plain name stays available for callers that
+// cannot know the fingerprint and is revalidated on every use.
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+const (
+	immutableCacheControl  = "public, max-age=31536000, immutable"
+	revalidateCacheControl = "no-cache"
+	fingerprintLength      = 12
+)
+
+type file struct {
+	content     []byte
+	etag        string
+	contentType string
+}
+
+// Assets holds the files of an fs.FS in memory, keyed by both their plain and their
+// fingerprinted names.
+type Assets struct {
+	prefix       string
+	files        map[string]*file
+	fingerprints map[string]string
+	modTime      time.Time
+}
+
+// New loads every file of fsys to be served under prefix, e.g. "/static".
+func New(fsys fs.FS, prefix string) (*Assets, error) {
+	a := &Assets{
+		prefix:       strings.TrimSuffix(prefix, "/"),
+		files:        make(map[string]*file),
+		fingerprints: make(map[string]string),
+		modTime:      time.Now(),
+	}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])[:fingerprintLength]
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = http.DetectContentType(content)
+		}
+
+		f := &file{content: content, etag: `"` + hash + `"`, contentType: contentType}
+		fingerprinted := fingerprint(name, hash)
+		a.files[name] = f
+		a.files[fingerprinted] = f
+		a.fingerprints[name] = fingerprinted
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load assets: %w", err)
+	}
+	return a, nil
+}
+
+// fingerprint inserts hash before the extension: app.js becomes app.<hash>.js.
+func fingerprint(name string, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// Path returns the fingerprinted URL of the asset called name. Unknown names are
+// returned unfingerprinted so a typo shows up as a 404 instead of a template error.
+func (a *Assets) Path(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if fingerprinted, ok := a.fingerprints[name]; ok {
+		return a.prefix + "/" + fingerprinted
+	}
+	return a.prefix + "/" + name
+}
+
+// Register serves the assets under the prefix.
+func (a *Assets) Register(router gin.IRoutes) {
+	router.GET(a.prefix+"/*filepath", a.serve)
+	router.HEAD(a.prefix+"/*filepath", a.serve)
+}
+
+func (a *Assets) serve(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param("filepath"), "/")
+	f, ok := a.files[name]
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	if _, plain := a.fingerprints[name]; plain {
+		c.Header("Cache-Control", revalidateCacheControl)
+	} else {
+		c.Header("Cache-Control", immutableCacheControl)
+	}
+	c.Header("ETag", f.etag)
+	c.Header("Content-Type", f.contentType)
+
+	// ServeContent answers If-None-Match against the ETag set above.
+	http.ServeContent(c.Writer, c.Request, name, a.modTime, bytes.NewReader(f.content))
+}
+
+// Templates parses the files of fsys matching patterns as HTML templates. They can
+// link to assets with {{asset "app.js"}}.
+func (a *Assets) Templates(fsys fs.FS, patterns ...string) (*template.Template, error) {
+	tmpl, err := template.New("").
+		Funcs(template.FuncMap{"asset": a.Path}).
+		ParseFS(fsys, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("parse templates: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Page renders the named template of tmpl. Pages link to fingerprinted assets, so they
+// are revalidated on every load to pick up new fingerprints after a deploy. The
+// template is passed explicitly so several template sets can share one engine.
+func Page(tmpl *template.Template, name string, data any) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", revalidateCacheControl)
+		c.Render(http.StatusOK, render.HTML{Template: tmpl, Name: name, Data: data})
+	}
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/events"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+const (
+	ActionCreate   = "subscription.created"
+	ActionUpdate   = "subscription.updated"
+	ActionDelete   = "subscription.deleted"
+	ActionRestore  = "subscription.restored"
+	ActionCancel   = "subscription.cancelled"
+	ActionMerge    = "subscription.merged"
+	ActionSplit    = "subscription.split"
+	ActionPurge    = "subscription.purged"
+	ActionRepair   = "subscription.repaired"
+	ActionActivate = "subscription.activated"
+)
+
+type repository interface {
+	Create(ctx context.Context, record *models.AuditRecord) error
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditRecord, error)
+}
+
+type emitter interface {
+	Emit(ctx context.Context, event events.Event)
+}
+
+type Recorder struct {
+	repo   repository
+	events emitter
+	logger *slog.Logger
+}
+
+func NewRecorder(repo repository, events emitter, logger *slog.Logger) *Recorder {
+	return &Recorder{
+		repo:   repo,
+		events: events,
+		logger: logger,
+	}
+}
+
+// Record stores an audit entry for the identity attached to ctx and emits it as a
+// domain event once stored. Failures are logged rather than returned so that auditing
+// never rolls back a completed write.
+func (r *Recorder) Record(ctx context.Context, action string, subscriptionID uuid.UUID, before any, after any) {
+	id := identity.FromContext(ctx)
+
+	record := &models.AuditRecord{
+		ID:             uuid.New(),
+		Actor:          id.Actor,
+		SubjectID:      id.Subject,
+		Action:         action,
+		SubscriptionID: &subscriptionID,
+		Before:         marshal(before),
+		After:          marshal(after),
+		ClientIP:       id.ClientIP,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	if err := r.repo.Create(ctx, record); err != nil {
+		r.logger.ErrorContext(ctx, "Failed to record audit entry",
+			slog.String("action", action),
+			slog.String("actor", id.Actor),
+			slog.String("subscription_id", subscriptionID.String()),
+			slog.String("error", err.Error()))
+		return
+	}
+
+	r.logger.InfoContext(ctx, "Recorded audit entry",
+		slog.String("audit_id", record.ID.String()),
+		slog.String("action", action),
+		slog.String("actor", id.Actor),
+		slog.Bool("impersonated", id.Impersonating()),
+		slog.String("client_ip", id.ClientIP),
+		slog.String("subscription_id", subscriptionID.String()))
+
+	r.events.Emit(ctx, events.FromAudit(*record))
+}
+
+func (r *Recorder) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditRecord, error) {
+	return r.repo.List(ctx, filter)
+}
+
+func marshal(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
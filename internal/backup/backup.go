@@ -0,0 +1,188 @@
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"awesomeProject1/internal/model"
+)
+
+const (
+	Format = "subscriptions-backup"
+
+	// SchemaVersion is the version of the latest migration the backup format matches.
+	SchemaVersion = 20250831090000
+
+	batchSize = 500
+)
+
+type Header struct {
+	Format        string    `json:"format"`
+	SchemaVersion int64     `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type Record struct {
+	models.Subscription
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type repository interface {
+	ForEachBatch(ctx context.Context, batchSize int, fn func(subs []models.Subscription) error) error
+	UpsertBatch(ctx context.Context, subs []models.Subscription) error
+}
+
+type Service struct {
+	repo              repository
+	logger            *slog.Logger
+	anonymizationSalt string
+}
+
+func NewService(repo repository, logger *slog.Logger, anonymizationSalt string) *Service {
+	return &Service{
+		repo:              repo,
+		logger:            logger,
+		anonymizationSalt: anonymizationSalt,
+	}
+}
+
+func (s *Service) Export(ctx context.Context, w io.Writer) (int, error) {
+	s.logger.InfoContext(ctx, "Starting subscriptions backup",
+		slog.Int64("schema_version", SchemaVersion))
+
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+
+	header := Header{
+		Format:        Format,
+		SchemaVersion: SchemaVersion,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := enc.Encode(header); err != nil {
+		return 0, fmt.Errorf("write backup header: %w", err)
+	}
+
+	count := 0
+	err := s.repo.ForEachBatch(ctx, batchSize, func(subs []models.Subscription) error {
+		for _, sub := range subs {
+			record := Record{Subscription: sub}
+			if sub.DeletedAt.Valid {
+				deletedAt := sub.DeletedAt.Time
+				record.DeletedAt = &deletedAt
+			}
+			if err := enc.Encode(record); err != nil {
+				return fmt.Errorf("write backup record: %w", err)
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Subscriptions backup failed",
+			slog.Int("written", count),
+			slog.String("error", err.Error()))
+		return count, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return count, fmt.Errorf("finish backup stream: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully completed subscriptions backup",
+		slog.Int("count", count))
+
+	return count, nil
+}
+
+func (s *Service) Import(ctx context.Context, r io.Reader) (int, error) {
+	s.logger.InfoContext(ctx, "Starting subscriptions restore")
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, fmt.Errorf("open backup stream: %w", err)
+	}
+	defer gz.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(gz))
+
+	var header Header
+	if err := dec.Decode(&header); err != nil {
+		return 0, fmt.Errorf("read backup header: %w", err)
+	}
+	if header.Format != Format {
+		return 0, fmt.Errorf("unsupported backup format %q", header.Format)
+	}
+	if header.SchemaVersion > SchemaVersion {
+		return 0, fmt.Errorf("backup schema version %d is newer than supported version %d", header.SchemaVersion, int64(SchemaVersion))
+	}
+
+	s.logger.InfoContext(ctx, "Read backup header",
+		slog.Int64("schema_version", header.SchemaVersion),
+		slog.Time("created_at", header.CreatedAt))
+
+	count := 0
+	batch := make([]models.Subscription, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.repo.UpsertBatch(ctx, batch); err != nil {
+			return err
+		}
+		count += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		var record Record
+		if err := dec.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return count, fmt.Errorf("read backup record %d: %w", count+len(batch)+1, err)
+		}
+
+		sub := record.Subscription
+		// Backups taken before kinds and billing periods existed only hold monthly
+		// recurring subscriptions.
+		if sub.Kind == "" {
+			sub.Kind = models.KindRecurring
+		}
+		if sub.BillingPeriod == "" {
+			sub.BillingPeriod = models.BillingMonthly
+			sub.BillingAnchorDay = 1
+		}
+		// Nor did scheduled subscriptions.
+		if sub.Status == "" {
+			sub.Status = models.StatusActive
+		}
+		if record.DeletedAt != nil {
+			sub.DeletedAt.Time = *record.DeletedAt
+			sub.DeletedAt.Valid = true
+		}
+		batch = append(batch, sub)
+
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return count, err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully completed subscriptions restore",
+		slog.Int("count", count))
+
+	return count, nil
+}
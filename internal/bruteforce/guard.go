@@ -0,0 +1,101 @@
+package bruteforce
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type Store interface {
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int, error)
+	Lock(ctx context.Context, key string, duration time.Duration) error
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	Reset(ctx context.Context, key string) error
+}
+
+type Guard struct {
+	store       Store
+	maxFailures int
+	window      time.Duration
+	lockout     time.Duration
+	logger      *slog.Logger
+}
+
+func NewGuard(store Store, maxFailures int, window time.Duration, lockout time.Duration, logger *slog.Logger) *Guard {
+	return &Guard{
+		store:       store,
+		maxFailures: maxFailures,
+		window:      window,
+		lockout:     lockout,
+		logger:      logger,
+	}
+}
+
+// Check reports how long the caller identified by keys must wait before trying again.
+// A zero duration means no key is locked out. Store errors fail open so that an
+// unavailable backend cannot lock everyone out.
+func (g *Guard) Check(ctx context.Context, keys ...string) time.Duration {
+	var wait time.Duration
+	for _, key := range keys {
+		locked, err := g.store.LockedFor(ctx, key)
+		if err != nil {
+			g.logger.ErrorContext(ctx, "Failed to check auth lockout",
+				slog.String("key", key),
+				slog.String("error", err.Error()))
+			continue
+		}
+		if locked > wait {
+			wait = locked
+		}
+	}
+	return wait
+}
+
+func (g *Guard) Fail(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		failures, err := g.store.RecordFailure(ctx, key, g.window)
+		if err != nil {
+			g.logger.ErrorContext(ctx, "Failed to record auth failure",
+				slog.String("key", key),
+				slog.String("error", err.Error()))
+			continue
+		}
+
+		g.logger.WarnContext(ctx, "Recorded failed authentication attempt",
+			slog.String("key", key),
+			slog.Int("failures", failures),
+			slog.Int("max_failures", g.maxFailures))
+
+		if failures < g.maxFailures {
+			continue
+		}
+
+		if err := g.store.Lock(ctx, key, g.lockout); err != nil {
+			g.logger.ErrorContext(ctx, "Failed to lock out auth key",
+				slog.String("key", key),
+				slog.String("error", err.Error()))
+			continue
+		}
+		if err := g.store.Reset(ctx, key); err != nil {
+			g.logger.ErrorContext(ctx, "Failed to reset auth failures after lockout",
+				slog.String("key", key),
+				slog.String("error", err.Error()))
+		}
+
+		g.logger.WarnContext(ctx, "ALERT: authentication locked out after repeated failures",
+			slog.String("alert", "auth_lockout"),
+			slog.String("key", key),
+			slog.Int("failures", failures),
+			slog.Duration("lockout", g.lockout))
+	}
+}
+
+func (g *Guard) Succeed(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := g.store.Reset(ctx, key); err != nil {
+			g.logger.ErrorContext(ctx, "Failed to reset auth failures",
+				slog.String("key", key),
+				slog.String("error", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package bruteforce
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redisKeyPrefix = "authguard:"
+
+// RedisStore shares failure counters and lockouts between replicas. It speaks the
+// small subset of RESP needed for counters with expiry over a single connection.
+type RedisStore struct {
+	addr    string
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+func NewRedisStore(addr string, timeout time.Duration) *RedisStore {
+	return &RedisStore{
+		addr:    addr,
+		timeout: timeout,
+	}
+}
+
+func (s *RedisStore) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	reply, err := s.do(ctx, "INCR", redisKeyPrefix+"failures:"+key)
+	if err != nil {
+		return 0, err
+	}
+	count, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected INCR reply %v", reply)
+	}
+	if count == 1 {
+		if _, err := s.do(ctx, "PEXPIRE", redisKeyPrefix+"failures:"+key, strconv.FormatInt(window.Milliseconds(), 10)); err != nil {
+			return 0, err
+		}
+	}
+	return int(count), nil
+}
+
+func (s *RedisStore) Lock(ctx context.Context, key string, duration time.Duration) error {
+	_, err := s.do(ctx, "SET", redisKeyPrefix+"lock:"+key, "1", "PX", strconv.FormatInt(duration.Milliseconds(), 10))
+	return err
+}
+
+func (s *RedisStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	reply, err := s.do(ctx, "PTTL", redisKeyPrefix+"lock:"+key)
+	if err != nil {
+		return 0, err
+	}
+	ttl, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected PTTL reply %v", reply)
+	}
+	if ttl <= 0 {
+		return 0, nil
+	}
+	return time.Duration(ttl) * time.Millisecond, nil
+}
+
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	_, err := s.do(ctx, "DEL", redisKeyPrefix+"failures:"+key)
+	return err
+}
+
+func (s *RedisStore) do(ctx context.Context, args ...string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		dialer := net.Dialer{Timeout: s.timeout}
+		conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+		if err != nil {
+			return nil, fmt.Errorf("redis: dial %s: %w", s.addr, err)
+		}
+		s.conn = conn
+		s.rd = bufio.NewReader(conn)
+	}
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = s.conn.SetDeadline(deadline)
+
+	var cmd strings.Builder
+	fmt.Fprintf(&cmd, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&cmd, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	if _, err := s.conn.Write([]byte(cmd.String())); err != nil {
+		s.closeLocked()
+		return nil, fmt.Errorf("redis: write: %w", err)
+	}
+
+	reply, err := readReply(s.rd)
+	if err != nil {
+		var replyErr redisError
+		if !errors.As(err, &replyErr) {
+			s.closeLocked()
+		}
+		return nil, err
+	}
+	return reply, nil
+}
+
+func (s *RedisStore) closeLocked() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+	s.conn = nil
+	s.rd = nil
+}
+
+func readReply(rd *bufio.Reader) (any, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: read: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, fmt.Errorf("redis: read bulk: %w", err)
+		}
+		return string(buf[:size]), nil
+	case '*':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		items := make([]any, 0, size)
+		for i := 0; i < size; i++ {
+			item, err := readReply(rd)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,69 @@
+// Package buildinfo identifies the running binary and instance. Release builds set
+// the version, commit and build time with ldflags:
+//
+//	go build -ldflags "-X awesomeProject1/internal/buildinfo.Version=1.4.0 \
+//	  -X awesomeProject1/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X awesomeProject1/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+//
+// Without them the commit and build time fall back to the VCS stamp Go embeds.
+package buildinfo
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info describes the build and the process serving a request.
+type Info struct {
+	Version    string    `json:"version"`
+	Commit     string    `json:"commit"`
+	BuildTime  string    `json:"build_time,omitempty"`
+	GoVersion  string    `json:"go_version"`
+	InstanceID uuid.UUID `json:"instance_id"`
+	Hostname   string    `json:"hostname"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+var current = load()
+
+func load() Info {
+	info := Info{
+		Version:    Version,
+		Commit:     Commit,
+		BuildTime:  BuildTime,
+		GoVersion:  runtime.Version(),
+		InstanceID: uuid.New(),
+		StartedAt:  time.Now().UTC(),
+	}
+	info.Hostname, _ = os.Hostname()
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	return info
+}
+
+// Current returns the identity of this process. The instance ID is new on every start.
+func Current() Info {
+	return current
+}
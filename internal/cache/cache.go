@@ -0,0 +1,113 @@
+// Package cache keeps short-lived values in memory. Entries expire after a fixed TTL
+// and the whole cache can be purged at once, which is how callers invalidate it when
+// the data behind the cached values changes.
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"awesomeProject1/internal/clock"
+)
+
+// Stats counts lookups since the cache was created.
+type Stats struct {
+	Entries int
+	Hits    int64
+	Misses  int64
+	Purges  int64
+}
+
+type entry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+type Cache[V any] struct {
+	ttl        time.Duration
+	maxEntries int
+	clock      clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]entry[V]
+	hits    int64
+	misses  int64
+	purges  int64
+}
+
+// New creates a cache keeping values for ttl and at most maxEntries of them.
+func New[V any](ttl time.Duration, maxEntries int, clock clock.Clock) *Cache[V] {
+	return &Cache[V]{
+		ttl:        ttl,
+		maxEntries: max(maxEntries, 1),
+		clock:      clock,
+		entries:    make(map[string]entry[V]),
+	}
+}
+
+// Get returns the value stored under key unless it has expired.
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if ok && c.clock.Now().Before(e.expires) {
+		c.hits++
+		return e.value, true
+	}
+	if ok {
+		delete(c.entries, key)
+	}
+	c.misses++
+	var zero V
+	return zero, false
+}
+
+// Set stores value under key. When the cache is full and no entry has expired yet the
+// value is not stored, so a burst of distinct keys cannot grow the cache unbounded.
+func (c *Cache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			return
+		}
+	}
+	c.entries[key] = entry[V]{value: value, expires: now.Add(c.ttl)}
+}
+
+// Delete drops the entry stored under key, if any.
+func (c *Cache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// Purge drops every entry.
+func (c *Cache[V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.purges++
+}
+
+func (c *Cache[V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Entries: len(c.entries),
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Purges:  c.purges,
+	}
+}
@@ -0,0 +1,190 @@
+// Package cdc captures subscription changes made outside the API. A database trigger
+// publishes them with NOTIFY; the listener records each one in the audit log, which
+// emits it as a domain event like any API change.
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"awesomeProject1/internal/audit"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/lock"
+	"awesomeProject1/internal/tenancy"
+)
+
+const (
+	// ApplicationName identifies the API's own database sessions. The trigger skips
+	// their changes because the API already records them.
+	ApplicationName = "subscriptions-api"
+
+	// Actor is recorded for changes captured from the database.
+	Actor = "database"
+
+	channel = "subscription_changes"
+
+	// lockKey makes one replica the listener; the others wait to take over.
+	lockKey = "cdc:listener"
+
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+)
+
+type recorder interface {
+	Record(ctx context.Context, action string, subscriptionID uuid.UUID, before any, after any)
+}
+
+type locker interface {
+	Acquire(ctx context.Context, key string) (*lock.Lock, error)
+}
+
+type Listener struct {
+	connString string
+	recorder   recorder
+	locker     locker
+	logger     *slog.Logger
+}
+
+func NewListener(connString string, recorder recorder, locker locker, logger *slog.Logger) *Listener {
+	return &Listener{
+		connString: connString,
+		recorder:   recorder,
+		locker:     locker,
+		logger:     logger,
+	}
+}
+
+type change struct {
+	Op     string          `json:"op"`
+	Schema string          `json:"schema"`
+	ID     uuid.UUID       `json:"id"`
+	Old    json.RawMessage `json:"old"`
+	New    json.RawMessage `json:"new"`
+}
+
+// Run listens until ctx is cancelled, reconnecting with backoff when the connection
+// drops. Notifications sent while disconnected are lost. Only the replica holding the
+// listener lock listens, otherwise every replica would record each change.
+func (l *Listener) Run(ctx context.Context) {
+	backoff := minBackoff
+	for {
+		err := l.lead(ctx)
+		if ctx.Err() != nil {
+			l.logger.Info("Stopped change data capture listener")
+			return
+		}
+
+		l.logger.ErrorContext(ctx, "Change data capture listener disconnected",
+			slog.String("error", err.Error()),
+			slog.Duration("retry_in", backoff))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// lead waits for the listener lock and listens while holding it.
+func (l *Listener) lead(ctx context.Context) error {
+	held, err := l.locker.Acquire(ctx, lockKey)
+	if err != nil {
+		return err
+	}
+	defer held.Release(context.WithoutCancel(ctx))
+	l.logger.InfoContext(ctx, "Acquired change data capture lock")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-held.Lost():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return l.listen(ctx)
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, l.connString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return err
+	}
+	l.logger.InfoContext(ctx, "Listening for subscription changes", slog.String("channel", channel))
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		l.handle(ctx, notification.Payload)
+	}
+}
+
+func (l *Listener) handle(ctx context.Context, payload string) {
+	var c change
+	if err := json.Unmarshal([]byte(payload), &c); err != nil {
+		l.logger.ErrorContext(ctx, "Ignoring malformed change notification",
+			slog.String("payload", payload),
+			slog.String("error", err.Error()))
+		return
+	}
+
+	id := identity.Identity{Actor: Actor, Tenant: identity.DefaultTenant}
+	if tenant, ok := tenancy.TenantName(c.Schema); ok {
+		id.Tenant, id.Schema = tenant, c.Schema
+	}
+
+	l.logger.InfoContext(ctx, "Captured subscription change from database",
+		slog.String("op", c.Op),
+		slog.String("tenant", id.Tenant),
+		slog.String("subscription_id", c.ID.String()))
+
+	ctx = identity.WithIdentity(ctx, id)
+	l.recorder.Record(ctx, action(c), c.ID, snapshot(c.Old), snapshot(c.New))
+}
+
+// action maps a row change onto the audit action the API would have recorded.
+func action(c change) string {
+	switch c.Op {
+	case "INSERT":
+		return audit.ActionCreate
+	case "DELETE":
+		return audit.ActionPurge
+	}
+
+	var before, after struct {
+		DeletedAt *time.Time `json:"deleted_at"`
+	}
+	_ = json.Unmarshal(c.Old, &before)
+	_ = json.Unmarshal(c.New, &after)
+	switch {
+	case before.DeletedAt == nil && after.DeletedAt != nil:
+		return audit.ActionDelete
+	case before.DeletedAt != nil && after.DeletedAt == nil:
+		return audit.ActionRestore
+	default:
+		return audit.ActionUpdate
+	}
+}
+
+func snapshot(row json.RawMessage) any {
+	if len(row) == 0 || string(row) == "null" {
+		return nil
+	}
+	return row
+}
@@ -0,0 +1,188 @@
+// Package chaos injects latency and errors into HTTP routes and repository calls, so
+// that retries, timeouts and circuit breakers can be verified end to end. It is meant
+// for test environments only: faults are read from a file named by configuration and
+// are refused unless the binary was built with -tags chaos.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+
+	"awesomeProject1/internal/i18n"
+)
+
+// FaultHeader marks responses whose error was injected.
+const FaultHeader = "X-Fault-Injected"
+
+var (
+	ErrInjected   = errors.New("injected fault")
+	ErrNotEnabled = errors.New("fault injection is not compiled in, build with -tags chaos")
+)
+
+// Fault delays matching calls by Latency plus up to Jitter and then fails a share of
+// ErrorRate of them, between 0 and 1.
+type Fault struct {
+	Latency   time.Duration `yaml:"latency"`
+	Jitter    time.Duration `yaml:"jitter"`
+	ErrorRate float64       `yaml:"error_rate"`
+}
+
+// RouteFault applies to the requests of one route, as matched by gin, or of every route
+// when Route is "*". Failed requests get Status, 503 by default.
+type RouteFault struct {
+	Method string `yaml:"method"`
+	Route  string `yaml:"route"`
+	Status int    `yaml:"status"`
+	Fault  `yaml:",inline"`
+}
+
+// OperationFault applies to one repository method such as GetByID, or to every method
+// when Operation is "*".
+type OperationFault struct {
+	Operation string `yaml:"operation"`
+	Fault     `yaml:",inline"`
+}
+
+type Faults struct {
+	Routes     []RouteFault     `yaml:"routes"`
+	Repository []OperationFault `yaml:"repository"`
+}
+
+// LoadFaults reads faults from YAML or JSON. It fails with ErrNotEnabled in builds
+// without fault injection, so a test configuration cannot reach production unnoticed.
+func LoadFaults(r io.Reader) (*Faults, error) {
+	if !Enabled {
+		return nil, ErrNotEnabled
+	}
+
+	var faults Faults
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&faults); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse faults: %w", err)
+	}
+
+	for i := range faults.Routes {
+		f := &faults.Routes[i]
+		f.Method = strings.ToUpper(f.Method)
+		if f.Status == 0 {
+			f.Status = http.StatusServiceUnavailable
+		}
+		if f.Route == "" || f.Status < 400 || f.Status > 599 {
+			return nil, fmt.Errorf("route fault %d: route is required and status must be an error status", i+1)
+		}
+		if err := f.validate(); err != nil {
+			return nil, fmt.Errorf("route fault %s %s: %w", f.Method, f.Route, err)
+		}
+	}
+	for i, f := range faults.Repository {
+		if f.Operation == "" {
+			return nil, fmt.Errorf("repository fault %d: operation is required", i+1)
+		}
+		if err := f.validate(); err != nil {
+			return nil, fmt.Errorf("repository fault %s: %w", f.Operation, err)
+		}
+	}
+	return &faults, nil
+}
+
+func (f Fault) validate() error {
+	if f.Latency < 0 || f.Jitter < 0 {
+		return errors.New("latency and jitter must not be negative")
+	}
+	if f.ErrorRate < 0 || f.ErrorRate > 1 {
+		return errors.New("error_rate must be between 0 and 1")
+	}
+	return nil
+}
+
+// apply waits out the fault's latency and reports whether the call should fail. It
+// returns early with the context's error when ctx is done while waiting.
+func (f Fault) apply(ctx context.Context) (bool, error) {
+	delay := f.Latency
+	if f.Jitter > 0 {
+		delay += rand.N(f.Jitter)
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	return f.ErrorRate > 0 && rand.Float64() < f.ErrorRate, nil
+}
+
+// Middleware injects the route faults into matching requests. Requests that fail are
+// answered without reaching their handler.
+func Middleware(faults []RouteFault, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, f := range faults {
+			if (f.Method != "" && f.Method != c.Request.Method) || (f.Route != "*" && f.Route != c.FullPath()) {
+				continue
+			}
+
+			fail, err := f.apply(c.Request.Context())
+			if err != nil {
+				c.Abort()
+				return
+			}
+			if fail {
+				logger.Warn("Injected request fault",
+					slog.String("method", c.Request.Method),
+					slog.String("route", c.FullPath()),
+					slog.Int("status", f.Status),
+					slog.String("client_ip", c.ClientIP()))
+
+				c.Header(FaultHeader, "true")
+				c.AbortWithStatusJSON(f.Status, i18n.ErrorBody(c, "fault_injected"))
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// Injector applies repository faults by operation name.
+type Injector struct {
+	faults []OperationFault
+	logger *slog.Logger
+}
+
+func NewInjector(faults []OperationFault, logger *slog.Logger) *Injector {
+	return &Injector{
+		faults: faults,
+		logger: logger,
+	}
+}
+
+// Inject delays the named operation and returns ErrInjected when it should fail.
+func (i *Injector) Inject(ctx context.Context, operation string) error {
+	for _, f := range i.faults {
+		if f.Operation != "*" && f.Operation != operation {
+			continue
+		}
+
+		fail, err := f.apply(ctx)
+		if err != nil {
+			return err
+		}
+		if fail {
+			i.logger.WarnContext(ctx, "Injected repository fault", slog.String("operation", operation))
+			return fmt.Errorf("%w: %s", ErrInjected, operation)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+//go:build !chaos
+
+package chaos
+
+// Enabled reports whether fault injection is compiled in. Release builds leave it out;
+// build with -tags chaos to use it.
+const Enabled = false
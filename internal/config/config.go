@@ -1,7 +1,12 @@
 package config
 
 import (
+	"fmt"
+	"io/fs"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +18,190 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	ServerPort string
+	AdminToken string
+
+	DBReplicaHost         string
+	DBReplicaPort         string
+	ReadOnlyProbeInterval time.Duration
+
+	Region            string
+	RegionRole        string
+	ReplicationMaxLag time.Duration
+
+	ServerSocket     string
+	ServerSocketMode fs.FileMode
+
+	ReusePort       bool
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
+
+	InternalPort string
+
+	MaxInFlightRequests int
+	LoadShedWait        time.Duration
+	LoadShedRetryAfter  time.Duration
+	PriorityClients     []string
+
+	ReadCacheMaxAge          time.Duration
+	AggregateCacheTTL        time.Duration
+	AggregateCacheMaxEntries int
+	NotFoundCacheTTL         time.Duration
+	NotFoundCacheMaxEntries  int
+	CategoryRefreshInterval  time.Duration
+
+	RoundingMode      string
+	RoundingPrecision int
+
+	ReconcileAmountTolerance string
+	ReconcileDateTolerance   int
+
+	BasePath string
+
+	AnonymizationSalt string
+
+	DefaultLanguage string
+
+	AuthMaxFailures     int
+	AuthFailureWindow   time.Duration
+	AuthLockoutDuration time.Duration
+	AuthRedisAddr       string
+
+	AdminAllowedCIDRs      []string
+	AdminDeniedCIDRs       []string
+	AdminTrustForwardedFor bool
+
+	TrustedProxies []string
+
+	HMACClients      map[string]string
+	HMACMaxSkew      time.Duration
+	HMACMaxBodyBytes int64
+
+	SecretEncryptionKey string
+
+	APIKeys          map[string]string
+	APIKeyRateLimit  int
+	APIKeyRateLimits map[string]int
+
+	QuotaKeyMonthlyRequests    int
+	QuotaKeyMonthlyCreates     int
+	QuotaTenantMonthlyRequests int
+	QuotaTenantMonthlyCreates  int
+
+	UsageFlushInterval time.Duration
+
+	TrashGracePeriod   time.Duration
+	TrashPurgeInterval time.Duration
+
+	ActivationInterval time.Duration
+
+	UserSubscriptionLimit        int
+	UserSubscriptionLimitTenants map[string]int
+
+	ListPageSize    int
+	ListMaxPageSize int
+
+	ReminderInterval time.Duration
+
+	ExchangeRates map[string]string
+
+	AnomalyScanInterval     time.Duration
+	AnomalyLookback         time.Duration
+	AnomalyPriceJumpPercent int
+	AnomalyExpensiveFactor  int
+	AnomalyNotifyChannel    string
+
+	MaintenanceAction           string
+	MaintenanceInterval         time.Duration
+	MaintenanceDeadRatioPercent int
+	MaintenanceMinDeadTuples    int
+	MaintenanceWindowStart      time.Duration
+	MaintenanceWindowEnd        time.Duration
+
+	TelegramBotToken string
+	TelegramChats    map[string]string
+
+	AlertSlackWebhooks map[string]string
+	AlertTeamsWebhooks map[string]string
+	AlertCooldown      time.Duration
+
+	WebhookURLs   map[string]string
+	WebhookSecret string
+
+	DeliveryWorkers   int
+	DeliveryQueueSize int
+
+	CDCEnabled bool
+
+	SOAPEnabled bool
+
+	LockKeepalive time.Duration
+
+	InstanceHeartbeatInterval time.Duration
+
+	SLOObjectivesFile string
+
+	ChaosFaultsFile string
+
+	LakeSyncFile     string
+	LakeSyncInterval time.Duration
+
+	SpendAlertsFile    string
+	SpendAlertInterval time.Duration
+
+	SchedulerJitterPercent int
+	SchedulerCatchUp       string
+	SchedulerMaxRuntime    time.Duration
+	SchedulerMaxRuntimes   map[string]time.Duration
+
+	AsyncJobHeartbeat  time.Duration
+	AsyncJobRetention  time.Duration
+	AsyncJobFilesDir   string
+	AsyncJobLinkSecret string
+	AsyncJobLinkTTL    time.Duration
+
+	BusinessMetricsInterval time.Duration
+
+	RemoteWriteURL         string
+	RemoteWriteInterval    time.Duration
+	RemoteWriteBearerToken string
+	RemoteWriteUsername    string
+	RemoteWritePassword    string
+	RemoteWriteLabels      map[string]string
+
+	SearchURL      string
+	SearchIndex    string
+	SearchUsername string
+	SearchPassword string
+	SearchTimeout  time.Duration
+
+	RecordingMaxBodyBytes int
+
+	SandboxClients []string
+	SandboxKeys    []string
+	SandboxResetAt time.Duration
+
+	TenantStorage      string
+	TenantClients      map[string]string
+	TenantPoolSize     int
+	TenantMaxIdleConns int
+
+	EventSourcing bool
+
+	MailBackend       string
+	MailFrom          string
+	MailRetryInterval time.Duration
+	MailMaxAttempts   int
+	NotifyEmails      map[string]string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	SESRegion          string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
 }
 
 func LoadConfig() (*Config, error) {
@@ -20,6 +209,426 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	trashGracePeriod, err := getDuration("TRASH_GRACE_PERIOD", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	trashPurgeInterval, err := getDuration("TRASH_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	activationInterval, err := getDuration("ACTIVATION_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	userSubscriptionLimit, err := getInt("USER_SUBSCRIPTION_LIMIT", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	userSubscriptionLimitTenants, err := getIntMap("USER_SUBSCRIPTION_LIMIT_TENANTS")
+	if err != nil {
+		return nil, err
+	}
+
+	listPageSize, err := getInt("LIST_PAGE_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+
+	listMaxPageSize, err := getInt("LIST_MAX_PAGE_SIZE", 1000)
+	if err != nil {
+		return nil, err
+	}
+	if listPageSize <= 0 || listMaxPageSize < listPageSize {
+		return nil, fmt.Errorf("invalid LIST_PAGE_SIZE %d: expected 1 to LIST_MAX_PAGE_SIZE (%d)", listPageSize, listMaxPageSize)
+	}
+
+	exchangeRates, err := getMap("EXCHANGE_RATES")
+	if err != nil {
+		return nil, err
+	}
+
+	authMaxFailures, err := getInt("AUTH_MAX_FAILURES", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	authFailureWindow, err := getDuration("AUTH_FAILURE_WINDOW", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	authLockoutDuration, err := getDuration("AUTH_LOCKOUT_DURATION", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	adminTrustForwardedFor, err := getBool("ADMIN_TRUST_FORWARDED_FOR", false)
+	if err != nil {
+		return nil, err
+	}
+
+	hmacClients, err := getMap("HMAC_CLIENTS")
+	if err != nil {
+		return nil, err
+	}
+
+	hmacMaxSkew, err := getDuration("HMAC_MAX_SKEW", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	hmacMaxBodyBytes, err := getInt("HMAC_MAX_BODY_BYTES", 10<<20)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := getMap("API_KEYS")
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeyRateLimit, err := getInt("API_KEY_RATE_LIMIT", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeyRateLimits, err := getIntMap("API_KEY_RATE_LIMITS")
+	if err != nil {
+		return nil, err
+	}
+
+	quotaKeyMonthlyRequests, err := getInt("QUOTA_KEY_MONTHLY_REQUESTS", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	quotaKeyMonthlyCreates, err := getInt("QUOTA_KEY_MONTHLY_CREATES", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	quotaTenantMonthlyRequests, err := getInt("QUOTA_TENANT_MONTHLY_REQUESTS", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	quotaTenantMonthlyCreates, err := getInt("QUOTA_TENANT_MONTHLY_CREATES", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	usageFlushInterval, err := getDuration("USAGE_FLUSH_INTERVAL", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	reminderInterval, err := getDuration("REMINDER_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalyScanInterval, err := getDuration("ANOMALY_SCAN_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalyLookback, err := getDuration("ANOMALY_LOOKBACK", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalyPriceJumpPercent, err := getInt("ANOMALY_PRICE_JUMP_PERCENT", 50)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalyExpensiveFactor, err := getInt("ANOMALY_EXPENSIVE_FACTOR", 3)
+	if err != nil {
+		return nil, err
+	}
+
+	maintenanceInterval, err := getDuration("MAINTENANCE_INTERVAL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	maintenanceDeadRatioPercent, err := getInt("MAINTENANCE_DEAD_RATIO_PERCENT", 20)
+	if err != nil {
+		return nil, err
+	}
+
+	maintenanceMinDeadTuples, err := getInt("MAINTENANCE_MIN_DEAD_TUPLES", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	maintenanceWindowStart, err := getTimeOfDay("MAINTENANCE_WINDOW_START", 2*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	maintenanceWindowEnd, err := getTimeOfDay("MAINTENANCE_WINDOW_END", 5*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	telegramChats, err := getMap("TELEGRAM_CHATS")
+	if err != nil {
+		return nil, err
+	}
+
+	alertSlackWebhooks, err := getMap("ALERT_SLACK_WEBHOOKS")
+	if err != nil {
+		return nil, err
+	}
+
+	alertTeamsWebhooks, err := getMap("ALERT_TEAMS_WEBHOOKS")
+	if err != nil {
+		return nil, err
+	}
+
+	alertCooldown, err := getDuration("ALERT_COOLDOWN", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookURLs, err := getMap("WEBHOOK_URLS")
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryWorkers, err := getInt("DELIVERY_WORKERS", 4)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryQueueSize, err := getInt("DELIVERY_QUEUE_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+
+	cdcEnabled, err := getBool("CDC_ENABLED", false)
+	if err != nil {
+		return nil, err
+	}
+
+	soapEnabled, err := getBool("SOAP_ENABLED", false)
+	if err != nil {
+		return nil, err
+	}
+
+	lockKeepalive, err := getDuration("LOCK_KEEPALIVE", 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceHeartbeatInterval, err := getDuration("INSTANCE_HEARTBEAT_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	lakeSyncInterval, err := getDuration("LAKE_SYNC_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	spendAlertInterval, err := getDuration("SPEND_ALERT_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	schedulerJitterPercent, err := getInt("SCHEDULER_JITTER_PERCENT", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	schedulerMaxRuntime, err := getDuration("SCHEDULER_MAX_RUNTIME", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	schedulerMaxRuntimes, err := getDurationMap("SCHEDULER_MAX_RUNTIME_JOBS")
+	if err != nil {
+		return nil, err
+	}
+
+	asyncJobHeartbeat, err := getDuration("ASYNC_JOB_HEARTBEAT", 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	asyncJobRetention, err := getDuration("ASYNC_JOB_RETENTION", 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	asyncJobLinkTTL, err := getDuration("ASYNC_JOB_LINK_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	businessMetricsInterval, err := getDuration("BUSINESS_METRICS_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteWriteInterval, err := getDuration("REMOTE_WRITE_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteWriteLabels, err := getMap("REMOTE_WRITE_LABELS")
+	if err != nil {
+		return nil, err
+	}
+
+	searchTimeout, err := getDuration("SEARCH_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	eventSourcing, err := getBool("EVENT_SOURCING", false)
+	if err != nil {
+		return nil, err
+	}
+
+	mailRetryInterval, err := getDuration("MAIL_RETRY_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	mailMaxAttempts, err := getInt("MAIL_MAX_ATTEMPTS", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	notifyEmails, err := getMap("NOTIFY_EMAILS")
+	if err != nil {
+		return nil, err
+	}
+
+	maxInFlightRequests, err := getInt("MAX_IN_FLIGHT_REQUESTS", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	loadShedWait, err := getDuration("LOAD_SHED_WAIT", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	loadShedRetryAfter, err := getDuration("LOAD_SHED_RETRY_AFTER", time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	readCacheMaxAge, err := getDuration("READ_CACHE_MAX_AGE", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregateCacheTTL, err := getDuration("AGGREGATE_CACHE_TTL", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregateCacheMaxEntries, err := getInt("AGGREGATE_CACHE_MAX_ENTRIES", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	notFoundCacheTTL, err := getDuration("NOT_FOUND_CACHE_TTL", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	notFoundCacheMaxEntries, err := getInt("NOT_FOUND_CACHE_MAX_ENTRIES", 10000)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryRefreshInterval, err := getDuration("CATEGORY_REFRESH_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	roundingPrecision, err := getInt("ROUNDING_PRECISION", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	reconcileDateTolerance, err := getInt("RECONCILE_DATE_TOLERANCE_DAYS", 3)
+	if err != nil {
+		return nil, err
+	}
+
+	recordingMaxBodyBytes, err := getInt("RECORDING_MAX_BODY_BYTES", 64<<10)
+	if err != nil {
+		return nil, err
+	}
+
+	sandboxResetAt, err := getTimeOfDay("SANDBOX_RESET_AT", 3*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	sandboxKeys := getList("API_KEY_SANDBOX")
+	for _, name := range sandboxKeys {
+		if _, ok := apiKeys[name]; !ok {
+			return nil, fmt.Errorf("invalid API_KEY_SANDBOX entry %q: not a key of API_KEYS", name)
+		}
+	}
+
+	tenantClients, err := getMap("TENANT_CLIENTS")
+	if err != nil {
+		return nil, err
+	}
+
+	tenantPoolSize, err := getInt("TENANT_POOL_SIZE", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantMaxIdleConns, err := getInt("TENANT_MAX_IDLE_CONNS", 20)
+	if err != nil {
+		return nil, err
+	}
+
+	serverSocketMode, err := getFileMode("SERVER_SOCKET_MODE", 0o660)
+	if err != nil {
+		return nil, err
+	}
+
+	reusePort, err := getBool("REUSE_PORT", false)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownDelay, err := getDuration("SHUTDOWN_DELAY", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownTimeout, err := getDuration("SHUTDOWN_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	readOnlyProbeInterval, err := getDuration("READ_ONLY_PROBE_INTERVAL", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	replicationMaxLag, err := getDuration("REPLICATION_MAX_LAG", 0)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		DBHost:     os.Getenv("DB_HOST"),
 		DBPort:     os.Getenv("DB_PORT"),
@@ -27,5 +636,330 @@ func LoadConfig() (*Config, error) {
 		DBUser:     os.Getenv("DB_USER"),
 		DBPassword: os.Getenv("DB_PASSWORD"),
 		ServerPort: os.Getenv("SERVER_PORT"),
+		AdminToken: os.Getenv("ADMIN_TOKEN"),
+
+		DBReplicaHost:         os.Getenv("DB_REPLICA_HOST"),
+		DBReplicaPort:         getString("DB_REPLICA_PORT", os.Getenv("DB_PORT")),
+		ReadOnlyProbeInterval: readOnlyProbeInterval,
+
+		Region:            os.Getenv("REGION"),
+		RegionRole:        getString("REGION_ROLE", "active"),
+		ReplicationMaxLag: replicationMaxLag,
+
+		ServerSocket:     os.Getenv("SERVER_SOCKET"),
+		ServerSocketMode: serverSocketMode,
+
+		ReusePort:       reusePort,
+		ShutdownDelay:   shutdownDelay,
+		ShutdownTimeout: shutdownTimeout,
+
+		InternalPort: os.Getenv("INTERNAL_PORT"),
+
+		MaxInFlightRequests: maxInFlightRequests,
+		LoadShedWait:        loadShedWait,
+		LoadShedRetryAfter:  loadShedRetryAfter,
+		PriorityClients:     getList("PRIORITY_CLIENTS"),
+
+		ReadCacheMaxAge:          readCacheMaxAge,
+		AggregateCacheTTL:        aggregateCacheTTL,
+		AggregateCacheMaxEntries: aggregateCacheMaxEntries,
+		NotFoundCacheTTL:         notFoundCacheTTL,
+		NotFoundCacheMaxEntries:  notFoundCacheMaxEntries,
+		CategoryRefreshInterval:  categoryRefreshInterval,
+
+		RoundingMode:      getString("ROUNDING_MODE", "half_up"),
+		RoundingPrecision: roundingPrecision,
+
+		ReconcileAmountTolerance: getString("RECONCILE_AMOUNT_TOLERANCE", "0"),
+		ReconcileDateTolerance:   reconcileDateTolerance,
+
+		BasePath: basePath(os.Getenv("BASE_PATH")),
+
+		AnonymizationSalt: os.Getenv("ANONYMIZATION_SALT"),
+
+		DefaultLanguage: getString("DEFAULT_LANGUAGE", "en"),
+
+		AuthMaxFailures:     authMaxFailures,
+		AuthFailureWindow:   authFailureWindow,
+		AuthLockoutDuration: authLockoutDuration,
+		AuthRedisAddr:       os.Getenv("AUTH_REDIS_ADDR"),
+
+		AdminAllowedCIDRs:      getList("ADMIN_ALLOWED_CIDRS"),
+		AdminDeniedCIDRs:       getList("ADMIN_DENIED_CIDRS"),
+		AdminTrustForwardedFor: adminTrustForwardedFor,
+
+		TrustedProxies: getList("TRUSTED_PROXIES"),
+
+		HMACClients:      hmacClients,
+		HMACMaxSkew:      hmacMaxSkew,
+		HMACMaxBodyBytes: int64(hmacMaxBodyBytes),
+
+		SecretEncryptionKey: os.Getenv("SECRET_ENCRYPTION_KEY"),
+
+		APIKeys:          apiKeys,
+		APIKeyRateLimit:  apiKeyRateLimit,
+		APIKeyRateLimits: apiKeyRateLimits,
+
+		QuotaKeyMonthlyRequests:    quotaKeyMonthlyRequests,
+		QuotaKeyMonthlyCreates:     quotaKeyMonthlyCreates,
+		QuotaTenantMonthlyRequests: quotaTenantMonthlyRequests,
+		QuotaTenantMonthlyCreates:  quotaTenantMonthlyCreates,
+
+		UsageFlushInterval: usageFlushInterval,
+
+		TrashGracePeriod:   trashGracePeriod,
+		TrashPurgeInterval: trashPurgeInterval,
+
+		ActivationInterval: activationInterval,
+
+		UserSubscriptionLimit:        userSubscriptionLimit,
+		UserSubscriptionLimitTenants: userSubscriptionLimitTenants,
+
+		ListPageSize:    listPageSize,
+		ListMaxPageSize: listMaxPageSize,
+
+		ReminderInterval: reminderInterval,
+
+		ExchangeRates: exchangeRates,
+
+		AnomalyScanInterval:     anomalyScanInterval,
+		AnomalyLookback:         anomalyLookback,
+		AnomalyPriceJumpPercent: anomalyPriceJumpPercent,
+		AnomalyExpensiveFactor:  anomalyExpensiveFactor,
+		AnomalyNotifyChannel:    os.Getenv("ANOMALY_NOTIFY_CHANNEL"),
+
+		MaintenanceAction:           os.Getenv("MAINTENANCE_ACTION"),
+		MaintenanceInterval:         maintenanceInterval,
+		MaintenanceDeadRatioPercent: maintenanceDeadRatioPercent,
+		MaintenanceMinDeadTuples:    maintenanceMinDeadTuples,
+		MaintenanceWindowStart:      maintenanceWindowStart,
+		MaintenanceWindowEnd:        maintenanceWindowEnd,
+
+		TelegramBotToken: os.Getenv("TELEGRAM_BOT_TOKEN"),
+		TelegramChats:    telegramChats,
+
+		AlertSlackWebhooks: alertSlackWebhooks,
+		AlertTeamsWebhooks: alertTeamsWebhooks,
+		AlertCooldown:      alertCooldown,
+
+		WebhookURLs:   webhookURLs,
+		WebhookSecret: os.Getenv("WEBHOOK_SECRET"),
+
+		DeliveryWorkers:   deliveryWorkers,
+		DeliveryQueueSize: deliveryQueueSize,
+
+		CDCEnabled: cdcEnabled,
+
+		SOAPEnabled: soapEnabled,
+
+		LockKeepalive: lockKeepalive,
+
+		InstanceHeartbeatInterval: instanceHeartbeatInterval,
+
+		SLOObjectivesFile: os.Getenv("SLO_OBJECTIVES_FILE"),
+
+		ChaosFaultsFile: os.Getenv("CHAOS_FAULTS_FILE"),
+
+		LakeSyncFile:     os.Getenv("LAKE_SYNC_FILE"),
+		LakeSyncInterval: lakeSyncInterval,
+
+		SpendAlertsFile:    os.Getenv("SPEND_ALERTS_FILE"),
+		SpendAlertInterval: spendAlertInterval,
+
+		SchedulerJitterPercent: schedulerJitterPercent,
+		SchedulerCatchUp:       getString("SCHEDULER_CATCH_UP", "skip"),
+		SchedulerMaxRuntime:    schedulerMaxRuntime,
+		SchedulerMaxRuntimes:   schedulerMaxRuntimes,
+
+		AsyncJobHeartbeat:  asyncJobHeartbeat,
+		AsyncJobRetention:  asyncJobRetention,
+		AsyncJobFilesDir:   os.Getenv("ASYNC_JOB_FILES_DIR"),
+		AsyncJobLinkSecret: os.Getenv("ASYNC_JOB_LINK_SECRET"),
+		AsyncJobLinkTTL:    asyncJobLinkTTL,
+
+		BusinessMetricsInterval: businessMetricsInterval,
+
+		RemoteWriteURL:         os.Getenv("REMOTE_WRITE_URL"),
+		RemoteWriteInterval:    remoteWriteInterval,
+		RemoteWriteBearerToken: os.Getenv("REMOTE_WRITE_BEARER_TOKEN"),
+		RemoteWriteUsername:    os.Getenv("REMOTE_WRITE_USERNAME"),
+		RemoteWritePassword:    os.Getenv("REMOTE_WRITE_PASSWORD"),
+		RemoteWriteLabels:      remoteWriteLabels,
+
+		SearchURL:      os.Getenv("SEARCH_URL"),
+		SearchIndex:    getString("SEARCH_INDEX", "subscriptions"),
+		SearchUsername: os.Getenv("SEARCH_USERNAME"),
+		SearchPassword: os.Getenv("SEARCH_PASSWORD"),
+		SearchTimeout:  searchTimeout,
+
+		RecordingMaxBodyBytes: recordingMaxBodyBytes,
+
+		SandboxClients: getList("SANDBOX_CLIENTS"),
+		SandboxKeys:    sandboxKeys,
+		SandboxResetAt: sandboxResetAt,
+
+		TenantStorage:      getString("TENANT_STORAGE", "shared"),
+		TenantClients:      tenantClients,
+		TenantPoolSize:     tenantPoolSize,
+		TenantMaxIdleConns: tenantMaxIdleConns,
+
+		EventSourcing: eventSourcing,
+
+		MailBackend:       os.Getenv("MAIL_BACKEND"),
+		MailFrom:          os.Getenv("MAIL_FROM"),
+		MailRetryInterval: mailRetryInterval,
+		MailMaxAttempts:   mailMaxAttempts,
+		NotifyEmails:      notifyEmails,
+
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     getString("SMTP_PORT", "587"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+
+		SESRegion:          os.Getenv("SES_REGION"),
+		AWSAccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		AWSSecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		AWSSessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
 	}, nil
 }
+
+// basePath normalizes a mount prefix to "/prefix" form; "" and "/" mount at the root.
+func basePath(value string) string {
+	value = strings.Trim(strings.TrimSpace(value), "/")
+	if value == "" {
+		return ""
+	}
+	return "/" + value
+}
+
+func getString(key string, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+func getDuration(key string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+func getInt(key string, def int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func getBool(key string, def bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func getFileMode(key string, def fs.FileMode) (fs.FileMode, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid %s: expected octal permissions such as 0660", key)
+	}
+	return fs.FileMode(mode), nil
+}
+
+// getTimeOfDay parses a UTC time such as 03:00 into the offset from midnight.
+func getTimeOfDay(key string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: expected a time such as 03:00", key)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func getList(key string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getMap(key string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, item := range getList(key) {
+		k, v, ok := strings.Cut(item, ":")
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid %s entry %q, expected key:value", key, item)
+		}
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return m, nil
+}
+
+func getIntMap(key string) (map[string]int, error) {
+	entries, err := getMap(key)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]int, len(entries))
+	for k, v := range entries {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry %q: %w", key, k+":"+v, err)
+		}
+		m[k] = n
+	}
+	return m, nil
+}
+
+func getDurationMap(key string) (map[string]time.Duration, error) {
+	entries, err := getMap(key)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]time.Duration, len(entries))
+	for k, v := range entries {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry %q: %w", key, k+":"+v, err)
+		}
+		m[k] = d
+	}
+	return m, nil
+}
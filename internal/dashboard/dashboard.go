@@ -0,0 +1,167 @@
+// Package dashboard assembles the operational overview behind /admin/dashboard from
+// the health of the database, the delivery queues, quota usage and the scheduler.
+package dashboard
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/quota"
+)
+
+const (
+	recentErrorsLimit = 20
+	topTenantsLimit   = 10
+)
+
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type deadLetterRepository interface {
+	Latest(ctx context.Context, limit int) ([]models.DeadLetter, error)
+	Count(ctx context.Context) (int64, error)
+}
+
+type mailRepository interface {
+	List(ctx context.Context, filter models.MailFilter) ([]models.MailDelivery, error)
+	CountByStatus(ctx context.Context) (map[string]int64, error)
+}
+
+type quotaRepository interface {
+	Top(ctx context.Context, scope string, period time.Time, limit int) ([]models.QuotaUsage, error)
+}
+
+type usageBuffer interface {
+	Buffered() int
+}
+
+type jobStatuses interface {
+	Status() []models.JobStatus
+}
+
+type Service struct {
+	db          pinger
+	deadLetters deadLetterRepository
+	mail        mailRepository
+	quota       quotaRepository
+	usage       usageBuffer
+	jobs        jobStatuses
+	clock       clock.Clock
+	logger      *slog.Logger
+}
+
+func NewService(db pinger, deadLetters deadLetterRepository, mail mailRepository, quota quotaRepository, usage usageBuffer, jobs jobStatuses, clock clock.Clock, logger *slog.Logger) *Service {
+	return &Service{
+		db:          db,
+		deadLetters: deadLetters,
+		mail:        mail,
+		quota:       quota,
+		usage:       usage,
+		jobs:        jobs,
+		clock:       clock,
+		logger:      logger,
+	}
+}
+
+// Snapshot builds the dashboard. An unreachable database degrades the health status
+// instead of failing, so the ops UI still shows the scheduler and the usage buffer.
+func (s *Service) Snapshot(ctx context.Context) (*models.Dashboard, error) {
+	now := s.clock.Now().UTC()
+	jobs := s.jobs.Status()
+
+	dashboard := &models.Dashboard{
+		GeneratedAt:  now,
+		Health:       models.DashboardHealth{Status: models.HealthOK, Database: models.HealthOK},
+		RecentErrors: jobErrors(jobs),
+		TopTenants:   []models.QuotaUsage{},
+		Queues:       models.DashboardQueues{UsageBuffered: s.usage.Buffered()},
+		Jobs:         jobs,
+	}
+	dashboard.Health.FailingJobs = len(dashboard.RecentErrors)
+
+	pingStart := time.Now()
+	err := s.db.Ping(ctx)
+	dashboard.Health.DatabaseLatencyMS = time.Since(pingStart).Milliseconds()
+	if err != nil {
+		s.logger.WarnContext(ctx, "Database is unreachable for the dashboard",
+			slog.String("error", err.Error()))
+		dashboard.Health.Status = models.HealthDegraded
+		dashboard.Health.Database = err.Error()
+		return dashboard, nil
+	}
+	if dashboard.Health.FailingJobs > 0 {
+		dashboard.Health.Status = models.HealthDegraded
+	}
+
+	if dashboard.Queues.DeadLetters, err = s.deadLetters.Count(ctx); err != nil {
+		return nil, err
+	}
+	mailCounts, err := s.mail.CountByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dashboard.Queues.MailPending = mailCounts[models.MailPending]
+	dashboard.Queues.MailFailed = mailCounts[models.MailFailed]
+
+	period := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if dashboard.TopTenants, err = s.quota.Top(ctx, quota.ScopeTenant, period, topTenantsLimit); err != nil {
+		return nil, err
+	}
+
+	letters, err := s.deadLetters.Latest(ctx, recentErrorsLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, letter := range letters {
+		dashboard.RecentErrors = append(dashboard.RecentErrors, models.DashboardError{
+			Source:  models.ErrorSourceDeadLetter,
+			Ref:     letter.ID.String(),
+			Message: letter.Sink + ": " + letter.Error,
+			At:      letter.LastAttemptAt,
+		})
+	}
+
+	deliveries, err := s.mail.List(ctx, models.MailFilter{Status: models.MailFailed, Limit: recentErrorsLimit})
+	if err != nil {
+		return nil, err
+	}
+	for _, delivery := range deliveries {
+		dashboard.RecentErrors = append(dashboard.RecentErrors, models.DashboardError{
+			Source:  models.ErrorSourceMail,
+			Ref:     delivery.ID.String(),
+			Message: delivery.LastError,
+			At:      delivery.CreatedAt,
+		})
+	}
+
+	sort.SliceStable(dashboard.RecentErrors, func(i, j int) bool {
+		return dashboard.RecentErrors[i].At.After(dashboard.RecentErrors[j].At)
+	})
+	if len(dashboard.RecentErrors) > recentErrorsLimit {
+		dashboard.RecentErrors = dashboard.RecentErrors[:recentErrorsLimit]
+	}
+
+	return dashboard, nil
+}
+
+// jobErrors lists the jobs whose last run failed.
+func jobErrors(jobs []models.JobStatus) []models.DashboardError {
+	errs := []models.DashboardError{}
+	for _, job := range jobs {
+		if job.LastError == "" || job.LastRunAt == nil {
+			continue
+		}
+		errs = append(errs, models.DashboardError{
+			Source:  models.ErrorSourceJob,
+			Ref:     job.Name,
+			Message: job.LastError,
+			At:      *job.LastRunAt,
+		})
+	}
+	return errs
+}
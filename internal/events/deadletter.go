@@ -0,0 +1,137 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+// maxBulkRetry bounds a single bulk retry so one request cannot run for hours.
+const maxBulkRetry = 1000
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+type deadLetterRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.DeadLetter, error)
+	List(ctx context.Context, filter models.DeadLetterFilter) ([]models.DeadLetter, error)
+	UpdateAttempt(ctx context.Context, letter *models.DeadLetter) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	Purge(ctx context.Context, filter models.DeadLetterFilter) (int64, error)
+}
+
+// DeadLetters manages events that could not be delivered. A successful retry
+// removes the dead letter; a failed one records the attempt.
+type DeadLetters struct {
+	repo       deadLetterRepository
+	dispatcher *Dispatcher
+	logger     *slog.Logger
+}
+
+func NewDeadLetters(repo deadLetterRepository, dispatcher *Dispatcher, logger *slog.Logger) *DeadLetters {
+	return &DeadLetters{
+		repo:       repo,
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+}
+
+func (s *DeadLetters) List(ctx context.Context, filter models.DeadLetterFilter) ([]models.DeadLetter, error) {
+	return s.repo.List(ctx, filter)
+}
+
+func (s *DeadLetters) Get(ctx context.Context, id uuid.UUID) (*models.DeadLetter, error) {
+	letter, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return letter, nil
+}
+
+func (s *DeadLetters) Retry(ctx context.Context, id uuid.UUID) error {
+	letter, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return notFound(err)
+	}
+	return s.retry(ctx, letter)
+}
+
+// RetryAll retries up to maxBulkRetry matching dead letters, oldest first.
+func (s *DeadLetters) RetryAll(ctx context.Context, filter models.DeadLetterFilter) (models.ReplayResult, error) {
+	filter.Limit = maxBulkRetry
+	letters, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return models.ReplayResult{}, err
+	}
+
+	var result models.ReplayResult
+	for i := range letters {
+		letter, err := s.repo.GetByID(ctx, letters[i].ID)
+		if err != nil {
+			// Deleted concurrently, e.g. by another retry.
+			continue
+		}
+		if err := s.retry(ctx, letter); err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			result.Failed++
+			continue
+		}
+		result.Replayed++
+	}
+
+	s.logger.InfoContext(ctx, "Retried dead letters",
+		slog.Int("delivered", result.Replayed),
+		slog.Int("failed", result.Failed))
+
+	return result, nil
+}
+
+func (s *DeadLetters) Delete(ctx context.Context, id uuid.UUID) error {
+	return notFound(s.repo.Delete(ctx, id))
+}
+
+func (s *DeadLetters) Purge(ctx context.Context, filter models.DeadLetterFilter) (int64, error) {
+	return s.repo.Purge(ctx, filter)
+}
+
+func (s *DeadLetters) retry(ctx context.Context, letter *models.DeadLetter) error {
+	var event Event
+	if err := json.Unmarshal(letter.Payload, &event); err != nil {
+		return fmt.Errorf("decode dead letter %s: %w", letter.ID, err)
+	}
+
+	deliverErr := s.dispatcher.Deliver(ctx, letter.Sink, event)
+	if errors.Is(deliverErr, ErrUnknownSink) {
+		return deliverErr
+	}
+	if deliverErr == nil {
+		s.logger.InfoContext(ctx, "Delivered dead letter",
+			slog.String("dead_letter_id", letter.ID.String()),
+			slog.String("sink", letter.Sink))
+		return s.repo.Delete(ctx, letter.ID)
+	}
+
+	letter.Attempts++
+	letter.Error = deliverErr.Error()
+	letter.LastAttemptAt = time.Now().UTC()
+	if err := s.repo.UpdateAttempt(ctx, letter); err != nil {
+		return err
+	}
+	return deliverErr
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrDeadLetterNotFound
+	}
+	return err
+}
@@ -0,0 +1,203 @@
+// Package events publishes domain events about subscriptions to downstream consumers.
+// Events are derived from the audit log, which doubles as the outbox: every audit
+// entry becomes one event with the entry's ID, so consumers can deduplicate replays.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/notify"
+)
+
+var ErrUnknownSink = errors.New("unknown event sink")
+
+// Event types match the audit actions they are derived from.
+type Event struct {
+	ID             uuid.UUID       `json:"id"`
+	Type           string          `json:"type"`
+	SubscriptionID *uuid.UUID      `json:"subscription_id,omitempty"`
+	Actor          string          `json:"actor"`
+	Tenant         string          `json:"tenant,omitempty"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	Before         json.RawMessage `json:"before,omitempty"`
+	After          json.RawMessage `json:"after,omitempty"`
+	Replay         bool            `json:"replay,omitempty"`
+}
+
+func FromAudit(record models.AuditRecord) Event {
+	return Event{
+		ID:             record.ID,
+		Type:           record.Action,
+		SubscriptionID: record.SubscriptionID,
+		Actor:          record.Actor,
+		OccurredAt:     record.CreatedAt,
+		Before:         record.Before,
+		After:          record.After,
+	}
+}
+
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+type alerter interface {
+	Alert(ctx context.Context, category string, title string, text string)
+}
+
+type workerPool interface {
+	Submit(destination string, task func()) error
+}
+
+type deadLetterStore interface {
+	Create(ctx context.Context, letter *models.DeadLetter) error
+}
+
+// Dispatcher fans events out to named sinks and in-process listeners.
+type Dispatcher struct {
+	sinks       map[string]Sink
+	listeners   []func(ctx context.Context, event Event)
+	pool        workerPool
+	deadLetters deadLetterStore
+	alerts      alerter
+	logger      *slog.Logger
+}
+
+func NewDispatcher(pool workerPool, deadLetters deadLetterStore, alerts alerter, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		sinks:       make(map[string]Sink),
+		pool:        pool,
+		deadLetters: deadLetters,
+		alerts:      alerts,
+		logger:      logger,
+	}
+}
+
+func (d *Dispatcher) Register(name string, sink Sink) {
+	d.sinks[name] = sink
+}
+
+// Subscribe calls listener with every live event before it is handed to the sinks.
+// Listeners run on the writing request, so they must be fast; they are meant for
+// in-process bookkeeping such as cache invalidation, not for delivery.
+func (d *Dispatcher) Subscribe(listener func(ctx context.Context, event Event)) {
+	d.listeners = append(d.listeners, listener)
+}
+
+func (d *Dispatcher) Has(name string) bool {
+	_, ok := d.sinks[name]
+	return ok
+}
+
+func (d *Dispatcher) Sinks() []string {
+	names := make([]string, 0, len(d.sinks))
+	for name := range d.sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Emit publishes a live event to every sink in the background so that slow
+// consumers never delay the write that produced it. Each sink has its own queue in
+// the worker pool, so one slow sink does not hold up the others. Events a sink fails
+// to accept, or that do not fit in its queue, are stored as dead letters for manual
+// retry. Events are stamped with the tenant of the writing request, other than the
+// default one. Sandbox events only reach the listeners.
+func (d *Dispatcher) Emit(ctx context.Context, event Event) {
+	id := identity.FromContext(ctx)
+	if event.Tenant == "" && id.Tenant != identity.DefaultTenant {
+		event.Tenant = id.Tenant
+	}
+	for _, listener := range d.listeners {
+		listener(ctx, event)
+	}
+	if id.Sandbox() {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	for name, sink := range d.sinks {
+		err := d.pool.Submit("event:"+name, func() {
+			ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			if err := d.publish(ctx, name, sink, event); err != nil {
+				d.deadLetter(ctx, name, event, err)
+			}
+		})
+		if err != nil {
+			d.deadLetter(ctx, name, event, err)
+		}
+	}
+}
+
+// Deliver publishes event synchronously to the named sink, or to every sink when
+// sink is empty.
+func (d *Dispatcher) Deliver(ctx context.Context, sink string, event Event) error {
+	targets := d.sinks
+	if sink != "" {
+		target, ok := d.sinks[sink]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownSink, sink)
+		}
+		targets = map[string]Sink{sink: target}
+	}
+
+	var errs []error
+	for name, target := range targets {
+		if err := d.publish(ctx, name, target, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Dispatcher) publish(ctx context.Context, name string, sink Sink, event Event) error {
+	if err := sink.Publish(ctx, event); err != nil {
+		d.logger.ErrorContext(ctx, "Failed to deliver event",
+			slog.String("sink", name),
+			slog.String("event_id", event.ID.String()),
+			slog.String("event_type", event.Type),
+			slog.String("error", err.Error()))
+		d.alerts.Alert(ctx, notify.AlertWebhookDelivery,
+			fmt.Sprintf("Event delivery to %s failed", name), err.Error())
+		return err
+	}
+
+	d.logger.DebugContext(ctx, "Delivered event",
+		slog.String("sink", name),
+		slog.String("event_id", event.ID.String()),
+		slog.String("event_type", event.Type))
+	return nil
+}
+
+func (d *Dispatcher) deadLetter(ctx context.Context, sink string, event Event, cause error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	letter := &models.DeadLetter{
+		ID:            uuid.New(),
+		Sink:          sink,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		Error:         cause.Error(),
+		Attempts:      1,
+		CreatedAt:     now,
+		LastAttemptAt: now,
+	}
+	// The repository logs storage failures; there is nowhere left to put the event.
+	_ = d.deadLetters.Create(ctx, letter)
+}
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+
+	"awesomeProject1/internal/model"
+)
+
+const replayBatch = 500
+
+type history interface {
+	ForEachEventBatch(ctx context.Context, filter models.EventFilter, batchSize int, fn func(records []models.AuditRecord) error) error
+}
+
+// Replayer re-emits past events from the audit history, e.g. to bootstrap a new
+// downstream consumer.
+type Replayer struct {
+	history    history
+	dispatcher *Dispatcher
+	logger     *slog.Logger
+}
+
+func NewReplayer(history history, dispatcher *Dispatcher, logger *slog.Logger) *Replayer {
+	return &Replayer{
+		history:    history,
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+}
+
+// Replay delivers the matching events in the order they happened, marked as
+// replays, to sink (or every sink when empty). Delivery failures are counted and
+// the replay carries on; only reading the history aborts it.
+func (r *Replayer) Replay(ctx context.Context, filter models.EventFilter, sink string) (models.ReplayResult, error) {
+	r.logger.InfoContext(ctx, "Starting event replay",
+		slog.Any("filter", filter),
+		slog.String("sink", sink))
+
+	var result models.ReplayResult
+	if sink != "" && !r.dispatcher.Has(sink) {
+		return result, ErrUnknownSink
+	}
+
+	err := r.history.ForEachEventBatch(ctx, filter, replayBatch, func(records []models.AuditRecord) error {
+		for _, record := range records {
+			event := FromAudit(record)
+			event.Replay = true
+			if err := r.dispatcher.Deliver(ctx, sink, event); err != nil {
+				result.Failed++
+				continue
+			}
+			result.Replayed++
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Event replay failed",
+			slog.Int("replayed", result.Replayed),
+			slog.Int("failed", result.Failed),
+			slog.String("error", err.Error()))
+		return result, err
+	}
+
+	r.logger.InfoContext(ctx, "Completed event replay",
+		slog.Int("replayed", result.Replayed),
+		slog.Int("failed", result.Failed))
+
+	return result, nil
+}
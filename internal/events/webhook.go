@@ -0,0 +1,111 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"awesomeProject1/internal/model"
+)
+
+const (
+	SignatureHeader = "X-Signature"
+	TimestampHeader = "X-Timestamp"
+)
+
+// WebhookSink POSTs events as JSON. With a secret, requests are signed with
+// HMAC-SHA256 over "<timestamp>.<body>" so receivers can verify origin and freshness.
+type WebhookSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhookSink(url string, secret string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (w *WebhookSink) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.ID.String())
+	req.Header.Set("X-Event-Type", event.Type)
+
+	if w.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// TenantWebhooksSink is the name TenantWebhooks is registered under.
+const TenantWebhooksSink = "tenant-webhooks"
+
+type tenantWebhookStore interface {
+	Webhooks(ctx context.Context, tenant string) ([]models.TenantWebhook, error)
+}
+
+// TenantWebhooks delivers the events of each tenant to the webhooks registered for it
+// when it was onboarded, each signed with its own secret. Events without a tenant, such
+// as those replayed from the audit log, are not delivered.
+type TenantWebhooks struct {
+	store tenantWebhookStore
+}
+
+func NewTenantWebhooks(store tenantWebhookStore) *TenantWebhooks {
+	return &TenantWebhooks{store: store}
+}
+
+func (t *TenantWebhooks) Publish(ctx context.Context, event Event) error {
+	if event.Tenant == "" {
+		return nil
+	}
+	webhooks, err := t.store.Webhooks(ctx, event.Tenant)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, webhook := range webhooks {
+		if err := NewWebhookSink(webhook.URL, webhook.Secret).Publish(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", webhook.URL, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,369 @@
+// Package formula parses the aggregation formulas admins define, such as
+//
+//	category("streaming", "gaming") - service("Student discount") / 2
+//
+// Formulas are arithmetic over numbers and terms, each term being the total of the
+// subscriptions a filter matches. Parsing only accepts this grammar, and terms only
+// become aggregation filters, so a formula can neither reach SQL nor run code:
+//
+//	expr    = product { ("+" | "-") product }
+//	product = unary { ("*" | "/") unary }
+//	unary   = "-" unary | primary
+//	primary = number | term | "(" expr ")"
+//	term    = "total" "(" ")" | name "(" string { "," string } ")"
+package formula
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+// Functions a term can call, other than total, and the filter each of them sets.
+const (
+	Total    = "total"
+	Category = "category"
+	Service  = "service"
+	Kind     = "kind"
+)
+
+// Limits keeping formulas cheap to parse and to evaluate; every distinct term costs
+// one aggregation query.
+const (
+	MaxLength = 1000
+	MaxTerms  = 16
+	MaxDepth  = 32
+)
+
+// Quotients are rounded half away from zero to DivisionPlaces decimals.
+const DivisionPlaces = 2
+
+var (
+	ErrInvalid        = errors.New("invalid formula")
+	ErrDivisionByZero = errors.New("division by zero")
+)
+
+var kinds = []string{"recurring", "one_time", "lifetime"}
+
+// Term totals the subscriptions whose Func field is one of Args; total has no Args.
+type Term struct {
+	Func string
+	Args []string
+}
+
+// String renders the term in canonical form, with its arguments sorted, so that
+// equivalent terms are only aggregated once.
+func (t Term) String() string {
+	quoted := make([]string, len(t.Args))
+	for i, arg := range t.Args {
+		quoted[i] = strconv.Quote(arg)
+	}
+	return t.Func + "(" + strings.Join(quoted, ", ") + ")"
+}
+
+// Formula is a parsed formula.
+type Formula struct {
+	root  node
+	terms []Term
+}
+
+// Terms returns the distinct terms of the formula in order of appearance.
+func (f *Formula) Terms() []Term {
+	return f.terms
+}
+
+// Eval computes the formula from the totals of its terms, keyed by Term.String.
+func (f *Formula) Eval(values map[string]decimal.Decimal) (decimal.Decimal, error) {
+	return f.root.eval(values)
+}
+
+type node interface {
+	eval(values map[string]decimal.Decimal) (decimal.Decimal, error)
+}
+
+type number decimal.Decimal
+
+func (n number) eval(map[string]decimal.Decimal) (decimal.Decimal, error) {
+	return decimal.Decimal(n), nil
+}
+
+type termNode string
+
+func (t termNode) eval(values map[string]decimal.Decimal) (decimal.Decimal, error) {
+	value, ok := values[string(t)]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("no value for term %s", string(t))
+	}
+	return value, nil
+}
+
+type negation struct {
+	operand node
+}
+
+func (n negation) eval(values map[string]decimal.Decimal) (decimal.Decimal, error) {
+	value, err := n.operand.eval(values)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.Zero.Sub(value), nil
+}
+
+type binary struct {
+	op          byte
+	left, right node
+}
+
+func (b binary) eval(values map[string]decimal.Decimal) (decimal.Decimal, error) {
+	left, err := b.left.eval(values)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	right, err := b.right.eval(values)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	switch b.op {
+	case '+':
+		return left.Add(right), nil
+	case '-':
+		return left.Sub(right), nil
+	case '*':
+		return left.Mul(right), nil
+	default:
+		if right.Sign() == 0 {
+			return decimal.Zero, ErrDivisionByZero
+		}
+		return left.DivRound(right, DivisionPlaces), nil
+	}
+}
+
+// Parse parses expression, which must be at most MaxLength bytes long and have at most
+// MaxTerms distinct terms.
+func Parse(expression string) (*Formula, error) {
+	if len(expression) > MaxLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrInvalid, MaxLength)
+	}
+
+	p := &parser{input: expression, seen: make(map[string]bool)}
+	root, err := p.expr(0)
+	if err == nil {
+		p.skipSpace()
+		if p.pos < len(p.input) {
+			err = p.errorf("unexpected %q", p.input[p.pos])
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Formula{root: root, terms: p.terms}, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+	terms []Term
+	seen  map[string]bool
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: at %d: %s", ErrInvalid, p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek skips whitespace and returns the next byte, or 0 at the end of the input.
+func (p *parser) peek() byte {
+	p.skipSpace()
+	if p.pos == len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) expect(c byte) error {
+	if p.peek() != c {
+		if p.pos == len(p.input) {
+			return p.errorf("expected %q, got end of formula", c)
+		}
+		return p.errorf("expected %q, got %q", c, p.input[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) expr(depth int) (node, error) {
+	if depth > MaxDepth {
+		return nil, p.errorf("nested deeper than %d", MaxDepth)
+	}
+	left, err := p.product(depth)
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		right, err := p.product(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) product(depth int) (node, error) {
+	left, err := p.unary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/'; op = p.peek() {
+		p.pos++
+		right, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary(depth int) (node, error) {
+	if p.peek() == '-' {
+		if depth > MaxDepth {
+			return nil, p.errorf("nested deeper than %d", MaxDepth)
+		}
+		p.pos++
+		operand, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return negation{operand: operand}, nil
+	}
+	return p.primary(depth)
+}
+
+func (p *parser) primary(depth int) (node, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, p.errorf("unexpected end of formula")
+	case c == '(':
+		p.pos++
+		inner, err := p.expr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(')')
+	case c >= '0' && c <= '9' || c == '.':
+		return p.number()
+	case c >= 'a' && c <= 'z':
+		return p.term()
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *parser) number() (node, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+		p.pos++
+	}
+	value, err := decimal.NewFromString(p.input[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid number %q", p.input[start:p.pos])
+	}
+	return number(value), nil
+}
+
+func (p *parser) term() (node, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (p.input[p.pos] >= 'a' && p.input[p.pos] <= 'z' || p.input[p.pos] == '_') {
+		p.pos++
+	}
+	term := Term{Func: p.input[start:p.pos]}
+	switch term.Func {
+	case Total, Category, Service, Kind:
+	default:
+		p.pos = start
+		return nil, p.errorf("unknown function %q", term.Func)
+	}
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+
+	for p.peek() != ')' {
+		if len(term.Args) > 0 {
+			if err := p.expect(','); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		if term.Func == Kind && !slices.Contains(kinds, arg) {
+			return nil, p.errorf("unknown kind %q", arg)
+		}
+		if !slices.Contains(term.Args, arg) {
+			term.Args = append(term.Args, arg)
+		}
+	}
+	p.pos++
+
+	switch {
+	case term.Func == Total && len(term.Args) > 0:
+		return nil, p.errorf("total takes no arguments")
+	case term.Func != Total && len(term.Args) == 0:
+		return nil, p.errorf("%s needs at least one argument", term.Func)
+	}
+	slices.Sort(term.Args)
+
+	key := term.String()
+	if !p.seen[key] {
+		if len(p.terms) == MaxTerms {
+			return nil, p.errorf("more than %d distinct terms", MaxTerms)
+		}
+		p.seen[key] = true
+		p.terms = append(p.terms, term)
+	}
+	return termNode(key), nil
+}
+
+// str reads a string quoted with " or ', in which a backslash escapes the next
+// character.
+func (p *parser) str() (string, error) {
+	quote := p.peek()
+	if quote != '"' && quote != '\'' {
+		return "", p.errorf("expected a quoted string")
+	}
+	p.pos++
+
+	var value strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			if value.Len() == 0 {
+				return "", p.errorf("empty string")
+			}
+			return value.String(), nil
+		case c == '\\' && p.pos < len(p.input):
+			value.WriteByte(p.input[p.pos])
+			p.pos++
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
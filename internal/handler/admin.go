@@ -0,0 +1,560 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/backup"
+	"awesomeProject1/internal/events"
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/service"
+)
+
+type AdminHandler struct {
+	backup     BackupService
+	audit      AuditLog
+	usage      UsageReporter
+	duplicates DuplicateFinder
+	mail       MailLog
+	events     EventReplayer
+	jobs       AsyncJobRunner
+	logger     *slog.Logger
+}
+
+type BackupService interface {
+	Export(ctx context.Context, w io.Writer) (int, error)
+	Import(ctx context.Context, r io.Reader) (int, error)
+	ExportAnonymized(ctx context.Context, w io.Writer, format string) (int, error)
+}
+
+// anonymizedExportFiles are the file extension and media type of each export format.
+var anonymizedExportFiles = map[string]struct {
+	extension   string
+	contentType string
+}{
+	backup.ExportJSONL:   {"jsonl.gz", "application/gzip"},
+	backup.ExportParquet: {"parquet", "application/vnd.apache.parquet"},
+}
+
+type AuditLog interface {
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditRecord, error)
+}
+
+type UsageReporter interface {
+	Report(ctx context.Context, filter models.UsageFilter) ([]models.UsageReportRow, error)
+}
+
+type DuplicateFinder interface {
+	Duplicates(ctx context.Context, minSimilarity float64, limit int) ([]models.DuplicatePair, error)
+}
+
+type MailLog interface {
+	List(ctx context.Context, filter models.MailFilter) ([]models.MailDelivery, error)
+}
+
+type EventReplayer interface {
+	Replay(ctx context.Context, filter models.EventFilter, sink string) (models.ReplayResult, error)
+}
+
+func NewAdminHandler(backup BackupService, audit AuditLog, usage UsageReporter, duplicates DuplicateFinder, mail MailLog, events EventReplayer, jobs AsyncJobRunner, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{
+		backup:     backup,
+		audit:      audit,
+		usage:      usage,
+		duplicates: duplicates,
+		mail:       mail,
+		events:     events,
+		jobs:       jobs,
+		logger:     logger,
+	}
+}
+
+func (h *AdminHandler) Backup(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting subscriptions backup",
+		slog.String("request_id", requestID),
+		slog.String("method", "Backup"),
+		slog.String("client_ip", c.ClientIP()))
+
+	filename := fmt.Sprintf("subscriptions-%s.jsonl.gz", time.Now().UTC().Format("20060102-150405"))
+	if preferAsync(c) {
+		acceptJob(c, h.jobs, h.logger, requestID, "backup", "bytes", &service.AsyncFile{Name: filename, ContentType: "application/gzip"},
+			func(ctx context.Context, run *service.AsyncRun) (any, error) {
+				count, err := h.backup.Export(ctx, run.Output())
+				return gin.H{"count": count}, err
+			})
+		return
+	}
+
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	count, err := h.backup.Export(c.Request.Context(), c.Writer)
+	if err != nil {
+		// Headers are already flushed, so the client only sees a truncated stream.
+		h.logger.Error("Backup export failed",
+			slog.String("request_id", requestID),
+			slog.Int("written", count),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return
+	}
+
+	h.logger.Info("Successfully streamed subscriptions backup",
+		slog.String("request_id", requestID),
+		slog.Int("count", count),
+		slog.Duration("duration", time.Since(start)))
+}
+
+func (h *AdminHandler) Restore(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting subscriptions restore",
+		slog.String("request_id", requestID),
+		slog.String("method", "Restore"),
+		slog.String("client_ip", c.ClientIP()))
+
+	if preferAsync(c) {
+		h.restoreAsync(c, requestID)
+		return
+	}
+
+	count, err := h.backup.Import(c.Request.Context(), c.Request.Body)
+	if err != nil {
+		h.logger.Error("Backup import failed",
+			slog.String("request_id", requestID),
+			slog.Int("restored", count),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		body := i18n.ErrorBody(c, "invalid_backup", err.Error())
+		body["restored"] = count
+		c.JSON(http.StatusBadRequest, body)
+		return
+	}
+
+	h.logger.Info("Successfully restored subscriptions backup",
+		slog.String("request_id", requestID),
+		slog.Int("count", count),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"restored": count})
+}
+
+// restoreAsync spools the backup to a temporary file, as the request body is gone
+// once the response is sent, and restores it in a job.
+func (h *AdminHandler) restoreAsync(c *gin.Context, requestID string) {
+	spool, err := os.CreateTemp("", "restore-*.jsonl.gz")
+	if err == nil {
+		_, err = io.Copy(spool, c.Request.Body)
+	}
+	if err != nil {
+		h.logger.Error("Failed to spool backup for async restore",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()))
+
+		if spool != nil {
+			spool.Close()
+			os.Remove(spool.Name())
+		}
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "submit_job_failed"))
+		return
+	}
+
+	accepted := acceptJob(c, h.jobs, h.logger, requestID, "restore", "bytes", nil,
+		func(ctx context.Context, run *service.AsyncRun) (any, error) {
+			defer os.Remove(spool.Name())
+			defer spool.Close()
+
+			size, err := spool.Seek(0, io.SeekEnd)
+			if err == nil {
+				_, err = spool.Seek(0, io.SeekStart)
+			}
+			if err != nil {
+				return nil, err
+			}
+			run.SetTotal(size)
+
+			count, err := h.backup.Import(ctx, run.Reader(spool))
+			return gin.H{"restored": count}, err
+		})
+	if !accepted {
+		spool.Close()
+		os.Remove(spool.Name())
+	}
+}
+
+func (h *AdminHandler) ExportAnonymized(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting anonymized subscriptions export",
+		slog.String("request_id", requestID),
+		slog.String("method", "ExportAnonymized"),
+		slog.String("client_ip", c.ClientIP()))
+
+	format := c.DefaultQuery("format", backup.ExportJSONL)
+	file, ok := anonymizedExportFiles[format]
+	if !ok {
+		h.logger.Warn("Invalid export format provided",
+			slog.String("request_id", requestID),
+			slog.String("format", format))
+
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "format"))
+		return
+	}
+
+	filename := fmt.Sprintf("subscriptions-anonymized-%s.%s", time.Now().UTC().Format("20060102-150405"), file.extension)
+	if preferAsync(c) {
+		acceptJob(c, h.jobs, h.logger, requestID, "anonymized_export", "bytes", &service.AsyncFile{Name: filename, ContentType: file.contentType},
+			func(ctx context.Context, run *service.AsyncRun) (any, error) {
+				count, err := h.backup.ExportAnonymized(ctx, run.Output(), format)
+				return gin.H{"count": count, "format": format}, err
+			})
+		return
+	}
+
+	c.Header("Content-Type", file.contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	count, err := h.backup.ExportAnonymized(c.Request.Context(), c.Writer, format)
+	if err != nil {
+		h.logger.Error("Anonymized export failed",
+			slog.String("request_id", requestID),
+			slog.Int("written", count),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		if !c.Writer.Written() {
+			// c.JSON keeps a Content-Type already set, so the file's must go too.
+			c.Header("Content-Type", "")
+			c.Header("Content-Disposition", "")
+			if errors.Is(err, backup.ErrSaltNotConfigured) {
+				c.JSON(http.StatusServiceUnavailable, i18n.ErrorBody(c, "anonymization_salt_missing"))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "export_failed"))
+		}
+		return
+	}
+
+	h.logger.Info("Successfully exported anonymized subscriptions",
+		slog.String("request_id", requestID),
+		slog.Int("count", count),
+		slog.String("format", format),
+		slog.Duration("duration", time.Since(start)))
+}
+
+func (h *AdminHandler) Audit(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting audit log listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "Audit"),
+		slog.String("client_ip", c.ClientIP()))
+
+	filter := models.AuditFilter{
+		Actor: c.Query("actor"),
+		Limit: 100,
+	}
+
+	if limitParam := c.Query("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "limit"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if subjectParam := c.Query("subject_id"); subjectParam != "" {
+		subjectID, err := uuid.Parse(subjectParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "subject_id"))
+			return
+		}
+		filter.SubjectID = &subjectID
+	}
+
+	if subscriptionParam := c.Query("subscription_id"); subscriptionParam != "" {
+		subscriptionID, err := uuid.Parse(subscriptionParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "subscription_id"))
+			return
+		}
+		filter.SubscriptionID = &subscriptionID
+	}
+
+	records, err := h.audit.List(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Audit log listing failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_audit_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved audit log",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(records)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, records)
+}
+
+func (h *AdminHandler) Usage(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting API usage report",
+		slog.String("request_id", requestID),
+		slog.String("method", "Usage"),
+		slog.String("client_ip", c.ClientIP()))
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	filter := models.UsageFilter{
+		From:       today.AddDate(0, 0, -30),
+		To:         today,
+		APIKey:     c.Query("key"),
+		ByEndpoint: c.Query("by_endpoint") == "true",
+	}
+
+	if fromParam := c.Query("from"); fromParam != "" {
+		from, err := time.Parse(time.DateOnly, fromParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_date_param", "from"))
+			return
+		}
+		filter.From = from
+	}
+
+	if toParam := c.Query("to"); toParam != "" {
+		to, err := time.Parse(time.DateOnly, toParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_date_param", "to"))
+			return
+		}
+		filter.To = to
+	}
+
+	rows, err := h.usage.Report(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("API usage report failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "usage_report_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully built API usage report",
+		slog.String("request_id", requestID),
+		slog.Int("rows", len(rows)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":  filter.From.Format(time.DateOnly),
+		"to":    filter.To.Format(time.DateOnly),
+		"usage": rows,
+	})
+}
+
+func (h *AdminHandler) Duplicates(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting duplicate subscription report",
+		slog.String("request_id", requestID),
+		slog.String("method", "Duplicates"),
+		slog.String("client_ip", c.ClientIP()))
+
+	minSimilarity := 0.6
+	if param := c.Query("min_similarity"); param != "" {
+		value, err := strconv.ParseFloat(param, 64)
+		if err != nil || value <= 0 || value > 1 {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "min_similarity"))
+			return
+		}
+		minSimilarity = value
+	}
+
+	limit := 100
+	if param := c.Query("limit"); param != "" {
+		value, err := strconv.Atoi(param)
+		if err != nil || value <= 0 {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "limit"))
+			return
+		}
+		limit = value
+	}
+
+	pairs, err := h.duplicates.Duplicates(c.Request.Context(), minSimilarity, limit)
+	if err != nil {
+		h.logger.Error("Duplicate subscription report failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "duplicates_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully built duplicate subscription report",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(pairs)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"min_similarity": minSimilarity,
+		"duplicates":     pairs,
+	})
+}
+
+func (h *AdminHandler) Mail(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting mail delivery log listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "Mail"),
+		slog.String("client_ip", c.ClientIP()))
+
+	filter := models.MailFilter{
+		Status: c.Query("status"),
+		Limit:  100,
+	}
+
+	switch filter.Status {
+	case "", models.MailPending, models.MailSent, models.MailFailed:
+	default:
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "status"))
+		return
+	}
+
+	if limitParam := c.Query("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "limit"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	deliveries, err := h.mail.List(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Mail delivery log listing failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_mail_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved mail delivery log",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(deliveries)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+func (h *AdminHandler) ReplayEvents(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting event replay",
+		slog.String("request_id", requestID),
+		slog.String("method", "ReplayEvents"),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		From            time.Time   `json:"from" binding:"required"`
+		To              *time.Time  `json:"to,omitempty"`
+		SubscriptionIDs []uuid.UUID `json:"subscription_ids,omitempty"`
+		UserID          *uuid.UUID  `json:"user_id,omitempty"`
+		Types           []string    `json:"types,omitempty"`
+		Sink            string      `json:"sink,omitempty"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for event replay",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	filter := models.EventFilter{
+		From:            req.From,
+		To:              time.Now().UTC(),
+		SubscriptionIDs: req.SubscriptionIDs,
+		UserID:          req.UserID,
+		Types:           req.Types,
+	}
+	if req.To != nil {
+		filter.To = *req.To
+	}
+	if !filter.To.After(filter.From) {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_event_range"))
+		return
+	}
+
+	if preferAsync(c) {
+		acceptJob(c, h.jobs, h.logger, requestID, "event_replay", "events", nil,
+			func(ctx context.Context, run *service.AsyncRun) (any, error) {
+				result, err := h.events.Replay(ctx, filter, req.Sink)
+				run.SetProgress(int64(result.Replayed + result.Failed))
+				return result, err
+			})
+		return
+	}
+
+	result, err := h.events.Replay(c.Request.Context(), filter, req.Sink)
+	if err != nil {
+		h.logger.Error("Event replay failed",
+			slog.String("request_id", requestID),
+			slog.Int("replayed", result.Replayed),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		if errors.Is(err, events.ErrUnknownSink) {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "unknown_event_sink"))
+			return
+		}
+		body := i18n.ErrorBody(c, "replay_events_failed")
+		body["replayed"] = result.Replayed
+		body["failed"] = result.Failed
+		c.JSON(http.StatusInternalServerError, body)
+		return
+	}
+
+	h.logger.Info("Successfully replayed events",
+		slog.String("request_id", requestID),
+		slog.Int("replayed", result.Replayed),
+		slog.Int("failed", result.Failed),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, result)
+}
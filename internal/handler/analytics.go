@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+const (
+	defaultTopServices = 10
+	maxTopServices     = 100
+)
+
+type AnalyticsHandler struct {
+	analytics AnalyticsService
+	logger    *slog.Logger
+}
+
+type AnalyticsService interface {
+	TopServices(ctx context.Context, startDateStr string, endDateStr string, limit int) ([]models.ServiceRanking, error)
+	Compare(ctx context.Context, startA string, endA string, startB string, endB string) (*models.SpendComparison, error)
+}
+
+func NewAnalyticsHandler(analytics AnalyticsService, logger *slog.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analytics: analytics,
+		logger:    logger,
+	}
+}
+
+func (h *AnalyticsHandler) TopServices(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting top services report",
+		slog.String("request_id", requestID),
+		slog.String("method", "TopServices"),
+		slog.String("client_ip", c.ClientIP()))
+
+	limit := defaultTopServices
+	if limitParam := c.Query("limit"); limitParam != "" {
+		n, err := strconv.Atoi(limitParam)
+		if err != nil || n <= 0 || n > maxTopServices {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "limit"))
+			return
+		}
+		limit = n
+	}
+
+	startDate, endDate := reportPeriod(c.Query("period"))
+
+	rankings, err := h.analytics.TopServices(c.Request.Context(), startDate, endDate, limit)
+	if err != nil {
+		h.logger.Error("Top services report failed",
+			slog.String("request_id", requestID),
+			slog.String("start_date", startDate),
+			slog.String("end_date", endDate),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "analytics_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully built top services report",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(rankings)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date": startDate,
+		"end_date":   endDate,
+		"services":   rankings,
+	})
+}
+
+// Compare reports the spend of period_b against period_a, both required and written
+// like the period of TopServices, e.g. period_a=01-2025..03-2025&period_b=04-2025..06-2025.
+func (h *AnalyticsHandler) Compare(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting period comparison report",
+		slog.String("request_id", requestID),
+		slog.String("method", "Compare"),
+		slog.String("client_ip", c.ClientIP()))
+
+	for _, param := range []string{"period_a", "period_b"} {
+		if c.Query(param) == "" {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "validation_required", param))
+			return
+		}
+	}
+	startA, endA := reportPeriod(c.Query("period_a"))
+	startB, endB := reportPeriod(c.Query("period_b"))
+
+	comparison, err := h.analytics.Compare(c.Request.Context(), startA, endA, startB, endB)
+	if err != nil {
+		h.logger.Error("Period comparison report failed",
+			slog.String("request_id", requestID),
+			slog.String("period_a", c.Query("period_a")),
+			slog.String("period_b", c.Query("period_b")),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "analytics_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully built period comparison report",
+		slog.String("request_id", requestID),
+		slog.Int("services", len(comparison.Services)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"period_a":       gin.H{"start_date": startA, "end_date": endA},
+		"period_b":       gin.H{"start_date": startB, "end_date": endB},
+		"total_a":        comparison.TotalA,
+		"total_b":        comparison.TotalB,
+		"change":         comparison.Change,
+		"change_percent": comparison.ChangePercent,
+		"services":       comparison.Services,
+	})
+}
+
+// reportPeriod parses period=MM-YYYY..MM-YYYY (or a single MM-YYYY month) and defaults
+// to the twelve months ending with the current one. Dates are validated by the service.
+func reportPeriod(period string) (string, string) {
+	if period == "" {
+		now := time.Now().UTC()
+		return now.AddDate(0, -11, 0).Format(monthYearLayout), now.Format(monthYearLayout)
+	}
+
+	startDate, endDate, ok := strings.Cut(period, "..")
+	if !ok {
+		return period, period
+	}
+	return startDate, endDate
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+const (
+	defaultAnomalies = 100
+	maxAnomalies     = 1000
+)
+
+type AnomalyHandler struct {
+	anomalies AnomalyService
+	logger    *slog.Logger
+}
+
+type AnomalyService interface {
+	List(ctx context.Context, userID uuid.UUID, page models.Page) ([]models.Anomaly, error)
+}
+
+func NewAnomalyHandler(anomalies AnomalyService, logger *slog.Logger) *AnomalyHandler {
+	return &AnomalyHandler{
+		anomalies: anomalies,
+		logger:    logger,
+	}
+}
+
+func (h *AnomalyHandler) List(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting user anomaly listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "ListAnomalies"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	userID, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_user_id"))
+		return
+	}
+
+	page := models.Page{Limit: defaultAnomalies}
+	if limitParam := c.Query("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 || limit > maxAnomalies {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "limit"))
+			return
+		}
+		page.Limit = limit
+	}
+	if offsetParam := c.Query("offset"); offsetParam != "" {
+		offset, err := strconv.Atoi(offsetParam)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "offset"))
+			return
+		}
+		page.Offset = offset
+	}
+
+	anomalies, err := h.anomalies.List(c.Request.Context(), userID, page)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, i18n.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		h.logger.Error("AnomalyService.List failed",
+			slog.String("request_id", requestID),
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_anomalies_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved user anomalies",
+		slog.String("request_id", requestID),
+		slog.String("user_id", userID.String()),
+		slog.Int("count", len(anomalies)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "anomalies": anomalies})
+}
@@ -0,0 +1,438 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+type CategoryHandler struct {
+	categories CategoryService
+	logger     *slog.Logger
+}
+
+type CategoryService interface {
+	Create(ctx context.Context, category *models.Category) error
+	Get(ctx context.Context, id uuid.UUID) (*models.Category, error)
+	Update(ctx context.Context, category *models.Category) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, page models.Page) ([]models.Category, error)
+	ListCatalog(ctx context.Context) ([]models.CatalogEntry, error)
+	SaveCatalogEntry(ctx context.Context, serviceName string, category string) (*models.CatalogEntry, error)
+	DeleteCatalogEntry(ctx context.Context, serviceName string) error
+	ListServiceAliases(ctx context.Context) ([]models.ServiceAlias, error)
+	SaveServiceAlias(ctx context.Context, alias string, serviceName string) (*models.ServiceAlias, error)
+	DeleteServiceAlias(ctx context.Context, alias string) error
+}
+
+func NewCategoryHandler(categories CategoryService, logger *slog.Logger) *CategoryHandler {
+	return &CategoryHandler{
+		categories: categories,
+		logger:     logger,
+	}
+}
+
+// List returns the whole taxonomy; it is small enough not to need paging.
+func (h *CategoryHandler) List(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting category listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "ListCategories"),
+		slog.String("client_ip", c.ClientIP()))
+
+	categories, err := h.categories.List(c.Request.Context(), models.Page{})
+	if err != nil {
+		h.logger.Error("CategoryService.List failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_categories_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved categories",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(categories)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
+func (h *CategoryHandler) Get(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting category retrieval",
+		slog.String("request_id", requestID),
+		slog.String("method", "GetCategory"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_category_id"))
+		return
+	}
+
+	category, err := h.categories.Get(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("CategoryService.Get failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(categoryError(c, err, "get_category_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved category",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, category)
+}
+
+func (h *CategoryHandler) Create(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting category creation",
+		slog.String("request_id", requestID),
+		slog.String("method", "CreateCategory"),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		Slug string `json:"slug" binding:"required"`
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for category",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	category := &models.Category{Slug: req.Slug, Name: req.Name}
+	if err := h.categories.Create(c.Request.Context(), category); err != nil {
+		h.logger.Error("CategoryService.Create failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "create_category_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully created category",
+		slog.String("request_id", requestID),
+		slog.String("category_id", category.ID.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusCreated, category)
+}
+
+func (h *CategoryHandler) Update(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting category update",
+		slog.String("request_id", requestID),
+		slog.String("method", "UpdateCategory"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_category_id"))
+		return
+	}
+
+	var req struct {
+		Slug string `json:"slug" binding:"required"`
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for category update",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	category := &models.Category{ID: id, Slug: req.Slug, Name: req.Name}
+	if err := h.categories.Update(c.Request.Context(), category); err != nil {
+		h.logger.Error("CategoryService.Update failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(categoryError(c, err, "update_category_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully updated category",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, category)
+}
+
+// Delete removes a category. Subscriptions using it fall back to the catalog and
+// catalog entries using it are removed.
+func (h *CategoryHandler) Delete(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting category deletion",
+		slog.String("request_id", requestID),
+		slog.String("method", "DeleteCategory"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_category_id"))
+		return
+	}
+
+	if err := h.categories.Delete(c.Request.Context(), id); err != nil {
+		h.logger.Error("CategoryService.Delete failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(categoryError(c, err, "delete_category_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully deleted category",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CategoryHandler) ListCatalog(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting catalog listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "ListCatalog"),
+		slog.String("client_ip", c.ClientIP()))
+
+	entries, err := h.categories.ListCatalog(c.Request.Context())
+	if err != nil {
+		h.logger.Error("CategoryService.ListCatalog failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_catalog_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved catalog",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(entries)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"catalog": entries})
+}
+
+func (h *CategoryHandler) SaveCatalogEntry(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	serviceName := c.Param("service_name")
+
+	h.logger.Info("Starting catalog entry save",
+		slog.String("request_id", requestID),
+		slog.String("method", "SaveCatalogEntry"),
+		slog.String("service_name", serviceName),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		Category string `json:"category" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for catalog entry",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	entry, err := h.categories.SaveCatalogEntry(c.Request.Context(), serviceName, req.Category)
+	if err != nil {
+		h.logger.Error("CategoryService.SaveCatalogEntry failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "save_catalog_entry_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully saved catalog entry",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *CategoryHandler) DeleteCatalogEntry(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	serviceName := c.Param("service_name")
+
+	h.logger.Info("Starting catalog entry deletion",
+		slog.String("request_id", requestID),
+		slog.String("method", "DeleteCatalogEntry"),
+		slog.String("service_name", serviceName),
+		slog.String("client_ip", c.ClientIP()))
+
+	if err := h.categories.DeleteCatalogEntry(c.Request.Context(), serviceName); err != nil {
+		h.logger.Error("CategoryService.DeleteCatalogEntry failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "delete_catalog_entry_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully deleted catalog entry",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CategoryHandler) ListServiceAliases(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting service alias listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "ListServiceAliases"),
+		slog.String("client_ip", c.ClientIP()))
+
+	aliases, err := h.categories.ListServiceAliases(c.Request.Context())
+	if err != nil {
+		h.logger.Error("CategoryService.ListServiceAliases failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_service_aliases_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved service aliases",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(aliases)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"aliases": aliases})
+}
+
+func (h *CategoryHandler) SaveServiceAlias(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	alias := c.Param("alias")
+
+	h.logger.Info("Starting service alias save",
+		slog.String("request_id", requestID),
+		slog.String("method", "SaveServiceAlias"),
+		slog.String("alias", alias),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		ServiceName string `json:"service_name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for service alias",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	entry, err := h.categories.SaveServiceAlias(c.Request.Context(), alias, req.ServiceName)
+	if err != nil {
+		h.logger.Error("CategoryService.SaveServiceAlias failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "save_service_alias_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully saved service alias",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *CategoryHandler) DeleteServiceAlias(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	alias := c.Param("alias")
+
+	h.logger.Info("Starting service alias deletion",
+		slog.String("request_id", requestID),
+		slog.String("method", "DeleteServiceAlias"),
+		slog.String("alias", alias),
+		slog.String("client_ip", c.ClientIP()))
+
+	if err := h.categories.DeleteServiceAlias(c.Request.Context(), alias); err != nil {
+		h.logger.Error("CategoryService.DeleteServiceAlias failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "delete_service_alias_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully deleted service alias",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.Status(http.StatusNoContent)
+}
+
+// categoryError maps a missing category to category_not_found; serviceError would
+// report it as a missing subscription.
+func categoryError(c *gin.Context, err error, fallbackCode string) (int, gin.H) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound, i18n.ErrorBody(c, "category_not_found")
+	}
+	return serviceError(c, err, http.StatusInternalServerError, fallbackCode)
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+type CustomFieldHandler struct {
+	fields CustomFieldService
+	logger *slog.Logger
+}
+
+type CustomFieldService interface {
+	List(ctx context.Context, tenant string) ([]models.CustomField, error)
+	Save(ctx context.Context, field *models.CustomField) error
+	Delete(ctx context.Context, tenant string, name string) error
+}
+
+func NewCustomFieldHandler(fields CustomFieldService, logger *slog.Logger) *CustomFieldHandler {
+	return &CustomFieldHandler{
+		fields: fields,
+		logger: logger,
+	}
+}
+
+func (h *CustomFieldHandler) List(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	tenant := templateTenant(c)
+
+	h.logger.Info("Starting custom field listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "ListCustomFields"),
+		slog.String("tenant", tenant),
+		slog.String("client_ip", c.ClientIP()))
+
+	fields, err := h.fields.List(c.Request.Context(), tenant)
+	if err != nil {
+		h.logger.Error("CustomFieldService.List failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_custom_fields_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved custom fields",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(fields)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"tenant": tenant, "custom_fields": fields})
+}
+
+func (h *CustomFieldHandler) Save(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	tenant := templateTenant(c)
+	name := c.Param("name")
+
+	h.logger.Info("Starting custom field save",
+		slog.String("request_id", requestID),
+		slog.String("method", "SaveCustomField"),
+		slog.String("tenant", tenant),
+		slog.String("field", name),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		Type     string `json:"type" binding:"required,oneof=string number boolean date"`
+		Required bool   `json:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for custom field",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	field := &models.CustomField{
+		Tenant:   tenant,
+		Name:     name,
+		Type:     req.Type,
+		Required: req.Required,
+	}
+	if err := h.fields.Save(c.Request.Context(), field); err != nil {
+		h.logger.Error("CustomFieldService.Save failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "save_custom_field_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully saved custom field",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, field)
+}
+
+func (h *CustomFieldHandler) Delete(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	tenant := templateTenant(c)
+	name := c.Param("name")
+
+	h.logger.Info("Starting custom field deletion",
+		slog.String("request_id", requestID),
+		slog.String("method", "DeleteCustomField"),
+		slog.String("tenant", tenant),
+		slog.String("field", name),
+		slog.String("client_ip", c.ClientIP()))
+
+	if err := h.fields.Delete(c.Request.Context(), tenant, name); err != nil {
+		h.logger.Error("CustomFieldService.Delete failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "delete_custom_field_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully deleted custom field",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+type DashboardHandler struct {
+	dashboard Dashboard
+	logger    *slog.Logger
+}
+
+type Dashboard interface {
+	Snapshot(ctx context.Context) (*models.Dashboard, error)
+}
+
+func NewDashboardHandler(dashboard Dashboard, logger *slog.Logger) *DashboardHandler {
+	return &DashboardHandler{
+		dashboard: dashboard,
+		logger:    logger,
+	}
+}
+
+func (h *DashboardHandler) Get(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting admin dashboard retrieval",
+		slog.String("request_id", requestID),
+		slog.String("method", "Get"),
+		slog.String("client_ip", c.ClientIP()))
+
+	dashboard, err := h.dashboard.Snapshot(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Admin dashboard retrieval failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "dashboard_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved admin dashboard",
+		slog.String("request_id", requestID),
+		slog.String("health", dashboard.Health.Status),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, dashboard)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+type DatabaseStatsHandler struct {
+	stats  DatabaseStatsReader
+	logger *slog.Logger
+}
+
+type DatabaseStatsReader interface {
+	DatabaseStats(ctx context.Context) (*models.DatabaseStats, error)
+}
+
+func NewDatabaseStatsHandler(stats DatabaseStatsReader, logger *slog.Logger) *DatabaseStatsHandler {
+	return &DatabaseStatsHandler{
+		stats:  stats,
+		logger: logger,
+	}
+}
+
+// Get reports the table sizes, row estimates and dead tuples PostgreSQL keeps in its
+// statistics views. The numbers are estimates and lag behind recent writes.
+func (h *DatabaseStatsHandler) Get(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting database stats report",
+		slog.String("request_id", requestID),
+		slog.String("method", "DatabaseStats"),
+		slog.String("client_ip", c.ClientIP()))
+
+	stats, err := h.stats.DatabaseStats(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Database stats report failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "db_stats_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully built database stats report",
+		slog.String("request_id", requestID),
+		slog.Int("tables", len(stats.Tables)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/events"
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/service"
+)
+
+type DeadLetterHandler struct {
+	deadLetters DeadLetterService
+	jobs        AsyncJobRunner
+	logger      *slog.Logger
+}
+
+type DeadLetterService interface {
+	List(ctx context.Context, filter models.DeadLetterFilter) ([]models.DeadLetter, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.DeadLetter, error)
+	Retry(ctx context.Context, id uuid.UUID) error
+	RetryAll(ctx context.Context, filter models.DeadLetterFilter) (models.ReplayResult, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Purge(ctx context.Context, filter models.DeadLetterFilter) (int64, error)
+}
+
+func NewDeadLetterHandler(deadLetters DeadLetterService, jobs AsyncJobRunner, logger *slog.Logger) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetters: deadLetters,
+		jobs:        jobs,
+		logger:      logger,
+	}
+}
+
+func (h *DeadLetterHandler) List(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting dead letter listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "ListDeadLetters"),
+		slog.String("client_ip", c.ClientIP()))
+
+	filter, ok := deadLetterFilterFromQuery(c)
+	if !ok {
+		return
+	}
+	filter.Limit = 100
+	if limitParam := c.Query("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "limit"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	letters, err := h.deadLetters.List(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Dead letter listing failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_dead_letters_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved dead letters",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(letters)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"dead_letters": letters})
+}
+
+func (h *DeadLetterHandler) Get(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting dead letter retrieval",
+		slog.String("request_id", requestID),
+		slog.String("method", "GetDeadLetter"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_dead_letter_id"))
+		return
+	}
+
+	letter, err := h.deadLetters.Get(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Dead letter retrieval failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(deadLetterError(c, err, "get_dead_letter_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved dead letter",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, letter)
+}
+
+func (h *DeadLetterHandler) Retry(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting dead letter retry",
+		slog.String("request_id", requestID),
+		slog.String("method", "RetryDeadLetter"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_dead_letter_id"))
+		return
+	}
+
+	if err := h.deadLetters.Retry(c.Request.Context(), id); err != nil {
+		h.logger.Error("Dead letter retry failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(deadLetterError(c, err, "retry_dead_letter_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully delivered dead letter",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"delivered": true})
+}
+
+func (h *DeadLetterHandler) RetryAll(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting bulk dead letter retry",
+		slog.String("request_id", requestID),
+		slog.String("method", "RetryDeadLetters"),
+		slog.String("client_ip", c.ClientIP()))
+
+	filter, ok := deadLetterFilterFromQuery(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.deadLetters.RetryAll(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Bulk dead letter retry failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "retry_dead_letter_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retried dead letters",
+		slog.String("request_id", requestID),
+		slog.Int("delivered", result.Replayed),
+		slog.Int("failed", result.Failed),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"delivered": result.Replayed, "failed": result.Failed})
+}
+
+func (h *DeadLetterHandler) Delete(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting dead letter deletion",
+		slog.String("request_id", requestID),
+		slog.String("method", "DeleteDeadLetter"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_dead_letter_id"))
+		return
+	}
+
+	if err := h.deadLetters.Delete(c.Request.Context(), id); err != nil {
+		h.logger.Error("Dead letter deletion failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(deadLetterError(c, err, "delete_dead_letter_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully deleted dead letter",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.Status(http.StatusNoContent)
+}
+
+// Purge deletes every dead letter matching the query filters. At least one filter is
+// required so that an empty request cannot wipe the table by accident.
+func (h *DeadLetterHandler) Purge(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting dead letter purge",
+		slog.String("request_id", requestID),
+		slog.String("method", "PurgeDeadLetters"),
+		slog.String("client_ip", c.ClientIP()))
+
+	filter, ok := deadLetterFilterFromQuery(c)
+	if !ok {
+		return
+	}
+	if filter.Sink == "" && filter.EventType == "" && filter.Before == nil && c.Query("all") != "true" {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "purge_filter_required"))
+		return
+	}
+
+	if preferAsync(c) {
+		acceptJob(c, h.jobs, h.logger, requestID, "dead_letter_purge", "dead_letters", nil,
+			func(ctx context.Context, run *service.AsyncRun) (any, error) {
+				purged, err := h.deadLetters.Purge(ctx, filter)
+				run.SetProgress(purged)
+				return gin.H{"purged": purged}, err
+			})
+		return
+	}
+
+	purged, err := h.deadLetters.Purge(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Dead letter purge failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "purge_dead_letters_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully purged dead letters",
+		slog.String("request_id", requestID),
+		slog.Int64("purged", purged),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
+
+func deadLetterFilterFromQuery(c *gin.Context) (models.DeadLetterFilter, bool) {
+	filter := models.DeadLetterFilter{
+		Sink:      c.Query("sink"),
+		EventType: c.Query("type"),
+	}
+
+	if beforeParam := c.Query("before"); beforeParam != "" {
+		before, err := time.Parse(time.RFC3339, beforeParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "before"))
+			return filter, false
+		}
+		filter.Before = &before
+	}
+
+	return filter, true
+}
+
+func deadLetterError(c *gin.Context, err error, fallbackCode string) (int, gin.H) {
+	switch {
+	case errors.Is(err, events.ErrDeadLetterNotFound):
+		return http.StatusNotFound, i18n.ErrorBody(c, "dead_letter_not_found")
+	case errors.Is(err, events.ErrUnknownSink):
+		return http.StatusConflict, i18n.ErrorBody(c, "unknown_event_sink")
+	default:
+		return http.StatusBadGateway, i18n.ErrorBody(c, fallbackCode)
+	}
+}
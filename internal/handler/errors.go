@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/formula"
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/service"
+	"awesomeProject1/internal/tenancy"
+)
+
+// Report JSON field names in validation errors so localized messages match the request body.
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+var serviceErrorCodes = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{service.ErrInvalidStartDate, http.StatusBadRequest, "invalid_start_date"},
+	{service.ErrInvalidEndDate, http.StatusBadRequest, "invalid_end_date"},
+	{service.ErrEndBeforeStart, http.StatusBadRequest, "end_before_start"},
+	{service.ErrSubjectMismatch, http.StatusBadRequest, "subject_mismatch"},
+	{service.ErrAlreadyCancelled, http.StatusConflict, "subscription_already_cancelled"},
+	{service.ErrInvalidBucket, http.StatusBadRequest, "invalid_bucket"},
+	{service.ErrInvalidMode, http.StatusBadRequest, "invalid_mode"},
+	{service.ErrMergeTooFew, http.StatusBadRequest, "merge_too_few"},
+	{service.ErrMergeUserMismatch, http.StatusConflict, "merge_user_mismatch"},
+	{service.ErrMergeServiceMismatch, http.StatusConflict, "merge_service_mismatch"},
+	{service.ErrMergeNotContiguous, http.StatusConflict, "merge_not_contiguous"},
+	{service.ErrMergeKindMismatch, http.StatusConflict, "merge_kind_mismatch"},
+	{service.ErrInvalidSplitDate, http.StatusBadRequest, "invalid_split_date"},
+	{service.ErrSplitOutOfRange, http.StatusBadRequest, "split_out_of_range"},
+	{service.ErrInvalidSimulation, http.StatusBadRequest, "invalid_simulation_change"},
+	{service.ErrScheduledNotFuture, http.StatusBadRequest, "scheduled_not_future"},
+	{service.ErrSubscriptionLimit, http.StatusUnprocessableEntity, "subscription_limit_reached"},
+	{service.ErrLimitOverrideDenied, http.StatusForbidden, "limit_override_forbidden"},
+	{service.ErrUnknownChannel, http.StatusBadRequest, "unknown_channel"},
+	{service.ErrNotRecurring, http.StatusBadRequest, "reminder_not_recurring"},
+	{service.ErrReminderNotFound, http.StatusNotFound, "reminder_not_found"},
+	{service.ErrNoReminderDefaults, http.StatusBadRequest, "reminder_defaults_missing"},
+	{service.ErrPreferencesNotFound, http.StatusNotFound, "preferences_not_found"},
+	{service.ErrUnsupportedCurrency, http.StatusBadRequest, "unsupported_currency"},
+	{service.ErrInvalidTimezone, http.StatusBadRequest, "invalid_timezone"},
+	{service.ErrUnknownCustomField, http.StatusBadRequest, "unknown_custom_field"},
+	{service.ErrInvalidCustomField, http.StatusBadRequest, "invalid_custom_field"},
+	{service.ErrMissingCustomField, http.StatusBadRequest, "missing_custom_field"},
+	{service.ErrInvalidCustomFieldDefinition, http.StatusBadRequest, "invalid_custom_field_definition"},
+	{service.ErrCustomFieldNotFound, http.StatusNotFound, "custom_field_not_found"},
+	{service.ErrUnknownCategory, http.StatusBadRequest, "unknown_category"},
+	{service.ErrInvalidCategorySlug, http.StatusBadRequest, "invalid_category_slug"},
+	{service.ErrCategoryExists, http.StatusConflict, "category_exists"},
+	{service.ErrCatalogEntryNotFound, http.StatusNotFound, "catalog_entry_not_found"},
+	{service.ErrInvalidCatalogService, http.StatusBadRequest, "invalid_catalog_service"},
+	{service.ErrServiceAliasNotFound, http.StatusNotFound, "service_alias_not_found"},
+	{tenancy.ErrInvalidName, http.StatusBadRequest, "invalid_tenant_name"},
+	{service.ErrTenantExists, http.StatusConflict, "tenant_exists"},
+	{service.ErrUnknownTenant, http.StatusNotFound, "tenant_not_found"},
+	{service.ErrTenantIsolationDisabled, http.StatusConflict, "tenant_isolation_disabled"},
+	{service.ErrSecretEncryptionOff, http.StatusConflict, "secret_encryption_disabled"},
+	{service.ErrInvalidTolerance, http.StatusBadRequest, "invalid_tolerance"},
+	{service.ErrFormulaNotFound, http.StatusNotFound, "formula_not_found"},
+	{formula.ErrDivisionByZero, http.StatusUnprocessableEntity, "formula_division_by_zero"},
+	{service.ErrAsyncJobNotFound, http.StatusNotFound, "job_not_found"},
+	{service.ErrAsyncJobNoFile, http.StatusConflict, "job_result_unavailable"},
+	{service.ErrAsyncJobsClosed, http.StatusServiceUnavailable, "jobs_shutting_down"},
+	{service.ErrInvalidDownloadURL, http.StatusForbidden, "invalid_download_url"},
+	{service.ErrDownloadURLExpired, http.StatusGone, "download_url_expired"},
+}
+
+// serviceError maps a service-layer error onto an HTTP status and localized payload.
+// Known domain errors get their own status and, when the service provides one, a
+// localized remediation hint; missing records 404; anything else keeps the caller's
+// status with a generic message so internal details are not leaked.
+func serviceError(c *gin.Context, err error, status int, fallbackCode string) (int, gin.H) {
+	for _, known := range serviceErrorCodes {
+		if errors.Is(err, known.err) {
+			body := i18n.ErrorBody(c, known.code)
+			if hint, ok := service.HintFor(err); ok {
+				body["hint"] = i18n.Message(c, hint.Code, hint.Args...)
+			}
+			return known.status, body
+		}
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound, i18n.ErrorBody(c, "subscription_not_found")
+	}
+
+	return status, i18n.ErrorBody(c, fallbackCode)
+}
+
+func bindError(c *gin.Context, err error) gin.H {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) && len(validationErrs) > 0 {
+		fieldErr := validationErrs[0]
+		switch fieldErr.Tag() {
+		case "required":
+			return i18n.ErrorBody(c, "validation_required", fieldErr.Field())
+		case "gt":
+			return i18n.ErrorBody(c, "validation_gt", fieldErr.Field(), fieldErr.Param())
+		default:
+			return i18n.ErrorBody(c, "validation_invalid", fieldErr.Field())
+		}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return i18n.ErrorBody(c, "validation_invalid", typeErr.Field)
+	}
+
+	return i18n.ErrorBody(c, "invalid_json")
+}
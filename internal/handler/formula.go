@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/service"
+)
+
+type FormulaHandler struct {
+	formulas FormulaService
+	logger   *slog.Logger
+}
+
+type FormulaService interface {
+	List(ctx context.Context, tenant string) ([]models.AggregationFormula, error)
+	Save(ctx context.Context, formula *models.AggregationFormula) error
+	Delete(ctx context.Context, tenant string, name string) error
+	Run(ctx context.Context, name string, startDateStr string, endDateStr string, mode string) (*models.FormulaResult, error)
+}
+
+func NewFormulaHandler(formulas FormulaService, logger *slog.Logger) *FormulaHandler {
+	return &FormulaHandler{
+		formulas: formulas,
+		logger:   logger,
+	}
+}
+
+func (h *FormulaHandler) List(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	tenant := templateTenant(c)
+
+	h.logger.Info("Starting aggregation formula listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "ListFormulas"),
+		slog.String("tenant", tenant),
+		slog.String("client_ip", c.ClientIP()))
+
+	formulas, err := h.formulas.List(c.Request.Context(), tenant)
+	if err != nil {
+		h.logger.Error("FormulaService.List failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_formulas_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved aggregation formulas",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(formulas)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"tenant": tenant, "formulas": formulas})
+}
+
+func (h *FormulaHandler) Save(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	tenant := templateTenant(c)
+	name := c.Param("name")
+
+	h.logger.Info("Starting aggregation formula save",
+		slog.String("request_id", requestID),
+		slog.String("method", "SaveFormula"),
+		slog.String("tenant", tenant),
+		slog.String("formula", name),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		Expression  string `json:"expression" binding:"required"`
+		Description string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for aggregation formula",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	formula := &models.AggregationFormula{
+		Tenant:      tenant,
+		Name:        name,
+		Expression:  req.Expression,
+		Description: req.Description,
+	}
+	if err := h.formulas.Save(c.Request.Context(), formula); err != nil {
+		h.logger.Error("FormulaService.Save failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(formulaError(c, err, "save_formula_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully saved aggregation formula",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, formula)
+}
+
+func (h *FormulaHandler) Delete(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	tenant := templateTenant(c)
+	name := c.Param("name")
+
+	h.logger.Info("Starting aggregation formula deletion",
+		slog.String("request_id", requestID),
+		slog.String("method", "DeleteFormula"),
+		slog.String("tenant", tenant),
+		slog.String("formula", name),
+		slog.String("client_ip", c.ClientIP()))
+
+	if err := h.formulas.Delete(c.Request.Context(), tenant, name); err != nil {
+		h.logger.Error("FormulaService.Delete failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(formulaError(c, err, "delete_formula_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully deleted aggregation formula",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.Status(http.StatusNoContent)
+}
+
+// Run evaluates a formula of the caller's tenant over the period in the query.
+func (h *FormulaHandler) Run(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	name := c.Param("name")
+
+	h.logger.Info("Starting aggregation formula run",
+		slog.String("request_id", requestID),
+		slog.String("method", "RunFormula"),
+		slog.String("formula", name),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		StartDate string `form:"start_date" binding:"required"`
+		EndDate   string `form:"end_date" binding:"required"`
+		Mode      string `form:"mode" binding:"omitempty,oneof=normalized exact"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Failed to bind query for aggregation formula run",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	result, err := h.formulas.Run(c.Request.Context(), name, req.StartDate, req.EndDate, req.Mode)
+	if err != nil {
+		h.logger.Error("FormulaService.Run failed",
+			slog.String("request_id", requestID),
+			slog.String("start_date", req.StartDate),
+			slog.String("end_date", req.EndDate),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(formulaError(c, err, "run_formula_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully ran aggregation formula",
+		slog.String("request_id", requestID),
+		slog.String("value", result.Value.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, result)
+}
+
+// formulaError reports where an invalid formula went wrong and maps other errors like
+// serviceError.
+func formulaError(c *gin.Context, err error, fallbackCode string) (int, gin.H) {
+	if errors.Is(err, service.ErrInvalidFormula) {
+		detail := strings.TrimPrefix(err.Error(), service.ErrInvalidFormula.Error()+": ")
+		return http.StatusBadRequest, i18n.ErrorBody(c, "invalid_formula", detail)
+	}
+	return serviceError(c, err, http.StatusInternalServerError, fallbackCode)
+}
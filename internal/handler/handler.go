@@ -2,36 +2,108 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/identity"
 	"awesomeProject1/internal/model"
 )
 
 type SubscriptionHandler struct {
-	service SubscriptionService
-	logger  *slog.Logger
+	service    SubscriptionService
+	currencies CurrencyPreferences
+	pages      PageSizes
+	logger     *slog.Logger
 }
 
 type SubscriptionService interface {
-	Create(ctx context.Context, serviceName string, price int, userID uuid.UUID, startDateStr string, endDateStr string) (*models.Subscription, error)
+	Create(ctx context.Context, serviceName string, price int, userID uuid.UUID, kind string, billingPeriod string, billingAnchorDay int, startDateStr string, endDateStr string, status string, category string, customFields map[string]any, overrideLimit bool) (*models.Subscription, error)
+	CreateBatch(ctx context.Context, drafts []models.SubscriptionDraft, overrideLimit bool) ([]models.Subscription, []error, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
-	Update(ctx context.Context, id uuid.UUID, serviceName string, price int, startDateStr string, endDateStr string) (*models.Subscription, error)
+	Update(ctx context.Context, id uuid.UUID, serviceName string, price int, kind string, billingPeriod string, billingAnchorDay int, startDateStr string, endDateStr string, category *string, customFields map[string]any) (*models.Subscription, error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, userID uuid.UUID, serviceName string) ([]models.Subscription, error)
-	Aggregate(ctx context.Context, startDateStr string, endDateStr string, userID *uuid.UUID, serviceName *string) (int, error)
+	Cancel(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	Merge(ctx context.Context, ids []uuid.UUID) (*models.Subscription, error)
+	MergePair(ctx context.Context, id uuid.UUID, otherID uuid.UUID) (*models.Subscription, error)
+	Split(ctx context.Context, id uuid.UUID, at string, price int, userID uuid.UUID) (*models.Subscription, *models.Subscription, error)
+	Undo(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	List(ctx context.Context, filter models.ListFilter, page models.Page) ([]models.Subscription, error)
+	Count(ctx context.Context, filter models.ListFilter) (int64, error)
+	Facets(ctx context.Context, filter models.ListFilter) (*models.FacetSummary, error)
+	Aggregate(ctx context.Context, startDateStr string, endDateStr string, mode string, filter models.AggregateFilter) (decimal.Decimal, error)
+	AggregateByBucket(ctx context.Context, startDateStr string, endDateStr string, bucket string, mode string, filter models.AggregateFilter) ([]models.BucketTotal, error)
+	AggregateByCategory(ctx context.Context, startDateStr string, endDateStr string, mode string, filter models.AggregateFilter) ([]models.CategoryTotal, error)
+	AggregateDetails(ctx context.Context, startDateStr string, endDateStr string, mode string, filter models.AggregateFilter) (*models.AggregateBreakdown, error)
+	Stats(ctx context.Context, filter models.AggregateFilter) (*models.SubscriptionStats, error)
+	Simulate(ctx context.Context, userID uuid.UUID, startDateStr string, endDateStr string, mode string, changes []models.SimulationChange) (*models.SimulationResult, error)
 }
 
-func NewSubscriptionHandler(service SubscriptionService, logger *slog.Logger) *SubscriptionHandler {
+// maxBatchSize is the number of subscriptions a batch create accepts.
+const maxBatchSize = 500
+
+type createSubscriptionRequest struct {
+	ServiceName      string         `json:"service_name" binding:"required"`
+	Price            int            `json:"price" binding:"required,gt=0"`
+	UserID           uuid.UUID      `json:"user_id" binding:"required"`
+	Kind             string         `json:"kind,omitempty" binding:"omitempty,oneof=recurring one_time lifetime"`
+	BillingPeriod    string         `json:"billing_period,omitempty" binding:"omitempty,oneof=weekly monthly quarterly yearly"`
+	BillingAnchorDay int            `json:"billing_anchor_day,omitempty" binding:"omitempty,min=1,max=31"`
+	StartDate        string         `json:"start_date" binding:"required"`
+	EndDate          string         `json:"end_date,omitempty"`
+	Status           string         `json:"status,omitempty" binding:"omitempty,oneof=active scheduled"`
+	Category         string         `json:"category,omitempty"`
+	CustomFields     map[string]any `json:"custom_fields,omitempty"`
+}
+
+func (r createSubscriptionRequest) draft() models.SubscriptionDraft {
+	return models.SubscriptionDraft{
+		ServiceName:      r.ServiceName,
+		Price:            r.Price,
+		UserID:           r.UserID,
+		Kind:             r.Kind,
+		BillingPeriod:    r.BillingPeriod,
+		BillingAnchorDay: r.BillingAnchorDay,
+		StartDate:        r.StartDate,
+		EndDate:          r.EndDate,
+		Status:           r.Status,
+		Category:         r.Category,
+		CustomFields:     r.CustomFields,
+	}
+}
+
+// batchItem reports the outcome of one subscription of a batch create, by its position
+// in the request.
+type batchItem struct {
+	Index        int   `json:"index"`
+	Status       int   `json:"status"`
+	Subscription any   `json:"subscription,omitempty"`
+	Error        gin.H `json:"error,omitempty"`
+}
+
+// CurrencyPreferences gives the currency a user prefers amounts in and its rate to
+// prices.
+type CurrencyPreferences interface {
+	Currency(ctx context.Context, userID uuid.UUID) (string, decimal.Decimal, error)
+}
+
+func NewSubscriptionHandler(service SubscriptionService, currencies CurrencyPreferences, pages PageSizes, logger *slog.Logger) *SubscriptionHandler {
 	return &SubscriptionHandler{
-		service: service,
-		logger:  logger,
+		service:    service,
+		currencies: currencies,
+		pages:      pages,
+		logger:     logger,
 	}
 }
 
@@ -45,13 +117,7 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 		slog.String("client_ip", c.ClientIP()),
 		slog.String("user_agent", c.GetHeader("User-Agent")))
 
-	var req struct {
-		ServiceName string    `json:"service_name" binding:"required"`
-		Price       int       `json:"price" binding:"required,gt=0"`
-		UserID      uuid.UUID `json:"user_id" binding:"required"`
-		StartDate   string    `json:"start_date" binding:"required"`
-		EndDate     string    `json:"end_date,omitempty"`
-	}
+	var req createSubscriptionRequest
 
 	h.logger.Debug("Attempting to bind JSON request",
 		slog.String("request_id", requestID))
@@ -62,7 +128,7 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 			slog.String("error", err.Error()),
 			slog.Duration("duration", time.Since(start)))
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, bindError(c, err))
 		return
 	}
 
@@ -71,20 +137,26 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 		slog.String("service_name", req.ServiceName),
 		slog.Int("price", req.Price),
 		slog.String("user_id", req.UserID.String()),
+		slog.String("kind", req.Kind),
+		slog.String("billing_period", req.BillingPeriod),
+		slog.Int("billing_anchor_day", req.BillingAnchorDay),
 		slog.String("start_date", req.StartDate),
-		slog.String("end_date", req.EndDate))
+		slog.String("end_date", req.EndDate),
+		slog.String("status", req.Status))
 
 	h.logger.Debug("Calling service.Create",
 		slog.String("request_id", requestID))
 
-	sub, err := h.service.Create(c.Request.Context(), req.ServiceName, req.Price, req.UserID, req.StartDate, req.EndDate)
+	// Admins bulk-loading a user can lift the active subscription limit.
+	overrideLimit := c.Query("override_limit") == "true"
+	sub, err := h.service.Create(c.Request.Context(), req.ServiceName, req.Price, req.UserID, req.Kind, req.BillingPeriod, req.BillingAnchorDay, req.StartDate, req.EndDate, req.Status, req.Category, req.CustomFields, overrideLimit)
 	if err != nil {
 		h.logger.Error("Service.Create failed",
 			slog.String("request_id", requestID),
 			slog.String("error", err.Error()),
 			slog.Duration("duration", time.Since(start)))
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(serviceError(c, err, http.StatusBadRequest, "create_subscription_failed"))
 		return
 	}
 
@@ -93,7 +165,102 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 		slog.String("subscription_id", sub.ID.String()),
 		slog.Duration("duration", time.Since(start)))
 
-	c.JSON(http.StatusCreated, sub)
+	respondSubscription(c, http.StatusCreated, sub)
+}
+
+// CreateBatch creates the subscriptions of a JSON array in one transaction. Each one is
+// validated as by Create; when any is invalid none is created, and the response gives
+// the error of every invalid one and 424 for the others.
+func (h *SubscriptionHandler) CreateBatch(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting batch subscription creation",
+		slog.String("request_id", requestID),
+		slog.String("method", "CreateBatch"),
+		slog.String("client_ip", c.ClientIP()),
+		slog.String("user_agent", c.GetHeader("User-Agent")))
+
+	// Items are validated one by one below, so that every invalid one is reported.
+	var reqs []createSubscriptionRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&reqs); err != nil {
+		h.logger.Error("Failed to decode batch request",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+	if len(reqs) == 0 || len(reqs) > maxBatchSize {
+		h.logger.Error("Batch size out of range",
+			slog.String("request_id", requestID),
+			slog.Int("count", len(reqs)),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_batch_size", maxBatchSize))
+		return
+	}
+
+	items := make([]batchItem, len(reqs))
+	drafts := make([]models.SubscriptionDraft, len(reqs))
+	invalid := 0
+	for i, req := range reqs {
+		items[i] = batchItem{Index: i, Status: http.StatusFailedDependency}
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			items[i].Status, items[i].Error = http.StatusBadRequest, bindError(c, err)
+			invalid++
+		}
+		drafts[i] = req.draft()
+	}
+
+	var subs []models.Subscription
+	if invalid == 0 {
+		overrideLimit := c.Query("override_limit") == "true"
+		var itemErrs []error
+		var err error
+		subs, itemErrs, err = h.service.CreateBatch(c.Request.Context(), drafts, overrideLimit)
+		if err != nil {
+			h.logger.Error("Service.CreateBatch failed",
+				slog.String("request_id", requestID),
+				slog.String("error", err.Error()),
+				slog.Duration("duration", time.Since(start)))
+
+			c.JSON(serviceError(c, err, http.StatusInternalServerError, "create_subscriptions_failed"))
+			return
+		}
+		for i, itemErr := range itemErrs {
+			if itemErr != nil {
+				items[i].Status, items[i].Error = serviceError(c, itemErr, http.StatusBadRequest, "create_subscription_failed")
+				invalid++
+			}
+		}
+	}
+
+	if invalid > 0 {
+		h.logger.Warn("Rejected batch with invalid subscriptions",
+			slog.String("request_id", requestID),
+			slog.Int("count", len(reqs)),
+			slog.Int("invalid", invalid),
+			slog.Duration("duration", time.Since(start)))
+
+		body := i18n.ErrorBody(c, "batch_rejected", invalid)
+		body["items"] = items
+		c.JSON(http.StatusUnprocessableEntity, body)
+		return
+	}
+
+	for i := range subs {
+		items[i].Status = http.StatusCreated
+		items[i].Subscription = newSubscriptionResource(c, &subs[i])
+	}
+
+	h.logger.Info("Successfully created subscription batch",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(subs)),
+		slog.Duration("duration", time.Since(start)))
+
+	respondVersioned(c, http.StatusCreated, gin.H{"created": len(subs), "items": items})
 }
 
 func (h *SubscriptionHandler) GetByID(c *gin.Context) {
@@ -119,7 +286,7 @@ func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 			slog.String("error", err.Error()),
 			slog.Duration("duration", time.Since(start)))
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription ID"})
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_subscription_id"))
 		return
 	}
 
@@ -135,7 +302,7 @@ func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 				slog.String("subscription_id", id.String()),
 				slog.Duration("duration", time.Since(start)))
 
-			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+			c.JSON(http.StatusNotFound, i18n.ErrorBody(c, "subscription_not_found"))
 			return
 		}
 
@@ -145,7 +312,7 @@ func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 			slog.String("error", err.Error()),
 			slog.Duration("duration", time.Since(start)))
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get subscription"})
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "get_subscription_failed"))
 		return
 	}
 
@@ -155,7 +322,7 @@ func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 		slog.String("service_name", sub.ServiceName),
 		slog.Duration("duration", time.Since(start)))
 
-	c.JSON(http.StatusOK, sub)
+	respondSubscription(c, http.StatusOK, sub)
 }
 
 func (h *SubscriptionHandler) Update(c *gin.Context) {
@@ -177,15 +344,20 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 			slog.String("error", err.Error()),
 			slog.Duration("duration", time.Since(start)))
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription ID"})
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_subscription_id"))
 		return
 	}
 
 	var req struct {
-		ServiceName string `json:"service_name,omitempty"`
-		Price       int    `json:"price,omitempty"`
-		StartDate   string `json:"start_date,omitempty"`
-		EndDate     string `json:"end_date,omitempty"`
+		ServiceName      string         `json:"service_name,omitempty"`
+		Price            int            `json:"price,omitempty"`
+		Kind             string         `json:"kind,omitempty" binding:"omitempty,oneof=recurring one_time lifetime"`
+		BillingPeriod    string         `json:"billing_period,omitempty" binding:"omitempty,oneof=weekly monthly quarterly yearly"`
+		BillingAnchorDay int            `json:"billing_anchor_day,omitempty" binding:"omitempty,min=1,max=31"`
+		StartDate        string         `json:"start_date,omitempty"`
+		EndDate          string         `json:"end_date,omitempty"`
+		Category         *string        `json:"category,omitempty"`
+		CustomFields     map[string]any `json:"custom_fields,omitempty"`
 	}
 
 	h.logger.Debug("Attempting to bind JSON request for update",
@@ -199,7 +371,7 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 			slog.String("error", err.Error()),
 			slog.Duration("duration", time.Since(start)))
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, bindError(c, err))
 		return
 	}
 
@@ -208,6 +380,9 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 		slog.String("subscription_id", id.String()),
 		slog.String("service_name", req.ServiceName),
 		slog.Int("price", req.Price),
+		slog.String("kind", req.Kind),
+		slog.String("billing_period", req.BillingPeriod),
+		slog.Int("billing_anchor_day", req.BillingAnchorDay),
 		slog.String("start_date", req.StartDate),
 		slog.String("end_date", req.EndDate))
 
@@ -215,7 +390,7 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 		slog.String("request_id", requestID),
 		slog.String("subscription_id", id.String()))
 
-	sub, err := h.service.Update(c.Request.Context(), id, req.ServiceName, req.Price, req.StartDate, req.EndDate)
+	sub, err := h.service.Update(c.Request.Context(), id, req.ServiceName, req.Price, req.Kind, req.BillingPeriod, req.BillingAnchorDay, req.StartDate, req.EndDate, req.Category, req.CustomFields)
 	if err != nil {
 		h.logger.Error("Service.Update failed",
 			slog.String("request_id", requestID),
@@ -223,7 +398,7 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 			slog.String("error", err.Error()),
 			slog.Duration("duration", time.Since(start)))
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(serviceError(c, err, http.StatusBadRequest, "update_subscription_failed"))
 		return
 	}
 
@@ -232,7 +407,7 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 		slog.String("subscription_id", id.String()),
 		slog.Duration("duration", time.Since(start)))
 
-	c.JSON(http.StatusOK, sub)
+	respondSubscription(c, http.StatusOK, sub)
 }
 
 func (h *SubscriptionHandler) Delete(c *gin.Context) {
@@ -254,7 +429,7 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 			slog.String("error", err.Error()),
 			slog.Duration("duration", time.Since(start)))
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription ID"})
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_subscription_id"))
 		return
 	}
 
@@ -269,7 +444,7 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 				slog.String("subscription_id", id.String()),
 				slog.Duration("duration", time.Since(start)))
 
-			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+			c.JSON(http.StatusNotFound, i18n.ErrorBody(c, "subscription_not_found"))
 			return
 		}
 
@@ -279,7 +454,7 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 			slog.String("error", err.Error()),
 			slog.Duration("duration", time.Since(start)))
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete subscription"})
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "delete_subscription_failed"))
 		return
 	}
 
@@ -291,19 +466,281 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+func (h *SubscriptionHandler) Cancel(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting subscription cancellation",
+		slog.String("request_id", requestID),
+		slog.String("method", "Cancel"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		h.logger.Error("Failed to parse UUID for cancellation",
+			slog.String("request_id", requestID),
+			slog.String("id_param", idParam),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_subscription_id"))
+		return
+	}
+
+	h.logger.Debug("Calling service.Cancel",
+		slog.String("request_id", requestID),
+		slog.String("subscription_id", id.String()))
+
+	sub, err := h.service.Cancel(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Service.Cancel failed",
+			slog.String("request_id", requestID),
+			slog.String("subscription_id", id.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "cancel_subscription_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully cancelled subscription",
+		slog.String("request_id", requestID),
+		slog.String("subscription_id", id.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	respondSubscription(c, http.StatusOK, sub)
+}
+
+func (h *SubscriptionHandler) Merge(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting subscription merge",
+		slog.String("request_id", requestID),
+		slog.String("method", "Merge"),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		IDs []uuid.UUID `json:"ids" binding:"required,min=2,max=20"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for merge",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	h.logger.Debug("Calling service.Merge",
+		slog.String("request_id", requestID),
+		slog.Any("subscription_ids", req.IDs))
+
+	sub, err := h.service.Merge(c.Request.Context(), req.IDs)
+	if err != nil {
+		h.logger.Error("Service.Merge failed",
+			slog.String("request_id", requestID),
+			slog.Any("subscription_ids", req.IDs),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "merge_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully merged subscriptions",
+		slog.String("request_id", requestID),
+		slog.String("subscription_id", sub.ID.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	respondSubscription(c, http.StatusOK, sub)
+}
+
+func (h *SubscriptionHandler) MergePair(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+	otherIDParam := c.Param("other_id")
+
+	h.logger.Info("Starting subscription pair merge",
+		slog.String("request_id", requestID),
+		slog.String("method", "MergePair"),
+		slog.String("id_param", idParam),
+		slog.String("other_id_param", otherIDParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_subscription_id"))
+		return
+	}
+	otherID, err := uuid.Parse(otherIDParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_subscription_id"))
+		return
+	}
+
+	sub, err := h.service.MergePair(c.Request.Context(), id, otherID)
+	if err != nil {
+		h.logger.Error("Service.MergePair failed",
+			slog.String("request_id", requestID),
+			slog.String("subscription_id", id.String()),
+			slog.String("other_id", otherID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "merge_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully merged subscription pair",
+		slog.String("request_id", requestID),
+		slog.String("subscription_id", sub.ID.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	respondSubscription(c, http.StatusOK, sub)
+}
+
+func (h *SubscriptionHandler) Split(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting subscription split",
+		slog.String("request_id", requestID),
+		slog.String("method", "Split"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_subscription_id"))
+		return
+	}
+
+	var req struct {
+		At     string    `json:"at" binding:"required"`
+		Price  int       `json:"price" binding:"omitempty,gt=0"`
+		UserID uuid.UUID `json:"user_id"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for split",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	before, after, err := h.service.Split(c.Request.Context(), id, req.At, req.Price, req.UserID)
+	if err != nil {
+		h.logger.Error("Service.Split failed",
+			slog.String("request_id", requestID),
+			slog.String("subscription_id", id.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "split_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully split subscription",
+		slog.String("request_id", requestID),
+		slog.String("subscription_id", before.ID.String()),
+		slog.String("new_subscription_id", after.ID.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	respondVersioned(c, http.StatusCreated, gin.H{
+		"before": newSubscriptionResource(c, before),
+		"after":  newSubscriptionResource(c, after),
+	})
+}
+
+func (h *SubscriptionHandler) Undo(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting subscription undo",
+		slog.String("request_id", requestID),
+		slog.String("method", "Undo"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		h.logger.Error("Failed to parse UUID for undo",
+			slog.String("request_id", requestID),
+			slog.String("id_param", idParam),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_subscription_id"))
+		return
+	}
+
+	h.logger.Debug("Calling service.Undo",
+		slog.String("request_id", requestID),
+		slog.String("subscription_id", id.String()))
+
+	sub, err := h.service.Undo(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.logger.Warn("No restorable subscription in trash",
+				slog.String("request_id", requestID),
+				slog.String("subscription_id", id.String()),
+				slog.Duration("duration", time.Since(start)))
+
+			c.JSON(http.StatusNotFound, i18n.ErrorBody(c, "subscription_not_in_trash"))
+			return
+		}
+
+		h.logger.Error("Service.Undo failed",
+			slog.String("request_id", requestID),
+			slog.String("subscription_id", id.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "restore_subscription_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully restored subscription",
+		slog.String("request_id", requestID),
+		slog.String("subscription_id", id.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	respondSubscription(c, http.StatusOK, sub)
+}
+
 func (h *SubscriptionHandler) List(c *gin.Context) {
 	start := time.Now()
 	requestID := uuid.New().String()
 	userIDParam := c.Query("user_id")
 	serviceName := c.Query("service_name")
+	kind := c.Query("kind")
+	category := c.Query("category")
 
 	h.logger.Info("Starting subscription listing",
 		slog.String("request_id", requestID),
 		slog.String("method", "List"),
 		slog.String("user_id_param", userIDParam),
 		slog.String("service_name", serviceName),
+		slog.String("kind", kind),
+		slog.String("category", category),
 		slog.String("client_ip", c.ClientIP()))
 
+	if kind != "" && !validKind(kind) {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "kind"))
+		return
+	}
+
 	userID, parseErr := uuid.Parse(userIDParam)
 	if parseErr != nil && userIDParam != "" {
 		h.logger.Warn("Invalid user_id parameter provided",
@@ -312,12 +749,89 @@ func (h *SubscriptionHandler) List(c *gin.Context) {
 			slog.String("parse_error", parseErr.Error()))
 	}
 
+	var page models.Page
+	for _, param := range []struct {
+		name   string
+		target *int
+	}{{"limit", &page.Limit}, {"offset", &page.Offset}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			h.logger.Warn("Invalid pagination parameter provided",
+				slog.String("request_id", requestID),
+				slog.String("param", param.name),
+				slog.String("value", value))
+
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", param.name))
+			return
+		}
+		*param.target = n
+	}
+
+	var withFacets bool
+	switch c.Query("facets") {
+	case "", "false":
+	case "true":
+		withFacets = true
+	default:
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "facets"))
+		return
+	}
+
+	if sortBy, order := c.Query("sort_by"), c.Query("order"); sortBy != "" || order != "" {
+		sort, badParam := listOrder(sortBy, order)
+		if badParam != "" {
+			h.logger.Warn("Invalid sort parameter provided",
+				slog.String("request_id", requestID),
+				slog.String("param", badParam),
+				slog.String("sort_by", sortBy),
+				slog.String("order", order))
+
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", badParam))
+			return
+		}
+		page.OrderBy = []models.Order{sort}
+	}
+
+	// OData options, used by BI tools, take over paging and switch the response shape.
+	odata := wantsOData(c)
+	sized := c.Query("limit") != ""
+	var options odataQuery
+	if odata {
+		var badParam string
+		var err error
+		options, badParam, err = parseODataQuery(c)
+		if err != nil {
+			h.logger.Warn("Invalid OData query option provided",
+				slog.String("request_id", requestID),
+				slog.String("param", badParam),
+				slog.String("error", err.Error()))
+
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", badParam))
+			return
+		}
+		page = options.page
+		page.OrderBy = options.orderBy
+		sized = options.top
+	}
+	page.Limit = h.pages.limit(page.Limit, sized)
+
+	// Fetch one extra row so the link builder knows whether a next page exists.
+	query := page
+	query.Limit++
+
 	h.logger.Debug("Calling service.List",
 		slog.String("request_id", requestID),
 		slog.String("user_id", userID.String()),
-		slog.String("service_name", serviceName))
+		slog.String("service_name", serviceName),
+		slog.Int("limit", page.Limit),
+		slog.Int("offset", page.Offset))
 
-	subs, err := h.service.List(c.Request.Context(), userID, serviceName)
+	filter := models.ListFilter{UserID: userID, ServiceName: serviceName, Kind: kind, Category: category, CustomFields: customFieldFilter(c), Conditions: options.conditions}
+	subs, err := h.service.List(c.Request.Context(), filter, query)
 	if err != nil {
 		h.logger.Error("Service.List failed",
 			slog.String("request_id", requestID),
@@ -326,7 +840,7 @@ func (h *SubscriptionHandler) List(c *gin.Context) {
 			slog.String("error", err.Error()),
 			slog.Duration("duration", time.Since(start)))
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list subscriptions"})
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "list_subscriptions_failed"))
 		return
 	}
 
@@ -337,7 +851,70 @@ func (h *SubscriptionHandler) List(c *gin.Context) {
 		slog.String("service_name", serviceName),
 		slog.Duration("duration", time.Since(start)))
 
-	c.JSON(http.StatusOK, subs)
+	if !odata {
+		summary, err := h.listSummary(c.Request.Context(), filter, withFacets)
+		if err != nil {
+			h.logger.Error("Failed to count subscriptions",
+				slog.String("request_id", requestID),
+				slog.Bool("facets", withFacets),
+				slog.String("error", err.Error()),
+				slog.Duration("duration", time.Since(start)))
+
+			c.JSON(serviceError(c, err, http.StatusInternalServerError, "list_subscriptions_failed"))
+			return
+		}
+		respondSubscriptions(c, subs, page, summary)
+		return
+	}
+
+	var count *int64
+	if options.count {
+		total, err := h.service.Count(c.Request.Context(), filter)
+		if err != nil {
+			h.logger.Error("Service.Count failed",
+				slog.String("request_id", requestID),
+				slog.String("error", err.Error()),
+				slog.Duration("duration", time.Since(start)))
+
+			c.JSON(serviceError(c, err, http.StatusInternalServerError, "list_subscriptions_failed"))
+			return
+		}
+		count = &total
+	}
+	respondOData(c, subs, page, count)
+}
+
+// listOrder reads the sort_by and order parameters of a listing. sort_by takes the
+// fields $orderby does and defaults to start_date; order is asc, the default, or desc.
+// On error it returns the name of the offending parameter.
+func listOrder(sortBy string, order string) (models.Order, string) {
+	if sortBy == "" {
+		sortBy = "start_date"
+	}
+	if _, ok := odataFields[sortBy]; !ok {
+		return models.Order{}, "sort_by"
+	}
+	switch order {
+	case "", "asc":
+		return models.Order{Field: sortBy}, ""
+	case "desc":
+		return models.Order{Field: sortBy, Desc: true}, ""
+	default:
+		return models.Order{}, "order"
+	}
+}
+
+// listSummary counts the subscriptions matching filter, and with facets counts them by
+// facet in the same query.
+func (h *SubscriptionHandler) listSummary(ctx context.Context, filter models.ListFilter, facets bool) (*models.FacetSummary, error) {
+	if facets {
+		return h.service.Facets(ctx, filter)
+	}
+	total, err := h.service.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &models.FacetSummary{Total: total}, nil
 }
 
 func (h *SubscriptionHandler) Aggregate(c *gin.Context) {
@@ -350,10 +927,20 @@ func (h *SubscriptionHandler) Aggregate(c *gin.Context) {
 		slog.String("client_ip", c.ClientIP()))
 
 	var req struct {
-		StartDate   string     `json:"start_date" binding:"required"`
-		EndDate     string     `json:"end_date" binding:"required"`
-		UserID      *uuid.UUID `json:"user_id,omitempty"`
-		ServiceName *string    `json:"service_name,omitempty"`
+		StartDate           string      `json:"start_date" binding:"required"`
+		EndDate             string      `json:"end_date" binding:"required"`
+		UserID              *uuid.UUID  `json:"user_id,omitempty"`
+		ServiceName         *string     `json:"service_name,omitempty"`
+		UserIDs             []uuid.UUID `json:"user_ids,omitempty" binding:"max=100"`
+		ServiceNames        []string    `json:"service_names,omitempty" binding:"max=100"`
+		ExcludeUserIDs      []uuid.UUID `json:"exclude_user_ids,omitempty" binding:"max=100"`
+		ExcludeServiceNames []string    `json:"exclude_service_names,omitempty" binding:"max=100"`
+		Kinds               []string    `json:"kinds,omitempty" binding:"max=3,dive,oneof=recurring one_time lifetime"`
+		Categories          []string    `json:"categories,omitempty" binding:"max=100"`
+		Bucket              string      `json:"bucket,omitempty" binding:"omitempty,oneof=month quarter year"`
+		GroupBy             string      `json:"group_by,omitempty" binding:"omitempty,oneof=category month"`
+		Mode                string      `json:"mode,omitempty" binding:"omitempty,oneof=normalized exact"`
+		IncludeDetails      bool        `json:"include_details,omitempty"`
 	}
 
 	h.logger.Debug("Attempting to bind JSON request for aggregation",
@@ -365,59 +952,409 @@ func (h *SubscriptionHandler) Aggregate(c *gin.Context) {
 			slog.String("error", err.Error()),
 			slog.Duration("duration", time.Since(start)))
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, bindError(c, err))
 		return
 	}
 
-	var userIDStr string
-	var serviceNameStr string
-
+	// The single-value fields predate the lists and are folded into them.
+	filter := models.AggregateFilter{
+		UserIDs:             req.UserIDs,
+		ServiceNames:        req.ServiceNames,
+		ExcludeUserIDs:      req.ExcludeUserIDs,
+		ExcludeServiceNames: req.ExcludeServiceNames,
+		Kinds:               req.Kinds,
+		Categories:          req.Categories,
+	}
 	if req.UserID != nil {
-		userIDStr = req.UserID.String()
+		filter.UserIDs = append(filter.UserIDs, *req.UserID)
 	}
 	if req.ServiceName != nil {
-		serviceNameStr = *req.ServiceName
+		filter.ServiceNames = append(filter.ServiceNames, *req.ServiceName)
 	}
 
 	h.logger.Info("Successfully bound aggregation request data",
 		slog.String("request_id", requestID),
 		slog.String("start_date", req.StartDate),
 		slog.String("end_date", req.EndDate),
-		slog.String("user_id", userIDStr),
-		slog.String("service_name", serviceNameStr))
+		slog.Any("filter", filter))
 
+	h.aggregate(c, requestID, start, req.StartDate, req.EndDate, req.Bucket, req.GroupBy, req.Mode, req.IncludeDetails, filter)
+}
+
+// AggregateQuery is the GET form of Aggregate for dashboards and caches: the period,
+// bucket and mode are query parameters and the filter uses the repeatable parameters
+// of Stats.
+func (h *SubscriptionHandler) AggregateQuery(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting subscription aggregation",
+		slog.String("request_id", requestID),
+		slog.String("method", "AggregateQuery"),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		StartDate string `form:"start_date" binding:"required"`
+		EndDate   string `form:"end_date" binding:"required"`
+		Bucket    string `form:"bucket" binding:"omitempty,oneof=month quarter year"`
+		GroupBy   string `form:"group_by" binding:"omitempty,oneof=category month"`
+		Mode      string `form:"mode" binding:"omitempty,oneof=normalized exact"`
+		Details   bool   `form:"include_details"`
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Failed to bind query for aggregation",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	filter, badParam := aggregateFilterFromQuery(c)
+	if badParam != "" {
+		h.logger.Warn("Invalid aggregation filter parameter provided",
+			slog.String("request_id", requestID),
+			slog.String("param", badParam))
+
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", badParam))
+		return
+	}
+
+	h.aggregate(c, requestID, start, req.StartDate, req.EndDate, req.Bucket, req.GroupBy, req.Mode, req.Details, filter)
+}
+
+// aggregate computes and writes the response shared by Aggregate and AggregateQuery.
+func (h *SubscriptionHandler) aggregate(c *gin.Context, requestID string, start time.Time, startDate string, endDate string, bucket string, groupBy string, mode string, includeDetails bool, filter models.AggregateFilter) {
 	h.logger.Debug("Calling service.Aggregate",
 		slog.String("request_id", requestID))
 
 	total, err := h.service.Aggregate(c.Request.Context(),
-		req.StartDate,
-		req.EndDate,
-		req.UserID,
-		req.ServiceName,
+		startDate,
+		endDate,
+		mode,
+		filter,
 	)
 
 	if err != nil {
 		h.logger.Error("Service.Aggregate failed",
 			slog.String("request_id", requestID),
-			slog.String("start_date", req.StartDate),
-			slog.String("end_date", req.EndDate),
-			slog.String("user_id", userIDStr),
-			slog.String("service_name", serviceNameStr),
+			slog.String("start_date", startDate),
+			slog.String("end_date", endDate),
+			slog.Any("filter", filter),
 			slog.String("error", err.Error()),
 			slog.Duration("duration", time.Since(start)))
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "aggregate_failed"))
 		return
 	}
 
 	h.logger.Info("Successfully calculated aggregation",
 		slog.String("request_id", requestID),
-		slog.Int("total", total),
-		slog.String("start_date", req.StartDate),
-		slog.String("end_date", req.EndDate),
-		slog.String("user_id", userIDStr),
-		slog.String("service_name", serviceNameStr),
+		slog.String("total", total.String()),
+		slog.String("start_date", startDate),
+		slog.String("end_date", endDate),
+		slog.Any("filter", filter),
 		slog.Duration("duration", time.Since(start)))
 
-	c.JSON(http.StatusOK, gin.H{"total": total})
+	reportedMode := mode
+	if reportedMode == "" {
+		reportedMode = models.AggregateNormalized
+	}
+
+	h.preferCurrency(c, requestID, filter)
+	response := gin.H{"total": total, "mode": reportedMode}
+	if apiVersion(c) >= 2 {
+		response["total"] = newMoney(c, total)
+	}
+
+	if bucket != "" {
+		buckets, err := h.service.AggregateByBucket(c.Request.Context(), startDate, endDate, bucket, mode, filter)
+		if err != nil {
+			h.logger.Error("Service.AggregateByBucket failed",
+				slog.String("request_id", requestID),
+				slog.String("bucket", bucket),
+				slog.String("error", err.Error()),
+				slog.Duration("duration", time.Since(start)))
+
+			c.JSON(serviceError(c, err, http.StatusInternalServerError, "aggregate_failed"))
+			return
+		}
+
+		response["bucket"] = bucket
+		response["buckets"] = newBucketTotals(c, buckets)
+
+		h.logger.Info("Successfully calculated bucketed aggregation",
+			slog.String("request_id", requestID),
+			slog.String("bucket", bucket),
+			slog.Int("buckets", len(buckets)),
+			slog.Duration("duration", time.Since(start)))
+	}
+
+	if groupBy == "category" {
+		groups, err := h.service.AggregateByCategory(c.Request.Context(), startDate, endDate, mode, filter)
+		if err != nil {
+			h.logger.Error("Service.AggregateByCategory failed",
+				slog.String("request_id", requestID),
+				slog.String("error", err.Error()),
+				slog.Duration("duration", time.Since(start)))
+
+			c.JSON(serviceError(c, err, http.StatusInternalServerError, "aggregate_failed"))
+			return
+		}
+
+		response["group_by"] = groupBy
+		response["groups"] = newCategoryTotals(c, groups)
+
+		h.logger.Info("Successfully calculated category aggregation",
+			slog.String("request_id", requestID),
+			slog.Int("groups", len(groups)),
+			slog.Duration("duration", time.Since(start)))
+	}
+
+	if groupBy == "month" {
+		months, err := h.service.AggregateByBucket(c.Request.Context(), startDate, endDate, "month", mode, filter)
+		if err != nil {
+			h.logger.Error("Service.AggregateByBucket failed",
+				slog.String("request_id", requestID),
+				slog.String("group_by", groupBy),
+				slog.String("error", err.Error()),
+				slog.Duration("duration", time.Since(start)))
+
+			c.JSON(serviceError(c, err, http.StatusInternalServerError, "aggregate_failed"))
+			return
+		}
+
+		response["group_by"] = groupBy
+		response["groups"] = newPeriodTotals(c, months)
+
+		h.logger.Info("Successfully calculated monthly aggregation",
+			slog.String("request_id", requestID),
+			slog.Int("groups", len(months)),
+			slog.Duration("duration", time.Since(start)))
+	}
+
+	if includeDetails {
+		breakdown, err := h.service.AggregateDetails(c.Request.Context(), startDate, endDate, mode, filter)
+		if err != nil {
+			h.logger.Error("Service.AggregateDetails failed",
+				slog.String("request_id", requestID),
+				slog.String("error", err.Error()),
+				slog.Duration("duration", time.Since(start)))
+
+			c.JSON(serviceError(c, err, http.StatusInternalServerError, "aggregate_failed"))
+			return
+		}
+
+		response["details"] = newAggregateDetails(c, breakdown.Details)
+		response["details_truncated"] = breakdown.Truncated
+
+		h.logger.Info("Successfully listed aggregation details",
+			slog.String("request_id", requestID),
+			slog.Int("details", len(breakdown.Details)),
+			slog.Bool("truncated", breakdown.Truncated),
+			slog.Duration("duration", time.Since(start)))
+	}
+
+	respondVersioned(c, http.StatusOK, response)
+}
+
+// preferCurrency reports the money of an aggregation restricted to a single user in the
+// currency they prefer. Amounts stay in priceCurrency when the preference cannot be read.
+func (h *SubscriptionHandler) preferCurrency(c *gin.Context, requestID string, filter models.AggregateFilter) {
+	userIDs := filter.UserIDs
+	if id := identity.FromContext(c.Request.Context()); id.Impersonating() {
+		userIDs = []uuid.UUID{*id.Subject}
+	}
+	if len(userIDs) != 1 {
+		return
+	}
+
+	currency, rate, err := h.currencies.Currency(c.Request.Context(), userIDs[0])
+	if err != nil {
+		h.logger.Warn("Failed to read preferred currency, reporting prices as stored",
+			slog.String("request_id", requestID),
+			slog.String("user_id", userIDs[0].String()),
+			slog.String("error", err.Error()))
+		return
+	}
+	if currency != priceCurrency {
+		c.Set(currencyKey, conversion{currency: currency, rate: rate})
+	}
+}
+
+// Simulate projects a user's monthly costs after hypothetical changes without
+// persisting them.
+func (h *SubscriptionHandler) Simulate(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting subscription simulation",
+		slog.String("request_id", requestID),
+		slog.String("method", "Simulate"),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		UserID    uuid.UUID `json:"user_id" binding:"required"`
+		StartDate string    `json:"start_date" binding:"required"`
+		EndDate   string    `json:"end_date" binding:"required"`
+		Mode      string    `json:"mode,omitempty" binding:"omitempty,oneof=normalized exact"`
+		Changes   []struct {
+			Action           string    `json:"action" binding:"required,oneof=add remove cancel change_price"`
+			SubscriptionID   uuid.UUID `json:"subscription_id,omitempty"`
+			ServiceName      string    `json:"service_name,omitempty"`
+			Price            int       `json:"price,omitempty" binding:"omitempty,gt=0"`
+			Kind             string    `json:"kind,omitempty" binding:"omitempty,oneof=recurring one_time lifetime"`
+			BillingPeriod    string    `json:"billing_period,omitempty" binding:"omitempty,oneof=weekly monthly quarterly yearly"`
+			BillingAnchorDay int       `json:"billing_anchor_day,omitempty" binding:"omitempty,min=1,max=31"`
+			StartDate        string    `json:"start_date,omitempty"`
+			EndDate          string    `json:"end_date,omitempty"`
+			At               string    `json:"at,omitempty"`
+		} `json:"changes" binding:"required,min=1,max=50,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for simulation",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	changes := make([]models.SimulationChange, 0, len(req.Changes))
+	for _, change := range req.Changes {
+		changes = append(changes, models.SimulationChange(change))
+	}
+
+	h.logger.Debug("Calling service.Simulate",
+		slog.String("request_id", requestID),
+		slog.String("user_id", req.UserID.String()),
+		slog.Int("changes", len(changes)))
+
+	result, err := h.service.Simulate(c.Request.Context(), req.UserID, req.StartDate, req.EndDate, req.Mode, changes)
+	if err != nil {
+		h.logger.Error("Service.Simulate failed",
+			slog.String("request_id", requestID),
+			slog.String("user_id", req.UserID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "simulate_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully simulated subscription changes",
+		slog.String("request_id", requestID),
+		slog.String("user_id", req.UserID.String()),
+		slog.String("difference", result.Difference.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	respondVersioned(c, http.StatusOK, newSimulationResult(c, result))
+}
+
+func (h *SubscriptionHandler) Stats(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting subscription statistics",
+		slog.String("request_id", requestID),
+		slog.String("method", "Stats"),
+		slog.String("client_ip", c.ClientIP()))
+
+	filter, badParam := aggregateFilterFromQuery(c)
+	if badParam != "" {
+		h.logger.Warn("Invalid statistics filter parameter provided",
+			slog.String("request_id", requestID),
+			slog.String("param", badParam))
+
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", badParam))
+		return
+	}
+
+	h.logger.Debug("Calling service.Stats",
+		slog.String("request_id", requestID),
+		slog.Any("filter", filter))
+
+	stats, err := h.service.Stats(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Service.Stats failed",
+			slog.String("request_id", requestID),
+			slog.Any("filter", filter),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "stats_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully computed subscription statistics",
+		slog.String("request_id", requestID),
+		slog.Int64("count", stats.Count),
+		slog.Duration("duration", time.Since(start)))
+
+	respondVersioned(c, http.StatusOK, stats)
+}
+
+// aggregateFilterFromQuery reads repeatable user_id, service_name, exclude_user_id and
+// exclude_service_name parameters. It returns the name of the first malformed parameter.
+func aggregateFilterFromQuery(c *gin.Context) (models.AggregateFilter, string) {
+	filter := models.AggregateFilter{
+		ServiceNames:        c.QueryArray("service_name"),
+		ExcludeServiceNames: c.QueryArray("exclude_service_name"),
+		Kinds:               c.QueryArray("kind"),
+		Categories:          c.QueryArray("category"),
+	}
+
+	for _, kind := range filter.Kinds {
+		if !validKind(kind) {
+			return filter, "kind"
+		}
+	}
+
+	for _, param := range []struct {
+		name   string
+		target *[]uuid.UUID
+	}{{"user_id", &filter.UserIDs}, {"exclude_user_id", &filter.ExcludeUserIDs}} {
+		for _, value := range c.QueryArray(param.name) {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return filter, param.name
+			}
+			*param.target = append(*param.target, id)
+		}
+	}
+
+	return filter, ""
+}
+
+func validKind(kind string) bool {
+	switch kind {
+	case models.KindRecurring, models.KindOneTime, models.KindLifetime:
+		return true
+	}
+	return false
+}
+
+// customFieldFilterPrefix marks the query parameters filtering on custom fields,
+// e.g. field.cost_center=sales.
+const customFieldFilterPrefix = "field."
+
+// customFieldFilter collects the custom field query parameters. The values stay
+// strings; the service converts them to the types of their fields.
+func customFieldFilter(c *gin.Context) map[string]any {
+	var filter map[string]any
+	for key, values := range c.Request.URL.Query() {
+		name, ok := strings.CutPrefix(key, customFieldFilterPrefix)
+		if !ok || name == "" || len(values) == 0 {
+			continue
+		}
+		if filter == nil {
+			filter = make(map[string]any)
+		}
+		filter[name] = values[0]
+	}
+	return filter
 }
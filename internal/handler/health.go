@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/model"
+)
+
+type HealthHandler struct {
+	db         Pinger
+	leadership Leadership
+	region     RegionStatus
+	draining   atomic.Bool
+	logger     *slog.Logger
+}
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type Leadership interface {
+	Status() models.LeaderStatus
+}
+
+type RegionStatus interface {
+	Name() string
+	Role() string
+	Replication(ctx context.Context) (*models.ReplicationStatus, bool, error)
+}
+
+func NewHealthHandler(db Pinger, leadership Leadership, region RegionStatus, logger *slog.Logger) *HealthHandler {
+	return &HealthHandler{
+		db:         db,
+		leadership: leadership,
+		region:     region,
+		logger:     logger,
+	}
+}
+
+// Drain makes readiness checks fail from now on, so load balancers stop sending new
+// requests while in-flight ones finish during shutdown.
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// Live reports that the process is up and serving requests. Probes call it every few
+// seconds, so successful checks are not logged.
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": models.HealthOK})
+}
+
+// Ready reports whether the service can handle traffic, i.e. whether the database is
+// reachable and, on a standby, not lagging too far behind. It also tells the region
+// and whether this instance is the leader running the background subsystems;
+// followers are ready all the same.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	leadership := h.leadership.Status()
+	body := gin.H{"status": models.HealthOK, "database": models.HealthOK, "leader": leadership.Leader, "region_role": h.region.Role()}
+	if leadership.Since != nil {
+		body["leader_since"] = leadership.Since
+	}
+	if name := h.region.Name(); name != "" {
+		body["region"] = name
+	}
+
+	if h.draining.Load() {
+		body["status"] = models.HealthDraining
+		c.JSON(http.StatusServiceUnavailable, body)
+		return
+	}
+
+	if err := h.db.Ping(c.Request.Context()); err != nil {
+		h.logger.Warn("Readiness check failed",
+			slog.String("error", err.Error()),
+			slog.String("client_ip", c.ClientIP()))
+
+		body["status"] = models.HealthDegraded
+		body["database"] = "unavailable"
+		c.JSON(http.StatusServiceUnavailable, body)
+		return
+	}
+
+	replication, withinLag, err := h.region.Replication(c.Request.Context())
+	if err != nil {
+		h.logger.Warn("Failed to read replication status",
+			slog.String("error", err.Error()),
+			slog.String("client_ip", c.ClientIP()))
+		c.JSON(http.StatusOK, body)
+		return
+	}
+	body["in_recovery"] = replication.InRecovery
+	if replication.LagSeconds != nil {
+		body["replication_lag_seconds"] = *replication.LagSeconds
+	}
+	if !withinLag {
+		h.logger.Warn("Readiness check failed: replication lag too high",
+			slog.Float64("replication_lag_seconds", *replication.LagSeconds),
+			slog.String("client_ip", c.ClientIP()))
+
+		body["status"] = models.HealthDegraded
+		body["replication"] = "lagging"
+		c.JSON(http.StatusServiceUnavailable, body)
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/buildinfo"
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+type InstanceHandler struct {
+	instances InstanceService
+	logger    *slog.Logger
+}
+
+type InstanceService interface {
+	Active(ctx context.Context) ([]models.Instance, error)
+}
+
+func NewInstanceHandler(instances InstanceService, logger *slog.Logger) *InstanceHandler {
+	return &InstanceHandler{
+		instances: instances,
+		logger:    logger,
+	}
+}
+
+// Version reports the build and the instance that served the request.
+func Version(c *gin.Context) {
+	c.JSON(http.StatusOK, buildinfo.Current())
+}
+
+func (h *InstanceHandler) List(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting instance listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "List"),
+		slog.String("client_ip", c.ClientIP()))
+
+	instances, err := h.instances.Active(c.Request.Context())
+	if err != nil {
+		h.logger.Error("InstanceService.Active failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "instances_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully listed instances",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(instances)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"instances": instances})
+}
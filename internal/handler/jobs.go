@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/service"
+)
+
+type JobHandler struct {
+	jobs     AsyncJobService
+	basePath string
+	logger   *slog.Logger
+}
+
+// AsyncJobRunner starts the long operations requested with Prefer: respond-async.
+type AsyncJobRunner interface {
+	Submit(ctx context.Context, kind string, unit string, file *service.AsyncFile, task service.AsyncTask) (*models.AsyncJob, error)
+}
+
+type AsyncJobService interface {
+	Get(ctx context.Context, id uuid.UUID) (*models.AsyncJob, error)
+	File(ctx context.Context, id uuid.UUID) (*models.AsyncJob, *service.AsyncDownload, error)
+	DownloadQuery(job *models.AsyncJob) (url.Values, bool)
+	Download(ctx context.Context, id uuid.UUID, expires string, signature string) (*models.AsyncJob, *service.AsyncDownload, error)
+}
+
+type jobResource struct {
+	*models.AsyncJob
+	Links links `json:"_links"`
+}
+
+// NewJobHandler links job files to the signed download route under basePath when the
+// service signs download URLs.
+func NewJobHandler(jobs AsyncJobService, basePath string, logger *slog.Logger) *JobHandler {
+	return &JobHandler{
+		jobs:     jobs,
+		basePath: basePath,
+		logger:   logger,
+	}
+}
+
+// Get returns a job of the caller, or any job for admins.
+func (h *JobHandler) Get(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting async job retrieval",
+		slog.String("request_id", requestID),
+		slog.String("method", "GetJob"),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_job_id"))
+		return
+	}
+
+	job, err := h.jobs.Get(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("AsyncJobService.Get failed",
+			slog.String("request_id", requestID),
+			slog.String("job_id", id.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "get_job_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved async job",
+		slog.String("request_id", requestID),
+		slog.String("job_id", id.String()),
+		slog.String("status", job.Status),
+		slog.Duration("duration", time.Since(start)))
+
+	resource := newJobResource(job, jobPath(c, job))
+	if _, ok := resource.Links["result"]; ok {
+		if query, signed := h.jobs.DownloadQuery(job); signed {
+			resource.Links["result"] = link{Href: h.basePath + "/downloads/jobs/" + job.ID.String() + "?" + query.Encode(), Method: http.MethodGet}
+		}
+	}
+	c.JSON(http.StatusOK, resource)
+}
+
+// Result downloads the file a succeeded job of the caller produced.
+func (h *JobHandler) Result(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting async job result download",
+		slog.String("request_id", requestID),
+		slog.String("method", "GetJobResult"),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_job_id"))
+		return
+	}
+
+	job, file, err := h.jobs.File(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("AsyncJobService.File failed",
+			slog.String("request_id", requestID),
+			slog.String("job_id", id.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "get_job_failed"))
+		return
+	}
+
+	h.logger.Info("Serving async job result",
+		slog.String("request_id", requestID),
+		slog.String("job_id", id.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	serveJobFile(c, job, file)
+}
+
+// Download serves the file of a job to anyone holding a download URL signed by the
+// service, without the admin token.
+func (h *JobHandler) Download(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting async job file download",
+		slog.String("request_id", requestID),
+		slog.String("method", "DownloadJobFile"),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_job_id"))
+		return
+	}
+
+	job, file, err := h.jobs.Download(c.Request.Context(), id, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		h.logger.Warn("AsyncJobService.Download failed",
+			slog.String("request_id", requestID),
+			slog.String("job_id", id.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "get_job_failed"))
+		return
+	}
+
+	h.logger.Info("Serving async job file",
+		slog.String("request_id", requestID),
+		slog.String("job_id", id.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	serveJobFile(c, job, file)
+}
+
+// serveJobFile sends files on disk with range support, so large downloads can resume.
+func serveJobFile(c *gin.Context, job *models.AsyncJob, file *service.AsyncDownload) {
+	c.Header("Content-Type", *job.ContentType)
+	if file.Path != "" {
+		c.FileAttachment(file.Path, *job.FileName)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", *job.FileName))
+	c.Data(http.StatusOK, *job.ContentType, file.Content)
+}
+
+// preferAsync reports whether the client asked for the operation to run as a job.
+func preferAsync(c *gin.Context) bool {
+	for _, prefer := range c.Request.Header.Values("Prefer") {
+		for _, token := range strings.Split(prefer, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptJob submits task and answers 202 with the job, whose link the client polls. It
+// reports whether the job was started.
+func acceptJob(c *gin.Context, jobs AsyncJobRunner, logger *slog.Logger, requestID string, kind string, unit string, file *service.AsyncFile, task service.AsyncTask) bool {
+	job, err := jobs.Submit(c.Request.Context(), kind, unit, file, task)
+	if err != nil {
+		logger.Error("AsyncJobService.Submit failed",
+			slog.String("request_id", requestID),
+			slog.String("kind", kind),
+			slog.String("error", err.Error()))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "submit_job_failed"))
+		return false
+	}
+
+	logger.Info("Accepted async job",
+		slog.String("request_id", requestID),
+		slog.String("job_id", job.ID.String()),
+		slog.String("kind", kind))
+
+	resource := newJobResource(job, adminPath(c, "/jobs/"+job.ID.String()))
+	c.Header("Location", resource.Links["self"].Href)
+	c.JSON(http.StatusAccepted, resource)
+	return true
+}
+
+func newJobResource(job *models.AsyncJob, self string) jobResource {
+	l := links{"self": {Href: self, Method: http.MethodGet}}
+	if job.Status == models.AsyncJobSucceeded && job.FileName != nil {
+		l["result"] = link{Href: self + "/result", Method: http.MethodGet}
+	}
+	return jobResource{AsyncJob: job, Links: l}
+}
+
+// jobPath returns the path of job under the job routes the request came through, so a
+// job polled through the API links back to the API and one polled as an admin to the
+// admin routes.
+func jobPath(c *gin.Context, job *models.AsyncJob) string {
+	prefix, _, _ := strings.Cut(c.FullPath(), "/jobs/:id")
+	return prefix + "/jobs/" + job.ID.String()
+}
+
+// adminPath returns path under the admin routes the request came through, keeping the
+// base path they are mounted under.
+func adminPath(c *gin.Context, path string) string {
+	prefix, _, found := strings.Cut(c.FullPath(), "/admin/")
+	if !found {
+		prefix = ""
+	}
+	return prefix + "/admin" + path
+}
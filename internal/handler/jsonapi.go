@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/model"
+)
+
+const jsonAPIMediaType = "application/vnd.api+json"
+
+type jsonAPIResource struct {
+	Type          string                         `json:"type"`
+	ID            string                         `json:"id"`
+	Attributes    any                            `json:"attributes,omitempty"`
+	Relationships map[string]jsonAPIRelationship `json:"relationships,omitempty"`
+	Links         map[string]string              `json:"links,omitempty"`
+}
+
+type jsonAPIIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type jsonAPIRelationship struct {
+	Data  jsonAPIIdentifier `json:"data"`
+	Links map[string]string `json:"links,omitempty"`
+}
+
+type jsonAPIDocument struct {
+	Data     any               `json:"data"`
+	Included []jsonAPIResource `json:"included,omitempty"`
+	Links    map[string]string `json:"links,omitempty"`
+	Meta     map[string]any    `json:"meta,omitempty"`
+}
+
+type subscriptionAttributes struct {
+	ServiceName      string          `json:"service_name"`
+	Price            int             `json:"price"`
+	Kind             string          `json:"kind"`
+	BillingPeriod    string          `json:"billing_period"`
+	BillingAnchorDay int             `json:"billing_anchor_day"`
+	StartDate        time.Time       `json:"start_date"`
+	EndDate          *time.Time      `json:"end_date,omitempty"`
+	Status           string          `json:"status"`
+	Category         *string         `json:"category,omitempty"`
+	CustomFields     json.RawMessage `json:"custom_fields,omitempty"`
+}
+
+func wantsJSONAPI(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), jsonAPIMediaType)
+}
+
+func respondSubscription(c *gin.Context, status int, sub *models.Subscription) {
+	if !wantsJSONAPI(c) {
+		respondVersioned(c, status, newSubscriptionResource(c, sub))
+		return
+	}
+
+	renderJSONAPI(c, status, jsonAPIDocument{
+		Data:     newJSONAPISubscription(c, sub),
+		Included: []jsonAPIResource{newJSONAPIUser(c, sub)},
+	})
+}
+
+// respondSubscriptions renders a page of a listing with the total and, when counted, the
+// facets of all its matches.
+func respondSubscriptions(c *gin.Context, subs []models.Subscription, page models.Page, summary *models.FacetSummary) {
+	if !wantsJSONAPI(c) {
+		respondVersioned(c, http.StatusOK, newSubscriptionCollection(c, subs, page, summary))
+		return
+	}
+
+	subs, hasNext := trimPage(subs, page)
+
+	data := make([]jsonAPIResource, 0, len(subs))
+	var included []jsonAPIResource
+	seenUsers := make(map[string]bool)
+	for i := range subs {
+		data = append(data, newJSONAPISubscription(c, &subs[i]))
+
+		user := newJSONAPIUser(c, &subs[i])
+		if !seenUsers[user.ID] {
+			seenUsers[user.ID] = true
+			included = append(included, user)
+		}
+	}
+
+	docLinks := make(map[string]string)
+	for rel, l := range pageLinks(c, page, hasNext) {
+		docLinks[rel] = l.Href
+	}
+
+	renderJSONAPI(c, http.StatusOK, jsonAPIDocument{
+		Data:     data,
+		Included: included,
+		Links:    docLinks,
+		Meta:     summaryMeta(summary),
+	})
+}
+
+func summaryMeta(summary *models.FacetSummary) map[string]any {
+	meta := map[string]any{"total": summary.Total}
+	if summary.Facets != nil {
+		meta["facets"] = summary.Facets
+	}
+	return meta
+}
+
+// gin keeps an explicitly set Content-Type, so the JSON renderer can be reused as is.
+func renderJSONAPI(c *gin.Context, status int, doc jsonAPIDocument) {
+	c.Header("Content-Type", jsonAPIMediaType)
+	c.JSON(status, doc)
+}
+
+func newJSONAPISubscription(c *gin.Context, sub *models.Subscription) jsonAPIResource {
+	return jsonAPIResource{
+		Type: "subscriptions",
+		ID:   sub.ID.String(),
+		Attributes: subscriptionAttributes{
+			ServiceName:      sub.ServiceName,
+			Price:            sub.Price,
+			Kind:             sub.Kind,
+			BillingPeriod:    sub.BillingPeriod,
+			BillingAnchorDay: sub.BillingAnchorDay,
+			StartDate:        sub.StartDate,
+			EndDate:          sub.EndDate,
+			Status:           sub.Status,
+			Category:         sub.Category,
+			CustomFields:     sub.CustomFields,
+		},
+		Relationships: map[string]jsonAPIRelationship{
+			"user": {Data: jsonAPIIdentifier{Type: "users", ID: sub.UserID.String()}},
+		},
+		Links: map[string]string{"self": subscriptionPath(c, sub)},
+	}
+}
+
+// Users are not stored as a resource of their own, so the included entry only links
+// back to the user's subscriptions.
+func newJSONAPIUser(c *gin.Context, sub *models.Subscription) jsonAPIResource {
+	return jsonAPIResource{
+		Type:  "users",
+		ID:    sub.UserID.String(),
+		Links: map[string]string{"subscriptions": userSubscriptionsPath(c, sub)},
+	}
+}
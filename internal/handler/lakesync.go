@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+const (
+	defaultLakeSyncRuns = 100
+	maxLakeSyncRuns     = 1000
+)
+
+type LakeSyncHandler struct {
+	runs   LakeSyncRuns
+	logger *slog.Logger
+}
+
+type LakeSyncRuns interface {
+	List(ctx context.Context, destination string, limit int) ([]models.LakeSyncRun, error)
+}
+
+func NewLakeSyncHandler(runs LakeSyncRuns, logger *slog.Logger) *LakeSyncHandler {
+	return &LakeSyncHandler{
+		runs:   runs,
+		logger: logger,
+	}
+}
+
+// Runs lists the data lake sync runs, most recent first, optionally of one destination.
+func (h *LakeSyncHandler) Runs(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting lake sync run listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "ListLakeSyncRuns"),
+		slog.String("client_ip", c.ClientIP()))
+
+	limit := defaultLakeSyncRuns
+	if limitParam := c.Query("limit"); limitParam != "" {
+		n, err := strconv.Atoi(limitParam)
+		if err != nil || n <= 0 || n > maxLakeSyncRuns {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "limit"))
+			return
+		}
+		limit = n
+	}
+	destination := c.Query("destination")
+
+	runs, err := h.runs.List(c.Request.Context(), destination, limit)
+	if err != nil {
+		h.logger.Error("Lake sync run listing failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_lake_sync_runs_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved lake sync runs",
+		slog.String("request_id", requestID),
+		slog.String("destination", destination),
+		slog.Int("count", len(runs)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/model"
+)
+
+// PageSizes bound the pages of subscription listings: a request without a limit gets
+// Default rows, and none gets more than Max.
+type PageSizes struct {
+	Default int
+	Max     int
+}
+
+// limit returns the page size to list for the requested one, zero meaning none. The
+// default applies only when the client gave no size.
+func (p PageSizes) limit(requested int, given bool) int {
+	if !given {
+		return p.Default
+	}
+	return min(requested, p.Max)
+}
+
+type link struct {
+	Href   string `json:"href"`
+	Method string `json:"method,omitempty"`
+}
+
+type links map[string]link
+
+type subscriptionResource struct {
+	*models.Subscription
+	Links links `json:"_links"`
+}
+
+type subscriptionCollection struct {
+	Embedded struct {
+		Subscriptions []any `json:"subscriptions"`
+	} `json:"_embedded"`
+	Total  int64                          `json:"total"`
+	Facets map[string][]models.FacetCount `json:"facets,omitempty"`
+	Links  links                          `json:"_links"`
+}
+
+// Links are relative so they stay valid behind proxies without trusting the Host header.
+func subscriptionPath(c *gin.Context, sub *models.Subscription) string {
+	return collectionPath(c) + "/" + sub.ID.String()
+}
+
+func userSubscriptionsPath(c *gin.Context, sub *models.Subscription) string {
+	return collectionPath(c) + "?" + url.Values{"user_id": {sub.UserID.String()}}.Encode()
+}
+
+func subscriptionLinks(c *gin.Context, sub *models.Subscription) links {
+	self := subscriptionPath(c, sub)
+	l := links{
+		"self":   {Href: self, Method: http.MethodGet},
+		"update": {Href: self, Method: http.MethodPut},
+		"delete": {Href: self, Method: http.MethodDelete},
+		"user":   {Href: userSubscriptionsPath(c, sub), Method: http.MethodGet},
+	}
+	if sub.EndDate == nil {
+		l["cancel"] = link{Href: self + "/cancel", Method: http.MethodPost}
+	}
+	return l
+}
+
+func newSubscriptionResource(c *gin.Context, sub *models.Subscription) any {
+	if apiVersion(c) >= 2 {
+		return newSubscriptionV2(c, sub)
+	}
+	return subscriptionResource{Subscription: sub, Links: subscriptionLinks(c, sub)}
+}
+
+func newSubscriptionCollection(c *gin.Context, subs []models.Subscription, page models.Page, summary *models.FacetSummary) subscriptionCollection {
+	subs, hasNext := trimPage(subs, page)
+
+	var collection subscriptionCollection
+	collection.Embedded.Subscriptions = make([]any, 0, len(subs))
+	for i := range subs {
+		collection.Embedded.Subscriptions = append(collection.Embedded.Subscriptions, newSubscriptionResource(c, &subs[i]))
+	}
+	collection.Total = summary.Total
+	collection.Facets = summary.Facets
+	collection.Links = pageLinks(c, page, hasNext)
+
+	return collection
+}
+
+// trimPage expects subs to hold at most page.Limit+1 rows; the extra row only signals
+// that a next page exists and is dropped. An empty page has no next page.
+func trimPage(subs []models.Subscription, page models.Page) ([]models.Subscription, bool) {
+	if len(subs) > page.Limit {
+		return subs[:page.Limit], page.Limit > 0
+	}
+	return subs, false
+}
+
+func pageLinks(c *gin.Context, page models.Page, hasNext bool) links {
+	l := links{"self": {Href: c.Request.URL.RequestURI(), Method: http.MethodGet}}
+	if hasNext {
+		l["next"] = link{Href: pageURL(c, page.Limit, page.Offset+page.Limit), Method: http.MethodGet}
+	}
+	if page.Limit > 0 && page.Offset > 0 {
+		l["prev"] = link{Href: pageURL(c, page.Limit, max(page.Offset-page.Limit, 0)), Method: http.MethodGet}
+	}
+	return l
+}
+
+func pageURL(c *gin.Context, limit int, offset int) string {
+	query := c.Request.URL.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	return c.Request.URL.Path + "?" + query.Encode()
+}
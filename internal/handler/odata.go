@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/model"
+)
+
+// OData system query options supported on GET /subscriptions.
+const (
+	odataFilter  = "$filter"
+	odataOrderBy = "$orderby"
+	odataTop     = "$top"
+	odataSkip    = "$skip"
+	odataCount   = "$count"
+)
+
+// Types of the fields $filter compares.
+const (
+	odataString = iota
+	odataInt
+	odataGUID
+	odataDate
+)
+
+type odataField struct {
+	kind     int
+	nullable bool
+}
+
+// odataFields are the subscription fields $filter and $orderby accept, named as in the
+// JSON of a subscription.
+var odataFields = map[string]odataField{
+	"id":                 {kind: odataGUID},
+	"service_name":       {kind: odataString},
+	"price":              {kind: odataInt},
+	"user_id":            {kind: odataGUID},
+	"kind":               {kind: odataString},
+	"billing_period":     {kind: odataString},
+	"billing_anchor_day": {kind: odataInt},
+	"start_date":         {kind: odataDate},
+	"end_date":           {kind: odataDate, nullable: true},
+	"status":             {kind: odataString},
+	"category":           {kind: odataString, nullable: true},
+}
+
+// odataQuery holds the OData options of a listing request.
+type odataQuery struct {
+	conditions []models.Condition
+	orderBy    []models.Order
+	page       models.Page
+	// top reports whether $top was given, so $top=0 lists no rows instead of the
+	// default page.
+	top   bool
+	count bool
+}
+
+// wantsOData reports whether the request uses any OData system query option, which
+// switches the listing to the OData response shape.
+func wantsOData(c *gin.Context) bool {
+	for key := range c.Request.URL.Query() {
+		if strings.HasPrefix(key, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseODataQuery reads the OData options of the request. On error it also returns
+// the name of the offending option.
+func parseODataQuery(c *gin.Context) (odataQuery, string, error) {
+	var query odataQuery
+
+	if filter := c.Query(odataFilter); filter != "" {
+		conditions, err := parseODataFilter(filter)
+		if err != nil {
+			return query, odataFilter, err
+		}
+		query.conditions = conditions
+	}
+
+	if orderBy := c.Query(odataOrderBy); orderBy != "" {
+		orders, err := parseODataOrderBy(orderBy)
+		if err != nil {
+			return query, odataOrderBy, err
+		}
+		query.orderBy = orders
+	}
+
+	for _, option := range []struct {
+		name   string
+		target *int
+	}{{odataTop, &query.page.Limit}, {odataSkip, &query.page.Offset}} {
+		value := c.Query(option.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return query, option.name, fmt.Errorf("%s must be a non-negative integer", option.name)
+		}
+		*option.target = n
+	}
+	query.top = c.Query(odataTop) != ""
+
+	switch c.Query(odataCount) {
+	case "", "false":
+	case "true":
+		query.count = true
+	default:
+		return query, odataCount, errors.New("$count must be true or false")
+	}
+
+	return query, "", nil
+}
+
+// parseODataFilter parses comparisons joined with "and", e.g.
+// "price gt 500 and end_date eq null and service_name eq 'Netflix'". Strings are quoted
+// with ' (doubled inside), dates are YYYY-MM-DD or RFC 3339, GUIDs may go unquoted.
+func parseODataFilter(filter string) ([]models.Condition, error) {
+	tokens, err := odataTokens(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var conditions []models.Condition
+	for i := 0; ; i += 4 {
+		if len(tokens) < i+3 {
+			return nil, errors.New("expected a comparison: field operator value")
+		}
+		cond, err := odataCondition(tokens[i], tokens[i+1], tokens[i+2])
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, cond)
+
+		if len(tokens) == i+3 {
+			return conditions, nil
+		}
+		if tokens[i+3].text != "and" || tokens[i+3].quoted {
+			return nil, fmt.Errorf("expected and, got %s", tokens[i+3].text)
+		}
+	}
+}
+
+func odataCondition(fieldToken odataToken, opToken odataToken, valueToken odataToken) (models.Condition, error) {
+	field, ok := odataFields[fieldToken.text]
+	if !ok || fieldToken.quoted {
+		return models.Condition{}, fmt.Errorf("unknown field %s", fieldToken.text)
+	}
+	switch opToken.text {
+	case models.OpEq, models.OpNe, models.OpGt, models.OpGe, models.OpLt, models.OpLe:
+	default:
+		return models.Condition{}, fmt.Errorf("unsupported operator %s", opToken.text)
+	}
+
+	cond := models.Condition{Field: fieldToken.text, Op: opToken.text}
+	if valueToken.text == "null" && !valueToken.quoted {
+		if !field.nullable || (cond.Op != models.OpEq && cond.Op != models.OpNe) {
+			return models.Condition{}, fmt.Errorf("%s cannot be compared with null", cond.Field)
+		}
+		return cond, nil
+	}
+
+	value, err := odataValue(field.kind, valueToken)
+	if err != nil {
+		return models.Condition{}, fmt.Errorf("invalid value for %s: %w", cond.Field, err)
+	}
+	cond.Value = value
+	return cond, nil
+}
+
+func odataValue(kind int, token odataToken) (any, error) {
+	switch kind {
+	case odataString:
+		if !token.quoted {
+			return nil, errors.New("strings must be quoted")
+		}
+		return token.text, nil
+	case odataInt:
+		return strconv.Atoi(token.text)
+	case odataGUID:
+		return uuid.Parse(token.text)
+	default:
+		if t, err := time.Parse(time.DateOnly, token.text); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.RFC3339, token.text)
+	}
+}
+
+// parseODataOrderBy parses comma-separated fields, each optionally followed by asc or
+// desc.
+func parseODataOrderBy(orderBy string) ([]models.Order, error) {
+	var orders []models.Order
+	for _, item := range strings.Split(orderBy, ",") {
+		parts := strings.Fields(item)
+		if len(parts) == 0 || len(parts) > 2 {
+			return nil, fmt.Errorf("invalid ordering %q", item)
+		}
+		if _, ok := odataFields[parts[0]]; !ok {
+			return nil, fmt.Errorf("unknown field %s", parts[0])
+		}
+		order := models.Order{Field: parts[0]}
+		if len(parts) == 2 {
+			switch parts[1] {
+			case "asc":
+			case "desc":
+				order.Desc = true
+			default:
+				return nil, fmt.Errorf("invalid direction %s", parts[1])
+			}
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+type odataToken struct {
+	text   string
+	quoted bool
+}
+
+// odataTokens splits a $filter expression on whitespace, keeping quoted strings whole.
+func odataTokens(expr string) ([]odataToken, error) {
+	var tokens []odataToken
+	for i := 0; i < len(expr); {
+		switch ch := expr[i]; {
+		case ch == ' ' || ch == '\t':
+			i++
+		case ch == '(' || ch == ')':
+			return nil, errors.New("parentheses and functions are not supported")
+		case ch == '\'':
+			var text strings.Builder
+			i++
+			for {
+				if i >= len(expr) {
+					return nil, errors.New("unterminated string")
+				}
+				if expr[i] == '\'' {
+					if i+1 < len(expr) && expr[i+1] == '\'' {
+						text.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				text.WriteByte(expr[i])
+				i++
+			}
+			tokens = append(tokens, odataToken{text: text.String(), quoted: true})
+		default:
+			end := strings.IndexAny(expr[i:], " \t()'")
+			if end < 0 {
+				end = len(expr) - i
+			}
+			tokens = append(tokens, odataToken{text: expr[i : i+end]})
+			i += end
+		}
+	}
+	return tokens, nil
+}
+
+// respondOData writes a listing in the OData JSON shape: the subscriptions under value,
+// with @odata.count when asked for and @odata.nextLink while more rows remain.
+func respondOData(c *gin.Context, subs []models.Subscription, page models.Page, count *int64) {
+	subs, hasNext := trimPage(subs, page)
+
+	value := make([]any, 0, len(subs))
+	for i := range subs {
+		value = append(value, newSubscriptionResource(c, &subs[i]))
+	}
+
+	body := gin.H{"value": value}
+	if count != nil {
+		body["@odata.count"] = *count
+	}
+	if hasNext {
+		query := c.Request.URL.Query()
+		query.Set(odataTop, strconv.Itoa(page.Limit))
+		query.Set(odataSkip, strconv.Itoa(page.Offset+page.Limit))
+		body["@odata.nextLink"] = c.Request.URL.Path + "?" + query.Encode()
+	}
+	respondVersioned(c, http.StatusOK, body)
+}
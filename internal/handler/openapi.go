@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPI serves spec with its servers list pointing at basePath, so "Try it out" in
+// Swagger UI sends requests through the same gateway prefix the spec was loaded from.
+func OpenAPI(spec []byte, basePath string) (gin.HandlerFunc, error) {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(spec, &document); err != nil {
+		return nil, fmt.Errorf("parse OpenAPI spec: %w", err)
+	}
+
+	url := basePath
+	if url == "" {
+		url = "/"
+	}
+	servers, err := json.Marshal([]map[string]string{{"url": url}})
+	if err != nil {
+		return nil, err
+	}
+	document["servers"] = servers
+
+	body, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-cache")
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}, nil
+}
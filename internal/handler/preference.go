@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+type PreferenceHandler struct {
+	preferences PreferenceService
+	logger      *slog.Logger
+}
+
+type PreferenceService interface {
+	Get(ctx context.Context, userID uuid.UUID) (*models.UserPreferences, error)
+	Save(ctx context.Context, prefs *models.UserPreferences) error
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
+
+func NewPreferenceHandler(preferences PreferenceService, logger *slog.Logger) *PreferenceHandler {
+	return &PreferenceHandler{
+		preferences: preferences,
+		logger:      logger,
+	}
+}
+
+type preferencesRequest struct {
+	Currency             string   `json:"currency" binding:"omitempty,len=3,alpha"`
+	Timezone             string   `json:"timezone" binding:"max=64"`
+	NotificationChannels []string `json:"notification_channels" binding:"max=10,unique"`
+	ReminderDaysBefore   *int     `json:"reminder_days_before" binding:"omitempty,min=0,max=365"`
+}
+
+func (h *PreferenceHandler) Get(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting user preferences retrieval",
+		slog.String("request_id", requestID),
+		slog.String("method", "GetPreferences"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	userID, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_user_id"))
+		return
+	}
+
+	prefs, err := h.preferences.Get(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("PreferenceService.Get failed",
+			slog.String("request_id", requestID),
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "get_preferences_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved user preferences",
+		slog.String("request_id", requestID),
+		slog.String("user_id", userID.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// Save replaces the preferences of a user with the request body. Fields left out are
+// cleared, falling back to the defaults of the service.
+func (h *PreferenceHandler) Save(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting user preferences save",
+		slog.String("request_id", requestID),
+		slog.String("method", "SavePreferences"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	userID, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_user_id"))
+		return
+	}
+
+	var req preferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for user preferences",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	prefs := &models.UserPreferences{
+		UserID:               userID,
+		Currency:             req.Currency,
+		Timezone:             req.Timezone,
+		NotificationChannels: req.NotificationChannels,
+		ReminderDaysBefore:   req.ReminderDaysBefore,
+	}
+	if err := h.preferences.Save(c.Request.Context(), prefs); err != nil {
+		h.logger.Error("PreferenceService.Save failed",
+			slog.String("request_id", requestID),
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "save_preferences_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully saved user preferences",
+		slog.String("request_id", requestID),
+		slog.String("user_id", userID.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+func (h *PreferenceHandler) Delete(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting user preferences deletion",
+		slog.String("request_id", requestID),
+		slog.String("method", "DeletePreferences"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	userID, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_user_id"))
+		return
+	}
+
+	if err := h.preferences.Delete(c.Request.Context(), userID); err != nil {
+		h.logger.Error("PreferenceService.Delete failed",
+			slog.String("request_id", requestID),
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "delete_preferences_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully deleted user preferences",
+		slog.String("request_id", requestID),
+		slog.String("user_id", userID.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+const (
+	defaultMaxPrice       = 1_000_000
+	defaultQualitySamples = 10
+	maxQualitySamples     = 100
+)
+
+type DataQualityHandler struct {
+	quality DataQualityService
+	logger  *slog.Logger
+}
+
+type DataQualityService interface {
+	Report(ctx context.Context, rules models.QualityRules) (*models.QualityReport, error)
+}
+
+func NewDataQualityHandler(quality DataQualityService, logger *slog.Logger) *DataQualityHandler {
+	return &DataQualityHandler{
+		quality: quality,
+		logger:  logger,
+	}
+}
+
+func (h *DataQualityHandler) Report(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting data quality report",
+		slog.String("request_id", requestID),
+		slog.String("method", "DataQuality"),
+		slog.String("client_ip", c.ClientIP()))
+
+	rules := models.QualityRules{MaxPrice: defaultMaxPrice, Samples: defaultQualitySamples}
+	if param := c.Query("max_price"); param != "" {
+		maxPrice, err := strconv.Atoi(param)
+		if err != nil || maxPrice <= 0 {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "max_price"))
+			return
+		}
+		rules.MaxPrice = maxPrice
+	}
+	if param := c.Query("samples"); param != "" {
+		samples, err := strconv.Atoi(param)
+		if err != nil || samples < 0 || samples > maxQualitySamples {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "samples"))
+			return
+		}
+		rules.Samples = samples
+	}
+
+	report, err := h.quality.Report(c.Request.Context(), rules)
+	if err != nil {
+		h.logger.Error("Data quality report failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "data_quality_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully built data quality report",
+		slog.String("request_id", requestID),
+		slog.Int("scanned", report.Scanned),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"max_price": rules.MaxPrice,
+		"scanned":   report.Scanned,
+		"checks":    report.Checks,
+	})
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/quota"
+)
+
+type QuotaHandler struct {
+	quotas QuotaService
+	logger *slog.Logger
+}
+
+type QuotaService interface {
+	Current(ctx context.Context, id identity.Identity) ([]quota.Status, error)
+}
+
+func NewQuotaHandler(quotas QuotaService, logger *slog.Logger) *QuotaHandler {
+	return &QuotaHandler{
+		quotas: quotas,
+		logger: logger,
+	}
+}
+
+func (h *QuotaHandler) Current(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	id := identity.FromContext(c.Request.Context())
+
+	h.logger.Info("Starting quota usage retrieval",
+		slog.String("request_id", requestID),
+		slog.String("method", "Current"),
+		slog.String("principal", id.Principal()),
+		slog.String("client_ip", c.ClientIP()))
+
+	statuses, err := h.quotas.Current(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Quota usage retrieval failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "quota_usage_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved quota usage",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"quotas": statuses})
+}
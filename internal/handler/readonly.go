@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/model"
+)
+
+type ReadOnlyHandler struct {
+	mode   ReadOnlySwitch
+	logger *slog.Logger
+}
+
+type ReadOnlySwitch interface {
+	Status() models.ReadOnlyStatus
+	Enable(reason string)
+	Disable()
+}
+
+func NewReadOnlyHandler(mode ReadOnlySwitch, logger *slog.Logger) *ReadOnlyHandler {
+	return &ReadOnlyHandler{
+		mode:   mode,
+		logger: logger,
+	}
+}
+
+func (h *ReadOnlyHandler) Status(c *gin.Context) {
+	c.JSON(http.StatusOK, h.mode.Status())
+}
+
+// Switch turns read-only mode on or off on this instance. Switching it off also ends
+// an automatic read-only mode, e.g. once the primary is known to be back.
+func (h *ReadOnlyHandler) Switch(c *gin.Context) {
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting read-only mode switch",
+		slog.String("request_id", requestID),
+		slog.String("method", "SwitchReadOnly"),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		Enabled *bool  `json:"enabled" binding:"required"`
+		Reason  string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for read-only mode",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	if *req.Enabled {
+		h.mode.Enable(req.Reason)
+	} else {
+		h.mode.Disable()
+	}
+
+	status := h.mode.Status()
+	h.logger.Info("Successfully switched read-only mode",
+		slog.String("request_id", requestID),
+		slog.Bool("enabled", status.Enabled))
+
+	c.JSON(http.StatusOK, status)
+}
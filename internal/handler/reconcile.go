@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/service"
+)
+
+type ReconciliationHandler struct {
+	reconciler ReconciliationService
+	logger     *slog.Logger
+}
+
+type ReconciliationService interface {
+	Tolerance() models.ReconciliationTolerance
+	Reconcile(ctx context.Context, startDateStr string, endDateStr string, r io.Reader, tolerance models.ReconciliationTolerance) (*models.ReconciliationReport, error)
+}
+
+func NewReconciliationHandler(reconciler ReconciliationService, logger *slog.Logger) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		reconciler: reconciler,
+		logger:     logger,
+	}
+}
+
+// Reconcile compares the CSV statement in the request body with the charges recorded
+// for the period. amount_tolerance and date_tolerance_days override the configured
+// tolerance.
+func (h *ReconciliationHandler) Reconcile(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting billing reconciliation",
+		slog.String("request_id", requestID),
+		slog.String("method", "Reconcile"),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		StartDate         string `form:"start_date" binding:"required"`
+		EndDate           string `form:"end_date" binding:"required"`
+		AmountTolerance   string `form:"amount_tolerance"`
+		DateToleranceDays *int   `form:"date_tolerance_days" binding:"omitempty,min=0"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Failed to bind query for reconciliation",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	tolerance := h.reconciler.Tolerance()
+	if req.AmountTolerance != "" {
+		amount, err := decimal.NewFromString(req.AmountTolerance)
+		if err != nil || amount.Sign() < 0 {
+			h.logger.Warn("Invalid amount tolerance provided",
+				slog.String("request_id", requestID),
+				slog.String("amount_tolerance", req.AmountTolerance))
+
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "amount_tolerance"))
+			return
+		}
+		tolerance.Amount = amount
+	}
+	if req.DateToleranceDays != nil {
+		tolerance.Days = *req.DateToleranceDays
+	}
+
+	report, err := h.reconciler.Reconcile(c.Request.Context(), req.StartDate, req.EndDate, c.Request.Body, tolerance)
+	if err != nil {
+		h.logger.Error("ReconciliationService.Reconcile failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		if errors.Is(err, service.ErrInvalidStatement) {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_statement", err.Error()))
+			return
+		}
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "reconcile_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully reconciled billing statement",
+		slog.String("request_id", requestID),
+		slog.Int("matched", len(report.Matched)),
+		slog.Int("mismatched", len(report.Mismatched)),
+		slog.Int("missing", len(report.Missing)),
+		slog.Int("unexpected", len(report.Unexpected)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, report)
+}
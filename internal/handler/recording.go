@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/recording"
+)
+
+type RecordingHandler struct {
+	recorder RequestRecorder
+	logger   *slog.Logger
+}
+
+type RequestRecorder interface {
+	Start(filter models.RecordingFilter, limit int, duration time.Duration) models.Capture
+	Stop() (models.Capture, bool)
+	Current() (models.Capture, bool)
+	List(ctx context.Context, filter models.RecordedRequestFilter) ([]models.RecordedRequest, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.RecordedRequest, error)
+	Purge(ctx context.Context, filter models.RecordedRequestFilter) (int64, error)
+}
+
+func NewRecordingHandler(recorder RequestRecorder, logger *slog.Logger) *RecordingHandler {
+	return &RecordingHandler{
+		recorder: recorder,
+		logger:   logger,
+	}
+}
+
+// StartCapture starts recording the requests matching the filter in the body,
+// replacing the running capture.
+func (h *RecordingHandler) StartCapture(c *gin.Context) {
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting request capture",
+		slog.String("request_id", requestID),
+		slog.String("method", "StartCapture"),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		models.RecordingFilter
+		Limit    int    `json:"limit" binding:"gte=0"`
+		Duration string `json:"duration,omitempty"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for request capture",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		parsed, err := time.ParseDuration(req.Duration)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "validation_invalid", "duration"))
+			return
+		}
+		duration = parsed
+	}
+
+	capture := h.recorder.Start(req.RecordingFilter, req.Limit, duration)
+
+	h.logger.Info("Successfully started request capture",
+		slog.String("request_id", requestID),
+		slog.String("capture_id", capture.ID.String()))
+
+	c.JSON(http.StatusCreated, capture)
+}
+
+func (h *RecordingHandler) CurrentCapture(c *gin.Context) {
+	capture, ok := h.recorder.Current()
+	if !ok {
+		c.JSON(http.StatusNotFound, i18n.ErrorBody(c, "no_capture_running"))
+		return
+	}
+	c.JSON(http.StatusOK, capture)
+}
+
+func (h *RecordingHandler) StopCapture(c *gin.Context) {
+	requestID := uuid.New().String()
+
+	h.logger.Info("Stopping request capture",
+		slog.String("request_id", requestID),
+		slog.String("method", "StopCapture"),
+		slog.String("client_ip", c.ClientIP()))
+
+	capture, ok := h.recorder.Stop()
+	if !ok {
+		c.JSON(http.StatusNotFound, i18n.ErrorBody(c, "no_capture_running"))
+		return
+	}
+	c.JSON(http.StatusOK, capture)
+}
+
+func (h *RecordingHandler) List(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting recorded request listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "ListRecordings"),
+		slog.String("client_ip", c.ClientIP()))
+
+	filter, ok := recordingFilterFromQuery(c)
+	if !ok {
+		return
+	}
+	filter.Limit = 100
+	if limitParam := c.Query("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "limit"))
+			return
+		}
+		filter.Limit = min(limit, recording.MaxLimit)
+	}
+
+	recorded, err := h.recorder.List(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Recorded request listing failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_recordings_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved recorded requests",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(recorded)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"recordings": recorded})
+}
+
+func (h *RecordingHandler) Get(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting recorded request retrieval",
+		slog.String("request_id", requestID),
+		slog.String("method", "GetRecording"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_recording_id"))
+		return
+	}
+
+	recorded, err := h.recorder.Get(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Recorded request retrieval failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		if errors.Is(err, recording.ErrRecordingNotFound) {
+			c.JSON(http.StatusNotFound, i18n.ErrorBody(c, "recording_not_found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "get_recording_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved recorded request",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, recorded)
+}
+
+// Purge deletes the recorded requests matching the query filters. As with dead
+// letters, at least one filter or all=true is required.
+func (h *RecordingHandler) Purge(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting recorded request purge",
+		slog.String("request_id", requestID),
+		slog.String("method", "PurgeRecordings"),
+		slog.String("client_ip", c.ClientIP()))
+
+	filter, ok := recordingFilterFromQuery(c)
+	if !ok {
+		return
+	}
+	if filter.CaptureID == nil && filter.Principal == "" && filter.Since == nil && c.Query("all") != "true" {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "purge_recordings_filter_required"))
+		return
+	}
+
+	purged, err := h.recorder.Purge(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Recorded request purge failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "purge_recordings_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully purged recorded requests",
+		slog.String("request_id", requestID),
+		slog.Int64("purged", purged),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
+
+func recordingFilterFromQuery(c *gin.Context) (models.RecordedRequestFilter, bool) {
+	filter := models.RecordedRequestFilter{Principal: c.Query("principal")}
+
+	if captureParam := c.Query("capture_id"); captureParam != "" {
+		captureID, err := uuid.Parse(captureParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "capture_id"))
+			return filter, false
+		}
+		filter.CaptureID = &captureID
+	}
+
+	if sinceParam := c.Query("since"); sinceParam != "" {
+		since, err := time.Parse(time.RFC3339, sinceParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_query_param", "since"))
+			return filter, false
+		}
+		filter.Since = &since
+	}
+
+	return filter, true
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+type ReminderHandler struct {
+	reminders ReminderService
+	logger    *slog.Logger
+}
+
+type ReminderService interface {
+	Create(ctx context.Context, subscriptionID uuid.UUID, daysBefore *int, message string, channel string) (*models.Reminder, error)
+	List(ctx context.Context, subscriptionID uuid.UUID) ([]models.Reminder, error)
+	Delete(ctx context.Context, subscriptionID uuid.UUID, id uuid.UUID) error
+}
+
+func NewReminderHandler(reminders ReminderService, logger *slog.Logger) *ReminderHandler {
+	return &ReminderHandler{
+		reminders: reminders,
+		logger:    logger,
+	}
+}
+
+func (h *ReminderHandler) Create(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting reminder creation",
+		slog.String("request_id", requestID),
+		slog.String("method", "CreateReminder"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	subscriptionID, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_subscription_id"))
+		return
+	}
+
+	var req struct {
+		DaysBefore *int   `json:"days_before,omitempty" binding:"omitempty,min=0,max=365"`
+		Message    string `json:"message,omitempty" binding:"max=1000"`
+		Channel    string `json:"channel,omitempty"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for reminder",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	reminder, err := h.reminders.Create(c.Request.Context(), subscriptionID, req.DaysBefore, req.Message, req.Channel)
+	if err != nil {
+		h.logger.Error("ReminderService.Create failed",
+			slog.String("request_id", requestID),
+			slog.String("subscription_id", subscriptionID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "create_reminder_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully created reminder",
+		slog.String("request_id", requestID),
+		slog.String("reminder_id", reminder.ID.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusCreated, reminder)
+}
+
+func (h *ReminderHandler) List(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting reminder listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "ListReminders"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	subscriptionID, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_subscription_id"))
+		return
+	}
+
+	reminders, err := h.reminders.List(c.Request.Context(), subscriptionID)
+	if err != nil {
+		h.logger.Error("ReminderService.List failed",
+			slog.String("request_id", requestID),
+			slog.String("subscription_id", subscriptionID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "list_reminders_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved reminders",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(reminders)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"reminders": reminders})
+}
+
+func (h *ReminderHandler) Delete(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+	reminderIDParam := c.Param("reminder_id")
+
+	h.logger.Info("Starting reminder deletion",
+		slog.String("request_id", requestID),
+		slog.String("method", "DeleteReminder"),
+		slog.String("id_param", idParam),
+		slog.String("reminder_id_param", reminderIDParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	subscriptionID, err := uuid.Parse(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_subscription_id"))
+		return
+	}
+	reminderID, err := uuid.Parse(reminderIDParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_reminder_id"))
+		return
+	}
+
+	if err := h.reminders.Delete(c.Request.Context(), subscriptionID, reminderID); err != nil {
+		h.logger.Error("ReminderService.Delete failed",
+			slog.String("request_id", requestID),
+			slog.String("reminder_id", reminderID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "delete_reminder_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully deleted reminder",
+		slog.String("request_id", requestID),
+		slog.String("reminder_id", reminderID.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/model"
+)
+
+// defaultSearchLimit is the page size of a search without limit.
+const defaultSearchLimit = 20
+
+// defaultSuggestLimit is the number of service names suggested without limit.
+const defaultSuggestLimit = 10
+
+type SearchHandler struct {
+	search SearchService
+	logger *slog.Logger
+}
+
+type SearchService interface {
+	Search(ctx context.Context, q models.SearchQuery) (*models.SearchResult, error)
+	Suggest(ctx context.Context, prefix string, limit int) ([]models.ServiceSuggestion, error)
+}
+
+type searchCollection struct {
+	Embedded struct {
+		Subscriptions []any `json:"subscriptions"`
+	} `json:"_embedded"`
+	Total  int64                          `json:"total"`
+	Facets map[string][]models.FacetCount `json:"facets"`
+	Engine string                         `json:"engine"`
+	Links  links                          `json:"_links"`
+}
+
+func NewSearchHandler(search SearchService, logger *slog.Logger) *SearchHandler {
+	return &SearchHandler{
+		search: search,
+		logger: logger,
+	}
+}
+
+// Search finds subscriptions by service name text and facet filters, with the facet
+// counts of all matches.
+func (h *SearchHandler) Search(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting subscription search",
+		slog.String("request_id", requestID),
+		slog.String("method", "Search"),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		Text       string   `form:"q" binding:"max=200"`
+		UserID     string   `form:"user_id" binding:"omitempty,uuid"`
+		Kinds      []string `form:"kind" binding:"max=3,dive,oneof=recurring one_time lifetime"`
+		Categories []string `form:"category" binding:"max=100"`
+		Statuses   []string `form:"status" binding:"max=2,dive,oneof=active scheduled"`
+		MinPrice   *int     `form:"min_price" binding:"omitempty,min=0"`
+		MaxPrice   *int     `form:"max_price" binding:"omitempty,min=0"`
+		Limit      int      `form:"limit" binding:"omitempty,min=1,max=100"`
+		Offset     int      `form:"offset" binding:"omitempty,min=0,max=9900"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Failed to bind query for subscription search",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultSearchLimit
+	}
+
+	q := models.SearchQuery{
+		Text:       req.Text,
+		Kinds:      req.Kinds,
+		Categories: req.Categories,
+		Statuses:   req.Statuses,
+		MinPrice:   req.MinPrice,
+		MaxPrice:   req.MaxPrice,
+		Page:       models.Page{Limit: req.Limit, Offset: req.Offset},
+	}
+	if req.UserID != "" {
+		q.UserID = uuid.MustParse(req.UserID)
+	}
+
+	result, err := h.search.Search(c.Request.Context(), q)
+	if err != nil {
+		h.logger.Error("SearchService.Search failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "search_subscriptions_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully searched subscriptions",
+		slog.String("request_id", requestID),
+		slog.Int64("total", result.Total),
+		slog.String("engine", result.Engine),
+		slog.Duration("duration", time.Since(start)))
+
+	var collection searchCollection
+	collection.Embedded.Subscriptions = make([]any, 0, len(result.Subscriptions))
+	for i := range result.Subscriptions {
+		collection.Embedded.Subscriptions = append(collection.Embedded.Subscriptions, newSubscriptionResource(c, &result.Subscriptions[i]))
+	}
+	collection.Total = result.Total
+	collection.Facets = result.Facets
+	collection.Engine = result.Engine
+	collection.Links = pageLinks(c, q.Page, int64(q.Page.Offset+len(result.Subscriptions)) < result.Total)
+
+	respondVersioned(c, http.StatusOK, collection)
+}
+
+// Suggest completes a service name prefix for form autocompletion, with the names in
+// use and the catalog services, most used first.
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting service name suggestion",
+		slog.String("request_id", requestID),
+		slog.String("method", "Suggest"),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		Prefix string `form:"q" binding:"required,max=100"`
+		Limit  int    `form:"limit" binding:"omitempty,min=1,max=50"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Failed to bind query for service name suggestion",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultSuggestLimit
+	}
+
+	suggestions, err := h.search.Suggest(c.Request.Context(), req.Prefix, req.Limit)
+	if err != nil {
+		h.logger.Error("SearchService.Suggest failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "suggest_services_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully suggested service names",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(suggestions)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+type SLOHandler struct {
+	tracker SLOTracker
+	logger  *slog.Logger
+}
+
+type SLOTracker interface {
+	Status() []models.SLOStatus
+	WriteRules(w io.Writer) error
+}
+
+func NewSLOHandler(tracker SLOTracker, logger *slog.Logger) *SLOHandler {
+	return &SLOHandler{
+		tracker: tracker,
+		logger:  logger,
+	}
+}
+
+// Status reports the burn rates of the configured objectives on this instance.
+func (h *SLOHandler) Status(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"objectives": h.tracker.Status()})
+}
+
+// Rules serves Prometheus alerting rules for the configured objectives, ready to be
+// loaded with rule_files.
+func (h *SLOHandler) Rules(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting SLO rules generation",
+		slog.String("request_id", requestID),
+		slog.String("method", "Rules"),
+		slog.String("client_ip", c.ClientIP()))
+
+	var rules bytes.Buffer
+	if err := h.tracker.WriteRules(&rules); err != nil {
+		h.logger.Error("SLO rules generation failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "slo_rules_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully generated SLO rules",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.Data(http.StatusOK, "application/yaml", rules.Bytes())
+}
@@ -0,0 +1,417 @@
+package handler
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/quota"
+)
+
+const (
+	soapEnvelopeNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soapNamespace         = "urn:subscriptions"
+	soapContentType       = "text/xml; charset=utf-8"
+	soapMaxBodyBytes      = 1 << 20
+)
+
+// SOAPHandler exposes subscription creation, retrieval, listing and aggregation as a
+// SOAP 1.1 service for consumers that cannot speak JSON. Every operation is posted to
+// the same endpoint and named by the element in the SOAP body; its fields carry the
+// names of the JSON API.
+type SOAPHandler struct {
+	service  SubscriptionService
+	readOnly SOAPReadOnly
+	quotas   SOAPQuota
+	pages    PageSizes
+	logger   *slog.Logger
+}
+
+type SOAPReadOnly interface {
+	Active() bool
+}
+
+// SOAPQuota guards creations like the create quota of the JSON API, which applies per
+// route and so cannot tell SOAP operations apart.
+type SOAPQuota interface {
+	ReserveCreates(ctx context.Context, id identity.Identity, count int64) (*quota.Reservation, []quota.Status, error)
+	Release(ctx context.Context, r *quota.Reservation) error
+}
+
+func NewSOAPHandler(service SubscriptionService, readOnly SOAPReadOnly, quotas SOAPQuota, pages PageSizes, logger *slog.Logger) *SOAPHandler {
+	return &SOAPHandler{
+		service:  service,
+		readOnly: readOnly,
+		quotas:   quotas,
+		pages:    pages,
+		logger:   logger,
+	}
+}
+
+type soapSubscription struct {
+	ID               uuid.UUID  `xml:"id"`
+	ServiceName      string     `xml:"service_name"`
+	Price            int        `xml:"price"`
+	UserID           uuid.UUID  `xml:"user_id"`
+	Kind             string     `xml:"kind"`
+	BillingPeriod    string     `xml:"billing_period"`
+	BillingAnchorDay int        `xml:"billing_anchor_day"`
+	StartDate        time.Time  `xml:"start_date"`
+	EndDate          *time.Time `xml:"end_date,omitempty"`
+	Category         *string    `xml:"category,omitempty"`
+}
+
+func newSOAPSubscription(sub *models.Subscription) soapSubscription {
+	return soapSubscription{
+		ID:               sub.ID,
+		ServiceName:      sub.ServiceName,
+		Price:            sub.Price,
+		UserID:           sub.UserID,
+		Kind:             sub.Kind,
+		BillingPeriod:    sub.BillingPeriod,
+		BillingAnchorDay: sub.BillingAnchorDay,
+		StartDate:        sub.StartDate,
+		EndDate:          sub.EndDate,
+		Category:         sub.Category,
+	}
+}
+
+type soapSubscriptionResponse struct {
+	XMLName      xml.Name
+	Subscription soapSubscription `xml:"subscription"`
+}
+
+type soapListResponse struct {
+	XMLName       xml.Name           `xml:"urn:subscriptions ListSubscriptionsResponse"`
+	Subscriptions []soapSubscription `xml:"subscription"`
+}
+
+type soapAggregateResponse struct {
+	XMLName xml.Name        `xml:"urn:subscriptions AggregateSubscriptionsResponse"`
+	Total   decimal.Decimal `xml:"total"`
+	Mode    string          `xml:"mode"`
+}
+
+type soapFault struct {
+	XMLName xml.Name `xml:"soap:Fault"`
+	Code    string   `xml:"faultcode"`
+	String  string   `xml:"faultstring"`
+	Detail  struct {
+		Code string `xml:"code"`
+		Hint string `xml:"hint,omitempty"`
+	} `xml:"detail"`
+}
+
+type soapResponseEnvelope struct {
+	XMLName   xml.Name `xml:"soap:Envelope"`
+	Namespace string   `xml:"xmlns:soap,attr"`
+	Body      struct {
+		Content any
+	} `xml:"soap:Body"`
+}
+
+// The request fields carry json tags too, so that validation errors name them as the
+// JSON API does.
+type soapCreateRequest struct {
+	ServiceName      string    `xml:"service_name" json:"service_name" binding:"required"`
+	Price            int       `xml:"price" json:"price" binding:"required,gt=0"`
+	UserID           uuid.UUID `xml:"user_id" json:"user_id" binding:"required"`
+	Kind             string    `xml:"kind" json:"kind" binding:"omitempty,oneof=recurring one_time lifetime"`
+	BillingPeriod    string    `xml:"billing_period" json:"billing_period" binding:"omitempty,oneof=weekly monthly quarterly yearly"`
+	BillingAnchorDay int       `xml:"billing_anchor_day" json:"billing_anchor_day" binding:"omitempty,min=1,max=31"`
+	StartDate        string    `xml:"start_date" json:"start_date" binding:"required"`
+	EndDate          string    `xml:"end_date" json:"end_date"`
+	Category         string    `xml:"category" json:"category"`
+}
+
+type soapGetRequest struct {
+	ID string `xml:"id" json:"id" binding:"required"`
+}
+
+type soapListRequest struct {
+	UserID      string `xml:"user_id" json:"user_id"`
+	ServiceName string `xml:"service_name" json:"service_name"`
+	Kind        string `xml:"kind" json:"kind" binding:"omitempty,oneof=recurring one_time lifetime"`
+	Category    string `xml:"category" json:"category"`
+	Limit       int    `xml:"limit" json:"limit" binding:"min=0"`
+	Offset      int    `xml:"offset" json:"offset" binding:"min=0"`
+}
+
+type soapAggregateRequest struct {
+	StartDate    string   `xml:"start_date" json:"start_date" binding:"required"`
+	EndDate      string   `xml:"end_date" json:"end_date" binding:"required"`
+	UserIDs      []string `xml:"user_id" json:"user_id" binding:"max=100"`
+	ServiceNames []string `xml:"service_name" json:"service_name" binding:"max=100"`
+	Kinds        []string `xml:"kind" json:"kind" binding:"max=3,dive,oneof=recurring one_time lifetime"`
+	Categories   []string `xml:"category" json:"category" binding:"max=100"`
+	Mode         string   `xml:"mode" json:"mode" binding:"omitempty,oneof=normalized exact"`
+}
+
+// Serve reads the SOAP envelope and runs the operation in its body. Errors are
+// answered with a SOAP fault carrying the code and localized message of the JSON API.
+func (h *SOAPHandler) Serve(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting SOAP request",
+		slog.String("request_id", requestID),
+		slog.String("method", "SOAP"),
+		slog.String("soap_action", c.GetHeader("SOAPAction")),
+		slog.String("client_ip", c.ClientIP()))
+
+	decoder, operation, err := readSOAPOperation(http.MaxBytesReader(c.Writer, c.Request.Body, soapMaxBodyBytes))
+	if err != nil {
+		h.logger.Error("Failed to read SOAP envelope",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		h.fault(c, http.StatusBadRequest, i18n.ErrorBody(c, "invalid_soap_request"))
+		return
+	}
+
+	var response any
+	var status int
+	var body gin.H
+	switch operation.Name.Local {
+	case "CreateSubscription":
+		response, status, body = h.create(c, decoder, operation)
+	case "GetSubscription":
+		response, status, body = h.get(c, decoder, operation)
+	case "ListSubscriptions":
+		response, status, body = h.list(c, decoder, operation)
+	case "AggregateSubscriptions":
+		response, status, body = h.aggregate(c, decoder, operation)
+	default:
+		status, body = http.StatusBadRequest, i18n.ErrorBody(c, "unknown_soap_operation", operation.Name.Local)
+	}
+
+	if body != nil {
+		h.logger.Error("SOAP operation failed",
+			slog.String("request_id", requestID),
+			slog.String("operation", operation.Name.Local),
+			slog.Any("code", body["code"]),
+			slog.Duration("duration", time.Since(start)))
+
+		h.fault(c, status, body)
+		return
+	}
+
+	h.logger.Info("Successfully handled SOAP operation",
+		slog.String("request_id", requestID),
+		slog.String("operation", operation.Name.Local),
+		slog.Duration("duration", time.Since(start)))
+
+	h.respond(c, http.StatusOK, response)
+}
+
+func (h *SOAPHandler) create(c *gin.Context, decoder *xml.Decoder, operation xml.StartElement) (any, int, gin.H) {
+	var req soapCreateRequest
+	if status, body := decodeSOAPOperation(c, decoder, operation, &req); body != nil {
+		return nil, status, body
+	}
+
+	if h.readOnly.Active() {
+		c.Header("Retry-After", "30")
+		return nil, http.StatusServiceUnavailable, i18n.ErrorBody(c, "read_only_mode")
+	}
+
+	ctx := c.Request.Context()
+	id := identity.FromContext(ctx)
+	reservation, _, err := h.quotas.ReserveCreates(ctx, id, 1)
+	if err != nil {
+		h.logger.Error("Create quota reservation failed, allowing request",
+			slog.String("principal", id.Principal()),
+			slog.String("error", err.Error()))
+	} else if reservation == nil {
+		return nil, http.StatusPaymentRequired, i18n.ErrorBody(c, "quota_creates_exhausted")
+	}
+
+	sub, err := h.service.Create(ctx, req.ServiceName, req.Price, req.UserID, req.Kind, req.BillingPeriod, req.BillingAnchorDay, req.StartDate, req.EndDate, "", req.Category, nil, false)
+	if err != nil {
+		h.logger.Error("Service.Create failed",
+			slog.String("error", err.Error()))
+		if reservation != nil {
+			h.quotas.Release(context.WithoutCancel(ctx), reservation)
+		}
+		status, body := serviceError(c, err, http.StatusBadRequest, "create_subscription_failed")
+		return nil, status, body
+	}
+
+	return soapSubscriptionResponse{
+		XMLName:      xml.Name{Space: soapNamespace, Local: "CreateSubscriptionResponse"},
+		Subscription: newSOAPSubscription(sub),
+	}, http.StatusOK, nil
+}
+
+func (h *SOAPHandler) get(c *gin.Context, decoder *xml.Decoder, operation xml.StartElement) (any, int, gin.H) {
+	var req soapGetRequest
+	if status, body := decodeSOAPOperation(c, decoder, operation, &req); body != nil {
+		return nil, status, body
+	}
+
+	id, err := uuid.Parse(req.ID)
+	if err != nil {
+		return nil, http.StatusBadRequest, i18n.ErrorBody(c, "invalid_subscription_id")
+	}
+
+	sub, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusNotFound, i18n.ErrorBody(c, "subscription_not_found")
+		}
+		h.logger.Error("Service.GetByID failed",
+			slog.String("subscription_id", id.String()),
+			slog.String("error", err.Error()))
+		return nil, http.StatusInternalServerError, i18n.ErrorBody(c, "get_subscription_failed")
+	}
+
+	return soapSubscriptionResponse{
+		XMLName:      xml.Name{Space: soapNamespace, Local: "GetSubscriptionResponse"},
+		Subscription: newSOAPSubscription(sub),
+	}, http.StatusOK, nil
+}
+
+func (h *SOAPHandler) list(c *gin.Context, decoder *xml.Decoder, operation xml.StartElement) (any, int, gin.H) {
+	var req soapListRequest
+	if status, body := decodeSOAPOperation(c, decoder, operation, &req); body != nil {
+		return nil, status, body
+	}
+
+	var userID uuid.UUID
+	if req.UserID != "" {
+		parsed, err := uuid.Parse(req.UserID)
+		if err != nil {
+			return nil, http.StatusBadRequest, i18n.ErrorBody(c, "validation_invalid", "user_id")
+		}
+		userID = parsed
+	}
+
+	// An absent limit decodes as zero, so SOAP clients cannot ask for an empty page.
+	filter := models.ListFilter{UserID: userID, ServiceName: req.ServiceName, Kind: req.Kind, Category: req.Category}
+	subs, err := h.service.List(c.Request.Context(), filter, models.Page{Limit: h.pages.limit(req.Limit, req.Limit > 0), Offset: req.Offset})
+	if err != nil {
+		h.logger.Error("Service.List failed",
+			slog.String("error", err.Error()))
+		status, body := serviceError(c, err, http.StatusInternalServerError, "list_subscriptions_failed")
+		return nil, status, body
+	}
+
+	response := soapListResponse{Subscriptions: make([]soapSubscription, 0, len(subs))}
+	for i := range subs {
+		response.Subscriptions = append(response.Subscriptions, newSOAPSubscription(&subs[i]))
+	}
+	return response, http.StatusOK, nil
+}
+
+func (h *SOAPHandler) aggregate(c *gin.Context, decoder *xml.Decoder, operation xml.StartElement) (any, int, gin.H) {
+	var req soapAggregateRequest
+	if status, body := decodeSOAPOperation(c, decoder, operation, &req); body != nil {
+		return nil, status, body
+	}
+
+	filter := models.AggregateFilter{
+		ServiceNames: req.ServiceNames,
+		Kinds:        req.Kinds,
+		Categories:   req.Categories,
+	}
+	for _, value := range req.UserIDs {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			return nil, http.StatusBadRequest, i18n.ErrorBody(c, "validation_invalid", "user_id")
+		}
+		filter.UserIDs = append(filter.UserIDs, userID)
+	}
+
+	total, err := h.service.Aggregate(c.Request.Context(), req.StartDate, req.EndDate, req.Mode, filter)
+	if err != nil {
+		h.logger.Error("Service.Aggregate failed",
+			slog.String("error", err.Error()))
+		status, body := serviceError(c, err, http.StatusInternalServerError, "aggregate_failed")
+		return nil, status, body
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = models.AggregateNormalized
+	}
+	return soapAggregateResponse{Total: total, Mode: mode}, http.StatusOK, nil
+}
+
+// fault answers with a SOAP fault. SOAP 1.1 sends every fault with status 500; status
+// only tells whether the client or the server is to blame.
+func (h *SOAPHandler) fault(c *gin.Context, status int, body gin.H) {
+	fault := soapFault{Code: "soap:Server"}
+	if status < http.StatusInternalServerError {
+		fault.Code = "soap:Client"
+	}
+	fault.String, _ = body["error"].(string)
+	fault.Detail.Code, _ = body["code"].(string)
+	fault.Detail.Hint, _ = body["hint"].(string)
+	h.respond(c, http.StatusInternalServerError, fault)
+}
+
+func (h *SOAPHandler) respond(c *gin.Context, status int, content any) {
+	envelope := soapResponseEnvelope{Namespace: soapEnvelopeNamespace}
+	envelope.Body.Content = content
+
+	out, err := xml.Marshal(envelope)
+	if err != nil {
+		h.logger.Error("Failed to encode SOAP response",
+			slog.String("error", err.Error()))
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, soapContentType, append([]byte(xml.Header), out...))
+}
+
+// readSOAPOperation reads r up to the first element in the SOAP body, the operation,
+// and returns the decoder positioned right after its start.
+func readSOAPOperation(r io.Reader) (*xml.Decoder, xml.StartElement, error) {
+	decoder := xml.NewDecoder(r)
+	inBody := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, xml.StartElement{}, errors.New("no operation in SOAP body")
+			}
+			return nil, xml.StartElement{}, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if inBody {
+			return decoder, start, nil
+		}
+		if start.Name.Space == soapEnvelopeNamespace && start.Name.Local == "Body" {
+			inBody = true
+		}
+	}
+}
+
+// decodeSOAPOperation decodes the fields of operation into req and validates them
+// like the JSON API validates its bodies.
+func decodeSOAPOperation(c *gin.Context, decoder *xml.Decoder, operation xml.StartElement, req any) (int, gin.H) {
+	if err := decoder.DecodeElement(req, &operation); err != nil {
+		return http.StatusBadRequest, i18n.ErrorBody(c, "invalid_soap_request")
+	}
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return http.StatusBadRequest, bindError(c, err)
+	}
+	return 0, nil
+}
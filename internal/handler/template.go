@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/templates"
+)
+
+type TemplateHandler struct {
+	templates TemplateService
+	logger    *slog.Logger
+}
+
+type TemplateService interface {
+	List(ctx context.Context, tenant string) ([]templates.Template, error)
+	Get(ctx context.Context, tenant string, name string) (*templates.Template, error)
+	Save(ctx context.Context, override *models.TemplateOverride) error
+	Delete(ctx context.Context, tenant string, name string) error
+	Preview(ctx context.Context, tenant string, name string, draft *models.TemplateOverride) (templates.Rendered, error)
+}
+
+func NewTemplateHandler(templates TemplateService, logger *slog.Logger) *TemplateHandler {
+	return &TemplateHandler{
+		templates: templates,
+		logger:    logger,
+	}
+}
+
+type templateRequest struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+func (h *TemplateHandler) List(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	tenant := templateTenant(c)
+
+	h.logger.Info("Starting template listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "ListTemplates"),
+		slog.String("tenant", tenant),
+		slog.String("client_ip", c.ClientIP()))
+
+	list, err := h.templates.List(c.Request.Context(), tenant)
+	if err != nil {
+		h.logger.Error("TemplateService.List failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_templates_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved templates",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(list)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"tenant": tenant, "templates": list})
+}
+
+func (h *TemplateHandler) Get(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	tenant := templateTenant(c)
+	name := c.Param("name")
+
+	h.logger.Info("Starting template retrieval",
+		slog.String("request_id", requestID),
+		slog.String("method", "GetTemplate"),
+		slog.String("tenant", tenant),
+		slog.String("template", name),
+		slog.String("client_ip", c.ClientIP()))
+
+	tmpl, err := h.templates.Get(c.Request.Context(), tenant, name)
+	if err != nil {
+		h.logger.Error("TemplateService.Get failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(templateError(c, err, "get_template_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved template",
+		slog.String("request_id", requestID),
+		slog.Bool("overridden", tmpl.Overridden),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+func (h *TemplateHandler) Save(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	tenant := templateTenant(c)
+	name := c.Param("name")
+
+	h.logger.Info("Starting template override save",
+		slog.String("request_id", requestID),
+		slog.String("method", "SaveTemplate"),
+		slog.String("tenant", tenant),
+		slog.String("template", name),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req templateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for template override",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	override := &models.TemplateOverride{
+		Tenant:  tenant,
+		Name:    name,
+		Subject: req.Subject,
+		Text:    req.Text,
+		HTML:    req.HTML,
+	}
+	if err := h.templates.Save(c.Request.Context(), override); err != nil {
+		h.logger.Error("TemplateService.Save failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(templateError(c, err, "save_template_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully saved template override",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, override)
+}
+
+func (h *TemplateHandler) Delete(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	tenant := templateTenant(c)
+	name := c.Param("name")
+
+	h.logger.Info("Starting template override deletion",
+		slog.String("request_id", requestID),
+		slog.String("method", "DeleteTemplate"),
+		slog.String("tenant", tenant),
+		slog.String("template", name),
+		slog.String("client_ip", c.ClientIP()))
+
+	if err := h.templates.Delete(c.Request.Context(), tenant, name); err != nil {
+		h.logger.Error("TemplateService.Delete failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(templateError(c, err, "delete_template_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully deleted template override",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.Status(http.StatusNoContent)
+}
+
+// Preview renders a template with sample data. A request body is rendered as a draft
+// override; without one the stored template is rendered.
+func (h *TemplateHandler) Preview(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	tenant := templateTenant(c)
+	name := c.Param("name")
+
+	h.logger.Info("Starting template preview",
+		slog.String("request_id", requestID),
+		slog.String("method", "PreviewTemplate"),
+		slog.String("tenant", tenant),
+		slog.String("template", name),
+		slog.String("client_ip", c.ClientIP()))
+
+	var draft *models.TemplateOverride
+	if c.Request.ContentLength != 0 {
+		var req templateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.Error("Failed to bind JSON request for template preview",
+				slog.String("request_id", requestID),
+				slog.String("error", err.Error()),
+				slog.Duration("duration", time.Since(start)))
+
+			c.JSON(http.StatusBadRequest, bindError(c, err))
+			return
+		}
+		draft = &models.TemplateOverride{Tenant: tenant, Name: name, Subject: req.Subject, Text: req.Text, HTML: req.HTML}
+	}
+
+	rendered, err := h.templates.Preview(c.Request.Context(), tenant, name, draft)
+	if err != nil {
+		h.logger.Error("TemplateService.Preview failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(templateError(c, err, "preview_template_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully rendered template preview",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, rendered)
+}
+
+// templateTenant returns the tenant whose templates an admin request manages.
+func templateTenant(c *gin.Context) string {
+	if tenant := c.Query("tenant"); tenant != "" {
+		return tenant
+	}
+	return identity.DefaultTenant
+}
+
+func templateError(c *gin.Context, err error, fallbackCode string) (int, gin.H) {
+	switch {
+	case errors.Is(err, templates.ErrUnknownTemplate):
+		return http.StatusNotFound, i18n.ErrorBody(c, "template_not_found")
+	case errors.Is(err, templates.ErrOverrideNotFound):
+		return http.StatusNotFound, i18n.ErrorBody(c, "template_override_not_found")
+	case errors.Is(err, templates.ErrInvalidTemplate):
+		detail := strings.TrimPrefix(err.Error(), templates.ErrInvalidTemplate.Error()+": ")
+		return http.StatusBadRequest, i18n.ErrorBody(c, "invalid_template", detail)
+	default:
+		return http.StatusInternalServerError, i18n.ErrorBody(c, fallbackCode)
+	}
+}
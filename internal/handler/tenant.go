@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+type TenantHandler struct {
+	tenants TenantService
+	logger  *slog.Logger
+}
+
+type TenantService interface {
+	Create(ctx context.Context, name string, isolated bool, webhookURL string) (*models.TenantProvisioning, error)
+	List(ctx context.Context) ([]models.Tenant, error)
+	Suspend(ctx context.Context, tenant string) (*models.Tenant, error)
+	Resume(ctx context.Context, tenant string) (*models.Tenant, error)
+	Delete(ctx context.Context, tenant string) error
+}
+
+func NewTenantHandler(tenants TenantService, logger *slog.Logger) *TenantHandler {
+	return &TenantHandler{
+		tenants: tenants,
+		logger:  logger,
+	}
+}
+
+func (h *TenantHandler) List(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting tenant listing",
+		slog.String("request_id", requestID),
+		slog.String("method", "ListTenants"),
+		slog.String("client_ip", c.ClientIP()))
+
+	tenants, err := h.tenants.List(c.Request.Context())
+	if err != nil {
+		h.logger.Error("TenantService.List failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "list_tenants_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved tenants",
+		slog.String("request_id", requestID),
+		slog.Int("count", len(tenants)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"tenants": tenants})
+}
+
+// Create onboards a tenant. The response holds the secrets of its API key and webhook,
+// which cannot be retrieved later.
+func (h *TenantHandler) Create(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	h.logger.Info("Starting tenant creation",
+		slog.String("request_id", requestID),
+		slog.String("method", "CreateTenant"),
+		slog.String("client_ip", c.ClientIP()))
+
+	var req struct {
+		Name       string `json:"name" binding:"required"`
+		Isolated   bool   `json:"isolated"`
+		WebhookURL string `json:"webhook_url" binding:"omitempty,url"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON request for tenant",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, bindError(c, err))
+		return
+	}
+
+	provisioned, err := h.tenants.Create(c.Request.Context(), req.Name, req.Isolated, req.WebhookURL)
+	if err != nil {
+		h.logger.Error("TenantService.Create failed",
+			slog.String("request_id", requestID),
+			slog.String("tenant", req.Name),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "create_tenant_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully created tenant",
+		slog.String("request_id", requestID),
+		slog.String("tenant", provisioned.Tenant.Name),
+		slog.String("client_id", provisioned.APIKey.ClientID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusCreated, provisioned)
+}
+
+// Suspend rejects the requests of a tenant until it is resumed.
+func (h *TenantHandler) Suspend(c *gin.Context) {
+	h.setStatus(c, "SuspendTenant", "suspend_tenant_failed", h.tenants.Suspend)
+}
+
+func (h *TenantHandler) Resume(c *gin.Context) {
+	h.setStatus(c, "ResumeTenant", "resume_tenant_failed", h.tenants.Resume)
+}
+
+func (h *TenantHandler) setStatus(c *gin.Context, method string, failureCode string, apply func(ctx context.Context, tenant string) (*models.Tenant, error)) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	name := c.Param("name")
+
+	h.logger.Info("Starting tenant status change",
+		slog.String("request_id", requestID),
+		slog.String("method", method),
+		slog.String("tenant", name),
+		slog.String("client_ip", c.ClientIP()))
+
+	tenant, err := apply(c.Request.Context(), name)
+	if err != nil {
+		h.logger.Error("Tenant status change failed",
+			slog.String("request_id", requestID),
+			slog.String("method", method),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, failureCode))
+		return
+	}
+
+	h.logger.Info("Successfully changed tenant status",
+		slog.String("request_id", requestID),
+		slog.String("tenant", name),
+		slog.String("status", tenant.Status),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+// Delete removes a tenant with its API keys and webhooks, and the schema of an
+// isolated one with all its data.
+func (h *TenantHandler) Delete(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	name := c.Param("name")
+
+	h.logger.Info("Starting tenant deletion",
+		slog.String("request_id", requestID),
+		slog.String("method", "DeleteTenant"),
+		slog.String("tenant", name),
+		slog.String("client_ip", c.ClientIP()))
+
+	if err := h.tenants.Delete(c.Request.Context(), name); err != nil {
+		h.logger.Error("TenantService.Delete failed",
+			slog.String("request_id", requestID),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(serviceError(c, err, http.StatusInternalServerError, "delete_tenant_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully deleted tenant",
+		slog.String("request_id", requestID),
+		slog.Duration("duration", time.Since(start)))
+
+	c.Status(http.StatusNoContent)
+}
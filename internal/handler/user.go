@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+type UserHandler struct {
+	timeline TimelineService
+	ltv      LTVService
+	logger   *slog.Logger
+}
+
+type TimelineService interface {
+	Timeline(ctx context.Context, userID uuid.UUID) ([]models.TimelineEvent, error)
+}
+
+type LTVService interface {
+	LifetimeValue(ctx context.Context, userID uuid.UUID) (*models.LifetimeValue, error)
+}
+
+func NewUserHandler(timeline TimelineService, ltv LTVService, logger *slog.Logger) *UserHandler {
+	return &UserHandler{
+		timeline: timeline,
+		ltv:      ltv,
+		logger:   logger,
+	}
+}
+
+func (h *UserHandler) Timeline(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting user timeline retrieval",
+		slog.String("request_id", requestID),
+		slog.String("method", "Timeline"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	userID, err := uuid.Parse(idParam)
+	if err != nil {
+		h.logger.Error("Failed to parse user UUID",
+			slog.String("request_id", requestID),
+			slog.String("id_param", idParam),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_user_id"))
+		return
+	}
+
+	events, err := h.timeline.Timeline(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, i18n.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		h.logger.Error("Service.Timeline failed",
+			slog.String("request_id", requestID),
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "timeline_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved user timeline",
+		slog.String("request_id", requestID),
+		slog.String("user_id", userID.String()),
+		slog.Int("events", len(events)),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "events": events})
+}
+
+func (h *UserHandler) LTV(c *gin.Context) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	idParam := c.Param("id")
+
+	h.logger.Info("Starting user lifetime value retrieval",
+		slog.String("request_id", requestID),
+		slog.String("method", "LTV"),
+		slog.String("id_param", idParam),
+		slog.String("client_ip", c.ClientIP()))
+
+	userID, err := uuid.Parse(idParam)
+	if err != nil {
+		h.logger.Error("Failed to parse user UUID",
+			slog.String("request_id", requestID),
+			slog.String("id_param", idParam),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_user_id"))
+		return
+	}
+
+	value, err := h.ltv.LifetimeValue(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, i18n.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		h.logger.Error("Service.LifetimeValue failed",
+			slog.String("request_id", requestID),
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+
+		c.JSON(http.StatusInternalServerError, i18n.ErrorBody(c, "ltv_failed"))
+		return
+	}
+
+	h.logger.Info("Successfully retrieved user lifetime value",
+		slog.String("request_id", requestID),
+		slog.String("user_id", userID.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	c.JSON(http.StatusOK, value)
+}
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/model"
+)
+
+const (
+	latestAPIVersion = 3
+
+	versionKey        = "api.version"
+	collectionPathKey = "api.collection_path"
+	// currencyKey holds the conversion applied to the money of the response.
+	currencyKey = "api.currency"
+
+	// Prices are stored as whole rubles; v2 makes the currency explicit.
+	priceCurrency = models.PriceCurrency
+
+	monthYearLayout = "01-2006"
+)
+
+var versionedMediaType = regexp.MustCompile(`application/vnd\.subscriptions\.v(\d+)\+json`)
+
+// APIVersion selects the payload version for a subscriptions route group. A non-zero
+// urlVersion pins the version for /vN routes; otherwise it is negotiated from an
+// Accept header such as application/vnd.subscriptions.v2+json and defaults to v1.
+func APIVersion(urlVersion int, collectionPath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := urlVersion
+		if version == 0 {
+			if match := versionedMediaType.FindStringSubmatch(c.GetHeader("Accept")); match != nil {
+				requested, err := strconv.Atoi(match[1])
+				if err != nil || requested < 1 || requested > latestAPIVersion {
+					c.AbortWithStatusJSON(http.StatusNotAcceptable, i18n.ErrorBody(c, "unsupported_api_version", match[1]))
+					return
+				}
+				version = requested
+			}
+			c.Header("Vary", "Accept")
+		}
+
+		// Only an explicitly selected version changes the Content-Type, so existing
+		// clients keep receiving plain application/json.
+		if version > 0 {
+			c.Set(versionKey, version)
+		}
+		c.Set(collectionPathKey, collectionPath)
+		c.Next()
+	}
+}
+
+func apiVersion(c *gin.Context) int {
+	if version := c.GetInt(versionKey); version > 0 {
+		return version
+	}
+	return 1
+}
+
+func collectionPath(c *gin.Context) string {
+	if path := c.GetString(collectionPathKey); path != "" {
+		return path
+	}
+	return "/subscriptions"
+}
+
+// money is an amount in priceCurrency. v3 reports the amount as a string, so that
+// clients decoding JSON numbers into floats can keep every digit.
+type money struct {
+	Amount   decimal.Decimal
+	Currency string
+	exact    bool
+}
+
+// conversion reports money in another currency than priceCurrency, at rate units of
+// it per ruble.
+type conversion struct {
+	currency string
+	rate     decimal.Decimal
+}
+
+func newMoney(c *gin.Context, amount decimal.Decimal) money {
+	m := money{Amount: amount, Currency: priceCurrency, exact: apiVersion(c) >= 3}
+	if value, ok := c.Get(currencyKey); ok {
+		to := value.(conversion)
+		m.Amount, m.Currency = amount.Mul(to.rate).Round(2), to.currency
+	}
+	return m
+}
+
+func (m money) MarshalJSON() ([]byte, error) {
+	amount := any(m.Amount)
+	if m.exact {
+		amount = m.Amount.String()
+	}
+	return json.Marshal(struct {
+		Amount   any    `json:"amount"`
+		Currency string `json:"currency"`
+	}{amount, m.Currency})
+}
+
+type subscriptionV2 struct {
+	ID               string          `json:"id"`
+	ServiceName      string          `json:"service_name"`
+	Price            money           `json:"price"`
+	UserID           string          `json:"user_id"`
+	Kind             string          `json:"kind"`
+	BillingPeriod    string          `json:"billing_period"`
+	BillingAnchorDay int             `json:"billing_anchor_day"`
+	StartDate        string          `json:"start_date"`
+	EndDate          *string         `json:"end_date,omitempty"`
+	Status           string          `json:"status"`
+	Category         *string         `json:"category,omitempty"`
+	CustomFields     json.RawMessage `json:"custom_fields,omitempty"`
+	Links            links           `json:"_links"`
+}
+
+// v2 reports dates in the same MM-YYYY form the API accepts instead of RFC 3339 timestamps.
+func newSubscriptionV2(c *gin.Context, sub *models.Subscription) subscriptionV2 {
+	resource := subscriptionV2{
+		ID:               sub.ID.String(),
+		ServiceName:      sub.ServiceName,
+		Price:            newMoney(c, decimal.NewFromInt(int64(sub.Price))),
+		UserID:           sub.UserID.String(),
+		Kind:             sub.Kind,
+		BillingPeriod:    sub.BillingPeriod,
+		BillingAnchorDay: sub.BillingAnchorDay,
+		StartDate:        sub.StartDate.Format(monthYearLayout),
+		Status:           sub.Status,
+		Category:         sub.Category,
+		CustomFields:     sub.CustomFields,
+		Links:            subscriptionLinks(c, sub),
+	}
+	if sub.EndDate != nil {
+		endDate := sub.EndDate.Format(monthYearLayout)
+		resource.EndDate = &endDate
+	}
+	return resource
+}
+
+func respondVersioned(c *gin.Context, status int, body any) {
+	if version := c.GetInt(versionKey); version > 0 {
+		c.Header("Content-Type", fmt.Sprintf("application/vnd.subscriptions.v%d+json", version))
+	}
+	c.JSON(status, body)
+}
+
+type bucketTotalV2 struct {
+	Start string `json:"start"`
+	Total money  `json:"total"`
+}
+
+func newBucketTotals(c *gin.Context, buckets []models.BucketTotal) any {
+	if apiVersion(c) < 2 {
+		return buckets
+	}
+
+	resources := make([]bucketTotalV2, 0, len(buckets))
+	for _, bucket := range buckets {
+		resources = append(resources, bucketTotalV2{
+			Start: bucket.Start.Format(monthYearLayout),
+			Total: newMoney(c, bucket.Total),
+		})
+	}
+	return resources
+}
+
+// periodTotal is the total of one month of a group_by=month aggregation.
+type periodTotal struct {
+	Period string `json:"period"`
+	Total  any    `json:"total"`
+}
+
+func newPeriodTotals(c *gin.Context, buckets []models.BucketTotal) []periodTotal {
+	totals := make([]periodTotal, 0, len(buckets))
+	for _, bucket := range buckets {
+		var total any = bucket.Total
+		if apiVersion(c) >= 2 {
+			total = newMoney(c, bucket.Total)
+		}
+		totals = append(totals, periodTotal{
+			Period: bucket.Start.Format(monthYearLayout),
+			Total:  total,
+		})
+	}
+	return totals
+}
+
+type categoryTotalV2 struct {
+	Category *string `json:"category"`
+	Total    money   `json:"total"`
+}
+
+func newCategoryTotals(c *gin.Context, totals []models.CategoryTotal) any {
+	if apiVersion(c) < 2 {
+		return totals
+	}
+
+	resources := make([]categoryTotalV2, 0, len(totals))
+	for _, total := range totals {
+		resources = append(resources, categoryTotalV2{
+			Category: total.Category,
+			Total:    newMoney(c, total.Total),
+		})
+	}
+	return resources
+}
+
+type aggregateDetailV2 struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	ServiceName    string    `json:"service_name"`
+	Amount         money     `json:"amount"`
+	Charges        int       `json:"charges,omitempty"`
+}
+
+func newAggregateDetails(c *gin.Context, details []models.AggregateDetail) any {
+	if apiVersion(c) < 2 {
+		return details
+	}
+
+	resources := make([]aggregateDetailV2, 0, len(details))
+	for _, detail := range details {
+		resources = append(resources, aggregateDetailV2{
+			SubscriptionID: detail.SubscriptionID,
+			UserID:         detail.UserID,
+			ServiceName:    detail.ServiceName,
+			Amount:         newMoney(c, detail.Amount),
+			Charges:        detail.Charges,
+		})
+	}
+	return resources
+}
+
+type simulatedMonthV2 struct {
+	Start      string `json:"start"`
+	Baseline   money  `json:"baseline"`
+	Projected  money  `json:"projected"`
+	Difference money  `json:"difference"`
+}
+
+type simulationResultV2 struct {
+	Months         []simulatedMonthV2 `json:"months"`
+	BaselineTotal  money              `json:"baseline_total"`
+	ProjectedTotal money              `json:"projected_total"`
+	Difference     money              `json:"difference"`
+}
+
+func newSimulationResult(c *gin.Context, result *models.SimulationResult) any {
+	if apiVersion(c) < 2 {
+		return result
+	}
+
+	resource := simulationResultV2{
+		Months:         make([]simulatedMonthV2, 0, len(result.Months)),
+		BaselineTotal:  newMoney(c, result.BaselineTotal),
+		ProjectedTotal: newMoney(c, result.ProjectedTotal),
+		Difference:     newMoney(c, result.Difference),
+	}
+	for _, month := range result.Months {
+		resource.Months = append(resource.Months, simulatedMonthV2{
+			Start:      month.Start.Format(monthYearLayout),
+			Baseline:   newMoney(c, month.Baseline),
+			Projected:  newMoney(c, month.Projected),
+			Difference: newMoney(c, month.Difference),
+		})
+	}
+	return resource
+}
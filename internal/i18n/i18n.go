@@ -0,0 +1,135 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+const languageKey = "i18n.language"
+
+var (
+	catalog         = mustLoad()
+	defaultLanguage = "en"
+)
+
+func mustLoad() map[string]map[string]string {
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: read embedded locales: %v", err))
+	}
+
+	messages := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := locales.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: read %s: %v", entry.Name(), err))
+		}
+
+		var m map[string]string
+		if err := json.Unmarshal(data, &m); err != nil {
+			panic(fmt.Sprintf("i18n: parse %s: %v", entry.Name(), err))
+		}
+		messages[strings.TrimSuffix(entry.Name(), ".json")] = m
+	}
+	return messages
+}
+
+func SetDefaultLanguage(lang string) error {
+	if _, ok := catalog[lang]; !ok {
+		return fmt.Errorf("unsupported language %q", lang)
+	}
+	defaultLanguage = lang
+	return nil
+}
+
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := Negotiate(c.GetHeader("Accept-Language"))
+		c.Set(languageKey, lang)
+		c.Header("Content-Language", lang)
+		c.Next()
+	}
+}
+
+// Negotiate picks the best supported language from an Accept-Language header,
+// honouring quality values and matching on the primary subtag (ru-RU -> ru).
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		candidates = append(candidates, candidate{lang: primary, q: q})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	for _, cand := range candidates {
+		if _, ok := catalog[cand.lang]; ok && cand.q > 0 {
+			return cand.lang
+		}
+	}
+	return defaultLanguage
+}
+
+func Language(c *gin.Context) string {
+	if lang := c.GetString(languageKey); lang != "" {
+		return lang
+	}
+	return defaultLanguage
+}
+
+func Translate(lang string, key string, args ...any) string {
+	format, ok := catalog[lang][key]
+	if !ok {
+		format, ok = catalog[defaultLanguage][key]
+	}
+	if !ok {
+		format = key
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func Message(c *gin.Context, key string, args ...any) string {
+	return Translate(Language(c), key, args...)
+}
+
+// ErrorBody builds the standard error payload with a stable machine-readable code and a
+// message in the negotiated language.
+func ErrorBody(c *gin.Context, code string, args ...any) gin.H {
+	return gin.H{
+		"error": Message(c, code, args...),
+		"code":  code,
+	}
+}
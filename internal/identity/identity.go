@@ -0,0 +1,56 @@
+package identity
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const (
+	Anonymous     = "anonymous"
+	DefaultTenant = "default"
+	// SandboxTenant holds the requests of sandbox clients, whose data is kept apart
+	// from production and wiped daily.
+	SandboxTenant = "sandbox"
+)
+
+type Identity struct {
+	Actor    string
+	Subject  *uuid.UUID
+	Admin    bool
+	ClientIP string
+	Tenant   string
+	// Schema holds the data of Tenant when tenants are isolated; it is empty when the
+	// data is in the shared tables.
+	Schema string
+}
+
+type contextKey struct{}
+
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+func FromContext(ctx context.Context) Identity {
+	if id, ok := ctx.Value(contextKey{}).(Identity); ok {
+		return id
+	}
+	return Identity{Actor: Anonymous, Tenant: DefaultTenant}
+}
+
+func (i Identity) Impersonating() bool {
+	return i.Subject != nil
+}
+
+func (i Identity) Sandbox() bool {
+	return i.Tenant == SandboxTenant
+}
+
+// Principal identifies the caller for accounting purposes. Anonymous callers are
+// distinguished by client IP.
+func (i Identity) Principal() string {
+	if i.Actor == Anonymous {
+		return "ip:" + i.ClientIP
+	}
+	return i.Actor
+}
@@ -0,0 +1,62 @@
+package lake
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"awesomeProject1/internal/sigv4"
+)
+
+// bucket uploads objects with the S3 PutObject API.
+type bucket struct {
+	base   string
+	region string
+	creds  sigv4.Credentials
+	client *http.Client
+}
+
+// newBucket addresses AWS buckets by virtual host and buckets behind a custom endpoint
+// by path, which S3-compatible stores support more widely.
+func newBucket(d Destination, creds sigv4.Credentials) *bucket {
+	base := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", d.Bucket, d.Region)
+	if d.Endpoint != "" {
+		base = strings.TrimSuffix(d.Endpoint, "/") + "/" + d.Bucket + "/"
+	}
+	return &bucket{
+		base:   base,
+		region: d.Region,
+		creds:  creds,
+		client: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (b *bucket) put(ctx context.Context, key string, body []byte, contentType string) error {
+	u, err := url.Parse(b.base + key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	sigv4.Sign(req, body, b.creds, b.region, "s3", time.Now())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("object store returned %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
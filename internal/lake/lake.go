@@ -0,0 +1,364 @@
+// Package lake syncs subscriptions to object storage for data lakes. Each run writes
+// the rows changed since the destination's previous run, soft deletions included, as
+// one JSON Lines or Parquet object; the runs are recorded in the database.
+package lake
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"awesomeProject1/internal/buildinfo"
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/parquet"
+	"awesomeProject1/internal/sigv4"
+)
+
+// Providers of a Destination. GCS is written through its S3-compatible XML API, with
+// HMAC keys.
+const (
+	ProviderS3  = "s3"
+	ProviderGCS = "gcs"
+)
+
+// Formats of the synced objects.
+const (
+	// FormatJSONL is gzip-compressed JSON Lines, one Record per line.
+	FormatJSONL = "jsonl"
+	// FormatParquet is an Apache Parquet file with a column per Record field.
+	FormatParquet = "parquet"
+)
+
+const (
+	gcsEndpoint = "https://storage.googleapis.com"
+	batchSize   = 500
+
+	// settleDelay keeps a run from reading up to the present: updated_at is the start
+	// of the writing transaction, which may commit after a run has read past it.
+	settleDelay = time.Minute
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Destination is a bucket the subscriptions are synced to. Endpoint replaces the AWS
+// endpoint, e.g. for MinIO. Empty credentials of an s3 destination default to the AWS
+// credentials of the service; values of the form ${VAR} are read from the environment.
+type Destination struct {
+	Name            string `yaml:"name"`
+	Provider        string `yaml:"provider"`
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"`
+	Region          string `yaml:"region"`
+	Endpoint        string `yaml:"endpoint"`
+	Format          string `yaml:"format"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	SessionToken    string `yaml:"session_token"`
+}
+
+// Record is a synced subscription. DeletedAt is set for soft-deleted subscriptions;
+// purged ones are not synced.
+type Record struct {
+	models.Subscription
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+var parquetColumns = []parquet.Column{
+	{Name: "id", Kind: parquet.String},
+	{Name: "service_name", Kind: parquet.String},
+	{Name: "price", Kind: parquet.Int64},
+	{Name: "user_id", Kind: parquet.String},
+	{Name: "kind", Kind: parquet.String},
+	{Name: "billing_period", Kind: parquet.String},
+	{Name: "billing_anchor_day", Kind: parquet.Int64},
+	{Name: "start_date", Kind: parquet.Date},
+	{Name: "end_date", Kind: parquet.Date, Optional: true},
+	{Name: "category", Kind: parquet.String, Optional: true},
+	{Name: "custom_fields", Kind: parquet.String, Optional: true},
+	{Name: "updated_at", Kind: parquet.Timestamp},
+	{Name: "deleted_at", Kind: parquet.Timestamp, Optional: true},
+}
+
+// LoadDestinations reads destinations from YAML or JSON of the form
+// {"destinations": [{"name": ..., "bucket": ..., ...}]}, filling in the defaults.
+func LoadDestinations(r io.Reader) ([]Destination, error) {
+	var file struct {
+		Destinations []Destination `yaml:"destinations"`
+	}
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse lake sync destinations: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for i := range file.Destinations {
+		d := &file.Destinations[i]
+		d.AccessKeyID = os.ExpandEnv(d.AccessKeyID)
+		d.SecretAccessKey = os.ExpandEnv(d.SecretAccessKey)
+		d.SessionToken = os.ExpandEnv(d.SessionToken)
+		if d.Provider == "" {
+			d.Provider = ProviderS3
+		}
+		if d.Format == "" {
+			d.Format = FormatJSONL
+		}
+		if d.Provider == ProviderGCS {
+			if d.Endpoint == "" {
+				d.Endpoint = gcsEndpoint
+			}
+			if d.Region == "" {
+				d.Region = "auto"
+			}
+		}
+		if err := validate(*d); err != nil {
+			return nil, fmt.Errorf("lake sync destination %q: %w", d.Name, err)
+		}
+		if names[d.Name] {
+			return nil, fmt.Errorf("lake sync destination %q: duplicate name", d.Name)
+		}
+		names[d.Name] = true
+	}
+	return file.Destinations, nil
+}
+
+func validate(d Destination) error {
+	switch {
+	case !namePattern.MatchString(d.Name):
+		return errors.New("name must be lower-case letters, digits, - and _")
+	case d.Provider != ProviderS3 && d.Provider != ProviderGCS:
+		return fmt.Errorf("provider must be %s or %s", ProviderS3, ProviderGCS)
+	case d.Bucket == "":
+		return errors.New("bucket is required")
+	case d.Region == "":
+		return errors.New("region is required")
+	case d.Format != FormatJSONL && d.Format != FormatParquet:
+		return fmt.Errorf("format must be %s or %s", FormatJSONL, FormatParquet)
+	case d.Provider == ProviderGCS && (d.AccessKeyID == "" || d.SecretAccessKey == ""):
+		return errors.New("gcs destinations need the access_key_id and secret_access_key of an HMAC key")
+	}
+	return nil
+}
+
+type repository interface {
+	ForEachChangedBatch(ctx context.Context, since time.Time, until time.Time, batchSize int, fn func(changes []models.SubscriptionChange) error) error
+	LastSucceeded(ctx context.Context, destination string) (*models.LakeSyncRun, error)
+	Start(ctx context.Context, run *models.LakeSyncRun) error
+	Finish(ctx context.Context, run *models.LakeSyncRun) error
+}
+
+// Syncer writes the changes of every destination on each Sync.
+type Syncer struct {
+	repo         repository
+	destinations []Destination
+	buckets      map[string]*bucket
+	clock        clock.Clock
+	logger       *slog.Logger
+}
+
+// NewSyncer syncs to destinations, using defaultCreds for s3 destinations without
+// credentials of their own.
+func NewSyncer(repo repository, destinations []Destination, defaultCreds sigv4.Credentials, clock clock.Clock, logger *slog.Logger) *Syncer {
+	buckets := make(map[string]*bucket, len(destinations))
+	for _, d := range destinations {
+		creds := sigv4.Credentials{
+			AccessKeyID:     d.AccessKeyID,
+			SecretAccessKey: d.SecretAccessKey,
+			SessionToken:    d.SessionToken,
+		}
+		if creds.AccessKeyID == "" {
+			creds = defaultCreds
+		}
+		buckets[d.Name] = newBucket(d, creds)
+	}
+	return &Syncer{
+		repo:         repo,
+		destinations: destinations,
+		buckets:      buckets,
+		clock:        clock,
+		logger:       logger,
+	}
+}
+
+// Sync runs every destination once. A failed destination does not keep the others
+// from running; its next run starts where its last succeeded run ended.
+func (s *Syncer) Sync(ctx context.Context) error {
+	var errs []error
+	for _, d := range s.destinations {
+		if err := s.syncDestination(ctx, d); err != nil {
+			errs = append(errs, fmt.Errorf("sync %s: %w", d.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Syncer) syncDestination(ctx context.Context, d Destination) error {
+	last, err := s.repo.LastSucceeded(ctx, d.Name)
+	if err != nil {
+		return err
+	}
+	// The first run of a destination writes a full snapshot.
+	var since time.Time
+	if last != nil {
+		since = last.Until
+	}
+	run := &models.LakeSyncRun{
+		Destination: d.Name,
+		Status:      models.LakeSyncRunning,
+		Since:       since,
+		Until:       s.clock.Now().UTC().Add(-settleDelay).Truncate(time.Microsecond),
+		StartedAt:   s.clock.Now().UTC(),
+	}
+	if !run.Until.After(since) {
+		return nil
+	}
+	if err := s.repo.Start(ctx, run); err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "Starting lake sync run",
+		slog.String("destination", d.Name),
+		slog.Time("since", run.Since),
+		slog.Time("until", run.Until))
+
+	key, rows, syncErr := s.write(ctx, d, run)
+	run.RowCount = rows
+	finishedAt := s.clock.Now().UTC()
+	run.FinishedAt = &finishedAt
+	if syncErr != nil {
+		message := syncErr.Error()
+		run.Status = models.LakeSyncFailed
+		run.Error = &message
+		s.logger.ErrorContext(ctx, "Lake sync run failed",
+			slog.String("destination", d.Name),
+			slog.Int64("rows", rows),
+			slog.String("error", message))
+	} else {
+		run.Status = models.LakeSyncSucceeded
+		if key != "" {
+			run.ObjectKey = &key
+		}
+		s.logger.InfoContext(ctx, "Successfully completed lake sync run",
+			slog.String("destination", d.Name),
+			slog.String("object_key", key),
+			slog.Int64("rows", rows),
+			slog.Duration("duration", finishedAt.Sub(run.StartedAt)))
+	}
+
+	// A run left running is never resumed; only succeeded runs move the start of the
+	// next one.
+	if err := s.repo.Finish(ctx, run); err != nil {
+		return errors.Join(syncErr, err)
+	}
+	return syncErr
+}
+
+// write uploads the changes of run and returns the key of the object, empty when
+// nothing changed.
+func (s *Syncer) write(ctx context.Context, d Destination, run *models.LakeSyncRun) (string, int64, error) {
+	var body bytes.Buffer
+	enc, err := newEncoder(&body, d.Format)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var rows int64
+	err = s.repo.ForEachChangedBatch(ctx, run.Since, run.Until, batchSize, func(changes []models.SubscriptionChange) error {
+		for _, change := range changes {
+			record := Record{Subscription: change.Subscription, UpdatedAt: change.UpdatedAt}
+			if change.DeletedAt.Valid {
+				deletedAt := change.DeletedAt.Time
+				record.DeletedAt = &deletedAt
+			}
+			if err := enc.write(record); err != nil {
+				return fmt.Errorf("write lake record: %w", err)
+			}
+			rows++
+		}
+		return nil
+	})
+	if err != nil {
+		return "", rows, err
+	}
+	if rows == 0 {
+		return "", 0, nil
+	}
+	if err := enc.close(); err != nil {
+		return "", rows, fmt.Errorf("finish lake object: %w", err)
+	}
+
+	key := objectKey(d, run.Until)
+	if err := s.buckets[d.Name].put(ctx, key, body.Bytes(), enc.contentType); err != nil {
+		return "", rows, fmt.Errorf("upload %s: %w", key, err)
+	}
+	return key, rows, nil
+}
+
+// objectKey partitions the objects of a destination by the day their run ended, e.g.
+// prefix/2025/08/27/subscriptions-20250827T120000Z.parquet.
+func objectKey(d Destination, until time.Time) string {
+	ext := ".jsonl.gz"
+	if d.Format == FormatParquet {
+		ext = ".parquet"
+	}
+	return d.Prefix + until.Format("2006/01/02") + "/subscriptions-" + until.Format("20060102T150405Z") + ext
+}
+
+type encoder struct {
+	write       func(record Record) error
+	close       func() error
+	contentType string
+}
+
+func newEncoder(w io.Writer, format string) (*encoder, error) {
+	if format == FormatParquet {
+		pw, err := parquet.NewWriter(w, parquetColumns, "subscriptions "+buildinfo.Version)
+		if err != nil {
+			return nil, err
+		}
+		return &encoder{
+			write: func(record Record) error {
+				var endDate, category, customFields, deletedAt any
+				if record.EndDate != nil {
+					endDate = *record.EndDate
+				}
+				if record.Category != nil {
+					category = *record.Category
+				}
+				if len(record.CustomFields) > 0 {
+					customFields = string(record.CustomFields)
+				}
+				if record.DeletedAt != nil {
+					deletedAt = *record.DeletedAt
+				}
+				return pw.Write(record.ID.String(), record.ServiceName, record.Price, record.UserID.String(),
+					record.Kind, record.BillingPeriod, record.BillingAnchorDay, record.StartDate, endDate,
+					category, customFields, record.UpdatedAt, deletedAt)
+			},
+			close:       pw.Close,
+			contentType: "application/vnd.apache.parquet",
+		}, nil
+	}
+
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	return &encoder{
+		write: func(record Record) error {
+			return enc.Encode(record)
+		},
+		close:       gz.Close,
+		contentType: "application/gzip",
+	}, nil
+}
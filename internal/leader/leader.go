@@ -0,0 +1,117 @@
+// Package leader elects one instance to run the background subsystems that must not
+// run on every replica. Leadership is a distributed lock held for as long as the
+// instance is alive; when its database session goes away another instance takes over.
+package leader
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/lock"
+	"awesomeProject1/internal/model"
+)
+
+const (
+	lockKey    = "leader"
+	retryDelay = 5 * time.Second
+)
+
+type locker interface {
+	Acquire(ctx context.Context, key string) (*lock.Lock, error)
+}
+
+type Elector struct {
+	locker locker
+	clock  clock.Clock
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	since *time.Time
+}
+
+func NewElector(locker locker, clock clock.Clock, logger *slog.Logger) *Elector {
+	return &Elector{
+		locker: locker,
+		clock:  clock,
+		logger: logger,
+	}
+}
+
+// IsLeader reports whether this instance currently holds leadership.
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.since != nil
+}
+
+func (e *Elector) Status() models.LeaderStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	status := models.LeaderStatus{Leader: e.since != nil}
+	if e.since != nil {
+		since := *e.since
+		status.Since = &since
+	}
+	return status
+}
+
+// Run campaigns for leadership until ctx is done. Each time it is elected it calls
+// lead with a context that is cancelled when leadership is lost; lead must return
+// once that context is done.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	for {
+		held, err := e.locker.Acquire(ctx, lockKey)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			e.logger.ErrorContext(ctx, "Leader election failed",
+				slog.String("error", err.Error()),
+				slog.Duration("retry_in", retryDelay))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		e.setLeader(true)
+		e.logger.InfoContext(ctx, "Elected leader")
+
+		leadCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-held.Lost():
+				cancel()
+			case <-leadCtx.Done():
+			}
+		}()
+		lead(leadCtx)
+		cancel()
+
+		e.setLeader(false)
+		_ = held.Release(context.WithoutCancel(ctx))
+		if ctx.Err() != nil {
+			return
+		}
+		e.logger.WarnContext(ctx, "Lost leadership, campaigning again")
+	}
+}
+
+func (e *Elector) setLeader(leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !leader {
+		e.since = nil
+		return
+	}
+	now := e.clock.Now().UTC()
+	e.since = &now
+}
@@ -0,0 +1,140 @@
+// Package listener opens the socket the HTTP server accepts connections on: a listener
+// inherited from systemd socket activation, a Unix domain socket or a TCP port.
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor systemd passes, see sd_listen_fds(3).
+const listenFDsStart = 3
+
+// Config selects the listener. Systemd activation wins over Socket, Socket over Port.
+// ReusePort lets several processes bind Port at once, for restarts without downtime.
+type Config struct {
+	Port       string
+	ReusePort  bool
+	Socket     string
+	SocketMode fs.FileMode
+}
+
+// Listen opens the listener described by cfg and returns it with a human-readable
+// address for logs.
+func Listen(cfg Config) (net.Listener, string, error) {
+	inherited, err := systemdListener()
+	if err != nil {
+		return nil, "", err
+	}
+	if inherited != nil {
+		return inherited, "systemd:" + inherited.Addr().String(), nil
+	}
+
+	if cfg.Socket != "" {
+		l, err := unixListener(cfg.Socket, cfg.SocketMode)
+		if err != nil {
+			return nil, "", err
+		}
+		return l, "unix:" + cfg.Socket, nil
+	}
+
+	l, err := TCP(cfg.Port, cfg.ReusePort)
+	if err != nil {
+		return nil, "", err
+	}
+	return l, l.Addr().String(), nil
+}
+
+// TCP listens on port, with SO_REUSEPORT when reuse is set.
+func TCP(port string, reuse bool) (net.Listener, error) {
+	var lc net.ListenConfig
+	if reuse {
+		if !reusePortSupported {
+			return nil, errors.New("SO_REUSEPORT is not supported on this platform")
+		}
+		lc.Control = reusePort
+	}
+	return lc.Listen(context.Background(), "tcp", ":"+port)
+}
+
+// systemdListener returns the first socket passed by systemd, or nil when the process
+// was not socket-activated. The variables are unset so child processes do not
+// mistake the descriptors for their own.
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, nil
+	}
+
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	file := os.NewFile(uintptr(listenFDsStart), "LISTEN_FD_3")
+	defer file.Close()
+
+	l, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("use systemd socket: %w", err)
+	}
+	if _, ok := l.(*net.UnixListener); ok {
+		return localListener{l}, nil
+	}
+	return l, nil
+}
+
+// unixListener listens on path, replacing a socket left behind by a previous run.
+func unixListener(path string, mode fs.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("listen on %s: file exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket %s: %w", path, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("chmod socket %s: %w", path, err)
+	}
+	return localListener{l}, nil
+}
+
+// localListener reports Unix socket peers as loopback. They have no IP address, which
+// would otherwise make the admin IP filter reject them and keep X-Forwarded-For from
+// a local proxy from being trusted.
+type localListener struct {
+	net.Listener
+}
+
+func (l localListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return localConn{conn}, nil
+}
+
+type localConn struct {
+	net.Conn
+}
+
+func (localConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+}
@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || freebsd)
+
+package listener
+
+import (
+	"errors"
+	"syscall"
+)
+
+const reusePortSupported = false
+
+func reusePort(network string, address string, conn syscall.RawConn) error {
+	return errors.New("SO_REUSEPORT is not supported on this platform")
+}
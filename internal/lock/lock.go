@@ -0,0 +1,146 @@
+// Package lock provides distributed locks on PostgreSQL advisory locks, so work that
+// must run on one replica at a time, such as scheduled jobs, is coordinated through
+// the database every replica already shares.
+//
+// Advisory locks belong to a database session. A Lock therefore pins one pooled
+// connection for as long as it is held and pings it periodically: if the session is
+// lost, so is the lock, and Lost reports it.
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var ErrNotHeld = errors.New("lock is not held")
+
+type Locker struct {
+	db        *sql.DB
+	keepalive time.Duration
+	logger    *slog.Logger
+}
+
+// NewLocker creates a locker on db that checks held sessions every keepalive.
+func NewLocker(db *sql.DB, keepalive time.Duration, logger *slog.Logger) *Locker {
+	return &Locker{
+		db:        db,
+		keepalive: keepalive,
+		logger:    logger,
+	}
+}
+
+// Acquire blocks until the lock named key is held or ctx is done.
+func (l *Locker) Acquire(ctx context.Context, key string) (*Lock, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", id(key)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return l.held(conn, key), nil
+}
+
+// TryAcquire takes the lock named key if it is free. It returns false without waiting
+// when another session holds it.
+func (l *Locker) TryAcquire(ctx context.Context, key string) (*Lock, bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", id(key)).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+	return l.held(conn, key), true, nil
+}
+
+func (l *Locker) held(conn *sql.Conn, key string) *Lock {
+	lock := &Lock{
+		key:    key,
+		conn:   conn,
+		logger: l.logger,
+		stop:   make(chan struct{}),
+		lost:   make(chan struct{}),
+	}
+	go lock.keepalive(l.keepalive)
+	return lock
+}
+
+// id maps a lock name onto the 64-bit key space of advisory locks.
+func id(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int64(h.Sum64())
+}
+
+type Lock struct {
+	key    string
+	conn   *sql.Conn
+	logger *slog.Logger
+
+	once sync.Once
+	stop chan struct{}
+	lost chan struct{}
+}
+
+// Lost is closed when the session holding the lock has gone away, after which
+// another replica may acquire it. Work done under the lock should stop.
+func (lk *Lock) Lost() <-chan struct{} {
+	return lk.lost
+}
+
+func (lk *Lock) keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lk.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := lk.conn.PingContext(ctx)
+			cancel()
+			if err != nil {
+				lk.logger.Error("Lost distributed lock",
+					slog.String("key", lk.key),
+					slog.String("error", err.Error()))
+				close(lk.lost)
+				return
+			}
+		}
+	}
+}
+
+// Release unlocks and returns the connection to the pool. Releasing twice returns
+// ErrNotHeld.
+func (lk *Lock) Release(ctx context.Context) error {
+	err := ErrNotHeld
+	lk.once.Do(func() {
+		close(lk.stop)
+		_, err = lk.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", id(lk.key))
+		if err != nil {
+			// Discard the session instead of pooling it, which drops the lock with it.
+			_ = lk.conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		if closeErr := lk.conn.Close(); err == nil {
+			err = closeErr
+		}
+	})
+	return err
+}
@@ -0,0 +1,62 @@
+// Package logging provides the call logging used by the logging decorators of the
+// repository and service layers, so every operation is logged in the same format:
+// a debug record when it starts, then an info record on success or an error record
+// on failure, both with the duration.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Call runs fn as the operation op. attrs describe the call and are added to every
+// record.
+func Call(ctx context.Context, logger *slog.Logger, op string, fn func() error, attrs ...slog.Attr) error {
+	_, err := Call1(ctx, logger, op, func() (struct{}, error) {
+		return struct{}{}, fn()
+	}, attrs...)
+	return err
+}
+
+// Call1 is Call for operations returning a result.
+func Call1[T any](ctx context.Context, logger *slog.Logger, op string, fn func() (T, error), attrs ...slog.Attr) (T, error) {
+	logger.LogAttrs(ctx, slog.LevelDebug, "Calling "+op, attrs...)
+
+	start := time.Now()
+	result, err := fn()
+	attrs = append(attrs[:len(attrs):len(attrs)], slog.Duration("duration", time.Since(start)))
+
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelError, "Failed "+op, append(attrs, slog.String("error", err.Error()))...)
+		return result, err
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "Completed "+op, attrs...)
+	return result, nil
+}
+
+// Call2 is Call for operations returning two results.
+func Call2[T, U any](ctx context.Context, logger *slog.Logger, op string, fn func() (T, U, error), attrs ...slog.Attr) (T, U, error) {
+	type pair struct {
+		first  T
+		second U
+	}
+	result, err := Call1(ctx, logger, op, func() (pair, error) {
+		first, second, err := fn()
+		return pair{first, second}, err
+	}, attrs...)
+	return result.first, result.second, err
+}
+
+// TypeName turns an entity name such as "dead letter" into the type name used in
+// operation names, e.g. DeadLetterRepository for the suffix "Repository".
+func TypeName(name string, suffix string) string {
+	var b strings.Builder
+	for _, word := range strings.Fields(name) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	b.WriteString(suffix)
+	return b.String()
+}
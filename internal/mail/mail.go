@@ -0,0 +1,231 @@
+// Package mail queues rendered emails in a persistent outbound queue and delivers
+// them through a configurable backend, retrying failed attempts with backoff.
+package mail
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/templates"
+)
+
+const (
+	deliveryBatch = 50
+	retryBase     = time.Minute
+	retryMax      = time.Hour
+
+	mailDestination = "mail"
+)
+
+// Message is a fully rendered email ready for a backend.
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers a single message. Implementations must be safe for concurrent use.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type repository interface {
+	Create(ctx context.Context, delivery *models.MailDelivery) error
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.MailDelivery, error)
+	UpdateAttempt(ctx context.Context, delivery *models.MailDelivery) error
+	List(ctx context.Context, filter models.MailFilter) ([]models.MailDelivery, error)
+}
+
+type renderer interface {
+	Render(ctx context.Context, tenant string, name string, data any) (templates.Rendered, error)
+}
+
+type workerPool interface {
+	Submit(destination string, task func()) error
+}
+
+type Mailer struct {
+	repo        repository
+	templates   renderer
+	sender      Sender
+	pool        workerPool
+	from        string
+	maxAttempts int
+	logger      *slog.Logger
+}
+
+func NewMailer(repo repository, templates renderer, sender Sender, pool workerPool, from string, maxAttempts int, logger *slog.Logger) *Mailer {
+	return &Mailer{
+		repo:        repo,
+		templates:   templates,
+		sender:      sender,
+		pool:        pool,
+		from:        from,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+// Send renders the named template with data, using the overrides of the caller's
+// tenant, and queues the result for delivery to the given recipients.
+func (m *Mailer) Send(ctx context.Context, template string, to []string, data any) error {
+	content, err := m.templates.Render(ctx, identity.FromContext(ctx).Tenant, template, data)
+	if err != nil {
+		return err
+	}
+	return m.Queue(ctx, template, to, content)
+}
+
+// Queue queues already rendered content for delivery. Delivery itself happens in
+// Deliver.
+func (m *Mailer) Queue(ctx context.Context, template string, to []string, content templates.Rendered) error {
+	now := time.Now().UTC()
+	delivery := &models.MailDelivery{
+		ID:            uuid.New(),
+		Template:      template,
+		Recipients:    strings.Join(to, ", "),
+		Subject:       content.Subject,
+		TextBody:      content.Text,
+		HTMLBody:      content.HTML,
+		Status:        models.MailPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}
+	if err := m.repo.Create(ctx, delivery); err != nil {
+		return err
+	}
+
+	m.logger.InfoContext(ctx, "Queued email",
+		slog.String("delivery_id", delivery.ID.String()),
+		slog.String("template", template),
+		slog.Int("recipients", len(to)))
+
+	return nil
+}
+
+// Deliver attempts every due delivery once. Failed attempts are retried with
+// exponential backoff until maxAttempts is reached, after which the delivery is
+// marked failed and stays in the log.
+func (m *Mailer) Deliver(ctx context.Context) error {
+	due, err := m.repo.ListDue(ctx, time.Now().UTC(), deliveryBatch)
+	if err != nil {
+		return err
+	}
+
+	// Deliveries are sent concurrently on the mail queue of the worker pool. Those
+	// that do not fit stay due and are picked up by the next run.
+	var (
+		mu                     sync.Mutex
+		wg                     sync.WaitGroup
+		sent, failed, deferred int
+		errs                   []error
+	)
+	for i := range due {
+		delivery := &due[i]
+		wg.Add(1)
+		err := m.pool.Submit(mailDestination, func() {
+			defer wg.Done()
+			ok := m.attempt(ctx, delivery)
+			err := m.repo.UpdateAttempt(ctx, delivery)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if ok {
+				sent++
+			} else {
+				failed++
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		})
+		if err != nil {
+			wg.Done()
+			deferred++
+		}
+	}
+	wg.Wait()
+
+	if len(due) > 0 {
+		m.logger.InfoContext(ctx, "Processed mail queue",
+			slog.Int("sent", sent),
+			slog.Int("failed", failed),
+			slog.Int("deferred", deferred))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *Mailer) List(ctx context.Context, filter models.MailFilter) ([]models.MailDelivery, error) {
+	return m.repo.List(ctx, filter)
+}
+
+func (m *Mailer) attempt(ctx context.Context, delivery *models.MailDelivery) bool {
+	delivery.Attempts++
+
+	err := m.sender.Send(ctx, Message{
+		From:    m.from,
+		To:      splitRecipients(delivery.Recipients),
+		Subject: delivery.Subject,
+		Text:    delivery.TextBody,
+		HTML:    delivery.HTMLBody,
+	})
+	if err == nil {
+		now := time.Now().UTC()
+		delivery.Status = models.MailSent
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+		delivery.SentAt = &now
+		return true
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= m.maxAttempts {
+		delivery.Status = models.MailFailed
+		delivery.NextAttemptAt = nil
+
+		m.logger.ErrorContext(ctx, "Giving up on email delivery",
+			slog.String("delivery_id", delivery.ID.String()),
+			slog.Int("attempts", delivery.Attempts),
+			slog.String("error", err.Error()))
+		return false
+	}
+
+	next := time.Now().UTC().Add(backoff(delivery.Attempts))
+	delivery.NextAttemptAt = &next
+
+	m.logger.WarnContext(ctx, "Email delivery failed, will retry",
+		slog.String("delivery_id", delivery.ID.String()),
+		slog.Int("attempts", delivery.Attempts),
+		slog.Time("next_attempt_at", next),
+		slog.String("error", err.Error()))
+	return false
+}
+
+func backoff(attempts int) time.Duration {
+	d := retryBase << (attempts - 1)
+	if d <= 0 || d > retryMax {
+		return retryMax
+	}
+	return d
+}
+
+func splitRecipients(recipients string) []string {
+	var to []string
+	for _, r := range strings.Split(recipients, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			to = append(to, r)
+		}
+	}
+	return to
+}
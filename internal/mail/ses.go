@@ -0,0 +1,75 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"awesomeProject1/internal/sigv4"
+)
+
+// SESSender delivers through the Amazon SES v2 SendEmail API.
+type SESSender struct {
+	endpoint string
+	region   string
+	creds    sigv4.Credentials
+	client   *http.Client
+}
+
+func NewSESSender(region string, creds sigv4.Credentials) *SESSender {
+	return &SESSender{
+		endpoint: fmt.Sprintf("https://email.%s.amazonaws.com/v2/email/outbound-emails", region),
+		region:   region,
+		creds:    creds,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+func (s *SESSender) Send(ctx context.Context, msg Message) error {
+	body := map[string]sesContent{"Text": {Data: msg.Text, Charset: "UTF-8"}}
+	if msg.HTML != "" {
+		body["Html"] = sesContent{Data: msg.HTML, Charset: "UTF-8"}
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"FromEmailAddress": msg.From,
+		"Destination":      map[string]any{"ToAddresses": msg.To},
+		"Content": map[string]any{
+			"Simple": map[string]any{
+				"Subject": sesContent{Data: msg.Subject, Charset: "UTF-8"},
+				"Body":    body,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	sigv4.Sign(req, payload, s.creds, s.region, "ses", time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("ses returned %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
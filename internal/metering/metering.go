@@ -0,0 +1,112 @@
+package metering
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"awesomeProject1/internal/model"
+)
+
+type repository interface {
+	AddBatch(ctx context.Context, usage []models.APIUsage) error
+	Report(ctx context.Context, filter models.UsageFilter) ([]models.UsageReportRow, error)
+}
+
+type usageKey struct {
+	day      time.Time
+	apiKey   string
+	endpoint string
+}
+
+// Meter aggregates API calls in memory and writes them to the database in batches,
+// keeping the request path free of synchronous writes.
+type Meter struct {
+	repo   repository
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	pending map[usageKey]*models.APIUsage
+}
+
+func NewMeter(repo repository, logger *slog.Logger) *Meter {
+	return &Meter{
+		repo:    repo,
+		logger:  logger,
+		pending: make(map[usageKey]*models.APIUsage),
+	}
+}
+
+func (m *Meter) Record(apiKey string, endpoint string, requestBytes int64, responseBytes int64) {
+	now := time.Now().UTC()
+	key := usageKey{
+		day:      time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		apiKey:   apiKey,
+		endpoint: endpoint,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage, ok := m.pending[key]
+	if !ok {
+		usage = &models.APIUsage{Day: key.day, APIKey: apiKey, Endpoint: endpoint}
+		m.pending[key] = usage
+	}
+	usage.Calls++
+	usage.RequestBytes += requestBytes
+	usage.ResponseBytes += responseBytes
+}
+
+func (m *Meter) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[usageKey]*models.APIUsage)
+	m.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	batch := make([]models.APIUsage, 0, len(pending))
+	for _, usage := range pending {
+		batch = append(batch, *usage)
+	}
+
+	if err := m.repo.AddBatch(ctx, batch); err != nil {
+		m.requeue(batch)
+		return err
+	}
+
+	m.logger.DebugContext(ctx, "Flushed API usage", slog.Int("rows", len(batch)))
+	return nil
+}
+
+// Buffered returns the number of usage rows waiting for the next flush.
+func (m *Meter) Buffered() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending)
+}
+
+func (m *Meter) Report(ctx context.Context, filter models.UsageFilter) ([]models.UsageReportRow, error) {
+	return m.repo.Report(ctx, filter)
+}
+
+func (m *Meter) requeue(batch []models.APIUsage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, failed := range batch {
+		key := usageKey{day: failed.Day, apiKey: failed.APIKey, endpoint: failed.Endpoint}
+		usage, ok := m.pending[key]
+		if !ok {
+			usage = &models.APIUsage{Day: failed.Day, APIKey: failed.APIKey, Endpoint: failed.Endpoint}
+			m.pending[key] = usage
+		}
+		usage.Calls += failed.Calls
+		usage.RequestBytes += failed.RequestBytes
+		usage.ResponseBytes += failed.ResponseBytes
+	}
+}
@@ -0,0 +1,287 @@
+// Package metrics records HTTP request metrics and exposes them, together with gauges
+// registered by other packages, in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the request duration histogram.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DurationBuckets returns the upper bounds of the request duration histogram, for
+// callers that have to line thresholds up with its le labels.
+func DurationBuckets() []float64 {
+	return append([]float64(nil), durationBuckets...)
+}
+
+// Observer is notified of every request the middleware records, after it is recorded.
+type Observer func(method string, route string, status int, duration time.Duration)
+
+type requestKey struct {
+	method string
+	route  string
+	status string
+}
+
+type routeKey struct {
+	method string
+	route  string
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// collector is a metric whose values are read from another package at scrape time.
+type collector struct {
+	name        string
+	help        string
+	kind        string
+	label       string
+	constLabels string
+	fixed       map[string]string
+	values      func() map[string]float64
+}
+
+type Registry struct {
+	mu         sync.Mutex
+	requests   map[requestKey]uint64
+	durations  map[routeKey]*histogram
+	collectors []collector
+	observers  []Observer
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[routeKey]*histogram),
+	}
+}
+
+// GaugeFunc exposes the value returned by fn, read at scrape time, as a gauge.
+func (r *Registry) GaugeFunc(name string, help string, fn func() float64) {
+	r.collect(collector{name: name, help: help, kind: "gauge", values: func() map[string]float64 {
+		return map[string]float64{"": fn()}
+	}})
+}
+
+// GaugeVecFunc exposes one gauge per key of the map returned by fn, labelled with label.
+func (r *Registry) GaugeVecFunc(name string, help string, label string, fn func() map[string]float64) {
+	r.collect(collector{name: name, help: help, kind: "gauge", label: label, values: fn})
+}
+
+// CounterVecFunc is GaugeVecFunc for values that only grow.
+func (r *Registry) CounterVecFunc(name string, help string, label string, fn func() map[string]float64) {
+	r.collect(collector{name: name, help: help, kind: "counter", label: label, values: fn})
+}
+
+// ConstGauge exposes a fixed value with fixed labels, such as build information.
+func (r *Registry) ConstGauge(name string, help string, labels map[string]string, value float64) {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+quote(labels[key]))
+	}
+
+	r.collect(collector{name: name, help: help, kind: "gauge", constLabels: strings.Join(pairs, ","), fixed: labels, values: func() map[string]float64 {
+		return map[string]float64{"": value}
+	}})
+}
+
+// Observe registers observer for the requests recorded by Middleware.
+func (r *Registry) Observe(observer Observer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observers = append(r.observers, observer)
+}
+
+func (r *Registry) collect(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Middleware counts requests and observes their duration per matched route. Requests
+// that match no route are grouped under "unmatched" to keep the label set bounded.
+func (r *Registry) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		duration := time.Since(start)
+		observers := r.observe(c.Request.Method, route, c.Writer.Status(), duration)
+		for _, observer := range observers {
+			observer(c.Request.Method, route, c.Writer.Status(), duration)
+		}
+	}
+}
+
+// observe records the request and returns the observers to notify outside the lock.
+func (r *Registry) observe(method string, route string, status int, duration time.Duration) []Observer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests[requestKey{method: method, route: route, status: strconv.Itoa(status)}]++
+
+	key := routeKey{method: method, route: route}
+	h, ok := r.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(durationBuckets))}
+		r.durations[key] = h
+	}
+	seconds := duration.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+	return r.observers
+}
+
+// Handler serves the metrics in the Prometheus text exposition format.
+func (r *Registry) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		r.write(c.Writer)
+	}
+}
+
+func (r *Registry) write(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP http_requests_total Number of HTTP requests by route and status.")
+	fmt.Fprintln(w, "# TYPE http_requests_total counter")
+	requestKeys := make([]requestKey, 0, len(r.requests))
+	for key := range r.requests {
+		requestKeys = append(requestKeys, key)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		a, b := requestKeys[i], requestKeys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+	for _, key := range requestKeys {
+		fmt.Fprintf(w, "http_requests_total{method=%s,route=%s,status=%s} %d\n",
+			quote(key.method), quote(key.route), quote(key.status), r.requests[key])
+	}
+
+	fmt.Fprintln(w, "# HELP http_request_duration_seconds Duration of HTTP requests by route.")
+	fmt.Fprintln(w, "# TYPE http_request_duration_seconds histogram")
+	routeKeys := make([]routeKey, 0, len(r.durations))
+	for key := range r.durations {
+		routeKeys = append(routeKeys, key)
+	}
+	sort.Slice(routeKeys, func(i, j int) bool {
+		a, b := routeKeys[i], routeKeys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		return a.method < b.method
+	})
+	for _, key := range routeKeys {
+		h := r.durations[key]
+		labels := "method=" + quote(key.method) + ",route=" + quote(key.route)
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(w, "http_request_duration_seconds_bucket{%s,le=%s} %d\n",
+				labels, quote(strconv.FormatFloat(bound, 'g', -1, 64)), h.counts[i])
+		}
+		fmt.Fprintf(w, "http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(w, "http_request_duration_seconds_sum{%s} %g\n", labels, h.sum)
+		fmt.Fprintf(w, "http_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	for _, c := range r.collectors {
+		fmt.Fprintf(w, "# HELP %s %s\n", c.name, c.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", c.name, c.kind)
+
+		values := c.values()
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if c.constLabels != "" {
+				fmt.Fprintf(w, "%s{%s} %g\n", c.name, c.constLabels, values[key])
+				continue
+			}
+			if c.label == "" {
+				fmt.Fprintf(w, "%s %g\n", c.name, values[key])
+				continue
+			}
+			fmt.Fprintf(w, "%s{%s=%s} %g\n", c.name, c.label, quote(key), values[key])
+		}
+	}
+}
+
+// Sample is the current value of a registered metric.
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// Gather reads the current values of the registered metrics named in names.
+func (r *Registry) Gather(names ...string) []Sample {
+	r.mu.Lock()
+	collectors := make([]collector, 0, len(names))
+	for _, c := range r.collectors {
+		for _, name := range names {
+			if c.name == name {
+				collectors = append(collectors, c)
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	var samples []Sample
+	for _, c := range collectors {
+		for key, value := range c.values() {
+			labels := maps.Clone(c.fixed)
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			if c.label != "" {
+				labels[c.label] = key
+			}
+			samples = append(samples, Sample{Name: c.name, Labels: labels, Value: value})
+		}
+	}
+	return samples
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quote(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// RemoteWriteConfig selects where RemoteWriter pushes to. Labels are added to every
+// series, e.g. to tell deployments apart; BearerToken takes precedence over basic auth.
+type RemoteWriteConfig struct {
+	URL         string
+	BearerToken string
+	Username    string
+	Password    string
+	Labels      map[string]string
+}
+
+// RemoteWriter pushes the current values of registered metrics with the Prometheus
+// remote-write protocol, for deployments the monitoring system cannot scrape.
+type RemoteWriter struct {
+	cfg      RemoteWriteConfig
+	registry *Registry
+	names    []string
+	client   *http.Client
+}
+
+// NewRemoteWriter pushes the metrics of registry named in names.
+func NewRemoteWriter(cfg RemoteWriteConfig, registry *Registry, names ...string) *RemoteWriter {
+	return &RemoteWriter{
+		cfg:      cfg,
+		registry: registry,
+		names:    names,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Push sends one sample of every series, timestamped now.
+func (w *RemoteWriter) Push(ctx context.Context) error {
+	samples := w.registry.Gather(w.names...)
+	if len(samples) == 0 {
+		return nil
+	}
+	body := snappyBlock(encodeWriteRequest(samples, w.cfg.Labels, time.Now()))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if w.cfg.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.cfg.BearerToken)
+	} else if w.cfg.Username != "" {
+		req.SetBasicAuth(w.cfg.Username, w.cfg.Password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("remote write returned %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
+
+// encodeWriteRequest encodes samples as a prometheus.WriteRequest protobuf message,
+// one time series per sample with its labels sorted by name.
+func encodeWriteRequest(samples []Sample, extra map[string]string, now time.Time) []byte {
+	var request []byte
+	for _, s := range samples {
+		labels := map[string]string{"__name__": s.Name}
+		for name, value := range extra {
+			labels[name] = value
+		}
+		for name, value := range s.Labels {
+			labels[name] = value
+		}
+		names := make([]string, 0, len(labels))
+		for name := range labels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var series []byte
+		for _, name := range names {
+			var label []byte
+			label = appendBytesField(label, 1, []byte(name))
+			label = appendBytesField(label, 2, []byte(labels[name]))
+			series = appendBytesField(series, 1, label)
+		}
+		var sample []byte
+		sample = binary.AppendUvarint(sample, 1<<3|1)
+		sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(s.Value))
+		sample = binary.AppendUvarint(sample, 2<<3)
+		sample = binary.AppendUvarint(sample, uint64(now.UnixMilli()))
+		series = appendBytesField(series, 2, sample)
+
+		request = appendBytesField(request, 1, series)
+	}
+	return request
+}
+
+// appendBytesField appends a length-delimited protobuf field.
+func appendBytesField(b []byte, field uint64, value []byte) []byte {
+	b = binary.AppendUvarint(b, field<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// snappyBlock wraps data in the snappy block format as literals only. The requests are
+// small, so compressing them is not worth a dependency.
+func snappyBlock(data []byte) []byte {
+	const maxLiteral = 1 << 16
+	out := binary.AppendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		n := min(len(data), maxLiteral)
+		if n <= 60 {
+			out = append(out, byte(n-1)<<2)
+		} else {
+			// Tag 61 is followed by the length minus one in two little-endian bytes.
+			out = append(out, 61<<2)
+			out = binary.LittleEndian.AppendUint16(out, uint16(n-1))
+		}
+		out = append(out, data[:n]...)
+		data = data[n:]
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/bruteforce"
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/identity"
+)
+
+const AdminTokenHeader = "X-Admin-Token"
+
+// AdminAuth relies on the Identity middleware having already authenticated the
+// admin token, so failed attempts are only counted once per request.
+func AdminAuth(token string, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			logger.Warn("Admin endpoint called but ADMIN_TOKEN is not configured",
+				slog.String("path", c.Request.URL.Path),
+				slog.String("client_ip", c.ClientIP()))
+
+			c.AbortWithStatusJSON(http.StatusForbidden, i18n.ErrorBody(c, "admin_disabled"))
+			return
+		}
+
+		if !identity.FromContext(c.Request.Context()).Admin {
+			logger.Warn("Rejected admin request with invalid token",
+				slog.String("path", c.Request.URL.Path),
+				slog.String("client_ip", c.ClientIP()))
+
+			c.AbortWithStatusJSON(http.StatusUnauthorized, i18n.ErrorBody(c, "invalid_admin_token"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// authenticateAdmin validates the admin token header while enforcing brute-force
+// lockouts per client IP and per presented token. It aborts the request itself
+// when the caller is locked out.
+func authenticateAdmin(c *gin.Context, token string, guard *bruteforce.Guard, logger *slog.Logger) (ok bool, aborted bool) {
+	provided := c.GetHeader(AdminTokenHeader)
+	ctx := c.Request.Context()
+	keys := []string{"ip:" + c.ClientIP(), "token:" + tokenFingerprint(provided)}
+
+	if wait := guard.Check(ctx, keys...); wait > 0 {
+		logger.Warn("Rejected admin authentication during lockout",
+			slog.String("path", c.Request.URL.Path),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Duration("retry_after", wait))
+
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, i18n.ErrorBody(c, "too_many_auth_failures"))
+		return false, true
+	}
+
+	if !validAdminToken(token, provided) {
+		guard.Fail(ctx, keys...)
+		return false, false
+	}
+
+	guard.Succeed(ctx, keys...)
+	return true, false
+}
+
+func validAdminToken(token string, provided string) bool {
+	if token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+func tokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/bruteforce"
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/identity"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// rateLimitWindow is the period per-key rate limits count requests over.
+const rateLimitWindow = time.Minute
+
+// APIKeyAuth identifies callers presenting an API key in X-API-Key as key:<name>.
+// keyHashes maps key names to the hex SHA-256 of the key, so plaintext keys are never
+// stored. Each key may make limits[name] requests a minute, or defaultLimit when it has
+// no limit of its own; zero is unlimited. Limits are counted per replica. Requests
+// without a key are passed through unchanged and left to RequireAuth. Without any
+// keys the API is open and presented keys are ignored.
+func APIKeyAuth(keyHashes map[string]string, defaultLimit int, limits map[string]int, guard *bruteforce.Guard, logger *slog.Logger) (gin.HandlerFunc, error) {
+	names := make(map[[sha256.Size]byte]string, len(keyHashes))
+	for name, keyHash := range keyHashes {
+		sum, err := hex.DecodeString(keyHash)
+		if err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("invalid key hash for API key %q", name)
+		}
+		names[[sha256.Size]byte(sum)] = name
+	}
+	if defaultLimit < 0 {
+		return nil, fmt.Errorf("invalid API key rate limit %d", defaultLimit)
+	}
+	for name, limit := range limits {
+		if _, ok := keyHashes[name]; !ok || limit < 0 {
+			return nil, fmt.Errorf("invalid rate limit %d for API key %q", limit, name)
+		}
+	}
+
+	if len(keyHashes) == 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}, nil
+	}
+
+	windows := newRateWindows()
+
+	return func(c *gin.Context) {
+		provided := c.GetHeader(APIKeyHeader)
+		if provided == "" {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		sum := sha256.Sum256([]byte(provided))
+		guardKeys := []string{"ip:" + c.ClientIP(), "apikey:" + hex.EncodeToString(sum[:8])}
+
+		if wait := guard.Check(ctx, guardKeys...); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			denyRequest(c, http.StatusTooManyRequests, "too_many_auth_failures", "", logger)
+			return
+		}
+
+		name, ok := names[sum]
+		if !ok {
+			guard.Fail(ctx, guardKeys...)
+			denyRequest(c, http.StatusUnauthorized, "invalid_api_key", "", logger)
+			return
+		}
+		guard.Succeed(ctx, guardKeys...)
+
+		limit := defaultLimit
+		if own, ok := limits[name]; ok {
+			limit = own
+		}
+		if limit > 0 {
+			used, reset := windows.take(name, time.Now())
+			c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(max(limit-used, 0)))
+			if used > limit {
+				c.Header("Retry-After", strconv.Itoa(int(reset.Seconds())+1))
+				denyRequest(c, http.StatusTooManyRequests, "rate_limit_exceeded", name, logger)
+				return
+			}
+		}
+
+		id := identity.FromContext(ctx)
+		id.Actor = "key:" + name
+		c.Request = c.Request.WithContext(identity.WithIdentity(ctx, id))
+
+		c.Next()
+	}, nil
+}
+
+// RequireAuth refuses anonymous requests when enabled: callers must present an API key,
+// sign the request or carry the admin token. It must run after the middleware
+// authenticating them.
+func RequireAuth(enabled bool, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if enabled && identity.FromContext(c.Request.Context()).Actor == identity.Anonymous {
+			denyRequest(c, http.StatusUnauthorized, "api_key_required", "", logger)
+			return
+		}
+		c.Next()
+	}
+}
+
+// denyRequest refuses a request for its credentials. Every denial is logged with
+// audit=access_denied, so they can be collected apart from the rest of the log.
+func denyRequest(c *gin.Context, status int, code string, key string, logger *slog.Logger) {
+	logger.Warn("Denied API request",
+		slog.String("audit", "access_denied"),
+		slog.String("reason", code),
+		slog.String("api_key", key),
+		slog.Int("status", status),
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("client_ip", c.ClientIP()),
+		slog.String("user_agent", c.Request.UserAgent()))
+
+	c.AbortWithStatusJSON(status, i18n.ErrorBody(c, code))
+}
+
+// rateWindows counts the requests of each key in fixed windows of rateLimitWindow.
+type rateWindows struct {
+	mu      sync.Mutex
+	windows map[string]rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateWindows() *rateWindows {
+	return &rateWindows{windows: make(map[string]rateWindow)}
+}
+
+// take counts a request of key at now and returns the requests counted in the current
+// window, including it, and the time left until the window resets.
+func (r *rateWindows) take(key string, now time.Time) (int, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w := r.windows[key]
+	if now.Sub(w.start) >= rateLimitWindow {
+		w = rateWindow{start: now.Truncate(rateLimitWindow)}
+	}
+	w.count++
+	r.windows[key] = w
+	return w.count, w.start.Add(rateLimitWindow).Sub(now)
+}
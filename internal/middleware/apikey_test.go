@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/bruteforce"
+	"awesomeProject1/internal/identity"
+)
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys := map[string]string{"crm": hashKey("crm-secret")}
+	tests := []struct {
+		name       string
+		keyHashes  map[string]string
+		limits     map[string]int
+		header     string
+		requests   int
+		wantStatus int
+		wantActor  string
+		wantLocked bool
+	}{
+		{name: "no keys configured ignores the header", header: "anything", requests: 1, wantStatus: http.StatusOK, wantActor: identity.Anonymous},
+		{name: "no header passes through", keyHashes: keys, requests: 1, wantStatus: http.StatusOK, wantActor: identity.Anonymous},
+		{name: "valid key", keyHashes: keys, header: "crm-secret", requests: 1, wantStatus: http.StatusOK, wantActor: "key:crm"},
+		{name: "invalid key", keyHashes: keys, header: "wrong", requests: 1, wantStatus: http.StatusUnauthorized, wantLocked: true},
+		{name: "within the limit", keyHashes: keys, limits: map[string]int{"crm": 2}, header: "crm-secret", requests: 2, wantStatus: http.StatusOK, wantActor: "key:crm"},
+		{name: "over the limit", keyHashes: keys, limits: map[string]int{"crm": 2}, header: "crm-secret", requests: 3, wantStatus: http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.DiscardHandler)
+			guard := bruteforce.NewGuard(bruteforce.NewMemoryStore(), 1, time.Minute, time.Minute, logger)
+			auth, err := APIKeyAuth(tt.keyHashes, 0, tt.limits, guard, logger)
+			if err != nil {
+				t.Fatalf("APIKeyAuth: %v", err)
+			}
+
+			var actor string
+			router := gin.New()
+			router.Use(auth)
+			router.GET("/", func(c *gin.Context) {
+				actor = identity.FromContext(c.Request.Context()).Actor
+				c.Status(http.StatusOK)
+			})
+
+			var w *httptest.ResponseRecorder
+			for range tt.requests {
+				actor = ""
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.RemoteAddr = "192.0.2.1:1234"
+				if tt.header != "" {
+					req.Header.Set(APIKeyHeader, tt.header)
+				}
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && actor != tt.wantActor {
+				t.Errorf("actor = %q, want %q", actor, tt.wantActor)
+			}
+			if locked := guard.Check(context.Background(), "ip:192.0.2.1") > 0; locked != tt.wantLocked {
+				t.Errorf("client locked out = %v, want %v", locked, tt.wantLocked)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthRejectsInvalidConfiguration(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	guard := bruteforce.NewGuard(bruteforce.NewMemoryStore(), 1, time.Minute, time.Minute, logger)
+	tests := []struct {
+		name      string
+		keyHashes map[string]string
+		limit     int
+		limits    map[string]int
+	}{
+		{name: "hash not hex", keyHashes: map[string]string{"crm": "zz"}},
+		{name: "hash too short", keyHashes: map[string]string{"crm": "abcd"}},
+		{name: "negative default limit", keyHashes: map[string]string{"crm": hashKey("k")}, limit: -1},
+		{name: "limit of unknown key", keyHashes: map[string]string{"crm": hashKey("k")}, limits: map[string]int{"erp": 1}},
+		{name: "negative key limit", keyHashes: map[string]string{"crm": hashKey("k")}, limits: map[string]int{"crm": -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := APIKeyAuth(tt.keyHashes, tt.limit, tt.limits, guard, logger); err == nil {
+				t.Error("APIKeyAuth succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/cache"
+	"awesomeProject1/internal/identity"
+)
+
+// CachedResponse is a successful response kept by ResponseCache.
+type CachedResponse struct {
+	ContentType     string
+	ContentLanguage string
+	Body            []byte
+}
+
+// CacheControl lets clients cache successful reads for maxAge. Responses depend on the
+// caller, so they are marked private and vary on Accept, which selects the version and
+// representation, and on the negotiated language; errors and writes are never cached. A
+// maxAge of zero makes clients revalidate.
+func CacheControl(maxAge time.Duration) gin.HandlerFunc {
+	success := "private, no-cache"
+	if maxAge > 0 {
+		success = "private, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		c.Writer = &cacheControlWriter{ResponseWriter: c.Writer, success: success}
+		c.Next()
+	}
+}
+
+// cacheControlWriter picks Cache-Control once the status is known.
+type cacheControlWriter struct {
+	gin.ResponseWriter
+	success string
+}
+
+func (w *cacheControlWriter) WriteHeader(code int) {
+	if code >= 200 && code < 300 {
+		w.Header().Set("Cache-Control", w.success)
+	} else {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	addVary(w.Header(), "Accept")
+	addVary(w.Header(), "Accept-Language")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// addVary adds field to the Vary header unless an earlier middleware already did.
+func addVary(header http.Header, field string) {
+	for _, value := range header.Values("Vary") {
+		for _, existing := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(existing), field) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", field)
+}
+
+// ResponseCache serves repeated GET requests from store. Requests are keyed by path,
+// the query with its parameters and values sorted, the negotiated version and language
+// and the caller's tenant and impersonated user, so equivalent filters share an entry
+// and callers never see each other's results. Only 200 responses are stored; the store
+// is purged on writes by its owner. A nil store disables the cache.
+func ResponseCache(store *cache.Cache[CachedResponse], logger *slog.Logger) gin.HandlerFunc {
+	if store == nil {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		key := responseCacheKey(c)
+		if cached, ok := store.Get(key); ok {
+			logger.Debug("Serving cached response",
+				slog.String("path", c.Request.URL.Path),
+				slog.String("client_ip", c.ClientIP()))
+
+			if cached.ContentLanguage != "" {
+				c.Header("Content-Language", cached.ContentLanguage)
+			}
+			c.Header("X-Cache", "HIT")
+			c.Data(http.StatusOK, cached.ContentType, cached.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Header("X-Cache", "MISS")
+		c.Next()
+
+		if recorder.Status() == http.StatusOK {
+			store.Set(key, CachedResponse{
+				ContentType:     recorder.Header().Get("Content-Type"),
+				ContentLanguage: recorder.Header().Get("Content-Language"),
+				Body:            recorder.body.Bytes(),
+			})
+		}
+	}
+}
+
+func responseCacheKey(c *gin.Context) string {
+	query := c.Request.URL.Query()
+	for _, values := range query {
+		sort.Strings(values)
+	}
+
+	id := identity.FromContext(c.Request.Context())
+	subject := ""
+	if id.Impersonating() {
+		subject = id.Subject.String()
+	}
+
+	return strings.Join([]string{
+		id.Tenant,
+		subject,
+		c.Request.URL.Path,
+		// Encode sorts by parameter name.
+		query.Encode(),
+		c.GetHeader("Accept"),
+		c.GetHeader("Accept-Language"),
+	}, "\x00")
+}
+
+// bodyRecorder keeps a copy of the response body while writing it through.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/i18n"
+)
+
+// prioritySlots is the share of the limit each priority may fill. Exports stop being
+// admitted at half the limit and writes at 80%, keeping the rest for interactive reads.
+var prioritySlots = map[Priority]float64{
+	PriorityExport:      0.5,
+	PriorityWrite:       0.8,
+	PriorityInteractive: 1,
+}
+
+// ConcurrencyLimit sheds load once limit requests are in flight. Lower priorities, as
+// classified by priority, are shed first: each may only fill its share of the limit,
+// so exports are turned away while interactive reads still get through. A request
+// waits up to wait for a slot and is then rejected with 503 and a Retry-After of
+// retryAfter, so a slow database bounds latency instead of piling up goroutines. A
+// limit of zero or less disables the check.
+func ConcurrencyLimit(limit int, wait time.Duration, retryAfter time.Duration, priority func(*gin.Context) Priority, logger *slog.Logger) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	slots := newSlots(limit)
+	retryAfterSeconds := strconv.Itoa(max(int(retryAfter.Seconds()), 1))
+
+	return func(c *gin.Context) {
+		p := priority(c)
+		if !slots.acquire(c, p, wait) {
+			logger.Warn("Shedding request, too many requests in flight",
+				slog.String("path", c.Request.URL.Path),
+				slog.String("priority", p.String()),
+				slog.String("client_ip", c.ClientIP()),
+				slog.Int("limit", limit))
+
+			c.Header("Retry-After", retryAfterSeconds)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, i18n.ErrorBody(c, "server_overloaded"))
+			return
+		}
+		defer slots.release()
+
+		c.Next()
+	}
+}
+
+type slots struct {
+	limits map[Priority]int
+
+	mu       sync.Mutex
+	inFlight int
+	// released is closed and replaced whenever a slot frees up, waking every waiter.
+	released chan struct{}
+}
+
+func newSlots(limit int) *slots {
+	limits := make(map[Priority]int, len(prioritySlots))
+	for priority, share := range prioritySlots {
+		limits[priority] = max(int(float64(limit)*share), 1)
+	}
+	return &slots{limits: limits, released: make(chan struct{})}
+}
+
+// tryAcquire takes a slot if priority may, or returns a channel closed on the next release.
+func (s *slots) tryAcquire(priority Priority) (bool, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inFlight < s.limits[priority] {
+		s.inFlight++
+		return true, nil
+	}
+	return false, s.released
+}
+
+func (s *slots) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight--
+	close(s.released)
+	s.released = make(chan struct{})
+}
+
+func (s *slots) acquire(c *gin.Context, priority Priority, wait time.Duration) bool {
+	ok, released := s.tryAcquire(priority)
+	if ok || wait <= 0 {
+		return ok
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-released:
+		case <-timer.C:
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		}
+		if ok, released = s.tryAcquire(priority); ok {
+			return true
+		}
+	}
+}
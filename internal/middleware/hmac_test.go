@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/bruteforce"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+type issuedKeysFunc func(ctx context.Context, clientID string) (*models.TenantAPIKey, error)
+
+func (f issuedKeysFunc) APIKey(ctx context.Context, clientID string) (*models.TenantAPIKey, error) {
+	return f(ctx, clientID)
+}
+
+func TestHMACAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	configured := sha256.Sum256([]byte("crm-secret"))
+	issuedKey := sha256.Sum256([]byte("partner-secret"))
+	signingKeys := map[string][]byte{
+		"crm":     configured[:],
+		"partner": issuedKey[:],
+		"ghost":   configured[:],
+	}
+	issued := issuedKeysFunc(func(_ context.Context, clientID string) (*models.TenantAPIKey, error) {
+		switch clientID {
+		case "partner":
+			return &models.TenantAPIKey{SigningKey: issuedKey[:]}, nil
+		case "broken":
+			return nil, errors.New("connection refused")
+		}
+		return nil, nil
+	})
+
+	tests := []struct {
+		name       string
+		clientID   string
+		unsigned   bool
+		skew       time.Duration
+		timestamp  string
+		body       string
+		tamper     bool
+		requests   int
+		wantStatus int
+		wantActor  string
+		wantLocked bool
+	}{
+		{name: "unsigned passes through", unsigned: true, requests: 1, wantStatus: http.StatusOK, wantActor: identity.Anonymous},
+		{name: "configured client", clientID: "crm", body: `{"price":100}`, requests: 1, wantStatus: http.StatusOK, wantActor: "client:crm"},
+		{name: "issued client", clientID: "partner", requests: 1, wantStatus: http.StatusOK, wantActor: "client:partner"},
+		{name: "unknown client", clientID: "ghost", requests: 1, wantStatus: http.StatusUnauthorized, wantLocked: true},
+		{name: "issued key lookup fails", clientID: "broken", requests: 1, wantStatus: http.StatusServiceUnavailable},
+		{name: "tampered body", clientID: "crm", body: `{"price":100}`, tamper: true, requests: 1, wantStatus: http.StatusUnauthorized, wantLocked: true},
+		{name: "timestamp not a number", clientID: "crm", timestamp: "yesterday", requests: 1, wantStatus: http.StatusUnauthorized},
+		{name: "timestamp too old", clientID: "crm", skew: -10 * time.Minute, requests: 1, wantStatus: http.StatusUnauthorized},
+		{name: "timestamp in the future", clientID: "crm", skew: 10 * time.Minute, requests: 1, wantStatus: http.StatusUnauthorized},
+		{name: "body too large", clientID: "crm", body: strings.Repeat("x", 65), requests: 1, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "replayed request", clientID: "crm", requests: 2, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.DiscardHandler)
+			guard := bruteforce.NewGuard(bruteforce.NewMemoryStore(), 1, time.Minute, time.Minute, logger)
+			auth, err := HMACAuth(map[string]string{"crm": hashKey("crm-secret")}, issued, 5*time.Minute, 64, guard, logger)
+			if err != nil {
+				t.Fatalf("HMACAuth: %v", err)
+			}
+
+			var actor, body string
+			router := gin.New()
+			router.Use(auth)
+			router.POST("/subscriptions", func(c *gin.Context) {
+				actor = identity.FromContext(c.Request.Context()).Actor
+				read, _ := io.ReadAll(c.Request.Body)
+				body = string(read)
+				c.Status(http.StatusOK)
+			})
+
+			unix := time.Now().Add(tt.skew).Unix()
+			timestamp := tt.timestamp
+			if timestamp == "" {
+				timestamp = strconv.FormatInt(unix, 10)
+			}
+			signature := Sign(signingKeys[tt.clientID], http.MethodPost, "/subscriptions?dry_run=true", unix, []byte(tt.body))
+			sent := tt.body
+			if tt.tamper {
+				sent = strings.Replace(sent, "100", "1", 1)
+			}
+
+			var w *httptest.ResponseRecorder
+			for range tt.requests {
+				actor, body = "", ""
+				req := httptest.NewRequest(http.MethodPost, "/subscriptions?dry_run=true", strings.NewReader(sent))
+				req.RemoteAddr = "192.0.2.1:1234"
+				if !tt.unsigned {
+					req.Header.Set(ClientIDHeader, tt.clientID)
+					req.Header.Set(TimestampHeader, timestamp)
+					req.Header.Set(SignatureHeader, signature)
+				}
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if actor != tt.wantActor {
+					t.Errorf("actor = %q, want %q", actor, tt.wantActor)
+				}
+				if body != sent {
+					t.Errorf("handler read body %q, want %q", body, sent)
+				}
+			}
+			if locked := guard.Check(context.Background(), "ip:192.0.2.1") > 0; locked != tt.wantLocked {
+				t.Errorf("client locked out = %v, want %v", locked, tt.wantLocked)
+			}
+		})
+	}
+}
+
+func TestHMACAuthRejectsInvalidSecretHash(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	guard := bruteforce.NewGuard(bruteforce.NewMemoryStore(), 1, time.Minute, time.Minute, logger)
+	for _, secretHash := range []string{"zz", "abcd", ""} {
+		if _, err := HMACAuth(map[string]string{"crm": secretHash}, nil, time.Minute, 64, guard, logger); err == nil {
+			t.Errorf("HMACAuth accepted secret hash %q", secretHash)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	key := []byte("key")
+	base := Sign(key, http.MethodPost, "/subscriptions", 1700000000, []byte("{}"))
+	if len(base) != 2*sha256.Size {
+		t.Fatalf("signature %q is not hex SHA-256", base)
+	}
+
+	tests := []struct {
+		name       string
+		key        []byte
+		method     string
+		requestURI string
+		timestamp  int64
+		body       string
+	}{
+		{name: "key", key: []byte("other"), method: http.MethodPost, requestURI: "/subscriptions", timestamp: 1700000000, body: "{}"},
+		{name: "method", key: key, method: http.MethodPut, requestURI: "/subscriptions", timestamp: 1700000000, body: "{}"},
+		{name: "request URI", key: key, method: http.MethodPost, requestURI: "/subscriptions?a=1", timestamp: 1700000000, body: "{}"},
+		{name: "timestamp", key: key, method: http.MethodPost, requestURI: "/subscriptions", timestamp: 1700000001, body: "{}"},
+		{name: "body", key: key, method: http.MethodPost, requestURI: "/subscriptions", timestamp: 1700000000, body: "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if Sign(tt.key, tt.method, tt.requestURI, tt.timestamp, []byte(tt.body)) == base {
+				t.Errorf("changing the %s did not change the signature", tt.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/bruteforce"
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/identity"
+)
+
+const ActAsHeader = "X-Act-As"
+
+func Identity(adminToken string, guard *bruteforce.Guard, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := identity.Identity{
+			Actor:    identity.Anonymous,
+			ClientIP: c.ClientIP(),
+			Tenant:   identity.DefaultTenant,
+		}
+		if adminToken != "" && c.GetHeader(AdminTokenHeader) != "" {
+			ok, aborted := authenticateAdmin(c, adminToken, guard, logger)
+			if aborted {
+				return
+			}
+			if ok {
+				id.Actor = "admin"
+				id.Admin = true
+			}
+		}
+
+		if actAs := c.GetHeader(ActAsHeader); actAs != "" {
+			if !id.Admin {
+				logger.Warn("Rejected impersonation attempt without admin privileges",
+					slog.String("path", c.Request.URL.Path),
+					slog.String("act_as", actAs),
+					slog.String("client_ip", c.ClientIP()))
+
+				c.AbortWithStatusJSON(http.StatusForbidden, i18n.ErrorBody(c, "act_as_forbidden"))
+				return
+			}
+
+			subject, err := uuid.Parse(actAs)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_act_as"))
+				return
+			}
+			id.Subject = &subject
+
+			logger.Info("Admin acting on behalf of user",
+				slog.String("actor", id.Actor),
+				slog.String("subject_id", subject.String()),
+				slog.String("method", c.Request.Method),
+				slog.String("path", c.Request.URL.Path),
+				slog.String("client_ip", c.ClientIP()))
+		}
+
+		c.Request = c.Request.WithContext(identity.WithIdentity(c.Request.Context(), id))
+		c.Next()
+	}
+}
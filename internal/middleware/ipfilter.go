@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/i18n"
+)
+
+// IPFilter restricts a route group to clients matching allow (when non-empty) and not
+// matching deny. Unless trustForwardedFor is set the TCP peer address is used, so a
+// spoofed X-Forwarded-For header cannot bypass the filter.
+func IPFilter(allow []string, deny []string, trustForwardedFor bool, logger *slog.Logger) (gin.HandlerFunc, error) {
+	allowNets, err := parseCIDRs(allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowlist: %w", err)
+	}
+	denyNets, err := parseCIDRs(deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid denylist: %w", err)
+	}
+
+	return func(c *gin.Context) {
+		clientIP := c.RemoteIP()
+		if trustForwardedFor {
+			clientIP = c.ClientIP()
+		}
+
+		ip := net.ParseIP(clientIP)
+		if ip == nil || matchesAny(denyNets, ip) || (len(allowNets) > 0 && !matchesAny(allowNets, ip)) {
+			logger.Warn("Rejected request from disallowed IP",
+				slog.String("path", c.Request.URL.Path),
+				slog.String("client_ip", clientIP))
+
+			c.AbortWithStatusJSON(http.StatusForbidden, i18n.ErrorBody(c, "access_denied"))
+			return
+		}
+
+		c.Next()
+	}, nil
+}
+
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %q", value)
+			}
+			if ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func matchesAny(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
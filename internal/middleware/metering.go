@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/identity"
+)
+
+type UsageMeter interface {
+	Record(apiKey string, endpoint string, requestBytes int64, responseBytes int64)
+}
+
+func Metering(meter UsageMeter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		endpoint := c.FullPath()
+		if endpoint == "" {
+			endpoint = "unmatched"
+		}
+
+		requestBytes := max(c.Request.ContentLength, 0)
+		responseBytes := int64(max(c.Writer.Size(), 0))
+
+		meter.Record(identity.FromContext(c.Request.Context()).Principal(), c.Request.Method+" "+endpoint, requestBytes, responseBytes)
+	}
+}
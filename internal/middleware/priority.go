@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/identity"
+)
+
+// PriorityHeader lets internal callers, admins and the signed clients listed as
+// internal, pick the priority of their request. It is ignored for everyone else.
+const PriorityHeader = "X-Priority"
+
+// Priority orders requests for load shedding; higher priorities are shed last.
+type Priority int
+
+const (
+	PriorityExport Priority = iota
+	PriorityWrite
+	PriorityInteractive
+)
+
+var priorityNames = map[Priority]string{
+	PriorityExport:      "export",
+	PriorityWrite:       "write",
+	PriorityInteractive: "interactive",
+}
+
+func (p Priority) String() string {
+	return priorityNames[p]
+}
+
+// RoutePriorities assigns priorities to routes. Routes without one are interactive
+// when they only read and writes otherwise.
+type RoutePriorities struct {
+	mu       sync.RWMutex
+	routes   map[string]Priority
+	internal map[string]bool
+}
+
+// NewRoutePriorities honours PriorityHeader for admins and the HMAC clients in
+// internalClients.
+func NewRoutePriorities(internalClients []string) *RoutePriorities {
+	internal := make(map[string]bool, len(internalClients))
+	for _, clientID := range internalClients {
+		internal["client:"+clientID] = true
+	}
+	return &RoutePriorities{routes: make(map[string]Priority), internal: internal}
+}
+
+// Set gives the routes of group matching paths, relative to the group, priority.
+func (r *RoutePriorities) Set(group *gin.RouterGroup, priority Priority, paths ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, path := range paths {
+		r.routes[joinPaths(group.BasePath(), path)] = priority
+	}
+}
+
+// Of returns the priority of the request, honouring PriorityHeader for internal callers.
+func (r *RoutePriorities) Of(c *gin.Context) Priority {
+	if id := identity.FromContext(c.Request.Context()); id.Admin || r.internal[id.Actor] {
+		for priority, name := range priorityNames {
+			if c.GetHeader(PriorityHeader) == name {
+				return priority
+			}
+		}
+	}
+
+	r.mu.RLock()
+	priority, ok := r.routes[c.FullPath()]
+	r.mu.RUnlock()
+	if ok {
+		return priority
+	}
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return PriorityInteractive
+	}
+	return PriorityWrite
+}
+
+func joinPaths(base string, path string) string {
+	if base == "/" {
+		return path
+	}
+	return base + path
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/quota"
+)
+
+type QuotaService interface {
+	ConsumeRequest(ctx context.Context, id identity.Identity) ([]quota.Status, error)
+	ReserveCreates(ctx context.Context, id identity.Identity, count int64) (*quota.Reservation, []quota.Status, error)
+	Release(ctx context.Context, r *quota.Reservation) error
+}
+
+// RequestQuota counts every request against the monthly quota of the caller's key and
+// tenant. Quota storage errors fail open.
+func RequestQuota(quotas QuotaService, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := identity.FromContext(c.Request.Context())
+
+		statuses, err := quotas.ConsumeRequest(c.Request.Context(), id)
+		if err != nil {
+			logger.Error("Quota check failed, allowing request",
+				slog.String("principal", id.Principal()),
+				slog.String("error", err.Error()))
+			c.Next()
+			return
+		}
+
+		setQuotaHeaders(c, statuses)
+
+		for _, status := range statuses {
+			if status.RequestsExceeded() {
+				logger.Warn("Monthly request quota exceeded",
+					slog.String("scope", status.Scope),
+					slog.String("subject", status.Subject),
+					slog.Int64("requests", status.Requests),
+					slog.Int64("limit", status.RequestLimit))
+
+				body := i18n.ErrorBody(c, "quota_requests_exceeded")
+				body["scope"] = status.Scope
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, body)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// CreateQuota reserves one create from the monthly create allowance before the handler
+// runs, and gives it back when the handler does not respond with success.
+func CreateQuota(quotas QuotaService, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reserveCreates(c, quotas, 1, logger)
+	}
+}
+
+// BatchCreateQuota reserves as many creates as the JSON array in the body has items,
+// like CreateQuota. Bodies that are not an array reserve none and are left to the
+// handler to refuse.
+func BatchCreateQuota(quotas QuotaService, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, i18n.ErrorBody(c, "invalid_json"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		count := countArrayItems(body)
+		if count == 0 {
+			c.Next()
+			return
+		}
+		reserveCreates(c, quotas, count, logger)
+	}
+}
+
+// reserveCreates reserves count creates for the caller, refusing the request with 402
+// when an allowance has fewer left, runs the rest of the chain and releases them unless
+// it succeeded. Quota storage errors fail open.
+func reserveCreates(c *gin.Context, quotas QuotaService, count int64, logger *slog.Logger) {
+	id := identity.FromContext(c.Request.Context())
+
+	reservation, statuses, err := quotas.ReserveCreates(c.Request.Context(), id, count)
+	if err != nil {
+		logger.Error("Create quota reservation failed, allowing request",
+			slog.String("principal", id.Principal()),
+			slog.String("error", err.Error()))
+		c.Next()
+		return
+	}
+	if reservation == nil {
+		status := statuses[len(statuses)-1]
+		logger.Warn("Monthly create quota exhausted",
+			slog.String("scope", status.Scope),
+			slog.String("subject", status.Subject),
+			slog.Int64("creates", status.Creates),
+			slog.Int64("requested", count),
+			slog.Int64("limit", status.CreateLimit))
+
+		c.Header("X-Quota-Creates-Limit", strconv.FormatInt(status.CreateLimit, 10))
+		c.Header("X-Quota-Creates-Used", strconv.FormatInt(status.Creates, 10))
+		body := i18n.ErrorBody(c, "quota_creates_exhausted")
+		body["scope"] = status.Scope
+		c.AbortWithStatusJSON(http.StatusPaymentRequired, body)
+		return
+	}
+
+	c.Next()
+
+	if status := c.Writer.Status(); status < 200 || status > 299 {
+		// The request may have been cancelled, but the creates must still go back.
+		if err := quotas.Release(context.WithoutCancel(c.Request.Context()), reservation); err != nil {
+			logger.Error("Failed to release reserved creates",
+				slog.String("principal", id.Principal()),
+				slog.Int64("count", count),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+// countArrayItems returns the number of items of the JSON array in body, or zero when
+// body is not one.
+func countArrayItems(body []byte) int64 {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return 0
+	}
+	var count int64
+	for decoder.More() {
+		var item json.RawMessage
+		if err := decoder.Decode(&item); err != nil {
+			return 0
+		}
+		count++
+	}
+	return count
+}
+
+// setQuotaHeaders reports the most constrained request quota among the caller's scopes.
+func setQuotaHeaders(c *gin.Context, statuses []quota.Status) {
+	var tightest *quota.Status
+	for i := range statuses {
+		status := &statuses[i]
+		if status.RequestLimit == 0 {
+			continue
+		}
+		if tightest == nil || status.RequestsRemaining() < tightest.RequestsRemaining() {
+			tightest = status
+		}
+	}
+	if tightest == nil {
+		return
+	}
+
+	c.Header("X-Quota-Limit", strconv.FormatInt(tightest.RequestLimit, 10))
+	c.Header("X-Quota-Used", strconv.FormatInt(tightest.Requests, 10))
+	c.Header("X-Quota-Remaining", strconv.FormatInt(tightest.RequestsRemaining(), 10))
+	c.Header("X-Quota-Scope", tightest.Scope)
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/i18n"
+)
+
+// readOnlyRetryAfter is how long clients are told to wait before retrying a write.
+const readOnlyRetryAfter = "30"
+
+type ReadOnlyMode interface {
+	Active() bool
+}
+
+// ReadOnly rejects the requests of the routes it guards with 503 while read-only mode
+// is on. Guard the routes that write; reads keep being served from the replica.
+func ReadOnly(mode ReadOnlyMode, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mode.Active() {
+			c.Next()
+			return
+		}
+
+		logger.Warn("Rejecting write in read-only mode",
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()))
+
+		c.Header("Retry-After", readOnlyRetryAfter)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, i18n.ErrorBody(c, "read_only_mode"))
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/identity"
+)
+
+// Sandbox moves the requests of the listed HMAC clients and API keys to the sandbox
+// tenant, whose data is kept apart from production. It must run after HMACAuth and
+// APIKeyAuth have identified them.
+func Sandbox(clients []string, keys []string, logger *slog.Logger) gin.HandlerFunc {
+	actors := make(map[string]bool, len(clients)+len(keys))
+	for _, clientID := range clients {
+		actors["client:"+clientID] = true
+	}
+	for _, name := range keys {
+		actors["key:"+name] = true
+	}
+
+	return func(c *gin.Context) {
+		id := identity.FromContext(c.Request.Context())
+		if actors[id.Actor] {
+			logger.Debug("Routing request to the sandbox",
+				slog.String("actor", id.Actor),
+				slog.String("path", c.Request.URL.Path))
+
+			id.Tenant = identity.SandboxTenant
+			c.Request = c.Request.WithContext(identity.WithIdentity(c.Request.Context(), id))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/i18n"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+// TenantDirectory knows the tenants onboarded through the admin API and the API keys
+// issued to them. Both lookups return nil when there is no such row.
+type TenantDirectory interface {
+	IssuedKeys
+	Tenant(ctx context.Context, name string) (*models.Tenant, error)
+}
+
+// Tenants moves the requests of HMAC clients to their tenant: the one clients maps them
+// to, or the one their API key was issued to. Requests of suspended tenants are
+// refused. With isolate set, requests of isolated tenants also run on the tenant's
+// schema, and requests of tenants that were never onboarded are refused rather than
+// served from the shared tables. It must run after HMACAuth and Sandbox, and leaves
+// sandbox requests alone.
+func Tenants(clients map[string]string, directory TenantDirectory, isolate bool, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		id := identity.FromContext(ctx)
+		clientID, signed := strings.CutPrefix(id.Actor, "client:")
+		if !signed || id.Sandbox() {
+			c.Next()
+			return
+		}
+
+		unavailable := func(message string, tenant string, err error) {
+			logger.Error(message,
+				slog.String("actor", id.Actor),
+				slog.String("tenant", tenant),
+				slog.String("error", err.Error()))
+
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, i18n.ErrorBody(c, "tenant_unavailable"))
+		}
+
+		name, ok := clients[clientID]
+		if !ok {
+			key, err := directory.APIKey(ctx, clientID)
+			if err != nil {
+				unavailable("Failed to look up issued API key", "", err)
+				return
+			}
+			if key == nil {
+				c.Next()
+				return
+			}
+			name = key.Tenant
+		}
+
+		tenant, err := directory.Tenant(ctx, name)
+		if err != nil {
+			unavailable("Failed to look up tenant", name, err)
+			return
+		}
+		switch {
+		case tenant != nil && tenant.Suspended():
+			logger.Warn("Rejected request of suspended tenant",
+				slog.String("actor", id.Actor),
+				slog.String("tenant", name))
+
+			c.AbortWithStatusJSON(http.StatusForbidden, i18n.ErrorBody(c, "tenant_suspended"))
+			return
+		case tenant == nil && isolate:
+			logger.Error("Rejected request of tenant that was never onboarded",
+				slog.String("actor", id.Actor),
+				slog.String("tenant", name))
+
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, i18n.ErrorBody(c, "tenant_unavailable"))
+			return
+		}
+
+		id.Tenant = name
+		if isolate && tenant.Schema != nil {
+			id.Schema = *tenant.Schema
+		}
+		c.Request = c.Request.WithContext(identity.WithIdentity(ctx, id))
+		c.Next()
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/shopspring/decimal"
+)
+
+// AggregateFilter narrows an aggregation. Empty include lists match everything;
+// exclusions are applied on top of the includes. Categories match like the Category
+// of ListFilter.
+type AggregateFilter struct {
+	UserIDs             []uuid.UUID
+	ServiceNames        []string
+	ExcludeUserIDs      []uuid.UUID
+	ExcludeServiceNames []string
+	Kinds               []string
+	Categories          []string
+}
+
+func (f AggregateFilter) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Any("user_ids", f.UserIDs),
+		slog.Any("service_names", f.ServiceNames),
+		slog.Any("exclude_user_ids", f.ExcludeUserIDs),
+		slog.Any("exclude_service_names", f.ExcludeServiceNames),
+		slog.Any("kinds", f.Kinds),
+		slog.Any("categories", f.Categories))
+}
+
+// Aggregation modes. Normalized totals monthly costs of what is active in the period;
+// exact totals the charges that fall due in it.
+const (
+	AggregateNormalized = "normalized"
+	AggregateExact      = "exact"
+)
+
+const (
+	BucketMonth   = "month"
+	BucketQuarter = "quarter"
+	BucketYear    = "year"
+)
+
+type BucketTotal struct {
+	Start time.Time       `json:"start"`
+	Total decimal.Decimal `json:"total"`
+}
+
+// AggregateDetail is what one subscription contributes to an aggregate total: its
+// monthly cost in normalized mode, or the sum of its Charges in exact mode. Amounts
+// are not rounded; a normalized total is their sum rounded by the rounding policy.
+type AggregateDetail struct {
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	UserID         uuid.UUID       `json:"user_id"`
+	ServiceName    string          `json:"service_name"`
+	Amount         decimal.Decimal `json:"amount"`
+	Charges        int             `json:"charges,omitempty"`
+}
+
+// AggregateBreakdown lists the subscriptions behind an aggregate total, largest amount
+// first. Truncated is set when there were more than could be listed.
+type AggregateBreakdown struct {
+	Details   []AggregateDetail `json:"details"`
+	Truncated bool              `json:"truncated"`
+}
+
+type StatSummary struct {
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Avg    float64 `json:"avg"`
+	Median float64 `json:"median"`
+	P95    float64 `json:"p95"`
+}
+
+// SubscriptionStats describes the distribution of prices and of durations in months,
+// where open-ended subscriptions count up to the current month.
+type SubscriptionStats struct {
+	Count          int64       `json:"count"`
+	Price          StatSummary `json:"price"`
+	DurationMonths StatSummary `json:"duration_months"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/shopspring/decimal"
+)
+
+type ServiceRanking struct {
+	ServiceName string          `json:"service_name"`
+	TotalSpend  decimal.Decimal `json:"total_spend"`
+	Subscribers int64           `json:"subscribers"`
+}
+
+// DuplicatePair is a pair of subscriptions of the same user with overlapping dates and
+// similar service names.
+type DuplicatePair struct {
+	UserID            uuid.UUID `json:"user_id"`
+	FirstID           uuid.UUID `json:"first_id"`
+	FirstServiceName  string    `json:"first_service_name"`
+	SecondID          uuid.UUID `json:"second_id"`
+	SecondServiceName string    `json:"second_service_name"`
+	Similarity        float64   `json:"similarity"`
+}
+
+// SpendComparison compares spend between two periods, A and B. Change is B minus A
+// and ChangePercent is relative to A; it is nil when A is zero.
+type SpendComparison struct {
+	TotalA        decimal.Decimal     `json:"total_a"`
+	TotalB        decimal.Decimal     `json:"total_b"`
+	Change        decimal.Decimal     `json:"change"`
+	ChangePercent *float64            `json:"change_percent"`
+	Services      []ServiceComparison `json:"services"`
+}
+
+type ServiceComparison struct {
+	ServiceName   string          `json:"service_name"`
+	TotalA        decimal.Decimal `json:"total_a"`
+	TotalB        decimal.Decimal `json:"total_b"`
+	Change        decimal.Decimal `json:"change"`
+	ChangePercent *float64        `json:"change_percent"`
+}
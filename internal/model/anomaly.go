@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AnomalyNewExpensive    = "new_expensive"
+	AnomalyPriceJump       = "price_jump"
+	AnomalyDuplicateCharge = "duplicate_charge"
+)
+
+// Anomaly is an unusual change in a user's spend found by the anomaly scan. MonthlyCost
+// is the normalized cost of the subscription; Baseline is what it is compared with, the
+// cost before a price jump or the user's median cost for a new expensive subscription.
+// Duplicate charges name the other subscription of the pair instead. Key identifies
+// what was flagged, so that rescans never flag it twice.
+type Anomaly struct {
+	ID                    uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID                uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	SubscriptionID        uuid.UUID  `gorm:"type:uuid;not null" json:"subscription_id"`
+	RelatedSubscriptionID *uuid.UUID `gorm:"type:uuid" json:"related_subscription_id,omitempty"`
+	Kind                  string     `gorm:"not null" json:"kind"`
+	ServiceName           string     `gorm:"not null" json:"service_name"`
+	MonthlyCost           int64      `gorm:"not null" json:"monthly_cost,omitempty"`
+	Baseline              *int64     `json:"baseline,omitempty"`
+	Key                   string     `gorm:"not null;uniqueIndex" json:"-"`
+	DetectedAt            time.Time  `gorm:"not null" json:"detected_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AsyncJobRunning   = "running"
+	AsyncJobSucceeded = "succeeded"
+	AsyncJobFailed    = "failed"
+)
+
+// AsyncJob is a long-running operation run in the background. Progress counts Unit,
+// e.g. bytes, out of Total when it is known. A job producing a file, such as an
+// export, stores it apart as an AsyncJobFile named FileName.
+type AsyncJob struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Kind        string          `gorm:"not null" json:"kind"`
+	Status      string          `gorm:"not null" json:"status"`
+	Actor       string          `gorm:"not null" json:"actor"`
+	Unit        string          `gorm:"not null" json:"unit"`
+	Progress    int64           `gorm:"not null" json:"progress"`
+	Total       *int64          `json:"total,omitempty"`
+	Result      json.RawMessage `gorm:"type:jsonb" json:"result,omitempty"`
+	Error       *string         `json:"error,omitempty"`
+	FileName    *string         `json:"file_name,omitempty"`
+	ContentType *string         `json:"-"`
+	CreatedAt   time.Time       `gorm:"not null" json:"created_at"`
+	HeartbeatAt time.Time       `gorm:"not null" json:"heartbeat_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+type AsyncJobFile struct {
+	JobID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Content []byte    `gorm:"not null"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditRecord struct {
+	ID             uuid.UUID       `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Actor          string          `gorm:"not null" json:"actor"`
+	SubjectID      *uuid.UUID      `gorm:"type:uuid;index" json:"subject_id,omitempty"`
+	Action         string          `gorm:"not null" json:"action"`
+	SubscriptionID *uuid.UUID      `gorm:"type:uuid;index" json:"subscription_id,omitempty"`
+	Before         json.RawMessage `gorm:"type:jsonb" json:"before,omitempty"`
+	After          json.RawMessage `gorm:"type:jsonb" json:"after,omitempty"`
+	ClientIP       string          `json:"client_ip,omitempty"`
+	CreatedAt      time.Time       `gorm:"not null" json:"created_at"`
+}
+
+func (AuditRecord) TableName() string {
+	return "audit_log"
+}
+
+type AuditFilter struct {
+	Actor           string
+	SubjectID       *uuid.UUID
+	SubscriptionID  *uuid.UUID
+	SubscriptionIDs []uuid.UUID
+	Actions         []string
+	Limit           int
+}
@@ -0,0 +1,12 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// TenantStats are the business figures of a tenant. Active subscriptions have started
+// and not ended; MonthlyRecurringRevenue is the monthly cost of the active recurring
+// ones.
+type TenantStats struct {
+	Tenant                  string          `json:"tenant"`
+	ActiveSubscriptions     int64           `json:"active_subscriptions"`
+	MonthlyRecurringRevenue decimal.Decimal `json:"monthly_recurring_revenue"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/shopspring/decimal"
+)
+
+// Category groups subscriptions for filtering and reporting, e.g. streaming or
+// fitness. Subscriptions and the service catalog refer to it by Slug.
+type Category struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Slug      string    `gorm:"not null;uniqueIndex" json:"slug"`
+	Name      string    `gorm:"not null" json:"name"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+}
+
+// CatalogEntry assigns a category to every subscription of a service that has none of
+// its own. ServiceName is stored trimmed and lower-cased.
+type CatalogEntry struct {
+	ServiceName string    `gorm:"primaryKey" json:"service_name"`
+	Category    string    `gorm:"not null" json:"category"`
+	UpdatedAt   time.Time `gorm:"not null" json:"updated_at"`
+}
+
+func (CatalogEntry) TableName() string {
+	return "service_catalog"
+}
+
+// ServiceAlias renames subscriptions of a misspelled or legacy service name to its
+// canonical spelling when the repair command runs. Alias is stored trimmed and
+// lower-cased.
+type ServiceAlias struct {
+	Alias       string    `gorm:"primaryKey" json:"alias"`
+	ServiceName string    `gorm:"not null" json:"service_name"`
+	UpdatedAt   time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// CategoryTotal is the aggregated cost of the subscriptions in a category. A nil
+// Category holds the uncategorized ones.
+type CategoryTotal struct {
+	Category *string         `json:"category"`
+	Total    decimal.Decimal `json:"total"`
+}
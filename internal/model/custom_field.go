@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Types of custom field values. Dates are written YYYY-MM-DD.
+const (
+	CustomFieldString  = "string"
+	CustomFieldNumber  = "number"
+	CustomFieldBoolean = "boolean"
+	CustomFieldDate    = "date"
+)
+
+// CustomField defines a field a tenant stores on its subscriptions next to the
+// built-in ones. The values live in Subscription.CustomFields.
+type CustomField struct {
+	Tenant    string    `gorm:"primaryKey" json:"tenant"`
+	Name      string    `gorm:"primaryKey" json:"name"`
+	Type      string    `gorm:"not null" json:"type"`
+	Required  bool      `gorm:"not null" json:"required"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
+}
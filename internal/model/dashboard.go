@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthDraining = "draining"
+)
+
+// Sources of the errors listed on the dashboard.
+const (
+	ErrorSourceMail       = "mail"
+	ErrorSourceDeadLetter = "dead_letter"
+	ErrorSourceJob        = "job"
+)
+
+// Dashboard is the operational overview served to the ops UI. When the database is
+// unreachable only the sections kept in memory are filled in.
+type Dashboard struct {
+	GeneratedAt  time.Time        `json:"generated_at"`
+	Health       DashboardHealth  `json:"health"`
+	RecentErrors []DashboardError `json:"recent_errors"`
+	TopTenants   []QuotaUsage     `json:"top_tenants"`
+	Queues       DashboardQueues  `json:"queues"`
+	Jobs         []JobStatus      `json:"jobs"`
+}
+
+type DashboardHealth struct {
+	Status            string `json:"status"`
+	Database          string `json:"database"`
+	DatabaseLatencyMS int64  `json:"database_latency_ms"`
+	FailingJobs       int    `json:"failing_jobs"`
+}
+
+type DashboardError struct {
+	Source  string    `json:"source"`
+	Ref     string    `json:"ref"`
+	Message string    `json:"message"`
+	At      time.Time `json:"at"`
+}
+
+// DashboardQueues reports the depth of each outbound queue. UsageBuffered counts the
+// API usage rows held in memory until the next flush.
+type DashboardQueues struct {
+	MailPending   int64 `json:"mail_pending"`
+	MailFailed    int64 `json:"mail_failed"`
+	DeadLetters   int64 `json:"dead_letters"`
+	UsageBuffered int   `json:"usage_buffered"`
+}
+
+// JobStatus is the state of a scheduled job. LastError is the error of the last run
+// and empty when it succeeded. Missed counts the runs due while the previous one was
+// still going, TimedOut the runs cancelled at MaxRuntime.
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Interval       string     `json:"interval"`
+	MaxRuntime     string     `json:"max_runtime"`
+	Runs           int64      `json:"runs"`
+	Failures       int64      `json:"failures"`
+	Skipped        int64      `json:"skipped"`
+	Missed         int64      `json:"missed"`
+	TimedOut       int64      `json:"timed_out"`
+	Running        bool       `json:"running"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastDurationMS int64      `json:"last_duration_ms"`
+	LastError      string     `json:"last_error,omitempty"`
+}
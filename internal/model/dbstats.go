@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// DatabaseStats describes the size of the database and of each table, as reported by
+// the pg_stat views.
+type DatabaseStats struct {
+	SizeBytes int64        `json:"size_bytes"`
+	Tables    []TableStats `json:"tables"`
+}
+
+// TableStats are the statistics of one table. EstimatedRows comes from the planner
+// statistics and is 0 until the table is first analyzed. DeadRatio is the share of
+// dead tuples among all tuples; a high one means autovacuum is falling behind.
+type TableStats struct {
+	Schema               string     `json:"schema"`
+	Table                string     `json:"table"`
+	EstimatedRows        int64      `json:"estimated_rows"`
+	LiveTuples           int64      `json:"live_tuples"`
+	DeadTuples           int64      `json:"dead_tuples"`
+	DeadRatio            float64    `json:"dead_ratio"`
+	ModifiedSinceAnalyze int64      `json:"modified_since_analyze"`
+	TableBytes           int64      `json:"table_bytes"`
+	IndexBytes           int64      `json:"index_bytes"`
+	TotalBytes           int64      `json:"total_bytes"`
+	LastVacuum           *time.Time `json:"last_vacuum"`
+	LastAutovacuum       *time.Time `json:"last_autovacuum"`
+	LastAnalyze          *time.Time `json:"last_analyze"`
+	LastAutoanalyze      *time.Time `json:"last_autoanalyze"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeadLetter is an event that could not be delivered to a sink. Payload holds the
+// complete event so it can be retried as is.
+type DeadLetter struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Sink          string          `gorm:"not null" json:"sink"`
+	EventID       uuid.UUID       `gorm:"type:uuid;not null" json:"event_id"`
+	EventType     string          `gorm:"not null" json:"event_type"`
+	Payload       json.RawMessage `gorm:"type:jsonb;not null" json:"payload,omitempty"`
+	Error         string          `gorm:"not null" json:"error"`
+	Attempts      int             `gorm:"not null" json:"attempts"`
+	CreatedAt     time.Time       `gorm:"not null" json:"created_at"`
+	LastAttemptAt time.Time       `gorm:"not null" json:"last_attempt_at"`
+}
+
+type DeadLetterFilter struct {
+	Sink      string
+	EventType string
+	Before    *time.Time
+	Limit     int
+}
@@ -0,0 +1,20 @@
+package models
+
+import "github.com/google/uuid"
+
+// Entity is a model stored in its own table with a UUID primary key column named id.
+type Entity interface {
+	PrimaryKey() uuid.UUID
+}
+
+func (s Subscription) PrimaryKey() uuid.UUID { return s.ID }
+
+func (r Reminder) PrimaryKey() uuid.UUID { return r.ID }
+
+func (d DeadLetter) PrimaryKey() uuid.UUID { return d.ID }
+
+func (r RecordedRequest) PrimaryKey() uuid.UUID { return r.ID }
+
+func (c Category) PrimaryKey() uuid.UUID { return c.ID }
+
+func (j AsyncJob) PrimaryKey() uuid.UUID { return j.ID }
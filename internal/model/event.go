@@ -0,0 +1,35 @@
+package models
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventFilter selects the audit history that domain events are replayed from.
+type EventFilter struct {
+	From            time.Time
+	To              time.Time
+	SubscriptionIDs []uuid.UUID
+	UserID          *uuid.UUID
+	Types           []string
+}
+
+func (f EventFilter) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.Time("from", f.From),
+		slog.Time("to", f.To),
+		slog.Int("subscription_ids", len(f.SubscriptionIDs)),
+		slog.Any("types", f.Types),
+	}
+	if f.UserID != nil {
+		attrs = append(attrs, slog.String("user_id", f.UserID.String()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+type ReplayResult struct {
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
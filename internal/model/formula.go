@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// AggregationFormula is a named aggregation a tenant defines as arithmetic over the
+// totals of filtered subscriptions; the grammar is described in package formula.
+type AggregationFormula struct {
+	Tenant      string    `gorm:"primaryKey" json:"tenant"`
+	Name        string    `gorm:"primaryKey" json:"name"`
+	Expression  string    `gorm:"not null" json:"expression"`
+	Description string    `gorm:"not null" json:"description"`
+	UpdatedAt   time.Time `gorm:"not null" json:"updated_at"`
+}
+
+func (AggregationFormula) TableName() string {
+	return "aggregation_formulas"
+}
+
+// FormulaTerm is the total of one term of a formula over the period.
+type FormulaTerm struct {
+	Term  string          `json:"term"`
+	Value decimal.Decimal `json:"value"`
+}
+
+// FormulaResult is a formula evaluated over the period from Start to End, along with
+// the totals of its terms.
+type FormulaResult struct {
+	Name       string          `json:"name"`
+	Expression string          `json:"expression"`
+	Start      time.Time       `json:"start"`
+	End        time.Time       `json:"end"`
+	Mode       string          `json:"mode"`
+	Value      decimal.Decimal `json:"value"`
+	Terms      []FormulaTerm   `json:"terms"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Instance is a running replica as last reported by its heartbeat.
+type Instance struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Hostname   string    `gorm:"not null" json:"hostname"`
+	Version    string    `gorm:"not null" json:"version"`
+	Commit     string    `gorm:"not null" json:"commit"`
+	Leader     bool      `gorm:"not null" json:"leader"`
+	StartedAt  time.Time `gorm:"not null" json:"started_at"`
+	LastSeenAt time.Time `gorm:"not null" json:"last_seen_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a LakeSyncRun.
+const (
+	LakeSyncRunning   = "running"
+	LakeSyncSucceeded = "succeeded"
+	LakeSyncFailed    = "failed"
+)
+
+// LakeSyncRun is one sync of a data lake destination: the subscriptions changed from
+// Since up to Until, written as the object ObjectKey. Runs without changes write no
+// object. The Until of a destination's last succeeded run is where its next run starts.
+type LakeSyncRun struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Destination string     `gorm:"not null" json:"destination"`
+	Status      string     `gorm:"not null" json:"status"`
+	Since       time.Time  `gorm:"not null" json:"since"`
+	Until       time.Time  `gorm:"not null" json:"until"`
+	RowCount    int64      `gorm:"not null" json:"row_count"`
+	ObjectKey   *string    `json:"object_key,omitempty"`
+	Error       *string    `json:"error,omitempty"`
+	StartedAt   time.Time  `gorm:"not null" json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// SubscriptionChange is a subscription as read by the data lake sync, soft-deleted
+// ones included, with the time it last changed.
+type SubscriptionChange struct {
+	Subscription
+	UpdatedAt time.Time
+}
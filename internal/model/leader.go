@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// LeaderStatus tells whether an instance runs the leader-only background subsystems.
+type LeaderStatus struct {
+	Leader bool       `json:"leader"`
+	Since  *time.Time `json:"leader_since,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/shopspring/decimal"
+)
+
+// LifetimeValue is what a user has been charged for their subscriptions so far.
+// TenureMonths counts the months from the start month of their first subscription to
+// the current month, or to the last billed month once every subscription has ended.
+type LifetimeValue struct {
+	UserID              uuid.UUID       `json:"user_id"`
+	TotalSpend          decimal.Decimal `json:"total_spend"`
+	AverageMonthlySpend decimal.Decimal `json:"average_monthly_spend"`
+	TenureMonths        int             `json:"tenure_months"`
+	FirstSubscribed     *time.Time      `json:"first_subscribed,omitempty"`
+	Subscriptions       int             `json:"subscriptions"`
+	Charges             int             `json:"charges"`
+	ComputedAt          time.Time       `json:"computed_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MailPending = "pending"
+	MailSent    = "sent"
+	MailFailed  = "failed"
+)
+
+// MailDelivery is a rendered email in the outbound queue. Rows stay after delivery
+// and double as the delivery log.
+type MailDelivery struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Template      string     `gorm:"not null" json:"template"`
+	Recipients    string     `gorm:"not null" json:"recipients"`
+	Subject       string     `gorm:"not null" json:"subject"`
+	TextBody      string     `gorm:"not null" json:"-"`
+	HTMLBody      string     `gorm:"not null" json:"-"`
+	Status        string     `gorm:"not null" json:"status"`
+	Attempts      int        `gorm:"not null" json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"not null" json:"created_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+type MailFilter struct {
+	Status string
+	Limit  int
+}
@@ -0,0 +1,49 @@
+package models
+
+import "github.com/google/uuid"
+
+// Page selects a window of a listing. A zero Limit returns every row, which only
+// internal callers use; API listings always bound it. OrderBy sorts the rows before the
+// window is taken, by start date otherwise.
+type Page struct {
+	Limit   int
+	Offset  int
+	OrderBy []Order
+}
+
+// Order sorts a listing by a field of the subscription, named as in its JSON.
+type Order struct {
+	Field string
+	Desc  bool
+}
+
+// Operators of a Condition.
+const (
+	OpEq = "eq"
+	OpNe = "ne"
+	OpGt = "gt"
+	OpGe = "ge"
+	OpLt = "lt"
+	OpLe = "le"
+)
+
+// Condition compares a field of the subscription, named as in its JSON, with Value. A
+// nil Value with OpEq or OpNe tests whether the field is unset.
+type Condition struct {
+	Field string
+	Op    string
+	Value any
+}
+
+// ListFilter narrows a subscription listing. Zero values match everything.
+// Category matches the category slug of the subscription or, failing that, of its
+// service in the catalog. CustomFields matches subscriptions having every given
+// custom field value, and Conditions subscriptions meeting all of them.
+type ListFilter struct {
+	UserID       uuid.UUID
+	ServiceName  string
+	Kind         string
+	Category     string
+	CustomFields map[string]any
+	Conditions   []Condition
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceCurrency is the currency subscription prices are stored in.
+const PriceCurrency = "RUB"
+
+// UserPreferences are the defaults a user sets for their own subscriptions. Empty
+// fields leave the deployment's behaviour unchanged: amounts in PriceCurrency, dates
+// in UTC, and reminders needing an explicit lead time and channel.
+type UserPreferences struct {
+	Tenant               string    `gorm:"primaryKey" json:"-"`
+	UserID               uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Currency             string    `gorm:"not null" json:"currency,omitempty"`
+	Timezone             string    `gorm:"not null" json:"timezone,omitempty"`
+	NotificationChannels []string  `gorm:"type:jsonb;serializer:json;not null" json:"notification_channels"`
+	ReminderDaysBefore   *int      `json:"reminder_days_before,omitempty"`
+	UpdatedAt            time.Time `gorm:"not null" json:"updated_at"`
+}
+
+func (UserPreferences) TableName() string {
+	return "user_preferences"
+}
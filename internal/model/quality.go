@@ -0,0 +1,31 @@
+package models
+
+import "github.com/google/uuid"
+
+// Data quality checks, in report order.
+const (
+	QualityEndBeforeStart = "end_before_start"
+	QualityZeroDuration   = "zero_duration"
+	QualityOrphanedUser   = "orphaned_user_id"
+	QualityAbsurdPrice    = "absurd_price"
+)
+
+// QualityRules are the limits of a data quality scan. Prices above MaxPrice are
+// reported as absurd; each check keeps up to Samples subscription IDs.
+type QualityRules struct {
+	MaxPrice int
+	Samples  int
+}
+
+// QualityReport counts the subscriptions failing each check. A subscription may fail
+// several checks.
+type QualityReport struct {
+	Scanned int            `json:"scanned"`
+	Checks  []QualityCheck `json:"checks"`
+}
+
+type QualityCheck struct {
+	Check     string      `json:"check"`
+	Count     int         `json:"count"`
+	SampleIDs []uuid.UUID `json:"sample_ids"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type QuotaUsage struct {
+	Scope    string    `gorm:"primaryKey" json:"scope"`
+	Subject  string    `gorm:"primaryKey" json:"subject"`
+	Period   time.Time `gorm:"primaryKey;type:date" json:"period"`
+	Requests int64     `gorm:"not null" json:"requests"`
+	Creates  int64     `gorm:"not null" json:"creates"`
+}
+
+func (QuotaUsage) TableName() string {
+	return "api_quota_usage"
+}
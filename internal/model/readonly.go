@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Sources of read-only mode.
+const (
+	ReadOnlyAutomatic = "automatic"
+	ReadOnlyAdmin     = "admin"
+	ReadOnlyPassive   = "passive_region"
+)
+
+// ReadOnlyStatus tells whether an instance rejects writes and serves reads from the
+// replica. Source says whether it switched on a failed write, by an admin or because
+// the instance runs in the passive region.
+type ReadOnlyStatus struct {
+	Enabled bool       `json:"enabled"`
+	Source  string     `json:"source,omitempty"`
+	Reason  string     `json:"reason,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+	Replica bool       `json:"replica"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/shopspring/decimal"
+)
+
+// Charge is a charge due on a subscription, laid out like the charges of the exact
+// aggregation.
+type Charge struct {
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	UserID         uuid.UUID       `json:"user_id"`
+	ServiceName    string          `json:"service_name"`
+	ChargedAt      time.Time       `json:"charged_at"`
+	Amount         decimal.Decimal `json:"amount"`
+}
+
+// StatementLine is a charge of an external billing statement; Line is its line number
+// in the CSV file.
+type StatementLine struct {
+	Line           int             `json:"line"`
+	SubscriptionID *uuid.UUID      `json:"subscription_id,omitempty"`
+	UserID         uuid.UUID       `json:"user_id"`
+	ServiceName    string          `json:"service_name"`
+	ChargedAt      time.Time       `json:"charged_at"`
+	Amount         decimal.Decimal `json:"amount"`
+}
+
+// ReconciledCharge pairs a statement line with the charge it was matched to.
+// Difference is the statement amount minus the recorded one.
+type ReconciledCharge struct {
+	Statement  StatementLine   `json:"statement"`
+	Recorded   Charge          `json:"recorded"`
+	Difference decimal.Decimal `json:"difference"`
+}
+
+// ReconciliationTolerance is how far a statement line may be from a charge and still
+// match it: Days apart, and Amount off before the match is reported as a mismatch.
+type ReconciliationTolerance struct {
+	Amount decimal.Decimal `json:"amount"`
+	Days   int             `json:"days"`
+}
+
+// ReconciliationReport compares a statement with the charges recorded for a period.
+// Missing charges are ours with no statement line; unexpected lines have no charge of
+// ours. Skipped counts the statement lines dated outside the period.
+type ReconciliationReport struct {
+	Start          time.Time               `json:"start"`
+	End            time.Time               `json:"end"`
+	Tolerance      ReconciliationTolerance `json:"tolerance"`
+	Matched        []ReconciledCharge      `json:"matched"`
+	Mismatched     []ReconciledCharge      `json:"mismatched"`
+	Missing        []Charge                `json:"missing"`
+	Unexpected     []StatementLine         `json:"unexpected"`
+	Skipped        int                     `json:"skipped"`
+	StatementTotal decimal.Decimal         `json:"statement_total"`
+	RecordedTotal  decimal.Decimal         `json:"recorded_total"`
+}
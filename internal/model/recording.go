@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecordingFilter selects the requests a capture records. Empty fields match every
+// request; Route is the route pattern, e.g. /subscriptions/:id.
+type RecordingFilter struct {
+	Principal string     `json:"principal,omitempty"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	Method    string     `json:"method,omitempty"`
+	Route     string     `json:"route,omitempty"`
+}
+
+// Capture is a running request recording. It stops once Limit requests are recorded
+// or at ExpiresAt, whichever comes first.
+type Capture struct {
+	ID        uuid.UUID       `json:"id"`
+	Filter    RecordingFilter `json:"filter"`
+	Limit     int             `json:"limit"`
+	Recorded  int             `json:"recorded"`
+	StartedAt time.Time       `json:"started_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// RecordedRequest is a request and its response captured for debugging. Credentials
+// and sensitive JSON fields are redacted before it is stored. A truncated body was cut
+// at the size limit or dropped because it was not text or could not be redacted.
+type RecordedRequest struct {
+	ID                uuid.UUID       `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CaptureID         uuid.UUID       `gorm:"type:uuid;not null" json:"capture_id"`
+	Principal         string          `gorm:"not null" json:"principal"`
+	UserID            *uuid.UUID      `gorm:"type:uuid" json:"user_id,omitempty"`
+	Method            string          `gorm:"not null" json:"method"`
+	Route             string          `gorm:"not null" json:"route"`
+	URL               string          `gorm:"column:url;not null" json:"url"`
+	RequestHeaders    json.RawMessage `gorm:"type:jsonb;not null" json:"request_headers"`
+	RequestBody       string          `gorm:"not null" json:"request_body,omitempty"`
+	RequestTruncated  bool            `gorm:"not null" json:"request_truncated"`
+	Status            int             `gorm:"not null" json:"status"`
+	ResponseHeaders   json.RawMessage `gorm:"type:jsonb;not null" json:"response_headers"`
+	ResponseBody      string          `gorm:"not null" json:"response_body,omitempty"`
+	ResponseTruncated bool            `gorm:"not null" json:"response_truncated"`
+	DurationMS        int64           `gorm:"column:duration_ms;not null" json:"duration_ms"`
+	RecordedAt        time.Time       `gorm:"not null" json:"recorded_at"`
+}
+
+type RecordedRequestFilter struct {
+	CaptureID *uuid.UUID
+	Principal string
+	Since     *time.Time
+	Limit     int
+}
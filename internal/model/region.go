@@ -0,0 +1,15 @@
+package models
+
+// Roles of a region in an active-passive deployment.
+const (
+	RegionActive  = "active"
+	RegionPassive = "passive"
+)
+
+// ReplicationStatus tells whether the database is a standby and how many seconds its
+// replay lags behind the primary. LagSeconds is nil on a primary, and also on a
+// standby that has not replayed anything yet.
+type ReplicationStatus struct {
+	InRecovery bool     `json:"in_recovery"`
+	LagSeconds *float64 `json:"replication_lag_seconds,omitempty"`
+}
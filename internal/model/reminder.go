@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reminder notifies the subscription's owner a number of days before each renewal.
+// LastSentFor is the renewal date the reminder last fired for, so it fires once per renewal.
+type Reminder struct {
+	ID             uuid.UUID     `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	SubscriptionID uuid.UUID     `gorm:"type:uuid;not null;index" json:"subscription_id"`
+	Subscription   *Subscription `gorm:"foreignKey:SubscriptionID" json:"-"`
+	DaysBefore     int           `gorm:"not null" json:"days_before"`
+	Message        string        `json:"message,omitempty"`
+	Channel        string        `gorm:"not null" json:"channel"`
+	LastSentFor    *time.Time    `json:"last_sent_for,omitempty"`
+	CreatedAt      time.Time     `gorm:"not null" json:"created_at"`
+}
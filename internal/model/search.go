@@ -0,0 +1,71 @@
+package models
+
+import "github.com/google/uuid"
+
+// Search engines a SearchResult can come from.
+const (
+	SearchEngineIndex    = "index"
+	SearchEnginePostgres = "postgres"
+)
+
+// SearchQuery matches subscriptions whose service name contains Text, tolerating
+// typos, and that fall in every facet filter set. Facets are counted over all matches.
+type SearchQuery struct {
+	Text       string
+	UserID     uuid.UUID
+	Kinds      []string
+	Categories []string
+	Statuses   []string
+	MinPrice   *int
+	MaxPrice   *int
+	Page       Page
+}
+
+// PriceFacet is the facet counting subscriptions by PriceBuckets.
+const PriceFacet = "price"
+
+// PriceBucket is a price range of the price facet, from From up to, but excluding, To.
+// The last bucket has no upper bound and a To of zero.
+type PriceBucket struct {
+	Key  string
+	From int
+	To   int
+}
+
+// PriceBuckets are the ranges of the price facet, in whole rubles.
+var PriceBuckets = []PriceBucket{
+	{Key: "0-499", From: 0, To: 500},
+	{Key: "500-999", From: 500, To: 1000},
+	{Key: "1000-1999", From: 1000, To: 2000},
+	{Key: "2000-4999", From: 2000, To: 5000},
+	{Key: "5000+", From: 5000},
+}
+
+// FacetSummary counts the matches of a query, in all and by kind, category, status,
+// service name and price bucket. Each facet lists its most frequent values first,
+// except the price facet, which follows PriceBuckets.
+type FacetSummary struct {
+	Total  int64
+	Facets map[string][]FacetCount
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// SearchResult holds a page of matches, best first, the number of matches across all
+// pages and the facet counts by field.
+type SearchResult struct {
+	Subscriptions []Subscription          `json:"subscriptions"`
+	Total         int64                   `json:"total"`
+	Facets        map[string][]FacetCount `json:"facets"`
+	Engine        string                  `json:"engine"`
+}
+
+// ServiceSuggestion is a service name completing a typed prefix, with the number of
+// subscriptions using it. Catalog services nobody subscribes to have no uses.
+type ServiceSuggestion struct {
+	ServiceName string `json:"service_name"`
+	Uses        int64  `json:"uses"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/shopspring/decimal"
+)
+
+// Simulation actions. Add describes a new subscription; the others apply to an
+// existing one. Cancel ends it with At as the last billed month, the current month by
+// default, and change_price charges Price from At on, for its whole span by default.
+const (
+	SimulateAdd         = "add"
+	SimulateRemove      = "remove"
+	SimulateCancel      = "cancel"
+	SimulateChangePrice = "change_price"
+)
+
+// SimulationChange is one hypothetical change in a what-if simulation. Dates are in
+// the MM-YYYY form the API accepts.
+type SimulationChange struct {
+	Action           string
+	SubscriptionID   uuid.UUID
+	ServiceName      string
+	Price            int
+	Kind             string
+	BillingPeriod    string
+	BillingAnchorDay int
+	StartDate        string
+	EndDate          string
+	At               string
+}
+
+// Scenario is the net effect of a simulation's changes on the stored subscriptions.
+type Scenario struct {
+	Added   []Subscription
+	Updated []Subscription
+	Removed []uuid.UUID
+}
+
+type SimulatedMonth struct {
+	Start      time.Time       `json:"start"`
+	Baseline   decimal.Decimal `json:"baseline"`
+	Projected  decimal.Decimal `json:"projected"`
+	Difference decimal.Decimal `json:"difference"`
+}
+
+type SimulationResult struct {
+	Months         []SimulatedMonth `json:"months"`
+	BaselineTotal  decimal.Decimal  `json:"baseline_total"`
+	ProjectedTotal decimal.Decimal  `json:"projected_total"`
+	Difference     decimal.Decimal  `json:"difference"`
+}
@@ -0,0 +1,30 @@
+package models
+
+const (
+	SLOStatusOK       = "ok"
+	SLOStatusWarning  = "warning"
+	SLOStatusCritical = "critical"
+)
+
+// SLOStatus reports how fast an objective's error budget is being spent on this
+// instance. A burn rate of 1 spends exactly the budget over the objective's period.
+type SLOStatus struct {
+	Name               string      `json:"name"`
+	Method             string      `json:"method"`
+	Route              string      `json:"route"`
+	AvailabilityTarget float64     `json:"availability_target"`
+	LatencyThreshold   string      `json:"latency_threshold,omitempty"`
+	LatencyTarget      float64     `json:"latency_target,omitempty"`
+	Status             string      `json:"status"`
+	Windows            []SLOWindow `json:"windows"`
+}
+
+// SLOWindow covers the requests of the last Window.
+type SLOWindow struct {
+	Window          string  `json:"window"`
+	Requests        int64   `json:"requests"`
+	Errors          int64   `json:"errors"`
+	Slow            int64   `json:"slow"`
+	ErrorBurnRate   float64 `json:"error_burn_rate"`
+	LatencyBurnRate float64 `json:"latency_burn_rate,omitempty"`
+}
@@ -1,16 +1,65 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kinds of purchase. One-time purchases are charged in their start month only and
+// lifetime purchases are left out of cost reports.
+const (
+	KindRecurring = "recurring"
+	KindOneTime   = "one_time"
+	KindLifetime  = "lifetime"
+)
+
+// Billing periods say how often Price is charged. The first charge falls on the
+// BillingAnchorDay of the start month, or on its last day for shorter months.
+const (
+	BillingWeekly    = "weekly"
+	BillingMonthly   = "monthly"
+	BillingQuarterly = "quarterly"
+	BillingYearly    = "yearly"
+)
+
+// Statuses of a subscription. Scheduled subscriptions start in a future month and are
+// left out of aggregations until the scheduler activates them in that month.
+const (
+	StatusActive    = "active"
+	StatusScheduled = "scheduled"
 )
 
 type Subscription struct {
-	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
-	ServiceName string     `gorm:"not null" json:"service_name"`
-	Price       int        `gorm:"not null;check:price > 0" json:"price"`
-	UserID      uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
-	StartDate   time.Time  `gorm:"not null" json:"start_date"`
-	EndDate     *time.Time `gorm:"index" json:"end_date,omitempty"`
+	ID               uuid.UUID       `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	ServiceName      string          `gorm:"not null" json:"service_name"`
+	Price            int             `gorm:"not null;check:price > 0" json:"price"`
+	UserID           uuid.UUID       `gorm:"type:uuid;not null" json:"user_id"`
+	Kind             string          `gorm:"not null;default:recurring" json:"kind"`
+	BillingPeriod    string          `gorm:"not null;default:monthly" json:"billing_period"`
+	BillingAnchorDay int             `gorm:"not null;default:1" json:"billing_anchor_day"`
+	StartDate        time.Time       `gorm:"not null" json:"start_date"`
+	EndDate          *time.Time      `gorm:"index" json:"end_date,omitempty"`
+	Status           string          `gorm:"not null;default:active" json:"status"`
+	Category         *string         `gorm:"index" json:"category,omitempty"`
+	CustomFields     json.RawMessage `gorm:"type:jsonb" json:"custom_fields,omitempty"`
+	DeletedAt        gorm.DeletedAt  `gorm:"index" json:"-"`
+}
+
+// SubscriptionDraft is a subscription to create as a client submits it: dates are
+// MM-YYYY strings and empty fields take their defaults.
+type SubscriptionDraft struct {
+	ServiceName      string
+	Price            int
+	UserID           uuid.UUID
+	Kind             string
+	BillingPeriod    string
+	BillingAnchorDay int
+	StartDate        string
+	EndDate          string
+	Status           string
+	Category         string
+	CustomFields     map[string]any
 }
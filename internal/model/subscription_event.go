@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription event types. Every event carries the full state of the subscription
+// after the change, so the latest event of a stream is enough to project it.
+const (
+	SubscriptionSnapshot  = "snapshot"
+	SubscriptionCreated   = "created"
+	SubscriptionUpdated   = "updated"
+	SubscriptionDeleted   = "deleted"
+	SubscriptionRestored  = "restored"
+	SubscriptionImported  = "imported"
+	SubscriptionPurged    = "purged"
+	SubscriptionActivated = "activated"
+)
+
+type SubscriptionEvent struct {
+	Position       int64           `gorm:"primaryKey;autoIncrement" json:"position"`
+	SubscriptionID uuid.UUID       `gorm:"type:uuid;not null" json:"subscription_id"`
+	Version        int             `gorm:"not null" json:"version"`
+	Type           string          `gorm:"not null" json:"type"`
+	State          json.RawMessage `gorm:"type:jsonb;not null" json:"state"`
+	Actor          string          `gorm:"not null" json:"actor"`
+	OccurredAt     time.Time       `gorm:"not null" json:"occurred_at"`
+}
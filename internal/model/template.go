@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// TemplateOverride replaces parts of a built-in notification template for a tenant.
+// Empty parts fall back to the built-in version.
+type TemplateOverride struct {
+	Tenant    string    `gorm:"primaryKey" json:"tenant"`
+	Name      string    `gorm:"primaryKey" json:"name"`
+	Subject   string    `json:"subject,omitempty"`
+	Text      string    `json:"text,omitempty"`
+	HTML      string    `json:"html,omitempty"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
+}
+
+func (TemplateOverride) TableName() string {
+	return "notification_templates"
+}
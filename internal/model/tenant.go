@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TenantActive    = "active"
+	TenantSuspended = "suspended"
+)
+
+// Tenant is a customer onboarded through the admin API. Isolated tenants keep their
+// data in a Postgres schema of their own, named Schema; the others use the shared
+// tables.
+type Tenant struct {
+	Name        string     `gorm:"primaryKey" json:"name"`
+	Schema      *string    `gorm:"column:schema_name;uniqueIndex" json:"schema,omitempty"`
+	Status      string     `gorm:"not null;default:active" json:"status"`
+	SuspendedAt *time.Time `json:"suspended_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"not null" json:"created_at"`
+}
+
+func (t Tenant) Suspended() bool {
+	return t.Status == TenantSuspended
+}
+
+// TenantSettings are the monthly quota limits of a tenant; zero means unlimited.
+type TenantSettings struct {
+	Tenant          string    `gorm:"primaryKey" json:"tenant"`
+	MonthlyRequests int64     `gorm:"not null" json:"monthly_requests"`
+	MonthlyCreates  int64     `gorm:"not null" json:"monthly_creates"`
+	UpdatedAt       time.Time `gorm:"not null" json:"updated_at"`
+}
+
+func (TenantSettings) TableName() string {
+	return "tenant_settings"
+}
+
+// TenantAPIKey is an HMAC client issued to a tenant. Its requests are signed with the
+// SHA-256 of the shared secret, which is as sensitive as the secret itself:
+// EncryptedKey holds it sealed with SECRET_ENCRYPTION_KEY, and SigningKey holds it
+// opened once the key is looked up.
+type TenantAPIKey struct {
+	ClientID     string    `gorm:"primaryKey" json:"client_id"`
+	Tenant       string    `gorm:"not null;index" json:"tenant"`
+	EncryptedKey string    `gorm:"column:signing_key;not null" json:"-"`
+	SigningKey   []byte    `gorm:"-" json:"-"`
+	CreatedAt    time.Time `gorm:"not null" json:"created_at"`
+}
+
+func (TenantAPIKey) TableName() string {
+	return "tenant_api_keys"
+}
+
+// TenantWebhook receives the events of its tenant, signed with Secret.
+type TenantWebhook struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Tenant    string    `gorm:"not null;index" json:"tenant"`
+	URL       string    `gorm:"not null" json:"url"`
+	Secret    string    `gorm:"not null" json:"-"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+}
+
+func (TenantWebhook) TableName() string {
+	return "tenant_webhooks"
+}
+
+// TenantProvisioning is the outcome of onboarding a tenant. Secret is the shared
+// secret of APIKey and is only ever returned here; WebhookSecret likewise signs the
+// deliveries to Webhook.
+type TenantProvisioning struct {
+	Tenant        Tenant         `json:"tenant"`
+	Settings      TenantSettings `json:"settings"`
+	APIKey        TenantAPIKey   `json:"api_key"`
+	Secret        string         `json:"secret"`
+	Webhook       *TenantWebhook `json:"webhook,omitempty"`
+	WebhookSecret string         `json:"webhook_secret,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TimelineStarted      = "started"
+	TimelinePriceChanged = "price_changed"
+	TimelineCancelled    = "cancelled"
+	TimelineExpired      = "expired"
+)
+
+type TimelineEvent struct {
+	Type           string    `json:"type"`
+	At             time.Time `json:"at"`
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	ServiceName    string    `json:"service_name"`
+	Price          int       `json:"price"`
+	PreviousPrice  *int      `json:"previous_price,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type APIUsage struct {
+	Day           time.Time `gorm:"primaryKey;type:date" json:"day"`
+	APIKey        string    `gorm:"primaryKey" json:"api_key"`
+	Endpoint      string    `gorm:"primaryKey" json:"endpoint"`
+	Calls         int64     `gorm:"not null" json:"calls"`
+	RequestBytes  int64     `gorm:"not null" json:"request_bytes"`
+	ResponseBytes int64     `gorm:"not null" json:"response_bytes"`
+}
+
+func (APIUsage) TableName() string {
+	return "api_usage"
+}
+
+type UsageFilter struct {
+	From       time.Time
+	To         time.Time
+	APIKey     string
+	ByEndpoint bool
+}
+
+type UsageReportRow struct {
+	Day           time.Time `json:"day"`
+	APIKey        string    `json:"api_key"`
+	Endpoint      string    `json:"endpoint,omitempty"`
+	Calls         int64     `json:"calls"`
+	RequestBytes  int64     `json:"request_bytes"`
+	ResponseBytes int64     `json:"response_bytes"`
+}
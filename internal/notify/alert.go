@@ -0,0 +1,159 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Operational alert categories. Sinks can be routed to a single category or, with
+// AllCategories, to every one of them.
+const (
+	AlertAggregationFailure = "aggregation_failure"
+	AlertWebhookDelivery    = "webhook_delivery_failure"
+	AlertCircuitOpen        = "circuit_breaker_open"
+	AlertTableBloat         = "table_bloat"
+	AlertSpendChange        = "spend_change"
+
+	AllCategories = "*"
+)
+
+type Alert struct {
+	Category string
+	Title    string
+	Text     string
+	At       time.Time
+}
+
+type AlertSink interface {
+	Post(ctx context.Context, alert Alert) error
+}
+
+// Alerter posts operational alerts to the sinks routed to their category. Alerts are
+// sent in the background and each category is throttled to one alert per cooldown, so
+// an outage does not flood the channels or slow down the requests that hit it.
+type Alerter struct {
+	routes   map[string][]AlertSink
+	cooldown time.Duration
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+}
+
+func NewAlerter(cooldown time.Duration, logger *slog.Logger) *Alerter {
+	return &Alerter{
+		routes:   make(map[string][]AlertSink),
+		cooldown: cooldown,
+		logger:   logger,
+		lastSent: make(map[string]time.Time),
+	}
+}
+
+func (a *Alerter) Route(category string, sink AlertSink) error {
+	switch category {
+	case AlertAggregationFailure, AlertWebhookDelivery, AlertCircuitOpen, AlertTableBloat, AlertSpendChange, AllCategories:
+	default:
+		return fmt.Errorf("unknown alert category %q", category)
+	}
+	a.routes[category] = append(a.routes[category], sink)
+	return nil
+}
+
+func (a *Alerter) Alert(ctx context.Context, category string, title string, text string) {
+	sinks := slices.Concat(a.routes[category], a.routes[AllCategories])
+	if len(sinks) == 0 {
+		return
+	}
+
+	now := time.Now()
+	a.mu.Lock()
+	if last, ok := a.lastSent[category]; ok && now.Sub(last) < a.cooldown {
+		a.mu.Unlock()
+		a.logger.DebugContext(ctx, "Suppressed alert during cooldown",
+			slog.String("category", category),
+			slog.String("title", title))
+		return
+	}
+	a.lastSent[category] = now
+	a.mu.Unlock()
+
+	alert := Alert{Category: category, Title: title, Text: text, At: now.UTC()}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	go func() {
+		defer cancel()
+		for _, sink := range sinks {
+			if err := sink.Post(ctx, alert); err != nil {
+				a.logger.ErrorContext(ctx, "Failed to post alert",
+					slog.String("category", category),
+					slog.String("error", err.Error()))
+			}
+		}
+	}()
+}
+
+// SlackWebhook posts alerts to a Slack incoming webhook.
+type SlackWebhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewSlackWebhook(url string) *SlackWebhook {
+	return &SlackWebhook{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *SlackWebhook) Post(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, w.client, w.url, map[string]string{
+		"text": fmt.Sprintf("*%s* (`%s`)\n%s", alert.Title, alert.Category, alert.Text),
+	})
+}
+
+// TeamsWebhook posts alerts to a Microsoft Teams incoming webhook as a message card.
+type TeamsWebhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewTeamsWebhook(url string) *TeamsWebhook {
+	return &TeamsWebhook{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *TeamsWebhook) Post(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, w.client, w.url, map[string]string{
+		"@type":    "MessageCard",
+		"@context": "https://schema.org/extensions",
+		"summary":  alert.Title,
+		"title":    alert.Title,
+		"text":     fmt.Sprintf("**%s** at %s\n\n%s", alert.Category, alert.At.Format(time.RFC3339), alert.Text),
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/templates"
+)
+
+type mailer interface {
+	Queue(ctx context.Context, template string, to []string, content templates.Rendered) error
+}
+
+// EmailChannel queues notifications on the mailer, which owns delivery and retries.
+type EmailChannel struct {
+	mailer    mailer
+	addresses map[uuid.UUID]string
+}
+
+// NewEmailChannel parses addresses, a map of user IDs to email addresses.
+func NewEmailChannel(mailer mailer, addresses map[string]string) (*EmailChannel, error) {
+	parsed := make(map[uuid.UUID]string, len(addresses))
+	for user, address := range addresses {
+		userID, err := uuid.Parse(user)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email mapping for %q: %w", user, err)
+		}
+		parsed[userID] = address
+	}
+
+	return &EmailChannel{
+		mailer:    mailer,
+		addresses: parsed,
+	}, nil
+}
+
+func (c *EmailChannel) Send(ctx context.Context, msg Message) error {
+	address, ok := c.addresses[msg.UserID]
+	if !ok {
+		return ErrNoRecipient
+	}
+	content := templates.Rendered{Subject: msg.Subject, Text: msg.Text, HTML: msg.HTML}
+	return c.mailer.Queue(ctx, msg.Template, []string{address}, content)
+}
@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownChannel = errors.New("unknown notification channel")
+	ErrNoRecipient    = errors.New("user has no recipient configured for this channel")
+)
+
+// Message is a notification for a single user. Channels resolve where the user is
+// reached themselves, so callers never deal with chat IDs or addresses. Template names
+// the template the content was rendered from; HTML is only used by channels that
+// support rich content.
+type Message struct {
+	UserID         uuid.UUID
+	SubscriptionID *uuid.UUID
+	Template       string
+	Subject        string
+	Text           string
+	HTML           string
+}
+
+type Channel interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type workerPool interface {
+	Do(ctx context.Context, destination string, task func() error) error
+}
+
+// Notifier dispatches messages to named channels. Sends run on the channel's queue in
+// the worker pool, so a slow channel cannot take up the capacity of the others.
+type Notifier struct {
+	channels map[string]Channel
+	pool     workerPool
+	logger   *slog.Logger
+}
+
+func NewNotifier(pool workerPool, logger *slog.Logger) *Notifier {
+	return &Notifier{
+		channels: make(map[string]Channel),
+		pool:     pool,
+		logger:   logger,
+	}
+}
+
+func (n *Notifier) Register(name string, channel Channel) {
+	n.channels[name] = channel
+}
+
+func (n *Notifier) Has(name string) bool {
+	_, ok := n.channels[name]
+	return ok
+}
+
+func (n *Notifier) Channels() []string {
+	names := make([]string, 0, len(n.channels))
+	for name := range n.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (n *Notifier) Notify(ctx context.Context, channel string, msg Message) error {
+	ch, ok := n.channels[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+
+	err := n.pool.Do(ctx, "notify:"+channel, func() error {
+		return ch.Send(ctx, msg)
+	})
+	if err != nil {
+		n.logger.ErrorContext(ctx, "Failed to send notification",
+			slog.String("channel", channel),
+			slog.String("user_id", msg.UserID.String()),
+			slog.String("error", err.Error()))
+		return fmt.Errorf("send %s notification: %w", channel, err)
+	}
+
+	n.logger.InfoContext(ctx, "Sent notification",
+		slog.String("channel", channel),
+		slog.String("user_id", msg.UserID.String()))
+
+	return nil
+}
+
+// LogChannel writes notifications to the application log. It is always available and
+// is handy in development or as a fallback when no real channel is configured.
+type LogChannel struct {
+	logger *slog.Logger
+}
+
+func NewLogChannel(logger *slog.Logger) *LogChannel {
+	return &LogChannel{logger: logger}
+}
+
+func (c *LogChannel) Send(ctx context.Context, msg Message) error {
+	attrs := []any{
+		slog.String("user_id", msg.UserID.String()),
+		slog.String("subject", msg.Subject),
+		slog.String("text", msg.Text),
+	}
+	if msg.SubscriptionID != nil {
+		attrs = append(attrs, slog.String("subscription_id", msg.SubscriptionID.String()))
+	}
+	c.logger.InfoContext(ctx, "Notification", attrs...)
+	return nil
+}
@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const telegramAPI = "https://api.telegram.org"
+
+// TelegramChannel delivers notifications as messages from a Telegram bot. Users are
+// mapped to the chat they opened with the bot.
+type TelegramChannel struct {
+	token  string
+	chats  map[uuid.UUID]string
+	client *http.Client
+}
+
+// NewTelegramChannel parses chats, a map of user IDs to chat IDs.
+func NewTelegramChannel(token string, chats map[string]string) (*TelegramChannel, error) {
+	parsed := make(map[uuid.UUID]string, len(chats))
+	for user, chat := range chats {
+		userID, err := uuid.Parse(user)
+		if err != nil {
+			return nil, fmt.Errorf("invalid telegram chat mapping for %q: %w", user, err)
+		}
+		parsed[userID] = chat
+	}
+
+	return &TelegramChannel{
+		token:  token,
+		chats:  parsed,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (c *TelegramChannel) Send(ctx context.Context, msg Message) error {
+	chatID, ok := c.chats[msg.UserID]
+	if !ok {
+		return ErrNoRecipient
+	}
+
+	text := msg.Text
+	if msg.Subject != "" {
+		text = msg.Subject + "\n\n" + msg.Text
+	}
+
+	body, err := json.Marshal(map[string]string{"chat_id": chatID, "text": text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/bot%s/sendMessage", telegramAPI, c.token), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// The URL carries the bot token, so only the underlying cause is reported.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("telegram request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var result struct {
+			Description string `json:"description"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&result)
+		return fmt.Errorf("telegram responded with %d: %s", resp.StatusCode, result.Description)
+	}
+
+	return nil
+}
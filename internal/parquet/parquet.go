@@ -0,0 +1,341 @@
+// Package parquet writes flat tables as Apache Parquet files for data warehouses. It
+// covers what the exports need and no more: string, integer, date and timestamp columns, PLAIN
+// encoding and GZIP-compressed pages, one page per column chunk.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+const magic = "PAR1"
+
+// RowGroupRows is the number of rows buffered before a row group is written.
+const RowGroupRows = 50000
+
+// Kinds of column values.
+type Kind int
+
+const (
+	// String columns hold UTF-8 strings.
+	String Kind = iota
+	// Int64 columns hold int or int64 values.
+	Int64
+	// Date columns hold the UTC date of time.Time values.
+	Date
+	// Timestamp columns hold time.Time values to the microsecond.
+	Timestamp
+)
+
+// Values of the Parquet format specification.
+const (
+	typeInt32     = 1
+	typeInt64     = 2
+	typeByteArray = 6
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	convertedUTF8            = 0
+	convertedDate            = 6
+	convertedTimestampMicros = 10
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecGzip = 2
+
+	pageData = 0
+)
+
+// Column describes a column of the table. Optional columns accept nil values.
+type Column struct {
+	Name     string
+	Kind     Kind
+	Optional bool
+}
+
+type columnBuffer struct {
+	values  bytes.Buffer
+	defined []bool
+}
+
+type chunkMeta struct {
+	offset       int64
+	numValues    int64
+	uncompressed int64
+	compressed   int64
+}
+
+type rowGroup struct {
+	chunks  []chunkMeta
+	numRows int64
+	size    int64
+}
+
+// Writer writes rows to w. The file is only complete once Close returns.
+type Writer struct {
+	w         io.Writer
+	offset    int64
+	columns   []Column
+	buffers   []columnBuffer
+	rows      int
+	rowGroups []rowGroup
+	createdBy string
+}
+
+// NewWriter starts a Parquet file with columns on w. createdBy names the application
+// in the file metadata.
+func NewWriter(w io.Writer, columns []Column, createdBy string) (*Writer, error) {
+	pw := &Writer{
+		w:         w,
+		columns:   columns,
+		buffers:   make([]columnBuffer, len(columns)),
+		createdBy: createdBy,
+	}
+	if err := pw.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// Write appends a row holding a value per column, in the order of the columns.
+func (w *Writer) Write(values ...any) error {
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("parquet: row has %d values for %d columns", len(values), len(w.columns))
+	}
+	for i, value := range values {
+		if err := w.buffers[i].append(w.columns[i], value); err != nil {
+			return err
+		}
+	}
+	w.rows++
+	if w.rows == RowGroupRows {
+		return w.flushRowGroup()
+	}
+	return nil
+}
+
+// Close writes the buffered rows and the file footer. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if w.rows > 0 {
+		if err := w.flushRowGroup(); err != nil {
+			return err
+		}
+	}
+
+	footer := w.footer()
+	if err := w.write(footer); err != nil {
+		return err
+	}
+	if err := w.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))); err != nil {
+		return err
+	}
+	return w.write([]byte(magic))
+}
+
+func (b *columnBuffer) append(column Column, value any) error {
+	if value == nil {
+		if !column.Optional {
+			return fmt.Errorf("parquet: column %s is required", column.Name)
+		}
+		b.defined = append(b.defined, false)
+		return nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		if column.Kind != String {
+			return fmt.Errorf("parquet: string value for column %s", column.Name)
+		}
+		b.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+		b.values.WriteString(v)
+	case int:
+		if column.Kind != Int64 {
+			return fmt.Errorf("parquet: integer value for column %s", column.Name)
+		}
+		b.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+	case int64:
+		if column.Kind != Int64 {
+			return fmt.Errorf("parquet: integer value for column %s", column.Name)
+		}
+		b.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+	case time.Time:
+		if column.Kind == Timestamp {
+			b.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v.UnixMicro())))
+			break
+		}
+		if column.Kind != Date {
+			return fmt.Errorf("parquet: time value for column %s", column.Name)
+		}
+		v = v.UTC()
+		days := time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+		b.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(int32(days))))
+	default:
+		return fmt.Errorf("parquet: unsupported value %T for column %s", value, column.Name)
+	}
+	b.defined = append(b.defined, true)
+	return nil
+}
+
+func (w *Writer) flushRowGroup() error {
+	group := rowGroup{numRows: int64(w.rows)}
+	for i, column := range w.columns {
+		chunk, err := w.writeChunk(column, &w.buffers[i])
+		if err != nil {
+			return fmt.Errorf("parquet: write column %s: %w", column.Name, err)
+		}
+		group.chunks = append(group.chunks, chunk)
+		group.size += chunk.uncompressed
+		w.buffers[i] = columnBuffer{}
+	}
+	w.rowGroups = append(w.rowGroups, group)
+	w.rows = 0
+	return nil
+}
+
+// writeChunk writes the buffered values of a column as a single data page.
+func (w *Writer) writeChunk(column Column, buf *columnBuffer) (chunkMeta, error) {
+	var page bytes.Buffer
+	if column.Optional {
+		levels := definitionLevels(buf.defined)
+		page.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(levels))))
+		page.Write(levels)
+	}
+	page.Write(buf.values.Bytes())
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(page.Bytes()); err != nil {
+		return chunkMeta{}, err
+	}
+	if err := gz.Close(); err != nil {
+		return chunkMeta{}, err
+	}
+
+	t := newThriftWriter()
+	t.i32(1, pageData)
+	t.i32(2, int32(page.Len()))
+	t.i32(3, int32(compressed.Len()))
+	t.beginStruct(5)
+	t.i32(1, int32(len(buf.defined)))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.endStruct()
+	header := t.bytes()
+
+	chunk := chunkMeta{
+		offset:       w.offset,
+		numValues:    int64(len(buf.defined)),
+		uncompressed: int64(len(header) + page.Len()),
+		compressed:   int64(len(header) + compressed.Len()),
+	}
+	if err := w.write(header); err != nil {
+		return chunkMeta{}, err
+	}
+	if err := w.write(compressed.Bytes()); err != nil {
+		return chunkMeta{}, err
+	}
+	return chunk, nil
+}
+
+// definitionLevels encodes whether each value is set as a single bit-packed run of the
+// RLE/bit-packing hybrid encoding with a bit width of 1.
+func definitionLevels(defined []bool) []byte {
+	groups := (len(defined) + 7) / 8
+	out := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	packed := make([]byte, groups)
+	for i, set := range defined {
+		if set {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return append(out, packed...)
+}
+
+func (w *Writer) footer() []byte {
+	var numRows int64
+	for _, group := range w.rowGroups {
+		numRows += group.numRows
+	}
+
+	t := newThriftWriter()
+	t.i32(1, 1)
+
+	t.list(2, thriftStruct, len(w.columns)+1)
+	t.beginStruct(0)
+	t.str(4, "schema")
+	t.i32(5, int32(len(w.columns)))
+	t.endStruct()
+	for _, column := range w.columns {
+		physical, converted := column.types()
+		repetition := int32(repetitionRequired)
+		if column.Optional {
+			repetition = repetitionOptional
+		}
+		t.beginStruct(0)
+		t.i32(1, physical)
+		t.i32(3, repetition)
+		t.str(4, column.Name)
+		if converted >= 0 {
+			t.i32(6, converted)
+		}
+		t.endStruct()
+	}
+
+	t.i64(3, numRows)
+
+	t.list(4, thriftStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		t.beginStruct(0)
+		t.list(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			physical, _ := w.columns[i].types()
+			t.beginStruct(0)
+			t.i64(2, chunk.offset)
+			t.beginStruct(3)
+			t.i32(1, physical)
+			t.listI32(2, encodingPlain, encodingRLE)
+			t.listStr(3, w.columns[i].Name)
+			t.i32(4, codecGzip)
+			t.i64(5, chunk.numValues)
+			t.i64(6, chunk.uncompressed)
+			t.i64(7, chunk.compressed)
+			t.i64(9, chunk.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.numRows)
+		t.endStruct()
+	}
+
+	t.str(6, w.createdBy)
+	return t.bytes()
+}
+
+// types returns the physical and converted types of the column, -1 for none.
+func (c Column) types() (int32, int32) {
+	switch c.Kind {
+	case String:
+		return typeByteArray, convertedUTF8
+	case Date:
+		return typeInt32, convertedDate
+	case Timestamp:
+		return typeInt64, convertedTimestampMicros
+	default:
+		return typeInt64, -1
+	}
+}
+
+func (w *Writer) write(p []byte) error {
+	n, err := w.w.Write(p)
+	w.offset += int64(n)
+	return err
+}
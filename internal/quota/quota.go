@@ -0,0 +1,242 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+const (
+	ScopeKey    = "key"
+	ScopeTenant = "tenant"
+)
+
+// Limits are monthly allowances; zero means unlimited.
+type Limits struct {
+	Requests int64
+	Creates  int64
+}
+
+type Status struct {
+	Scope        string    `json:"scope"`
+	Subject      string    `json:"subject"`
+	Period       time.Time `json:"period"`
+	Requests     int64     `json:"requests"`
+	RequestLimit int64     `json:"request_limit"`
+	Creates      int64     `json:"creates"`
+	CreateLimit  int64     `json:"create_limit"`
+}
+
+func (s Status) RequestsExceeded() bool {
+	return s.RequestLimit > 0 && s.Requests > s.RequestLimit
+}
+
+func (s Status) CreatesExhausted() bool {
+	return s.CreateLimit > 0 && s.Creates >= s.CreateLimit
+}
+
+func (s Status) RequestsRemaining() int64 {
+	if s.RequestLimit == 0 {
+		return -1
+	}
+	return max(s.RequestLimit-s.Requests, 0)
+}
+
+type repository interface {
+	Increment(ctx context.Context, scope string, subject string, period time.Time, requests int64, creates int64) (*models.QuotaUsage, error)
+	ReserveCreates(ctx context.Context, scope string, subject string, period time.Time, count int64, limit int64) (*models.QuotaUsage, bool, error)
+	Get(ctx context.Context, scope string, subject string, period time.Time) (*models.QuotaUsage, error)
+}
+
+// TenantLimits looks up the limits a tenant was onboarded with; ok is false for
+// tenants that were not.
+type TenantLimits interface {
+	TenantLimits(ctx context.Context, tenant string) (limits Limits, ok bool, err error)
+}
+
+type Service struct {
+	repo    repository
+	limits  map[string]Limits
+	tenants TenantLimits
+	logger  *slog.Logger
+}
+
+// NewService enforces keyLimits on every key and tenantLimits on every tenant without
+// limits of its own in tenants.
+func NewService(repo repository, keyLimits Limits, tenantLimits Limits, tenants TenantLimits, logger *slog.Logger) *Service {
+	return &Service{
+		repo: repo,
+		limits: map[string]Limits{
+			ScopeKey:    keyLimits,
+			ScopeTenant: tenantLimits,
+		},
+		tenants: tenants,
+		logger:  logger,
+	}
+}
+
+func (s *Service) ConsumeRequest(ctx context.Context, id identity.Identity) ([]Status, error) {
+	return s.increment(ctx, id, 1, 0)
+}
+
+// Reservation holds creates taken from the allowances of a caller before the
+// subscriptions are made.
+type Reservation struct {
+	keys   []quotaKey
+	period time.Time
+	count  int64
+}
+
+// ReserveCreates takes count creates from the monthly allowances of the caller's key and
+// tenant. Each allowance is checked and taken in one statement, so concurrent requests
+// cannot overdraw it. When an allowance has fewer creates left, nothing is taken and the
+// reservation is nil; the last status is then the one of that allowance.
+func (s *Service) ReserveCreates(ctx context.Context, id identity.Identity, count int64) (*Reservation, []Status, error) {
+	reservation := &Reservation{period: currentPeriod(), count: count}
+	statuses := make([]Status, 0, 2)
+	for _, key := range keys(id) {
+		limits, err := s.limitsOf(ctx, key.scope, key.subject)
+		if err != nil {
+			s.release(ctx, reservation)
+			return nil, nil, err
+		}
+		usage, ok, err := s.repo.ReserveCreates(ctx, key.scope, key.subject, reservation.period, count, limits.Creates)
+		if err == nil && !ok {
+			usage, err = s.repo.Get(ctx, key.scope, key.subject, reservation.period)
+		}
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to reserve creates",
+				slog.String("scope", key.scope),
+				slog.String("subject", key.subject),
+				slog.String("error", err.Error()))
+			s.release(ctx, reservation)
+			return nil, nil, err
+		}
+		statuses = append(statuses, newStatus(usage, limits))
+		if !ok {
+			s.release(ctx, reservation)
+			return nil, statuses, nil
+		}
+		reservation.keys = append(reservation.keys, key)
+	}
+	return reservation, statuses, nil
+}
+
+// Release gives the creates of r back to the allowances they were taken from.
+func (s *Service) Release(ctx context.Context, r *Reservation) error {
+	return s.release(ctx, r)
+}
+
+func (s *Service) release(ctx context.Context, r *Reservation) error {
+	var errs []error
+	for _, key := range r.keys {
+		if _, err := s.repo.Increment(ctx, key.scope, key.subject, r.period, 0, -r.count); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to release reserved creates",
+				slog.String("scope", key.scope),
+				slog.String("subject", key.subject),
+				slog.Int64("count", r.count),
+				slog.String("error", err.Error()))
+			errs = append(errs, err)
+		}
+	}
+	r.keys = nil
+	return errors.Join(errs...)
+}
+
+func (s *Service) Current(ctx context.Context, id identity.Identity) ([]Status, error) {
+	period := currentPeriod()
+	statuses := make([]Status, 0, 2)
+	for _, key := range keys(id) {
+		usage, err := s.repo.Get(ctx, key.scope, key.subject, period)
+		if err != nil {
+			return nil, err
+		}
+		status, err := s.status(ctx, usage)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (s *Service) increment(ctx context.Context, id identity.Identity, requests int64, creates int64) ([]Status, error) {
+	period := currentPeriod()
+	statuses := make([]Status, 0, 2)
+	for _, key := range keys(id) {
+		usage, err := s.repo.Increment(ctx, key.scope, key.subject, period, requests, creates)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to record quota usage",
+				slog.String("scope", key.scope),
+				slog.String("subject", key.subject),
+				slog.String("error", err.Error()))
+			return nil, err
+		}
+		status, err := s.status(ctx, usage)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (s *Service) status(ctx context.Context, usage *models.QuotaUsage) (Status, error) {
+	limits, err := s.limitsOf(ctx, usage.Scope, usage.Subject)
+	if err != nil {
+		return Status{}, err
+	}
+	return newStatus(usage, limits), nil
+}
+
+// limitsOf returns the limits of subject in scope: the onboarded limits of a tenant, or
+// the configured ones of its scope.
+func (s *Service) limitsOf(ctx context.Context, scope string, subject string) (Limits, error) {
+	limits := s.limits[scope]
+	if scope == ScopeTenant {
+		own, ok, err := s.tenants.TenantLimits(ctx, subject)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to look up tenant quota limits",
+				slog.String("tenant", subject),
+				slog.String("error", err.Error()))
+			return Limits{}, err
+		}
+		if ok {
+			limits = own
+		}
+	}
+	return limits, nil
+}
+
+func newStatus(usage *models.QuotaUsage, limits Limits) Status {
+	return Status{
+		Scope:        usage.Scope,
+		Subject:      usage.Subject,
+		Period:       usage.Period,
+		Requests:     usage.Requests,
+		RequestLimit: limits.Requests,
+		Creates:      usage.Creates,
+		CreateLimit:  limits.Creates,
+	}
+}
+
+type quotaKey struct {
+	scope   string
+	subject string
+}
+
+func keys(id identity.Identity) []quotaKey {
+	return []quotaKey{
+		{scope: ScopeKey, subject: id.Principal()},
+		{scope: ScopeTenant, subject: id.Tenant},
+	}
+}
+
+func currentPeriod() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
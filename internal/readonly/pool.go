@@ -0,0 +1,88 @@
+package readonly
+
+import (
+	"context"
+	"database/sql"
+
+	"awesomeProject1/internal/identity"
+)
+
+// Pool is the connection pool of the primary, possibly routing sandbox requests.
+type Pool interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// ConnPool is a gorm connection pool sending queries to the replica while mode is
+// active and everything else to primary, whose failed writes switch mode on. The
+// sandbox and the tenant schemas have no replica pools, so their requests always go to
+// primary.
+type ConnPool struct {
+	primary Pool
+	main    *sql.DB
+	replica *sql.DB
+	mode    *Mode
+}
+
+// NewConnPool routes between primary and replica; main is the production pool behind
+// primary, for health checks and locks.
+func NewConnPool(primary Pool, main *sql.DB, replica *sql.DB, mode *Mode) *ConnPool {
+	return &ConnPool{
+		primary: primary,
+		main:    main,
+		replica: replica,
+		mode:    mode,
+	}
+}
+
+func (p *ConnPool) reads(ctx context.Context) bool {
+	id := identity.FromContext(ctx)
+	return p.mode.Active() && !id.Sandbox() && id.Schema == ""
+}
+
+func (p *ConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if p.reads(ctx) {
+		return p.replica.PrepareContext(ctx, query)
+	}
+	return p.primary.PrepareContext(ctx, query)
+}
+
+func (p *ConnPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	result, err := p.primary.ExecContext(ctx, query, args...)
+	if err != nil {
+		p.mode.trip(err)
+	}
+	return result, err
+}
+
+func (p *ConnPool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if p.reads(ctx) {
+		return p.replica.QueryContext(ctx, query, args...)
+	}
+	return p.primary.QueryContext(ctx, query, args...)
+}
+
+func (p *ConnPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if p.reads(ctx) {
+		return p.replica.QueryRowContext(ctx, query, args...)
+	}
+	return p.primary.QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx starts transactions on the primary, since they usually write. Reads done in
+// a transaction fail along with it while the primary is down.
+func (p *ConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	tx, err := p.primary.BeginTx(ctx, opts)
+	if err != nil {
+		p.mode.trip(err)
+	}
+	return tx, err
+}
+
+// GetDBConn returns the production primary pool, for health checks and locks.
+func (p *ConnPool) GetDBConn() (*sql.DB, error) {
+	return p.main, nil
+}
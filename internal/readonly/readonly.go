@@ -0,0 +1,179 @@
+// Package readonly keeps the API partly available while the primary database is
+// down. In read-only mode writes are rejected and reads go to a streaming replica.
+// The mode switches on by itself when a write fails to reach the primary, and off
+// again once the primary accepts writes; admins can also switch it by hand.
+package readonly
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/model"
+)
+
+// Mode is the read-only state of this instance. Every replica of the API keeps its
+// own, so an admin switch applies to the instance that served it only.
+type Mode struct {
+	primary   *sql.DB
+	automatic bool
+	clock     clock.Clock
+	logger    *slog.Logger
+
+	mu     sync.Mutex
+	source string
+	reason string
+	since  *time.Time
+	fenced *time.Time
+}
+
+// NewMode watches primary, whose failed writes switch the mode on when automatic is
+// set. Without a replica there is nothing to serve reads from, so automatic switching
+// is off.
+func NewMode(primary *sql.DB, automatic bool, clock clock.Clock, logger *slog.Logger) *Mode {
+	return &Mode{
+		primary:   primary,
+		automatic: automatic,
+		clock:     clock,
+		logger:    logger,
+	}
+}
+
+// Active reports whether writes are rejected.
+func (m *Mode) Active() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.since != nil || m.fenced != nil
+}
+
+func (m *Mode) Status() models.ReadOnlyStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := models.ReadOnlyStatus{Enabled: m.since != nil || m.fenced != nil, Replica: m.automatic}
+	if m.fenced != nil {
+		since := *m.fenced
+		status.Source = models.ReadOnlyPassive
+		status.Since = &since
+	} else if m.since != nil {
+		since := *m.since
+		status.Source = m.source
+		status.Reason = m.reason
+		status.Since = &since
+	}
+	return status
+}
+
+// Enable switches read-only mode on by hand. It stays on until Disable, even once the
+// primary is back.
+func (m *Mode) Enable(reason string) {
+	m.switchOn(models.ReadOnlyAdmin, reason)
+}
+
+// Fence rejects writes for good, on instances of the passive region whose database is
+// a standby. Neither Disable nor a recovered primary lifts it; promoting the region
+// takes a restart with the active role.
+func (m *Mode) Fence() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clock.Now().UTC()
+	m.fenced = &now
+	m.logger.Warn("Fencing writes in the passive region")
+}
+
+// Disable switches read-only mode off, whatever switched it on, except the fence of
+// the passive region.
+func (m *Mode) Disable() {
+	m.switchOff("")
+}
+
+// switchOff switches read-only mode off if source, when set, switched it on.
+func (m *Mode) switchOff(source string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.since == nil || (source != "" && m.source != source) {
+		return
+	}
+	m.logger.Warn("Leaving read-only mode",
+		slog.String("source", m.source),
+		slog.Duration("duration", m.clock.Now().Sub(*m.since)))
+	m.since = nil
+	m.source = ""
+	m.reason = ""
+}
+
+// trip switches read-only mode on after a write failed with err, unless it is already
+// on or err is not a sign that the primary is unreachable.
+func (m *Mode) trip(err error) {
+	if !m.automatic || !unavailable(err) {
+		return
+	}
+	m.switchOn(models.ReadOnlyAutomatic, err.Error())
+}
+
+func (m *Mode) switchOn(source string, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.since != nil && (m.source == models.ReadOnlyAdmin || source == models.ReadOnlyAutomatic) {
+		return
+	}
+	now := m.clock.Now().UTC()
+	m.since = &now
+	m.source = source
+	m.reason = reason
+	m.logger.Warn("Entering read-only mode",
+		slog.String("source", source),
+		slog.String("reason", reason))
+}
+
+// Probe switches automatic read-only mode off once the primary accepts writes again.
+// It has the signature of a scheduled job.
+func (m *Mode) Probe(ctx context.Context) error {
+	m.mu.Lock()
+	automatic := m.since != nil && m.source == models.ReadOnlyAutomatic
+	m.mu.Unlock()
+	if !automatic {
+		return nil
+	}
+
+	// A primary that came back as a standby answers pings but still refuses writes.
+	var inRecovery bool
+	if err := m.primary.QueryRowContext(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		m.logger.DebugContext(ctx, "Primary database still unavailable", slog.String("error", err.Error()))
+		return nil
+	}
+	if !inRecovery {
+		m.switchOff(models.ReadOnlyAutomatic)
+	}
+	return nil
+}
+
+// unavailable reports whether err means the primary cannot take writes: the
+// connection failed or broke, the server is shutting down or it is a read-only standby.
+// Cancelled requests say nothing about the primary.
+func unavailable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "57P01", "57P02", "57P03", "25006":
+			return true
+		}
+		return strings.HasPrefix(pgErr.Code, "08")
+	}
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connectErr) || errors.As(err, &netErr) ||
+		errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone)
+}
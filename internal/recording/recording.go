@@ -0,0 +1,336 @@
+// Package recording captures the requests of one caller, user or route together with
+// their responses, so that a problem reported in production can be reproduced on a
+// staging instance. A capture is started by an admin, records at most a bounded number
+// of requests for a bounded time, and stores them sanitized: credentials are redacted
+// and bodies that cannot be redacted are dropped. The replay command re-sends them.
+//
+// Captures are held in memory by the replica that started them; the recorded requests
+// are stored in the database and shared by every replica.
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+const (
+	DefaultLimit    = 100
+	MaxLimit        = 1000
+	DefaultDuration = 10 * time.Minute
+	MaxDuration     = time.Hour
+)
+
+var ErrRecordingNotFound = errors.New("recorded request not found")
+
+type store interface {
+	Create(ctx context.Context, recorded *models.RecordedRequest) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.RecordedRequest, error)
+	List(ctx context.Context, filter models.RecordedRequestFilter) ([]models.RecordedRequest, error)
+	Purge(ctx context.Context, filter models.RecordedRequestFilter) (int64, error)
+}
+
+// Exchange is a request and its response as seen by Middleware, before sanitizing.
+type Exchange struct {
+	Principal         string
+	UserID            *uuid.UUID
+	Method            string
+	Route             string
+	URL               string
+	RequestHeader     http.Header
+	RequestBody       []byte
+	RequestTruncated  bool
+	Status            int
+	ResponseHeader    http.Header
+	ResponseBody      []byte
+	ResponseTruncated bool
+	Duration          time.Duration
+}
+
+type Recorder struct {
+	store  store
+	clock  clock.Clock
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	capture *models.Capture
+}
+
+func NewRecorder(store store, clock clock.Clock, logger *slog.Logger) *Recorder {
+	return &Recorder{
+		store:  store,
+		clock:  clock,
+		logger: logger,
+	}
+}
+
+// Start begins a capture of up to limit requests matching filter for duration,
+// replacing the running one. Zero values pick the defaults and larger ones are capped.
+func (r *Recorder) Start(filter models.RecordingFilter, limit int, duration time.Duration) models.Capture {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if duration <= 0 {
+		duration = DefaultDuration
+	}
+	filter.Method = strings.ToUpper(filter.Method)
+
+	now := r.clock.Now().UTC()
+	capture := models.Capture{
+		ID:        uuid.New(),
+		Filter:    filter,
+		Limit:     min(limit, MaxLimit),
+		StartedAt: now,
+		ExpiresAt: now.Add(min(duration, MaxDuration)),
+	}
+
+	r.mu.Lock()
+	r.capture = &capture
+	r.mu.Unlock()
+
+	r.logger.Info("Started request capture",
+		slog.String("capture_id", capture.ID.String()),
+		slog.Any("filter", filter),
+		slog.Int("limit", capture.Limit),
+		slog.Time("expires_at", capture.ExpiresAt))
+	return capture
+}
+
+// Stop ends the running capture and returns it, or false when none is running.
+func (r *Recorder) Stop() (models.Capture, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	capture := r.running()
+	if capture == nil {
+		return models.Capture{}, false
+	}
+	r.capture = nil
+
+	r.logger.Info("Stopped request capture",
+		slog.String("capture_id", capture.ID.String()),
+		slog.Int("recorded", capture.Recorded))
+	return *capture, true
+}
+
+// Current returns the running capture, or false when none is running.
+func (r *Recorder) Current() (models.Capture, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	capture := r.running()
+	if capture == nil {
+		return models.Capture{}, false
+	}
+	return *capture, true
+}
+
+// running returns the capture unless it has expired. r.mu must be held.
+func (r *Recorder) running() *models.Capture {
+	if r.capture != nil && !r.clock.Now().Before(r.capture.ExpiresAt) {
+		r.logger.Info("Request capture expired",
+			slog.String("capture_id", r.capture.ID.String()),
+			slog.Int("recorded", r.capture.Recorded))
+		r.capture = nil
+	}
+	return r.capture
+}
+
+// Record stores e when the running capture selects it. Failures are logged only, a
+// capture must never fail the request it records.
+func (r *Recorder) Record(ctx context.Context, e Exchange) {
+	capture, ok := r.claim(e)
+	if !ok {
+		return
+	}
+
+	recorded := sanitize(e)
+	recorded.CaptureID = capture.ID
+	recorded.RecordedAt = r.clock.Now().UTC()
+	if err := r.store.Create(context.WithoutCancel(ctx), &recorded); err != nil {
+		r.logger.ErrorContext(ctx, "Failed to store recorded request",
+			slog.String("capture_id", capture.ID.String()),
+			slog.String("error", err.Error()))
+	}
+}
+
+// claim counts e against the running capture if it matches, ending the capture once
+// its limit is reached.
+func (r *Recorder) claim(e Exchange) (models.Capture, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	capture := r.running()
+	if capture == nil || !matches(capture.Filter, e) {
+		return models.Capture{}, false
+	}
+
+	capture.Recorded++
+	if capture.Recorded >= capture.Limit {
+		r.logger.Info("Request capture finished",
+			slog.String("capture_id", capture.ID.String()),
+			slog.Int("recorded", capture.Recorded))
+		r.capture = nil
+	}
+	return *capture, true
+}
+
+func matches(filter models.RecordingFilter, e Exchange) bool {
+	switch {
+	case filter.Principal != "" && filter.Principal != e.Principal:
+		return false
+	case filter.UserID != nil && (e.UserID == nil || *filter.UserID != *e.UserID):
+		return false
+	case filter.Method != "" && filter.Method != e.Method:
+		return false
+	case filter.Route != "" && filter.Route != e.Route:
+		return false
+	}
+	return true
+}
+
+func (r *Recorder) List(ctx context.Context, filter models.RecordedRequestFilter) ([]models.RecordedRequest, error) {
+	return r.store.List(ctx, filter)
+}
+
+func (r *Recorder) Get(ctx context.Context, id uuid.UUID) (*models.RecordedRequest, error) {
+	recorded, err := r.store.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordingNotFound
+	}
+	return recorded, err
+}
+
+func (r *Recorder) Purge(ctx context.Context, filter models.RecordedRequestFilter) (int64, error) {
+	return r.store.Purge(ctx, filter)
+}
+
+// Middleware hands every request to recorder while a capture is running, keeping up to
+// maxBodyBytes of the request and response bodies. Routes are matched by gin pattern.
+func Middleware(recorder *Recorder, maxBodyBytes int, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := recorder.Current(); !ok {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		requestBody, requestTruncated, err := peekBody(c.Request, maxBodyBytes)
+		if err != nil {
+			logger.Warn("Failed to read request body for capture",
+				slog.String("path", c.Request.URL.Path),
+				slog.String("error", err.Error()))
+		}
+
+		writer := &captureWriter{ResponseWriter: c.Writer, limit: maxBodyBytes}
+		c.Writer = writer
+		c.Next()
+
+		id := identity.FromContext(c.Request.Context())
+		recorder.Record(c.Request.Context(), Exchange{
+			Principal:         id.Principal(),
+			UserID:            userOf(c, id, requestBody),
+			Method:            c.Request.Method,
+			Route:             c.FullPath(),
+			URL:               c.Request.URL.RequestURI(),
+			RequestHeader:     c.Request.Header.Clone(),
+			RequestBody:       requestBody,
+			RequestTruncated:  requestTruncated,
+			Status:            writer.Status(),
+			ResponseHeader:    writer.Header().Clone(),
+			ResponseBody:      writer.body.Bytes(),
+			ResponseTruncated: writer.truncated,
+			Duration:          time.Since(start),
+		})
+	}
+}
+
+// peekBody reads up to limit bytes of the request body and puts them back in front of
+// the rest, so handlers still see the whole body.
+func peekBody(req *http.Request, limit int) ([]byte, bool, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, false, nil
+	}
+
+	peeked, err := io.ReadAll(io.LimitReader(req.Body, int64(limit)+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peeked), req.Body), req.Body}
+	if err != nil {
+		return nil, true, err
+	}
+	if len(peeked) > limit {
+		return peeked[:limit], true, nil
+	}
+	return peeked, false, nil
+}
+
+// userOf finds the user a request is about: the impersonated user, the user_id query
+// parameter or JSON field, or the user of a /users/:id route.
+func userOf(c *gin.Context, id identity.Identity, body []byte) *uuid.UUID {
+	if id.Impersonating() {
+		return id.Subject
+	}
+
+	candidate := c.Query("user_id")
+	if candidate == "" && strings.Contains(c.FullPath(), "/users/:id/") {
+		candidate = c.Param("id")
+	}
+	if candidate == "" && len(body) > 0 {
+		var payload struct {
+			UserID string `json:"user_id"`
+		}
+		if json.Unmarshal(body, &payload) == nil {
+			candidate = payload.UserID
+		}
+	}
+
+	userID, err := uuid.Parse(candidate)
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
+
+// captureWriter keeps up to limit bytes of the response body while writing it through.
+type captureWriter struct {
+	gin.ResponseWriter
+	limit     int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *captureWriter) keep(data []byte) {
+	room := w.limit - w.body.Len()
+	if len(data) > room {
+		data = data[:max(room, 0)]
+		w.truncated = true
+	}
+	w.body.Write(data)
+}
+
+func (w *captureWriter) Write(data []byte) (int, error) {
+	w.keep(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,133 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"awesomeProject1/internal/middleware"
+	"awesomeProject1/internal/model"
+)
+
+// skippedHeaders are recomputed by the HTTP client for the replayed request.
+var skippedHeaders = []string{"Content-Length", "Connection", "Accept-Encoding"}
+
+type ReplayResult struct {
+	Replayed   int `json:"replayed"`
+	Mismatched int `json:"mismatched"`
+	Failed     int `json:"failed"`
+}
+
+// Replayer re-sends recorded requests to another instance, e.g. staging, and compares
+// the status codes with the recorded ones.
+type Replayer struct {
+	target     string
+	adminToken string
+	apiKey     string
+	client     *http.Client
+	logger     *slog.Logger
+}
+
+// NewReplayer replays against target, the scheme and host of the instance. Recorded
+// credentials are redacted, so adminToken and apiKey, when set, authenticate every
+// request.
+func NewReplayer(target string, adminToken string, apiKey string, logger *slog.Logger) *Replayer {
+	return &Replayer{
+		target:     strings.TrimSuffix(target, "/"),
+		adminToken: adminToken,
+		apiKey:     apiKey,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		logger:     logger,
+	}
+}
+
+// Replay sends the requests one at a time in the order given. Requests whose body was
+// not recorded in full are skipped and counted as failed, as are transport errors; the
+// replay carries on until ctx is cancelled.
+func (r *Replayer) Replay(ctx context.Context, recorded []models.RecordedRequest) (ReplayResult, error) {
+	var result ReplayResult
+	for _, rec := range recorded {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		if rec.RequestTruncated {
+			r.logger.WarnContext(ctx, "Skipping recorded request without its full body",
+				slog.String("recording_id", rec.ID.String()),
+				slog.String("method", rec.Method),
+				slog.String("url", rec.URL))
+			result.Failed++
+			continue
+		}
+
+		status, err := r.send(ctx, rec)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to replay recorded request",
+				slog.String("recording_id", rec.ID.String()),
+				slog.String("method", rec.Method),
+				slog.String("url", rec.URL),
+				slog.String("error", err.Error()))
+			result.Failed++
+			continue
+		}
+
+		result.Replayed++
+		if status != rec.Status {
+			result.Mismatched++
+			r.logger.WarnContext(ctx, "Replayed request returned a different status",
+				slog.String("recording_id", rec.ID.String()),
+				slog.String("method", rec.Method),
+				slog.String("url", rec.URL),
+				slog.Int("recorded_status", rec.Status),
+				slog.Int("replayed_status", status))
+			continue
+		}
+
+		r.logger.DebugContext(ctx, "Replayed recorded request",
+			slog.String("recording_id", rec.ID.String()),
+			slog.String("method", rec.Method),
+			slog.String("url", rec.URL),
+			slog.Int("status", status))
+	}
+	return result, nil
+}
+
+func (r *Replayer) send(ctx context.Context, rec models.RecordedRequest) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, rec.Method, r.target+rec.URL, strings.NewReader(rec.RequestBody))
+	if err != nil {
+		return 0, err
+	}
+
+	var header http.Header
+	if err := json.Unmarshal(rec.RequestHeaders, &header); err != nil {
+		return 0, fmt.Errorf("decode recorded headers: %w", err)
+	}
+	for name, values := range header {
+		if len(values) == 1 && values[0] == Redacted {
+			continue
+		}
+		req.Header[name] = values
+	}
+	for _, name := range skippedHeaders {
+		req.Header.Del(name)
+	}
+	if r.adminToken != "" {
+		req.Header.Set(middleware.AdminTokenHeader, r.adminToken)
+	}
+	if r.apiKey != "" {
+		req.Header.Set(middleware.APIKeyHeader, r.apiKey)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
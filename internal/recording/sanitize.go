@@ -0,0 +1,119 @@
+package recording
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"awesomeProject1/internal/middleware"
+	"awesomeProject1/internal/model"
+)
+
+// Redacted replaces the values of credentials and sensitive fields.
+const Redacted = "[REDACTED]"
+
+// credentialHeaders are never stored. HMAC signatures are useless once the body or the
+// timestamp changes, so they are dropped along with the secrets.
+var credentialHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	middleware.AdminTokenHeader,
+	middleware.APIKeyHeader,
+	middleware.SignatureHeader,
+}
+
+// sensitiveFields are JSON keys whose values are redacted wherever they appear,
+// matched case-insensitively by substring.
+var sensitiveFields = []string{"password", "secret", "token", "email"}
+
+func sanitize(e Exchange) models.RecordedRequest {
+	requestBody, requestRedacted := sanitizeBody(e.RequestHeader, e.RequestBody, e.RequestTruncated)
+	responseBody, responseRedacted := sanitizeBody(e.ResponseHeader, e.ResponseBody, e.ResponseTruncated)
+
+	return models.RecordedRequest{
+		Principal:         e.Principal,
+		UserID:            e.UserID,
+		Method:            e.Method,
+		Route:             e.Route,
+		URL:               e.URL,
+		RequestHeaders:    sanitizeHeader(e.RequestHeader),
+		RequestBody:       requestBody,
+		RequestTruncated:  e.RequestTruncated || requestRedacted,
+		Status:            e.Status,
+		ResponseHeaders:   sanitizeHeader(e.ResponseHeader),
+		ResponseBody:      responseBody,
+		ResponseTruncated: e.ResponseTruncated || responseRedacted,
+		DurationMS:        e.Duration.Milliseconds(),
+	}
+}
+
+func sanitizeHeader(header http.Header) json.RawMessage {
+	for _, name := range credentialHeaders {
+		if header.Get(name) != "" {
+			header.Set(name, Redacted)
+		}
+	}
+	encoded, _ := json.Marshal(header)
+	return encoded
+}
+
+// sanitizeBody redacts sensitive fields of JSON bodies and keeps other text as is. It
+// drops bodies it cannot vouch for, binary ones and JSON cut off at the size limit,
+// reporting true when it did.
+func sanitizeBody(header http.Header, body []byte, truncated bool) (string, bool) {
+	if len(body) == 0 {
+		return "", false
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if truncated {
+			return "", true
+		}
+		var value any
+		if err := json.Unmarshal(body, &value); err != nil {
+			return "", true
+		}
+		redacted, err := json.Marshal(redact(value))
+		if err != nil {
+			return "", true
+		}
+		return string(redacted), false
+	case strings.HasPrefix(mediaType, "text/"):
+		return string(body), false
+	default:
+		return "", true
+	}
+}
+
+func redact(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSensitive(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = redact(field)
+			}
+		}
+	case []any:
+		for i := range v {
+			v[i] = redact(v[i])
+		}
+	}
+	return value
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range sensitiveFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
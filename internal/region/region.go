@@ -0,0 +1,169 @@
+// Package region runs the service as one of two regions in an active-passive
+// deployment. The passive region's database is a streaming standby of the active
+// one's: its instances serve reads, fence writes and run no leader, until an operator
+// promotes it with the promote command.
+package region
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"awesomeProject1/internal/model"
+)
+
+var (
+	ErrNotPassive       = errors.New("this region is not passive")
+	ErrNotStandby       = errors.New("the database is not a standby")
+	ErrActiveReachable  = errors.New("the active region still reports itself active and ready")
+	ErrPromotionPending = errors.New("the database did not finish promotion in time")
+)
+
+type database interface {
+	ReplicationStatus(ctx context.Context) (*models.ReplicationStatus, error)
+	Promote(ctx context.Context, wait time.Duration) (bool, error)
+}
+
+// Region is the role of this deployment. MaxLag, when set, fails the readiness of a
+// standby lagging further behind, so that load balancers stop sending it reads.
+type Region struct {
+	name   string
+	role   string
+	maxLag time.Duration
+	db     database
+	client *http.Client
+	logger *slog.Logger
+}
+
+func New(name string, role string, maxLag time.Duration, db database, logger *slog.Logger) *Region {
+	return &Region{
+		name:   name,
+		role:   role,
+		maxLag: maxLag,
+		db:     db,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger,
+	}
+}
+
+func (r *Region) Name() string {
+	return r.name
+}
+
+func (r *Region) Role() string {
+	return r.role
+}
+
+func (r *Region) Passive() bool {
+	return r.role == models.RegionPassive
+}
+
+// Replication reads the replication status of the database and reports whether the
+// replay lag is within MaxLag.
+func (r *Region) Replication(ctx context.Context) (*models.ReplicationStatus, bool, error) {
+	status, err := r.db.ReplicationStatus(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if r.maxLag > 0 && status.LagSeconds != nil && *status.LagSeconds > r.maxLag.Seconds() {
+		return status, false, nil
+	}
+	return status, true, nil
+}
+
+// PromoteOptions guard a promotion. ActiveReadyz is the /readyz URL of an instance of
+// the active region; the promotion is refused while it answers as active and ready.
+// Force skips that check, for when the active region is known to be down.
+type PromoteOptions struct {
+	ActiveReadyz string
+	Force        bool
+	Wait         time.Duration
+}
+
+// Promote makes the database of this passive region the primary. The instances of
+// both regions keep their configured role: they have to be restarted with their new
+// REGION_ROLE afterwards, the former active region's before it comes back.
+func (r *Region) Promote(ctx context.Context, opts PromoteOptions) error {
+	if !r.Passive() {
+		return ErrNotPassive
+	}
+
+	status, err := r.db.ReplicationStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("read replication status: %w", err)
+	}
+	if !status.InRecovery {
+		return ErrNotStandby
+	}
+
+	if !opts.Force {
+		if opts.ActiveReadyz == "" {
+			return errors.New("the active region's readiness URL is required to promote without force")
+		}
+		active, err := r.activeReady(ctx, opts.ActiveReadyz)
+		if err != nil {
+			return err
+		}
+		if active {
+			return ErrActiveReachable
+		}
+	}
+
+	lag := "unknown"
+	if status.LagSeconds != nil {
+		lag = fmt.Sprintf("%.1fs", *status.LagSeconds)
+	}
+	r.logger.WarnContext(ctx, "Promoting the passive region's database",
+		slog.String("region", r.name),
+		slog.String("replication_lag", lag),
+		slog.Bool("force", opts.Force))
+
+	promoted, err := r.db.Promote(ctx, opts.Wait)
+	if err != nil {
+		return fmt.Errorf("promote database: %w", err)
+	}
+	if !promoted {
+		return ErrPromotionPending
+	}
+
+	r.logger.WarnContext(ctx, "Promoted the passive region's database",
+		slog.String("region", r.name))
+	return nil
+}
+
+// activeReady reports whether the instance behind readyz answers as active and ready.
+// An unreachable or unready instance is taken as down.
+func (r *Region) activeReady(ctx context.Context, readyz string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, readyz, nil)
+	if err != nil {
+		return false, fmt.Errorf("invalid active readiness URL: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		r.logger.InfoContext(ctx, "Active region is unreachable",
+			slog.String("url", readyz),
+			slog.String("error", err.Error()))
+		return false, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		r.logger.InfoContext(ctx, "Active region is not ready",
+			slog.String("url", readyz),
+			slog.Int("status", resp.StatusCode))
+		return false, nil
+	}
+
+	var body struct {
+		Role string `json:"region_role"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return false, fmt.Errorf("decode active readiness response: %w", err)
+	}
+	return body.Role != models.RegionPassive, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+// monthsActiveExpr counts the months of a subscription that fall inside [@start, @end_month],
+// both ends inclusive.
+const monthsActiveExpr = `(EXTRACT(YEAR FROM age(LEAST(COALESCE(end_date, @end_month), @end_month), GREATEST(start_date, @start))) * 12 +
+	EXTRACT(MONTH FROM age(LEAST(COALESCE(end_date, @end_month), @end_month), GREATEST(start_date, @start))) + 1)::int`
+
+// TopServices ranks services by spend within [start, end], where spend is the monthly
+// cost multiplied by the months each subscription was active in the period. One-time
+// purchases count once.
+func (r *SubscriptionRepository) TopServices(ctx context.Context, start time.Time, end time.Time, limit int, filter models.AggregateFilter) ([]models.ServiceRanking, error) {
+	var rankings []models.ServiceRanking
+	err := r.serviceSpend(ctx, start, end, filter).
+		Order("total_spend DESC, subscribers DESC, service_name").
+		Limit(limit).
+		Scan(&rankings).Error
+	if err != nil {
+		return nil, fmt.Errorf("top services query failed: %w", err)
+	}
+	return rankings, nil
+}
+
+// ServiceSpend returns the spend of every service within [start, end] as TopServices
+// computes it, ordered by service name.
+func (r *SubscriptionRepository) ServiceSpend(ctx context.Context, start time.Time, end time.Time, filter models.AggregateFilter) ([]models.ServiceRanking, error) {
+	var spend []models.ServiceRanking
+	if err := r.serviceSpend(ctx, start, end, filter).Order("service_name").Scan(&spend).Error; err != nil {
+		return nil, fmt.Errorf("service spend query failed: %w", err)
+	}
+	return spend, nil
+}
+
+func (r *SubscriptionRepository) serviceSpend(ctx context.Context, start time.Time, end time.Time, filter models.AggregateFilter) *gorm.DB {
+	endMonth := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.UTC)
+	params := map[string]any{"start": start, "end": end, "end_month": endMonth}
+
+	db := r.db.WithContext(ctx).Model(&models.Subscription{}).
+		Select(fmt.Sprintf("service_name, COALESCE(%s, 0) AS total_spend, COUNT(DISTINCT user_id) AS subscribers", r.rounding.SQL(fmt.Sprintf("SUM(%s * CASE WHEN kind = 'one_time' THEN 1 ELSE %s END)", monthlyCostExpr(""), monthsActiveExpr))), params).
+		Where(chargedInPeriodExpr, params)
+	return applyAggregateFilter(db, filter).Group("service_name")
+}
+
+func (r *SubscriptionRepository) FindDuplicates(ctx context.Context, minSimilarity float64, limit int, filter models.AggregateFilter) ([]models.DuplicatePair, error) {
+	conditions := []string{
+		"a.deleted_at IS NULL",
+		"b.deleted_at IS NULL",
+		"similarity(lower(a.service_name), lower(b.service_name)) >= ?",
+		"a.start_date <= COALESCE(b.end_date, 'infinity')",
+		"b.start_date <= COALESCE(a.end_date, 'infinity')",
+	}
+	args := []any{minSimilarity}
+	if len(filter.UserIDs) > 0 {
+		conditions = append(conditions, "a.user_id IN ?")
+		args = append(args, filter.UserIDs)
+	}
+
+	query := `SELECT a.user_id, a.id AS first_id, a.service_name AS first_service_name,
+			b.id AS second_id, b.service_name AS second_service_name,
+			similarity(lower(a.service_name), lower(b.service_name)) AS similarity
+		FROM subscriptions a
+		JOIN subscriptions b ON b.user_id = a.user_id AND a.id < b.id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY similarity DESC, a.user_id
+		LIMIT ?`
+	args = append(args, limit)
+
+	var pairs []models.DuplicatePair
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&pairs).Error; err != nil {
+		return nil, fmt.Errorf("duplicate detection query failed: %w", err)
+	}
+	return pairs, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"awesomeProject1/internal/model"
+)
+
+type AnomalyRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewAnomalyRepository(db *gorm.DB, logger *slog.Logger) *AnomalyRepository {
+	return &AnomalyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create stores anomaly unless one with the same key exists, reporting whether it did.
+func (r *AnomalyRepository) Create(ctx context.Context, anomaly *models.Anomaly) (bool, error) {
+	start := time.Now()
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "key"}}, DoNothing: true}).
+		Create(anomaly)
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to create anomaly in database",
+			slog.String("key", anomaly.Key),
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return false, result.Error
+	}
+
+	r.logger.DebugContext(ctx, "Stored anomaly in database",
+		slog.String("key", anomaly.Key),
+		slog.Bool("created", result.RowsAffected > 0),
+		slog.Duration("duration", time.Since(start)))
+
+	return result.RowsAffected > 0, nil
+}
+
+// ListByUser returns the anomalies of a user, most recent first.
+func (r *AnomalyRepository) ListByUser(ctx context.Context, userID uuid.UUID, page models.Page) ([]models.Anomaly, error) {
+	start := time.Now()
+	query := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("detected_at DESC, id")
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit).Offset(page.Offset)
+	}
+
+	var anomalies []models.Anomaly
+	if err := query.Find(&anomalies).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list anomalies from database",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.DebugContext(ctx, "Successfully retrieved anomalies from database",
+		slog.String("user_id", userID.String()),
+		slog.Int("count", len(anomalies)),
+		slog.Duration("duration", time.Since(start)))
+
+	return anomalies, nil
+}
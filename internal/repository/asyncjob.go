@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+type AsyncJobRepository struct {
+	*Repository[models.AsyncJob]
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewAsyncJobRepository(db *gorm.DB, logger *slog.Logger) *AsyncJobRepository {
+	return &AsyncJobRepository{
+		Repository: NewRepository[models.AsyncJob](db, logger, "async job"),
+		db:         db,
+		logger:     logger,
+	}
+}
+
+// Heartbeat records the progress of a running job.
+func (r *AsyncJobRepository) Heartbeat(ctx context.Context, job *models.AsyncJob) error {
+	err := r.db.WithContext(ctx).Model(job).
+		Where("status = ?", models.AsyncJobRunning).
+		Select("progress", "total", "heartbeat_at").
+		Updates(job).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to update async job progress in database",
+			slog.String("async_job_id", job.ID.String()),
+			slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// Finish records the outcome of a job together with the file it produced, if any.
+func (r *AsyncJobRepository) Finish(ctx context.Context, job *models.AsyncJob, file []byte) error {
+	start := time.Now()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if file != nil {
+			if err := tx.Create(&models.AsyncJobFile{JobID: job.ID, Content: file}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(job).
+			Select("status", "progress", "total", "result", "error", "heartbeat_at", "finished_at").
+			Updates(job).Error
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to finish async job in database",
+			slog.String("async_job_id", job.ID.String()),
+			slog.String("status", job.Status),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	return nil
+}
+
+// File returns the file a job produced, or gorm.ErrRecordNotFound.
+func (r *AsyncJobRepository) File(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	var file models.AsyncJobFile
+	if err := r.db.WithContext(ctx).Take(&file, "job_id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return file.Content, nil
+}
+
+// FailStale marks the running jobs whose last heartbeat is older than before as
+// failed; the instance running them went away.
+func (r *AsyncJobRepository) FailStale(ctx context.Context, before time.Time, message string, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.AsyncJob{}).
+		Where("status = ? AND heartbeat_at < ?", models.AsyncJobRunning, before).
+		Updates(map[string]any{
+			"status":      models.AsyncJobFailed,
+			"error":       message,
+			"finished_at": now,
+		})
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to fail stale async jobs in database",
+			slog.String("error", result.Error.Error()))
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// PurgeFinished deletes the jobs, and their files, finished before before.
+func (r *AsyncJobRepository) PurgeFinished(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("finished_at < ?", before).
+		Delete(&models.AsyncJob{})
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to purge finished async jobs from database",
+			slog.String("error", result.Error.Error()))
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+type AuditRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewAuditRepository(db *gorm.DB, logger *slog.Logger) *AuditRepository {
+	return &AuditRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, record *models.AuditRecord) error {
+	start := time.Now()
+	err := r.db.WithContext(ctx).Create(record).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to write audit record to database",
+			slog.String("action", record.Action),
+			slog.String("actor", record.Actor),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	r.logger.DebugContext(ctx, "Successfully wrote audit record to database",
+		slog.String("audit_id", record.ID.String()),
+		slog.String("action", record.Action),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditRecord, error) {
+	r.logger.InfoContext(ctx, "Listing audit records from repository",
+		slog.String("actor", filter.Actor),
+		slog.Int("limit", filter.Limit))
+
+	start := time.Now()
+	var records []models.AuditRecord
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.SubjectID != nil {
+		query = query.Where("subject_id = ?", *filter.SubjectID)
+	}
+	if filter.SubscriptionID != nil {
+		query = query.Where("subscription_id = ?", *filter.SubscriptionID)
+	}
+	if len(filter.SubscriptionIDs) > 0 {
+		query = query.Where("subscription_id IN ?", filter.SubscriptionIDs)
+	}
+	if len(filter.Actions) > 0 {
+		query = query.Where("action IN ?", filter.Actions)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if err := query.Find(&records).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list audit records from database",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully retrieved audit records from database",
+		slog.Int("count", len(records)),
+		slog.Duration("duration", time.Since(start)))
+
+	return records, nil
+}
+
+// ForEachEventBatch streams the audit records matching filter in chronological order.
+func (r *AuditRepository) ForEachEventBatch(ctx context.Context, filter models.EventFilter, batchSize int, fn func(records []models.AuditRecord) error) error {
+	start := time.Now()
+	total := 0
+
+	var last *models.AuditRecord
+	for {
+		query := r.db.WithContext(ctx).
+			Where("created_at >= ? AND created_at < ?", filter.From, filter.To).
+			Order("created_at, id").
+			Limit(batchSize)
+
+		if len(filter.SubscriptionIDs) > 0 {
+			query = query.Where("subscription_id IN ?", filter.SubscriptionIDs)
+		}
+		if filter.UserID != nil {
+			query = query.Where("(after->>'user_id' = ? OR before->>'user_id' = ?)", filter.UserID.String(), filter.UserID.String())
+		}
+		if len(filter.Types) > 0 {
+			query = query.Where("action IN ?", filter.Types)
+		}
+		if last != nil {
+			query = query.Where("(created_at, id) > (?, ?)", last.CreatedAt, last.ID)
+		}
+
+		var records []models.AuditRecord
+		if err := query.Find(&records).Error; err != nil {
+			r.logger.ErrorContext(ctx, "Failed to stream audit records from database",
+				slog.Int("streamed", total),
+				slog.String("error", err.Error()),
+				slog.Duration("duration", time.Since(start)))
+			return err
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		total += len(records)
+		if err := fn(records); err != nil {
+			return err
+		}
+		if len(records) < batchSize {
+			break
+		}
+		last = &records[len(records)-1]
+	}
+
+	r.logger.InfoContext(ctx, "Successfully streamed audit records from database",
+		slog.Int("count", total),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
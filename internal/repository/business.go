@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/rounding"
+)
+
+// BusinessRepository reads business figures. Its queries name the schema of the
+// tenant explicitly, so they run on the production connections.
+type BusinessRepository struct {
+	db       *gorm.DB
+	rounding rounding.Policy
+	logger   *slog.Logger
+}
+
+func NewBusinessRepository(db *gorm.DB, policy rounding.Policy, logger *slog.Logger) *BusinessRepository {
+	return &BusinessRepository{
+		db:       db,
+		rounding: policy,
+		logger:   logger,
+	}
+}
+
+// TenantStats counts the subscriptions of the tenant kept in schema that are active
+// on day, and their monthly recurring revenue.
+func (r *BusinessRepository) TenantStats(ctx context.Context, tenant string, schema string, day time.Time) (models.TenantStats, error) {
+	start := time.Now()
+	var stats models.TenantStats
+	query := fmt.Sprintf(`SELECT COUNT(*) AS active_subscriptions,
+		COALESCE(%s, 0) AS monthly_recurring_revenue
+		FROM %s.subscriptions
+		WHERE deleted_at IS NULL AND status = 'active' AND start_date <= ? AND (end_date IS NULL OR end_date >= ?)`, r.rounding.SQL(fmt.Sprintf("SUM(%s) FILTER (WHERE kind = 'recurring')", monthlyCostExpr(""))), schema)
+	if err := r.db.WithContext(ctx).Raw(query, day, day).Scan(&stats).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to read tenant stats from database",
+			slog.String("tenant", tenant),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return stats, err
+	}
+	stats.Tenant = tenant
+
+	r.logger.DebugContext(ctx, "Successfully read tenant stats from database",
+		slog.String("tenant", tenant),
+		slog.Int64("active_subscriptions", stats.ActiveSubscriptions),
+		slog.Duration("duration", time.Since(start)))
+
+	return stats, nil
+}
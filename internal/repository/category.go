@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"awesomeProject1/internal/model"
+)
+
+// categoryExpr is the category of the subscriptions row referenced by table: its own,
+// or else the one the service catalog gives its service.
+func categoryExpr(table string) string {
+	return strings.NewReplacer("{t}", table).Replace(`COALESCE({t}.category,
+		(SELECT sc.category FROM service_catalog sc WHERE sc.service_name = lower(trim({t}.service_name))))`)
+}
+
+type CategoryRepository struct {
+	*Repository[models.Category]
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewCategoryRepository(db *gorm.DB, logger *slog.Logger) *CategoryRepository {
+	return &CategoryRepository{
+		Repository: NewRepository[models.Category](db, logger, "category"),
+		db:         db,
+		logger:     logger,
+	}
+}
+
+func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// List returns a page of categories ordered by slug.
+func (r *CategoryRepository) List(ctx context.Context, page models.Page) ([]models.Category, error) {
+	start := time.Now()
+	query := r.db.WithContext(ctx).Order("slug").Offset(page.Offset)
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit)
+	}
+
+	var categories []models.Category
+	if err := query.Find(&categories).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list categories from database",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+	return categories, nil
+}
+
+type CatalogRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewCatalogRepository(db *gorm.DB, logger *slog.Logger) *CatalogRepository {
+	return &CatalogRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *CatalogRepository) List(ctx context.Context) ([]models.CatalogEntry, error) {
+	start := time.Now()
+	var entries []models.CatalogEntry
+	if err := r.db.WithContext(ctx).Order("service_name").Find(&entries).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list service catalog from database",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *CatalogRepository) Upsert(ctx context.Context, entry *models.CatalogEntry) error {
+	start := time.Now()
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(entry).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to save service catalog entry in database",
+			slog.String("service_name", entry.ServiceName),
+			slog.String("category", entry.Category),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully saved service catalog entry in database",
+		slog.String("service_name", entry.ServiceName),
+		slog.String("category", entry.Category),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func (r *CatalogRepository) Delete(ctx context.Context, serviceName string) error {
+	start := time.Now()
+	result := r.db.WithContext(ctx).
+		Where("service_name = ?", serviceName).
+		Delete(&models.CatalogEntry{})
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete service catalog entry from database",
+			slog.String("service_name", serviceName),
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	r.logger.InfoContext(ctx, "Successfully deleted service catalog entry from database",
+		slog.String("service_name", serviceName),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func (r *CatalogRepository) ListAliases(ctx context.Context) ([]models.ServiceAlias, error) {
+	start := time.Now()
+	var aliases []models.ServiceAlias
+	if err := r.db.WithContext(ctx).Order("alias").Find(&aliases).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list service aliases from database",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+	return aliases, nil
+}
+
+func (r *CatalogRepository) UpsertAlias(ctx context.Context, alias *models.ServiceAlias) error {
+	start := time.Now()
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(alias).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to save service alias in database",
+			slog.String("alias", alias.Alias),
+			slog.String("service_name", alias.ServiceName),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully saved service alias in database",
+		slog.String("alias", alias.Alias),
+		slog.String("service_name", alias.ServiceName),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func (r *CatalogRepository) DeleteAlias(ctx context.Context, alias string) error {
+	start := time.Now()
+	result := r.db.WithContext(ctx).
+		Where("alias = ?", alias).
+		Delete(&models.ServiceAlias{})
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete service alias from database",
+			slog.String("alias", alias),
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	r.logger.InfoContext(ctx, "Successfully deleted service alias from database",
+		slog.String("alias", alias),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
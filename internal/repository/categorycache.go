@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/model"
+)
+
+// CachingCategoryRepository answers GetBySlug from an in-memory copy of the category
+// taxonomy, so validating the category of every subscription write costs no query.
+// Writes through it drop the copy, which is reloaded on the next lookup; changes made
+// by other replicas show up on the next Refresh, except new categories, which a lookup
+// missing the copy still finds in the database.
+type CachingCategoryRepository struct {
+	*CategoryRepository
+	logger *slog.Logger
+
+	mu     sync.RWMutex
+	bySlug map[string]models.Category
+	// generation counts invalidations, so that a Refresh overlapping a write does not
+	// store what it read before the write.
+	generation uint64
+}
+
+func NewCachingCategoryRepository(next *CategoryRepository, logger *slog.Logger) *CachingCategoryRepository {
+	return &CachingCategoryRepository{
+		CategoryRepository: next,
+		logger:             logger,
+	}
+}
+
+// Refresh reloads the copy of the categories.
+func (r *CachingCategoryRepository) Refresh(ctx context.Context) error {
+	r.mu.RLock()
+	generation := r.generation
+	r.mu.RUnlock()
+
+	categories, err := r.CategoryRepository.List(ctx, models.Page{})
+	if err != nil {
+		return err
+	}
+
+	bySlug := make(map[string]models.Category, len(categories))
+	for _, category := range categories {
+		bySlug[category.Slug] = category
+	}
+	r.mu.Lock()
+	if r.generation == generation {
+		r.bySlug = bySlug
+	}
+	r.mu.Unlock()
+
+	r.logger.DebugContext(ctx, "Refreshed category cache",
+		slog.Int("categories", len(bySlug)))
+	return nil
+}
+
+func (r *CachingCategoryRepository) GetBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	r.mu.RLock()
+	loaded := r.bySlug != nil
+	category, ok := r.bySlug[slug]
+	r.mu.RUnlock()
+
+	if !loaded {
+		if err := r.Refresh(ctx); err != nil {
+			return nil, err
+		}
+		r.mu.RLock()
+		category, ok = r.bySlug[slug]
+		r.mu.RUnlock()
+	}
+	if ok {
+		return &category, nil
+	}
+
+	found, err := r.CategoryRepository.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	if r.bySlug != nil {
+		r.bySlug[found.Slug] = *found
+	}
+	r.mu.Unlock()
+	return found, nil
+}
+
+func (r *CachingCategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	defer r.invalidate()
+	return r.CategoryRepository.Create(ctx, category)
+}
+
+func (r *CachingCategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	defer r.invalidate()
+	return r.CategoryRepository.Update(ctx, category)
+}
+
+func (r *CachingCategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer r.invalidate()
+	return r.CategoryRepository.Delete(ctx, id)
+}
+
+func (r *CachingCategoryRepository) invalidate() {
+	r.mu.Lock()
+	r.bySlug = nil
+	r.generation++
+	r.mu.Unlock()
+}
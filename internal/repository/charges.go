@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+// monthlyCostExpr normalizes the price of the subscriptions row aliased by prefix to a
+// monthly cost. One-time purchases keep their full price.
+func monthlyCostExpr(prefix string) string {
+	return strings.NewReplacer("{p}", prefix).Replace(`(CASE
+		WHEN {p}kind = 'one_time' THEN {p}price
+		WHEN {p}billing_period = 'weekly' THEN {p}price * 52 / 12.0
+		WHEN {p}billing_period = 'quarterly' THEN {p}price / 3.0
+		WHEN {p}billing_period = 'yearly' THEN {p}price / 12.0
+		ELSE {p}price END)`)
+}
+
+// chargesCTE builds a "charges" CTE with one row per charge due between the first day
+// of start and the last day of end's month, with its price, charged_at, category and
+// subscription. Charges are laid out from the anchor day of the start month every
+// billing period until the last billed month ends; one-time purchases are charged once
+// and lifetime ones and scheduled subscriptions never.
+func chargesCTE(start time.Time, end time.Time, filter models.AggregateFilter) (string, []any) {
+	periodEnd := time.Date(end.Year(), end.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+
+	conditions := []string{
+		"s.deleted_at IS NULL",
+		"s.status = 'active'",
+		"s.kind <> 'lifetime'",
+		"s.start_date < ?",
+		"(s.end_date >= ? OR s.end_date IS NULL)",
+	}
+	args := []any{periodEnd, periodEnd, start}
+
+	filterConditions, filterArgs := aggregateConditions("s.", filter)
+	conditions = append(conditions, filterConditions...)
+	args = append(args, filterArgs...)
+	args = append(args, start, periodEnd)
+
+	// The series is sized for weekly charges, the most frequent period, so it covers
+	// the whole period whatever the subscription's billing period is.
+	cte := `WITH charges AS (
+		SELECT s.price, c.charged_at, ` + categoryExpr("s") + ` AS category,
+			s.id AS subscription_id, s.user_id, s.service_name
+		FROM subscriptions s
+		CROSS JOIN LATERAL (
+			SELECT f.first_charge + n * f.step AS charged_at
+			FROM (SELECT
+					s.start_date + (LEAST(s.billing_anchor_day,
+						EXTRACT(DAY FROM date_trunc('month', s.start_date) + interval '1 month - 1 day')::int) - 1) * interval '1 day' AS first_charge,
+					CASE s.billing_period
+						WHEN 'weekly' THEN interval '1 week'
+						WHEN 'quarterly' THEN interval '3 months'
+						WHEN 'yearly' THEN interval '1 year'
+						ELSE interval '1 month' END AS step) f,
+				generate_series(0, CASE WHEN s.kind = 'one_time' THEN 0
+					ELSE (?::date - s.start_date) / 7 + 1 END) AS n
+		) c
+		WHERE ` + strings.Join(conditions, " AND ") + `
+			AND (s.end_date IS NULL OR c.charged_at < s.end_date + interval '1 month')
+			AND c.charged_at >= ? AND c.charged_at < ?
+	)`
+	return cte, args
+}
+
+func (r *SubscriptionRepository) chargesByBucket(ctx context.Context, start time.Time, end time.Time, bucket string, interval string, filter models.AggregateFilter) ([]models.BucketTotal, error) {
+	cte, args := chargesCTE(start, end, filter)
+	args = append(args, bucket, start, end, interval, interval)
+
+	query := cte + `
+		SELECT b.bucket AS start, COALESCE(SUM(c.price), 0) AS total
+		FROM generate_series(date_trunc(?, ?::timestamptz), ?::timestamptz, ?::interval) AS b(bucket)
+		LEFT JOIN charges c ON c.charged_at >= b.bucket AND c.charged_at < b.bucket + ?::interval
+		GROUP BY b.bucket
+		ORDER BY b.bucket`
+
+	var totals []models.BucketTotal
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("bucketed charges query failed: %w", err)
+	}
+	return totals, nil
+}
+
+// ChargeRepository lists the charges of every subscription, for reconciliation with
+// external billing.
+type ChargeRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewChargeRepository(db *gorm.DB, logger *slog.Logger) *ChargeRepository {
+	return &ChargeRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Charges returns the charges due in the months from start to end, oldest first.
+func (r *ChargeRepository) Charges(ctx context.Context, start time.Time, end time.Time) ([]models.Charge, error) {
+	queryStart := time.Now()
+	cte, args := chargesCTE(start, end, models.AggregateFilter{})
+
+	var charges []models.Charge
+	err := r.db.WithContext(ctx).Raw(cte+` SELECT subscription_id, user_id, service_name, charged_at, price AS amount
+		FROM charges
+		ORDER BY charged_at, subscription_id`, args...).Scan(&charges).Error
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list charges from database",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(queryStart)))
+		return nil, fmt.Errorf("charges query failed: %w", err)
+	}
+	return charges, nil
+}
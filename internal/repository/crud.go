@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/logging"
+	"awesomeProject1/internal/model"
+)
+
+// Repository implements the basic CRUD operations for an entity and logs them like
+// the logging decorators do. Entity repositories embed it and add their specific
+// queries, overriding the operations that need more than a single statement.
+type Repository[T models.Entity] struct {
+	db     *gorm.DB
+	logger *slog.Logger
+	op     string
+	idKey  string
+}
+
+// NewRepository returns a repository for T. name is the entity name used in log
+// records, e.g. "dead letter" logs DeadLetterRepository.Create with a dead_letter_id.
+func NewRepository[T models.Entity](db *gorm.DB, logger *slog.Logger, name string) *Repository[T] {
+	return &Repository[T]{
+		db:     db,
+		logger: logger,
+		op:     logging.TypeName(name, "Repository"),
+		idKey:  strings.ReplaceAll(name, " ", "_") + "_id",
+	}
+}
+
+func (r *Repository[T]) Create(ctx context.Context, entity *T) error {
+	return logging.Call(ctx, r.logger, r.op+".Create", func() error {
+		return r.db.WithContext(ctx).Create(entity).Error
+	}, slog.String(r.idKey, (*entity).PrimaryKey().String()))
+}
+
+func (r *Repository[T]) GetByID(ctx context.Context, id uuid.UUID) (*T, error) {
+	return logging.Call1(ctx, r.logger, r.op+".GetByID", func() (*T, error) {
+		var entity T
+		if err := r.db.WithContext(ctx).First(&entity, "id = ?", id).Error; err != nil {
+			return nil, err
+		}
+		return &entity, nil
+	}, slog.String(r.idKey, id.String()))
+}
+
+// Update saves every field of entity. It returns gorm.ErrRecordNotFound when the
+// row does not exist instead of inserting it.
+func (r *Repository[T]) Update(ctx context.Context, entity *T) error {
+	return logging.Call(ctx, r.logger, r.op+".Update", func() error {
+		result := r.db.WithContext(ctx).Select("*").Updates(entity)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	}, slog.String(r.idKey, (*entity).PrimaryKey().String()))
+}
+
+func (r *Repository[T]) Delete(ctx context.Context, id uuid.UUID) error {
+	return logging.Call(ctx, r.logger, r.op+".Delete", func() error {
+		result := r.db.WithContext(ctx).Delete(new(T), "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	}, slog.String(r.idKey, id.String()))
+}
+
+// List returns a page of entities ordered by ID, so pages are stable.
+func (r *Repository[T]) List(ctx context.Context, page models.Page) ([]T, error) {
+	return logging.Call1(ctx, r.logger, r.op+".List", func() ([]T, error) {
+		query := r.db.WithContext(ctx).Order("id").Offset(page.Offset)
+		if page.Limit > 0 {
+			query = query.Limit(page.Limit)
+		}
+
+		var entities []T
+		if err := query.Find(&entities).Error; err != nil {
+			return nil, err
+		}
+		return entities, nil
+	}, slog.Int("limit", page.Limit), slog.Int("offset", page.Offset))
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"awesomeProject1/internal/model"
+)
+
+type CustomFieldRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewCustomFieldRepository(db *gorm.DB, logger *slog.Logger) *CustomFieldRepository {
+	return &CustomFieldRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *CustomFieldRepository) List(ctx context.Context, tenant string) ([]models.CustomField, error) {
+	start := time.Now()
+	var fields []models.CustomField
+	err := r.db.WithContext(ctx).
+		Where("tenant = ?", tenant).
+		Order("name").
+		Find(&fields).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list custom fields from database",
+			slog.String("tenant", tenant),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func (r *CustomFieldRepository) Upsert(ctx context.Context, field *models.CustomField) error {
+	start := time.Now()
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(field).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to save custom field in database",
+			slog.String("tenant", field.Tenant),
+			slog.String("field", field.Name),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully saved custom field in database",
+		slog.String("tenant", field.Tenant),
+		slog.String("field", field.Name),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func (r *CustomFieldRepository) Delete(ctx context.Context, tenant string, name string) error {
+	start := time.Now()
+	result := r.db.WithContext(ctx).
+		Where("tenant = ? AND name = ?", tenant, name).
+		Delete(&models.CustomField{})
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete custom field from database",
+			slog.String("tenant", tenant),
+			slog.String("field", name),
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	r.logger.InfoContext(ctx, "Successfully deleted custom field from database",
+		slog.String("tenant", tenant),
+		slog.String("field", name),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
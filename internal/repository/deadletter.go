@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+type DeadLetterRepository struct {
+	*Repository[models.DeadLetter]
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewDeadLetterRepository(db *gorm.DB, logger *slog.Logger) *DeadLetterRepository {
+	return &DeadLetterRepository{
+		Repository: NewRepository[models.DeadLetter](db, logger, "dead letter"),
+		db:         db,
+		logger:     logger,
+	}
+}
+
+// List returns matching dead letters, oldest first, without their payloads.
+func (r *DeadLetterRepository) List(ctx context.Context, filter models.DeadLetterFilter) ([]models.DeadLetter, error) {
+	start := time.Now()
+	var letters []models.DeadLetter
+	query := deadLetterQuery(r.db.WithContext(ctx), filter).
+		Omit("payload").
+		Order("created_at")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if err := query.Find(&letters).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list dead letters from database",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.DebugContext(ctx, "Successfully retrieved dead letters from database",
+		slog.Int("count", len(letters)),
+		slog.Duration("duration", time.Since(start)))
+
+	return letters, nil
+}
+
+// Latest returns the most recently failed dead letters, without their payloads.
+func (r *DeadLetterRepository) Latest(ctx context.Context, limit int) ([]models.DeadLetter, error) {
+	var letters []models.DeadLetter
+	err := r.db.WithContext(ctx).
+		Omit("payload").
+		Order("last_attempt_at DESC").
+		Limit(limit).
+		Find(&letters).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list latest dead letters from database",
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return letters, nil
+}
+
+func (r *DeadLetterRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.DeadLetter{}).Count(&count).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to count dead letters in database",
+			slog.String("error", err.Error()))
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *DeadLetterRepository) UpdateAttempt(ctx context.Context, letter *models.DeadLetter) error {
+	err := r.db.WithContext(ctx).Model(letter).
+		Select("error", "attempts", "last_attempt_at").
+		Updates(letter).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to update dead letter in database",
+			slog.String("dead_letter_id", letter.ID.String()),
+			slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// Purge deletes every dead letter matching filter. Limit is ignored.
+func (r *DeadLetterRepository) Purge(ctx context.Context, filter models.DeadLetterFilter) (int64, error) {
+	start := time.Now()
+	result := deadLetterQuery(r.db.WithContext(ctx), filter).
+		Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Delete(&models.DeadLetter{})
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to purge dead letters from database",
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return 0, result.Error
+	}
+
+	r.logger.InfoContext(ctx, "Successfully purged dead letters from database",
+		slog.Int64("count", result.RowsAffected),
+		slog.Duration("duration", time.Since(start)))
+
+	return result.RowsAffected, nil
+}
+
+func deadLetterQuery(db *gorm.DB, filter models.DeadLetterFilter) *gorm.DB {
+	if filter.Sink != "" {
+		db = db.Where("sink = ?", filter.Sink)
+	}
+	if filter.EventType != "" {
+		db = db.Where("event_type = ?", filter.EventType)
+	}
+	if filter.Before != nil {
+		db = db.Where("created_at < ?", *filter.Before)
+	}
+	return db
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+// appendEventsSQL records the current state of every subscription matched by the
+// appended WHERE clause as the next event of its stream. The alias s refers to the
+// subscriptions row.
+const appendEventsSQL = `
+INSERT INTO subscription_events (subscription_id, version, type, state, actor)
+SELECT s.id,
+       COALESCE((SELECT max(e.version) FROM subscription_events e WHERE e.subscription_id = s.id), 0) + 1,
+       ?, to_jsonb(s), ?
+FROM subscriptions s
+WHERE `
+
+// projectSQL upserts the latest state of every stream that was not purged.
+const projectSQL = `
+INSERT INTO subscriptions (id, service_name, price, user_id, kind, billing_period, billing_anchor_day, start_date, end_date, status, category, custom_fields, deleted_at)
+SELECT p.id, p.service_name, p.price, p.user_id, p.kind, p.billing_period, p.billing_anchor_day, p.start_date, p.end_date, COALESCE(p.status, 'active'), p.category, p.custom_fields, p.deleted_at
+FROM (
+    SELECT DISTINCT ON (subscription_id) subscription_id, type, state
+    FROM subscription_events
+    ORDER BY subscription_id, version DESC
+) latest
+CROSS JOIN LATERAL jsonb_populate_record(NULL::subscriptions, latest.state) p
+WHERE latest.type <> 'purged'
+ON CONFLICT (id) DO UPDATE SET
+    service_name = EXCLUDED.service_name,
+    price = EXCLUDED.price,
+    user_id = EXCLUDED.user_id,
+    kind = EXCLUDED.kind,
+    billing_period = EXCLUDED.billing_period,
+    billing_anchor_day = EXCLUDED.billing_anchor_day,
+    start_date = EXCLUDED.start_date,
+    end_date = EXCLUDED.end_date,
+    status = EXCLUDED.status,
+    category = EXCLUDED.category,
+    custom_fields = EXCLUDED.custom_fields,
+    deleted_at = EXCLUDED.deleted_at`
+
+// removePurgedSQL deletes rows whose stream ends with a purge.
+const removePurgedSQL = `
+DELETE FROM subscriptions
+WHERE id IN (
+    SELECT subscription_id FROM (
+        SELECT DISTINCT ON (subscription_id) subscription_id, type
+        FROM subscription_events
+        ORDER BY subscription_id, version DESC
+    ) latest
+    WHERE latest.type = 'purged'
+)`
+
+// appendEvents is a no-op unless event sourcing is enabled. It must run inside the
+// transaction of the mutation it records; purges call it before deleting the rows.
+func (r *SubscriptionRepository) appendEvents(tx *gorm.DB, eventType string, where string, args ...any) error {
+	if !r.eventSourced {
+		return nil
+	}
+	actor := identity.FromContext(tx.Statement.Context).Actor
+	return tx.Exec(appendEventsSQL+where, append([]any{eventType, actor}, args...)...).Error
+}
+
+// EventStoreRepository maintains the subscription_events streams outside of regular
+// writes: seeding them from the current table and rebuilding the table from them.
+type EventStoreRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewEventStoreRepository(db *gorm.DB, logger *slog.Logger) *EventStoreRepository {
+	return &EventStoreRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Snapshot starts a stream for every subscription that does not have one yet,
+// including trashed ones. It is needed once when enabling event sourcing on
+// existing data.
+func (r *EventStoreRepository) Snapshot(ctx context.Context) (int64, error) {
+	start := time.Now()
+	result := r.db.WithContext(ctx).Exec(appendEventsSQL+missingStreamSQL,
+		models.SubscriptionSnapshot, "system")
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to snapshot subscriptions into event streams",
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return 0, result.Error
+	}
+
+	r.logger.InfoContext(ctx, "Successfully snapshotted subscriptions into event streams",
+		slog.Int64("count", result.RowsAffected),
+		slog.Duration("duration", time.Since(start)))
+
+	return result.RowsAffected, nil
+}
+
+// missingStreamSQL matches the subscriptions s without a stream.
+const missingStreamSQL = "NOT EXISTS (SELECT 1 FROM subscription_events e WHERE e.subscription_id = s.id)"
+
+// CountMissingStreams returns the number of subscriptions, trashed ones included, that
+// do not have a stream yet.
+func (r *EventStoreRepository) CountMissingStreams(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Raw("SELECT count(*) FROM subscriptions s WHERE " + missingStreamSQL).Scan(&count).Error
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to count subscriptions without event streams",
+			slog.String("error", err.Error()))
+		return 0, err
+	}
+	return count, nil
+}
+
+// ForEachMissingStreamBatch calls fn with the IDs of the subscriptions without a
+// stream, batchSize at a time in ID order. Each batch is read after fn returns for the
+// previous one, starting after its last ID, so streams started meanwhile are skipped.
+func (r *EventStoreRepository) ForEachMissingStreamBatch(ctx context.Context, batchSize int, fn func(ids []uuid.UUID) error) error {
+	var after uuid.UUID
+	for {
+		var ids []uuid.UUID
+		err := r.db.WithContext(ctx).Raw("SELECT s.id FROM subscriptions s WHERE s.id > ? AND "+missingStreamSQL+" ORDER BY s.id LIMIT ?",
+			after, batchSize).Scan(&ids).Error
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to scan subscriptions without event streams",
+				slog.String("after", after.String()),
+				slog.String("error", err.Error()))
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := fn(ids); err != nil {
+			return err
+		}
+		if len(ids) < batchSize {
+			return nil
+		}
+		after = ids[len(ids)-1]
+	}
+}
+
+// SnapshotBatch starts a stream for each of the subscriptions ids that does not have
+// one yet, like Snapshot.
+func (r *EventStoreRepository) SnapshotBatch(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	start := time.Now()
+	result := r.db.WithContext(ctx).Exec(appendEventsSQL+"s.id IN ? AND "+missingStreamSQL,
+		models.SubscriptionSnapshot, "system", ids)
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to snapshot subscription batch into event streams",
+			slog.Int("batch_size", len(ids)),
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return 0, result.Error
+	}
+
+	r.logger.DebugContext(ctx, "Snapshotted subscription batch into event streams",
+		slog.Int("batch_size", len(ids)),
+		slog.Int64("count", result.RowsAffected),
+		slog.Duration("duration", time.Since(start)))
+
+	return result.RowsAffected, nil
+}
+
+// Rebuild regenerates the subscriptions table from the event streams in one
+// transaction. Rows without a stream are left untouched.
+func (r *EventStoreRepository) Rebuild(ctx context.Context) (projected int64, removed int64, err error) {
+	start := time.Now()
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(projectSQL)
+		if result.Error != nil {
+			return result.Error
+		}
+		projected = result.RowsAffected
+
+		result = tx.Exec(removePurgedSQL)
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to rebuild subscriptions from event streams",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return 0, 0, err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully rebuilt subscriptions from event streams",
+		slog.Int64("projected", projected),
+		slog.Int64("removed", removed),
+		slog.Duration("duration", time.Since(start)))
+
+	return projected, removed, nil
+}
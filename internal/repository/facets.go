@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+// facetSize is the number of values returned per facet.
+const facetSize = 20
+
+// facetSQL counts the rows of the subquery m in all, under the empty facet, and by each
+// of its columns in a single pass, keeping the facetSize most frequent values of each.
+const facetSQL = `
+SELECT facet, value, count FROM (
+    SELECT facet, value, count, row_number() OVER (PARTITION BY facet ORDER BY count DESC, value) AS rank
+    FROM (
+        SELECT CASE
+                   WHEN GROUPING(m.kind) = 0 THEN 'kind'
+                   WHEN GROUPING(m.category) = 0 THEN 'category'
+                   WHEN GROUPING(m.status) = 0 THEN 'status'
+                   WHEN GROUPING(m.service_name) = 0 THEN 'service_name'
+                   WHEN GROUPING(m.price) = 0 THEN 'price'
+                   ELSE ''
+               END AS facet,
+               COALESCE(m.kind, m.category, m.status, m.service_name, m.price) AS value,
+               count(*) AS count
+        FROM (?) m
+        GROUP BY GROUPING SETS ((), (m.kind), (m.category), (m.status), (m.service_name), (m.price))
+    ) g
+) f
+WHERE facet = '' OR (value IS NOT NULL AND rank <= ?)`
+
+// summarizeFacets counts the subscriptions matched by the subscriptions query matches
+// by facet with one statement run on db.
+func summarizeFacets(db *gorm.DB, matches *gorm.DB) (*models.FacetSummary, error) {
+	columns := matches.Select("kind, " + categoryExpr("subscriptions") + " AS category, status, service_name, " + priceBucketExpr() + " AS price")
+
+	var rows []struct {
+		Facet string
+		Value *string
+		Count int64
+	}
+	if err := db.Raw(facetSQL, columns, facetSize).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	summary := &models.FacetSummary{Facets: map[string][]models.FacetCount{
+		"kind":            {},
+		"category":        {},
+		"status":          {},
+		"service_name":    {},
+		models.PriceFacet: {},
+	}}
+	for _, row := range rows {
+		if row.Facet == "" {
+			summary.Total = row.Count
+			continue
+		}
+		summary.Facets[row.Facet] = append(summary.Facets[row.Facet], models.FacetCount{Value: *row.Value, Count: row.Count})
+	}
+	slices.SortFunc(summary.Facets[models.PriceFacet], func(a, b models.FacetCount) int {
+		return priceBucketIndex(a.Value) - priceBucketIndex(b.Value)
+	})
+	return summary, nil
+}
+
+// priceBucketExpr maps the price of a subscription to the key of its price bucket.
+func priceBucketExpr() string {
+	var expr strings.Builder
+	expr.WriteString("CASE")
+	for _, bucket := range models.PriceBuckets {
+		if bucket.To == 0 {
+			fmt.Fprintf(&expr, " ELSE '%s'", bucket.Key)
+			continue
+		}
+		fmt.Fprintf(&expr, " WHEN price < %d THEN '%s'", bucket.To, bucket.Key)
+	}
+	expr.WriteString(" END")
+	return expr.String()
+}
+
+func priceBucketIndex(key string) int {
+	return slices.IndexFunc(models.PriceBuckets, func(bucket models.PriceBucket) bool {
+		return bucket.Key == key
+	})
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"awesomeProject1/internal/model"
+)
+
+type faultInjector interface {
+	Inject(ctx context.Context, operation string) error
+}
+
+// FaultInjectingSubscriptionRepository delays or fails calls to the wrapped repository
+// as configured in faults, before they reach the database. It is only installed in
+// builds with fault injection, to exercise error handling end to end.
+type FaultInjectingSubscriptionRepository struct {
+	next   SubscriptionStore
+	faults faultInjector
+}
+
+func NewFaultInjectingSubscriptionRepository(next SubscriptionStore, faults faultInjector) *FaultInjectingSubscriptionRepository {
+	return &FaultInjectingSubscriptionRepository{
+		next:   next,
+		faults: faults,
+	}
+}
+
+func (r *FaultInjectingSubscriptionRepository) Create(ctx context.Context, sub *models.Subscription) error {
+	if err := r.faults.Inject(ctx, "Create"); err != nil {
+		return err
+	}
+	return r.next.Create(ctx, sub)
+}
+
+func (r *FaultInjectingSubscriptionRepository) CreateBatch(ctx context.Context, subs []models.Subscription) error {
+	if err := r.faults.Inject(ctx, "CreateBatch"); err != nil {
+		return err
+	}
+	return r.next.CreateBatch(ctx, subs)
+}
+
+func (r *FaultInjectingSubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	if err := r.faults.Inject(ctx, "GetByID"); err != nil {
+		return nil, err
+	}
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *FaultInjectingSubscriptionRepository) Update(ctx context.Context, sub *models.Subscription) error {
+	if err := r.faults.Inject(ctx, "Update"); err != nil {
+		return err
+	}
+	return r.next.Update(ctx, sub)
+}
+
+func (r *FaultInjectingSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.faults.Inject(ctx, "Delete"); err != nil {
+		return err
+	}
+	return r.next.Delete(ctx, id)
+}
+
+func (r *FaultInjectingSubscriptionRepository) Merge(ctx context.Context, merged *models.Subscription, absorbedIDs []uuid.UUID) error {
+	if err := r.faults.Inject(ctx, "Merge"); err != nil {
+		return err
+	}
+	return r.next.Merge(ctx, merged, absorbedIDs)
+}
+
+func (r *FaultInjectingSubscriptionRepository) Split(ctx context.Context, updated *models.Subscription, created *models.Subscription) error {
+	if err := r.faults.Inject(ctx, "Split"); err != nil {
+		return err
+	}
+	return r.next.Split(ctx, updated, created)
+}
+
+func (r *FaultInjectingSubscriptionRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	if err := r.faults.Inject(ctx, "GetDeletedByID"); err != nil {
+		return nil, err
+	}
+	return r.next.GetDeletedByID(ctx, id)
+}
+
+func (r *FaultInjectingSubscriptionRepository) Restore(ctx context.Context, id uuid.UUID, deletedAfter time.Time) error {
+	if err := r.faults.Inject(ctx, "Restore"); err != nil {
+		return err
+	}
+	return r.next.Restore(ctx, id, deletedAfter)
+}
+
+func (r *FaultInjectingSubscriptionRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	if err := r.faults.Inject(ctx, "PurgeDeleted"); err != nil {
+		return 0, err
+	}
+	return r.next.PurgeDeleted(ctx, deletedBefore)
+}
+
+func (r *FaultInjectingSubscriptionRepository) ActivateScheduled(ctx context.Context, startedBefore time.Time) ([]models.Subscription, error) {
+	if err := r.faults.Inject(ctx, "ActivateScheduled"); err != nil {
+		return nil, err
+	}
+	return r.next.ActivateScheduled(ctx, startedBefore)
+}
+
+func (r *FaultInjectingSubscriptionRepository) List(ctx context.Context, filter models.ListFilter, page models.Page) ([]models.Subscription, error) {
+	if err := r.faults.Inject(ctx, "List"); err != nil {
+		return nil, err
+	}
+	return r.next.List(ctx, filter, page)
+}
+
+func (r *FaultInjectingSubscriptionRepository) Count(ctx context.Context, filter models.ListFilter) (int64, error) {
+	if err := r.faults.Inject(ctx, "Count"); err != nil {
+		return 0, err
+	}
+	return r.next.Count(ctx, filter)
+}
+
+func (r *FaultInjectingSubscriptionRepository) Facets(ctx context.Context, filter models.ListFilter) (*models.FacetSummary, error) {
+	if err := r.faults.Inject(ctx, "Facets"); err != nil {
+		return nil, err
+	}
+	return r.next.Facets(ctx, filter)
+}
+
+func (r *FaultInjectingSubscriptionRepository) CountActive(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	if err := r.faults.Inject(ctx, "CountActive"); err != nil {
+		return 0, err
+	}
+	return r.next.CountActive(ctx, userID, since)
+}
+
+func (r *FaultInjectingSubscriptionRepository) ForEachBatch(ctx context.Context, batchSize int, fn func(subs []models.Subscription) error) error {
+	if err := r.faults.Inject(ctx, "ForEachBatch"); err != nil {
+		return err
+	}
+	return r.next.ForEachBatch(ctx, batchSize, fn)
+}
+
+func (r *FaultInjectingSubscriptionRepository) UpsertBatch(ctx context.Context, subs []models.Subscription) error {
+	if err := r.faults.Inject(ctx, "UpsertBatch"); err != nil {
+		return err
+	}
+	return r.next.UpsertBatch(ctx, subs)
+}
+
+func (r *FaultInjectingSubscriptionRepository) Aggregate(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter) (decimal.Decimal, error) {
+	if err := r.faults.Inject(ctx, "Aggregate"); err != nil {
+		return decimal.Zero, err
+	}
+	return r.next.Aggregate(ctx, start, end, mode, filter)
+}
+
+func (r *FaultInjectingSubscriptionRepository) AggregateByBucket(ctx context.Context, start time.Time, end time.Time, bucket string, mode string, filter models.AggregateFilter) ([]models.BucketTotal, error) {
+	if err := r.faults.Inject(ctx, "AggregateByBucket"); err != nil {
+		return nil, err
+	}
+	return r.next.AggregateByBucket(ctx, start, end, bucket, mode, filter)
+}
+
+func (r *FaultInjectingSubscriptionRepository) AggregateByCategory(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter) ([]models.CategoryTotal, error) {
+	if err := r.faults.Inject(ctx, "AggregateByCategory"); err != nil {
+		return nil, err
+	}
+	return r.next.AggregateByCategory(ctx, start, end, mode, filter)
+}
+
+func (r *FaultInjectingSubscriptionRepository) AggregateDetails(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter, limit int) ([]models.AggregateDetail, error) {
+	if err := r.faults.Inject(ctx, "AggregateDetails"); err != nil {
+		return nil, err
+	}
+	return r.next.AggregateDetails(ctx, start, end, mode, filter, limit)
+}
+
+func (r *FaultInjectingSubscriptionRepository) Stats(ctx context.Context, filter models.AggregateFilter) (*models.SubscriptionStats, error) {
+	if err := r.faults.Inject(ctx, "Stats"); err != nil {
+		return nil, err
+	}
+	return r.next.Stats(ctx, filter)
+}
+
+func (r *FaultInjectingSubscriptionRepository) Simulate(ctx context.Context, scenario models.Scenario, start time.Time, end time.Time, mode string, filter models.AggregateFilter) ([]models.BucketTotal, []models.BucketTotal, error) {
+	if err := r.faults.Inject(ctx, "Simulate"); err != nil {
+		return nil, nil, err
+	}
+	return r.next.Simulate(ctx, scenario, start, end, mode, filter)
+}
+
+func (r *FaultInjectingSubscriptionRepository) TopServices(ctx context.Context, start time.Time, end time.Time, limit int, filter models.AggregateFilter) ([]models.ServiceRanking, error) {
+	if err := r.faults.Inject(ctx, "TopServices"); err != nil {
+		return nil, err
+	}
+	return r.next.TopServices(ctx, start, end, limit, filter)
+}
+
+func (r *FaultInjectingSubscriptionRepository) ServiceSpend(ctx context.Context, start time.Time, end time.Time, filter models.AggregateFilter) ([]models.ServiceRanking, error) {
+	if err := r.faults.Inject(ctx, "ServiceSpend"); err != nil {
+		return nil, err
+	}
+	return r.next.ServiceSpend(ctx, start, end, filter)
+}
+
+func (r *FaultInjectingSubscriptionRepository) FindDuplicates(ctx context.Context, minSimilarity float64, limit int, filter models.AggregateFilter) ([]models.DuplicatePair, error) {
+	if err := r.faults.Inject(ctx, "FindDuplicates"); err != nil {
+		return nil, err
+	}
+	return r.next.FindDuplicates(ctx, minSimilarity, limit, filter)
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"awesomeProject1/internal/model"
+)
+
+type FormulaRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewFormulaRepository(db *gorm.DB, logger *slog.Logger) *FormulaRepository {
+	return &FormulaRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *FormulaRepository) List(ctx context.Context, tenant string) ([]models.AggregationFormula, error) {
+	start := time.Now()
+	var formulas []models.AggregationFormula
+	err := r.db.WithContext(ctx).
+		Where("tenant = ?", tenant).
+		Order("name").
+		Find(&formulas).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list aggregation formulas from database",
+			slog.String("tenant", tenant),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	return formulas, nil
+}
+
+func (r *FormulaRepository) Get(ctx context.Context, tenant string, name string) (*models.AggregationFormula, error) {
+	start := time.Now()
+	var formula models.AggregationFormula
+	err := r.db.WithContext(ctx).
+		Where("tenant = ? AND name = ?", tenant, name).
+		Take(&formula).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			r.logger.ErrorContext(ctx, "Failed to get aggregation formula from database",
+				slog.String("tenant", tenant),
+				slog.String("formula", name),
+				slog.String("error", err.Error()),
+				slog.Duration("duration", time.Since(start)))
+		}
+		return nil, err
+	}
+
+	return &formula, nil
+}
+
+func (r *FormulaRepository) Upsert(ctx context.Context, formula *models.AggregationFormula) error {
+	start := time.Now()
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(formula).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to save aggregation formula in database",
+			slog.String("tenant", formula.Tenant),
+			slog.String("formula", formula.Name),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully saved aggregation formula in database",
+		slog.String("tenant", formula.Tenant),
+		slog.String("formula", formula.Name),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func (r *FormulaRepository) Delete(ctx context.Context, tenant string, name string) error {
+	start := time.Now()
+	result := r.db.WithContext(ctx).
+		Where("tenant = ? AND name = ?", tenant, name).
+		Delete(&models.AggregationFormula{})
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete aggregation formula from database",
+			slog.String("tenant", tenant),
+			slog.String("formula", name),
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	r.logger.InfoContext(ctx, "Successfully deleted aggregation formula from database",
+		slog.String("tenant", tenant),
+		slog.String("formula", name),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
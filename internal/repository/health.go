@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"awesomeProject1/internal/model"
+)
+
+type HealthRepository struct {
+	db *gorm.DB
+}
+
+func NewHealthRepository(db *gorm.DB) *HealthRepository {
+	return &HealthRepository{db: db}
+}
+
+// Ping checks that the database accepts connections.
+func (r *HealthRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// tableStatsQuery reads the statistics of the user tables; reltuples is -1 for tables
+// never analyzed.
+const tableStatsQuery = `SELECT s.schemaname AS schema, s.relname AS "table",
+		GREATEST(c.reltuples, 0)::bigint AS estimated_rows,
+		s.n_live_tup AS live_tuples, s.n_dead_tup AS dead_tuples,
+		CASE WHEN s.n_live_tup + s.n_dead_tup > 0
+			THEN s.n_dead_tup::float8 / (s.n_live_tup + s.n_dead_tup) ELSE 0 END AS dead_ratio,
+		s.n_mod_since_analyze AS modified_since_analyze,
+		pg_table_size(s.relid) AS table_bytes,
+		pg_indexes_size(s.relid) AS index_bytes,
+		pg_total_relation_size(s.relid) AS total_bytes,
+		s.last_vacuum, s.last_autovacuum, s.last_analyze, s.last_autoanalyze
+	FROM pg_stat_user_tables s
+	JOIN pg_class c ON c.oid = s.relid`
+
+// DatabaseStats reads the size of the database and the statistics of every user table,
+// largest first. The sandbox schema's tables are listed separately from public's.
+func (r *HealthRepository) DatabaseStats(ctx context.Context) (*models.DatabaseStats, error) {
+	var stats models.DatabaseStats
+	db := r.db.WithContext(ctx)
+	if err := db.Raw("SELECT pg_database_size(current_database())").Scan(&stats.SizeBytes).Error; err != nil {
+		return nil, err
+	}
+
+	err := db.Raw(tableStatsQuery + " ORDER BY total_bytes DESC, s.schemaname, s.relname").Scan(&stats.Tables).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// TableStats reads the statistics of one table of the public schema.
+func (r *HealthRepository) TableStats(ctx context.Context, table string) (*models.TableStats, error) {
+	var stats []models.TableStats
+	err := r.db.WithContext(ctx).
+		Raw(tableStatsQuery+" WHERE s.schemaname = 'public' AND s.relname = ?", table).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &stats[0], nil
+}
+
+// Analyze refreshes the planner statistics of one table of the public schema.
+func (r *HealthRepository) Analyze(ctx context.Context, table string) error {
+	return r.db.WithContext(ctx).Exec("ANALYZE ?", clause.Table{Name: "public." + table}).Error
+}
+
+// ReplicationStatus reports whether the database is a standby in recovery and, if so,
+// how far its replay lags behind the primary. A standby that has replayed everything
+// it received reports no lag, however long ago the primary last wrote.
+func (r *HealthRepository) ReplicationStatus(ctx context.Context) (*models.ReplicationStatus, error) {
+	var status models.ReplicationStatus
+	err := r.db.WithContext(ctx).Raw(`SELECT pg_is_in_recovery() AS in_recovery,
+		CASE
+			WHEN NOT pg_is_in_recovery() THEN NULL
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+		END AS lag_seconds`).Scan(&status).Error
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Promote ends recovery on a standby, making it the primary, and waits up to wait for
+// it to finish. It reports false if the promotion did not finish in time.
+func (r *HealthRepository) Promote(ctx context.Context, wait time.Duration) (bool, error) {
+	var promoted bool
+	err := r.db.WithContext(ctx).
+		Raw("SELECT pg_promote(true, ?)", int(wait.Seconds())).
+		Scan(&promoted).Error
+	return promoted, err
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"awesomeProject1/internal/model"
+)
+
+type InstanceRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewInstanceRepository(db *gorm.DB, logger *slog.Logger) *InstanceRepository {
+	return &InstanceRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Heartbeat records instance as alive, inserting it on its first beat.
+func (r *InstanceRepository) Heartbeat(ctx context.Context, instance *models.Instance) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(instance).Error
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to record instance heartbeat in database",
+			slog.String("instance_id", instance.ID.String()),
+			slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// SeenSince returns the instances whose last heartbeat is not older than since,
+// oldest first.
+func (r *InstanceRepository) SeenSince(ctx context.Context, since time.Time) ([]models.Instance, error) {
+	var instances []models.Instance
+	err := r.db.WithContext(ctx).
+		Where("last_seen_at >= ?", since).
+		Order("started_at").
+		Find(&instances).Error
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list instances from database",
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+	return instances, nil
+}
+
+// PruneBefore deletes instances that have not sent a heartbeat since before.
+func (r *InstanceRepository) PruneBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("last_seen_at < ?", before).
+		Delete(&models.Instance{})
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to prune instances from database",
+			slog.String("error", result.Error.Error()))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *InstanceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&models.Instance{}, "id = ?", id).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete instance from database",
+			slog.String("instance_id", id.String()),
+			slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+type LakeSyncRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewLakeSyncRepository(db *gorm.DB, logger *slog.Logger) *LakeSyncRepository {
+	return &LakeSyncRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ForEachChangedBatch calls fn with the subscriptions, soft-deleted ones included,
+// whose updated_at is at or after since and before until.
+func (r *LakeSyncRepository) ForEachChangedBatch(ctx context.Context, since time.Time, until time.Time, batchSize int, fn func(changes []models.SubscriptionChange) error) error {
+	var changes []models.SubscriptionChange
+	return r.db.WithContext(ctx).Unscoped().
+		Table("subscriptions").
+		Where("updated_at >= ? AND updated_at < ?", since, until).
+		FindInBatches(&changes, batchSize, func(tx *gorm.DB, batch int) error {
+			return fn(changes)
+		}).Error
+}
+
+// LastSucceeded returns the last succeeded run of destination, or nil if there is none.
+func (r *LakeSyncRepository) LastSucceeded(ctx context.Context, destination string) (*models.LakeSyncRun, error) {
+	var run models.LakeSyncRun
+	err := r.db.WithContext(ctx).
+		Where("destination = ? AND status = ?", destination, models.LakeSyncSucceeded).
+		Order("until DESC").
+		First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to read last lake sync run from database",
+			slog.String("destination", destination),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *LakeSyncRepository) Start(ctx context.Context, run *models.LakeSyncRun) error {
+	if err := r.db.WithContext(ctx).Create(run).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to store lake sync run in database",
+			slog.String("destination", run.Destination),
+			slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// Finish stores the outcome of run.
+func (r *LakeSyncRepository) Finish(ctx context.Context, run *models.LakeSyncRun) error {
+	err := r.db.WithContext(ctx).Model(run).Updates(map[string]any{
+		"status":      run.Status,
+		"row_count":   run.RowCount,
+		"object_key":  run.ObjectKey,
+		"error":       run.Error,
+		"finished_at": run.FinishedAt,
+	}).Error
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to finish lake sync run in database",
+			slog.String("id", run.ID.String()),
+			slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// List returns the runs of destination, or of every destination if it is empty, most
+// recent first.
+func (r *LakeSyncRepository) List(ctx context.Context, destination string, limit int) ([]models.LakeSyncRun, error) {
+	start := time.Now()
+	query := r.db.WithContext(ctx).Order("started_at DESC, id").Limit(limit)
+	if destination != "" {
+		query = query.Where("destination = ?", destination)
+	}
+
+	var runs []models.LakeSyncRun
+	if err := query.Find(&runs).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list lake sync runs from database",
+			slog.String("destination", destination),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.DebugContext(ctx, "Successfully retrieved lake sync runs from database",
+		slog.Int("count", len(runs)),
+		slog.Duration("duration", time.Since(start)))
+
+	return runs, nil
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"awesomeProject1/internal/logging"
+	"awesomeProject1/internal/model"
+)
+
+// LoggingSubscriptionRepository logs every call to the wrapped repository.
+type LoggingSubscriptionRepository struct {
+	next   SubscriptionStore
+	logger *slog.Logger
+}
+
+// SubscriptionStore is implemented by SubscriptionRepository and by the
+// replacements tests and embedders install in its place.
+type SubscriptionStore interface {
+	Create(ctx context.Context, sub *models.Subscription) error
+	CreateBatch(ctx context.Context, subs []models.Subscription) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	Update(ctx context.Context, sub *models.Subscription) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	Merge(ctx context.Context, merged *models.Subscription, absorbedIDs []uuid.UUID) error
+	Split(ctx context.Context, updated *models.Subscription, created *models.Subscription) error
+	GetDeletedByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	Restore(ctx context.Context, id uuid.UUID, deletedAfter time.Time) error
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
+	ActivateScheduled(ctx context.Context, startedBefore time.Time) ([]models.Subscription, error)
+	List(ctx context.Context, filter models.ListFilter, page models.Page) ([]models.Subscription, error)
+	Count(ctx context.Context, filter models.ListFilter) (int64, error)
+	Facets(ctx context.Context, filter models.ListFilter) (*models.FacetSummary, error)
+	CountActive(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error)
+	ForEachBatch(ctx context.Context, batchSize int, fn func(subs []models.Subscription) error) error
+	UpsertBatch(ctx context.Context, subs []models.Subscription) error
+	Aggregate(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter) (decimal.Decimal, error)
+	AggregateByBucket(ctx context.Context, start time.Time, end time.Time, bucket string, mode string, filter models.AggregateFilter) ([]models.BucketTotal, error)
+	AggregateByCategory(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter) ([]models.CategoryTotal, error)
+	AggregateDetails(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter, limit int) ([]models.AggregateDetail, error)
+	Stats(ctx context.Context, filter models.AggregateFilter) (*models.SubscriptionStats, error)
+	Simulate(ctx context.Context, scenario models.Scenario, start time.Time, end time.Time, mode string, filter models.AggregateFilter) ([]models.BucketTotal, []models.BucketTotal, error)
+	TopServices(ctx context.Context, start time.Time, end time.Time, limit int, filter models.AggregateFilter) ([]models.ServiceRanking, error)
+	ServiceSpend(ctx context.Context, start time.Time, end time.Time, filter models.AggregateFilter) ([]models.ServiceRanking, error)
+	FindDuplicates(ctx context.Context, minSimilarity float64, limit int, filter models.AggregateFilter) ([]models.DuplicatePair, error)
+}
+
+func NewLoggingSubscriptionRepository(next SubscriptionStore, logger *slog.Logger) *LoggingSubscriptionRepository {
+	return &LoggingSubscriptionRepository{
+		next:   next,
+		logger: logger,
+	}
+}
+
+func (r *LoggingSubscriptionRepository) Create(ctx context.Context, sub *models.Subscription) error {
+	return logging.Call(ctx, r.logger, "SubscriptionRepository.Create", func() error {
+		return r.next.Create(ctx, sub)
+	}, slog.String("subscription_id", sub.ID.String()), slog.String("user_id", sub.UserID.String()))
+}
+
+func (r *LoggingSubscriptionRepository) CreateBatch(ctx context.Context, subs []models.Subscription) error {
+	return logging.Call(ctx, r.logger, "SubscriptionRepository.CreateBatch", func() error {
+		return r.next.CreateBatch(ctx, subs)
+	}, slog.Int("count", len(subs)))
+}
+
+func (r *LoggingSubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.GetByID", func() (*models.Subscription, error) {
+		return r.next.GetByID(ctx, id)
+	}, slog.String("subscription_id", id.String()))
+}
+
+func (r *LoggingSubscriptionRepository) Update(ctx context.Context, sub *models.Subscription) error {
+	return logging.Call(ctx, r.logger, "SubscriptionRepository.Update", func() error {
+		return r.next.Update(ctx, sub)
+	}, slog.String("subscription_id", sub.ID.String()))
+}
+
+func (r *LoggingSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return logging.Call(ctx, r.logger, "SubscriptionRepository.Delete", func() error {
+		return r.next.Delete(ctx, id)
+	}, slog.String("subscription_id", id.String()))
+}
+
+func (r *LoggingSubscriptionRepository) Merge(ctx context.Context, merged *models.Subscription, absorbedIDs []uuid.UUID) error {
+	return logging.Call(ctx, r.logger, "SubscriptionRepository.Merge", func() error {
+		return r.next.Merge(ctx, merged, absorbedIDs)
+	}, slog.String("subscription_id", merged.ID.String()), slog.Any("absorbed_ids", absorbedIDs))
+}
+
+func (r *LoggingSubscriptionRepository) Split(ctx context.Context, updated *models.Subscription, created *models.Subscription) error {
+	return logging.Call(ctx, r.logger, "SubscriptionRepository.Split", func() error {
+		return r.next.Split(ctx, updated, created)
+	}, slog.String("subscription_id", updated.ID.String()), slog.String("new_subscription_id", created.ID.String()))
+}
+
+func (r *LoggingSubscriptionRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.GetDeletedByID", func() (*models.Subscription, error) {
+		return r.next.GetDeletedByID(ctx, id)
+	}, slog.String("subscription_id", id.String()))
+}
+
+func (r *LoggingSubscriptionRepository) Restore(ctx context.Context, id uuid.UUID, deletedAfter time.Time) error {
+	return logging.Call(ctx, r.logger, "SubscriptionRepository.Restore", func() error {
+		return r.next.Restore(ctx, id, deletedAfter)
+	}, slog.String("subscription_id", id.String()), slog.Time("deleted_after", deletedAfter))
+}
+
+func (r *LoggingSubscriptionRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.PurgeDeleted", func() (int64, error) {
+		return r.next.PurgeDeleted(ctx, deletedBefore)
+	}, slog.Time("deleted_before", deletedBefore))
+}
+
+func (r *LoggingSubscriptionRepository) ActivateScheduled(ctx context.Context, startedBefore time.Time) ([]models.Subscription, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.ActivateScheduled", func() ([]models.Subscription, error) {
+		return r.next.ActivateScheduled(ctx, startedBefore)
+	}, slog.Time("started_before", startedBefore))
+}
+
+func (r *LoggingSubscriptionRepository) List(ctx context.Context, filter models.ListFilter, page models.Page) ([]models.Subscription, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.List", func() ([]models.Subscription, error) {
+		return r.next.List(ctx, filter, page)
+	}, slog.String("user_id", filter.UserID.String()), slog.String("service_name", filter.ServiceName),
+		slog.String("kind", filter.Kind), slog.Int("limit", page.Limit), slog.Int("offset", page.Offset))
+}
+
+func (r *LoggingSubscriptionRepository) Count(ctx context.Context, filter models.ListFilter) (int64, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.Count", func() (int64, error) {
+		return r.next.Count(ctx, filter)
+	}, slog.String("user_id", filter.UserID.String()), slog.String("service_name", filter.ServiceName),
+		slog.String("kind", filter.Kind))
+}
+
+func (r *LoggingSubscriptionRepository) Facets(ctx context.Context, filter models.ListFilter) (*models.FacetSummary, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.Facets", func() (*models.FacetSummary, error) {
+		return r.next.Facets(ctx, filter)
+	}, slog.String("user_id", filter.UserID.String()), slog.String("service_name", filter.ServiceName),
+		slog.String("kind", filter.Kind))
+}
+
+func (r *LoggingSubscriptionRepository) CountActive(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.CountActive", func() (int64, error) {
+		return r.next.CountActive(ctx, userID, since)
+	}, slog.String("user_id", userID.String()), slog.Time("since", since))
+}
+
+func (r *LoggingSubscriptionRepository) ForEachBatch(ctx context.Context, batchSize int, fn func(subs []models.Subscription) error) error {
+	return logging.Call(ctx, r.logger, "SubscriptionRepository.ForEachBatch", func() error {
+		return r.next.ForEachBatch(ctx, batchSize, fn)
+	}, slog.Int("batch_size", batchSize))
+}
+
+func (r *LoggingSubscriptionRepository) UpsertBatch(ctx context.Context, subs []models.Subscription) error {
+	return logging.Call(ctx, r.logger, "SubscriptionRepository.UpsertBatch", func() error {
+		return r.next.UpsertBatch(ctx, subs)
+	}, slog.Int("count", len(subs)))
+}
+
+func (r *LoggingSubscriptionRepository) Aggregate(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter) (decimal.Decimal, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.Aggregate", func() (decimal.Decimal, error) {
+		return r.next.Aggregate(ctx, start, end, mode, filter)
+	}, slog.Time("start_date", start), slog.Time("end_date", end), slog.String("mode", mode), slog.Any("filter", filter))
+}
+
+func (r *LoggingSubscriptionRepository) AggregateByBucket(ctx context.Context, start time.Time, end time.Time, bucket string, mode string, filter models.AggregateFilter) ([]models.BucketTotal, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.AggregateByBucket", func() ([]models.BucketTotal, error) {
+		return r.next.AggregateByBucket(ctx, start, end, bucket, mode, filter)
+	}, slog.Time("start_date", start), slog.Time("end_date", end), slog.String("bucket", bucket),
+		slog.String("mode", mode), slog.Any("filter", filter))
+}
+
+func (r *LoggingSubscriptionRepository) AggregateByCategory(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter) ([]models.CategoryTotal, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.AggregateByCategory", func() ([]models.CategoryTotal, error) {
+		return r.next.AggregateByCategory(ctx, start, end, mode, filter)
+	}, slog.Time("start_date", start), slog.Time("end_date", end), slog.String("mode", mode), slog.Any("filter", filter))
+}
+
+func (r *LoggingSubscriptionRepository) AggregateDetails(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter, limit int) ([]models.AggregateDetail, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.AggregateDetails", func() ([]models.AggregateDetail, error) {
+		return r.next.AggregateDetails(ctx, start, end, mode, filter, limit)
+	}, slog.Time("start_date", start), slog.Time("end_date", end), slog.String("mode", mode), slog.Any("filter", filter),
+		slog.Int("limit", limit))
+}
+
+func (r *LoggingSubscriptionRepository) Stats(ctx context.Context, filter models.AggregateFilter) (*models.SubscriptionStats, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.Stats", func() (*models.SubscriptionStats, error) {
+		return r.next.Stats(ctx, filter)
+	}, slog.Any("filter", filter))
+}
+
+func (r *LoggingSubscriptionRepository) Simulate(ctx context.Context, scenario models.Scenario, start time.Time, end time.Time, mode string, filter models.AggregateFilter) ([]models.BucketTotal, []models.BucketTotal, error) {
+	return logging.Call2(ctx, r.logger, "SubscriptionRepository.Simulate", func() ([]models.BucketTotal, []models.BucketTotal, error) {
+		return r.next.Simulate(ctx, scenario, start, end, mode, filter)
+	}, slog.Int("added", len(scenario.Added)), slog.Int("updated", len(scenario.Updated)), slog.Int("removed", len(scenario.Removed)),
+		slog.Time("start_date", start), slog.Time("end_date", end), slog.String("mode", mode), slog.Any("filter", filter))
+}
+
+func (r *LoggingSubscriptionRepository) TopServices(ctx context.Context, start time.Time, end time.Time, limit int, filter models.AggregateFilter) ([]models.ServiceRanking, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.TopServices", func() ([]models.ServiceRanking, error) {
+		return r.next.TopServices(ctx, start, end, limit, filter)
+	}, slog.Time("start_date", start), slog.Time("end_date", end), slog.Int("limit", limit), slog.Any("filter", filter))
+}
+
+func (r *LoggingSubscriptionRepository) ServiceSpend(ctx context.Context, start time.Time, end time.Time, filter models.AggregateFilter) ([]models.ServiceRanking, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.ServiceSpend", func() ([]models.ServiceRanking, error) {
+		return r.next.ServiceSpend(ctx, start, end, filter)
+	}, slog.Time("start_date", start), slog.Time("end_date", end), slog.Any("filter", filter))
+}
+
+func (r *LoggingSubscriptionRepository) FindDuplicates(ctx context.Context, minSimilarity float64, limit int, filter models.AggregateFilter) ([]models.DuplicatePair, error) {
+	return logging.Call1(ctx, r.logger, "SubscriptionRepository.FindDuplicates", func() ([]models.DuplicatePair, error) {
+		return r.next.FindDuplicates(ctx, minSimilarity, limit, filter)
+	}, slog.Float64("min_similarity", minSimilarity), slog.Int("limit", limit), slog.Any("filter", filter))
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+type MailRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewMailRepository(db *gorm.DB, logger *slog.Logger) *MailRepository {
+	return &MailRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *MailRepository) Create(ctx context.Context, delivery *models.MailDelivery) error {
+	start := time.Now()
+	err := r.db.WithContext(ctx).Create(delivery).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to queue mail delivery in database",
+			slog.String("template", delivery.Template),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	r.logger.DebugContext(ctx, "Successfully queued mail delivery in database",
+		slog.String("delivery_id", delivery.ID.String()),
+		slog.String("template", delivery.Template),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+// ListDue returns pending deliveries whose next attempt is due, oldest first.
+func (r *MailRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.MailDelivery, error) {
+	start := time.Now()
+	var deliveries []models.MailDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.MailPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&deliveries).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list due mail deliveries from database",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.DebugContext(ctx, "Successfully retrieved due mail deliveries from database",
+		slog.Int("count", len(deliveries)),
+		slog.Duration("duration", time.Since(start)))
+
+	return deliveries, nil
+}
+
+// UpdateAttempt persists the outcome of a delivery attempt.
+func (r *MailRepository) UpdateAttempt(ctx context.Context, delivery *models.MailDelivery) error {
+	err := r.db.WithContext(ctx).Model(delivery).
+		Select("status", "attempts", "last_error", "next_attempt_at", "sent_at").
+		Updates(delivery).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to update mail delivery in database",
+			slog.String("delivery_id", delivery.ID.String()),
+			slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *MailRepository) List(ctx context.Context, filter models.MailFilter) ([]models.MailDelivery, error) {
+	start := time.Now()
+	var deliveries []models.MailDelivery
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if err := query.Find(&deliveries).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list mail deliveries from database",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.DebugContext(ctx, "Successfully retrieved mail deliveries from database",
+		slog.Int("count", len(deliveries)),
+		slog.Duration("duration", time.Since(start)))
+
+	return deliveries, nil
+}
+
+// CountByStatus returns the number of deliveries in each status.
+func (r *MailRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).Model(&models.MailDelivery{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to count mail deliveries in database",
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
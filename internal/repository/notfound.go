@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/cache"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+// NotFoundCachingSubscriptionRepository remembers IDs GetByID did not find, so clients
+// probing random IDs are answered from memory instead of the database. Writes that
+// can make an ID appear forget it once they are done, even when they fail; rows written
+// by other replicas show up once the entry expires. IDs are remembered per tenant, as
+// the sandbox has rows of its own.
+type NotFoundCachingSubscriptionRepository struct {
+	SubscriptionStore
+	missing *cache.Cache[struct{}]
+}
+
+func NewNotFoundCachingSubscriptionRepository(next SubscriptionStore, missing *cache.Cache[struct{}]) *NotFoundCachingSubscriptionRepository {
+	return &NotFoundCachingSubscriptionRepository{
+		SubscriptionStore: next,
+		missing:           missing,
+	}
+}
+
+func (r *NotFoundCachingSubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	if _, ok := r.missing.Get(missingKey(ctx, id)); ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	sub, err := r.SubscriptionStore.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		r.missing.Set(missingKey(ctx, id), struct{}{})
+	}
+	return sub, err
+}
+
+func (r *NotFoundCachingSubscriptionRepository) Create(ctx context.Context, sub *models.Subscription) error {
+	err := r.SubscriptionStore.Create(ctx, sub)
+	r.missing.Delete(missingKey(ctx, sub.ID))
+	return err
+}
+
+func (r *NotFoundCachingSubscriptionRepository) CreateBatch(ctx context.Context, subs []models.Subscription) error {
+	err := r.SubscriptionStore.CreateBatch(ctx, subs)
+	for i := range subs {
+		r.missing.Delete(missingKey(ctx, subs[i].ID))
+	}
+	return err
+}
+
+func (r *NotFoundCachingSubscriptionRepository) Split(ctx context.Context, updated *models.Subscription, created *models.Subscription) error {
+	err := r.SubscriptionStore.Split(ctx, updated, created)
+	r.missing.Delete(missingKey(ctx, created.ID))
+	return err
+}
+
+func (r *NotFoundCachingSubscriptionRepository) Restore(ctx context.Context, id uuid.UUID, deletedAfter time.Time) error {
+	err := r.SubscriptionStore.Restore(ctx, id, deletedAfter)
+	r.missing.Delete(missingKey(ctx, id))
+	return err
+}
+
+func (r *NotFoundCachingSubscriptionRepository) UpsertBatch(ctx context.Context, subs []models.Subscription) error {
+	err := r.SubscriptionStore.UpsertBatch(ctx, subs)
+	for i := range subs {
+		r.missing.Delete(missingKey(ctx, subs[i].ID))
+	}
+	return err
+}
+
+func missingKey(ctx context.Context, id uuid.UUID) string {
+	return identity.FromContext(ctx).Tenant + "/" + id.String()
+}
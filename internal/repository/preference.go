@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"awesomeProject1/internal/model"
+)
+
+type PreferenceRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewPreferenceRepository(db *gorm.DB, logger *slog.Logger) *PreferenceRepository {
+	return &PreferenceRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Get returns gorm.ErrRecordNotFound when the user has no preferences.
+func (r *PreferenceRepository) Get(ctx context.Context, tenant string, userID uuid.UUID) (*models.UserPreferences, error) {
+	var prefs models.UserPreferences
+	err := r.db.WithContext(ctx).
+		Where("tenant = ? AND user_id = ?", tenant, userID).
+		Take(&prefs).Error
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// ListByUsers returns the preferences of those of userIDs that have any.
+func (r *PreferenceRepository) ListByUsers(ctx context.Context, tenant string, userIDs []uuid.UUID) ([]models.UserPreferences, error) {
+	start := time.Now()
+	var prefs []models.UserPreferences
+	err := r.db.WithContext(ctx).
+		Where("tenant = ? AND user_id IN ?", tenant, userIDs).
+		Find(&prefs).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list user preferences from database",
+			slog.String("tenant", tenant),
+			slog.Int("users", len(userIDs)),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	return prefs, nil
+}
+
+func (r *PreferenceRepository) Upsert(ctx context.Context, prefs *models.UserPreferences) error {
+	start := time.Now()
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(prefs).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to save user preferences in database",
+			slog.String("tenant", prefs.Tenant),
+			slog.String("user_id", prefs.UserID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully saved user preferences in database",
+		slog.String("tenant", prefs.Tenant),
+		slog.String("user_id", prefs.UserID.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func (r *PreferenceRepository) Delete(ctx context.Context, tenant string, userID uuid.UUID) error {
+	start := time.Now()
+	result := r.db.WithContext(ctx).
+		Where("tenant = ? AND user_id = ?", tenant, userID).
+		Delete(&models.UserPreferences{})
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete user preferences from database",
+			slog.String("tenant", tenant),
+			slog.String("user_id", userID.String()),
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	r.logger.InfoContext(ctx, "Successfully deleted user preferences from database",
+		slog.String("tenant", tenant),
+		slog.String("user_id", userID.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+type QuotaRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewQuotaRepository(db *gorm.DB, logger *slog.Logger) *QuotaRepository {
+	return &QuotaRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *QuotaRepository) Increment(ctx context.Context, scope string, subject string, period time.Time, requests int64, creates int64) (*models.QuotaUsage, error) {
+	start := time.Now()
+	var usage models.QuotaUsage
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO api_quota_usage (scope, subject, period, requests, creates)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (scope, subject, period) DO UPDATE
+		SET requests = api_quota_usage.requests + EXCLUDED.requests,
+		    creates = api_quota_usage.creates + EXCLUDED.creates
+		RETURNING scope, subject, period, requests, creates`,
+		scope, subject, period, requests, creates).Scan(&usage).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to increment quota usage in database",
+			slog.String("scope", scope),
+			slog.String("subject", subject),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.DebugContext(ctx, "Incremented quota usage in database",
+		slog.String("scope", scope),
+		slog.String("subject", subject),
+		slog.Int64("requests", usage.Requests),
+		slog.Int64("creates", usage.Creates),
+		slog.Duration("duration", time.Since(start)))
+
+	return &usage, nil
+}
+
+// ReserveCreates adds count creates to the usage of subject in period unless that takes
+// it over limit, zero meaning unlimited, checking and adding in one statement. ok is
+// false, and nothing is added, when it would.
+func (r *QuotaRepository) ReserveCreates(ctx context.Context, scope string, subject string, period time.Time, count int64, limit int64) (*models.QuotaUsage, bool, error) {
+	var usage []models.QuotaUsage
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO api_quota_usage AS u (scope, subject, period, requests, creates)
+		SELECT @scope, @subject, @period, 0, @count
+		WHERE @limit = 0 OR @count <= @limit
+		ON CONFLICT (scope, subject, period) DO UPDATE
+		SET creates = u.creates + EXCLUDED.creates
+		WHERE @limit = 0 OR u.creates + EXCLUDED.creates <= @limit
+		RETURNING scope, subject, period, requests, creates`,
+		map[string]any{"scope": scope, "subject": subject, "period": period, "count": count, "limit": limit}).
+		Scan(&usage).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to reserve creates in database",
+			slog.String("scope", scope),
+			slog.String("subject", subject),
+			slog.String("error", err.Error()))
+		return nil, false, err
+	}
+	if len(usage) == 0 {
+		return nil, false, nil
+	}
+	return &usage[0], true, nil
+}
+
+func (r *QuotaRepository) Get(ctx context.Context, scope string, subject string, period time.Time) (*models.QuotaUsage, error) {
+	start := time.Now()
+	var usage models.QuotaUsage
+	err := r.db.WithContext(ctx).
+		First(&usage, "scope = ? AND subject = ? AND period = ?", scope, subject, period).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.QuotaUsage{Scope: scope, Subject: subject, Period: period}, nil
+	}
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to retrieve quota usage from database",
+			slog.String("scope", scope),
+			slog.String("subject", subject),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
+// Top returns the subjects of scope with the most requests in period.
+func (r *QuotaRepository) Top(ctx context.Context, scope string, period time.Time, limit int) ([]models.QuotaUsage, error) {
+	var usage []models.QuotaUsage
+	err := r.db.WithContext(ctx).
+		Where("scope = ? AND period = ?", scope, period).
+		Order("requests DESC, subject").
+		Limit(limit).
+		Find(&usage).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list top quota usage from database",
+			slog.String("scope", scope),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB returns a transaction on the database of TEST_DATABASE_DSN whose search_path
+// starts with an empty schema holding the tables of migrations, rolled back when the
+// test ends. The test is skipped without TEST_DATABASE_DSN.
+func testDB(t *testing.T, migrations ...string) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to PostgreSQL: %v", err)
+	}
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("begin transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if err := tx.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	if err := tx.Exec("SET LOCAL search_path TO " + schema + ", public").Error; err != nil {
+		t.Fatalf("set search_path: %v", err)
+	}
+	for _, migration := range migrations {
+		sql, err := os.ReadFile("../../migrations/" + migration)
+		if err != nil {
+			t.Fatalf("read migration: %v", err)
+		}
+		if err := tx.Exec(string(sql)).Error; err != nil {
+			t.Fatalf("run migration %s: %v", migration, err)
+		}
+	}
+	return tx
+}
+
+func TestQuotaRepositoryReserveCreates(t *testing.T) {
+	db := testDB(t, "20250808110000_create_api_quota_usage_table.up.sql")
+	repo := NewQuotaRepository(db, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+	period := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+	// The steps run in order on the same usage rows.
+	steps := []struct {
+		name        string
+		subject     string
+		count       int64
+		limit       int64
+		wantOK      bool
+		wantCreates int64
+	}{
+		{name: "first reservation inserts the row", subject: "key:crm", count: 3, limit: 5, wantOK: true, wantCreates: 3},
+		{name: "reaching the limit", subject: "key:crm", count: 2, limit: 5, wantOK: true, wantCreates: 5},
+		{name: "going over the limit", subject: "key:crm", count: 1, limit: 5, wantOK: false, wantCreates: 5},
+		{name: "zero limit is unlimited", subject: "key:crm", count: 10, limit: 0, wantOK: true, wantCreates: 15},
+		{name: "first reservation over the limit inserts nothing", subject: "key:erp", count: 6, limit: 5, wantOK: false, wantCreates: 0},
+		{name: "first reservation within the limit", subject: "key:erp", count: 5, limit: 5, wantOK: true, wantCreates: 5},
+	}
+	for _, step := range steps {
+		usage, ok, err := repo.ReserveCreates(ctx, "key", step.subject, period, step.count, step.limit)
+		if err != nil {
+			t.Fatalf("%s: ReserveCreates: %v", step.name, err)
+		}
+		if ok != step.wantOK {
+			t.Errorf("%s: ok = %v, want %v", step.name, ok, step.wantOK)
+		}
+		if ok && usage.Creates != step.wantCreates {
+			t.Errorf("%s: returned creates = %d, want %d", step.name, usage.Creates, step.wantCreates)
+		}
+
+		stored, err := repo.Get(ctx, "key", step.subject, period)
+		if err != nil {
+			t.Fatalf("%s: Get: %v", step.name, err)
+		}
+		if stored.Creates != step.wantCreates || stored.Requests != 0 {
+			t.Errorf("%s: stored creates = %d, requests = %d, want %d and 0", step.name, stored.Creates, stored.Requests, step.wantCreates)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+type RecordingRepository struct {
+	*Repository[models.RecordedRequest]
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewRecordingRepository(db *gorm.DB, logger *slog.Logger) *RecordingRepository {
+	return &RecordingRepository{
+		Repository: NewRepository[models.RecordedRequest](db, logger, "recorded request"),
+		db:         db,
+		logger:     logger,
+	}
+}
+
+// List returns matching recorded requests in the order they were recorded, so they can
+// be replayed as is.
+func (r *RecordingRepository) List(ctx context.Context, filter models.RecordedRequestFilter) ([]models.RecordedRequest, error) {
+	start := time.Now()
+	var recorded []models.RecordedRequest
+	query := recordingQuery(r.db.WithContext(ctx), filter).Order("recorded_at")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if err := query.Find(&recorded).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list recorded requests from database",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.DebugContext(ctx, "Successfully retrieved recorded requests from database",
+		slog.Int("count", len(recorded)),
+		slog.Duration("duration", time.Since(start)))
+
+	return recorded, nil
+}
+
+// Purge deletes every recorded request matching filter. Limit is ignored.
+func (r *RecordingRepository) Purge(ctx context.Context, filter models.RecordedRequestFilter) (int64, error) {
+	start := time.Now()
+	result := recordingQuery(r.db.WithContext(ctx), filter).
+		Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Delete(&models.RecordedRequest{})
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to purge recorded requests from database",
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return 0, result.Error
+	}
+
+	r.logger.InfoContext(ctx, "Successfully purged recorded requests from database",
+		slog.Int64("count", result.RowsAffected),
+		slog.Duration("duration", time.Since(start)))
+
+	return result.RowsAffected, nil
+}
+
+func recordingQuery(db *gorm.DB, filter models.RecordedRequestFilter) *gorm.DB {
+	if filter.CaptureID != nil {
+		db = db.Where("capture_id = ?", *filter.CaptureID)
+	}
+	if filter.Principal != "" {
+		db = db.Where("principal = ?", filter.Principal)
+	}
+	if filter.Since != nil {
+		db = db.Where("recorded_at >= ?", *filter.Since)
+	}
+	return db
+}
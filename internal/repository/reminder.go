@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+type ReminderRepository struct {
+	*Repository[models.Reminder]
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewReminderRepository(db *gorm.DB, logger *slog.Logger) *ReminderRepository {
+	return &ReminderRepository{
+		Repository: NewRepository[models.Reminder](db, logger, "reminder"),
+		db:         db,
+		logger:     logger,
+	}
+}
+
+func (r *ReminderRepository) ListBySubscription(ctx context.Context, subscriptionID uuid.UUID) ([]models.Reminder, error) {
+	start := time.Now()
+	var reminders []models.Reminder
+	err := r.db.WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Order("created_at").
+		Find(&reminders).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list reminders from database",
+			slog.String("subscription_id", subscriptionID.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.DebugContext(ctx, "Successfully retrieved reminders from database",
+		slog.String("subscription_id", subscriptionID.String()),
+		slog.Int("count", len(reminders)),
+		slog.Duration("duration", time.Since(start)))
+
+	return reminders, nil
+}
+
+func (r *ReminderRepository) Delete(ctx context.Context, subscriptionID uuid.UUID, id uuid.UUID) error {
+	start := time.Now()
+	result := r.db.WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Delete(&models.Reminder{}, "id = ?", id)
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete reminder from database",
+			slog.String("reminder_id", id.String()),
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	r.logger.InfoContext(ctx, "Successfully deleted reminder from database",
+		slog.String("reminder_id", id.String()),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+// ListActive returns the reminders of live recurring subscriptions that have not
+// ended before the current month, with the subscription loaded.
+func (r *ReminderRepository) ListActive(ctx context.Context) ([]models.Reminder, error) {
+	start := time.Now()
+	var reminders []models.Reminder
+	err := r.db.WithContext(ctx).
+		InnerJoins("Subscription").
+		Where(`"Subscription".kind = ?`, models.KindRecurring).
+		Where(`("Subscription".end_date IS NULL OR "Subscription".end_date >= date_trunc('month', now()))`).
+		Find(&reminders).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list active reminders from database",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.DebugContext(ctx, "Successfully retrieved active reminders from database",
+		slog.Int("count", len(reminders)),
+		slog.Duration("duration", time.Since(start)))
+
+	return reminders, nil
+}
+
+func (r *ReminderRepository) MarkSent(ctx context.Context, id uuid.UUID, renewal time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.Reminder{}).
+		Where("id = ?", id).
+		Update("last_sent_for", renewal).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to mark reminder as sent in database",
+			slog.String("reminder_id", id.String()),
+			slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
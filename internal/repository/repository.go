@@ -2,213 +2,577 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
-	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/rounding"
 )
 
+// SubscriptionRepository holds the queries only; wrap it with
+// NewLoggingSubscriptionRepository to log its calls.
 type SubscriptionRepository struct {
-	db     *gorm.DB
-	logger *slog.Logger
+	db           *gorm.DB
+	eventSourced bool
+	rounding     rounding.Policy
 }
 
-func NewSubscriptionRepository(db *gorm.DB, logger *slog.Logger) *SubscriptionRepository {
+// NewSubscriptionRepository returns a repository whose writes, when eventSourced is
+// set, also append to the subscription_events streams in the same transaction. Its
+// normalized totals are rounded by policy.
+func NewSubscriptionRepository(db *gorm.DB, eventSourced bool, policy rounding.Policy) *SubscriptionRepository {
 	return &SubscriptionRepository{
-		db:     db,
-		logger: logger,
+		db:           db,
+		eventSourced: eventSourced,
+		rounding:     policy,
 	}
 }
 
 func (r *SubscriptionRepository) Create(ctx context.Context, sub *models.Subscription) error {
-	r.logger.InfoContext(ctx, "Creating new subscription in repository",
-		slog.String("subscription_id", sub.ID.String()),
-		slog.String("service_name", sub.ServiceName),
-		slog.String("user_id", sub.UserID.String()))
-
-	start := time.Now()
-	err := r.db.WithContext(ctx).Create(sub).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sub).Error; err != nil {
+			return err
+		}
+		return r.appendEvents(tx, models.SubscriptionCreated, "s.id = ?", sub.ID)
+	})
+}
 
-	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to create subscription in database",
-			slog.String("subscription_id", sub.ID.String()),
-			slog.String("error", err.Error()),
-			slog.Duration("duration", time.Since(start)))
-		return err
+// CreateBatch inserts subs in a single transaction, so that either all of them are
+// created or none is.
+func (r *SubscriptionRepository) CreateBatch(ctx context.Context, subs []models.Subscription) error {
+	ids := make([]uuid.UUID, len(subs))
+	for i, sub := range subs {
+		ids[i] = sub.ID
 	}
 
-	r.logger.InfoContext(ctx, "Successfully created subscription in database",
-		slog.String("subscription_id", sub.ID.String()),
-		slog.Duration("duration", time.Since(start)))
-
-	return nil
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&subs).Error; err != nil {
+			return err
+		}
+		return r.appendEvents(tx, models.SubscriptionCreated, "s.id IN ?", ids)
+	})
 }
 
 func (r *SubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
-	r.logger.InfoContext(ctx, "Retrieving subscription by ID from repository",
-		slog.String("subscription_id", id.String()))
-
-	start := time.Now()
 	var sub models.Subscription
-	err := r.db.WithContext(ctx).First(&sub, "id = ?", id).Error
-
-	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to retrieve subscription from database",
-			slog.String("subscription_id", id.String()),
-			slog.String("error", err.Error()),
-			slog.Duration("duration", time.Since(start)))
+	if err := r.db.WithContext(ctx).First(&sub, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
+	return &sub, nil
+}
 
-	r.logger.InfoContext(ctx, "Successfully retrieved subscription from database",
-		slog.String("subscription_id", id.String()),
-		slog.String("service_name", sub.ServiceName),
-		slog.Duration("duration", time.Since(start)))
+func (r *SubscriptionRepository) Update(ctx context.Context, sub *models.Subscription) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(sub).Error; err != nil {
+			return err
+		}
+		return r.appendEvents(tx, models.SubscriptionUpdated, "s.id = ?", sub.ID)
+	})
+}
 
+func (r *SubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Subscription{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return r.appendEvents(tx, models.SubscriptionDeleted, "s.id = ?", id)
+	})
+}
+
+// Merge saves the consolidated subscription and soft-deletes the absorbed ones in a
+// single transaction.
+func (r *SubscriptionRepository) Merge(ctx context.Context, merged *models.Subscription, absorbedIDs []uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(merged).Error; err != nil {
+			return err
+		}
+		if err := r.appendEvents(tx, models.SubscriptionUpdated, "s.id = ?", merged.ID); err != nil {
+			return err
+		}
+
+		result := tx.Delete(&models.Subscription{}, "id IN ?", absorbedIDs)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(absorbedIDs)) {
+			return gorm.ErrRecordNotFound
+		}
+		return r.appendEvents(tx, models.SubscriptionDeleted, "s.id IN ?", absorbedIDs)
+	})
+}
+
+func (r *SubscriptionRepository) Split(ctx context.Context, updated *models.Subscription, created *models.Subscription) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Save(updated)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := r.appendEvents(tx, models.SubscriptionUpdated, "s.id = ?", updated.ID); err != nil {
+			return err
+		}
+		if err := tx.Create(created).Error; err != nil {
+			return err
+		}
+		return r.appendEvents(tx, models.SubscriptionCreated, "s.id = ?", created.ID)
+	})
+}
+
+func (r *SubscriptionRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	var sub models.Subscription
+	if err := r.db.WithContext(ctx).Unscoped().First(&sub, "id = ? AND deleted_at IS NOT NULL", id).Error; err != nil {
+		return nil, err
+	}
 	return &sub, nil
 }
 
-func (r *SubscriptionRepository) Update(ctx context.Context, sub *models.Subscription) error {
-	r.logger.InfoContext(ctx, "Updating subscription in repository",
-		slog.String("subscription_id", sub.ID.String()),
-		slog.String("service_name", sub.ServiceName))
+func (r *SubscriptionRepository) Restore(ctx context.Context, id uuid.UUID, deletedAfter time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&models.Subscription{}).
+			Where("id = ? AND deleted_at IS NOT NULL AND deleted_at > ?", id, deletedAfter).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return r.appendEvents(tx, models.SubscriptionRestored, "s.id = ?", id)
+	})
+}
 
-	start := time.Now()
-	err := r.db.WithContext(ctx).Save(sub).Error
+func (r *SubscriptionRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The purged events must be appended while the rows still exist.
+		if err := r.appendEvents(tx, models.SubscriptionPurged,
+			"s.deleted_at IS NOT NULL AND s.deleted_at <= ?", deletedBefore); err != nil {
+			return err
+		}
+		result := tx.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at <= ?", deletedBefore).
+			Delete(&models.Subscription{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
 
+// ActivateScheduled activates the scheduled subscriptions that start before
+// startedBefore and returns them as activated. Trashed ones stay scheduled until they
+// are restored.
+func (r *SubscriptionRepository) ActivateScheduled(ctx context.Context, startedBefore time.Time) ([]models.Subscription, error) {
+	var activated []models.Subscription
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&activated).Clauses(clause.Returning{}).
+			Where("status = ? AND start_date < ?", models.StatusScheduled, startedBefore).
+			Update("status", models.StatusActive).Error
+		if err != nil || len(activated) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(activated))
+		for i, sub := range activated {
+			ids[i] = sub.ID
+		}
+		return r.appendEvents(tx, models.SubscriptionActivated, "s.id IN ?", ids)
+	})
 	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to update subscription in database",
-			slog.String("subscription_id", sub.ID.String()),
-			slog.String("error", err.Error()),
-			slog.Duration("duration", time.Since(start)))
-		return err
+		return nil, err
 	}
+	return activated, nil
+}
 
-	r.logger.InfoContext(ctx, "Successfully updated subscription in database",
-		slog.String("subscription_id", sub.ID.String()),
-		slog.Duration("duration", time.Since(start)))
+func (r *SubscriptionRepository) List(ctx context.Context, filter models.ListFilter, page models.Page) ([]models.Subscription, error) {
+	query, err := r.listQuery(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(page.OrderBy) > 0 {
+		for _, order := range page.OrderBy {
+			column, ok := listColumns[order.Field]
+			if !ok {
+				return nil, fmt.Errorf("unknown list field %q", order.Field)
+			}
+			if order.Desc {
+				column += " DESC"
+			}
+			query = query.Order(column)
+		}
+		query = query.Order("id")
+	} else if page.Limit > 0 {
+		query = query.Order("start_date, id")
+	}
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit).Offset(page.Offset)
+	}
 
-	return nil
+	var subs []models.Subscription
+	if err := query.Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
 }
 
-func (r *SubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	r.logger.InfoContext(ctx, "Deleting subscription from repository",
-		slog.String("subscription_id", id.String()))
+// Count counts the subscriptions List returns for filter without a page.
+func (r *SubscriptionRepository) Count(ctx context.Context, filter models.ListFilter) (int64, error) {
+	query, err := r.listQuery(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
 
-	start := time.Now()
-	err := r.db.WithContext(ctx).Delete(&models.Subscription{}, "id = ?", id).Error
+	var count int64
+	if err := query.Model(&models.Subscription{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
 
+// Facets counts the subscriptions List returns for filter without a page, in all and
+// by facet, in one query.
+func (r *SubscriptionRepository) Facets(ctx context.Context, filter models.ListFilter) (*models.FacetSummary, error) {
+	query, err := r.listQuery(ctx, filter)
 	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to delete subscription from database",
-			slog.String("subscription_id", id.String()),
-			slog.String("error", err.Error()),
-			slog.Duration("duration", time.Since(start)))
-		return err
+		return nil, err
 	}
+	return summarizeFacets(r.db.WithContext(ctx), query.Model(&models.Subscription{}))
+}
 
-	r.logger.InfoContext(ctx, "Successfully deleted subscription from database",
-		slog.String("subscription_id", id.String()),
-		slog.Duration("duration", time.Since(start)))
+// CountActive counts the subscriptions of the user that have not ended before since,
+// scheduled ones included.
+func (r *SubscriptionRepository) CountActive(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Subscription{}).
+		Where("user_id = ? AND (end_date IS NULL OR end_date >= ?)", userID, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
 
-	return nil
+// listColumns maps the fields Conditions and Orders name to their SQL expressions.
+var listColumns = map[string]string{
+	"id":                 "id",
+	"service_name":       "service_name",
+	"price":              "price",
+	"user_id":            "user_id",
+	"kind":               "kind",
+	"billing_period":     "billing_period",
+	"billing_anchor_day": "billing_anchor_day",
+	"start_date":         "start_date",
+	"end_date":           "end_date",
+	"status":             "status",
+	"category":           categoryExpr("subscriptions"),
 }
 
-func (r *SubscriptionRepository) List(ctx context.Context, userID uuid.UUID, serviceName string) ([]models.Subscription, error) {
-	r.logger.InfoContext(ctx, "Listing subscriptions from repository",
-		slog.String("user_id", userID.String()),
-		slog.String("service_name", serviceName))
+var conditionOperators = map[string]string{
+	models.OpEq: "=",
+	models.OpNe: "<>",
+	models.OpGt: ">",
+	models.OpGe: ">=",
+	models.OpLt: "<",
+	models.OpLe: "<=",
+}
 
-	start := time.Now()
-	var subs []models.Subscription
+func (r *SubscriptionRepository) listQuery(ctx context.Context, filter models.ListFilter) (*gorm.DB, error) {
 	query := r.db.WithContext(ctx)
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ServiceName != "" {
+		query = query.Where("service_name = ?", filter.ServiceName)
+	}
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+	if filter.Category != "" {
+		query = query.Where(categoryExpr("subscriptions")+" = ?", filter.Category)
+	}
+	if len(filter.CustomFields) > 0 {
+		match, err := json.Marshal(filter.CustomFields)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("custom_fields @> ?::jsonb", string(match))
+	}
+	for _, cond := range filter.Conditions {
+		column, ok := listColumns[cond.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown list field %q", cond.Field)
+		}
+		operator, ok := conditionOperators[cond.Op]
+		if !ok {
+			return nil, fmt.Errorf("unknown operator %q", cond.Op)
+		}
+		switch {
+		case cond.Value == nil && cond.Op == models.OpEq:
+			query = query.Where(column + " IS NULL")
+		case cond.Value == nil && cond.Op == models.OpNe:
+			query = query.Where(column + " IS NOT NULL")
+		case cond.Value == nil:
+			return nil, fmt.Errorf("operator %q cannot compare with null", cond.Op)
+		default:
+			query = query.Where(column+" "+operator+" ?", cond.Value)
+		}
+	}
+	return query, nil
+}
 
-	if userID != uuid.Nil {
-		query = query.Where("user_id = ?", userID)
-		r.logger.DebugContext(ctx, "Applied user_id filter", slog.String("user_id", userID.String()))
+func (r *SubscriptionRepository) ForEachBatch(ctx context.Context, batchSize int, fn func(subs []models.Subscription) error) error {
+	var subs []models.Subscription
+	return r.db.WithContext(ctx).Unscoped().FindInBatches(&subs, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(subs)
+	}).Error
+}
+
+func (r *SubscriptionRepository) UpsertBatch(ctx context.Context, subs []models.Subscription) error {
+	ids := make([]uuid.UUID, len(subs))
+	for i, sub := range subs {
+		ids[i] = sub.ID
 	}
 
-	if serviceName != "" {
-		query = query.Where("service_name = ?", serviceName)
-		r.logger.DebugContext(ctx, "Applied service_name filter", slog.String("service_name", serviceName))
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Clauses(clause.OnConflict{UpdateAll: true}).Create(&subs).Error; err != nil {
+			return err
+		}
+		return r.appendEvents(tx, models.SubscriptionImported, "s.id IN ?", ids)
+	})
+}
+
+func (r *SubscriptionRepository) Aggregate(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter) (decimal.Decimal, error) {
+	var row *sql.Row
+	if mode == models.AggregateExact {
+		cte, args := chargesCTE(start, end, filter)
+		row = r.db.WithContext(ctx).Raw(cte+" SELECT COALESCE(SUM(price), 0) FROM charges", args...).Row()
+	} else {
+		db := r.db.WithContext(ctx).Model(&models.Subscription{}).
+			Select("COALESCE("+r.rounding.SQL("SUM("+monthlyCostExpr("")+")")+", 0)").
+			Where(chargedInPeriodExpr, map[string]any{"start": start, "end": end})
+		row = applyAggregateFilter(db, filter).Row()
 	}
 
-	err := query.Find(&subs).Error
+	var total decimal.Decimal
+	if err := row.Scan(&total); err != nil {
+		return decimal.Zero, fmt.Errorf("aggregation query failed: %w", err)
+	}
+	return total, nil
+}
 
-	if err != nil {
-		r.logger.ErrorContext(ctx, "Failed to list subscriptions from database",
-			slog.String("user_id", userID.String()),
-			slog.String("service_name", serviceName),
-			slog.String("error", err.Error()),
-			slog.Duration("duration", time.Since(start)))
-		return nil, err
+// chargedInPeriodExpr matches subscriptions that cost something in [@start, @end]:
+// recurring ones active at any point of it and one-time purchases made within it.
+// Lifetime purchases and scheduled subscriptions never match.
+const chargedInPeriodExpr = `status = 'active' AND ((kind = 'recurring' AND start_date <= @end AND (end_date >= @start OR end_date IS NULL))
+	OR (kind = 'one_time' AND start_date BETWEEN @start AND @end))`
+
+var bucketIntervals = map[string]string{
+	models.BucketMonth:   "1 month",
+	models.BucketQuarter: "3 months",
+	models.BucketYear:    "1 year",
+}
+
+// AggregateByBucket sums the monthly costs of subscriptions active in each calendar bucket
+// overlapping [start, end], or in exact mode the charges due in each bucket. Buckets with
+// nothing to sum are returned with a zero total.
+func (r *SubscriptionRepository) AggregateByBucket(ctx context.Context, start time.Time, end time.Time, bucket string, mode string, filter models.AggregateFilter) ([]models.BucketTotal, error) {
+	interval, ok := bucketIntervals[bucket]
+	if !ok {
+		return nil, fmt.Errorf("unsupported bucket %q", bucket)
 	}
 
-	r.logger.InfoContext(ctx, "Successfully retrieved subscriptions list from database",
-		slog.String("user_id", userID.String()),
-		slog.String("service_name", serviceName),
-		slog.Int("count", len(subs)),
-		slog.Duration("duration", time.Since(start)))
+	if mode == models.AggregateExact {
+		return r.chargesByBucket(ctx, start, end, bucket, interval, filter)
+	}
 
-	return subs, nil
+	// One-time purchases only land in the bucket of their start month.
+	conditions := []string{
+		"s.deleted_at IS NULL",
+		"s.status = 'active'",
+		"s.start_date < b.bucket + ?::interval",
+		"s.start_date <= ?",
+		`((s.kind = 'recurring' AND (s.end_date >= b.bucket OR s.end_date IS NULL) AND (s.end_date >= ? OR s.end_date IS NULL))
+			OR (s.kind = 'one_time' AND s.start_date >= b.bucket AND s.start_date >= ?))`,
+	}
+	args := []any{bucket, start, end, interval, interval, end, start, start}
+
+	filterConditions, filterArgs := aggregateConditions("s.", filter)
+	conditions = append(conditions, filterConditions...)
+	args = append(args, filterArgs...)
+
+	query := `SELECT b.bucket AS start, COALESCE(` + r.rounding.SQL("SUM("+monthlyCostExpr("s.")+")") + `, 0) AS total
+		FROM generate_series(date_trunc(?, ?::timestamptz), ?::timestamptz, ?::interval) AS b(bucket)
+		LEFT JOIN subscriptions s ON ` + strings.Join(conditions, " AND ") + `
+		GROUP BY b.bucket
+		ORDER BY b.bucket`
+
+	var totals []models.BucketTotal
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("bucketed aggregation query failed: %w", err)
+	}
+	return totals, nil
 }
 
-func (r *SubscriptionRepository) Aggregate(ctx context.Context, start time.Time, end time.Time, userID *uuid.UUID, serviceName *string) (int, error) {
-	var userIDStr string
-	var serviceNameStr string
+// AggregateByCategory totals like Aggregate, per category. Categories without
+// subscriptions in the period are left out.
+func (r *SubscriptionRepository) AggregateByCategory(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter) ([]models.CategoryTotal, error) {
+	var totals []models.CategoryTotal
+	var err error
+	if mode == models.AggregateExact {
+		cte, args := chargesCTE(start, end, filter)
+		err = r.db.WithContext(ctx).Raw(cte+` SELECT category, SUM(price) AS total FROM charges
+			GROUP BY category
+			ORDER BY total DESC, category`, args...).Scan(&totals).Error
+	} else {
+		db := r.db.WithContext(ctx).Model(&models.Subscription{}).
+			Select(fmt.Sprintf("%s AS category, COALESCE(%s, 0) AS total", categoryExpr("subscriptions"), r.rounding.SQL("SUM("+monthlyCostExpr("")+")"))).
+			Where(chargedInPeriodExpr, map[string]any{"start": start, "end": end})
+		err = applyAggregateFilter(db, filter).Group("1").Order("total DESC, category").Scan(&totals).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("category aggregation query failed: %w", err)
+	}
+	return totals, nil
+}
 
-	if userID != nil {
-		userIDStr = userID.String()
+// AggregateDetails lists what each subscription contributes to Aggregate, largest
+// amount first, at most limit of them.
+func (r *SubscriptionRepository) AggregateDetails(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter, limit int) ([]models.AggregateDetail, error) {
+	var details []models.AggregateDetail
+	var err error
+	if mode == models.AggregateExact {
+		cte, args := chargesCTE(start, end, filter)
+		err = r.db.WithContext(ctx).Raw(cte+` SELECT subscription_id, user_id, service_name, SUM(price) AS amount, COUNT(*) AS charges
+			FROM charges
+			GROUP BY subscription_id, user_id, service_name
+			ORDER BY amount DESC, subscription_id
+			LIMIT ?`, append(args, limit)...).Scan(&details).Error
+	} else {
+		db := r.db.WithContext(ctx).Model(&models.Subscription{}).
+			Select("id AS subscription_id, user_id, service_name, "+monthlyCostExpr("")+"::numeric AS amount").
+			Where(chargedInPeriodExpr, map[string]any{"start": start, "end": end})
+		err = applyAggregateFilter(db, filter).Order("amount DESC, id").Limit(limit).Scan(&details).Error
 	}
-	if serviceName != nil {
-		serviceNameStr = *serviceName
+	if err != nil {
+		return nil, fmt.Errorf("aggregation details query failed: %w", err)
 	}
+	return details, nil
+}
 
-	r.logger.InfoContext(ctx, "Aggregating subscription costs from repository",
-		slog.Time("start_date", start),
-		slog.Time("end_date", end),
-		slog.String("user_id", userIDStr),
-		slog.String("service_name", serviceNameStr))
+// Months covered by a subscription, inclusive of its first and last month.
+const durationMonthsExpr = `(EXTRACT(YEAR FROM age(COALESCE(end_date, date_trunc('month', now())), start_date)) * 12 +
+	EXTRACT(MONTH FROM age(COALESCE(end_date, date_trunc('month', now())), start_date)) + 1)`
+
+func (r *SubscriptionRepository) Stats(ctx context.Context, filter models.AggregateFilter) (*models.SubscriptionStats, error) {
+	summary := func(expr string, prefix string) string {
+		return fmt.Sprintf(`COALESCE(MIN(%[1]s), 0) AS %[2]s_min,
+			COALESCE(MAX(%[1]s), 0) AS %[2]s_max,
+			COALESCE(AVG(%[1]s), 0) AS %[2]s_avg,
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY %[1]s), 0) AS %[2]s_median,
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY %[1]s), 0) AS %[2]s_p95`, expr, prefix)
+	}
 
-	queryStart := time.Now()
 	db := r.db.WithContext(ctx).Model(&models.Subscription{}).
-		Select("COALESCE(SUM(price), 0)").
-		Where("start_date <= ?", end).
-		Where("(end_date >= ? OR end_date IS NULL)", start)
-
-	if userID != nil {
-		db = db.Where("user_id = ?", *userID)
-		r.logger.DebugContext(ctx, "Applied user_id filter for aggregation", slog.String("user_id", userID.String()))
+		Select("COUNT(*) AS count, " + summary("price", "price") + ", " + summary(durationMonthsExpr, "duration"))
+	db = applyAggregateFilter(db, filter)
+
+	var row struct {
+		Count          int64
+		PriceMin       float64
+		PriceMax       float64
+		PriceAvg       float64
+		PriceMedian    float64
+		PriceP95       float64 `gorm:"column:price_p95"`
+		DurationMin    float64
+		DurationMax    float64
+		DurationAvg    float64
+		DurationMedian float64
+		DurationP95    float64 `gorm:"column:duration_p95"`
+	}
+	if err := db.Scan(&row).Error; err != nil {
+		return nil, fmt.Errorf("statistics query failed: %w", err)
 	}
 
-	if serviceName != nil {
-		db = db.Where("service_name = ?", *serviceName)
-		r.logger.DebugContext(ctx, "Applied service_name filter for aggregation", slog.String("service_name", *serviceName))
+	return &models.SubscriptionStats{
+		Count: row.Count,
+		Price: models.StatSummary{
+			Min:    row.PriceMin,
+			Max:    row.PriceMax,
+			Avg:    row.PriceAvg,
+			Median: row.PriceMedian,
+			P95:    row.PriceP95,
+		},
+		DurationMonths: models.StatSummary{
+			Min:    row.DurationMin,
+			Max:    row.DurationMax,
+			Avg:    row.DurationAvg,
+			Median: row.DurationMedian,
+			P95:    row.DurationP95,
+		},
+	}, nil
+}
+
+// aggregateConditions renders the filter as SQL conditions on the columns of the
+// subscriptions table aliased by prefix, for queries that are built by hand.
+func aggregateConditions(prefix string, filter models.AggregateFilter) ([]string, []any) {
+	var conditions []string
+	var args []any
+	if len(filter.UserIDs) > 0 {
+		conditions = append(conditions, prefix+"user_id IN ?")
+		args = append(args, filter.UserIDs)
+	}
+	if len(filter.ServiceNames) > 0 {
+		conditions = append(conditions, prefix+"service_name IN ?")
+		args = append(args, filter.ServiceNames)
+	}
+	if len(filter.ExcludeUserIDs) > 0 {
+		conditions = append(conditions, prefix+"user_id NOT IN ?")
+		args = append(args, filter.ExcludeUserIDs)
+	}
+	if len(filter.ExcludeServiceNames) > 0 {
+		conditions = append(conditions, prefix+"service_name NOT IN ?")
+		args = append(args, filter.ExcludeServiceNames)
+	}
+	if len(filter.Kinds) > 0 {
+		conditions = append(conditions, prefix+"kind IN ?")
+		args = append(args, filter.Kinds)
+	}
+	if len(filter.Categories) > 0 {
+		conditions = append(conditions, categoryExpr(aggregateTable(prefix))+" IN ?")
+		args = append(args, filter.Categories)
 	}
+	return conditions, args
+}
 
-	var total int
-	row := db.Row()
-	if err := row.Scan(&total); err != nil {
-		r.logger.ErrorContext(ctx, "Aggregation query failed",
-			slog.Time("start_date", start),
-			slog.Time("end_date", end),
-			slog.String("user_id", userIDStr),
-			slog.String("service_name", serviceNameStr),
-			slog.String("error", err.Error()),
-			slog.Duration("duration", time.Since(queryStart)))
-		return 0, fmt.Errorf("aggregation query failed: %w", err)
-	}
-
-	r.logger.InfoContext(ctx, "Successfully completed aggregation query",
-		slog.Time("start_date", start),
-		slog.Time("end_date", end),
-		slog.String("user_id", userIDStr),
-		slog.String("service_name", serviceNameStr),
-		slog.Int("total", total),
-		slog.Duration("duration", time.Since(queryStart)))
+// aggregateTable names the subscriptions table aliased by prefix, for conditions with
+// subqueries where unqualified columns would be ambiguous.
+func aggregateTable(prefix string) string {
+	if prefix == "" {
+		return "subscriptions"
+	}
+	return strings.TrimSuffix(prefix, ".")
+}
 
-	return total, nil
+func applyAggregateFilter(db *gorm.DB, filter models.AggregateFilter) *gorm.DB {
+	conditions, args := aggregateConditions("", filter)
+	for i, condition := range conditions {
+		db = db.Where(condition, args[i])
+	}
+	return db
 }
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sandboxTables are the sandbox copies wiped by Reset, truncated together.
+var sandboxTables = []string{
+	"sandbox.reminders",
+	"sandbox.audit_log",
+	"sandbox.subscription_events",
+	"sandbox.subscriptions",
+}
+
+// SandboxRepository maintains the sandbox schema. Its queries name the schema
+// explicitly, so they run on the production connections.
+type SandboxRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewSandboxRepository(db *gorm.DB, logger *slog.Logger) *SandboxRepository {
+	return &SandboxRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// LastReset returns when the sandbox was last wiped, or nil if it never was.
+func (r *SandboxRepository) LastReset(ctx context.Context) (*time.Time, error) {
+	var last sql.NullTime
+	if err := r.db.WithContext(ctx).Raw("SELECT max(reset_at) FROM sandbox.resets").Scan(&last).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to read last sandbox reset from database",
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	return &last.Time, nil
+}
+
+// Reset wipes every sandbox table and records the reset at at. It returns the number
+// of subscriptions removed.
+func (r *SandboxRepository) Reset(ctx context.Context, at time.Time) (int64, error) {
+	start := time.Now()
+	var removed int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT count(*) FROM sandbox.subscriptions").Scan(&removed).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("TRUNCATE " + strings.Join(sandboxTables, ", ")).Error; err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO sandbox.resets (reset_at, subscriptions) VALUES (?, ?)", at, removed).Error
+	})
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to reset sandbox in database",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return 0, err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully reset sandbox in database",
+		slog.Int64("subscriptions", removed),
+		slog.Duration("duration", time.Since(start)))
+
+	return removed, nil
+}
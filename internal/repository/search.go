@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"awesomeProject1/internal/model"
+)
+
+// searchSimilarity is the trigram similarity above which a service name matches a
+// search text it does not contain, to tolerate typos.
+const searchSimilarity = 0.3
+
+// SearchRepository searches subscriptions in the database when no search index is
+// configured, and reads them in batches to fill the index.
+type SearchRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewSearchRepository(db *gorm.DB, logger *slog.Logger) *SearchRepository {
+	return &SearchRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Search matches the service name against the text by substring or trigram similarity,
+// best matches first, and counts the matches by facet in the same filters.
+func (r *SearchRepository) Search(ctx context.Context, q models.SearchQuery) (*models.SearchResult, error) {
+	start := time.Now()
+	text := strings.ToLower(strings.TrimSpace(q.Text))
+
+	matches := func() *gorm.DB {
+		query := r.db.WithContext(ctx).Model(&models.Subscription{})
+		if text != "" {
+			query = query.Where("(lower(service_name) LIKE ? OR similarity(lower(service_name), ?) >= ?)",
+				"%"+escapeLike(text)+"%", text, searchSimilarity)
+		}
+		if q.UserID != uuid.Nil {
+			query = query.Where("user_id = ?", q.UserID)
+		}
+		if len(q.Kinds) > 0 {
+			query = query.Where("kind IN ?", q.Kinds)
+		}
+		if len(q.Categories) > 0 {
+			query = query.Where(categoryExpr("subscriptions")+" IN ?", q.Categories)
+		}
+		if len(q.Statuses) > 0 {
+			query = query.Where("status IN ?", q.Statuses)
+		}
+		if q.MinPrice != nil {
+			query = query.Where("price >= ?", *q.MinPrice)
+		}
+		if q.MaxPrice != nil {
+			query = query.Where("price <= ?", *q.MaxPrice)
+		}
+		return query
+	}
+
+	summary, err := summarizeFacets(r.db.WithContext(ctx), matches())
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to count subscription search facets",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	result := &models.SearchResult{
+		Subscriptions: []models.Subscription{},
+		Total:         summary.Total,
+		Facets:        summary.Facets,
+		Engine:        models.SearchEnginePostgres,
+	}
+	query := matches()
+	if text != "" {
+		query = query.Order(clause.Expr{SQL: "similarity(lower(service_name), ?) DESC", Vars: []any{text}})
+	}
+	query = query.Order("start_date, id").Limit(q.Page.Limit).Offset(q.Page.Offset)
+	if err := query.Find(&result.Subscriptions).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to search subscriptions",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.DebugContext(ctx, "Searched subscriptions",
+		slog.String("text", text),
+		slog.Int64("total", result.Total),
+		slog.Duration("duration", time.Since(start)))
+
+	return result, nil
+}
+
+// suggestSQL merges the spellings of the service names in the subquery u, which counts
+// the uses of each, with the catalog services matching the same pattern. Each name is
+// suggested once, under its most used spelling.
+const suggestSQL = `
+SELECT (array_agg(service_name ORDER BY uses DESC, service_name))[1] AS service_name, sum(uses)::bigint AS uses
+FROM (
+    SELECT name, service_name, uses FROM (?) u
+    UNION ALL
+    SELECT service_name, service_name, 0 FROM service_catalog WHERE service_name LIKE ?
+) s
+GROUP BY name
+ORDER BY uses DESC, name
+LIMIT ?`
+
+// Suggest returns up to limit service names starting with prefix, regardless of case,
+// most used first. A non-nil userID only counts the uses of that user.
+func (r *SearchRepository) Suggest(ctx context.Context, prefix string, userID uuid.UUID, limit int) ([]models.ServiceSuggestion, error) {
+	start := time.Now()
+	pattern := escapeLike(strings.ToLower(strings.TrimSpace(prefix))) + "%"
+
+	used := r.db.WithContext(ctx).Model(&models.Subscription{}).
+		Select("lower(service_name) AS name, service_name, count(*) AS uses").
+		Where("lower(service_name) LIKE ?", pattern).
+		Group("service_name")
+	if userID != uuid.Nil {
+		used = used.Where("user_id = ?", userID)
+	}
+
+	suggestions := []models.ServiceSuggestion{}
+	if err := r.db.WithContext(ctx).Raw(suggestSQL, used, pattern, limit).Scan(&suggestions).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to suggest service names",
+			slog.String("prefix", prefix),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.DebugContext(ctx, "Suggested service names",
+		slog.String("prefix", prefix),
+		slog.Int("count", len(suggestions)),
+		slog.Duration("duration", time.Since(start)))
+
+	return suggestions, nil
+}
+
+// CountIndexable returns the number of subscriptions outside the trash.
+func (r *SearchRepository) CountIndexable(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.Subscription{}).Count(&count).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to count subscriptions to index",
+			slog.String("error", err.Error()))
+		return 0, err
+	}
+	return count, nil
+}
+
+// ForEachIndexableBatch calls fn with the IDs of the subscriptions outside the trash,
+// batchSize at a time in ID order.
+func (r *SearchRepository) ForEachIndexableBatch(ctx context.Context, batchSize int, fn func(ids []uuid.UUID) error) error {
+	var after uuid.UUID
+	for {
+		var ids []uuid.UUID
+		err := r.db.WithContext(ctx).Model(&models.Subscription{}).
+			Where("id > ?", after).
+			Order("id").
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to scan subscriptions to index",
+				slog.String("after", after.String()),
+				slog.String("error", err.Error()))
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := fn(ids); err != nil {
+			return err
+		}
+		if len(ids) < batchSize {
+			return nil
+		}
+		after = ids[len(ids)-1]
+	}
+}
+
+// FindByIDs returns the subscriptions ids outside the trash, in no particular order.
+func (r *SearchRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Subscription, error) {
+	var subs []models.Subscription
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("load subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern with the default escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
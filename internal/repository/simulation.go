@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+// errRollback aborts the simulation transaction once both totals have been read.
+var errRollback = errors.New("rollback simulation")
+
+// Simulate returns the monthly totals matching filter in [start, end] as stored and as
+// they would be after scenario. The scenario is written inside a transaction that is
+// always rolled back, so both totals come from the same queries as AggregateByBucket
+// and nothing is persisted, appended to the event streams or announced to listeners.
+func (r *SubscriptionRepository) Simulate(ctx context.Context, scenario models.Scenario, start time.Time, end time.Time, mode string, filter models.AggregateFilter) ([]models.BucketTotal, []models.BucketTotal, error) {
+	var baseline, projected []models.BucketTotal
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sandbox := &SubscriptionRepository{db: tx, rounding: r.rounding}
+
+		var err error
+		if baseline, err = sandbox.AggregateByBucket(ctx, start, end, models.BucketMonth, mode, filter); err != nil {
+			return err
+		}
+
+		for i := range scenario.Added {
+			if err := tx.Create(&scenario.Added[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range scenario.Updated {
+			if err := tx.Save(&scenario.Updated[i]).Error; err != nil {
+				return err
+			}
+		}
+		if len(scenario.Removed) > 0 {
+			if err := tx.Delete(&models.Subscription{}, "id IN ?", scenario.Removed).Error; err != nil {
+				return err
+			}
+		}
+
+		if projected, err = sandbox.AggregateByBucket(ctx, start, end, models.BucketMonth, mode, filter); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		return nil, nil, err
+	}
+	return baseline, projected, nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"awesomeProject1/internal/model"
+)
+
+type TemplateRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewTemplateRepository(db *gorm.DB, logger *slog.Logger) *TemplateRepository {
+	return &TemplateRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *TemplateRepository) Get(ctx context.Context, tenant string, name string) (*models.TemplateOverride, error) {
+	var override models.TemplateOverride
+	err := r.db.WithContext(ctx).
+		Where("tenant = ? AND name = ?", tenant, name).
+		First(&override).Error
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+func (r *TemplateRepository) List(ctx context.Context, tenant string) ([]models.TemplateOverride, error) {
+	start := time.Now()
+	var overrides []models.TemplateOverride
+	err := r.db.WithContext(ctx).
+		Where("tenant = ?", tenant).
+		Order("name").
+		Find(&overrides).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list template overrides from database",
+			slog.String("tenant", tenant),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	return overrides, nil
+}
+
+func (r *TemplateRepository) Upsert(ctx context.Context, override *models.TemplateOverride) error {
+	start := time.Now()
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(override).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to save template override in database",
+			slog.String("tenant", override.Tenant),
+			slog.String("template", override.Name),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully saved template override in database",
+		slog.String("tenant", override.Tenant),
+		slog.String("template", override.Name),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func (r *TemplateRepository) Delete(ctx context.Context, tenant string, name string) error {
+	start := time.Now()
+	result := r.db.WithContext(ctx).
+		Where("tenant = ? AND name = ?", tenant, name).
+		Delete(&models.TemplateOverride{})
+
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete template override from database",
+			slog.String("tenant", tenant),
+			slog.String("template", name),
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	r.logger.InfoContext(ctx, "Successfully deleted template override from database",
+		slog.String("tenant", tenant),
+		slog.String("template", name),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+// TenantRepository registers tenants along with their settings, API keys and webhooks,
+// and provisions the schemas of isolated ones. Its tables are shared, so its queries
+// see the same rows from every tenant's connections.
+type TenantRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewTenantRepository(db *gorm.DB, logger *slog.Logger) *TenantRepository {
+	return &TenantRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *TenantRepository) Create(ctx context.Context, tenant *models.Tenant) error {
+	start := time.Now()
+	if err := r.db.WithContext(ctx).Create(tenant).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to create tenant in database",
+			slog.String("tenant", tenant.Name),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully created tenant in database",
+		slog.String("tenant", tenant.Name),
+		slog.Duration("duration", time.Since(start)))
+	return nil
+}
+
+func (r *TenantRepository) Get(ctx context.Context, name string) (*models.Tenant, error) {
+	var tenant models.Tenant
+	if err := r.db.WithContext(ctx).First(&tenant, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *TenantRepository) List(ctx context.Context) ([]models.Tenant, error) {
+	start := time.Now()
+	var tenants []models.Tenant
+	if err := r.db.WithContext(ctx).Order("name").Find(&tenants).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list tenants from database",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+	return tenants, nil
+}
+
+// SetStatus marks the tenant name active or suspended.
+func (r *TenantRepository) SetStatus(ctx context.Context, name string, status string, suspendedAt *time.Time) error {
+	start := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.Tenant{}).Where("name = ?", name).
+		Updates(map[string]any{"status": status, "suspended_at": suspendedAt})
+	if result.Error != nil {
+		r.logger.ErrorContext(ctx, "Failed to update tenant status in database",
+			slog.String("tenant", name),
+			slog.String("status", status),
+			slog.String("error", result.Error.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete removes tenant, with its settings, API keys and webhooks, and drops
+// its schema in the same transaction.
+func (r *TenantRepository) Delete(ctx context.Context, tenant *models.Tenant) error {
+	start := time.Now()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if tenant.Schema != nil {
+			if err := tx.Exec("SELECT drop_tenant_schema(?)", *tenant.Schema).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.Tenant{}, "name = ?", tenant.Name).Error
+	})
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to delete tenant from database",
+			slog.String("tenant", tenant.Name),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully deleted tenant from database",
+		slog.String("tenant", tenant.Name),
+		slog.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// CreateSchema creates schema with the tables of the current migration.
+func (r *TenantRepository) CreateSchema(ctx context.Context, schema string) error {
+	start := time.Now()
+	if err := r.db.WithContext(ctx).Exec("SELECT provision_tenant_schema(?)", schema).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to provision tenant schema in database",
+			slog.String("schema", schema),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully provisioned tenant schema in database",
+		slog.String("schema", schema),
+		slog.Duration("duration", time.Since(start)))
+	return nil
+}
+
+func (r *TenantRepository) DropSchema(ctx context.Context, schema string) error {
+	return r.db.WithContext(ctx).Exec("SELECT drop_tenant_schema(?)", schema).Error
+}
+
+func (r *TenantRepository) SaveSettings(ctx context.Context, settings *models.TenantSettings) error {
+	if err := r.db.WithContext(ctx).Save(settings).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to save tenant settings in database",
+			slog.String("tenant", settings.Tenant),
+			slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (r *TenantRepository) Settings(ctx context.Context, tenant string) (*models.TenantSettings, error) {
+	var settings models.TenantSettings
+	if err := r.db.WithContext(ctx).First(&settings, "tenant = ?", tenant).Error; err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *TenantRepository) DeleteSettings(ctx context.Context, tenant string) error {
+	return r.db.WithContext(ctx).Delete(&models.TenantSettings{}, "tenant = ?", tenant).Error
+}
+
+func (r *TenantRepository) CreateAPIKey(ctx context.Context, key *models.TenantAPIKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to create tenant API key in database",
+			slog.String("tenant", key.Tenant),
+			slog.String("client_id", key.ClientID),
+			slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (r *TenantRepository) APIKey(ctx context.Context, clientID string) (*models.TenantAPIKey, error) {
+	var key models.TenantAPIKey
+	if err := r.db.WithContext(ctx).First(&key, "client_id = ?", clientID).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// SetAPIKeySigningKey replaces the stored signing key of clientID.
+func (r *TenantRepository) SetAPIKeySigningKey(ctx context.Context, clientID string, encryptedKey string) error {
+	return r.db.WithContext(ctx).Model(&models.TenantAPIKey{}).
+		Where("client_id = ?", clientID).
+		Update("signing_key", encryptedKey).Error
+}
+
+func (r *TenantRepository) DeleteAPIKey(ctx context.Context, clientID string) error {
+	return r.db.WithContext(ctx).Delete(&models.TenantAPIKey{}, "client_id = ?", clientID).Error
+}
+
+func (r *TenantRepository) CreateWebhook(ctx context.Context, webhook *models.TenantWebhook) error {
+	if err := r.db.WithContext(ctx).Create(webhook).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to create tenant webhook in database",
+			slog.String("tenant", webhook.Tenant),
+			slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (r *TenantRepository) Webhooks(ctx context.Context, tenant string) ([]models.TenantWebhook, error) {
+	var webhooks []models.TenantWebhook
+	if err := r.db.WithContext(ctx).Order("created_at").Find(&webhooks, "tenant = ?", tenant).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to list tenant webhooks from database",
+			slog.String("tenant", tenant),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *TenantRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.TenantWebhook{}, "id = ?", id).Error
+}
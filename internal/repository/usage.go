@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"awesomeProject1/internal/model"
+)
+
+type UsageRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewUsageRepository(db *gorm.DB, logger *slog.Logger) *UsageRepository {
+	return &UsageRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *UsageRepository) AddBatch(ctx context.Context, usage []models.APIUsage) error {
+	start := time.Now()
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "day"}, {Name: "api_key"}, {Name: "endpoint"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "calls"}, Value: gorm.Expr("api_usage.calls + EXCLUDED.calls")},
+			{Column: clause.Column{Name: "request_bytes"}, Value: gorm.Expr("api_usage.request_bytes + EXCLUDED.request_bytes")},
+			{Column: clause.Column{Name: "response_bytes"}, Value: gorm.Expr("api_usage.response_bytes + EXCLUDED.response_bytes")},
+		},
+	}).Create(&usage).Error
+
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to write API usage batch to database",
+			slog.Int("rows", len(usage)),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return err
+	}
+
+	r.logger.DebugContext(ctx, "Wrote API usage batch to database",
+		slog.Int("rows", len(usage)),
+		slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+func (r *UsageRepository) Report(ctx context.Context, filter models.UsageFilter) ([]models.UsageReportRow, error) {
+	r.logger.InfoContext(ctx, "Building API usage report from repository",
+		slog.Time("from", filter.From),
+		slog.Time("to", filter.To),
+		slog.String("api_key", filter.APIKey),
+		slog.Bool("by_endpoint", filter.ByEndpoint))
+
+	start := time.Now()
+	groupBy := "day, api_key"
+	if filter.ByEndpoint {
+		groupBy += ", endpoint"
+	}
+
+	query := r.db.WithContext(ctx).Model(&models.APIUsage{}).
+		Select(groupBy+", SUM(calls) AS calls, SUM(request_bytes) AS request_bytes, SUM(response_bytes) AS response_bytes").
+		Where("day BETWEEN ? AND ?", filter.From, filter.To).
+		Group(groupBy).
+		Order(groupBy)
+
+	if filter.APIKey != "" {
+		query = query.Where("api_key = ?", filter.APIKey)
+	}
+
+	var rows []models.UsageReportRow
+	if err := query.Scan(&rows).Error; err != nil {
+		r.logger.ErrorContext(ctx, "Failed to build API usage report",
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return nil, err
+	}
+
+	r.logger.InfoContext(ctx, "Successfully built API usage report",
+		slog.Int("rows", len(rows)),
+		slog.Duration("duration", time.Since(start)))
+
+	return rows, nil
+}
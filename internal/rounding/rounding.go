@@ -0,0 +1,95 @@
+// Package rounding rounds the fractional amounts that normalizing prices to monthly
+// costs produces, such as a yearly price spread over twelve months. Totals are rounded
+// once, after summing, by the same policy in every report.
+package rounding
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+)
+
+// Modes of a Policy.
+const (
+	// HalfUp rounds halves away from zero, as PostgreSQL's round does.
+	HalfUp = "half_up"
+	// HalfEven rounds halves to the nearest even digit, known as banker's rounding.
+	HalfEven = "half_even"
+	Floor    = "floor"
+	Ceil     = "ceil"
+)
+
+// MaxPrecision is the largest number of decimals a Policy keeps.
+const MaxPrecision = 4
+
+var ErrInvalidPolicy = errors.New("invalid rounding policy")
+
+// Policy rounds amounts to Precision decimals by Mode.
+type Policy struct {
+	Mode      string
+	Precision int
+}
+
+// Default is the policy of whole amounts with halves rounded up.
+var Default = Policy{Mode: HalfUp}
+
+func NewPolicy(mode string, precision int) (Policy, error) {
+	switch mode {
+	case HalfUp, HalfEven, Floor, Ceil:
+	default:
+		return Policy{}, fmt.Errorf("%w: unknown mode %q, expected %s, %s, %s or %s", ErrInvalidPolicy, mode, HalfUp, HalfEven, Floor, Ceil)
+	}
+	if precision < 0 || precision > MaxPrecision {
+		return Policy{}, fmt.Errorf("%w: precision must be between 0 and %d", ErrInvalidPolicy, MaxPrecision)
+	}
+	return Policy{Mode: mode, Precision: precision}, nil
+}
+
+// SQL wraps the SQL expression expr so that it evaluates to its rounded value, as a
+// numeric with Precision decimals. HalfEven relies on the round_half_even function of
+// the migrations. The outer round of the other modes is exact; it only sets the scale
+// that their division leaves too large.
+func (p Policy) SQL(expr string) string {
+	places := strconv.Itoa(p.Precision)
+	scale := strconv.FormatFloat(math.Pow10(p.Precision), 'f', 0, 64)
+	switch p.Mode {
+	case HalfEven:
+		return fmt.Sprintf("round(round_half_even((%s)::numeric, %s), %s)", expr, places, places)
+	case Floor:
+		return fmt.Sprintf("round(floor((%s)::numeric * %s) / %s, %s)", expr, scale, scale, places)
+	case Ceil:
+		return fmt.Sprintf("round(ceil((%s)::numeric * %s) / %s, %s)", expr, scale, scale, places)
+	default:
+		return fmt.Sprintf("round((%s)::numeric, %s)", expr, places)
+	}
+}
+
+// Rat rounds the exact amount r, for sums computed in Go rather than in SQL.
+func (p Policy) Rat(r *big.Rat) decimal.Decimal {
+	num := new(big.Int).Mul(r.Num(), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(p.Precision)), nil))
+	quo, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if rem.Sign() != 0 {
+		// quo is truncated toward zero; twice the remainder tells how far from it r is.
+		half := new(big.Int).Abs(rem)
+		half.Lsh(half, 1)
+		away := false
+		switch p.Mode {
+		case Floor:
+			away = num.Sign() < 0
+		case Ceil:
+			away = num.Sign() > 0
+		case HalfEven:
+			away = half.Cmp(r.Denom()) > 0 || half.Cmp(r.Denom()) == 0 && quo.Bit(0) == 1
+		default:
+			away = half.Cmp(r.Denom()) >= 0
+		}
+		if away {
+			quo.Add(quo, big.NewInt(int64(num.Sign())))
+		}
+	}
+	return decimal.NewFromBigInt(quo, -int32(p.Precision))
+}
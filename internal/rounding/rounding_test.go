@@ -0,0 +1,91 @@
+package rounding
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestNewPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		precision int
+		wantErr   bool
+	}{
+		{name: "half up", mode: HalfUp, precision: 0},
+		{name: "half even", mode: HalfEven, precision: 2},
+		{name: "floor", mode: Floor, precision: 1},
+		{name: "ceil at max precision", mode: Ceil, precision: MaxPrecision},
+		{name: "unknown mode", mode: "truncate", wantErr: true},
+		{name: "empty mode", mode: "", wantErr: true},
+		{name: "negative precision", mode: HalfUp, precision: -1, wantErr: true},
+		{name: "precision over max", mode: HalfUp, precision: MaxPrecision + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPolicy(tt.mode, tt.precision)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPolicy) {
+					t.Errorf("NewPolicy error = %v, want ErrInvalidPolicy", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewPolicy: %v", err)
+			}
+			if want := (Policy{Mode: tt.mode, Precision: tt.precision}); policy != want {
+				t.Errorf("NewPolicy = %+v, want %+v", policy, want)
+			}
+		})
+	}
+}
+
+func TestPolicyRat(t *testing.T) {
+	tests := []struct {
+		num, denom int64
+		precision  int
+		// want holds the result of HalfUp, HalfEven, Floor and Ceil.
+		want [4]string
+	}{
+		{num: 5, denom: 2, precision: 0, want: [4]string{"3", "2", "2", "3"}},
+		{num: 7, denom: 2, precision: 0, want: [4]string{"4", "4", "3", "4"}},
+		{num: -5, denom: 2, precision: 0, want: [4]string{"-3", "-2", "-3", "-2"}},
+		{num: 1, denom: 3, precision: 2, want: [4]string{"0.33", "0.33", "0.33", "0.34"}},
+		{num: 2, denom: 3, precision: 2, want: [4]string{"0.67", "0.67", "0.66", "0.67"}},
+		{num: -1, denom: 3, precision: 2, want: [4]string{"-0.33", "-0.33", "-0.34", "-0.33"}},
+		{num: 1, denom: 8, precision: 2, want: [4]string{"0.13", "0.12", "0.12", "0.13"}},
+		{num: 5, denom: 2, precision: 1, want: [4]string{"2.5", "2.5", "2.5", "2.5"}},
+		{num: 1200, denom: 12, precision: 0, want: [4]string{"100", "100", "100", "100"}},
+	}
+	for _, tt := range tests {
+		r := big.NewRat(tt.num, tt.denom)
+		for i, mode := range []string{HalfUp, HalfEven, Floor, Ceil} {
+			policy := Policy{Mode: mode, Precision: tt.precision}
+			want := decimal.RequireFromString(tt.want[i])
+			if got := policy.Rat(r); !got.Equal(want) {
+				t.Errorf("%+v.Rat(%s) = %s, want %s", policy, r.RatString(), got, want)
+			}
+		}
+	}
+}
+
+func TestPolicySQL(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		want   string
+	}{
+		{policy: Default, want: "round((price)::numeric, 0)"},
+		{policy: Policy{Mode: HalfUp, Precision: 2}, want: "round((price)::numeric, 2)"},
+		{policy: Policy{Mode: HalfEven, Precision: 2}, want: "round(round_half_even((price)::numeric, 2), 2)"},
+		{policy: Policy{Mode: Floor, Precision: 0}, want: "round(floor((price)::numeric * 1) / 1, 0)"},
+		{policy: Policy{Mode: Ceil, Precision: 3}, want: "round(ceil((price)::numeric * 1000) / 1000, 3)"},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.SQL("price"); got != tt.want {
+			t.Errorf("%+v.SQL(price) = %q, want %q", tt.policy, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package sandbox keeps the data of integration partners testing against the live API
+// apart from production data. Requests of sandbox clients run on connections whose
+// search_path starts with the sandbox schema, which holds copies of the tables the API
+// writes to; every other table is shared. The sandbox is wiped once a day.
+package sandbox
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/identity"
+)
+
+// CheckInterval is how often the scheduler checks whether a reset is due.
+const CheckInterval = 10 * time.Minute
+
+// DSN returns dsn with the sandbox schema first on the search_path.
+func DSN(dsn string) string {
+	return dsn + " search_path=sandbox,public"
+}
+
+// ConnPool is a gorm connection pool sending the queries of sandbox requests, as told
+// by the identity in their context, to sandbox and every other query to main.
+// Transactions stay on the pool they were begun on.
+type ConnPool struct {
+	main    *sql.DB
+	sandbox *sql.DB
+}
+
+func NewConnPool(main *sql.DB, sandbox *sql.DB) *ConnPool {
+	return &ConnPool{
+		main:    main,
+		sandbox: sandbox,
+	}
+}
+
+func (p *ConnPool) pool(ctx context.Context) *sql.DB {
+	if identity.FromContext(ctx).Sandbox() {
+		return p.sandbox
+	}
+	return p.main
+}
+
+func (p *ConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.pool(ctx).PrepareContext(ctx, query)
+}
+
+func (p *ConnPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return p.pool(ctx).ExecContext(ctx, query, args...)
+}
+
+func (p *ConnPool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return p.pool(ctx).QueryContext(ctx, query, args...)
+}
+
+func (p *ConnPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return p.pool(ctx).QueryRowContext(ctx, query, args...)
+}
+
+func (p *ConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return p.pool(ctx).BeginTx(ctx, opts)
+}
+
+// GetDBConn returns the production pool, for health checks and locks.
+func (p *ConnPool) GetDBConn() (*sql.DB, error) {
+	return p.main, nil
+}
+
+type store interface {
+	LastReset(ctx context.Context) (*time.Time, error)
+	Reset(ctx context.Context, at time.Time) (int64, error)
+}
+
+// Resetter wipes the sandbox daily at a fixed UTC time. The last reset is stored, so
+// a reset missed during a restart or a leader change happens on the next check.
+type Resetter struct {
+	store  store
+	at     time.Duration
+	clock  clock.Clock
+	logger *slog.Logger
+}
+
+// NewResetter resets at at, the offset from UTC midnight.
+func NewResetter(store store, at time.Duration, clock clock.Clock, logger *slog.Logger) *Resetter {
+	return &Resetter{
+		store:  store,
+		at:     at,
+		clock:  clock,
+		logger: logger,
+	}
+}
+
+// ResetIfDue wipes the sandbox unless it has been wiped since the last reset time
+// passed. It has the signature of a scheduled job.
+func (r *Resetter) ResetIfDue(ctx context.Context) error {
+	now := r.clock.Now().UTC()
+	due := now.Truncate(24 * time.Hour).Add(r.at)
+	if now.Before(due) {
+		due = due.Add(-24 * time.Hour)
+	}
+
+	last, err := r.store.LastReset(ctx)
+	if err != nil {
+		return err
+	}
+	if last != nil && !last.Before(due) {
+		return nil
+	}
+
+	removed, err := r.store.Reset(ctx, now)
+	if err != nil {
+		return err
+	}
+	r.logger.InfoContext(ctx, "Reset sandbox",
+		slog.Time("due", due),
+		slog.Int64("subscriptions", removed))
+	return nil
+}
@@ -0,0 +1,330 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/lock"
+	"awesomeProject1/internal/model"
+)
+
+// Catch-up policies for the runs a job misses while its previous run is still going.
+const (
+	// CatchUpSkip drops the missed runs; the job next runs at its next tick.
+	CatchUpSkip = "skip"
+	// CatchUpOnce runs the job once more right after the long run, however many runs
+	// it missed.
+	CatchUpOnce = "once"
+)
+
+// Job is a task run every Interval. An exclusive job runs only on the elected leader
+// and, to cover the overlap while leadership changes hands, under the job's lock.
+type Job struct {
+	Name      string
+	Interval  time.Duration
+	Run       func(ctx context.Context) error
+	Exclusive bool
+}
+
+// Policy shapes when jobs run. Every wait between runs is the interval moved by up
+// to JitterPercent of it either way, so replicas and jobs sharing an interval do not
+// hit the database in lockstep. A run is cancelled after MaxRuntime, or the job's
+// interval when it is zero; MaxRuntimes overrides it per job name.
+type Policy struct {
+	JitterPercent int
+	CatchUp       string
+	MaxRuntime    time.Duration
+	MaxRuntimes   map[string]time.Duration
+}
+
+type locker interface {
+	TryAcquire(ctx context.Context, key string) (*lock.Lock, bool, error)
+}
+
+type leadership interface {
+	IsLeader() bool
+}
+
+// entry is a registered job. running is held for the whole of a run, so a run that
+// outlasts its interval, or ignores the cancellation at its max runtime, never
+// overlaps the next one.
+type entry struct {
+	Job
+	maxRuntime time.Duration
+	running    sync.Mutex
+	// pending is set when a run was missed under CatchUpOnce. Guarded by Scheduler.mu.
+	pending bool
+}
+
+type Scheduler struct {
+	jobs   []*entry
+	clock  clock.Clock
+	locker locker
+	leader leadership
+	policy Policy
+	logger *slog.Logger
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	status map[string]*models.JobStatus
+}
+
+func NewScheduler(clock clock.Clock, locker locker, leader leadership, policy Policy, logger *slog.Logger) *Scheduler {
+	if policy.CatchUp == "" {
+		policy.CatchUp = CatchUpSkip
+	}
+	return &Scheduler{
+		clock:  clock,
+		locker: locker,
+		leader: leader,
+		policy: policy,
+		logger: logger,
+		status: make(map[string]*models.JobStatus),
+	}
+}
+
+// Register adds a job that runs on every replica, for work on per-replica state.
+func (s *Scheduler) Register(name string, interval time.Duration, run func(ctx context.Context) error) {
+	s.add(Job{Name: name, Interval: interval, Run: run})
+}
+
+// RegisterExclusive adds a job that runs only on the leader.
+func (s *Scheduler) RegisterExclusive(name string, interval time.Duration, run func(ctx context.Context) error) {
+	s.add(Job{Name: name, Interval: interval, Run: run, Exclusive: true})
+}
+
+func (s *Scheduler) add(job Job) {
+	e := &entry{Job: job, maxRuntime: job.Interval}
+	if s.policy.MaxRuntime > 0 {
+		e.maxRuntime = s.policy.MaxRuntime
+	}
+	if maxRuntime, ok := s.policy.MaxRuntimes[job.Name]; ok {
+		e.maxRuntime = maxRuntime
+	}
+	s.jobs = append(s.jobs, e)
+
+	s.mu.Lock()
+	s.status[job.Name] = &models.JobStatus{
+		Name:       job.Name,
+		Interval:   job.Interval.String(),
+		MaxRuntime: e.maxRuntime.String(),
+	}
+	s.mu.Unlock()
+}
+
+// Status returns the state of the registered jobs in registration order.
+func (s *Scheduler) Status() []models.JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]models.JobStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		status := *s.status[job.Name]
+		if status.LastRunAt != nil {
+			lastRunAt := *status.LastRunAt
+			status.LastRunAt = &lastRunAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.logger.InfoContext(ctx, "Starting scheduled job",
+			slog.String("job", job.Name),
+			slog.Duration("interval", job.Interval),
+			slog.Duration("max_runtime", job.maxRuntime))
+
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Wait returns once the job loops and their runs have stopped.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// loop starts a run of job after every jittered interval. Runs go on in their own
+// goroutine, so that the schedule keeps its pace while one is slow.
+func (s *Scheduler) loop(ctx context.Context, job *entry) {
+	defer s.wg.Done()
+
+	for {
+		if !s.sleep(ctx, s.jittered(job.Interval)) {
+			s.logger.Info("Stopping scheduled job", slog.String("job", job.Name))
+			return
+		}
+		if !job.running.TryLock() {
+			s.miss(ctx, job)
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer job.running.Unlock()
+
+			s.run(ctx, job)
+			for s.catchUp(job) && ctx.Err() == nil {
+				s.logger.InfoContext(ctx, "Catching up on missed scheduled job run", slog.String("job", job.Name))
+				s.run(ctx, job)
+			}
+		}()
+	}
+}
+
+// sleep waits for d on the scheduler's clock and reports whether ctx is still live.
+func (s *Scheduler) sleep(ctx context.Context, d time.Duration) bool {
+	ticker := s.clock.NewTicker(d)
+	defer ticker.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-ticker.C():
+		return true
+	}
+}
+
+func (s *Scheduler) jittered(interval time.Duration) time.Duration {
+	if s.policy.JitterPercent <= 0 {
+		return interval
+	}
+	spread := int64(interval) * int64(s.policy.JitterPercent) / 100
+	if spread <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Int64N(2*spread+1)-spread)
+}
+
+// miss records a tick that came while the previous run was still going.
+func (s *Scheduler) miss(ctx context.Context, job *entry) {
+	s.mu.Lock()
+	s.status[job.Name].Missed++
+	if s.policy.CatchUp == CatchUpOnce {
+		job.pending = true
+	}
+	s.mu.Unlock()
+
+	s.logger.WarnContext(ctx, "Scheduled job missed a run, the previous one is still running",
+		slog.String("job", job.Name),
+		slog.String("catch_up", s.policy.CatchUp))
+}
+
+// catchUp reports whether a missed run is pending, clearing it.
+func (s *Scheduler) catchUp(job *entry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := job.pending
+	job.pending = false
+	return pending
+}
+
+func (s *Scheduler) run(ctx context.Context, job *entry) {
+	if job.Exclusive {
+		if !s.leader.IsLeader() {
+			s.skip(ctx, job, "not the leader")
+			return
+		}
+
+		held, acquired, err := s.locker.TryAcquire(ctx, "job:"+job.Name)
+		if err != nil {
+			start := s.begin(job)
+			s.finish(job, start, fmt.Errorf("acquire lock: %w", err))
+			s.logger.ErrorContext(ctx, "Failed to acquire scheduled job lock",
+				slog.String("job", job.Name),
+				slog.String("error", err.Error()))
+			return
+		}
+		if !acquired {
+			s.skip(ctx, job, "running on another replica")
+			return
+		}
+		defer held.Release(context.WithoutCancel(ctx))
+
+		// Stop the run if the lock is lost, since another replica may start it.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			select {
+			case <-held.Lost():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	s.logger.DebugContext(ctx, "Running scheduled job", slog.String("job", job.Name))
+	start := s.begin(job)
+
+	runCtx, cancel := context.WithTimeout(ctx, job.maxRuntime)
+	err := job.Run(runCtx)
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("exceeded max runtime of %s: %w", job.maxRuntime, context.DeadlineExceeded)
+	}
+	cancel()
+	s.finish(job, start, err)
+
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Scheduled job failed",
+			slog.String("job", job.Name),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)))
+		return
+	}
+
+	s.logger.DebugContext(ctx, "Scheduled job completed",
+		slog.String("job", job.Name),
+		slog.Duration("duration", time.Since(start)))
+}
+
+func (s *Scheduler) skip(ctx context.Context, job *entry, reason string) {
+	s.mu.Lock()
+	s.status[job.Name].Skipped++
+	s.mu.Unlock()
+
+	s.logger.DebugContext(ctx, "Skipping scheduled job",
+		slog.String("job", job.Name),
+		slog.String("reason", reason))
+}
+
+// begin marks job as running and returns the start of the run.
+func (s *Scheduler) begin(job *entry) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.status[job.Name]
+	lastRunAt := s.clock.Now().UTC()
+	status.Running = true
+	status.LastRunAt = &lastRunAt
+	return time.Now()
+}
+
+// finish records the outcome of a run started at start.
+func (s *Scheduler) finish(job *entry, start time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.status[job.Name]
+	status.Running = false
+	status.Runs++
+	status.LastDurationMS = time.Since(start).Milliseconds()
+	status.LastError = ""
+	if err != nil {
+		status.Failures++
+		status.LastError = err.Error()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		status.TimedOut++
+	}
+}
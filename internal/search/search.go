@@ -0,0 +1,388 @@
+// Package search mirrors subscriptions into an Elasticsearch or OpenSearch index and
+// runs full-text and facet queries against it. The index is fed by the domain events
+// of the dispatcher, so it lags writes slightly and catches up on the events replayed
+// from the dead letters; the backfill command fills it from the table.
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/events"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+// SinkName is the name Index is registered under with the event dispatcher.
+const SinkName = "search-index"
+
+// facetSize is the number of values returned per facet.
+const facetSize = 20
+
+// facetFields are the facets of a search by name, with the indexed field they count.
+var facetFields = map[string]string{
+	"kind":         "kind",
+	"category":     "category",
+	"status":       "status",
+	"service_name": "service_name.keyword",
+}
+
+// indexMapping keeps the filtered and counted fields as keywords and analyzes the
+// service name for text queries.
+const indexMapping = `{
+  "mappings": {
+    "properties": {
+      "id": {"type": "keyword"},
+      "service_name": {"type": "text", "fields": {"keyword": {"type": "keyword", "ignore_above": 256}}},
+      "price": {"type": "integer"},
+      "user_id": {"type": "keyword"},
+      "kind": {"type": "keyword"},
+      "billing_period": {"type": "keyword"},
+      "billing_anchor_day": {"type": "integer"},
+      "start_date": {"type": "date"},
+      "end_date": {"type": "date"},
+      "status": {"type": "keyword"},
+      "category": {"type": "keyword"},
+      "custom_fields": {"type": "object", "enabled": false},
+      "tenant": {"type": "keyword"}
+    }
+  }
+}`
+
+// Config addresses the index. Username and Password, when set, authenticate with
+// basic auth.
+type Config struct {
+	URL      string
+	Index    string
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// document is a subscription as indexed, stamped with its tenant.
+type document struct {
+	models.Subscription
+	Tenant string `json:"tenant"`
+}
+
+// Index is an Elasticsearch or OpenSearch index of subscriptions. Writes are versioned
+// with the time of the change they mirror, so events delivered out of order never
+// overwrite a newer state.
+type Index struct {
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	created bool
+}
+
+func NewIndex(cfg Config, logger *slog.Logger) *Index {
+	return &Index{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		logger: logger,
+	}
+}
+
+// Publish mirrors the change of an event: a subscription deleted, or merged into
+// another one, leaves the index and any other state is indexed.
+func (i *Index) Publish(ctx context.Context, event events.Event) error {
+	if event.SubscriptionID == nil {
+		return nil
+	}
+	if err := i.ensure(ctx); err != nil {
+		return err
+	}
+
+	id := *event.SubscriptionID
+	version := event.OccurredAt.UnixNano()
+	if len(event.After) == 0 || string(event.After) == "null" {
+		return i.remove(ctx, id.String(), version)
+	}
+
+	var sub models.Subscription
+	if err := json.Unmarshal(event.After, &sub); err != nil {
+		return fmt.Errorf("decode subscription: %w", err)
+	}
+	if sub.ID != id {
+		if err := i.remove(ctx, id.String(), version); err != nil {
+			return err
+		}
+	}
+	return i.put(ctx, document{Subscription: sub, Tenant: tenantOf(event.Tenant)}, version)
+}
+
+// IndexBatch indexes subs of the default tenant in one bulk request, versioned with the
+// current time, and returns how many were indexed. Subscriptions changed since they
+// were read keep their newer state.
+func (i *Index) IndexBatch(ctx context.Context, subs []models.Subscription) (int64, error) {
+	if len(subs) == 0 {
+		return 0, nil
+	}
+	if err := i.ensure(ctx); err != nil {
+		return 0, err
+	}
+
+	version := time.Now().UnixNano()
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, sub := range subs {
+		action := map[string]any{"index": map[string]any{
+			"_index":       i.cfg.Index,
+			"_id":          sub.ID.String(),
+			"version":      version,
+			"version_type": "external",
+		}}
+		if err := encoder.Encode(action); err != nil {
+			return 0, err
+		}
+		if err := encoder.Encode(document{Subscription: sub, Tenant: identity.DefaultTenant}); err != nil {
+			return 0, err
+		}
+	}
+
+	var result struct {
+		Items []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if _, err := i.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", &body, &result); err != nil {
+		return 0, err
+	}
+
+	var indexed int64
+	var failed []string
+	for _, item := range result.Items {
+		for _, outcome := range item {
+			switch {
+			case outcome.Status < http.StatusMultipleChoices:
+				indexed++
+			case outcome.Status == http.StatusConflict:
+				// A newer change was mirrored meanwhile.
+			case outcome.Error != nil:
+				failed = append(failed, outcome.Error.Reason)
+			default:
+				failed = append(failed, http.StatusText(outcome.Status))
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return indexed, fmt.Errorf("%d subscriptions could not be indexed: %s", len(failed), failed[0])
+	}
+	return indexed, nil
+}
+
+// Search runs q over the subscriptions of the caller's tenant.
+func (i *Index) Search(ctx context.Context, q models.SearchQuery) (*models.SearchResult, error) {
+	filters := []any{
+		map[string]any{"term": map[string]any{"tenant": tenantOf(identity.FromContext(ctx).Tenant)}},
+	}
+	if q.UserID != uuid.Nil {
+		filters = append(filters, map[string]any{"term": map[string]any{"user_id": q.UserID.String()}})
+	}
+	for field, values := range map[string][]string{"kind": q.Kinds, "category": q.Categories, "status": q.Statuses} {
+		if len(values) > 0 {
+			filters = append(filters, map[string]any{"terms": map[string]any{field: values}})
+		}
+	}
+	if q.MinPrice != nil || q.MaxPrice != nil {
+		bounds := make(map[string]any)
+		if q.MinPrice != nil {
+			bounds["gte"] = *q.MinPrice
+		}
+		if q.MaxPrice != nil {
+			bounds["lte"] = *q.MaxPrice
+		}
+		filters = append(filters, map[string]any{"range": map[string]any{"price": bounds}})
+	}
+
+	boolQuery := map[string]any{"filter": filters}
+	sort := []any{map[string]any{"start_date": "asc"}, map[string]any{"id": "asc"}}
+	if text := strings.TrimSpace(q.Text); text != "" {
+		boolQuery["should"] = []any{
+			map[string]any{"match": map[string]any{"service_name": map[string]any{"query": text, "fuzziness": "AUTO", "operator": "and"}}},
+			map[string]any{"match_phrase_prefix": map[string]any{"service_name": text}},
+		}
+		boolQuery["minimum_should_match"] = 1
+		sort = append([]any{"_score"}, sort...)
+	}
+
+	aggs := make(map[string]any, len(facetFields)+1)
+	for name, field := range facetFields {
+		aggs[name] = map[string]any{"terms": map[string]any{"field": field, "size": facetSize}}
+	}
+	ranges := make([]any, 0, len(models.PriceBuckets))
+	for _, bucket := range models.PriceBuckets {
+		r := map[string]any{"key": bucket.Key, "from": bucket.From}
+		if bucket.To > 0 {
+			r["to"] = bucket.To
+		}
+		ranges = append(ranges, r)
+	}
+	aggs[models.PriceFacet] = map[string]any{"range": map[string]any{"field": "price", "ranges": ranges}}
+
+	request := map[string]any{
+		"query":            map[string]any{"bool": boolQuery},
+		"sort":             sort,
+		"from":             q.Page.Offset,
+		"size":             q.Page.Limit,
+		"track_total_hits": true,
+		"aggs":             aggs,
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source document `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+		Aggregations map[string]struct {
+			Buckets []struct {
+				Key      string `json:"key"`
+				DocCount int64  `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"aggregations"`
+	}
+	status, err := i.do(ctx, http.MethodPost, "/"+url.PathEscape(i.cfg.Index)+"/_search", "application/json", bytes.NewReader(body), &response)
+	if status == http.StatusNotFound {
+		// Nothing has been indexed yet.
+		return &models.SearchResult{Subscriptions: []models.Subscription{}, Facets: map[string][]models.FacetCount{}, Engine: models.SearchEngineIndex}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.SearchResult{
+		Subscriptions: make([]models.Subscription, 0, len(response.Hits.Hits)),
+		Total:         response.Hits.Total.Value,
+		Facets:        make(map[string][]models.FacetCount, len(response.Aggregations)),
+		Engine:        models.SearchEngineIndex,
+	}
+	for _, hit := range response.Hits.Hits {
+		result.Subscriptions = append(result.Subscriptions, hit.Source.Subscription)
+	}
+	for name, agg := range response.Aggregations {
+		counts := make([]models.FacetCount, 0, len(agg.Buckets))
+		for _, bucket := range agg.Buckets {
+			// Range aggregations return every range, matched or not.
+			if bucket.DocCount > 0 {
+				counts = append(counts, models.FacetCount{Value: bucket.Key, Count: bucket.DocCount})
+			}
+		}
+		result.Facets[name] = counts
+	}
+	return result, nil
+}
+
+// ensure creates the index with its mapping unless it exists, once per process.
+func (i *Index) ensure(ctx context.Context) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.created {
+		return nil
+	}
+
+	path := "/" + url.PathEscape(i.cfg.Index)
+	status, err := i.do(ctx, http.MethodHead, path, "", nil, nil)
+	switch {
+	case err == nil:
+	case status == http.StatusNotFound:
+		if _, err := i.do(ctx, http.MethodPut, path, "application/json", strings.NewReader(indexMapping), nil); err != nil {
+			return fmt.Errorf("create search index: %w", err)
+		}
+		i.logger.InfoContext(ctx, "Created search index", slog.String("index", i.cfg.Index))
+	default:
+		return fmt.Errorf("check search index: %w", err)
+	}
+	i.created = true
+	return nil
+}
+
+func (i *Index) put(ctx context.Context, doc document, version int64) error {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	status, err := i.do(ctx, http.MethodPut, i.docPath(doc.ID.String(), version), "application/json", bytes.NewReader(body), nil)
+	if status == http.StatusConflict {
+		return nil
+	}
+	return err
+}
+
+func (i *Index) remove(ctx context.Context, id string, version int64) error {
+	status, err := i.do(ctx, http.MethodDelete, i.docPath(id, version), "", nil, nil)
+	if status == http.StatusConflict || status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func (i *Index) docPath(id string, version int64) string {
+	return fmt.Sprintf("/%s/_doc/%s?version=%d&version_type=external", url.PathEscape(i.cfg.Index), url.PathEscape(id), version)
+}
+
+// do sends a request to the cluster and decodes a successful response into out when
+// it is set. It returns the status of the response, if one was received.
+func (i *Index) do(ctx context.Context, method string, path string, contentType string, body io.Reader, out any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(i.cfg.URL, "/")+path, body)
+	if err != nil {
+		return 0, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if i.cfg.Username != "" {
+		req.SetBasicAuth(i.cfg.Username, i.cfg.Password)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return resp.StatusCode, fmt.Errorf("search index returned %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("decode search index response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// tenantOf returns the tenant documents are stamped with; events of the default tenant
+// carry none.
+func tenantOf(tenant string) string {
+	if tenant == "" {
+		return identity.DefaultTenant
+	}
+	return tenant
+}
@@ -0,0 +1,75 @@
+// Package secretbox encrypts secrets kept in the database, such as the signing keys of
+// issued HMAC clients, with AES-256-GCM under a key from the configuration.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// prefix marks sealed values, telling them apart from values stored in plaintext
+// before they were encrypted.
+const prefix = "v1:"
+
+var ErrOpen = errors.New("cannot decrypt secret")
+
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New takes the hex encoding of a 32-byte key.
+func New(hexKey string) (*Box, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("encryption key must be 64 hex characters")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal encrypts plaintext bound to context, e.g. the ID of the row it is stored in, so
+// a sealed value copied to another row does not open.
+func (b *Box) Seal(plaintext []byte, context string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, plaintext, []byte(context))
+	return prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value sealed with the same context.
+func (b *Box) Open(sealed string, context string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(sealed, prefix)
+	if !ok {
+		return nil, fmt.Errorf("%w: not sealed", ErrOpen)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return nil, fmt.Errorf("%w: malformed", ErrOpen)
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, []byte(context))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpen, err)
+	}
+	return plaintext, nil
+}
+
+// Sealed reports whether value was sealed rather than stored in plaintext.
+func Sealed(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
@@ -0,0 +1,113 @@
+package secretbox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		hexKey  string
+		wantErr bool
+	}{
+		{name: "32-byte key", hexKey: testKey},
+		{name: "empty", hexKey: "", wantErr: true},
+		{name: "not hex", hexKey: strings.Repeat("zz", 32), wantErr: true},
+		{name: "16-byte key", hexKey: testKey[:32], wantErr: true},
+		{name: "odd length", hexKey: testKey[:63], wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.hexKey)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	box, err := New(testKey)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	other, err := New(strings.Repeat("ff", 32))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	plaintext := []byte("signing key")
+	sealed, err := box.Seal(plaintext, "client-a")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if !Sealed(sealed) {
+		t.Fatalf("Sealed(%q) = false", sealed)
+	}
+	if again, _ := box.Seal(plaintext, "client-a"); again == sealed {
+		t.Error("sealing twice gave the same value, want a fresh nonce")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, "v1:"))
+	if err != nil {
+		t.Fatalf("decode sealed value: %v", err)
+	}
+	data[len(data)-1] ^= 1
+	tampered := "v1:" + base64.StdEncoding.EncodeToString(data)
+
+	tests := []struct {
+		name    string
+		box     *Box
+		sealed  string
+		context string
+		wantErr bool
+	}{
+		{name: "same context", box: box, sealed: sealed, context: "client-a"},
+		{name: "other context", box: box, sealed: sealed, context: "client-b", wantErr: true},
+		{name: "other key", box: other, sealed: sealed, context: "client-a", wantErr: true},
+		{name: "plaintext value", box: box, sealed: "signing key", context: "client-a", wantErr: true},
+		{name: "not base64", box: box, sealed: "v1:%%%", context: "client-a", wantErr: true},
+		{name: "shorter than a nonce", box: box, sealed: "v1:AAAA", context: "client-a", wantErr: true},
+		{name: "tampered", box: box, sealed: tampered, context: "client-a", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.box.Open(tt.sealed, tt.context)
+			if tt.wantErr {
+				if !errors.Is(err, ErrOpen) {
+					t.Errorf("Open error = %v, want ErrOpen", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Errorf("Open = %q, want %q", got, plaintext)
+			}
+		})
+	}
+}
+
+func TestSealed(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "v1:AAAA", want: true},
+		{value: "v2:AAAA", want: false},
+		{value: "plaintext", want: false},
+		{value: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := Sealed(tt.value); got != tt.want {
+			t.Errorf("Sealed(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
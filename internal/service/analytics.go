@@ -0,0 +1,178 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+type AnalyticsService struct {
+	repo   repositoryAnalytics
+	logger *slog.Logger
+}
+
+type repositoryAnalytics interface {
+	TopServices(ctx context.Context, start time.Time, end time.Time, limit int, filter models.AggregateFilter) ([]models.ServiceRanking, error)
+	ServiceSpend(ctx context.Context, start time.Time, end time.Time, filter models.AggregateFilter) ([]models.ServiceRanking, error)
+	FindDuplicates(ctx context.Context, minSimilarity float64, limit int, filter models.AggregateFilter) ([]models.DuplicatePair, error)
+}
+
+func NewAnalyticsService(repo repositoryAnalytics, logger *slog.Logger) *AnalyticsService {
+	return &AnalyticsService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// analyticsFilter scopes reports to the impersonated user, like the subscription endpoints.
+func analyticsFilter(ctx context.Context) models.AggregateFilter {
+	var filter models.AggregateFilter
+	if id := identity.FromContext(ctx); id.Impersonating() {
+		filter.UserIDs = []uuid.UUID{*id.Subject}
+	}
+	return filter
+}
+
+func (s *AnalyticsService) TopServices(ctx context.Context, startDateStr string, endDateStr string, limit int) ([]models.ServiceRanking, error) {
+	s.logger.InfoContext(ctx, "Ranking services in service layer",
+		slog.String("start_date", startDateStr),
+		slog.String("end_date", endDateStr),
+		slog.Int("limit", limit))
+
+	startPeriod, endPeriod, err := aggregationPeriod(startDateStr, endDateStr)
+	if err != nil {
+		return nil, err
+	}
+	if endPeriod.Before(startPeriod) {
+		s.logger.ErrorContext(ctx, "Report end date is before start date",
+			slog.Time("start_period", startPeriod),
+			slog.Time("end_period", endPeriod))
+		return nil, ErrEndBeforeStart
+	}
+
+	rankings, err := s.repo.TopServices(ctx, startPeriod, endPeriod, limit, analyticsFilter(ctx))
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Repository failed to rank services",
+			slog.Time("start_period", startPeriod),
+			slog.Time("end_period", endPeriod),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully ranked services in service layer",
+		slog.Int("count", len(rankings)))
+
+	return rankings, nil
+}
+
+// Compare compares the spend of period A with period B, in total and per service, with
+// spend computed as for TopServices. Services are ordered by the size of their change.
+func (s *AnalyticsService) Compare(ctx context.Context, startA string, endA string, startB string, endB string) (*models.SpendComparison, error) {
+	s.logger.InfoContext(ctx, "Comparing periods in service layer",
+		slog.String("start_date_a", startA),
+		slog.String("end_date_a", endA),
+		slog.String("start_date_b", startB),
+		slog.String("end_date_b", endB))
+
+	spendA, err := s.periodSpend(ctx, startA, endA)
+	if err != nil {
+		return nil, err
+	}
+	spendB, err := s.periodSpend(ctx, startB, endB)
+	if err != nil {
+		return nil, err
+	}
+
+	byService := make(map[string]*models.ServiceComparison)
+	service := func(name string) *models.ServiceComparison {
+		if byService[name] == nil {
+			byService[name] = &models.ServiceComparison{ServiceName: name}
+		}
+		return byService[name]
+	}
+	comparison := &models.SpendComparison{Services: []models.ServiceComparison{}}
+	for _, spend := range spendA {
+		service(spend.ServiceName).TotalA = spend.TotalSpend
+		comparison.TotalA = comparison.TotalA.Add(spend.TotalSpend)
+	}
+	for _, spend := range spendB {
+		service(spend.ServiceName).TotalB = spend.TotalSpend
+		comparison.TotalB = comparison.TotalB.Add(spend.TotalSpend)
+	}
+	comparison.Change, comparison.ChangePercent = spendChange(comparison.TotalA, comparison.TotalB)
+
+	for _, sc := range byService {
+		sc.Change, sc.ChangePercent = spendChange(sc.TotalA, sc.TotalB)
+		comparison.Services = append(comparison.Services, *sc)
+	}
+	slices.SortFunc(comparison.Services, func(a, b models.ServiceComparison) int {
+		return cmp.Or(b.Change.Abs().Cmp(a.Change.Abs()), cmp.Compare(a.ServiceName, b.ServiceName))
+	})
+
+	s.logger.InfoContext(ctx, "Successfully compared periods in service layer",
+		slog.String("total_a", comparison.TotalA.String()),
+		slog.String("total_b", comparison.TotalB.String()),
+		slog.Int("services", len(comparison.Services)))
+
+	return comparison, nil
+}
+
+func (s *AnalyticsService) periodSpend(ctx context.Context, startDateStr string, endDateStr string) ([]models.ServiceRanking, error) {
+	startPeriod, endPeriod, err := aggregationPeriod(startDateStr, endDateStr)
+	if err != nil {
+		return nil, err
+	}
+	if endPeriod.Before(startPeriod) {
+		s.logger.ErrorContext(ctx, "Report end date is before start date",
+			slog.Time("start_period", startPeriod),
+			slog.Time("end_period", endPeriod))
+		return nil, ErrEndBeforeStart
+	}
+
+	spend, err := s.repo.ServiceSpend(ctx, startPeriod, endPeriod, analyticsFilter(ctx))
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Repository failed to compute service spend",
+			slog.Time("start_period", startPeriod),
+			slog.Time("end_period", endPeriod),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+	return spend, nil
+}
+
+// spendChange returns b minus a and the change as a percentage of a, rounded to two
+// decimals. There is no percentage when a is zero.
+func spendChange(a decimal.Decimal, b decimal.Decimal) (decimal.Decimal, *float64) {
+	change := b.Sub(a)
+	if a.Sign() == 0 {
+		return change, nil
+	}
+	percent := change.Mul(decimal.NewFromInt(100)).DivRound(a, 2).InexactFloat64()
+	return change, &percent
+}
+
+func (s *AnalyticsService) Duplicates(ctx context.Context, minSimilarity float64, limit int) ([]models.DuplicatePair, error) {
+	s.logger.InfoContext(ctx, "Detecting duplicate subscriptions in service layer",
+		slog.Float64("min_similarity", minSimilarity),
+		slog.Int("limit", limit))
+
+	pairs, err := s.repo.FindDuplicates(ctx, minSimilarity, limit, analyticsFilter(ctx))
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Repository failed to detect duplicate subscriptions",
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully detected duplicate subscriptions in service layer",
+		slog.Int("count", len(pairs)))
+
+	return pairs, nil
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/audit"
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/events"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/notify"
+	"awesomeProject1/internal/templates"
+)
+
+// EventAnomalyDetected is the type of the domain event emitted for new anomalies.
+const EventAnomalyDetected = "anomaly.detected"
+
+const (
+	anomalyBatchSize = 500
+	// duplicateSimilarity matches the default of the admin duplicate report.
+	duplicateSimilarity = 0.6
+	maxDuplicatePairs   = 1000
+)
+
+// AnomalyRules are the thresholds of the anomaly scan. Changes older than Lookback
+// when a scan runs are not analyzed. Users are notified of their anomalies on their
+// preferred notification channels, or on Channel when they prefer none and it is set.
+type AnomalyRules struct {
+	Lookback         time.Duration
+	PriceJumpPercent int
+	ExpensiveFactor  int
+	Channel          string
+}
+
+// AnomalyService flags unusual changes in users' spend: new subscriptions costing
+// ExpensiveFactor times the user's median, price increases above PriceJumpPercent and
+// subscriptions charged twice under similar names.
+type AnomalyService struct {
+	anomalies     anomalyRepository
+	subscriptions anomalySubscriptions
+	history       auditHistory
+	events        eventEmitter
+	notifier      notifier
+	templates     renderer
+	preferences   userPreferences
+	rules         AnomalyRules
+	clock         clock.Clock
+	logger        *slog.Logger
+}
+
+type anomalyRepository interface {
+	Create(ctx context.Context, anomaly *models.Anomaly) (bool, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, page models.Page) ([]models.Anomaly, error)
+}
+
+type anomalySubscriptions interface {
+	List(ctx context.Context, filter models.ListFilter, page models.Page) ([]models.Subscription, error)
+	FindDuplicates(ctx context.Context, minSimilarity float64, limit int, filter models.AggregateFilter) ([]models.DuplicatePair, error)
+}
+
+type auditHistory interface {
+	ForEachEventBatch(ctx context.Context, filter models.EventFilter, batchSize int, fn func(records []models.AuditRecord) error) error
+}
+
+type eventEmitter interface {
+	Emit(ctx context.Context, event events.Event)
+}
+
+func NewAnomalyService(anomalies anomalyRepository, subscriptions anomalySubscriptions, history auditHistory, events eventEmitter, notifier notifier, templates renderer, preferences userPreferences, rules AnomalyRules, clock clock.Clock, logger *slog.Logger) *AnomalyService {
+	return &AnomalyService{
+		anomalies:     anomalies,
+		subscriptions: subscriptions,
+		history:       history,
+		events:        events,
+		notifier:      notifier,
+		templates:     templates,
+		preferences:   preferences,
+		rules:         rules,
+		clock:         clock,
+		logger:        logger,
+	}
+}
+
+// List returns the anomalies of a user, most recent first.
+func (s *AnomalyService) List(ctx context.Context, userID uuid.UUID, page models.Page) ([]models.Anomaly, error) {
+	if id := identity.FromContext(ctx); id.Impersonating() && *id.Subject != userID {
+		s.logger.WarnContext(ctx, "Anomalies requested for a different user than the impersonated one",
+			slog.String("user_id", userID.String()),
+			slog.String("subject_id", id.Subject.String()))
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.anomalies.ListByUser(ctx, userID, page)
+}
+
+// Detect scans the subscriptions created and updated within the lookback and every
+// pair of duplicate subscriptions, storing what it flags. Anomalies found before are
+// skipped, so overlapping scans are harmless. It is run by the scheduler.
+func (s *AnomalyService) Detect(ctx context.Context) error {
+	now := s.clock.Now().UTC()
+	scan := &anomalyScan{service: s, medians: make(map[uuid.UUID]*decimal.Decimal)}
+
+	filter := models.EventFilter{
+		From:  now.Add(-s.rules.Lookback),
+		To:    now,
+		Types: []string{audit.ActionCreate, audit.ActionUpdate},
+	}
+	err := s.history.ForEachEventBatch(ctx, filter, anomalyBatchSize, func(records []models.AuditRecord) error {
+		for _, record := range records {
+			if err := scan.change(ctx, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("scan subscription changes: %w", err)
+	}
+
+	pairs, err := s.subscriptions.FindDuplicates(ctx, duplicateSimilarity, maxDuplicatePairs, models.AggregateFilter{})
+	if err != nil {
+		return fmt.Errorf("scan duplicate subscriptions: %w", err)
+	}
+	for _, pair := range pairs {
+		related := pair.SecondID
+		scan.flag(ctx, &models.Anomaly{
+			UserID:                pair.UserID,
+			SubscriptionID:        pair.FirstID,
+			RelatedSubscriptionID: &related,
+			Kind:                  models.AnomalyDuplicateCharge,
+			ServiceName:           pair.FirstServiceName,
+			Key:                   fmt.Sprintf("%s:%s:%s", models.AnomalyDuplicateCharge, pair.FirstID, pair.SecondID),
+		})
+	}
+
+	s.logger.InfoContext(ctx, "Finished anomaly scan",
+		slog.Int("changes", scan.changes),
+		slog.Int("duplicate_pairs", len(pairs)),
+		slog.Int("flagged", scan.flagged),
+		slog.Int("failed", scan.failed))
+
+	if scan.failed > 0 {
+		return fmt.Errorf("%d anomalies could not be stored or notified", scan.failed)
+	}
+	return nil
+}
+
+// anomalyScan holds the state of one Detect run.
+type anomalyScan struct {
+	service *AnomalyService
+	medians map[uuid.UUID]*decimal.Decimal
+	changes int
+	flagged int
+	failed  int
+}
+
+// change checks one audited create or update.
+func (scan *anomalyScan) change(ctx context.Context, record models.AuditRecord) error {
+	var after models.Subscription
+	if len(record.After) == 0 || json.Unmarshal(record.After, &after) != nil {
+		return nil
+	}
+	scan.changes++
+	rules := scan.service.rules
+	cost := monthlyCost(after)
+
+	if record.Action == audit.ActionCreate {
+		median, err := scan.median(ctx, after.UserID, after.ID)
+		if err != nil {
+			return err
+		}
+		if median == nil || median.Sign() <= 0 || cost.Cmp(median.Mul(decimal.NewFromInt(int64(rules.ExpensiveFactor)))) < 0 {
+			return nil
+		}
+		baseline := median.Round(0).IntPart()
+		scan.flag(ctx, &models.Anomaly{
+			UserID:         after.UserID,
+			SubscriptionID: after.ID,
+			Kind:           models.AnomalyNewExpensive,
+			ServiceName:    after.ServiceName,
+			MonthlyCost:    cost.Round(0).IntPart(),
+			Baseline:       &baseline,
+			Key:            fmt.Sprintf("%s:%s", models.AnomalyNewExpensive, after.ID),
+		})
+		return nil
+	}
+
+	var before models.Subscription
+	if len(record.Before) == 0 || json.Unmarshal(record.Before, &before) != nil {
+		return nil
+	}
+	previous := monthlyCost(before)
+	// Compared in hundredths, cost is a jump when cost*100 > previous*(100+percent).
+	jump := previous.Mul(decimal.NewFromInt(int64(100 + rules.PriceJumpPercent)))
+	if previous.Sign() <= 0 || cost.Mul(decimal.NewFromInt(100)).Cmp(jump) <= 0 {
+		return nil
+	}
+	baseline := previous.Round(0).IntPart()
+	scan.flag(ctx, &models.Anomaly{
+		UserID:         after.UserID,
+		SubscriptionID: after.ID,
+		Kind:           models.AnomalyPriceJump,
+		ServiceName:    after.ServiceName,
+		MonthlyCost:    cost.Round(0).IntPart(),
+		Baseline:       &baseline,
+		Key:            fmt.Sprintf("%s:%s", models.AnomalyPriceJump, record.ID),
+	})
+	return nil
+}
+
+// median returns the median monthly cost of the user's recurring subscriptions other
+// than exclude, or nil when there are none to compare with.
+func (scan *anomalyScan) median(ctx context.Context, userID uuid.UUID, exclude uuid.UUID) (*decimal.Decimal, error) {
+	if median, ok := scan.medians[userID]; ok {
+		return median, nil
+	}
+
+	subs, err := scan.service.subscriptions.List(ctx, models.ListFilter{UserID: userID}, models.Page{})
+	if err != nil {
+		return nil, fmt.Errorf("list subscriptions of user %s: %w", userID, err)
+	}
+	var costs []decimal.Decimal
+	for _, sub := range subs {
+		if sub.ID != exclude && sub.Kind == models.KindRecurring {
+			costs = append(costs, monthlyCost(sub))
+		}
+	}
+
+	var median *decimal.Decimal
+	if len(costs) > 0 {
+		slices.SortFunc(costs, decimal.Decimal.Cmp)
+		m := costs[len(costs)/2]
+		if len(costs)%2 == 0 {
+			m = costs[len(costs)/2-1].Add(m).Mul(decimal.New(5, -1))
+		}
+		median = &m
+	}
+	// The median barely moves with the one subscription excluded, so it is reused for
+	// the user's other new subscriptions in this scan.
+	scan.medians[userID] = median
+	return median, nil
+}
+
+// flag stores anomaly and, when it is new, emits it as a domain event and notifies the
+// user. Failures are logged and counted so that one bad row does not stop the scan; a
+// failed notification is not retried, the anomaly stays stored.
+func (scan *anomalyScan) flag(ctx context.Context, anomaly *models.Anomaly) {
+	s := scan.service
+	anomaly.ID = uuid.New()
+	anomaly.DetectedAt = s.clock.Now().UTC()
+
+	created, err := s.anomalies.Create(ctx, anomaly)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to store anomaly",
+			slog.String("key", anomaly.Key),
+			slog.String("error", err.Error()))
+		scan.failed++
+		return
+	}
+	if !created {
+		return
+	}
+	scan.flagged++
+
+	s.logger.InfoContext(ctx, "Detected spend anomaly",
+		slog.String("anomaly_id", anomaly.ID.String()),
+		slog.String("kind", anomaly.Kind),
+		slog.String("user_id", anomaly.UserID.String()),
+		slog.String("subscription_id", anomaly.SubscriptionID.String()))
+
+	payload, _ := json.Marshal(anomaly)
+	s.events.Emit(ctx, events.Event{
+		ID:             anomaly.ID,
+		Type:           EventAnomalyDetected,
+		SubscriptionID: &anomaly.SubscriptionID,
+		Actor:          "system",
+		OccurredAt:     anomaly.DetectedAt,
+		After:          payload,
+	})
+
+	if err := s.notify(ctx, anomaly); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to notify user of anomaly",
+			slog.String("anomaly_id", anomaly.ID.String()),
+			slog.String("error", err.Error()))
+		scan.failed++
+	}
+}
+
+func (s *AnomalyService) notify(ctx context.Context, anomaly *models.Anomaly) error {
+	prefs, err := s.preferences.For(ctx, anomaly.UserID)
+	if err != nil {
+		return err
+	}
+	channels := prefs.NotificationChannels
+	if len(channels) == 0 && s.rules.Channel != "" {
+		channels = []string{s.rules.Channel}
+	}
+	if len(channels) == 0 {
+		return nil
+	}
+
+	data := templates.AnomalyData{
+		Kind:        anomaly.Kind,
+		ServiceName: anomaly.ServiceName,
+		MonthlyCost: anomaly.MonthlyCost,
+	}
+	if anomaly.Baseline != nil {
+		data.Baseline = *anomaly.Baseline
+	}
+	// Subscriptions are not tenant-scoped yet, so anomalies use the default tenant's templates.
+	content, err := s.templates.Render(ctx, identity.DefaultTenant, templates.Anomaly, data)
+	if err != nil {
+		return err
+	}
+
+	msg := notify.Message{
+		UserID:         anomaly.UserID,
+		SubscriptionID: &anomaly.SubscriptionID,
+		Template:       templates.Anomaly,
+		Subject:        content.Subject,
+		Text:           content.Text,
+		HTML:           content.HTML,
+	}
+	var errs []error
+	for _, channel := range channels {
+		// Channels removed from the configuration since the user chose them are skipped.
+		if !s.notifier.Has(channel) {
+			continue
+		}
+		if err := s.notifier.Notify(ctx, channel, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// monthlyCost normalizes the price of sub to a monthly cost like the aggregations do,
+// to as many decimals as PostgreSQL keeps for their numeric division.
+func monthlyCost(sub models.Subscription) decimal.Decimal {
+	const places = 16
+	price := decimal.NewFromInt(int64(sub.Price))
+	switch {
+	case sub.Kind == models.KindOneTime:
+		return price
+	case sub.BillingPeriod == models.BillingWeekly:
+		return price.Mul(decimal.NewFromInt(52)).DivRound(decimal.NewFromInt(12), places)
+	case sub.BillingPeriod == models.BillingQuarterly:
+		return price.DivRound(decimal.NewFromInt(3), places)
+	case sub.BillingPeriod == models.BillingYearly:
+		return price.DivRound(decimal.NewFromInt(12), places)
+	}
+	return price
+}
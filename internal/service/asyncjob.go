@@ -0,0 +1,484 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+var (
+	ErrAsyncJobNotFound   = errors.New("async job not found")
+	ErrAsyncJobNoFile     = errors.New("async job has no result file")
+	ErrAsyncJobsClosed    = errors.New("async jobs are shutting down")
+	ErrInvalidDownloadURL = errors.New("invalid download URL")
+	ErrDownloadURLExpired = errors.New("download URL expired")
+)
+
+// staleHeartbeats is the number of missed heartbeats after which a running job is
+// taken for interrupted.
+const staleHeartbeats = 3
+
+// AsyncTask does the work of a job. It reports progress on run and writes the file of
+// a job submitted with one to run.Output. Its result is stored as the job result in
+// JSON, also when it fails, so partial counts are kept.
+type AsyncTask func(ctx context.Context, run *AsyncRun) (any, error)
+
+// AsyncFile names the file a job produces and its media type.
+type AsyncFile struct {
+	Name        string
+	ContentType string
+}
+
+// AsyncJobFiles says where job files are kept and how they are downloaded. With Dir
+// set they are written to local disk instead of the database. With LinkSecret set a
+// succeeded job links to its file by a URL signed with it, which expires after
+// LinkTTL and needs no admin token, so the file can be handed to whoever needs it.
+type AsyncJobFiles struct {
+	Dir        string
+	LinkSecret string
+	LinkTTL    time.Duration
+}
+
+// AsyncDownload is a job file, either on disk at Path or loaded as Content.
+type AsyncDownload struct {
+	Path    string
+	Content []byte
+}
+
+// AsyncRun is the progress of a running job.
+type AsyncRun struct {
+	progress atomic.Int64
+	total    atomic.Int64
+	output   *bytes.Buffer
+}
+
+// SetTotal sets the amount of work, in the unit of the job, once it is known.
+func (r *AsyncRun) SetTotal(total int64) {
+	r.total.Store(total)
+}
+
+func (r *AsyncRun) SetProgress(progress int64) {
+	r.progress.Store(progress)
+}
+
+// Reader wraps src so that every byte read from it counts as progress.
+func (r *AsyncRun) Reader(src io.Reader) io.Reader {
+	return &progressReader{src: src, run: r}
+}
+
+// Output returns the writer of the job's file, counting every byte written as
+// progress. It is nil when the job was submitted without a file.
+func (r *AsyncRun) Output() io.Writer {
+	if r.output == nil {
+		return nil
+	}
+	return &progressWriter{dst: r.output, run: r}
+}
+
+type progressReader struct {
+	src io.Reader
+	run *AsyncRun
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.src.Read(b)
+	p.run.progress.Add(int64(n))
+	return n, err
+}
+
+type progressWriter struct {
+	dst io.Writer
+	run *AsyncRun
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.dst.Write(b)
+	p.run.progress.Add(int64(n))
+	return n, err
+}
+
+// AsyncJobService runs long operations in the background and keeps their state in
+// the database, so it can be polled from any instance and outlives restarts. A running
+// job heartbeats its progress; Sweep fails the jobs whose instance stopped
+// heartbeating, e.g. because it was killed mid-export, and deletes finished jobs after
+// the retention period.
+type AsyncJobService struct {
+	repo      asyncJobRepository
+	clock     clock.Clock
+	heartbeat time.Duration
+	retention time.Duration
+	files     AsyncJobFiles
+	logger    *slog.Logger
+
+	// ctx is the parent of every task; Close cancels it when tasks outlast shutdown.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type asyncJobRepository interface {
+	Create(ctx context.Context, job *models.AsyncJob) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AsyncJob, error)
+	Heartbeat(ctx context.Context, job *models.AsyncJob) error
+	Finish(ctx context.Context, job *models.AsyncJob, file []byte) error
+	File(ctx context.Context, id uuid.UUID) ([]byte, error)
+	FailStale(ctx context.Context, before time.Time, message string, now time.Time) (int64, error)
+	PurgeFinished(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewAsyncJobService(repo asyncJobRepository, clock clock.Clock, heartbeat time.Duration, retention time.Duration, files AsyncJobFiles, logger *slog.Logger) *AsyncJobService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &AsyncJobService{
+		repo:      repo,
+		clock:     clock,
+		heartbeat: heartbeat,
+		retention: retention,
+		files:     files,
+		logger:    logger,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Submit records a running job of kind and starts task in the background with the
+// caller's identity. unit names what the progress counts.
+func (s *AsyncJobService) Submit(ctx context.Context, kind string, unit string, file *AsyncFile, task AsyncTask) (*models.AsyncJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrAsyncJobsClosed
+	}
+
+	id := identity.FromContext(ctx)
+	now := s.clock.Now().UTC()
+	job := &models.AsyncJob{
+		ID:          uuid.New(),
+		Kind:        kind,
+		Status:      models.AsyncJobRunning,
+		Actor:       id.Actor,
+		Unit:        unit,
+		CreatedAt:   now,
+		HeartbeatAt: now,
+	}
+	run := &AsyncRun{}
+	run.total.Store(-1)
+	if file != nil {
+		job.FileName = &file.Name
+		job.ContentType = &file.ContentType
+		run.output = &bytes.Buffer{}
+	}
+	if err := s.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Started async job",
+		slog.String("job_id", job.ID.String()),
+		slog.String("kind", kind),
+		slog.String("actor", id.Actor))
+
+	s.wg.Add(1)
+	snapshot := *job
+	go s.run(identity.WithIdentity(s.ctx, id), &snapshot, run, task)
+	return job, nil
+}
+
+func (s *AsyncJobService) run(ctx context.Context, job *models.AsyncJob, run *AsyncRun, task AsyncTask) {
+	defer s.wg.Done()
+	start := time.Now()
+
+	stop := make(chan struct{})
+	heartbeats := make(chan struct{})
+	go func() {
+		defer close(heartbeats)
+		ticker := s.clock.NewTicker(s.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C():
+				s.record(job, run)
+				job.HeartbeatAt = s.clock.Now().UTC()
+				// A failed heartbeat is logged by the repository; the next one may succeed.
+				_ = s.repo.Heartbeat(context.WithoutCancel(ctx), job)
+			}
+		}
+	}()
+
+	result, err := runTask(ctx, run, task)
+	close(stop)
+	<-heartbeats
+
+	s.record(job, run)
+	now := s.clock.Now().UTC()
+	job.HeartbeatAt = now
+	job.FinishedAt = &now
+	job.Status = models.AsyncJobSucceeded
+	if result != nil {
+		if encoded, marshalErr := json.Marshal(result); marshalErr == nil {
+			job.Result = encoded
+		} else if err == nil {
+			err = fmt.Errorf("encode result: %w", marshalErr)
+		}
+	}
+	var file []byte
+	if err == nil && run.output != nil {
+		file = run.output.Bytes()
+		if s.files.Dir != "" {
+			if writeErr := s.writeFile(job.ID, file); writeErr != nil {
+				err = fmt.Errorf("store file: %w", writeErr)
+			}
+			file = nil
+		}
+	}
+	if err != nil {
+		message := err.Error()
+		job.Status = models.AsyncJobFailed
+		job.Error = &message
+	}
+
+	if finishErr := s.repo.Finish(context.WithoutCancel(ctx), job, file); finishErr != nil {
+		// The job stays running until Sweep finds its heartbeat stale.
+		return
+	}
+
+	attrs := []any{
+		slog.String("job_id", job.ID.String()),
+		slog.String("kind", job.Kind),
+		slog.String("status", job.Status),
+		slog.Int64("progress", job.Progress),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Async job failed", append(attrs, slog.String("error", err.Error()))...)
+		return
+	}
+	s.logger.InfoContext(ctx, "Async job succeeded", attrs...)
+}
+
+// writeFile stores the file of a job in the files directory, renaming it into place so
+// that a download never sees a partial file.
+func (s *AsyncJobService) writeFile(id uuid.UUID, content []byte) error {
+	tmp, err := os.CreateTemp(s.files.Dir, ".job-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.files.Dir, id.String()))
+}
+
+// runTask turns a panicking task into a failed job instead of a crashed process.
+func runTask(ctx context.Context, run *AsyncRun, task AsyncTask) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return task(ctx, run)
+}
+
+// record copies the progress of run onto job.
+func (s *AsyncJobService) record(job *models.AsyncJob, run *AsyncRun) {
+	job.Progress = run.progress.Load()
+	if total := run.total.Load(); total >= 0 {
+		job.Total = &total
+	}
+}
+
+// Get returns a job of the caller. Jobs submitted by anyone else are not found unless
+// the caller is an admin, so a job ID alone does not reveal another caller's job.
+func (s *AsyncJobService) Get(ctx context.Context, id uuid.UUID) (*models.AsyncJob, error) {
+	job, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if caller := identity.FromContext(ctx); !caller.Admin && (caller.Actor == identity.Anonymous || caller.Actor != job.Actor) {
+		return nil, ErrAsyncJobNotFound
+	}
+	return job, nil
+}
+
+func (s *AsyncJobService) find(ctx context.Context, id uuid.UUID) (*models.AsyncJob, error) {
+	job, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAsyncJobNotFound
+	}
+	return job, err
+}
+
+// File returns a succeeded job of the caller with the file it produced.
+func (s *AsyncJobService) File(ctx context.Context, id uuid.UUID) (*models.AsyncJob, *AsyncDownload, error) {
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.file(ctx, job)
+}
+
+// file reads the file of job. Files written before the files directory was configured
+// are still read from the database.
+func (s *AsyncJobService) file(ctx context.Context, job *models.AsyncJob) (*models.AsyncJob, *AsyncDownload, error) {
+	if job.Status != models.AsyncJobSucceeded || job.FileName == nil {
+		return nil, nil, ErrAsyncJobNoFile
+	}
+
+	if s.files.Dir != "" {
+		path := filepath.Join(s.files.Dir, job.ID.String())
+		if _, err := os.Stat(path); err == nil {
+			return job, &AsyncDownload{Path: path}, nil
+		}
+	}
+	content, err := s.repo.File(ctx, job.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrAsyncJobNoFile
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, &AsyncDownload{Content: content}, nil
+}
+
+// DownloadQuery returns the query signing the download URL of a job's file, valid for
+// the link TTL. It reports false when no link secret is configured.
+func (s *AsyncJobService) DownloadQuery(job *models.AsyncJob) (url.Values, bool) {
+	if s.files.LinkSecret == "" {
+		return nil, false
+	}
+	expires := strconv.FormatInt(s.clock.Now().Add(s.files.LinkTTL).Unix(), 10)
+	return url.Values{
+		"expires":   {expires},
+		"signature": {s.signDownload(job.ID, expires)},
+	}, true
+}
+
+// Download returns the file of a job for a URL signed by DownloadQuery.
+func (s *AsyncJobService) Download(ctx context.Context, id uuid.UUID, expires string, signature string) (*models.AsyncJob, *AsyncDownload, error) {
+	if s.files.LinkSecret == "" {
+		return nil, nil, ErrInvalidDownloadURL
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signDownload(id, expires))) {
+		return nil, nil, ErrInvalidDownloadURL
+	}
+	// The signature covers expires, so only a link we issued gets this far.
+	deadline, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return nil, nil, ErrInvalidDownloadURL
+	}
+	if s.clock.Now().Unix() > deadline {
+		return nil, nil, ErrDownloadURLExpired
+	}
+	// The link is the credential, so whoever holds it gets the file.
+	job, err := s.find(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.file(ctx, job)
+}
+
+// signDownload returns the hex HMAC-SHA256 of "JOB_ID\nEXPIRES".
+func (s *AsyncJobService) signDownload(id uuid.UUID, expires string) string {
+	mac := hmac.New(sha256.New, []byte(s.files.LinkSecret))
+	fmt.Fprintf(mac, "%s\n%s", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sweep fails the running jobs that missed their heartbeats and purges the jobs
+// finished before the retention period. It is run by the scheduler.
+func (s *AsyncJobService) Sweep(ctx context.Context) error {
+	now := s.clock.Now().UTC()
+	failed, err := s.repo.FailStale(ctx, now.Add(-staleHeartbeats*s.heartbeat), "interrupted before completion", now)
+	if err != nil {
+		return err
+	}
+	purged, err := s.repo.PurgeFinished(ctx, now.Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	if s.files.Dir != "" {
+		if err := s.purgeFiles(now.Add(-s.retention)); err != nil {
+			return fmt.Errorf("purge job files: %w", err)
+		}
+	}
+
+	if failed > 0 || purged > 0 {
+		s.logger.InfoContext(ctx, "Swept async jobs",
+			slog.Int64("interrupted", failed),
+			slog.Int64("purged", purged))
+	}
+	return nil
+}
+
+// purgeFiles deletes the files written before before, whose jobs are purged along.
+// Every instance may hold files, so this also runs on instances not leading the sweep.
+func (s *AsyncJobService) purgeFiles(before time.Time) error {
+	entries, err := os.ReadDir(s.files.Dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || !info.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.files.Dir, entry.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops accepting jobs and waits until the running ones finish or ctx is done.
+// Tasks still running then are cancelled; the ones that do not record their failure in
+// time are failed by the next Sweep.
+func (s *AsyncJobService) Close(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
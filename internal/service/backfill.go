@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/model"
+)
+
+// Derived data BackfillService computes.
+const (
+	// BackfillEventStreams seeds a snapshot event for every subscription without an
+	// event stream, like es-snapshot but in batches.
+	BackfillEventStreams = "event-streams"
+	// BackfillSearchIndex indexes every subscription outside the trash into the search
+	// index, when one is configured.
+	BackfillSearchIndex = "search-index"
+)
+
+var ErrUnknownBackfill = errors.New("unknown backfill target")
+
+// BackfillOptions control a backfill run. Batches of BatchSize rows are processed by
+// Workers in parallel; Pause is waited by each worker between its batches to leave the
+// database room for the API.
+type BackfillOptions struct {
+	Targets   []string
+	BatchSize int
+	Workers   int
+	Pause     time.Duration
+}
+
+type BackfillResult struct {
+	Target     string
+	Total      int64
+	Scanned    int64
+	Backfilled int64
+	Failed     int64
+}
+
+// BackfillService (re)computes derived data from the subscriptions table, for use
+// after enabling a feature that maintains it on existing data. Each target is
+// idempotent, so an interrupted run can simply be started again.
+type BackfillService struct {
+	targets map[string]backfillTarget
+	logger  *slog.Logger
+}
+
+// backfillTarget scans the rows still to backfill in batches of IDs and backfills a
+// batch, returning how many rows it changed.
+type backfillTarget struct {
+	count func(ctx context.Context) (int64, error)
+	scan  func(ctx context.Context, batchSize int, fn func(ids []uuid.UUID) error) error
+	apply func(ctx context.Context, ids []uuid.UUID) (int64, error)
+}
+
+type backfillStreams interface {
+	CountMissingStreams(ctx context.Context) (int64, error)
+	ForEachMissingStreamBatch(ctx context.Context, batchSize int, fn func(ids []uuid.UUID) error) error
+	SnapshotBatch(ctx context.Context, ids []uuid.UUID) (int64, error)
+}
+
+type backfillSubscriptions interface {
+	CountIndexable(ctx context.Context) (int64, error)
+	ForEachIndexableBatch(ctx context.Context, batchSize int, fn func(ids []uuid.UUID) error) error
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Subscription, error)
+}
+
+type searchIndexer interface {
+	IndexBatch(ctx context.Context, subs []models.Subscription) (int64, error)
+}
+
+// NewBackfillService takes a nil index when no search index is configured, which
+// leaves out the search-index target.
+func NewBackfillService(streams backfillStreams, subscriptions backfillSubscriptions, index searchIndexer, logger *slog.Logger) *BackfillService {
+	targets := map[string]backfillTarget{
+		BackfillEventStreams: {
+			count: streams.CountMissingStreams,
+			scan:  streams.ForEachMissingStreamBatch,
+			apply: streams.SnapshotBatch,
+		},
+	}
+	if index != nil {
+		targets[BackfillSearchIndex] = backfillTarget{
+			count: subscriptions.CountIndexable,
+			scan:  subscriptions.ForEachIndexableBatch,
+			apply: func(ctx context.Context, ids []uuid.UUID) (int64, error) {
+				subs, err := subscriptions.FindByIDs(ctx, ids)
+				if err != nil {
+					return 0, err
+				}
+				return index.IndexBatch(ctx, subs)
+			},
+		}
+	}
+
+	return &BackfillService{
+		targets: targets,
+		logger:  logger,
+	}
+}
+
+// Run backfills opts.Targets one after the other. A batch that fails is logged and
+// counted so the run carries on; Run then returns an error once done.
+func (s *BackfillService) Run(ctx context.Context, opts BackfillOptions) ([]BackfillResult, error) {
+	for _, name := range opts.Targets {
+		if _, ok := s.targets[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownBackfill, name)
+		}
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+
+	results := make([]BackfillResult, 0, len(opts.Targets))
+	var failed []error
+	for _, name := range opts.Targets {
+		result, err := s.backfill(ctx, name, s.targets[name], opts)
+		results = append(results, result)
+		if err != nil {
+			if ctx.Err() != nil {
+				return results, err
+			}
+			failed = append(failed, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return results, errors.Join(failed...)
+}
+
+func (s *BackfillService) backfill(ctx context.Context, name string, target backfillTarget, opts BackfillOptions) (BackfillResult, error) {
+	start := time.Now()
+	result := BackfillResult{Target: name}
+
+	total, err := target.count(ctx)
+	if err != nil {
+		return result, fmt.Errorf("count rows to backfill: %w", err)
+	}
+	result.Total = total
+
+	s.logger.InfoContext(ctx, "Starting backfill",
+		slog.String("target", name),
+		slog.Int64("total", total),
+		slog.Int("batch_size", opts.BatchSize),
+		slog.Int("workers", opts.Workers))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	batches := make(chan []uuid.UUID)
+	for range opts.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			first := true
+			for ids := range batches {
+				if !first && !pause(ctx, opts.Pause) {
+					return
+				}
+				first = false
+
+				count, err := target.apply(ctx, ids)
+
+				mu.Lock()
+				result.Scanned += int64(len(ids))
+				if err != nil {
+					result.Failed += int64(len(ids))
+				} else {
+					result.Backfilled += count
+				}
+				progress := result
+				mu.Unlock()
+
+				if err != nil {
+					s.logger.ErrorContext(ctx, "Failed to backfill batch",
+						slog.String("target", name),
+						slog.String("first_id", ids[0].String()),
+						slog.Int("batch_size", len(ids)),
+						slog.String("error", err.Error()))
+					continue
+				}
+				s.logger.InfoContext(ctx, "Backfill progress",
+					slog.String("target", name),
+					slog.Int64("scanned", progress.Scanned),
+					slog.Int64("total", progress.Total),
+					slog.String("percent", percentOf(progress.Scanned, progress.Total)),
+					slog.Duration("elapsed", time.Since(start)))
+			}
+		}()
+	}
+
+	scanErr := target.scan(ctx, opts.BatchSize, func(ids []uuid.UUID) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case batches <- ids:
+			return nil
+		}
+	})
+	close(batches)
+	wg.Wait()
+
+	s.logger.InfoContext(ctx, "Finished backfill",
+		slog.String("target", name),
+		slog.Int64("scanned", result.Scanned),
+		slog.Int64("backfilled", result.Backfilled),
+		slog.Int64("failed", result.Failed),
+		slog.Duration("duration", time.Since(start)))
+
+	if scanErr != nil {
+		return result, fmt.Errorf("scan rows to backfill: %w", scanErr)
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	if result.Failed > 0 {
+		return result, fmt.Errorf("%d rows could not be backfilled", result.Failed)
+	}
+	return result, nil
+}
+
+// pause waits d and reports whether ctx is still live.
+func pause(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// percentOf formats done as a percentage of total, which may have grown past it when
+// rows were written during the run.
+func percentOf(done int64, total int64) string {
+	if total <= 0 || done >= total {
+		return "100.0"
+	}
+	return fmt.Sprintf("%.1f", float64(done)*100/float64(total))
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+type repositoryBusiness interface {
+	TenantStats(ctx context.Context, tenant string, schema string, day time.Time) (models.TenantStats, error)
+}
+
+// BusinessMetricsService keeps the business figures of every tenant for the metrics,
+// which are read too often to query the database each time.
+type BusinessMetricsService struct {
+	repo    repositoryBusiness
+	schemas map[string]string
+	clock   clock.Clock
+	logger  *slog.Logger
+
+	mu    sync.RWMutex
+	stats map[string]models.TenantStats
+}
+
+// NewBusinessMetricsService covers the default tenant, and the sandbox tenant when
+// sandbox is set.
+func NewBusinessMetricsService(repo repositoryBusiness, sandbox bool, clock clock.Clock, logger *slog.Logger) *BusinessMetricsService {
+	schemas := map[string]string{identity.DefaultTenant: "public"}
+	if sandbox {
+		schemas[identity.SandboxTenant] = "sandbox"
+	}
+	return &BusinessMetricsService{
+		repo:    repo,
+		schemas: schemas,
+		clock:   clock,
+		logger:  logger,
+		stats:   make(map[string]models.TenantStats),
+	}
+}
+
+// Refresh reads the figures of every tenant. The previous figures of a tenant are kept
+// when reading its new ones fails.
+func (s *BusinessMetricsService) Refresh(ctx context.Context) error {
+	now := s.clock.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	for tenant, schema := range s.schemas {
+		stats, err := s.repo.TenantStats(ctx, tenant, schema, day)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.stats[tenant] = stats
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// ActiveSubscriptions returns the number of active subscriptions per tenant.
+func (s *BusinessMetricsService) ActiveSubscriptions() map[string]float64 {
+	return s.values(func(stats models.TenantStats) float64 { return float64(stats.ActiveSubscriptions) })
+}
+
+// MonthlyRecurringRevenue returns the monthly recurring revenue per tenant.
+func (s *BusinessMetricsService) MonthlyRecurringRevenue() map[string]float64 {
+	return s.values(func(stats models.TenantStats) float64 { return stats.MonthlyRecurringRevenue.InexactFloat64() })
+}
+
+func (s *BusinessMetricsService) values(field func(models.TenantStats) float64) map[string]float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	values := make(map[string]float64, len(s.stats))
+	for tenant, stats := range s.stats {
+		values[tenant] = field(stats)
+	}
+	return values
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/model"
+)
+
+var (
+	ErrUnknownCategory       = errors.New("unknown category")
+	ErrInvalidCategorySlug   = errors.New("invalid category slug")
+	ErrCategoryExists        = errors.New("category slug is already taken")
+	ErrCatalogEntryNotFound  = errors.New("service is not in the catalog")
+	ErrInvalidCatalogService = errors.New("invalid catalog service name")
+	ErrServiceAliasNotFound  = errors.New("service alias not found")
+)
+
+var categorySlug = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}$`)
+
+// CategoryService manages the category taxonomy and the service catalog, which gives
+// the category of subscriptions that have none of their own.
+type CategoryService struct {
+	*CRUDService[models.Category]
+	categories categoryRepository
+	catalog    catalogRepository
+	clock      clock.Clock
+	logger     *slog.Logger
+}
+
+type categoryLookup interface {
+	GetBySlug(ctx context.Context, slug string) (*models.Category, error)
+}
+
+type categoryRepository interface {
+	crudRepository[models.Category]
+	categoryLookup
+}
+
+type catalogRepository interface {
+	List(ctx context.Context) ([]models.CatalogEntry, error)
+	Upsert(ctx context.Context, entry *models.CatalogEntry) error
+	Delete(ctx context.Context, serviceName string) error
+	ListAliases(ctx context.Context) ([]models.ServiceAlias, error)
+	UpsertAlias(ctx context.Context, alias *models.ServiceAlias) error
+	DeleteAlias(ctx context.Context, alias string) error
+}
+
+func NewCategoryService(categories categoryRepository, catalog catalogRepository, clock clock.Clock, logger *slog.Logger) *CategoryService {
+	return &CategoryService{
+		CRUDService: NewCRUDService[models.Category](categories, logger, "category"),
+		categories:  categories,
+		catalog:     catalog,
+		clock:       clock,
+		logger:      logger,
+	}
+}
+
+func (s *CategoryService) Create(ctx context.Context, category *models.Category) error {
+	if err := s.checkSlug(ctx, category.ID, category.Slug); err != nil {
+		return err
+	}
+	if category.ID == uuid.Nil {
+		category.ID = uuid.New()
+	}
+	category.CreatedAt = s.clock.Now().UTC()
+	return s.CRUDService.Create(ctx, category)
+}
+
+// Update renames a category. Changing the slug carries over to the subscriptions and
+// catalog entries using it.
+func (s *CategoryService) Update(ctx context.Context, category *models.Category) error {
+	current, err := s.Get(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkSlug(ctx, category.ID, category.Slug); err != nil {
+		return err
+	}
+	category.CreatedAt = current.CreatedAt
+	return s.CRUDService.Update(ctx, category)
+}
+
+// checkSlug rejects malformed slugs and slugs of categories other than id.
+func (s *CategoryService) checkSlug(ctx context.Context, id uuid.UUID, slug string) error {
+	if !categorySlug.MatchString(slug) {
+		return fmt.Errorf("%w: %q", ErrInvalidCategorySlug, slug)
+	}
+	existing, err := s.categories.GetBySlug(ctx, slug)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil
+	case err != nil:
+		return err
+	case existing.ID != id:
+		return withHint(fmt.Errorf("%w: %s", ErrCategoryExists, slug), "hint_category_exists", existing.ID.String())
+	}
+	return nil
+}
+
+func (s *CategoryService) ListCatalog(ctx context.Context) ([]models.CatalogEntry, error) {
+	return s.catalog.List(ctx)
+}
+
+// SaveCatalogEntry puts a service in the catalog under a category, replacing its
+// previous one. Service names match case-insensitively.
+func (s *CategoryService) SaveCatalogEntry(ctx context.Context, serviceName string, category string) (*models.CatalogEntry, error) {
+	serviceName = catalogKey(serviceName)
+	if serviceName == "" {
+		return nil, ErrInvalidCatalogService
+	}
+	if err := checkCategory(ctx, s.categories, category); err != nil {
+		return nil, err
+	}
+
+	entry := &models.CatalogEntry{
+		ServiceName: serviceName,
+		Category:    category,
+		UpdatedAt:   s.clock.Now().UTC(),
+	}
+	if err := s.catalog.Upsert(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *CategoryService) DeleteCatalogEntry(ctx context.Context, serviceName string) error {
+	err := s.catalog.Delete(ctx, catalogKey(serviceName))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrCatalogEntryNotFound
+	}
+	return err
+}
+
+func (s *CategoryService) ListServiceAliases(ctx context.Context) ([]models.ServiceAlias, error) {
+	return s.catalog.ListAliases(ctx)
+}
+
+// SaveServiceAlias makes alias, matched case-insensitively, a spelling of serviceName
+// for the repair command to normalize.
+func (s *CategoryService) SaveServiceAlias(ctx context.Context, alias string, serviceName string) (*models.ServiceAlias, error) {
+	alias = catalogKey(alias)
+	serviceName = strings.TrimSpace(serviceName)
+	if alias == "" || serviceName == "" || alias == catalogKey(serviceName) {
+		return nil, ErrInvalidCatalogService
+	}
+
+	entry := &models.ServiceAlias{
+		Alias:       alias,
+		ServiceName: serviceName,
+		UpdatedAt:   s.clock.Now().UTC(),
+	}
+	if err := s.catalog.UpsertAlias(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *CategoryService) DeleteServiceAlias(ctx context.Context, alias string) error {
+	err := s.catalog.DeleteAlias(ctx, catalogKey(alias))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrServiceAliasNotFound
+	}
+	return err
+}
+
+func catalogKey(serviceName string) string {
+	return strings.ToLower(strings.TrimSpace(serviceName))
+}
+
+// checkCategory returns ErrUnknownCategory unless slug names a category.
+func checkCategory(ctx context.Context, categories categoryLookup, slug string) error {
+	_, err := categories.GetBySlug(ctx, slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return withHint(fmt.Errorf("%w: %s", ErrUnknownCategory, slug), "hint_unknown_category", slug)
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/logging"
+	"awesomeProject1/internal/model"
+)
+
+// crudRepository is satisfied by repository.Repository and by every entity
+// repository embedding it.
+type crudRepository[T models.Entity] interface {
+	Create(ctx context.Context, entity *T) error
+	GetByID(ctx context.Context, id uuid.UUID) (*T, error)
+	Update(ctx context.Context, entity *T) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, page models.Page) ([]T, error)
+}
+
+// CRUDService is the service layer for entities without business rules beyond
+// storage, logging its calls like the logging decorators do. Entity services embed
+// it and override the operations that validate, audit or notify.
+type CRUDService[T models.Entity] struct {
+	repo   crudRepository[T]
+	logger *slog.Logger
+	op     string
+	idKey  string
+}
+
+// NewCRUDService returns a service for T. name is the entity name used in log
+// records, e.g. "plan" logs PlanService.Create with a plan_id.
+func NewCRUDService[T models.Entity](repo crudRepository[T], logger *slog.Logger, name string) *CRUDService[T] {
+	return &CRUDService[T]{
+		repo:   repo,
+		logger: logger,
+		op:     logging.TypeName(name, "Service"),
+		idKey:  strings.ReplaceAll(name, " ", "_") + "_id",
+	}
+}
+
+func (s *CRUDService[T]) Create(ctx context.Context, entity *T) error {
+	return logging.Call(ctx, s.logger, s.op+".Create", func() error {
+		return s.repo.Create(ctx, entity)
+	}, slog.String(s.idKey, (*entity).PrimaryKey().String()))
+}
+
+// Get returns gorm.ErrRecordNotFound from the repository unchanged, like the other
+// services, so handlers map it to 404.
+func (s *CRUDService[T]) Get(ctx context.Context, id uuid.UUID) (*T, error) {
+	return logging.Call1(ctx, s.logger, s.op+".Get", func() (*T, error) {
+		return s.repo.GetByID(ctx, id)
+	}, slog.String(s.idKey, id.String()))
+}
+
+func (s *CRUDService[T]) Update(ctx context.Context, entity *T) error {
+	return logging.Call(ctx, s.logger, s.op+".Update", func() error {
+		return s.repo.Update(ctx, entity)
+	}, slog.String(s.idKey, (*entity).PrimaryKey().String()))
+}
+
+func (s *CRUDService[T]) Delete(ctx context.Context, id uuid.UUID) error {
+	return logging.Call(ctx, s.logger, s.op+".Delete", func() error {
+		return s.repo.Delete(ctx, id)
+	}, slog.String(s.idKey, id.String()))
+}
+
+func (s *CRUDService[T]) List(ctx context.Context, page models.Page) ([]T, error) {
+	return logging.Call1(ctx, s.logger, s.op+".List", func() ([]T, error) {
+		return s.repo.List(ctx, page)
+	}, slog.Int("limit", page.Limit), slog.Int("offset", page.Offset))
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/model"
+)
+
+var (
+	ErrUnknownCustomField           = errors.New("unknown custom field")
+	ErrInvalidCustomField           = errors.New("invalid custom field value")
+	ErrMissingCustomField           = errors.New("missing required custom field")
+	ErrInvalidCustomFieldDefinition = errors.New("invalid custom field definition")
+	ErrCustomFieldNotFound          = errors.New("custom field not found")
+)
+
+// customFieldName keeps names usable as query parameters and JSON keys.
+var customFieldName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// CustomFieldService manages the custom fields tenants define for their
+// subscriptions. SubscriptionService validates the values against them.
+type CustomFieldService struct {
+	repo   customFieldRepository
+	clock  clock.Clock
+	logger *slog.Logger
+}
+
+type customFieldLister interface {
+	List(ctx context.Context, tenant string) ([]models.CustomField, error)
+}
+
+type customFieldRepository interface {
+	customFieldLister
+	Upsert(ctx context.Context, field *models.CustomField) error
+	Delete(ctx context.Context, tenant string, name string) error
+}
+
+func NewCustomFieldService(repo customFieldRepository, clock clock.Clock, logger *slog.Logger) *CustomFieldService {
+	return &CustomFieldService{
+		repo:   repo,
+		clock:  clock,
+		logger: logger,
+	}
+}
+
+func (s *CustomFieldService) List(ctx context.Context, tenant string) ([]models.CustomField, error) {
+	return s.repo.List(ctx, tenant)
+}
+
+// Save creates or replaces a field definition. Values already stored are not
+// revalidated; a field made required is enforced on the next write of each
+// subscription's custom fields.
+func (s *CustomFieldService) Save(ctx context.Context, field *models.CustomField) error {
+	if !customFieldName.MatchString(field.Name) {
+		return fmt.Errorf("%w: name %q", ErrInvalidCustomFieldDefinition, field.Name)
+	}
+	switch field.Type {
+	case models.CustomFieldString, models.CustomFieldNumber, models.CustomFieldBoolean, models.CustomFieldDate:
+	default:
+		return fmt.Errorf("%w: type %q", ErrInvalidCustomFieldDefinition, field.Type)
+	}
+
+	field.UpdatedAt = s.clock.Now().UTC()
+	return s.repo.Upsert(ctx, field)
+}
+
+// Delete removes a field definition. Stored values of the field are dropped the next
+// time the subscription's custom fields are written.
+func (s *CustomFieldService) Delete(ctx context.Context, tenant string, name string) error {
+	err := s.repo.Delete(ctx, tenant, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrCustomFieldNotFound
+	}
+	return err
+}
+
+// customFieldSchema holds the field definitions of a tenant by name.
+type customFieldSchema map[string]models.CustomField
+
+func loadCustomFieldSchema(ctx context.Context, fields customFieldLister, tenant string) (customFieldSchema, error) {
+	list, err := fields.List(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	schema := make(customFieldSchema, len(list))
+	for _, field := range list {
+		schema[field.Name] = field
+	}
+	return schema, nil
+}
+
+// apply merges values into the stored custom fields of a subscription and returns
+// the result, or nil when no field is left. A nil value removes the field; stored
+// values of fields that are no longer defined are dropped.
+func (schema customFieldSchema) apply(stored json.RawMessage, values map[string]any) (json.RawMessage, error) {
+	merged := make(map[string]any)
+	if len(stored) > 0 {
+		if err := json.Unmarshal(stored, &merged); err != nil {
+			return nil, fmt.Errorf("decode stored custom fields: %w", err)
+		}
+	}
+	for name := range merged {
+		if _, ok := schema[name]; !ok {
+			delete(merged, name)
+		}
+	}
+
+	for name, value := range values {
+		field, ok := schema[name]
+		if !ok {
+			return nil, withHint(fmt.Errorf("%w: %s", ErrUnknownCustomField, name), "hint_unknown_custom_field", name)
+		}
+		if value == nil {
+			delete(merged, name)
+			continue
+		}
+		if !acceptsCustomField(field, value) {
+			return nil, withHint(fmt.Errorf("%w: %s", ErrInvalidCustomField, name), "hint_custom_field_type", name, field.Type)
+		}
+		merged[name] = value
+	}
+
+	for name, field := range schema {
+		if _, ok := merged[name]; field.Required && !ok {
+			return nil, withHint(fmt.Errorf("%w: %s", ErrMissingCustomField, name), "hint_missing_custom_field", name)
+		}
+	}
+
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(merged)
+}
+
+// match converts filter values, given as query strings, to the types of their
+// fields so that they compare equal to the stored JSON values.
+func (schema customFieldSchema) match(filter map[string]any) (map[string]any, error) {
+	typed := make(map[string]any, len(filter))
+	for name, value := range filter {
+		field, ok := schema[name]
+		if !ok {
+			return nil, withHint(fmt.Errorf("%w: %s", ErrUnknownCustomField, name), "hint_unknown_custom_field", name)
+		}
+		parsed, err := parseCustomField(field, fmt.Sprint(value))
+		if err != nil {
+			return nil, withHint(fmt.Errorf("%w: %s: %w", ErrInvalidCustomField, name, err), "hint_custom_field_type", name, field.Type)
+		}
+		typed[name] = parsed
+	}
+	return typed, nil
+}
+
+// acceptsCustomField reports whether a value decoded from a JSON request body has the
+// field's type.
+func acceptsCustomField(field models.CustomField, value any) bool {
+	switch field.Type {
+	case models.CustomFieldString:
+		_, ok := value.(string)
+		return ok
+	case models.CustomFieldNumber:
+		_, ok := value.(float64)
+		return ok
+	case models.CustomFieldBoolean:
+		_, ok := value.(bool)
+		return ok
+	case models.CustomFieldDate:
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	}
+	return false
+}
+
+func parseCustomField(field models.CustomField, s string) (any, error) {
+	switch field.Type {
+	case models.CustomFieldNumber:
+		return strconv.ParseFloat(s, 64)
+	case models.CustomFieldBoolean:
+		return strconv.ParseBool(s)
+	case models.CustomFieldDate:
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
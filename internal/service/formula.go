@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/formula"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+// ErrInvalidFormula is the error of package formula, so that parse errors need no
+// wrapping to keep their position.
+var (
+	ErrInvalidFormula  = formula.ErrInvalid
+	ErrFormulaNotFound = errors.New("aggregation formula not found")
+)
+
+// FormulaService manages the aggregation formulas tenants define and evaluates them
+// with the aggregation of SubscriptionService, one aggregation per distinct term.
+type FormulaService struct {
+	repo       formulaRepository
+	aggregator aggregator
+	clock      clock.Clock
+	logger     *slog.Logger
+}
+
+type formulaRepository interface {
+	List(ctx context.Context, tenant string) ([]models.AggregationFormula, error)
+	Get(ctx context.Context, tenant string, name string) (*models.AggregationFormula, error)
+	Upsert(ctx context.Context, formula *models.AggregationFormula) error
+	Delete(ctx context.Context, tenant string, name string) error
+}
+
+type aggregator interface {
+	Aggregate(ctx context.Context, startDateStr string, endDateStr string, mode string, filter models.AggregateFilter) (decimal.Decimal, error)
+}
+
+func NewFormulaService(repo formulaRepository, aggregator aggregator, clock clock.Clock, logger *slog.Logger) *FormulaService {
+	return &FormulaService{
+		repo:       repo,
+		aggregator: aggregator,
+		clock:      clock,
+		logger:     logger,
+	}
+}
+
+func (s *FormulaService) List(ctx context.Context, tenant string) ([]models.AggregationFormula, error) {
+	return s.repo.List(ctx, tenant)
+}
+
+// Save creates or replaces a formula once its expression parses.
+func (s *FormulaService) Save(ctx context.Context, f *models.AggregationFormula) error {
+	if !customFieldName.MatchString(f.Name) {
+		return fmt.Errorf("%w: name %q", ErrInvalidFormula, f.Name)
+	}
+	if _, err := formula.Parse(f.Expression); err != nil {
+		return err
+	}
+
+	f.UpdatedAt = s.clock.Now().UTC()
+	return s.repo.Upsert(ctx, f)
+}
+
+func (s *FormulaService) Delete(ctx context.Context, tenant string, name string) error {
+	err := s.repo.Delete(ctx, tenant, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrFormulaNotFound
+	}
+	return err
+}
+
+// Run evaluates the formula of the caller's tenant over the MM-YYYY period. Terms are
+// aggregated like the aggregate endpoint does, so they are narrowed to the impersonated
+// user as well.
+func (s *FormulaService) Run(ctx context.Context, name string, startDateStr string, endDateStr string, mode string) (*models.FormulaResult, error) {
+	mode, err := aggregationMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	startPeriod, endPeriod, err := aggregationPeriod(startDateStr, endDateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.repo.Get(ctx, identity.FromContext(ctx).Tenant, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFormulaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Formulas are validated when saved, so this only fails for rows written otherwise.
+	parsed, err := formula.Parse(stored.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.FormulaResult{
+		Name:       stored.Name,
+		Expression: stored.Expression,
+		Start:      startPeriod,
+		End:        endPeriod,
+		Mode:       mode,
+		Terms:      make([]models.FormulaTerm, 0, len(parsed.Terms())),
+	}
+	values := make(map[string]decimal.Decimal, len(parsed.Terms()))
+	for _, term := range parsed.Terms() {
+		total, err := s.aggregator.Aggregate(ctx, startDateStr, endDateStr, mode, termFilter(term))
+		if err != nil {
+			return nil, fmt.Errorf("aggregate %s: %w", term, err)
+		}
+		values[term.String()] = total
+		result.Terms = append(result.Terms, models.FormulaTerm{Term: term.String(), Value: total})
+	}
+
+	if result.Value, err = parsed.Eval(values); err != nil {
+		return nil, err
+	}
+
+	s.logger.DebugContext(ctx, "Evaluated aggregation formula",
+		slog.String("formula", name),
+		slog.Int("terms", len(result.Terms)),
+		slog.String("value", result.Value.String()))
+
+	return result, nil
+}
+
+// termFilter turns a formula term into the filter of its aggregation.
+func termFilter(term formula.Term) models.AggregateFilter {
+	switch term.Func {
+	case formula.Category:
+		return models.AggregateFilter{Categories: term.Args}
+	case formula.Service:
+		return models.AggregateFilter{ServiceNames: term.Args}
+	case formula.Kind:
+		return models.AggregateFilter{Kinds: term.Args}
+	default:
+		return models.AggregateFilter{}
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+
+	"awesomeProject1/internal/tenancy"
+)
+
+// Hint tells the caller how to fix a request that failed with a domain error. Code is
+// a message key localized by the handler, Args fill its placeholders.
+type Hint struct {
+	Code string
+	Args []any
+}
+
+type hintedError struct {
+	err  error
+	hint Hint
+}
+
+func (e *hintedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *hintedError) Unwrap() error {
+	return e.err
+}
+
+// withHint attaches a hint that depends on the failed request, overriding the
+// default hint of err.
+func withHint(err error, code string, args ...any) error {
+	return &hintedError{err: err, hint: Hint{Code: code, Args: args}}
+}
+
+// defaultHints apply when no hint was attached where the error was returned.
+var defaultHints = []struct {
+	err  error
+	code string
+}{
+	{ErrInvalidStartDate, "hint_month_year_format"},
+	{ErrInvalidEndDate, "hint_month_year_format"},
+	{ErrInvalidSplitDate, "hint_month_year_format"},
+	{ErrEndBeforeStart, "hint_end_before_start"},
+	{ErrSubjectMismatch, "hint_subject_mismatch"},
+	{ErrInvalidBucket, "hint_bucket"},
+	{ErrInvalidMode, "hint_mode"},
+	{ErrMergeTooFew, "hint_merge_too_few"},
+	{ErrMergeUserMismatch, "hint_merge_mismatch"},
+	{ErrMergeServiceMismatch, "hint_merge_mismatch"},
+	{ErrMergeKindMismatch, "hint_merge_mismatch"},
+	{ErrMergeNotContiguous, "hint_merge_not_contiguous"},
+	{ErrNotRecurring, "hint_not_recurring"},
+	{ErrUnknownChannel, "hint_unknown_channel"},
+	{ErrInvalidCustomFieldDefinition, "hint_custom_field_definition"},
+	{ErrInvalidCategorySlug, "hint_category_slug"},
+	{tenancy.ErrInvalidName, "hint_tenant_name"},
+}
+
+// HintFor returns the remediation hint for err, if there is one.
+func HintFor(err error) (Hint, bool) {
+	var hinted *hintedError
+	if errors.As(err, &hinted) {
+		return hinted.hint, true
+	}
+	for _, known := range defaultHints {
+		if errors.Is(err, known.err) {
+			return Hint{Code: known.code}, true
+		}
+	}
+	return Hint{}, false
+}
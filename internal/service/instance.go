@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/buildinfo"
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/model"
+)
+
+// Instances that miss this many heartbeats are no longer listed, and are deleted once
+// they have been silent for pruneAfterBeats.
+const (
+	missedBeats     = 3
+	pruneAfterBeats = 100
+)
+
+type InstanceService struct {
+	repo     repositoryInstance
+	leader   leadership
+	interval time.Duration
+	clock    clock.Clock
+	logger   *slog.Logger
+}
+
+type repositoryInstance interface {
+	Heartbeat(ctx context.Context, instance *models.Instance) error
+	SeenSince(ctx context.Context, since time.Time) ([]models.Instance, error)
+	PruneBefore(ctx context.Context, before time.Time) (int64, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type leadership interface {
+	IsLeader() bool
+}
+
+func NewInstanceService(repo repositoryInstance, leader leadership, interval time.Duration, clock clock.Clock, logger *slog.Logger) *InstanceService {
+	return &InstanceService{
+		repo:     repo,
+		leader:   leader,
+		interval: interval,
+		clock:    clock,
+		logger:   logger,
+	}
+}
+
+// Heartbeat records this instance as alive and prunes instances that have long
+// stopped reporting.
+func (s *InstanceService) Heartbeat(ctx context.Context) error {
+	info := buildinfo.Current()
+	now := s.clock.Now().UTC()
+
+	err := s.repo.Heartbeat(ctx, &models.Instance{
+		ID:         info.InstanceID,
+		Hostname:   info.Hostname,
+		Version:    info.Version,
+		Commit:     info.Commit,
+		Leader:     s.leader.IsLeader(),
+		StartedAt:  info.StartedAt,
+		LastSeenAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	pruned, err := s.repo.PruneBefore(ctx, now.Add(-pruneAfterBeats*s.interval))
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		s.logger.InfoContext(ctx, "Pruned stale instances", slog.Int64("count", pruned))
+	}
+	return nil
+}
+
+// Active lists the instances that sent a heartbeat recently.
+func (s *InstanceService) Active(ctx context.Context) ([]models.Instance, error) {
+	since := s.clock.Now().UTC().Add(-missedBeats * s.interval)
+	return s.repo.SeenSince(ctx, since)
+}
+
+// Leave removes this instance from the list on graceful shutdown.
+func (s *InstanceService) Leave(ctx context.Context) error {
+	return s.repo.Delete(ctx, buildinfo.Current().InstanceID)
+}
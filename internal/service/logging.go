@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"awesomeProject1/internal/logging"
+	"awesomeProject1/internal/model"
+)
+
+// LoggingSubscriptionService logs every call to the wrapped service.
+type LoggingSubscriptionService struct {
+	next   *SubscriptionService
+	logger *slog.Logger
+}
+
+func NewLoggingSubscriptionService(next *SubscriptionService, logger *slog.Logger) *LoggingSubscriptionService {
+	return &LoggingSubscriptionService{
+		next:   next,
+		logger: logger,
+	}
+}
+
+func (s *LoggingSubscriptionService) Create(ctx context.Context, serviceName string, price int, userID uuid.UUID, kind string, billingPeriod string, billingAnchorDay int, startDateStr string, endDateStr string, status string, category string, customFields map[string]any, overrideLimit bool) (*models.Subscription, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.Create", func() (*models.Subscription, error) {
+		return s.next.Create(ctx, serviceName, price, userID, kind, billingPeriod, billingAnchorDay, startDateStr, endDateStr, status, category, customFields, overrideLimit)
+	}, slog.String("service_name", serviceName), slog.Int("price", price), slog.String("user_id", userID.String()),
+		slog.String("kind", kind), slog.String("billing_period", billingPeriod), slog.Int("billing_anchor_day", billingAnchorDay),
+		slog.String("start_date", startDateStr), slog.String("end_date", endDateStr), slog.String("status", status))
+}
+
+func (s *LoggingSubscriptionService) CreateBatch(ctx context.Context, drafts []models.SubscriptionDraft, overrideLimit bool) ([]models.Subscription, []error, error) {
+	return logging.Call2(ctx, s.logger, "SubscriptionService.CreateBatch", func() ([]models.Subscription, []error, error) {
+		return s.next.CreateBatch(ctx, drafts, overrideLimit)
+	}, slog.Int("count", len(drafts)), slog.Bool("override_limit", overrideLimit))
+}
+
+func (s *LoggingSubscriptionService) GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.GetByID", func() (*models.Subscription, error) {
+		return s.next.GetByID(ctx, id)
+	}, slog.String("subscription_id", id.String()))
+}
+
+func (s *LoggingSubscriptionService) Update(ctx context.Context, id uuid.UUID, serviceName string, price int, kind string, billingPeriod string, billingAnchorDay int, startDateStr string, endDateStr string, category *string, customFields map[string]any) (*models.Subscription, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.Update", func() (*models.Subscription, error) {
+		return s.next.Update(ctx, id, serviceName, price, kind, billingPeriod, billingAnchorDay, startDateStr, endDateStr, category, customFields)
+	}, slog.String("subscription_id", id.String()), slog.String("service_name", serviceName), slog.Int("price", price),
+		slog.String("kind", kind), slog.String("billing_period", billingPeriod), slog.Int("billing_anchor_day", billingAnchorDay),
+		slog.String("start_date", startDateStr), slog.String("end_date", endDateStr))
+}
+
+func (s *LoggingSubscriptionService) Delete(ctx context.Context, id uuid.UUID) error {
+	return logging.Call(ctx, s.logger, "SubscriptionService.Delete", func() error {
+		return s.next.Delete(ctx, id)
+	}, slog.String("subscription_id", id.String()))
+}
+
+func (s *LoggingSubscriptionService) Cancel(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.Cancel", func() (*models.Subscription, error) {
+		return s.next.Cancel(ctx, id)
+	}, slog.String("subscription_id", id.String()))
+}
+
+func (s *LoggingSubscriptionService) Merge(ctx context.Context, ids []uuid.UUID) (*models.Subscription, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.Merge", func() (*models.Subscription, error) {
+		return s.next.Merge(ctx, ids)
+	}, slog.Any("subscription_ids", ids))
+}
+
+func (s *LoggingSubscriptionService) MergePair(ctx context.Context, id uuid.UUID, otherID uuid.UUID) (*models.Subscription, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.MergePair", func() (*models.Subscription, error) {
+		return s.next.MergePair(ctx, id, otherID)
+	}, slog.String("subscription_id", id.String()), slog.String("other_id", otherID.String()))
+}
+
+func (s *LoggingSubscriptionService) Split(ctx context.Context, id uuid.UUID, atStr string, price int, userID uuid.UUID) (*models.Subscription, *models.Subscription, error) {
+	return logging.Call2(ctx, s.logger, "SubscriptionService.Split", func() (*models.Subscription, *models.Subscription, error) {
+		return s.next.Split(ctx, id, atStr, price, userID)
+	}, slog.String("subscription_id", id.String()), slog.String("at", atStr), slog.Int("price", price),
+		slog.String("user_id", userID.String()))
+}
+
+func (s *LoggingSubscriptionService) Undo(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.Undo", func() (*models.Subscription, error) {
+		return s.next.Undo(ctx, id)
+	}, slog.String("subscription_id", id.String()))
+}
+
+func (s *LoggingSubscriptionService) PurgeTrash(ctx context.Context) error {
+	return logging.Call(ctx, s.logger, "SubscriptionService.PurgeTrash", func() error {
+		return s.next.PurgeTrash(ctx)
+	})
+}
+
+func (s *LoggingSubscriptionService) ActivateScheduled(ctx context.Context) error {
+	return logging.Call(ctx, s.logger, "SubscriptionService.ActivateScheduled", func() error {
+		return s.next.ActivateScheduled(ctx)
+	})
+}
+
+func (s *LoggingSubscriptionService) List(ctx context.Context, filter models.ListFilter, page models.Page) ([]models.Subscription, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.List", func() ([]models.Subscription, error) {
+		return s.next.List(ctx, filter, page)
+	}, slog.String("user_id", filter.UserID.String()), slog.String("service_name", filter.ServiceName),
+		slog.String("kind", filter.Kind), slog.Int("limit", page.Limit), slog.Int("offset", page.Offset))
+}
+
+func (s *LoggingSubscriptionService) Count(ctx context.Context, filter models.ListFilter) (int64, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.Count", func() (int64, error) {
+		return s.next.Count(ctx, filter)
+	}, slog.String("user_id", filter.UserID.String()), slog.String("service_name", filter.ServiceName),
+		slog.String("kind", filter.Kind))
+}
+
+func (s *LoggingSubscriptionService) Facets(ctx context.Context, filter models.ListFilter) (*models.FacetSummary, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.Facets", func() (*models.FacetSummary, error) {
+		return s.next.Facets(ctx, filter)
+	}, slog.String("user_id", filter.UserID.String()), slog.String("service_name", filter.ServiceName),
+		slog.String("kind", filter.Kind))
+}
+
+func (s *LoggingSubscriptionService) Aggregate(ctx context.Context, startDateStr string, endDateStr string, mode string, filter models.AggregateFilter) (decimal.Decimal, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.Aggregate", func() (decimal.Decimal, error) {
+		return s.next.Aggregate(ctx, startDateStr, endDateStr, mode, filter)
+	}, slog.String("start_date", startDateStr), slog.String("end_date", endDateStr), slog.String("mode", mode),
+		slog.Any("filter", filter))
+}
+
+func (s *LoggingSubscriptionService) AggregateByBucket(ctx context.Context, startDateStr string, endDateStr string, bucket string, mode string, filter models.AggregateFilter) ([]models.BucketTotal, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.AggregateByBucket", func() ([]models.BucketTotal, error) {
+		return s.next.AggregateByBucket(ctx, startDateStr, endDateStr, bucket, mode, filter)
+	}, slog.String("start_date", startDateStr), slog.String("end_date", endDateStr), slog.String("bucket", bucket),
+		slog.String("mode", mode), slog.Any("filter", filter))
+}
+
+func (s *LoggingSubscriptionService) AggregateDetails(ctx context.Context, startDateStr string, endDateStr string, mode string, filter models.AggregateFilter) (*models.AggregateBreakdown, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.AggregateDetails", func() (*models.AggregateBreakdown, error) {
+		return s.next.AggregateDetails(ctx, startDateStr, endDateStr, mode, filter)
+	}, slog.String("start_date", startDateStr), slog.String("end_date", endDateStr), slog.String("mode", mode),
+		slog.Any("filter", filter))
+}
+
+func (s *LoggingSubscriptionService) AggregateByCategory(ctx context.Context, startDateStr string, endDateStr string, mode string, filter models.AggregateFilter) ([]models.CategoryTotal, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.AggregateByCategory", func() ([]models.CategoryTotal, error) {
+		return s.next.AggregateByCategory(ctx, startDateStr, endDateStr, mode, filter)
+	}, slog.String("start_date", startDateStr), slog.String("end_date", endDateStr), slog.String("mode", mode),
+		slog.Any("filter", filter))
+}
+
+func (s *LoggingSubscriptionService) Simulate(ctx context.Context, userID uuid.UUID, startDateStr string, endDateStr string, mode string, changes []models.SimulationChange) (*models.SimulationResult, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.Simulate", func() (*models.SimulationResult, error) {
+		return s.next.Simulate(ctx, userID, startDateStr, endDateStr, mode, changes)
+	}, slog.String("user_id", userID.String()), slog.String("start_date", startDateStr), slog.String("end_date", endDateStr),
+		slog.String("mode", mode), slog.Int("changes", len(changes)))
+}
+
+func (s *LoggingSubscriptionService) Stats(ctx context.Context, filter models.AggregateFilter) (*models.SubscriptionStats, error) {
+	return logging.Call1(ctx, s.logger, "SubscriptionService.Stats", func() (*models.SubscriptionStats, error) {
+		return s.next.Stats(ctx, filter)
+	}, slog.Any("filter", filter))
+}
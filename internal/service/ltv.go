@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/audit"
+	"awesomeProject1/internal/cache"
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/events"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/rounding"
+)
+
+// ltvCacheTTL bounds how long a lifetime value is served after writes made through
+// other replicas, and after charges that came due since it was computed.
+const ltvCacheTTL = 10 * time.Minute
+
+// LTVService computes what users have spent on their subscriptions over time.
+type LTVService struct {
+	subscriptions subscriptionLister
+	audit         auditLister
+	values        *cache.Cache[*models.LifetimeValue]
+	rounding      rounding.Policy
+	clock         clock.Clock
+	logger        *slog.Logger
+}
+
+// NewLTVService rounds the amounts it reports by policy, once each, from their exact
+// values.
+func NewLTVService(subscriptions subscriptionLister, audit auditLister, policy rounding.Policy, clock clock.Clock, logger *slog.Logger) *LTVService {
+	return &LTVService{
+		subscriptions: subscriptions,
+		audit:         audit,
+		values:        cache.New[*models.LifetimeValue](ltvCacheTTL, 10000, clock),
+		rounding:      policy,
+		clock:         clock,
+		logger:        logger,
+	}
+}
+
+// LifetimeValue sums the charges of the user's subscriptions that have come due, at
+// the prices in effect when they did. Price changes recorded in the audit log are
+// prorated over the billing period they fall in. Scheduled subscriptions and those in
+// the trash are left out. Values are cached until one of the user's subscriptions is
+// written.
+func (s *LTVService) LifetimeValue(ctx context.Context, userID uuid.UUID) (*models.LifetimeValue, error) {
+	if id := identity.FromContext(ctx); id.Impersonating() && *id.Subject != userID {
+		s.logger.WarnContext(ctx, "Lifetime value requested for a different user than the impersonated one",
+			slog.String("user_id", userID.String()),
+			slog.String("subject_id", id.Subject.String()))
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	key := ltvKey(ctx, userID)
+	if value, ok := s.values.Get(key); ok {
+		return value, nil
+	}
+
+	subs, err := s.subscriptions.List(ctx, models.ListFilter{UserID: userID}, models.Page{})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list subscriptions for lifetime value",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+	history, err := s.priceHistory(ctx, subs)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list price changes for lifetime value",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	value := &models.LifetimeValue{
+		UserID:              userID,
+		TotalSpend:          decimal.Zero,
+		AverageMonthlySpend: decimal.Zero,
+		ComputedAt:          now,
+	}
+	totalSpend := new(big.Rat)
+	var first, last time.Time
+	for i := range subs {
+		sub := &subs[i]
+		if sub.Status == models.StatusScheduled || sub.StartDate.After(now) {
+			continue
+		}
+
+		spend, charges := lifetimeSpend(sub, history[sub.ID], now)
+		totalSpend.Add(totalSpend, spend)
+		value.Charges += charges
+		value.Subscriptions++
+
+		if first.IsZero() || sub.StartDate.Before(first) {
+			first = sub.StartDate
+		}
+		lastMonth := currentMonth
+		if sub.EndDate != nil && sub.EndDate.Before(currentMonth) {
+			lastMonth = *sub.EndDate
+		}
+		if lastMonth.After(last) {
+			last = lastMonth
+		}
+	}
+	if value.Subscriptions > 0 {
+		value.FirstSubscribed = &first
+		value.TenureMonths = (last.Year()-first.Year())*12 + int(last.Month()-first.Month()) + 1
+		value.TotalSpend = s.rounding.Rat(totalSpend)
+		average := new(big.Rat).Quo(totalSpend, new(big.Rat).SetInt64(int64(value.TenureMonths)))
+		value.AverageMonthlySpend = s.rounding.Rat(average)
+	}
+
+	s.values.Set(key, value)
+	s.logger.InfoContext(ctx, "Computed user lifetime value",
+		slog.String("user_id", userID.String()),
+		slog.String("total_spend", value.TotalSpend.String()),
+		slog.Int("tenure_months", value.TenureMonths))
+	return value, nil
+}
+
+// Invalidate forgets the lifetime value of the user the subscription of event belongs
+// to, before and after the change. It is meant to be subscribed to the events of
+// this replica.
+func (s *LTVService) Invalidate(ctx context.Context, event events.Event) {
+	for _, state := range []json.RawMessage{event.Before, event.After} {
+		var sub struct {
+			UserID uuid.UUID `json:"user_id"`
+		}
+		if json.Unmarshal(state, &sub) == nil && sub.UserID != uuid.Nil {
+			s.values.Delete(ltvKey(ctx, sub.UserID))
+		}
+	}
+}
+
+func (s *LTVService) CacheStats() cache.Stats {
+	return s.values.Stats()
+}
+
+func ltvKey(ctx context.Context, userID uuid.UUID) string {
+	return identity.FromContext(ctx).Tenant + "/" + userID.String()
+}
+
+// pricePoint is the price of a subscription from at on; the first point of a history
+// has a zero at.
+type pricePoint struct {
+	at    time.Time
+	price int64
+}
+
+// priceHistory returns the prices of the subscriptions whose price was ever changed,
+// oldest first, from the audit log.
+func (s *LTVService) priceHistory(ctx context.Context, subs []models.Subscription) (map[uuid.UUID][]pricePoint, error) {
+	history := make(map[uuid.UUID][]pricePoint)
+	if len(subs) == 0 {
+		return history, nil
+	}
+
+	ids := make([]uuid.UUID, len(subs))
+	for i, sub := range subs {
+		ids[i] = sub.ID
+	}
+	records, err := s.audit.List(ctx, models.AuditFilter{
+		SubscriptionIDs: ids,
+		Actions:         []string{audit.ActionUpdate, audit.ActionMerge, audit.ActionRepair},
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+
+	for _, record := range records {
+		var before, after struct {
+			Price int `json:"price"`
+		}
+		if json.Unmarshal(record.Before, &before) != nil || json.Unmarshal(record.After, &after) != nil {
+			continue
+		}
+		if before.Price == 0 || after.Price == 0 || before.Price == after.Price {
+			continue
+		}
+
+		id := *record.SubscriptionID
+		if len(history[id]) == 0 {
+			history[id] = []pricePoint{{price: int64(before.Price)}}
+		}
+		history[id] = append(history[id], pricePoint{at: record.CreatedAt, price: int64(after.Price)})
+	}
+	return history, nil
+}
+
+// lifetimeSpend sums the charges of sub due by now, exactly, and counts them. One-time
+// and lifetime purchases are charged once, at the price they were bought for.
+func lifetimeSpend(sub *models.Subscription, history []pricePoint, now time.Time) (*big.Rat, int) {
+	if len(history) == 0 {
+		history = []pricePoint{{price: int64(sub.Price)}}
+	}
+
+	if sub.Kind != models.KindRecurring {
+		charge := nthCharge(sub, 0)
+		if charge.After(now) {
+			return new(big.Rat), 0
+		}
+		return new(big.Rat).SetInt64(priceAt(history, charge)), 1
+	}
+
+	total := new(big.Rat)
+	charges := 0
+	for n := 0; ; n++ {
+		charge := nthCharge(sub, n)
+		// The end date is the last billed month, so charges stop when it is over.
+		if charge.After(now) || (sub.EndDate != nil && !charge.Before(sub.EndDate.AddDate(0, 1, 0))) {
+			return total, charges
+		}
+		total.Add(total, prorate(history, charge, nthCharge(sub, n+1)))
+		charges++
+	}
+}
+
+// priceAt returns the price in effect at t.
+func priceAt(history []pricePoint, t time.Time) int64 {
+	price := history[0].price
+	for _, point := range history[1:] {
+		if point.at.After(t) {
+			break
+		}
+		price = point.price
+	}
+	return price
+}
+
+// prorate charges the billing period [from, to) at each price in effect during it, for
+// the share of the period it was in effect. The shares are summed unrounded.
+func prorate(history []pricePoint, from time.Time, to time.Time) *big.Rat {
+	weighted := new(big.Int)
+	for i, point := range history {
+		start, end := point.at, to
+		if start.Before(from) {
+			start = from
+		}
+		if i+1 < len(history) && history[i+1].at.Before(to) {
+			end = history[i+1].at
+		}
+		if !end.After(start) {
+			continue
+		}
+		share := new(big.Int).Mul(big.NewInt(point.price), big.NewInt(int64(end.Sub(start))))
+		weighted.Add(weighted, share)
+	}
+	return new(big.Rat).SetFrac(weighted, big.NewInt(int64(to.Sub(from))))
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/notify"
+)
+
+// Actions of the maintenance job when the subscriptions table has too many dead tuples.
+const (
+	MaintenanceAlert   = "alert"
+	MaintenanceAnalyze = "analyze"
+)
+
+const maintainedTable = "subscriptions"
+
+// MaintenanceRules configure the maintenance job. The table needs attention once its
+// dead tuples exceed both DeadRatioPercent of its tuples and MinDeadTuples. ANALYZE
+// only runs between WindowStart and WindowEnd, offsets from UTC midnight; the window
+// may wrap past midnight.
+type MaintenanceRules struct {
+	Action           string
+	DeadRatioPercent int
+	MinDeadTuples    int
+	WindowStart      time.Duration
+	WindowEnd        time.Duration
+}
+
+// MaintenanceService watches the dead tuples of the subscriptions table, which grows
+// them with every update and soft delete, and alerts operators or refreshes its
+// planner statistics at low-traffic hours.
+type MaintenanceService struct {
+	tables maintenanceTables
+	alerts alerter
+	rules  MaintenanceRules
+	clock  clock.Clock
+	logger *slog.Logger
+}
+
+type maintenanceTables interface {
+	TableStats(ctx context.Context, table string) (*models.TableStats, error)
+	Analyze(ctx context.Context, table string) error
+}
+
+func NewMaintenanceService(tables maintenanceTables, alerts alerter, rules MaintenanceRules, clock clock.Clock, logger *slog.Logger) *MaintenanceService {
+	return &MaintenanceService{
+		tables: tables,
+		alerts: alerts,
+		rules:  rules,
+		clock:  clock,
+		logger: logger,
+	}
+}
+
+// Check reads the statistics of the subscriptions table and acts on them when it has
+// too many dead tuples. It is run by the scheduler.
+func (s *MaintenanceService) Check(ctx context.Context) error {
+	stats, err := s.tables.TableStats(ctx, maintainedTable)
+	if err != nil {
+		return fmt.Errorf("read %s statistics: %w", maintainedTable, err)
+	}
+	if stats.DeadTuples < int64(s.rules.MinDeadTuples) || stats.DeadRatio*100 < float64(s.rules.DeadRatioPercent) {
+		s.logger.DebugContext(ctx, "Table needs no maintenance",
+			slog.String("table", maintainedTable),
+			slog.Int64("dead_tuples", stats.DeadTuples),
+			slog.Float64("dead_ratio", stats.DeadRatio))
+		return nil
+	}
+
+	s.logger.WarnContext(ctx, "Table has too many dead tuples",
+		slog.String("table", maintainedTable),
+		slog.Int64("live_tuples", stats.LiveTuples),
+		slog.Int64("dead_tuples", stats.DeadTuples),
+		slog.Float64("dead_ratio", stats.DeadRatio),
+		slog.String("action", s.rules.Action))
+
+	if s.rules.Action == MaintenanceAlert {
+		s.alerts.Alert(ctx, notify.AlertTableBloat,
+			fmt.Sprintf("Table %s needs VACUUM", maintainedTable),
+			fmt.Sprintf("%d of %d tuples (%.0f%%) are dead; last autovacuum: %s",
+				stats.DeadTuples, stats.LiveTuples+stats.DeadTuples, stats.DeadRatio*100, formatLastRun(stats.LastAutovacuum)))
+		return nil
+	}
+
+	now := s.clock.Now().UTC()
+	windowStart, open := s.window(now)
+	if !open {
+		s.logger.InfoContext(ctx, "Deferring ANALYZE to the maintenance window",
+			slog.String("table", maintainedTable),
+			slog.Duration("window_start", s.rules.WindowStart))
+		return nil
+	}
+	if analyzedSince(stats, windowStart) {
+		return nil
+	}
+
+	start := time.Now()
+	if err := s.tables.Analyze(ctx, maintainedTable); err != nil {
+		return fmt.Errorf("analyze %s: %w", maintainedTable, err)
+	}
+	s.logger.InfoContext(ctx, "Analyzed table",
+		slog.String("table", maintainedTable),
+		slog.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// window reports whether now falls in the maintenance window and, if so, when the
+// window opened.
+func (s *MaintenanceService) window(now time.Time) (time.Time, bool) {
+	midnight := now.Truncate(24 * time.Hour)
+	offset := now.Sub(midnight)
+	start, end := s.rules.WindowStart, s.rules.WindowEnd
+
+	switch {
+	case start <= end:
+		return midnight.Add(start), offset >= start && offset < end
+	case offset >= start:
+		return midnight.Add(start), true
+	default:
+		// The window opened yesterday and closes after midnight.
+		return midnight.Add(start - 24*time.Hour), offset < end
+	}
+}
+
+// analyzedSince reports whether the table was analyzed, manually or automatically,
+// at or after t, so ANALYZE runs at most once per window.
+func analyzedSince(stats *models.TableStats, t time.Time) bool {
+	for _, last := range []*time.Time{stats.LastAnalyze, stats.LastAutoanalyze} {
+		if last != nil && !last.Before(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatLastRun(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	// Time zones are validated and applied without relying on the host's zoneinfo.
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+var (
+	ErrPreferencesNotFound = errors.New("user preferences not found")
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrInvalidTimezone     = errors.New("invalid timezone")
+)
+
+// PreferenceService manages the preferences users set for their own subscriptions in
+// the caller's tenant. Reminders and anomaly notifications take their defaults from
+// them, and aggregations of a single user report amounts in the preferred currency.
+type PreferenceService struct {
+	repo     preferenceRepository
+	channels channelChecker
+	rates    map[string]decimal.Decimal
+	clock    clock.Clock
+	logger   *slog.Logger
+}
+
+type preferenceRepository interface {
+	Get(ctx context.Context, tenant string, userID uuid.UUID) (*models.UserPreferences, error)
+	ListByUsers(ctx context.Context, tenant string, userIDs []uuid.UUID) ([]models.UserPreferences, error)
+	Upsert(ctx context.Context, prefs *models.UserPreferences) error
+	Delete(ctx context.Context, tenant string, userID uuid.UUID) error
+}
+
+type channelChecker interface {
+	Has(channel string) bool
+}
+
+// userPreferences is what the services applying preferences need of them.
+type userPreferences interface {
+	For(ctx context.Context, userID uuid.UUID) (models.UserPreferences, error)
+	ForUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]models.UserPreferences, error)
+}
+
+// NewPreferenceService takes the rates converting a price in models.PriceCurrency to
+// each other currency users may prefer.
+func NewPreferenceService(repo preferenceRepository, channels channelChecker, rates map[string]decimal.Decimal, clock clock.Clock, logger *slog.Logger) *PreferenceService {
+	return &PreferenceService{
+		repo:     repo,
+		channels: channels,
+		rates:    rates,
+		clock:    clock,
+		logger:   logger,
+	}
+}
+
+func (s *PreferenceService) Get(ctx context.Context, userID uuid.UUID) (*models.UserPreferences, error) {
+	if err := s.checkSubject(ctx, userID); err != nil {
+		return nil, err
+	}
+	prefs, err := s.repo.Get(ctx, identity.FromContext(ctx).Tenant, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPreferencesNotFound
+	}
+	return prefs, err
+}
+
+// Save replaces the preferences of a user. The currency must be models.PriceCurrency
+// or one with an exchange rate, the time zone an IANA name and every channel a
+// configured notification channel.
+func (s *PreferenceService) Save(ctx context.Context, prefs *models.UserPreferences) error {
+	if id := identity.FromContext(ctx); id.Impersonating() && prefs.UserID != *id.Subject {
+		return ErrSubjectMismatch
+	}
+
+	prefs.Currency = strings.ToUpper(prefs.Currency)
+	if _, ok := s.rates[prefs.Currency]; !ok && prefs.Currency != "" && prefs.Currency != models.PriceCurrency {
+		return withHint(fmt.Errorf("%w: %s", ErrUnsupportedCurrency, prefs.Currency), "hint_supported_currencies", strings.Join(s.currencies(), ", "))
+	}
+	if prefs.Timezone != "" {
+		if _, err := time.LoadLocation(prefs.Timezone); err != nil || prefs.Timezone == "Local" {
+			return fmt.Errorf("%w: %s", ErrInvalidTimezone, prefs.Timezone)
+		}
+	}
+	if prefs.NotificationChannels == nil {
+		prefs.NotificationChannels = []string{}
+	}
+	for _, channel := range prefs.NotificationChannels {
+		if !s.channels.Has(channel) {
+			return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+		}
+	}
+
+	prefs.Tenant = identity.FromContext(ctx).Tenant
+	prefs.UpdatedAt = s.clock.Now().UTC()
+	return s.repo.Upsert(ctx, prefs)
+}
+
+func (s *PreferenceService) Delete(ctx context.Context, userID uuid.UUID) error {
+	if err := s.checkSubject(ctx, userID); err != nil {
+		return err
+	}
+	err := s.repo.Delete(ctx, identity.FromContext(ctx).Tenant, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPreferencesNotFound
+	}
+	return err
+}
+
+// For returns the preferences of a user, empty when they set none.
+func (s *PreferenceService) For(ctx context.Context, userID uuid.UUID) (models.UserPreferences, error) {
+	prefs, err := s.repo.Get(ctx, identity.FromContext(ctx).Tenant, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.UserPreferences{UserID: userID}, nil
+	}
+	if err != nil {
+		return models.UserPreferences{}, err
+	}
+	return *prefs, nil
+}
+
+// ForUsers returns the preferences of the users that set any, by user.
+func (s *PreferenceService) ForUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]models.UserPreferences, error) {
+	list, err := s.repo.ListByUsers(ctx, identity.FromContext(ctx).Tenant, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	prefs := make(map[uuid.UUID]models.UserPreferences, len(list))
+	for _, p := range list {
+		prefs[p.UserID] = p
+	}
+	return prefs, nil
+}
+
+// Currency returns the currency a user prefers amounts in and the rate converting
+// prices to it, or models.PriceCurrency and a rate of one when they prefer none.
+func (s *PreferenceService) Currency(ctx context.Context, userID uuid.UUID) (string, decimal.Decimal, error) {
+	prefs, err := s.For(ctx, userID)
+	if err != nil {
+		return "", decimal.Zero, err
+	}
+	// A rate dropped from the configuration since the preference was saved falls back
+	// to the price currency rather than failing the request.
+	if rate, ok := s.rates[prefs.Currency]; ok {
+		return prefs.Currency, rate, nil
+	}
+	return models.PriceCurrency, decimal.NewFromInt(1), nil
+}
+
+func (s *PreferenceService) currencies() []string {
+	currencies := []string{models.PriceCurrency}
+	for currency := range s.rates {
+		currencies = append(currencies, currency)
+	}
+	slices.Sort(currencies[1:])
+	return currencies
+}
+
+// checkSubject hides the preferences of other users while impersonating.
+func (s *PreferenceService) checkSubject(ctx context.Context, userID uuid.UUID) error {
+	if id := identity.FromContext(ctx); id.Impersonating() && userID != *id.Subject {
+		s.logger.WarnContext(ctx, "Preferences requested for a different user than the impersonated one",
+			slog.String("user_id", userID.String()),
+			slog.String("subject_id", id.Subject.String()))
+		return ErrPreferencesNotFound
+	}
+	return nil
+}
+
+// location returns the time zone of prefs, UTC when they set none.
+func location(prefs models.UserPreferences) *time.Location {
+	if prefs.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
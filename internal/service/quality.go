@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/model"
+)
+
+const qualityBatchSize = 1000
+
+// qualityChecks are the data quality checks, each reporting whether sub fails it.
+var qualityChecks = []struct {
+	name  string
+	fails func(sub models.Subscription, rules models.QualityRules) bool
+}{
+	{models.QualityEndBeforeStart, func(sub models.Subscription, _ models.QualityRules) bool {
+		return sub.EndDate != nil && sub.EndDate.Before(sub.StartDate)
+	}},
+	// A recurring subscription ending in its start month is charged once, which is
+	// usually a one-time purchase recorded as recurring or a mistaken create.
+	{models.QualityZeroDuration, func(sub models.Subscription, _ models.QualityRules) bool {
+		return sub.Kind == models.KindRecurring && sub.EndDate != nil &&
+			sub.EndDate.Year() == sub.StartDate.Year() && sub.EndDate.Month() == sub.StartDate.Month()
+	}},
+	// There is no user directory, so the nil UUID left by clients sending zero
+	// values is the only owner known to be missing.
+	{models.QualityOrphanedUser, func(sub models.Subscription, _ models.QualityRules) bool {
+		return sub.UserID == uuid.Nil
+	}},
+	{models.QualityAbsurdPrice, func(sub models.Subscription, rules models.QualityRules) bool {
+		return sub.Price <= 0 || sub.Price > rules.MaxPrice
+	}},
+}
+
+// DataQualityService reports suspicious subscription records, mostly legacy rows
+// written before the current validation.
+type DataQualityService struct {
+	subscriptions batchReader
+	logger        *slog.Logger
+}
+
+type batchReader interface {
+	ForEachBatch(ctx context.Context, batchSize int, fn func(subs []models.Subscription) error) error
+}
+
+func NewDataQualityService(subscriptions batchReader, logger *slog.Logger) *DataQualityService {
+	return &DataQualityService{
+		subscriptions: subscriptions,
+		logger:        logger,
+	}
+}
+
+// Report scans every subscription outside the trash in batches and runs the checks
+// on each.
+func (s *DataQualityService) Report(ctx context.Context, rules models.QualityRules) (*models.QualityReport, error) {
+	s.logger.InfoContext(ctx, "Building data quality report in service layer",
+		slog.Int("max_price", rules.MaxPrice),
+		slog.Int("samples", rules.Samples))
+
+	report := &models.QualityReport{Checks: make([]models.QualityCheck, len(qualityChecks))}
+	for i, check := range qualityChecks {
+		report.Checks[i] = models.QualityCheck{Check: check.name, SampleIDs: []uuid.UUID{}}
+	}
+
+	err := s.subscriptions.ForEachBatch(ctx, qualityBatchSize, func(subs []models.Subscription) error {
+		for _, sub := range subs {
+			if sub.DeletedAt.Valid {
+				continue
+			}
+			report.Scanned++
+			for i, check := range qualityChecks {
+				if !check.fails(sub, rules) {
+					continue
+				}
+				result := &report.Checks[i]
+				result.Count++
+				if len(result.SampleIDs) < rules.Samples {
+					result.SampleIDs = append(result.SampleIDs, sub.ID)
+				}
+			}
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to scan subscriptions for data quality report",
+			slog.Int("scanned", report.Scanned),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully built data quality report in service layer",
+		slog.Int("scanned", report.Scanned))
+
+	return report, nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"awesomeProject1/internal/model"
+)
+
+var (
+	ErrInvalidStatement = errors.New("invalid statement")
+	ErrInvalidTolerance = errors.New("invalid reconciliation tolerance")
+)
+
+// maxStatementLines bounds the statements Reconcile reads, which are held in memory.
+const maxStatementLines = 100000
+
+const statementDateLayout = "2006-01-02"
+
+type chargeRepository interface {
+	Charges(ctx context.Context, start time.Time, end time.Time) ([]models.Charge, error)
+}
+
+// ReconciliationService compares the statements of external billing with the charges
+// recorded for the same period.
+type ReconciliationService struct {
+	charges   chargeRepository
+	tolerance models.ReconciliationTolerance
+	logger    *slog.Logger
+}
+
+// NewReconciliationService matches with tolerance unless a reconciliation sets its own.
+func NewReconciliationService(charges chargeRepository, tolerance models.ReconciliationTolerance, logger *slog.Logger) (*ReconciliationService, error) {
+	if err := checkTolerance(tolerance); err != nil {
+		return nil, err
+	}
+	return &ReconciliationService{
+		charges:   charges,
+		tolerance: tolerance,
+		logger:    logger,
+	}, nil
+}
+
+// Tolerance returns the tolerance reconciliations use by default.
+func (s *ReconciliationService) Tolerance() models.ReconciliationTolerance {
+	return s.tolerance
+}
+
+func checkTolerance(tolerance models.ReconciliationTolerance) error {
+	if tolerance.Amount.Sign() < 0 || tolerance.Days < 0 {
+		return fmt.Errorf("%w: amount %s, days %d", ErrInvalidTolerance, tolerance.Amount, tolerance.Days)
+	}
+	return nil
+}
+
+// Reconcile reads a CSV statement from r and matches its lines to the charges due from
+// startDateStr to endDateStr. Each line is matched to the closest unmatched charge of
+// the same subscription, or of the same user and service when the line names no
+// subscription, at most tolerance.Days apart; matches off by more than
+// tolerance.Amount are reported as mismatches.
+func (s *ReconciliationService) Reconcile(ctx context.Context, startDateStr string, endDateStr string, r io.Reader, tolerance models.ReconciliationTolerance) (*models.ReconciliationReport, error) {
+	if err := checkTolerance(tolerance); err != nil {
+		return nil, err
+	}
+	start, end, err := aggregationPeriod(startDateStr, endDateStr)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := parseStatement(r)
+	if err != nil {
+		return nil, err
+	}
+	charges, err := s.charges.Charges(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ReconciliationReport{
+		Start:          start,
+		End:            end,
+		Tolerance:      tolerance,
+		Matched:        []models.ReconciledCharge{},
+		Mismatched:     []models.ReconciledCharge{},
+		Missing:        []models.Charge{},
+		Unexpected:     []models.StatementLine{},
+		StatementTotal: decimal.Zero,
+		RecordedTotal:  decimal.Zero,
+	}
+
+	bySubscription := make(map[uuid.UUID][]int)
+	byUserService := make(map[string][]int)
+	for i, charge := range charges {
+		bySubscription[charge.SubscriptionID] = append(bySubscription[charge.SubscriptionID], i)
+		key := userServiceKey(charge.UserID, charge.ServiceName)
+		byUserService[key] = append(byUserService[key], i)
+		report.RecordedTotal = report.RecordedTotal.Add(charge.Amount)
+	}
+
+	matched := make([]bool, len(charges))
+	maxApart := time.Duration(tolerance.Days) * 24 * time.Hour
+	periodEnd := time.Date(end.Year(), end.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	slices.SortStableFunc(lines, func(a, b models.StatementLine) int {
+		return a.ChargedAt.Compare(b.ChargedAt)
+	})
+	for _, line := range lines {
+		if line.ChargedAt.Before(start) || !line.ChargedAt.Before(periodEnd) {
+			report.Skipped++
+			continue
+		}
+		report.StatementTotal = report.StatementTotal.Add(line.Amount)
+
+		candidates := byUserService[userServiceKey(line.UserID, line.ServiceName)]
+		if line.SubscriptionID != nil {
+			candidates = bySubscription[*line.SubscriptionID]
+		}
+		best := -1
+		var bestApart time.Duration
+		for _, i := range candidates {
+			apart := line.ChargedAt.Sub(charges[i].ChargedAt).Abs()
+			if matched[i] || apart > maxApart {
+				continue
+			}
+			if best < 0 || apart < bestApart {
+				best, bestApart = i, apart
+			}
+		}
+		if best < 0 {
+			report.Unexpected = append(report.Unexpected, line)
+			continue
+		}
+
+		matched[best] = true
+		reconciled := models.ReconciledCharge{
+			Statement:  line,
+			Recorded:   charges[best],
+			Difference: line.Amount.Sub(charges[best].Amount),
+		}
+		if reconciled.Difference.Abs().Cmp(tolerance.Amount) > 0 {
+			report.Mismatched = append(report.Mismatched, reconciled)
+		} else {
+			report.Matched = append(report.Matched, reconciled)
+		}
+	}
+	for i, charge := range charges {
+		if !matched[i] {
+			report.Missing = append(report.Missing, charge)
+		}
+	}
+
+	s.logger.InfoContext(ctx, "Reconciled billing statement",
+		slog.Time("start", start),
+		slog.Time("end", end),
+		slog.Int("matched", len(report.Matched)),
+		slog.Int("mismatched", len(report.Mismatched)),
+		slog.Int("missing", len(report.Missing)),
+		slog.Int("unexpected", len(report.Unexpected)),
+		slog.Int("skipped", report.Skipped))
+	return report, nil
+}
+
+func userServiceKey(userID uuid.UUID, serviceName string) string {
+	return userID.String() + "\x00" + strings.ToLower(strings.TrimSpace(serviceName))
+}
+
+// parseStatement reads a CSV statement with a header row naming its columns: user_id,
+// service_name, charged_at (YYYY-MM-DD) and amount, and optionally subscription_id,
+// in which case user_id and service_name may be left empty.
+func parseStatement(r io.Reader) ([]models.StatementLine, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: empty statement", ErrInvalidStatement)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidStatement, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	required := []string{"charged_at", "amount"}
+	if !hasColumn(columns, "subscription_id") {
+		required = append(required, "user_id", "service_name")
+	}
+	for _, name := range required {
+		if !hasColumn(columns, name) {
+			return nil, fmt.Errorf("%w: missing column %s", ErrInvalidStatement, name)
+		}
+	}
+
+	var lines []models.StatementLine
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidStatement, err)
+		}
+		if len(lines) == maxStatementLines {
+			return nil, fmt.Errorf("%w: more than %d lines", ErrInvalidStatement, maxStatementLines)
+		}
+		number, _ := reader.FieldPos(0)
+		line, err := parseStatementLine(number, columns, record)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidStatement, number, err)
+		}
+		lines = append(lines, line)
+	}
+}
+
+func hasColumn(columns map[string]int, name string) bool {
+	_, ok := columns[name]
+	return ok
+}
+
+func parseStatementLine(number int, columns map[string]int, record []string) (models.StatementLine, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	line := models.StatementLine{Line: number, ServiceName: field("service_name")}
+	if value := field("subscription_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return line, fmt.Errorf("subscription_id: %w", err)
+		}
+		line.SubscriptionID = &id
+	}
+	if value := field("user_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return line, fmt.Errorf("user_id: %w", err)
+		}
+		line.UserID = id
+	}
+	if line.SubscriptionID == nil && (line.UserID == uuid.Nil || line.ServiceName == "") {
+		return line, errors.New("either subscription_id or user_id and service_name are required")
+	}
+
+	chargedAt, err := time.Parse(statementDateLayout, field("charged_at"))
+	if err != nil {
+		return line, fmt.Errorf("charged_at: %w", err)
+	}
+	line.ChargedAt = chargedAt
+	if line.Amount, err = decimal.NewFromString(field("amount")); err != nil {
+		return line, fmt.Errorf("amount: %w", err)
+	}
+	return line, nil
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/notify"
+	"awesomeProject1/internal/templates"
+)
+
+var (
+	ErrUnknownChannel     = errors.New("unknown notification channel")
+	ErrNotRecurring       = errors.New("reminders are only available for recurring subscriptions")
+	ErrReminderNotFound   = errors.New("reminder not found")
+	ErrNoReminderDefaults = errors.New("days_before and channel are required when the user has no default for them")
+)
+
+type ReminderService struct {
+	reminders     reminderRepository
+	subscriptions subscriptionGetter
+	notifier      notifier
+	templates     renderer
+	preferences   userPreferences
+	clock         clock.Clock
+	logger        *slog.Logger
+}
+
+type reminderRepository interface {
+	Create(ctx context.Context, reminder *models.Reminder) error
+	ListBySubscription(ctx context.Context, subscriptionID uuid.UUID) ([]models.Reminder, error)
+	Delete(ctx context.Context, subscriptionID uuid.UUID, id uuid.UUID) error
+	ListActive(ctx context.Context) ([]models.Reminder, error)
+	MarkSent(ctx context.Context, id uuid.UUID, renewal time.Time) error
+}
+
+type subscriptionGetter interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+}
+
+type notifier interface {
+	Has(channel string) bool
+	Notify(ctx context.Context, channel string, msg notify.Message) error
+}
+
+type renderer interface {
+	Render(ctx context.Context, tenant string, name string, data any) (templates.Rendered, error)
+}
+
+func NewReminderService(reminders reminderRepository, subscriptions subscriptionGetter, notifier notifier, templates renderer, preferences userPreferences, clock clock.Clock, logger *slog.Logger) *ReminderService {
+	return &ReminderService{
+		reminders:     reminders,
+		subscriptions: subscriptions,
+		notifier:      notifier,
+		templates:     templates,
+		preferences:   preferences,
+		clock:         clock,
+		logger:        logger,
+	}
+}
+
+// Create adds a reminder to a recurring subscription. A nil daysBefore and an empty
+// channel take the default lead time and the first notification channel of the
+// subscription's user.
+func (s *ReminderService) Create(ctx context.Context, subscriptionID uuid.UUID, daysBefore *int, message string, channel string) (*models.Reminder, error) {
+	s.logger.InfoContext(ctx, "Creating reminder in service layer",
+		slog.String("subscription_id", subscriptionID.String()),
+		slog.Any("days_before", daysBefore),
+		slog.String("channel", channel))
+
+	sub, err := s.subscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Kind != models.KindRecurring {
+		return nil, ErrNotRecurring
+	}
+
+	if daysBefore == nil || channel == "" {
+		prefs, err := s.preferences.For(ctx, sub.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if daysBefore == nil {
+			daysBefore = prefs.ReminderDaysBefore
+		}
+		if channel == "" && len(prefs.NotificationChannels) > 0 {
+			channel = prefs.NotificationChannels[0]
+		}
+		if daysBefore == nil || channel == "" {
+			return nil, ErrNoReminderDefaults
+		}
+	}
+	if !s.notifier.Has(channel) {
+		s.logger.ErrorContext(ctx, "Reminder requested on an unknown channel",
+			slog.String("channel", channel))
+		return nil, ErrUnknownChannel
+	}
+
+	reminder := &models.Reminder{
+		ID:             uuid.New(),
+		SubscriptionID: subscriptionID,
+		DaysBefore:     *daysBefore,
+		Message:        message,
+		Channel:        channel,
+		CreatedAt:      s.clock.Now().UTC(),
+	}
+	if err := s.reminders.Create(ctx, reminder); err != nil {
+		s.logger.ErrorContext(ctx, "Repository failed to create reminder",
+			slog.String("subscription_id", subscriptionID.String()),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+func (s *ReminderService) List(ctx context.Context, subscriptionID uuid.UUID) ([]models.Reminder, error) {
+	if _, err := s.subscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.reminders.ListBySubscription(ctx, subscriptionID)
+}
+
+func (s *ReminderService) Delete(ctx context.Context, subscriptionID uuid.UUID, id uuid.UUID) error {
+	if _, err := s.subscription(ctx, subscriptionID); err != nil {
+		return err
+	}
+	if err := s.reminders.Delete(ctx, subscriptionID, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrReminderNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// subscription loads the subscription a reminder belongs to, hiding other users'
+// subscriptions while impersonating.
+func (s *ReminderService) subscription(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	sub, err := s.subscriptions.GetByID(ctx, id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve subscription for reminders",
+			slog.String("subscription_id", id.String()),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+	if id := identity.FromContext(ctx); id.Impersonating() && sub.UserID != *id.Subject {
+		s.logger.WarnContext(ctx, "Subscription does not belong to impersonated user",
+			slog.String("subscription_id", sub.ID.String()),
+			slog.String("subject_id", id.Subject.String()))
+		return nil, gorm.ErrRecordNotFound
+	}
+	return sub, nil
+}
+
+// SendDue dispatches every reminder whose renewal is at most DaysBefore days away
+// and that has not fired for that renewal yet. Days are counted in the time zone of
+// the subscription's user. It is run by the scheduler.
+func (s *ReminderService) SendDue(ctx context.Context) error {
+	reminders, err := s.reminders.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(reminders))
+	for _, reminder := range reminders {
+		userIDs = append(userIDs, reminder.Subscription.UserID)
+	}
+	prefs, err := s.preferences.ForUsers(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	sent, failed := 0, 0
+
+	for _, reminder := range reminders {
+		sub := reminder.Subscription
+		local := now.In(location(prefs[sub.UserID]))
+		today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+		renewal, ok := nextRenewal(sub, today)
+		if !ok || today.Before(renewal.AddDate(0, 0, -reminder.DaysBefore)) {
+			continue
+		}
+		if reminder.LastSentFor != nil && reminder.LastSentFor.Equal(renewal) {
+			continue
+		}
+
+		// Subscriptions are not tenant-scoped yet, so reminders use the default tenant's templates.
+		content, err := s.templates.Render(ctx, identity.DefaultTenant, templates.Reminder, templates.ReminderData{
+			ServiceName: sub.ServiceName,
+			Price:       sub.Price,
+			RenewalDate: renewal,
+			DaysBefore:  reminder.DaysBefore,
+			Message:     reminder.Message,
+		})
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to render reminder",
+				slog.String("reminder_id", reminder.ID.String()),
+				slog.String("error", err.Error()))
+			failed++
+			continue
+		}
+
+		msg := notify.Message{
+			UserID:         sub.UserID,
+			SubscriptionID: &sub.ID,
+			Template:       templates.Reminder,
+			Subject:        content.Subject,
+			Text:           content.Text,
+			HTML:           content.HTML,
+		}
+		if err := s.notifier.Notify(ctx, reminder.Channel, msg); err != nil {
+			failed++
+			continue
+		}
+		if err := s.reminders.MarkSent(ctx, reminder.ID, renewal); err != nil {
+			failed++
+			continue
+		}
+		sent++
+	}
+
+	s.logger.InfoContext(ctx, "Processed subscription reminders",
+		slog.Int("reminders", len(reminders)),
+		slog.Int("sent", sent),
+		slog.Int("failed", failed))
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d due reminders failed", failed, sent+failed)
+	}
+	return nil
+}
+
+// nextRenewal returns the first charge after the initial one that falls on or after
+// from, following the subscription's billing period and anchor day. It reports false
+// for subscriptions that do not renew or have no charge left.
+func nextRenewal(sub *models.Subscription, from time.Time) (time.Time, bool) {
+	if sub.Kind != models.KindRecurring {
+		return time.Time{}, false
+	}
+
+	for n := 1; ; n++ {
+		charge := nthCharge(sub, n)
+
+		// The end date is the last billed month, so charges stop when it is over.
+		if sub.EndDate != nil && !charge.Before(sub.EndDate.AddDate(0, 1, 0)) {
+			return time.Time{}, false
+		}
+		if !charge.Before(from) {
+			return charge, true
+		}
+	}
+}
+
+// nthCharge returns the charge of sub n billing periods after the first one, which
+// falls on the anchor day of the start month.
+func nthCharge(sub *models.Subscription, n int) time.Time {
+	first := addMonthsClamped(sub.StartDate, 0, sub.BillingAnchorDay)
+	switch sub.BillingPeriod {
+	case models.BillingWeekly:
+		return first.AddDate(0, 0, 7*n)
+	case models.BillingQuarterly:
+		return addMonthsClamped(first, 3*n, sub.BillingAnchorDay)
+	case models.BillingYearly:
+		return addMonthsClamped(first, 12*n, sub.BillingAnchorDay)
+	default:
+		return addMonthsClamped(first, n, sub.BillingAnchorDay)
+	}
+}
+
+// addMonthsClamped moves t by months and sets the day to day, or to the last day of
+// the resulting month when it is shorter.
+func addMonthsClamped(t time.Time, months int, day int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if day < 1 {
+		day = 1
+	}
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"awesomeProject1/internal/audit"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+// Fixes applied by RepairService.
+const (
+	// RepairDates truncates start and end dates to the first of their month, where
+	// the MM-YYYY dates the API accepts always fall.
+	RepairDates = "dates"
+	// RepairServiceNames trims service names and renames service aliases to their
+	// canonical spelling.
+	RepairServiceNames = "service-names"
+)
+
+// RepairActor is recorded in the audit log for repaired subscriptions.
+const RepairActor = "repair"
+
+var ErrUnknownRepair = errors.New("unknown repair")
+
+// RepairOptions control a repair run. Pause is waited between batches to leave the
+// database room for the API; DryRun logs the changes without saving them.
+type RepairOptions struct {
+	Fixes     []string
+	BatchSize int
+	Pause     time.Duration
+	DryRun    bool
+}
+
+type RepairResult struct {
+	Scanned  int
+	Repaired int
+	Failed   int
+}
+
+// RepairService normalizes legacy subscriptions written before the API validated its
+// input. Every repaired subscription is audited as subscription.repaired.
+type RepairService struct {
+	subscriptions repairSubscriptions
+	aliases       aliasLister
+	audit         auditRecorder
+	logger        *slog.Logger
+}
+
+type repairSubscriptions interface {
+	ForEachBatch(ctx context.Context, batchSize int, fn func(subs []models.Subscription) error) error
+	Update(ctx context.Context, sub *models.Subscription) error
+}
+
+type aliasLister interface {
+	ListAliases(ctx context.Context) ([]models.ServiceAlias, error)
+}
+
+func NewRepairService(subscriptions repairSubscriptions, aliases aliasLister, audit auditRecorder, logger *slog.Logger) *RepairService {
+	return &RepairService{
+		subscriptions: subscriptions,
+		aliases:       aliases,
+		audit:         audit,
+		logger:        logger,
+	}
+}
+
+// Run applies opts.Fixes to every subscription outside the trash. A subscription that
+// fails to save is logged and counted so the run carries on; Run then returns an error
+// once done.
+func (s *RepairService) Run(ctx context.Context, opts RepairOptions) (RepairResult, error) {
+	var result RepairResult
+	var fixDates, fixNames bool
+	for _, fix := range opts.Fixes {
+		switch fix {
+		case RepairDates:
+			fixDates = true
+		case RepairServiceNames:
+			fixNames = true
+		default:
+			return result, fmt.Errorf("%w: %s", ErrUnknownRepair, fix)
+		}
+	}
+
+	aliases := make(map[string]string)
+	if fixNames {
+		list, err := s.aliases.ListAliases(ctx)
+		if err != nil {
+			return result, fmt.Errorf("list service aliases: %w", err)
+		}
+		for _, alias := range list {
+			aliases[alias.Alias] = alias.ServiceName
+		}
+	}
+
+	s.logger.InfoContext(ctx, "Starting subscription repair",
+		slog.Any("fixes", opts.Fixes),
+		slog.Int("aliases", len(aliases)),
+		slog.Bool("dry_run", opts.DryRun))
+
+	ctx = identity.WithIdentity(ctx, identity.Identity{Actor: RepairActor, Tenant: identity.DefaultTenant})
+	first := true
+	err := s.subscriptions.ForEachBatch(ctx, opts.BatchSize, func(subs []models.Subscription) error {
+		if !first && opts.Pause > 0 {
+			timer := time.NewTimer(opts.Pause)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		first = false
+
+		for _, sub := range subs {
+			if sub.DeletedAt.Valid {
+				continue
+			}
+			result.Scanned++
+
+			before := sub
+			changed := false
+			if fixDates {
+				changed = repairDates(&sub) || changed
+			}
+			if fixNames {
+				changed = repairServiceName(&sub, aliases) || changed
+			}
+			if !changed {
+				continue
+			}
+
+			s.logger.InfoContext(ctx, "Repairing subscription",
+				slog.String("subscription_id", sub.ID.String()),
+				slog.String("service_name", before.ServiceName),
+				slog.String("repaired_service_name", sub.ServiceName),
+				slog.Time("start_date", before.StartDate),
+				slog.Time("repaired_start_date", sub.StartDate),
+				slog.Bool("dry_run", opts.DryRun))
+			if opts.DryRun {
+				result.Repaired++
+				continue
+			}
+
+			if err := s.subscriptions.Update(ctx, &sub); err != nil {
+				s.logger.ErrorContext(ctx, "Failed to save repaired subscription",
+					slog.String("subscription_id", sub.ID.String()),
+					slog.String("error", err.Error()))
+				result.Failed++
+				continue
+			}
+			s.audit.Record(ctx, audit.ActionRepair, sub.ID, before, sub)
+			result.Repaired++
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("scan subscriptions: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Finished subscription repair",
+		slog.Int("scanned", result.Scanned),
+		slog.Int("repaired", result.Repaired),
+		slog.Int("failed", result.Failed),
+		slog.Bool("dry_run", opts.DryRun))
+
+	if result.Failed > 0 {
+		return result, fmt.Errorf("%d subscriptions could not be repaired", result.Failed)
+	}
+	return result, nil
+}
+
+// repairDates truncates the dates of sub to the first of their month in UTC.
+func repairDates(sub *models.Subscription) bool {
+	changed := false
+	if start := monthStart(sub.StartDate); !start.Equal(sub.StartDate) {
+		sub.StartDate = start
+		changed = true
+	}
+	if sub.EndDate != nil {
+		if end := monthStart(*sub.EndDate); !end.Equal(*sub.EndDate) {
+			sub.EndDate = &end
+			changed = true
+		}
+	}
+	return changed
+}
+
+// repairServiceName trims the service name of sub and replaces it with the canonical
+// spelling when it is an alias.
+func repairServiceName(sub *models.Subscription, aliases map[string]string) bool {
+	name := strings.Join(strings.Fields(sub.ServiceName), " ")
+	if canonical, ok := aliases[catalogKey(name)]; ok {
+		name = canonical
+	}
+	if name == "" || name == sub.ServiceName {
+		return false
+	}
+	sub.ServiceName = name
+	return true
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
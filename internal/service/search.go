@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+// SearchService answers subscription searches from the search index when one is
+// configured and from the database otherwise, or when the index fails. Sandbox
+// requests always read the database, since sandbox writes are not indexed.
+type SearchService struct {
+	index    subscriptionSearcher
+	database serviceSuggester
+	logger   *slog.Logger
+}
+
+type subscriptionSearcher interface {
+	Search(ctx context.Context, q models.SearchQuery) (*models.SearchResult, error)
+}
+
+type serviceSuggester interface {
+	subscriptionSearcher
+	Suggest(ctx context.Context, prefix string, userID uuid.UUID, limit int) ([]models.ServiceSuggestion, error)
+}
+
+// NewSearchService takes a nil index when no search index is configured.
+func NewSearchService(index subscriptionSearcher, database serviceSuggester, logger *slog.Logger) *SearchService {
+	return &SearchService{
+		index:    index,
+		database: database,
+		logger:   logger,
+	}
+}
+
+func (s *SearchService) Search(ctx context.Context, q models.SearchQuery) (*models.SearchResult, error) {
+	id := identity.FromContext(ctx)
+	if id.Impersonating() {
+		q.UserID = *id.Subject
+	}
+
+	if s.index == nil || id.Sandbox() {
+		return s.database.Search(ctx, q)
+	}
+
+	result, err := s.index.Search(ctx, q)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Search index failed, searching the database instead",
+			slog.String("error", err.Error()))
+		return s.database.Search(ctx, q)
+	}
+	return result, nil
+}
+
+// Suggest completes a service name prefix from the database, which counts the uses of
+// every name; the search index only holds whole subscriptions. Impersonated requests
+// only count the subject's own subscriptions.
+func (s *SearchService) Suggest(ctx context.Context, prefix string, limit int) ([]models.ServiceSuggestion, error) {
+	var userID uuid.UUID
+	if id := identity.FromContext(ctx); id.Impersonating() {
+		userID = *id.Subject
+	}
+	return s.database.Suggest(ctx, prefix, userID, limit)
+}
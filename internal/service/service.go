@@ -5,134 +5,297 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 
+	"awesomeProject1/internal/audit"
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/identity"
 	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/notify"
 )
 
+var (
+	ErrInvalidStartDate     = errors.New("invalid start_date")
+	ErrInvalidEndDate       = errors.New("invalid end_date")
+	ErrEndBeforeStart       = errors.New("end_date must be after start_date")
+	ErrSubjectMismatch      = errors.New("user_id must match the impersonated user")
+	ErrAlreadyCancelled     = errors.New("subscription is already cancelled")
+	ErrInvalidBucket        = errors.New("bucket must be one of month, quarter, year")
+	ErrInvalidMode          = errors.New("mode must be one of normalized, exact")
+	ErrMergeTooFew          = errors.New("at least two distinct subscriptions are required to merge")
+	ErrMergeUserMismatch    = errors.New("subscriptions to merge must belong to the same user")
+	ErrMergeServiceMismatch = errors.New("subscriptions to merge must be for the same service")
+	ErrMergeNotContiguous   = errors.New("subscriptions to merge must overlap or be adjacent")
+	ErrMergeKindMismatch    = errors.New("subscriptions to merge must have the same kind and billing period")
+	ErrInvalidSplitDate     = errors.New("invalid split date")
+	ErrSplitOutOfRange      = errors.New("split month must be after the start month and not after the end month")
+	ErrInvalidSimulation    = errors.New("invalid simulation change")
+	ErrScheduledNotFuture   = errors.New("scheduled subscriptions must start in a future month")
+	ErrSubscriptionLimit    = errors.New("active subscription limit reached")
+	ErrLimitOverrideDenied  = errors.New("only admins can override the subscription limit")
+)
+
+// SubscriptionLimits cap the active subscriptions of each user, so that a runaway
+// import script cannot create rows without bound. PerUser applies to the tenants
+// missing from Tenants; zero means unlimited.
+type SubscriptionLimits struct {
+	PerUser int
+	Tenants map[string]int
+}
+
+func (l SubscriptionLimits) forTenant(tenant string) int {
+	if limit, ok := l.Tenants[tenant]; ok {
+		return limit
+	}
+	return l.PerUser
+}
+
+// SchedulerActor is recorded in the audit log for subscriptions the scheduler activates.
+const SchedulerActor = "scheduler"
+
 type SubscriptionService struct {
-	repo   repositorySubscription
-	logger *slog.Logger
+	repo             repositorySubscription
+	fields           customFieldLister
+	categories       categoryLookup
+	audit            auditRecorder
+	alerts           alerter
+	clock            clock.Clock
+	logger           *slog.Logger
+	trashGracePeriod time.Duration
+	limits           SubscriptionLimits
 }
 
 type repositorySubscription interface {
 	Create(ctx context.Context, sub *models.Subscription) error
+	CreateBatch(ctx context.Context, subs []models.Subscription) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
 	Update(ctx context.Context, sub *models.Subscription) error
 	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, userID uuid.UUID, serviceName string) ([]models.Subscription, error)
-	Aggregate(ctx context.Context, start time.Time, end time.Time, userID *uuid.UUID, serviceName *string) (int, error)
+	Merge(ctx context.Context, merged *models.Subscription, absorbedIDs []uuid.UUID) error
+	Split(ctx context.Context, updated *models.Subscription, created *models.Subscription) error
+	GetDeletedByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	Restore(ctx context.Context, id uuid.UUID, deletedAfter time.Time) error
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
+	ActivateScheduled(ctx context.Context, startedBefore time.Time) ([]models.Subscription, error)
+	List(ctx context.Context, filter models.ListFilter, page models.Page) ([]models.Subscription, error)
+	Count(ctx context.Context, filter models.ListFilter) (int64, error)
+	Facets(ctx context.Context, filter models.ListFilter) (*models.FacetSummary, error)
+	CountActive(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error)
+	Aggregate(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter) (decimal.Decimal, error)
+	AggregateByBucket(ctx context.Context, start time.Time, end time.Time, bucket string, mode string, filter models.AggregateFilter) ([]models.BucketTotal, error)
+	AggregateByCategory(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter) ([]models.CategoryTotal, error)
+	AggregateDetails(ctx context.Context, start time.Time, end time.Time, mode string, filter models.AggregateFilter, limit int) ([]models.AggregateDetail, error)
+	Stats(ctx context.Context, filter models.AggregateFilter) (*models.SubscriptionStats, error)
+	Simulate(ctx context.Context, scenario models.Scenario, start time.Time, end time.Time, mode string, filter models.AggregateFilter) ([]models.BucketTotal, []models.BucketTotal, error)
+}
+
+type alerter interface {
+	Alert(ctx context.Context, category string, title string, text string)
 }
 
-func NewSubscriptionService(repo repositorySubscription, logger *slog.Logger) *SubscriptionService {
+type auditRecorder interface {
+	Record(ctx context.Context, action string, subscriptionID uuid.UUID, before any, after any)
+}
+
+func NewSubscriptionService(repo repositorySubscription, fields customFieldLister, categories categoryLookup, audit auditRecorder, alerts alerter, clock clock.Clock, logger *slog.Logger, trashGracePeriod time.Duration, limits SubscriptionLimits) *SubscriptionService {
 	return &SubscriptionService{
-		repo:   repo,
-		logger: logger,
+		repo:             repo,
+		fields:           fields,
+		categories:       categories,
+		audit:            audit,
+		alerts:           alerts,
+		clock:            clock,
+		logger:           logger,
+		trashGracePeriod: trashGracePeriod,
+		limits:           limits,
 	}
 }
 
-func (s *SubscriptionService) Create(ctx context.Context, serviceName string, price int, userID uuid.UUID, startDateStr string, endDateStr string) (*models.Subscription, error) {
-	s.logger.InfoContext(ctx, "Creating subscription in service layer",
-		slog.String("service_name", serviceName),
-		slog.Int("price", price),
-		slog.String("user_id", userID.String()),
-		slog.String("start_date", startDateStr),
-		slog.String("end_date", endDateStr))
+// Create validates customFields against the custom fields of the caller's tenant. An
+// empty category leaves the category to the service catalog. Subscriptions are active
+// unless status schedules them, which requires a start month after the current one.
+// Users are held to the active subscription limit of the tenant unless an admin sets
+// overrideLimit.
+func (s *SubscriptionService) Create(ctx context.Context, serviceName string, price int, userID uuid.UUID, kind string, billingPeriod string, billingAnchorDay int, startDateStr string, endDateStr string, status string, category string, customFields map[string]any, overrideLimit bool) (*models.Subscription, error) {
+	sub, err := s.prepare(ctx, models.SubscriptionDraft{
+		ServiceName:      serviceName,
+		Price:            price,
+		UserID:           userID,
+		Kind:             kind,
+		BillingPeriod:    billingPeriod,
+		BillingAnchorDay: billingAnchorDay,
+		StartDate:        startDateStr,
+		EndDate:          endDateStr,
+		Status:           status,
+		Category:         category,
+		CustomFields:     customFields,
+	}, overrideLimit, 0)
+	if err != nil {
+		return nil, err
+	}
 
-	s.logger.DebugContext(ctx, "Parsing start date", slog.String("start_date", startDateStr))
-	startDate, err := parseMonthYear(startDateStr)
+	if err := s.repo.Create(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, audit.ActionCreate, sub.ID, nil, sub)
+	return sub, nil
+}
+
+// CreateBatch creates drafts as Create does, in a single transaction. When any draft
+// is invalid nothing is created, and the errors are returned by position, nil for the
+// valid drafts. The active subscription limit counts the drafts of the same user that
+// come before.
+func (s *SubscriptionService) CreateBatch(ctx context.Context, drafts []models.SubscriptionDraft, overrideLimit bool) ([]models.Subscription, []error, error) {
+	subs := make([]models.Subscription, 0, len(drafts))
+	itemErrs := make([]error, len(drafts))
+	invalid := false
+	pending := make(map[uuid.UUID]int64)
+	for i, draft := range drafts {
+		sub, err := s.prepare(ctx, draft, overrideLimit, pending[draft.UserID])
+		if err != nil {
+			itemErrs[i] = err
+			invalid = true
+			continue
+		}
+		if sub.EndDate == nil || !sub.EndDate.Before(s.currentMonth()) {
+			pending[sub.UserID]++
+		}
+		subs = append(subs, *sub)
+	}
+	if invalid {
+		return nil, itemErrs, nil
+	}
+
+	if err := s.repo.CreateBatch(ctx, subs); err != nil {
+		return nil, nil, err
+	}
+
+	for i := range subs {
+		s.audit.Record(ctx, audit.ActionCreate, subs[i].ID, nil, &subs[i])
+	}
+	return subs, nil, nil
+}
+
+// prepare validates draft and returns the subscription to insert for it. pending
+// subscriptions of the user, not yet inserted, count towards the limit.
+func (s *SubscriptionService) prepare(ctx context.Context, draft models.SubscriptionDraft, overrideLimit bool, pending int64) (*models.Subscription, error) {
+	if id := identity.FromContext(ctx); id.Impersonating() && draft.UserID != *id.Subject {
+		s.logger.ErrorContext(ctx, "User ID does not match impersonated user",
+			slog.String("user_id", draft.UserID.String()),
+			slog.String("subject_id", id.Subject.String()))
+		return nil, ErrSubjectMismatch
+	}
+
+	startDate, err := parseMonthYear(draft.StartDate)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "Failed to parse start date",
-			slog.String("start_date", startDateStr),
-			slog.String("error", err.Error()))
-		return nil, fmt.Errorf("invalid start_date: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidStartDate, err)
 	}
 
 	var endDate *time.Time
-	if endDateStr != "" {
-		s.logger.DebugContext(ctx, "Parsing end date", slog.String("end_date", endDateStr))
-		ed, err := parseMonthYear(endDateStr)
+	if draft.EndDate != "" {
+		ed, err := parseMonthYear(draft.EndDate)
 		if err != nil {
-			s.logger.ErrorContext(ctx, "Failed to parse end date",
-				slog.String("end_date", endDateStr),
-				slog.String("error", err.Error()))
-			return nil, fmt.Errorf("invalid end_date: %w", err)
+			return nil, fmt.Errorf("%w: %w", ErrInvalidEndDate, err)
 		}
 		if ed.Before(startDate) {
-			s.logger.ErrorContext(ctx, "End date is before start date",
-				slog.Time("start_date", startDate),
-				slog.Time("end_date", ed))
-			return nil, errors.New("end_date must be after start_date")
+			return nil, ErrEndBeforeStart
 		}
 		endDate = &ed
 	}
 
-	subID := uuid.New()
-	sub := &models.Subscription{
-		ID:          subID,
-		ServiceName: serviceName,
-		Price:       price,
-		UserID:      userID,
-		StartDate:   startDate,
-		EndDate:     endDate,
+	if draft.Kind == "" {
+		draft.Kind = models.KindRecurring
+	}
+	if draft.BillingPeriod == "" {
+		draft.BillingPeriod = models.BillingMonthly
+	}
+	if draft.BillingAnchorDay == 0 {
+		draft.BillingAnchorDay = 1
+	}
+	if draft.Status == "" {
+		draft.Status = models.StatusActive
+	}
+	if draft.Status == models.StatusScheduled && !startDate.After(s.currentMonth()) {
+		return nil, ErrScheduledNotFuture
 	}
 
-	s.logger.InfoContext(ctx, "Subscription model created, calling repository",
-		slog.String("subscription_id", subID.String()))
+	var categoryRef *string
+	if draft.Category != "" {
+		if err := checkCategory(ctx, s.categories, draft.Category); err != nil {
+			return nil, err
+		}
+		categoryRef = &draft.Category
+	}
 
-	if err := s.repo.Create(ctx, sub); err != nil {
-		s.logger.ErrorContext(ctx, "Repository failed to create subscription",
-			slog.String("subscription_id", subID.String()),
-			slog.String("error", err.Error()))
+	schema, err := loadCustomFieldSchema(ctx, s.fields, identity.FromContext(ctx).Tenant)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := schema.apply(nil, draft.CustomFields)
+	if err != nil {
 		return nil, err
 	}
 
-	s.logger.InfoContext(ctx, "Successfully created subscription in service layer",
-		slog.String("subscription_id", subID.String()))
+	if overrideLimit && !identity.FromContext(ctx).Admin {
+		return nil, ErrLimitOverrideDenied
+	}
+	if !overrideLimit {
+		if err := s.checkLimit(ctx, draft.UserID, pending); err != nil {
+			return nil, err
+		}
+	}
+
+	sub := &models.Subscription{
+		ID:               uuid.New(),
+		ServiceName:      draft.ServiceName,
+		Price:            draft.Price,
+		UserID:           draft.UserID,
+		Kind:             draft.Kind,
+		BillingPeriod:    draft.BillingPeriod,
+		BillingAnchorDay: draft.BillingAnchorDay,
+		StartDate:        startDate,
+		EndDate:          endDate,
+		Status:           draft.Status,
+		Category:         categoryRef,
+		CustomFields:     fields,
+	}
 
 	return sub, nil
 }
 
 func (s *SubscriptionService) GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
-	s.logger.InfoContext(ctx, "Retrieving subscription by ID in service layer",
-		slog.String("subscription_id", id.String()))
-
 	sub, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "Repository failed to get subscription",
-			slog.String("subscription_id", id.String()),
-			slog.String("error", err.Error()))
 		return nil, err
 	}
 
-	s.logger.InfoContext(ctx, "Successfully retrieved subscription in service layer",
-		slog.String("subscription_id", id.String()),
-		slog.String("service_name", sub.ServiceName))
-
+	if err := s.checkSubject(ctx, sub); err != nil {
+		return nil, err
+	}
 	return sub, nil
 }
 
-func (s *SubscriptionService) Update(ctx context.Context, id uuid.UUID, serviceName string, price int, startDateStr string, endDateStr string) (*models.Subscription, error) {
-	s.logger.InfoContext(ctx, "Updating subscription in service layer",
-		slog.String("subscription_id", id.String()),
-		slog.String("service_name", serviceName),
-		slog.Int("price", price),
-		slog.String("start_date", startDateStr),
-		slog.String("end_date", endDateStr))
-
-	s.logger.DebugContext(ctx, "Retrieving existing subscription for update",
-		slog.String("subscription_id", id.String()))
-
+// Update changes the category when category is not nil, an empty one handing it back
+// to the service catalog. It merges customFields into the stored ones when it is not
+// nil; a nil value removes a field.
+func (s *SubscriptionService) Update(ctx context.Context, id uuid.UUID, serviceName string, price int, kind string, billingPeriod string, billingAnchorDay int, startDateStr string, endDateStr string, category *string, customFields map[string]any) (*models.Subscription, error) {
 	sub, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "Failed to retrieve subscription for update",
-			slog.String("subscription_id", id.String()),
-			slog.String("error", err.Error()))
 		return nil, err
 	}
 
+	if err := s.checkSubject(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	before := *sub
 	var updatedFields []string
 
 	if serviceName != "" {
@@ -145,33 +308,37 @@ func (s *SubscriptionService) Update(ctx context.Context, id uuid.UUID, serviceN
 		updatedFields = append(updatedFields, "price")
 	}
 
+	if kind != "" {
+		sub.Kind = kind
+		updatedFields = append(updatedFields, "kind")
+	}
+
+	if billingPeriod != "" {
+		sub.BillingPeriod = billingPeriod
+		updatedFields = append(updatedFields, "billing_period")
+	}
+
+	if billingAnchorDay > 0 {
+		sub.BillingAnchorDay = billingAnchorDay
+		updatedFields = append(updatedFields, "billing_anchor_day")
+	}
+
 	if startDateStr != "" {
-		s.logger.DebugContext(ctx, "Parsing new start date", slog.String("start_date", startDateStr))
 		startDate, err := parseMonthYear(startDateStr)
 		if err != nil {
-			s.logger.ErrorContext(ctx, "Failed to parse new start date",
-				slog.String("start_date", startDateStr),
-				slog.String("error", err.Error()))
-			return nil, fmt.Errorf("invalid start_date: %w", err)
+			return nil, fmt.Errorf("%w: %w", ErrInvalidStartDate, err)
 		}
 		sub.StartDate = startDate
 		updatedFields = append(updatedFields, "start_date")
 	}
 
 	if endDateStr != "" {
-		s.logger.DebugContext(ctx, "Parsing new end date", slog.String("end_date", endDateStr))
 		endDate, err := parseMonthYear(endDateStr)
 		if err != nil {
-			s.logger.ErrorContext(ctx, "Failed to parse new end date",
-				slog.String("end_date", endDateStr),
-				slog.String("error", err.Error()))
-			return nil, fmt.Errorf("invalid end_date: %w", err)
+			return nil, fmt.Errorf("%w: %w", ErrInvalidEndDate, err)
 		}
 		if endDate.Before(sub.StartDate) {
-			s.logger.ErrorContext(ctx, "New end date is before start date",
-				slog.Time("start_date", sub.StartDate),
-				slog.Time("end_date", endDate))
-			return nil, errors.New("end_date must be after start_date")
+			return nil, ErrEndBeforeStart
 		}
 		sub.EndDate = &endDate
 		updatedFields = append(updatedFields, "end_date")
@@ -180,126 +347,581 @@ func (s *SubscriptionService) Update(ctx context.Context, id uuid.UUID, serviceN
 		updatedFields = append(updatedFields, "end_date_cleared")
 	}
 
-	s.logger.InfoContext(ctx, "Fields to be updated",
+	if category != nil {
+		sub.Category = nil
+		if *category != "" {
+			if err := checkCategory(ctx, s.categories, *category); err != nil {
+				return nil, err
+			}
+			sub.Category = category
+		}
+		updatedFields = append(updatedFields, "category")
+	}
+
+	if customFields != nil {
+		schema, err := loadCustomFieldSchema(ctx, s.fields, identity.FromContext(ctx).Tenant)
+		if err != nil {
+			return nil, err
+		}
+		sub.CustomFields, err = schema.apply(sub.CustomFields, customFields)
+		if err != nil {
+			return nil, err
+		}
+		updatedFields = append(updatedFields, "custom_fields")
+	}
+
+	s.logger.DebugContext(ctx, "Fields to be updated",
 		slog.String("subscription_id", id.String()),
 		slog.Any("updated_fields", updatedFields))
 
 	if err := s.repo.Update(ctx, sub); err != nil {
-		s.logger.ErrorContext(ctx, "Repository failed to update subscription",
-			slog.String("subscription_id", id.String()),
-			slog.String("error", err.Error()))
 		return nil, err
 	}
 
-	s.logger.InfoContext(ctx, "Successfully updated subscription in service layer",
-		slog.String("subscription_id", id.String()))
-
+	s.audit.Record(ctx, audit.ActionUpdate, id, before, sub)
 	return sub, nil
 }
 
 func (s *SubscriptionService) Delete(ctx context.Context, id uuid.UUID) error {
-	s.logger.InfoContext(ctx, "Deleting subscription in service layer",
-		slog.String("subscription_id", id.String()))
-
-	err := s.repo.Delete(ctx, id)
+	sub, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "Repository failed to delete subscription",
-			slog.String("subscription_id", id.String()),
-			slog.String("error", err.Error()))
 		return err
 	}
 
-	s.logger.InfoContext(ctx, "Successfully deleted subscription in service layer",
-		slog.String("subscription_id", id.String()))
+	if err := s.checkSubject(ctx, sub); err != nil {
+		return err
+	}
 
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, audit.ActionDelete, id, sub, nil)
 	return nil
 }
 
-func (s *SubscriptionService) List(ctx context.Context, userID uuid.UUID, serviceName string) ([]models.Subscription, error) {
-	s.logger.InfoContext(ctx, "Listing subscriptions in service layer",
-		slog.String("user_id", userID.String()),
-		slog.String("service_name", serviceName))
+// Cancel ends an open-ended subscription with the current month as its last billed
+// month, or with its start month if it has not started yet.
+func (s *SubscriptionService) Cancel(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSubject(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	if sub.EndDate != nil {
+		return nil, withHint(ErrAlreadyCancelled, "hint_already_cancelled", sub.ID.String(), sub.EndDate.Format("01-2006"))
+	}
+
+	before := *sub
+	now := s.clock.Now().UTC()
+	endDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if endDate.Before(sub.StartDate) {
+		endDate = sub.StartDate
+	}
+	sub.EndDate = &endDate
+
+	if err := s.repo.Update(ctx, sub); err != nil {
+		return nil, err
+	}
 
-	subs, err := s.repo.List(ctx, userID, serviceName)
+	s.audit.Record(ctx, audit.ActionCancel, id, before, sub)
+	return sub, nil
+}
+
+// Merge consolidates subscriptions of one user into the one that started first. The
+// result spans from the earliest start to the latest end (open-ended if any of them is)
+// and keeps the price of the most recently started subscription. The others are moved
+// to the trash and the audit log keeps their state from before the merge.
+func (s *SubscriptionService) Merge(ctx context.Context, ids []uuid.UUID) (*models.Subscription, error) {
+	subs, err := s.loadForMerge(ctx, ids)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "Repository failed to list subscriptions",
-			slog.String("user_id", userID.String()),
-			slog.String("service_name", serviceName),
-			slog.String("error", err.Error()))
 		return nil, err
 	}
 
-	s.logger.InfoContext(ctx, "Successfully retrieved subscriptions list in service layer",
-		slog.String("user_id", userID.String()),
-		slog.String("service_name", serviceName),
-		slog.Int("count", len(subs)))
+	return s.merge(ctx, subs)
+}
+
+// MergePair merges two records of the same user and service into one continuous
+// subscription. Unlike Merge it refuses records that leave a gap between them.
+func (s *SubscriptionService) MergePair(ctx context.Context, id uuid.UUID, otherID uuid.UUID) (*models.Subscription, error) {
+	subs, err := s.loadForMerge(ctx, []uuid.UUID{id, otherID})
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) != 2 {
+		return nil, ErrMergeTooFew
+	}
 
+	first, second := subs[0], subs[1]
+	if first.UserID != second.UserID {
+		return nil, ErrMergeUserMismatch
+	}
+	if !strings.EqualFold(strings.TrimSpace(first.ServiceName), strings.TrimSpace(second.ServiceName)) {
+		s.logger.ErrorContext(ctx, "Subscriptions to merge are for different services",
+			slog.String("service_name", first.ServiceName),
+			slog.String("other_service_name", second.ServiceName))
+		return nil, ErrMergeServiceMismatch
+	}
+	if first.Kind != second.Kind || first.BillingPeriod != second.BillingPeriod {
+		s.logger.ErrorContext(ctx, "Subscriptions to merge are billed differently",
+			slog.String("kind", first.Kind),
+			slog.String("other_kind", second.Kind),
+			slog.String("billing_period", first.BillingPeriod),
+			slog.String("other_billing_period", second.BillingPeriod))
+		return nil, ErrMergeKindMismatch
+	}
+	// End dates are the last billed month, so the next month still counts as continuous.
+	if first.EndDate != nil && second.StartDate.After(first.EndDate.AddDate(0, 1, 0)) {
+		s.logger.ErrorContext(ctx, "Subscriptions to merge are not continuous",
+			slog.Time("end_date", *first.EndDate),
+			slog.Time("next_start_date", second.StartDate))
+		return nil, ErrMergeNotContiguous
+	}
+
+	return s.merge(ctx, subs)
+}
+
+// loadForMerge fetches the distinct subscriptions to merge, ordered by start date.
+func (s *SubscriptionService) loadForMerge(ctx context.Context, ids []uuid.UUID) ([]*models.Subscription, error) {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	subs := make([]*models.Subscription, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		sub, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkSubject(ctx, sub); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	sort.SliceStable(subs, func(i, j int) bool {
+		return subs[i].StartDate.Before(subs[j].StartDate)
+	})
 	return subs, nil
 }
 
-func (s *SubscriptionService) Aggregate(ctx context.Context, startDateStr string, endDateStr string, userID *uuid.UUID, serviceName *string) (int, error) {
-	var userIDStr string
-	var serviceNameStr string
+func (s *SubscriptionService) merge(ctx context.Context, subs []*models.Subscription) (*models.Subscription, error) {
+	if len(subs) < 2 {
+		return nil, ErrMergeTooFew
+	}
 
-	if userID != nil {
-		userIDStr = userID.String()
+	primary := subs[0]
+	before := *primary
+	merged := *primary
+	absorbedIDs := make([]uuid.UUID, 0, len(subs)-1)
+
+	for _, sub := range subs[1:] {
+		if sub.UserID != primary.UserID {
+			s.logger.ErrorContext(ctx, "Subscriptions to merge belong to different users",
+				slog.String("subscription_id", sub.ID.String()),
+				slog.String("user_id", sub.UserID.String()),
+				slog.String("expected_user_id", primary.UserID.String()))
+			return nil, ErrMergeUserMismatch
+		}
+
+		merged.Price = sub.Price
+		switch {
+		case merged.EndDate == nil:
+		case sub.EndDate == nil:
+			merged.EndDate = nil
+		case sub.EndDate.After(*merged.EndDate):
+			endDate := *sub.EndDate
+			merged.EndDate = &endDate
+		}
+		absorbedIDs = append(absorbedIDs, sub.ID)
 	}
-	if serviceName != nil {
-		serviceNameStr = *serviceName
+
+	if err := s.repo.Merge(ctx, &merged, absorbedIDs); err != nil {
+		return nil, err
 	}
 
-	s.logger.InfoContext(ctx, "Aggregating subscription costs in service layer",
-		slog.String("start_date", startDateStr),
-		slog.String("end_date", endDateStr),
-		slog.String("user_id", userIDStr),
-		slog.String("service_name", serviceNameStr))
+	s.audit.Record(ctx, audit.ActionMerge, merged.ID, before, merged)
+	for _, sub := range subs[1:] {
+		s.audit.Record(ctx, audit.ActionMerge, sub.ID, sub, merged)
+	}
+	return &merged, nil
+}
 
-	s.logger.DebugContext(ctx, "Parsing aggregation start date", slog.String("start_date", startDateStr))
-	startDate, err := parseMonthYear(startDateStr)
+// Split ends the subscription the month before atStr and continues it as a new record
+// starting at atStr. A positive price or a non-nil userID apply to the new record only,
+// so earlier months keep their original price and owner.
+func (s *SubscriptionService) Split(ctx context.Context, id uuid.UUID, atStr string, price int, userID uuid.UUID) (*models.Subscription, *models.Subscription, error) {
+	at, err := parseMonthYear(atStr)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "Failed to parse aggregation start date",
-			slog.String("start_date", startDateStr),
-			slog.String("error", err.Error()))
-		return 0, fmt.Errorf("invalid start_date: %w", err)
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidSplitDate, err)
 	}
 
-	s.logger.DebugContext(ctx, "Parsing aggregation end date", slog.String("end_date", endDateStr))
-	endDate, err := parseMonthYear(endDateStr)
+	sub, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "Failed to parse aggregation end date",
-			slog.String("end_date", endDateStr),
-			slog.String("error", err.Error()))
-		return 0, fmt.Errorf("invalid end_date: %w", err)
+		return nil, nil, err
 	}
 
-	startPeriod := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, time.UTC)
-	endPeriod := time.Date(endDate.Year(), endDate.Month()+1, 0, 23, 59, 59, 0, time.UTC)
+	if err := s.checkSubject(ctx, sub); err != nil {
+		return nil, nil, err
+	}
 
-	s.logger.DebugContext(ctx, "Calculated aggregation period",
-		slog.Time("start_period", startPeriod),
-		slog.Time("end_period", endPeriod))
+	if !at.After(sub.StartDate) || (sub.EndDate != nil && at.After(*sub.EndDate)) {
+		if sub.EndDate == nil {
+			return nil, nil, withHint(ErrSplitOutOfRange, "hint_split_after", sub.StartDate.Format("01-2006"))
+		}
+		return nil, nil, withHint(ErrSplitOutOfRange, "hint_split_between",
+			sub.StartDate.AddDate(0, 1, 0).Format("01-2006"), sub.EndDate.Format("01-2006"))
+	}
+
+	after := &models.Subscription{
+		ID:               uuid.New(),
+		ServiceName:      sub.ServiceName,
+		Price:            sub.Price,
+		UserID:           sub.UserID,
+		Kind:             sub.Kind,
+		BillingPeriod:    sub.BillingPeriod,
+		BillingAnchorDay: sub.BillingAnchorDay,
+		StartDate:        at,
+		EndDate:          sub.EndDate,
+		Category:         sub.Category,
+		CustomFields:     sub.CustomFields,
+		Status:           sub.Status,
+	}
+	// A continuation starting in a future month waits for the scheduler like any
+	// other subscription starting then.
+	if at.After(s.currentMonth()) {
+		after.Status = models.StatusScheduled
+	} else if after.Status == "" {
+		after.Status = models.StatusActive
+	}
+	if price > 0 {
+		after.Price = price
+	}
+	if userID != uuid.Nil {
+		if id := identity.FromContext(ctx); id.Impersonating() && userID != *id.Subject {
+			s.logger.ErrorContext(ctx, "User ID does not match impersonated user",
+				slog.String("user_id", userID.String()),
+				slog.String("subject_id", id.Subject.String()))
+			return nil, nil, ErrSubjectMismatch
+		}
+		after.UserID = userID
+	}
 
-	total, err := s.repo.Aggregate(ctx, startPeriod, endPeriod, userID, serviceName)
+	before := *sub
+	endDate := at.AddDate(0, -1, 0)
+	sub.EndDate = &endDate
+
+	if err := s.repo.Split(ctx, sub, after); err != nil {
+		return nil, nil, err
+	}
+
+	s.audit.Record(ctx, audit.ActionSplit, sub.ID, before, sub)
+	s.audit.Record(ctx, audit.ActionSplit, after.ID, before, after)
+	return sub, after, nil
+}
+
+func (s *SubscriptionService) Undo(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	trashed, err := s.repo.GetDeletedByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSubject(ctx, trashed); err != nil {
+		return nil, err
+	}
+
+	deletedAfter := s.clock.Now().Add(-s.trashGracePeriod)
+	if err := s.repo.Restore(ctx, id, deletedAfter); err != nil {
+		return nil, err
+	}
+
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, audit.ActionRestore, id, nil, sub)
+	return sub, nil
+}
+
+func (s *SubscriptionService) PurgeTrash(ctx context.Context) error {
+	purged, err := s.repo.PurgeDeleted(ctx, s.clock.Now().Add(-s.trashGracePeriod))
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "Purged expired trash", slog.Int64("purged", purged))
+	return nil
+}
+
+// ActivateScheduled activates the scheduled subscriptions whose start month has come,
+// in the tables the identity in ctx refers to.
+func (s *SubscriptionService) ActivateScheduled(ctx context.Context) error {
+	id := identity.FromContext(ctx)
+	id.Actor = SchedulerActor
+	ctx = identity.WithIdentity(ctx, id)
+
+	activated, err := s.repo.ActivateScheduled(ctx, s.currentMonth().AddDate(0, 1, 0))
+	if err != nil {
+		return err
+	}
+	for i := range activated {
+		before := activated[i]
+		before.Status = models.StatusScheduled
+		s.audit.Record(ctx, audit.ActionActivate, activated[i].ID, before, &activated[i])
+	}
+
+	s.logger.InfoContext(ctx, "Activated scheduled subscriptions",
+		slog.String("tenant", id.Tenant),
+		slog.Int("activated", len(activated)))
+	return nil
+}
+
+// checkLimit fails when the user already has as many subscriptions not ended before the
+// current month as the tenant allows, counting the pending ones about to be inserted.
+// The count and the insert are not atomic, so concurrent creates can overshoot the
+// limit slightly.
+func (s *SubscriptionService) checkLimit(ctx context.Context, userID uuid.UUID, pending int64) error {
+	limit := s.limits.forTenant(identity.FromContext(ctx).Tenant)
+	if limit <= 0 {
+		return nil
+	}
+	active, err := s.repo.CountActive(ctx, userID, s.currentMonth())
+	if err != nil {
+		return err
+	}
+	active += pending
+	if active >= int64(limit) {
+		s.logger.WarnContext(ctx, "User reached the active subscription limit",
+			slog.String("user_id", userID.String()),
+			slog.Int64("active", active),
+			slog.Int("limit", limit))
+		return withHint(fmt.Errorf("%w: %d", ErrSubscriptionLimit, limit), "hint_subscription_limit", limit)
+	}
+	return nil
+}
+
+// currentMonth returns the first day of the current month.
+func (s *SubscriptionService) currentMonth() time.Time {
+	now := s.clock.Now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *SubscriptionService) List(ctx context.Context, filter models.ListFilter, page models.Page) ([]models.Subscription, error) {
+	filter, err := s.listFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, filter, page)
+}
+
+// Count counts the subscriptions List returns for filter without a page.
+func (s *SubscriptionService) Count(ctx context.Context, filter models.ListFilter) (int64, error) {
+	filter, err := s.listFilter(ctx, filter)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "Repository failed to aggregate subscriptions",
-			slog.Time("start_period", startPeriod),
-			slog.Time("end_period", endPeriod),
-			slog.String("user_id", userIDStr),
-			slog.String("service_name", serviceNameStr),
-			slog.String("error", err.Error()))
 		return 0, err
 	}
+	return s.repo.Count(ctx, filter)
+}
 
-	s.logger.InfoContext(ctx, "Successfully completed aggregation in service layer",
-		slog.Time("start_period", startPeriod),
-		slog.Time("end_period", endPeriod),
-		slog.String("user_id", userIDStr),
-		slog.String("service_name", serviceNameStr),
-		slog.Int("total", total))
+// Facets counts the subscriptions List returns for filter without a page, in all and
+// by facet.
+func (s *SubscriptionService) Facets(ctx context.Context, filter models.ListFilter) (*models.FacetSummary, error) {
+	filter, err := s.listFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Facets(ctx, filter)
+}
+
+// listFilter narrows filter to the impersonated user and converts its custom field
+// values to the types of their fields.
+func (s *SubscriptionService) listFilter(ctx context.Context, filter models.ListFilter) (models.ListFilter, error) {
+	id := identity.FromContext(ctx)
+	if id.Impersonating() {
+		filter.UserID = *id.Subject
+	}
+
+	if len(filter.CustomFields) > 0 {
+		schema, err := loadCustomFieldSchema(ctx, s.fields, id.Tenant)
+		if err != nil {
+			return filter, err
+		}
+		if filter.CustomFields, err = schema.match(filter.CustomFields); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// Aggregate totals the monthly costs of the subscriptions charged in the period, or in
+// exact mode the charges that fall due in it.
+func (s *SubscriptionService) Aggregate(ctx context.Context, startDateStr string, endDateStr string, mode string, filter models.AggregateFilter) (decimal.Decimal, error) {
+	if id := identity.FromContext(ctx); id.Impersonating() {
+		filter.UserIDs = []uuid.UUID{*id.Subject}
+	}
+
+	mode, err := aggregationMode(mode)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	startPeriod, endPeriod, err := aggregationPeriod(startDateStr, endDateStr)
+	if err != nil {
+		return decimal.Zero, err
+	}
 
+	total, err := s.repo.Aggregate(ctx, startPeriod, endPeriod, mode, filter)
+	if err != nil {
+		s.alerts.Alert(ctx, notify.AlertAggregationFailure, "Subscription aggregation failed", err.Error())
+		return decimal.Zero, err
+	}
 	return total, nil
 }
 
+// AggregateByBucket splits the aggregation period into month, quarter or year buckets
+// and totals the subscriptions active in each of them.
+func (s *SubscriptionService) AggregateByBucket(ctx context.Context, startDateStr string, endDateStr string, bucket string, mode string, filter models.AggregateFilter) ([]models.BucketTotal, error) {
+	if id := identity.FromContext(ctx); id.Impersonating() {
+		filter.UserIDs = []uuid.UUID{*id.Subject}
+	}
+
+	mode, err := aggregationMode(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	switch bucket {
+	case models.BucketMonth, models.BucketQuarter, models.BucketYear:
+	default:
+		return nil, ErrInvalidBucket
+	}
+
+	startPeriod, endPeriod, err := aggregationPeriod(startDateStr, endDateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.repo.AggregateByBucket(ctx, startPeriod, endPeriod, bucket, mode, filter)
+	if err != nil {
+		s.alerts.Alert(ctx, notify.AlertAggregationFailure, "Bucketed subscription aggregation failed", err.Error())
+		return nil, err
+	}
+	return totals, nil
+}
+
+// AggregateByCategory totals the aggregation per category, the subscriptions without
+// one under a nil category.
+func (s *SubscriptionService) AggregateByCategory(ctx context.Context, startDateStr string, endDateStr string, mode string, filter models.AggregateFilter) ([]models.CategoryTotal, error) {
+	if id := identity.FromContext(ctx); id.Impersonating() {
+		filter.UserIDs = []uuid.UUID{*id.Subject}
+	}
+
+	mode, err := aggregationMode(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	startPeriod, endPeriod, err := aggregationPeriod(startDateStr, endDateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.repo.AggregateByCategory(ctx, startPeriod, endPeriod, mode, filter)
+	if err != nil {
+		s.alerts.Alert(ctx, notify.AlertAggregationFailure, "Category subscription aggregation failed", err.Error())
+		return nil, err
+	}
+	return totals, nil
+}
+
+// maxAggregateDetails caps the subscriptions listed by AggregateDetails, which keeps
+// the response of a whole-tenant aggregation bounded.
+const maxAggregateDetails = 10000
+
+// AggregateDetails lists what each subscription contributes to the aggregation, so
+// that a total can be audited.
+func (s *SubscriptionService) AggregateDetails(ctx context.Context, startDateStr string, endDateStr string, mode string, filter models.AggregateFilter) (*models.AggregateBreakdown, error) {
+	if id := identity.FromContext(ctx); id.Impersonating() {
+		filter.UserIDs = []uuid.UUID{*id.Subject}
+	}
+
+	mode, err := aggregationMode(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	startPeriod, endPeriod, err := aggregationPeriod(startDateStr, endDateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := s.repo.AggregateDetails(ctx, startPeriod, endPeriod, mode, filter, maxAggregateDetails+1)
+	if err != nil {
+		s.alerts.Alert(ctx, notify.AlertAggregationFailure, "Subscription aggregation details failed", err.Error())
+		return nil, err
+	}
+	breakdown := &models.AggregateBreakdown{Details: details}
+	if len(details) > maxAggregateDetails {
+		breakdown.Details, breakdown.Truncated = details[:maxAggregateDetails], true
+	}
+	return breakdown, nil
+}
+
+func (s *SubscriptionService) Stats(ctx context.Context, filter models.AggregateFilter) (*models.SubscriptionStats, error) {
+	if id := identity.FromContext(ctx); id.Impersonating() {
+		filter.UserIDs = []uuid.UUID{*id.Subject}
+	}
+
+	return s.repo.Stats(ctx, filter)
+}
+
+// aggregationMode defaults an empty mode to normalized.
+func aggregationMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return models.AggregateNormalized, nil
+	case models.AggregateNormalized, models.AggregateExact:
+		return mode, nil
+	}
+	return mode, ErrInvalidMode
+}
+
+// aggregationPeriod turns MM-YYYY bounds into the first instant of the start month and
+// the last second of the end month.
+func aggregationPeriod(startDateStr string, endDateStr string) (time.Time, time.Time, error) {
+	startDate, err := parseMonthYear(startDateStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %w", ErrInvalidStartDate, err)
+	}
+
+	endDate, err := parseMonthYear(endDateStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %w", ErrInvalidEndDate, err)
+	}
+
+	startPeriod := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	endPeriod := time.Date(endDate.Year(), endDate.Month()+1, 0, 23, 59, 59, 0, time.UTC)
+	return startPeriod, endPeriod, nil
+}
+
+func (s *SubscriptionService) checkSubject(ctx context.Context, sub *models.Subscription) error {
+	id := identity.FromContext(ctx)
+	if id.Impersonating() && sub.UserID != *id.Subject {
+		s.logger.WarnContext(ctx, "Subscription does not belong to impersonated user",
+			slog.String("subscription_id", sub.ID.String()),
+			slog.String("subject_id", id.Subject.String()))
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func parseMonthYear(dateStr string) (time.Time, error) {
 	return time.Parse("01-2006", dateStr)
 }
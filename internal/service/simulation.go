@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/notify"
+)
+
+// Simulate applies hypothetical changes to a user's subscriptions and returns the
+// monthly costs of the period before and after them. The projection runs through the
+// same aggregation as Aggregate, in the given mode, and nothing is persisted.
+func (s *SubscriptionService) Simulate(ctx context.Context, userID uuid.UUID, startDateStr string, endDateStr string, mode string, changes []models.SimulationChange) (*models.SimulationResult, error) {
+	if id := identity.FromContext(ctx); id.Impersonating() && userID != *id.Subject {
+		s.logger.ErrorContext(ctx, "User ID does not match impersonated user",
+			slog.String("user_id", userID.String()),
+			slog.String("subject_id", id.Subject.String()))
+		return nil, ErrSubjectMismatch
+	}
+
+	mode, err := aggregationMode(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	startPeriod, endPeriod, err := aggregationPeriod(startDateStr, endDateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	scenario, err := s.scenario(ctx, userID, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := models.AggregateFilter{UserIDs: []uuid.UUID{userID}}
+	baseline, projected, err := s.repo.Simulate(ctx, scenario, startPeriod, endPeriod, mode, filter)
+	if err != nil {
+		s.alerts.Alert(ctx, notify.AlertAggregationFailure, "Subscription simulation failed", err.Error())
+		return nil, err
+	}
+
+	// Both series come from the same month buckets, so they line up by index.
+	result := &models.SimulationResult{Months: make([]models.SimulatedMonth, 0, len(baseline))}
+	for i, month := range baseline {
+		simulated := models.SimulatedMonth{Start: month.Start, Baseline: month.Total}
+		if i < len(projected) {
+			simulated.Projected = projected[i].Total
+		}
+		simulated.Difference = simulated.Projected.Sub(simulated.Baseline)
+
+		result.Months = append(result.Months, simulated)
+		result.BaselineTotal = result.BaselineTotal.Add(simulated.Baseline)
+		result.ProjectedTotal = result.ProjectedTotal.Add(simulated.Projected)
+	}
+	result.Difference = result.ProjectedTotal.Sub(result.BaselineTotal)
+
+	return result, nil
+}
+
+// scenario validates the changes in order and folds them into the rows to add, update
+// and remove. Changes to existing subscriptions are limited to userID's, and later
+// changes see the effect of earlier ones on the same subscription.
+func (s *SubscriptionService) scenario(ctx context.Context, userID uuid.UUID, changes []models.SimulationChange) (models.Scenario, error) {
+	var scenario models.Scenario
+	loaded := make(map[uuid.UUID]*models.Subscription)
+	removed := make(map[uuid.UUID]bool)
+	var order []uuid.UUID
+
+	existing := func(id uuid.UUID) (*models.Subscription, error) {
+		if sub, ok := loaded[id]; ok {
+			if removed[id] {
+				return nil, fmt.Errorf("subscription %s is already removed", id)
+			}
+			return sub, nil
+		}
+
+		sub, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if sub.UserID != userID {
+			s.logger.WarnContext(ctx, "Simulation references a subscription of another user",
+				slog.String("subscription_id", id.String()),
+				slog.String("user_id", userID.String()))
+			return nil, gorm.ErrRecordNotFound
+		}
+
+		loaded[id] = sub
+		order = append(order, id)
+		return sub, nil
+	}
+
+	for i, change := range changes {
+		invalid := func(reason string) error {
+			return fmt.Errorf("%w: change %d: %s", ErrInvalidSimulation, i+1, reason)
+		}
+
+		if change.Action == models.SimulateAdd {
+			sub, err := simulatedSubscription(userID, change)
+			if err != nil {
+				return scenario, invalid(err.Error())
+			}
+			scenario.Added = append(scenario.Added, *sub)
+			continue
+		}
+
+		if change.SubscriptionID == uuid.Nil {
+			return scenario, invalid("subscription_id is required")
+		}
+		sub, err := existing(change.SubscriptionID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return scenario, err
+			}
+			return scenario, invalid(err.Error())
+		}
+
+		switch change.Action {
+		case models.SimulateRemove:
+			removed[sub.ID] = true
+			scenario.Removed = append(scenario.Removed, sub.ID)
+
+		case models.SimulateCancel:
+			// Like Cancel, the end date is the last billed month.
+			now := s.clock.Now().UTC()
+			lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+			if change.At != "" {
+				if lastMonth, err = parseMonthYear(change.At); err != nil {
+					return scenario, invalid("invalid at")
+				}
+			}
+			if lastMonth.Before(sub.StartDate) {
+				lastMonth = sub.StartDate
+			}
+			if sub.EndDate != nil && !lastMonth.Before(*sub.EndDate) {
+				return scenario, invalid("subscription already ends by then")
+			}
+			sub.EndDate = &lastMonth
+
+		case models.SimulateChangePrice:
+			if change.Price <= 0 {
+				return scenario, invalid("price must be greater than zero")
+			}
+			if change.At == "" {
+				sub.Price = change.Price
+				break
+			}
+
+			// A price change from a given month on is simulated as a split.
+			at, err := parseMonthYear(change.At)
+			if err != nil {
+				return scenario, invalid("invalid at")
+			}
+			if !at.After(sub.StartDate) || (sub.EndDate != nil && at.After(*sub.EndDate)) {
+				return scenario, invalid("at must be after the start month and not after the end month")
+			}
+			after := *sub
+			after.ID = uuid.New()
+			after.Price = change.Price
+			after.StartDate = at
+			scenario.Added = append(scenario.Added, after)
+
+			endDate := at.AddDate(0, -1, 0)
+			sub.EndDate = &endDate
+
+		default:
+			return scenario, invalid(fmt.Sprintf("unknown action %q", change.Action))
+		}
+	}
+
+	for _, id := range order {
+		if !removed[id] {
+			scenario.Updated = append(scenario.Updated, *loaded[id])
+		}
+	}
+	return scenario, nil
+}
+
+// simulatedSubscription builds the subscription an add change describes, with the same
+// defaults as Create.
+func simulatedSubscription(userID uuid.UUID, change models.SimulationChange) (*models.Subscription, error) {
+	if change.ServiceName == "" {
+		return nil, errors.New("service_name is required")
+	}
+	if change.Price <= 0 {
+		return nil, errors.New("price must be greater than zero")
+	}
+
+	startDate, err := parseMonthYear(change.StartDate)
+	if err != nil {
+		return nil, errors.New("invalid start_date")
+	}
+
+	var endDate *time.Time
+	if change.EndDate != "" {
+		ed, err := parseMonthYear(change.EndDate)
+		if err != nil {
+			return nil, errors.New("invalid end_date")
+		}
+		if ed.Before(startDate) {
+			return nil, ErrEndBeforeStart
+		}
+		endDate = &ed
+	}
+
+	sub := &models.Subscription{
+		ID:               uuid.New(),
+		ServiceName:      change.ServiceName,
+		Price:            change.Price,
+		UserID:           userID,
+		Kind:             change.Kind,
+		BillingPeriod:    change.BillingPeriod,
+		BillingAnchorDay: change.BillingAnchorDay,
+		StartDate:        startDate,
+		EndDate:          endDate,
+	}
+	if sub.Kind == "" {
+		sub.Kind = models.KindRecurring
+	}
+	if sub.BillingPeriod == "" {
+		sub.BillingPeriod = models.BillingMonthly
+	}
+	if sub.BillingAnchorDay == 0 {
+		sub.BillingAnchorDay = 1
+	}
+	return sub, nil
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/notify"
+)
+
+// Directions of the spend change a rule alerts on.
+const (
+	SpendIncrease = "increase"
+	SpendDecrease = "decrease"
+)
+
+// SpendAlertRule trips when the monthly spend of Tenant, or of its Service only, moves
+// by more than ThresholdPercent in Direction from the previous month to the current
+// one. Spend is computed as by AnalyticsService.Compare.
+type SpendAlertRule struct {
+	Name             string  `yaml:"name"`
+	Tenant           string  `yaml:"tenant"`
+	Service          string  `yaml:"service"`
+	Direction        string  `yaml:"direction"`
+	ThresholdPercent float64 `yaml:"threshold_percent"`
+}
+
+// LoadSpendAlertRules reads rules from YAML or JSON of the form
+// {"rules": [{"name": ..., "threshold_percent": ..., ...}]}. Tenant defaults to the
+// default tenant and Direction to increase.
+func LoadSpendAlertRules(r io.Reader) ([]SpendAlertRule, error) {
+	var file struct {
+		Rules []SpendAlertRule `yaml:"rules"`
+	}
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse spend alert rules: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for i := range file.Rules {
+		rule := &file.Rules[i]
+		if rule.Tenant == "" {
+			rule.Tenant = identity.DefaultTenant
+		}
+		if rule.Direction == "" {
+			rule.Direction = SpendIncrease
+		}
+		switch {
+		case rule.Name == "":
+			return nil, errors.New("spend alert rule: name is required")
+		case rule.Direction != SpendIncrease && rule.Direction != SpendDecrease:
+			return nil, fmt.Errorf("spend alert rule %q: direction must be increase or decrease", rule.Name)
+		case rule.ThresholdPercent <= 0:
+			return nil, fmt.Errorf("spend alert rule %q: threshold_percent must be positive", rule.Name)
+		case names[rule.Name]:
+			return nil, fmt.Errorf("spend alert rule %q: duplicate name", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	return file.Rules, nil
+}
+
+// SpendAlertService evaluates the spend alert rules and posts a spend_change alert
+// listing the rules that tripped. A rule alerts once per month, however long its
+// change lasts.
+type SpendAlertService struct {
+	rules     []SpendAlertRule
+	analytics spendComparer
+	tenants   tenantLookup
+	alerts    alerter
+	clock     clock.Clock
+	logger    *slog.Logger
+
+	// alerted holds the month each rule last alerted in, as MM-YYYY.
+	alerted map[string]string
+}
+
+type spendComparer interface {
+	Compare(ctx context.Context, startA string, endA string, startB string, endB string) (*models.SpendComparison, error)
+}
+
+type tenantLookup interface {
+	Tenant(ctx context.Context, name string) (*models.Tenant, error)
+}
+
+func NewSpendAlertService(rules []SpendAlertRule, analytics spendComparer, tenants tenantLookup, alerts alerter, clock clock.Clock, logger *slog.Logger) *SpendAlertService {
+	return &SpendAlertService{
+		rules:     rules,
+		analytics: analytics,
+		tenants:   tenants,
+		alerts:    alerts,
+		clock:     clock,
+		logger:    logger,
+		alerted:   make(map[string]string),
+	}
+}
+
+// Evaluate compares the spend of the current month with the previous one for every
+// tenant with rules, and alerts on the rules newly tripped. It is run by the scheduler.
+func (s *SpendAlertService) Evaluate(ctx context.Context) error {
+	now := s.clock.Now().UTC()
+	current := now.Format("01-2006")
+	previous := now.AddDate(0, 0, -now.Day()+1).AddDate(0, -1, 0).Format("01-2006")
+
+	var tenants []string
+	for _, rule := range s.rules {
+		if !slices.Contains(tenants, rule.Tenant) {
+			tenants = append(tenants, rule.Tenant)
+		}
+	}
+
+	var tripped []string
+	var failed []error
+	for _, tenant := range tenants {
+		tenantCtx, err := s.tenantContext(ctx, tenant)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("tenant %s: %w", tenant, err))
+			continue
+		}
+		comparison, err := s.analytics.Compare(tenantCtx, previous, previous, current, current)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("tenant %s: %w", tenant, err))
+			continue
+		}
+
+		for _, rule := range s.rules {
+			if rule.Tenant != tenant {
+				continue
+			}
+			percent, ok := ruleChange(rule, comparison)
+			if !ok || !exceeds(rule, percent) {
+				continue
+			}
+			if s.alerted[rule.Name] == current {
+				continue
+			}
+			s.alerted[rule.Name] = current
+
+			s.logger.WarnContext(ctx, "Spend alert rule tripped",
+				slog.String("rule", rule.Name),
+				slog.String("tenant", rule.Tenant),
+				slog.String("service", rule.Service),
+				slog.Float64("change_percent", percent))
+			tripped = append(tripped, fmt.Sprintf("%s: %s spend of tenant %s changed by %+.2f%% from %s to %s (threshold %s %g%%)",
+				rule.Name, spendSubject(rule), rule.Tenant, percent, previous, current, rule.Direction, rule.ThresholdPercent))
+		}
+	}
+
+	if len(tripped) > 0 {
+		s.alerts.Alert(ctx, notify.AlertSpendChange,
+			fmt.Sprintf("%d spend alert rule(s) tripped", len(tripped)),
+			strings.Join(tripped, "\n"))
+	}
+	return errors.Join(failed...)
+}
+
+// tenantContext returns ctx with the identity of tenant, so that its spend is read
+// from its schema when it is isolated.
+func (s *SpendAlertService) tenantContext(ctx context.Context, name string) (context.Context, error) {
+	if name == identity.DefaultTenant {
+		return ctx, nil
+	}
+	tenant, err := s.tenants.Tenant(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if tenant == nil {
+		return nil, ErrUnknownTenant
+	}
+	id := identity.Identity{Actor: identity.FromContext(ctx).Actor, Tenant: tenant.Name}
+	if tenant.Schema != nil {
+		id.Schema = *tenant.Schema
+	}
+	return identity.WithIdentity(ctx, id), nil
+}
+
+// ruleChange returns the change in percent the rule watches. There is none when the
+// previous month had no spend.
+func ruleChange(rule SpendAlertRule, comparison *models.SpendComparison) (float64, bool) {
+	percent := comparison.ChangePercent
+	if rule.Service != "" {
+		percent = nil
+		for _, service := range comparison.Services {
+			if service.ServiceName == rule.Service {
+				percent = service.ChangePercent
+				break
+			}
+		}
+	}
+	if percent == nil {
+		return 0, false
+	}
+	return *percent, true
+}
+
+func exceeds(rule SpendAlertRule, percent float64) bool {
+	if rule.Direction == SpendDecrease {
+		return -percent > rule.ThresholdPercent
+	}
+	return percent > rule.ThresholdPercent
+}
+
+func spendSubject(rule SpendAlertRule) string {
+	if rule.Service == "" {
+		return "total"
+	}
+	return rule.Service
+}
@@ -0,0 +1,449 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/cache"
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+	"awesomeProject1/internal/quota"
+	"awesomeProject1/internal/secretbox"
+	"awesomeProject1/internal/tenancy"
+)
+
+var (
+	ErrTenantExists            = errors.New("tenant already exists")
+	ErrUnknownTenant           = errors.New("tenant has not been provisioned")
+	ErrTenantIsolationDisabled = errors.New("tenant isolation is disabled")
+	ErrSecretEncryptionOff     = errors.New("secret encryption is not configured")
+)
+
+// tenantCacheTTL bounds how long the instances that did not suspend or delete a tenant
+// keep serving it.
+const tenantCacheTTL = 30 * time.Second
+
+type tenantRepository interface {
+	Create(ctx context.Context, tenant *models.Tenant) error
+	Get(ctx context.Context, name string) (*models.Tenant, error)
+	List(ctx context.Context) ([]models.Tenant, error)
+	SetStatus(ctx context.Context, name string, status string, suspendedAt *time.Time) error
+	Delete(ctx context.Context, tenant *models.Tenant) error
+	CreateSchema(ctx context.Context, schema string) error
+	DropSchema(ctx context.Context, schema string) error
+	SaveSettings(ctx context.Context, settings *models.TenantSettings) error
+	Settings(ctx context.Context, tenant string) (*models.TenantSettings, error)
+	DeleteSettings(ctx context.Context, tenant string) error
+	CreateAPIKey(ctx context.Context, key *models.TenantAPIKey) error
+	APIKey(ctx context.Context, clientID string) (*models.TenantAPIKey, error)
+	SetAPIKeySigningKey(ctx context.Context, clientID string, encryptedKey string) error
+	DeleteAPIKey(ctx context.Context, clientID string) error
+	CreateWebhook(ctx context.Context, webhook *models.TenantWebhook) error
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+}
+
+// TenantPools hold the connections of isolated tenants, which must be closed when their
+// schema is dropped.
+type TenantPools interface {
+	CloseSchema(schema string) error
+}
+
+// TenantService onboards tenants and resolves them, and the API keys issued to them,
+// for their requests. Lookups are cached briefly, missing rows included.
+type TenantService struct {
+	repo     tenantRepository
+	defaults quota.Limits
+	isolate  bool
+	pools    TenantPools
+	box      *secretbox.Box
+	clock    clock.Clock
+	logger   *slog.Logger
+
+	tenants  *cache.Cache[*models.Tenant]
+	keys     *cache.Cache[*models.TenantAPIKey]
+	settings *cache.Cache[*models.TenantSettings]
+}
+
+// NewTenantService onboards tenants with the quota limits of defaults. Tenants can
+// only be isolated when pools are given, which TENANT_STORAGE=schema does. API keys
+// are only issued with a box to encrypt their signing keys.
+func NewTenantService(repo tenantRepository, defaults quota.Limits, pools TenantPools, box *secretbox.Box, clock clock.Clock, logger *slog.Logger) *TenantService {
+	return &TenantService{
+		repo:     repo,
+		defaults: defaults,
+		isolate:  pools != nil,
+		pools:    pools,
+		box:      box,
+		clock:    clock,
+		logger:   logger,
+		tenants:  cache.New[*models.Tenant](tenantCacheTTL, 10000, clock),
+		keys:     cache.New[*models.TenantAPIKey](tenantCacheTTL, 10000, clock),
+		settings: cache.New[*models.TenantSettings](tenantCacheTTL, 10000, clock),
+	}
+}
+
+// provisioningStep is one part of onboarding a tenant; undo reverts it when a later
+// step fails.
+type provisioningStep struct {
+	name string
+	do   func(ctx context.Context) error
+	undo func(ctx context.Context) error
+}
+
+// Create onboards the tenant name: it registers the tenant with the default settings,
+// issues its first API key, registers webhookURL, if any, and provisions the schema
+// of an isolated tenant. When a step fails, the steps done so far are undone in
+// reverse order.
+func (s *TenantService) Create(ctx context.Context, name string, isolated bool, webhookURL string) (*models.TenantProvisioning, error) {
+	if _, err := tenancy.SchemaName(name); err != nil {
+		return nil, err
+	}
+	if isolated && !s.isolate {
+		return nil, ErrTenantIsolationDisabled
+	}
+	if s.box == nil {
+		return nil, ErrSecretEncryptionOff
+	}
+	_, err := s.repo.Get(ctx, name)
+	switch {
+	case err == nil:
+		return nil, fmt.Errorf("%w: %s", ErrTenantExists, name)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	result := &models.TenantProvisioning{
+		Tenant: models.Tenant{Name: name, Status: models.TenantActive, CreatedAt: now},
+		Settings: models.TenantSettings{
+			Tenant:          name,
+			MonthlyRequests: s.defaults.Requests,
+			MonthlyCreates:  s.defaults.Creates,
+			UpdatedAt:       now,
+		},
+	}
+	if isolated {
+		schema, _ := tenancy.SchemaName(name)
+		result.Tenant.Schema = &schema
+	}
+	suffix, err := randomHex(4)
+	if err != nil {
+		return nil, err
+	}
+	if result.Secret, err = randomHex(32); err != nil {
+		return nil, err
+	}
+	signingKey := sha256.Sum256([]byte(result.Secret))
+	result.APIKey = models.TenantAPIKey{
+		ClientID:  name + "-" + suffix,
+		Tenant:    name,
+		CreatedAt: now,
+	}
+	if result.APIKey.EncryptedKey, err = s.box.Seal(signingKey[:], result.APIKey.ClientID); err != nil {
+		return nil, err
+	}
+
+	steps := []provisioningStep{
+		{
+			name: "register tenant",
+			do:   func(ctx context.Context) error { return s.repo.Create(ctx, &result.Tenant) },
+			undo: func(ctx context.Context) error { return s.repo.Delete(ctx, &models.Tenant{Name: name}) },
+		},
+		{
+			name: "save settings",
+			do:   func(ctx context.Context) error { return s.repo.SaveSettings(ctx, &result.Settings) },
+			undo: func(ctx context.Context) error { return s.repo.DeleteSettings(ctx, name) },
+		},
+		{
+			name: "issue API key",
+			do:   func(ctx context.Context) error { return s.repo.CreateAPIKey(ctx, &result.APIKey) },
+			undo: func(ctx context.Context) error { return s.repo.DeleteAPIKey(ctx, result.APIKey.ClientID) },
+		},
+	}
+	if webhookURL != "" {
+		if result.WebhookSecret, err = randomHex(32); err != nil {
+			return nil, err
+		}
+		result.Webhook = &models.TenantWebhook{
+			ID:        uuid.New(),
+			Tenant:    name,
+			URL:       webhookURL,
+			Secret:    result.WebhookSecret,
+			CreatedAt: now,
+		}
+		steps = append(steps, provisioningStep{
+			name: "register webhook",
+			do:   func(ctx context.Context) error { return s.repo.CreateWebhook(ctx, result.Webhook) },
+			undo: func(ctx context.Context) error { return s.repo.DeleteWebhook(ctx, result.Webhook.ID) },
+		})
+	}
+	if schema := result.Tenant.Schema; schema != nil {
+		steps = append(steps, provisioningStep{
+			name: "provision schema",
+			do:   func(ctx context.Context) error { return s.repo.CreateSchema(ctx, *schema) },
+			undo: func(ctx context.Context) error { return s.repo.DropSchema(ctx, *schema) },
+		})
+	}
+
+	if err := s.provision(ctx, name, steps); err != nil {
+		return nil, err
+	}
+	s.forget(name)
+
+	s.logger.InfoContext(ctx, "Onboarded tenant",
+		slog.String("tenant", name),
+		slog.Bool("isolated", isolated),
+		slog.String("client_id", result.APIKey.ClientID),
+		slog.Bool("webhook", result.Webhook != nil))
+	return result, nil
+}
+
+func (s *TenantService) provision(ctx context.Context, tenant string, steps []provisioningStep) error {
+	for i, step := range steps {
+		if err := step.do(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "Tenant provisioning step failed, rolling back",
+				slog.String("tenant", tenant),
+				slog.String("step", step.name),
+				slog.String("error", err.Error()))
+			s.rollback(context.WithoutCancel(ctx), tenant, steps[:i])
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+	return nil
+}
+
+// rollback undoes steps in reverse order. It carries on past failures, which leave
+// rows for an operator to remove with Delete.
+func (s *TenantService) rollback(ctx context.Context, tenant string, steps []provisioningStep) {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if err := steps[i].undo(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to roll back tenant provisioning step",
+				slog.String("tenant", tenant),
+				slog.String("step", steps[i].name),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (s *TenantService) List(ctx context.Context) ([]models.Tenant, error) {
+	return s.repo.List(ctx)
+}
+
+// EverySchema wraps job to run on the shared tables and then on the schema of every
+// isolated tenant, with the tenant in the identity of its context. A failure in one
+// schema does not keep job from the others.
+func (s *TenantService) EverySchema(job func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		errs := []error{job(ctx)}
+		if !s.isolate {
+			return errs[0]
+		}
+
+		tenants, err := s.repo.List(ctx)
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		for _, tenant := range tenants {
+			if tenant.Schema == nil {
+				continue
+			}
+			tenantCtx := identity.WithIdentity(ctx, identity.Identity{
+				Actor:  identity.FromContext(ctx).Actor,
+				Tenant: tenant.Name,
+				Schema: *tenant.Schema,
+			})
+			if err := job(tenantCtx); err != nil {
+				errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.Name, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// Suspend rejects the requests of tenant until it is resumed; its data is kept.
+func (s *TenantService) Suspend(ctx context.Context, tenant string) (*models.Tenant, error) {
+	now := s.clock.Now().UTC()
+	return s.setStatus(ctx, tenant, models.TenantSuspended, &now)
+}
+
+func (s *TenantService) Resume(ctx context.Context, tenant string) (*models.Tenant, error) {
+	return s.setStatus(ctx, tenant, models.TenantActive, nil)
+}
+
+func (s *TenantService) setStatus(ctx context.Context, name string, status string, suspendedAt *time.Time) (*models.Tenant, error) {
+	tenant, err := s.get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if tenant.Status == status {
+		return tenant, nil
+	}
+	if err := s.repo.SetStatus(ctx, name, status, suspendedAt); err != nil {
+		return nil, err
+	}
+	s.forget(name)
+
+	tenant.Status = status
+	tenant.SuspendedAt = suspendedAt
+	s.logger.InfoContext(ctx, "Changed tenant status",
+		slog.String("tenant", name),
+		slog.String("status", status))
+	return tenant, nil
+}
+
+// Delete removes tenant with its settings, API keys and webhooks, and drops the schema
+// of an isolated tenant with all its data. The data of other tenants in the shared
+// tables is kept.
+func (s *TenantService) Delete(ctx context.Context, name string) error {
+	tenant, err := s.get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, tenant); err != nil {
+		return err
+	}
+	s.forget(name)
+	// API keys are cached by client ID, so the only way to drop the tenant's is to drop
+	// all of them.
+	s.keys.Purge()
+	if tenant.Schema != nil && s.pools != nil {
+		if err := s.pools.CloseSchema(*tenant.Schema); err != nil {
+			s.logger.WarnContext(ctx, "Failed to close tenant connection pool",
+				slog.String("tenant", name),
+				slog.String("error", err.Error()))
+		}
+	}
+
+	s.logger.InfoContext(ctx, "Deleted tenant",
+		slog.String("tenant", name),
+		slog.Bool("isolated", tenant.Schema != nil))
+	return nil
+}
+
+func (s *TenantService) get(ctx context.Context, name string) (*models.Tenant, error) {
+	tenant, err := s.repo.Get(ctx, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTenant, name)
+	}
+	return tenant, err
+}
+
+func (s *TenantService) forget(name string) {
+	s.tenants.Delete(name)
+	s.settings.Delete(name)
+}
+
+// Tenant returns the onboarded tenant name, or nil when there is none.
+func (s *TenantService) Tenant(ctx context.Context, name string) (*models.Tenant, error) {
+	if tenant, ok := s.tenants.Get(name); ok {
+		return tenant, nil
+	}
+	tenant, err := s.repo.Get(ctx, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		tenant, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.tenants.Set(name, tenant)
+	return tenant, nil
+}
+
+// APIKey returns the API key issued as clientID, or nil when there is none.
+func (s *TenantService) APIKey(ctx context.Context, clientID string) (*models.TenantAPIKey, error) {
+	if key, ok := s.keys.Get(clientID); ok {
+		return key, nil
+	}
+	key, err := s.repo.APIKey(ctx, clientID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		key, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key != nil {
+		if err := s.openSigningKey(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+	s.keys.Set(clientID, key)
+	return key, nil
+}
+
+// openSigningKey decrypts the signing key of key. Keys issued before signing keys were
+// encrypted hold them in plaintext; they are encrypted the first time they are used.
+func (s *TenantService) openSigningKey(ctx context.Context, key *models.TenantAPIKey) error {
+	if secretbox.Sealed(key.EncryptedKey) {
+		if s.box == nil {
+			return fmt.Errorf("open signing key of %s: %w", key.ClientID, ErrSecretEncryptionOff)
+		}
+		signingKey, err := s.box.Open(key.EncryptedKey, key.ClientID)
+		if err != nil {
+			return fmt.Errorf("open signing key of %s: %w", key.ClientID, err)
+		}
+		key.SigningKey = signingKey
+		return nil
+	}
+
+	signingKey, err := hex.DecodeString(key.EncryptedKey)
+	if err != nil {
+		return fmt.Errorf("decode signing key of %s: %w", key.ClientID, err)
+	}
+	key.SigningKey = signingKey
+	if s.box == nil {
+		s.logger.WarnContext(ctx, "Signing key of issued API key is stored in plaintext, set SECRET_ENCRYPTION_KEY to encrypt it",
+			slog.String("client_id", key.ClientID))
+		return nil
+	}
+
+	sealed, err := s.box.Seal(signingKey, key.ClientID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SetAPIKeySigningKey(ctx, key.ClientID, sealed); err != nil {
+		return fmt.Errorf("encrypt signing key of %s: %w", key.ClientID, err)
+	}
+	key.EncryptedKey = sealed
+	s.logger.InfoContext(ctx, "Encrypted plaintext signing key of issued API key",
+		slog.String("client_id", key.ClientID))
+	return nil
+}
+
+// TenantLimits returns the quota limits tenant was onboarded with; ok is false for
+// tenants that were not.
+func (s *TenantService) TenantLimits(ctx context.Context, tenant string) (quota.Limits, bool, error) {
+	settings, ok := s.settings.Get(tenant)
+	if !ok {
+		var err error
+		settings, err = s.repo.Settings(ctx, tenant)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			settings, err = nil, nil
+		}
+		if err != nil {
+			return quota.Limits{}, false, err
+		}
+		s.settings.Set(tenant, settings)
+	}
+	if settings == nil {
+		return quota.Limits{}, false, nil
+	}
+	return quota.Limits{Requests: settings.MonthlyRequests, Creates: settings.MonthlyCreates}, true, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
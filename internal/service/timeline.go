@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/audit"
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/identity"
+	"awesomeProject1/internal/model"
+)
+
+type TimelineService struct {
+	subscriptions subscriptionLister
+	audit         auditLister
+	clock         clock.Clock
+	logger        *slog.Logger
+}
+
+type subscriptionLister interface {
+	List(ctx context.Context, filter models.ListFilter, page models.Page) ([]models.Subscription, error)
+}
+
+type auditLister interface {
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditRecord, error)
+}
+
+func NewTimelineService(subscriptions subscriptionLister, audit auditLister, clock clock.Clock, logger *slog.Logger) *TimelineService {
+	return &TimelineService{
+		subscriptions: subscriptions,
+		audit:         audit,
+		clock:         clock,
+		logger:        logger,
+	}
+}
+
+// Timeline assembles a user's subscription history in chronological order. Start and
+// expiry come from the subscriptions themselves; price changes and cancellations come
+// from the audit log, which is the only place earlier prices are kept.
+func (s *TimelineService) Timeline(ctx context.Context, userID uuid.UUID) ([]models.TimelineEvent, error) {
+	s.logger.InfoContext(ctx, "Building user timeline in service layer",
+		slog.String("user_id", userID.String()))
+
+	if id := identity.FromContext(ctx); id.Impersonating() && *id.Subject != userID {
+		s.logger.WarnContext(ctx, "Timeline requested for a different user than the impersonated one",
+			slog.String("user_id", userID.String()),
+			slog.String("subject_id", id.Subject.String()))
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	subs, err := s.subscriptions.List(ctx, models.ListFilter{UserID: userID}, models.Page{})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list subscriptions for timeline",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	events := make([]models.TimelineEvent, 0, len(subs))
+	byID := make(map[uuid.UUID]models.Subscription, len(subs))
+	ids := make([]uuid.UUID, 0, len(subs))
+	now := s.clock.Now().UTC()
+
+	for _, sub := range subs {
+		byID[sub.ID] = sub
+		ids = append(ids, sub.ID)
+
+		events = append(events, models.TimelineEvent{
+			Type:           models.TimelineStarted,
+			At:             sub.StartDate,
+			SubscriptionID: sub.ID,
+			ServiceName:    sub.ServiceName,
+			Price:          sub.Price,
+		})
+
+		// The end date is the last billed month, so the subscription expires when it is over.
+		if sub.EndDate != nil {
+			expiresAt := sub.EndDate.AddDate(0, 1, 0)
+			if !expiresAt.After(now) {
+				events = append(events, models.TimelineEvent{
+					Type:           models.TimelineExpired,
+					At:             expiresAt,
+					SubscriptionID: sub.ID,
+					ServiceName:    sub.ServiceName,
+					Price:          sub.Price,
+				})
+			}
+		}
+	}
+
+	if len(ids) > 0 {
+		records, err := s.audit.List(ctx, models.AuditFilter{
+			SubscriptionIDs: ids,
+			Actions:         []string{audit.ActionUpdate, audit.ActionCancel},
+		})
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to list audit records for timeline",
+				slog.String("user_id", userID.String()),
+				slog.String("error", err.Error()))
+			return nil, err
+		}
+
+		for _, record := range records {
+			events = append(events, timelineEventsFromAudit(record, byID[*record.SubscriptionID])...)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.Before(events[j].At)
+	})
+
+	s.logger.InfoContext(ctx, "Successfully built user timeline in service layer",
+		slog.String("user_id", userID.String()),
+		slog.Int("events", len(events)))
+
+	return events, nil
+}
+
+// timelineEventsFromAudit derives events from an update or cancel audit entry. An update
+// that sets the end date of an open-ended subscription counts as a cancellation too.
+func timelineEventsFromAudit(record models.AuditRecord, sub models.Subscription) []models.TimelineEvent {
+	var before, after struct {
+		Price   int        `json:"price"`
+		EndDate *time.Time `json:"end_date"`
+	}
+	if json.Unmarshal(record.Before, &before) != nil || json.Unmarshal(record.After, &after) != nil {
+		return nil
+	}
+
+	newEvent := func(eventType string) models.TimelineEvent {
+		return models.TimelineEvent{
+			Type:           eventType,
+			At:             record.CreatedAt,
+			SubscriptionID: sub.ID,
+			ServiceName:    sub.ServiceName,
+			Price:          after.Price,
+		}
+	}
+
+	var events []models.TimelineEvent
+	if before.Price != after.Price {
+		event := newEvent(models.TimelinePriceChanged)
+		event.PreviousPrice = &before.Price
+		events = append(events, event)
+	}
+	if record.Action == audit.ActionCancel || (before.EndDate == nil && after.EndDate != nil) {
+		events = append(events, newEvent(models.TimelineCancelled))
+	}
+	return events
+}
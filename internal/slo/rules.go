@@ -0,0 +1,84 @@
+package slo
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type ruleFile struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []rule `yaml:"rules"`
+}
+
+type rule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// WriteRules writes Prometheus alerting rules firing on the same burn rates Status
+// reports, computed from the http_requests_total and http_request_duration_seconds
+// metrics of every instance.
+func WriteRules(w io.Writer, objectives []Objective) error {
+	group := ruleGroup{Name: "slo-burn-rate", Rules: []rule{}}
+	for _, o := range objectives {
+		selector := fmt.Sprintf(`method=%s,route=%s`, strconv.Quote(o.Method), strconv.Quote(o.Route))
+
+		errorRatio := func(window string) string {
+			return fmt.Sprintf(`sum(rate(http_requests_total{%s,status=~"5.."}[%s])) / sum(rate(http_requests_total{%s}[%s]))`,
+				selector, window, selector, window)
+		}
+		group.Rules = append(group.Rules, burnRules(o, "availability", errorRatio, o.errorBudget())...)
+
+		if o.Latency > 0 {
+			le := strconv.Quote(strconv.FormatFloat(o.Latency.Seconds(), 'g', -1, 64))
+			slowRatio := func(window string) string {
+				return fmt.Sprintf(`1 - sum(rate(http_request_duration_seconds_bucket{%s,le=%s}[%s])) / sum(rate(http_request_duration_seconds_count{%s}[%s]))`,
+					selector, le, window, selector, window)
+			}
+			group.Rules = append(group.Rules, burnRules(o, "latency", slowRatio, o.latencyBudget())...)
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(ruleFile{Groups: []ruleGroup{group}}); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func burnRules(o Objective, sli string, ratio func(window string) string, budget float64) []rule {
+	rules := make([]rule, 0, len(burnAlerts))
+	for _, alert := range burnAlerts {
+		long, short := formatWindow(alert.long), formatWindow(alert.short)
+		// Six significant digits hide the float noise of budgets such as 1 - 0.999.
+		threshold := strconv.FormatFloat(alert.factor*budget, 'g', 6, 64)
+		rules = append(rules, rule{
+			Alert: "SLOBurnRate" + strings.ToUpper(sli[:1]) + sli[1:],
+			Expr: fmt.Sprintf("(%s) > %s\nand\n(%s) > %s",
+				ratio(long), threshold, ratio(short), threshold),
+			For: "2m",
+			Labels: map[string]string{
+				"severity": alert.severity,
+				"slo":      o.Name,
+				"sli":      sli,
+			},
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("%s %s %s error budget is burning %gx faster than sustainable over %s and %s",
+					o.Name, o.Method, sli, alert.factor, long, short),
+			},
+		})
+	}
+	return rules
+}
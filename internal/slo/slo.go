@@ -0,0 +1,265 @@
+// Package slo tracks service level objectives per route from the requests recorded by
+// the metrics middleware. Each objective spends an error budget, the share of requests
+// allowed to fail or be slow; the burn rate is how fast it is being spent, 1 spending
+// exactly the budget. As in the multiwindow alerts of the Google SRE workbook, a budget
+// burning fast over both a long and a short window is critical, a slower but sustained
+// burn is a warning.
+//
+// The tracker only sees the requests of its own instance. The Prometheus rules
+// generated by WriteRules evaluate the same objectives across every instance.
+package slo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"awesomeProject1/internal/clock"
+	"awesomeProject1/internal/metrics"
+	"awesomeProject1/internal/model"
+)
+
+// Objective is a target for one route, as labelled by the metrics middleware.
+// Availability is the percentage of requests that must not fail with a 5xx status;
+// when Latency is set, LatencyTarget is the percentage that must finish within it.
+type Objective struct {
+	Name          string        `yaml:"name"`
+	Method        string        `yaml:"method"`
+	Route         string        `yaml:"route"`
+	Availability  float64       `yaml:"availability"`
+	Latency       time.Duration `yaml:"latency"`
+	LatencyTarget float64       `yaml:"latency_target"`
+}
+
+func (o Objective) errorBudget() float64 {
+	return 1 - o.Availability/100
+}
+
+func (o Objective) latencyBudget() float64 {
+	return 1 - o.LatencyTarget/100
+}
+
+type burnAlert struct {
+	severity string
+	long     time.Duration
+	short    time.Duration
+	factor   float64
+}
+
+// burnAlerts page when 2% of a 30-day budget is spent within an hour and warn when 5%
+// is spent within six hours.
+var burnAlerts = []burnAlert{
+	{severity: models.SLOStatusCritical, long: time.Hour, short: 5 * time.Minute, factor: 14.4},
+	{severity: models.SLOStatusWarning, long: 6 * time.Hour, short: 30 * time.Minute, factor: 6},
+}
+
+// windows are the periods reported by Status, shortest first.
+var windows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour}
+
+// LoadObjectives reads objectives from YAML or JSON of the form
+// {"objectives": [{"name": ..., "method": ..., "route": ..., ...}]}.
+func LoadObjectives(r io.Reader) ([]Objective, error) {
+	var file struct {
+		Objectives []Objective `yaml:"objectives"`
+	}
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse SLO objectives: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for i := range file.Objectives {
+		o := &file.Objectives[i]
+		o.Method = strings.ToUpper(o.Method)
+		if err := validate(*o); err != nil {
+			return nil, fmt.Errorf("SLO objective %q: %w", o.Name, err)
+		}
+		if names[o.Name] {
+			return nil, fmt.Errorf("SLO objective %q: duplicate name", o.Name)
+		}
+		names[o.Name] = true
+	}
+	return file.Objectives, nil
+}
+
+func validate(o Objective) error {
+	switch {
+	case o.Name == "":
+		return errors.New("name is required")
+	case o.Method == "":
+		return errors.New("method is required")
+	case !strings.HasPrefix(o.Route, "/"):
+		return errors.New("route must be a path such as /subscriptions/:id")
+	case o.Availability <= 0 || o.Availability >= 100:
+		return errors.New("availability must be a percentage between 0 and 100")
+	case o.Latency < 0:
+		return errors.New("latency must not be negative")
+	}
+
+	if o.Latency == 0 {
+		if o.LatencyTarget != 0 {
+			return errors.New("latency_target requires latency")
+		}
+		return nil
+	}
+	if o.LatencyTarget <= 0 || o.LatencyTarget >= 100 {
+		return errors.New("latency_target must be a percentage between 0 and 100")
+	}
+	// Prometheus can only count requests below a histogram bound.
+	if !slices.Contains(metrics.DurationBuckets(), o.Latency.Seconds()) {
+		return fmt.Errorf("latency must be one of the request duration histogram bounds %v (seconds)", metrics.DurationBuckets())
+	}
+	return nil
+}
+
+// bucket counts the requests of one minute.
+type bucket struct {
+	minute   int64
+	requests int64
+	errors   int64
+	slow     int64
+}
+
+type tracked struct {
+	objective Objective
+	buckets   []bucket
+}
+
+type Tracker struct {
+	clock clock.Clock
+
+	mu      sync.Mutex
+	tracked []*tracked
+	routes  map[string][]*tracked
+}
+
+// NewTracker tracks objectives, keeping per-minute counts for the longest window.
+func NewTracker(objectives []Objective, clock clock.Clock) *Tracker {
+	t := &Tracker{clock: clock, routes: make(map[string][]*tracked)}
+	minutes := int(windows[len(windows)-1] / time.Minute)
+	for _, o := range objectives {
+		tr := &tracked{objective: o, buckets: make([]bucket, minutes)}
+		t.tracked = append(t.tracked, tr)
+		key := o.Method + " " + o.Route
+		t.routes[key] = append(t.routes[key], tr)
+	}
+	return t
+}
+
+// Observe counts a request against the objectives of its route. It has the signature
+// of metrics.Observer.
+func (t *Tracker) Observe(method string, route string, status int, duration time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	matching := t.routes[method+" "+route]
+	if len(matching) == 0 {
+		return
+	}
+
+	minute := t.clock.Now().Unix() / 60
+	for _, tr := range matching {
+		b := &tr.buckets[minute%int64(len(tr.buckets))]
+		if b.minute != minute {
+			*b = bucket{minute: minute}
+		}
+		b.requests++
+		if status >= http.StatusInternalServerError {
+			b.errors++
+		}
+		if tr.objective.Latency > 0 && duration > tr.objective.Latency {
+			b.slow++
+		}
+	}
+}
+
+// Status reports the burn rates of every objective, in configuration order.
+func (t *Tracker) Status() []models.SLOStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now().Unix() / 60
+	statuses := make([]models.SLOStatus, 0, len(t.tracked))
+	for _, tr := range t.tracked {
+		o := tr.objective
+		status := models.SLOStatus{
+			Name:               o.Name,
+			Method:             o.Method,
+			Route:              o.Route,
+			AvailabilityTarget: o.Availability,
+			LatencyTarget:      o.LatencyTarget,
+			Status:             models.SLOStatusOK,
+		}
+		if o.Latency > 0 {
+			status.LatencyThreshold = o.Latency.String()
+		}
+
+		burn := make(map[time.Duration]float64, len(windows))
+		for _, window := range windows {
+			w := tr.window(now, window)
+			burn[window] = max(w.ErrorBurnRate, w.LatencyBurnRate)
+			status.Windows = append(status.Windows, w)
+		}
+
+		// burnAlerts are ordered by severity, so the first one firing wins.
+		for _, alert := range burnAlerts {
+			if burn[alert.long] > alert.factor && burn[alert.short] > alert.factor {
+				status.Status = alert.severity
+				break
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (tr *tracked) window(now int64, window time.Duration) models.SLOWindow {
+	w := models.SLOWindow{Window: formatWindow(window)}
+	since := now - int64(window/time.Minute)
+	for _, b := range tr.buckets {
+		if b.minute > since && b.minute <= now {
+			w.Requests += b.requests
+			w.Errors += b.errors
+			w.Slow += b.slow
+		}
+	}
+	if w.Requests == 0 {
+		return w
+	}
+
+	w.ErrorBurnRate = round(float64(w.Errors) / float64(w.Requests) / tr.objective.errorBudget())
+	if tr.objective.Latency > 0 {
+		w.LatencyBurnRate = round(float64(w.Slow) / float64(w.Requests) / tr.objective.latencyBudget())
+	}
+	return w
+}
+
+func round(burnRate float64) float64 {
+	return math.Round(burnRate*1000) / 1000
+}
+
+// formatWindow writes windows the way Prometheus range selectors do, e.g. 5m or 6h.
+func formatWindow(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
+
+// WriteRules writes the Prometheus alerting rules of the tracked objectives.
+func (t *Tracker) WriteRules(w io.Writer) error {
+	objectives := make([]Objective, 0, len(t.tracked))
+	for _, tr := range t.tracked {
+		objectives = append(objectives, tr.objective)
+	}
+	return WriteRules(w, objectives)
+}
@@ -0,0 +1,39 @@
+package templates
+
+import "time"
+
+// ReminderData is the data the reminder template is rendered with.
+type ReminderData struct {
+	ServiceName string
+	Price       int
+	RenewalDate time.Time
+	DaysBefore  int
+	Message     string
+}
+
+// AnomalyData is the data the anomaly template is rendered with. Baseline is zero for
+// duplicate charges.
+type AnomalyData struct {
+	Kind        string
+	ServiceName string
+	MonthlyCost int64
+	Baseline    int64
+}
+
+// samples holds representative data for every template, used for previews and to
+// validate overrides before they are saved.
+var samples = map[string]any{
+	Reminder: ReminderData{
+		ServiceName: "Yandex Plus",
+		Price:       400,
+		RenewalDate: time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC),
+		DaysBefore:  3,
+		Message:     "",
+	},
+	Anomaly: AnomalyData{
+		Kind:        "price_jump",
+		ServiceName: "Netflix",
+		MonthlyCost: 1500,
+		Baseline:    900,
+	},
+}
@@ -0,0 +1,285 @@
+// Package templates renders notification content from Go templates. Built-in
+// templates are embedded in the binary and each tenant can override any part of them.
+package templates
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"gorm.io/gorm"
+
+	"awesomeProject1/internal/model"
+)
+
+// Template names.
+const (
+	Reminder = "reminder"
+	Anomaly  = "anomaly"
+)
+
+// Each built-in template is a set of files under defaults/: <name>.subject.tmpl and
+// <name>.txt.tmpl are required, <name>.html.tmpl adds an HTML alternative.
+//
+//go:embed defaults/*.tmpl
+var defaultFiles embed.FS
+
+var (
+	ErrUnknownTemplate  = errors.New("unknown template")
+	ErrInvalidTemplate  = errors.New("invalid template")
+	ErrOverrideNotFound = errors.New("template has no override")
+)
+
+type Rendered struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+}
+
+// Template is the effective source of a template for a tenant.
+type Template struct {
+	Name       string     `json:"name"`
+	Subject    string     `json:"subject"`
+	Text       string     `json:"text"`
+	HTML       string     `json:"html,omitempty"`
+	Overridden bool       `json:"overridden"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+type repository interface {
+	Get(ctx context.Context, tenant string, name string) (*models.TemplateOverride, error)
+	List(ctx context.Context, tenant string) ([]models.TemplateOverride, error)
+	Upsert(ctx context.Context, override *models.TemplateOverride) error
+	Delete(ctx context.Context, tenant string, name string) error
+}
+
+type Engine struct {
+	repo     repository
+	defaults map[string]models.TemplateOverride
+	logger   *slog.Logger
+}
+
+func NewEngine(repo repository, logger *slog.Logger) *Engine {
+	return &Engine{
+		repo:     repo,
+		defaults: loadDefaults(),
+		logger:   logger,
+	}
+}
+
+// Render renders the named template for tenant, using the tenant's override where
+// one exists.
+func (e *Engine) Render(ctx context.Context, tenant string, name string, data any) (Rendered, error) {
+	tmpl, err := e.Get(ctx, tenant, name)
+	if err != nil {
+		return Rendered{}, err
+	}
+	return render(tmpl, data)
+}
+
+// Preview renders a template with sample data. A non-nil draft is rendered instead
+// of the stored override, so changes can be checked before saving them.
+func (e *Engine) Preview(ctx context.Context, tenant string, name string, draft *models.TemplateOverride) (Rendered, error) {
+	var tmpl *Template
+	if draft != nil {
+		if _, ok := e.defaults[name]; !ok {
+			return Rendered{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+		}
+		tmpl = e.merge(name, draft)
+	} else {
+		var err error
+		if tmpl, err = e.Get(ctx, tenant, name); err != nil {
+			return Rendered{}, err
+		}
+	}
+
+	out, err := render(tmpl, samples[name])
+	if err != nil {
+		return Rendered{}, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return out, nil
+}
+
+func (e *Engine) Get(ctx context.Context, tenant string, name string) (*Template, error) {
+	base, ok := e.defaults[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	override, err := e.repo.Get(ctx, tenant, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.merge(name, &base), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := e.merge(name, override)
+	tmpl.Overridden = true
+	tmpl.UpdatedAt = &override.UpdatedAt
+	return tmpl, nil
+}
+
+func (e *Engine) List(ctx context.Context, tenant string) ([]Template, error) {
+	overrides, err := e.repo.List(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]models.TemplateOverride, len(overrides))
+	for _, override := range overrides {
+		byName[override.Name] = override
+	}
+
+	names := make([]string, 0, len(e.defaults))
+	for name := range e.defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]Template, 0, len(names))
+	for _, name := range names {
+		base := e.defaults[name]
+		tmpl := e.merge(name, &base)
+		if override, ok := byName[name]; ok {
+			tmpl = e.merge(name, &override)
+			tmpl.Overridden = true
+			tmpl.UpdatedAt = &override.UpdatedAt
+		}
+		list = append(list, *tmpl)
+	}
+	return list, nil
+}
+
+// Save stores an override after checking that it renders with the sample data.
+func (e *Engine) Save(ctx context.Context, override *models.TemplateOverride) error {
+	if _, ok := e.defaults[override.Name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownTemplate, override.Name)
+	}
+	if _, err := render(e.merge(override.Name, override), samples[override.Name]); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	override.UpdatedAt = time.Now().UTC()
+	if err := e.repo.Upsert(ctx, override); err != nil {
+		return err
+	}
+
+	e.logger.InfoContext(ctx, "Saved template override",
+		slog.String("tenant", override.Tenant),
+		slog.String("template", override.Name))
+
+	return nil
+}
+
+func (e *Engine) Delete(ctx context.Context, tenant string, name string) error {
+	if _, ok := e.defaults[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	if err := e.repo.Delete(ctx, tenant, name); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOverrideNotFound
+		}
+		return err
+	}
+
+	e.logger.InfoContext(ctx, "Deleted template override",
+		slog.String("tenant", tenant),
+		slog.String("template", name))
+
+	return nil
+}
+
+// merge fills the parts an override leaves empty from the built-in template.
+func (e *Engine) merge(name string, override *models.TemplateOverride) *Template {
+	base := e.defaults[name]
+	tmpl := &Template{
+		Name:    name,
+		Subject: base.Subject,
+		Text:    base.Text,
+		HTML:    base.HTML,
+	}
+	if override.Subject != "" {
+		tmpl.Subject = override.Subject
+	}
+	if override.Text != "" {
+		tmpl.Text = override.Text
+	}
+	if override.HTML != "" {
+		tmpl.HTML = override.HTML
+	}
+	return tmpl
+}
+
+func render(tmpl *Template, data any) (Rendered, error) {
+	var out Rendered
+	var buf bytes.Buffer
+
+	subject, err := texttemplate.New("subject").Option("missingkey=error").Parse(tmpl.Subject)
+	if err != nil {
+		return Rendered{}, err
+	}
+	if err := subject.Execute(&buf, data); err != nil {
+		return Rendered{}, err
+	}
+	// Subjects are a single header line.
+	out.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	text, err := texttemplate.New("text").Option("missingkey=error").Parse(tmpl.Text)
+	if err != nil {
+		return Rendered{}, err
+	}
+	buf.Reset()
+	if err := text.Execute(&buf, data); err != nil {
+		return Rendered{}, err
+	}
+	out.Text = strings.TrimSpace(buf.String())
+
+	if tmpl.HTML != "" {
+		html, err := htmltemplate.New("html").Option("missingkey=error").Parse(tmpl.HTML)
+		if err != nil {
+			return Rendered{}, err
+		}
+		buf.Reset()
+		if err := html.Execute(&buf, data); err != nil {
+			return Rendered{}, err
+		}
+		out.HTML = buf.String()
+	}
+
+	return out, nil
+}
+
+func loadDefaults() map[string]models.TemplateOverride {
+	read := func(path string) string {
+		data, err := fs.ReadFile(defaultFiles, path)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	defaults := make(map[string]models.TemplateOverride)
+	paths, err := fs.Glob(defaultFiles, "defaults/*.subject.tmpl")
+	if err != nil {
+		panic(fmt.Sprintf("templates: list embedded defaults: %v", err))
+	}
+	for _, path := range paths {
+		name := strings.TrimSuffix(strings.TrimPrefix(path, "defaults/"), ".subject.tmpl")
+		defaults[name] = models.TemplateOverride{
+			Name:    name,
+			Subject: read(path),
+			Text:    read("defaults/" + name + ".txt.tmpl"),
+			HTML:    read("defaults/" + name + ".html.tmpl"),
+		}
+	}
+	return defaults
+}
@@ -0,0 +1,154 @@
+// Package tenancy keeps the data of tenants apart in Postgres schemas of their own, for
+// customers who require hard isolation. Requests of a tenant run on connections whose
+// search_path starts with its schema, which holds copies of the tables the API writes
+// to, like the sandbox schema; every other table is shared.
+package tenancy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"awesomeProject1/internal/identity"
+)
+
+// Storage modes. Shared keeps every tenant in the public tables; Schema gives each
+// tenant a schema of its own.
+const (
+	StorageShared = "shared"
+	StorageSchema = "schema"
+)
+
+// schemaPrefix starts the name of every tenant schema.
+const schemaPrefix = "tenant_"
+
+var ErrInvalidName = errors.New("invalid tenant name")
+
+var validName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,47}$`)
+
+// SchemaName returns the schema holding the data of tenant.
+func SchemaName(tenant string) (string, error) {
+	if !validName.MatchString(tenant) || tenant == identity.DefaultTenant || tenant == identity.SandboxTenant {
+		return "", fmt.Errorf("%w: %q", ErrInvalidName, tenant)
+	}
+	return schemaPrefix + tenant, nil
+}
+
+// TenantName returns the tenant whose data schema holds, the inverse of SchemaName.
+func TenantName(schema string) (string, bool) {
+	tenant, ok := strings.CutPrefix(schema, schemaPrefix)
+	if !ok || !validName.MatchString(tenant) {
+		return "", false
+	}
+	return tenant, true
+}
+
+// Pool is the connection pool the queries of other requests go to.
+type Pool interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// ConnPool is a gorm connection pool sending the queries of requests with a tenant
+// schema, as told by the identity in their context, to a pool of that schema and every
+// other query to next. The pool of a schema is created on its first query; open must
+// put the schema first on the search_path of its connections. Transactions stay on
+// the pool they were begun on.
+type ConnPool struct {
+	next    Pool
+	main    *sql.DB
+	open    func(schema string) *sql.DB
+	maxIdle int
+
+	mu      sync.Mutex
+	schemas map[string]*sql.DB
+}
+
+// NewConnPool routes between next and the pools that open returns; main is the
+// production pool behind next, for health checks and locks. The schema pools keep at
+// most maxIdle idle connections between them.
+func NewConnPool(next Pool, main *sql.DB, maxIdle int, open func(schema string) *sql.DB) *ConnPool {
+	return &ConnPool{
+		next:    next,
+		main:    main,
+		open:    open,
+		maxIdle: maxIdle,
+		schemas: make(map[string]*sql.DB),
+	}
+}
+
+// CloseSchema closes the pool of schema, once the schema is dropped. A later query for
+// it opens a new pool.
+func (p *ConnPool) CloseSchema(schema string) error {
+	p.mu.Lock()
+	db, ok := p.schemas[schema]
+	delete(p.schemas, schema)
+	p.shareIdle()
+	p.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return db.Close()
+}
+
+// shareIdle splits the idle connections allowed evenly between the schema pools. It
+// must be called with mu held whenever a pool is added or removed.
+func (p *ConnPool) shareIdle() {
+	if len(p.schemas) == 0 {
+		return
+	}
+	perSchema := p.maxIdle / len(p.schemas)
+	for _, db := range p.schemas {
+		db.SetMaxIdleConns(perSchema)
+	}
+}
+
+func (p *ConnPool) pool(ctx context.Context) Pool {
+	schema := identity.FromContext(ctx).Schema
+	if schema == "" {
+		return p.next
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	db, ok := p.schemas[schema]
+	if !ok {
+		db = p.open(schema)
+		p.schemas[schema] = db
+		p.shareIdle()
+	}
+	return db
+}
+
+func (p *ConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.pool(ctx).PrepareContext(ctx, query)
+}
+
+func (p *ConnPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return p.pool(ctx).ExecContext(ctx, query, args...)
+}
+
+func (p *ConnPool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return p.pool(ctx).QueryContext(ctx, query, args...)
+}
+
+func (p *ConnPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return p.pool(ctx).QueryRowContext(ctx, query, args...)
+}
+
+func (p *ConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return p.pool(ctx).BeginTx(ctx, opts)
+}
+
+// GetDBConn returns the production pool, for health checks and locks.
+func (p *ConnPool) GetDBConn() (*sql.DB, error) {
+	return p.main, nil
+}
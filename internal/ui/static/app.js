@@ -0,0 +1,185 @@
+"use strict";
+
+// The page only uses the public v1 API, the same one documented in the README. The
+// server renders its mount prefix into the page so the UI works behind a gateway.
+const api = document.body.dataset.basePath + "/v1/subscriptions";
+const pageSize = 20;
+
+let offset = 0;
+
+function $(selector) {
+  return document.querySelector(selector);
+}
+
+function showMessage(text, isError) {
+  const message = $("#message");
+  message.textContent = text;
+  message.className = isError ? "error" : "";
+  if (text) {
+    setTimeout(() => {
+      if (message.textContent === text) {
+        message.textContent = "";
+      }
+    }, 5000);
+  }
+}
+
+async function request(method, path, body) {
+  const options = { method, headers: { Accept: "application/json" } };
+  if (body !== undefined) {
+    options.headers["Content-Type"] = "application/json";
+    options.body = JSON.stringify(body);
+  }
+  const apiKey = sessionStorage.getItem("apiKey");
+  if (apiKey) {
+    options.headers["X-API-Key"] = apiKey;
+  }
+
+  const response = await fetch(path, options);
+  if (response.status === 204) {
+    return null;
+  }
+  const payload = await response.json().catch(() => ({}));
+  // Servers requiring API keys get one from the user, kept for the browser tab only.
+  if (response.status === 401 && (payload.code === "api_key_required" || payload.code === "invalid_api_key")) {
+    const entered = window.prompt("API key");
+    if (entered) {
+      sessionStorage.setItem("apiKey", entered);
+      return request(method, path, body);
+    }
+  }
+  if (!response.ok) {
+    const message = payload.error || response.statusText;
+    throw new Error(payload.hint ? message + " (" + payload.hint + ")" : message);
+  }
+  return payload;
+}
+
+// formValues returns the non-empty fields of a form.
+function formValues(form) {
+  const values = {};
+  for (const [name, value] of new FormData(form)) {
+    if (value !== "") {
+      values[name] = value;
+    }
+  }
+  return values;
+}
+
+function monthYear(timestamp) {
+  if (!timestamp) {
+    return "";
+  }
+  const date = new Date(timestamp);
+  return String(date.getUTCMonth() + 1).padStart(2, "0") + "-" + date.getUTCFullYear();
+}
+
+function cell(row, text) {
+  const td = document.createElement("td");
+  td.textContent = text;
+  row.appendChild(td);
+  return td;
+}
+
+async function loadSubscriptions() {
+  const params = new URLSearchParams(formValues($("#filter")));
+  params.set("limit", pageSize);
+  params.set("offset", offset);
+
+  try {
+    const collection = await request("GET", api + "?" + params);
+    const subscriptions = collection._embedded.subscriptions;
+    const body = $("#subscriptions");
+    body.replaceChildren();
+
+    for (const sub of subscriptions) {
+      const row = document.createElement("tr");
+      cell(row, sub.service_name);
+      cell(row, sub.price);
+      cell(row, sub.user_id);
+      cell(row, sub.kind);
+      cell(row, sub.billing_period);
+      cell(row, monthYear(sub.start_date));
+      cell(row, monthYear(sub.end_date));
+
+      const remove = document.createElement("button");
+      remove.type = "button";
+      remove.textContent = "Delete";
+      remove.addEventListener("click", () => deleteSubscription(sub.id));
+      cell(row, "").appendChild(remove);
+
+      body.appendChild(row);
+    }
+
+    $("#prev").disabled = offset === 0;
+    $("#next").disabled = !collection._links.next;
+  } catch (err) {
+    showMessage("Failed to load subscriptions: " + err.message, true);
+  }
+}
+
+async function deleteSubscription(id) {
+  try {
+    await request("DELETE", api + "/" + id);
+    showMessage("Subscription moved to the trash");
+    await loadSubscriptions();
+  } catch (err) {
+    showMessage("Failed to delete subscription: " + err.message, true);
+  }
+}
+
+$("#filter").addEventListener("submit", (event) => {
+  event.preventDefault();
+  offset = 0;
+  loadSubscriptions();
+});
+
+$("#prev").addEventListener("click", () => {
+  offset = Math.max(offset - pageSize, 0);
+  loadSubscriptions();
+});
+
+$("#next").addEventListener("click", () => {
+  offset += pageSize;
+  loadSubscriptions();
+});
+
+$("#create").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const body = formValues(event.target);
+  body.price = Number(body.price);
+
+  try {
+    const sub = await request("POST", api, body);
+    showMessage("Created subscription " + sub.id);
+    event.target.reset();
+    await loadSubscriptions();
+  } catch (err) {
+    showMessage("Failed to create subscription: " + err.message, true);
+  }
+});
+
+$("#aggregate").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const body = formValues(event.target);
+
+  try {
+    const result = await request("POST", api + "/aggregate", body);
+    $("#total").textContent = "Total (" + result.mode + "): " + result.total;
+
+    const table = $("#buckets");
+    const rows = table.querySelector("tbody");
+    rows.replaceChildren();
+    for (const bucket of result.buckets || []) {
+      const row = document.createElement("tr");
+      cell(row, monthYear(bucket.start));
+      cell(row, bucket.total);
+      rows.appendChild(row);
+    }
+    table.hidden = !result.buckets;
+  } catch (err) {
+    showMessage("Failed to aggregate: " + err.message, true);
+  }
+});
+
+loadSubscriptions();
@@ -0,0 +1,40 @@
+// Package ui serves a small single-page UI for browsing, creating and aggregating
+// subscriptions. It only talks to the public API, so it needs no handlers of its own.
+package ui
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/gin-gonic/gin"
+
+	"awesomeProject1/internal/assets"
+)
+
+//go:embed static templates
+var files embed.FS
+
+type page struct {
+	BasePath string
+}
+
+// Register serves the page at basePath/ and its assets under basePath/ui/.
+func Register(router *gin.Engine, basePath string) error {
+	static, err := fs.Sub(files, "static")
+	if err != nil {
+		return err
+	}
+
+	uiAssets, err := assets.New(static, basePath+"/ui")
+	if err != nil {
+		return err
+	}
+	tmpl, err := uiAssets.Templates(files, "templates/*.html")
+	if err != nil {
+		return err
+	}
+
+	router.GET(basePath+"/", assets.Page(tmpl, "index.html", page{BasePath: basePath}))
+	uiAssets.Register(router)
+	return nil
+}
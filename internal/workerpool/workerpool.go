@@ -0,0 +1,176 @@
+// Package workerpool runs outbound deliveries on bounded, per-destination queues. Each
+// destination gets its own queue and workers, so a slow webhook endpoint or mail relay
+// only backs up its own deliveries; once its queue is full new work is rejected instead
+// of piling up goroutines.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrQueueFull = errors.New("delivery queue is full")
+	ErrClosed    = errors.New("worker pool is closed")
+)
+
+// Stats describes the queue of one destination.
+type Stats struct {
+	Destination string `json:"destination"`
+	Queued      int    `json:"queued"`
+	Capacity    int    `json:"capacity"`
+	InFlight    int64  `json:"in_flight"`
+	Completed   int64  `json:"completed"`
+	Rejected    int64  `json:"rejected"`
+}
+
+type queue struct {
+	tasks     chan func()
+	inFlight  int64
+	completed int64
+	rejected  int64
+}
+
+type Pool struct {
+	workers   int
+	queueSize int
+	logger    *slog.Logger
+
+	mu     sync.Mutex
+	queues map[string]*queue
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewPool creates a pool running workers goroutines per destination, each destination
+// buffering up to queueSize pending tasks.
+func NewPool(workers int, queueSize int, logger *slog.Logger) *Pool {
+	return &Pool{
+		workers:   max(workers, 1),
+		queueSize: max(queueSize, 0),
+		logger:    logger,
+		queues:    make(map[string]*queue),
+	}
+}
+
+// Submit queues task for destination without waiting for it to run. It fails with
+// ErrQueueFull when the destination is saturated.
+func (p *Pool) Submit(destination string, task func()) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	q := p.queue(destination)
+	select {
+	case q.tasks <- task:
+		return nil
+	default:
+		q.rejected++
+		p.logger.Warn("Rejected outbound delivery, queue is full",
+			slog.String("destination", destination),
+			slog.Int("capacity", p.queueSize))
+		return fmt.Errorf("%w: %s", ErrQueueFull, destination)
+	}
+}
+
+// Do runs task on the destination's queue and waits for its result, so synchronous
+// callers get the same isolation and backpressure as Submit.
+func (p *Pool) Do(ctx context.Context, destination string, task func() error) error {
+	result := make(chan error, 1)
+	if err := p.Submit(destination, func() { result <- task() }); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// queue returns the queue of destination, starting its workers on first use. The
+// caller holds p.mu.
+func (p *Pool) queue(destination string) *queue {
+	if q, ok := p.queues[destination]; ok {
+		return q
+	}
+
+	q := &queue{tasks: make(chan func(), p.queueSize)}
+	p.queues[destination] = q
+	for range p.workers {
+		p.wg.Add(1)
+		go p.work(q)
+	}
+	return q
+}
+
+func (p *Pool) work(q *queue) {
+	defer p.wg.Done()
+	for task := range q.tasks {
+		p.mu.Lock()
+		q.inFlight++
+		p.mu.Unlock()
+
+		task()
+
+		p.mu.Lock()
+		q.inFlight--
+		q.completed++
+		p.mu.Unlock()
+	}
+}
+
+// Stats returns the state of every destination's queue, sorted by destination.
+func (p *Pool) Stats() []Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]Stats, 0, len(p.queues))
+	for destination, q := range p.queues {
+		stats = append(stats, Stats{
+			Destination: destination,
+			Queued:      len(q.tasks),
+			Capacity:    cap(q.tasks),
+			InFlight:    q.inFlight,
+			Completed:   q.completed,
+			Rejected:    q.rejected,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Destination < stats[j].Destination
+	})
+	return stats
+}
+
+// Close stops accepting work and waits until the queued tasks have run or ctx is done.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, q := range p.queues {
+			close(q.tasks)
+		}
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,14 @@
+CREATE TABLE audit_log (
+                           id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                           actor TEXT NOT NULL,
+                           subject_id UUID,
+                           action TEXT NOT NULL,
+                           subscription_id UUID,
+                           before JSONB,
+                           after JSONB,
+                           created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_audit_log_subject_id ON audit_log (subject_id);
+CREATE INDEX idx_audit_log_subscription_id ON audit_log (subscription_id);
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at);
@@ -0,0 +1 @@
+ALTER TABLE audit_log ADD COLUMN client_ip TEXT;
@@ -0,0 +1,8 @@
+CREATE TABLE api_quota_usage (
+                                 scope TEXT NOT NULL,
+                                 subject TEXT NOT NULL,
+                                 period DATE NOT NULL,
+                                 requests BIGINT NOT NULL DEFAULT 0,
+                                 creates BIGINT NOT NULL DEFAULT 0,
+                                 PRIMARY KEY (scope, subject, period)
+);
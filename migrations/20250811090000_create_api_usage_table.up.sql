@@ -0,0 +1,11 @@
+CREATE TABLE api_usage (
+                           day DATE NOT NULL,
+                           api_key TEXT NOT NULL,
+                           endpoint TEXT NOT NULL,
+                           calls BIGINT NOT NULL DEFAULT 0,
+                           request_bytes BIGINT NOT NULL DEFAULT 0,
+                           response_bytes BIGINT NOT NULL DEFAULT 0,
+                           PRIMARY KEY (day, api_key, endpoint)
+);
+
+CREATE INDEX idx_api_usage_api_key ON api_usage (api_key, day);
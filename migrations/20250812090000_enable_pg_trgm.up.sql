@@ -0,0 +1,3 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_subscriptions_service_name_trgm ON subscriptions USING gin (lower(service_name) gin_trgm_ops);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscriptions_kind;
+
+ALTER TABLE subscriptions DROP COLUMN kind;
@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions ADD COLUMN kind TEXT NOT NULL DEFAULT 'recurring'
+    CHECK (kind IN ('recurring', 'one_time', 'lifetime'));
+
+CREATE INDEX idx_subscriptions_kind ON subscriptions (kind);
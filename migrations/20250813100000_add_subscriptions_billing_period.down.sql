@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions DROP COLUMN billing_anchor_day;
+
+ALTER TABLE subscriptions DROP COLUMN billing_period;
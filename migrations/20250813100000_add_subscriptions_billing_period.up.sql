@@ -0,0 +1,5 @@
+ALTER TABLE subscriptions ADD COLUMN billing_period TEXT NOT NULL DEFAULT 'monthly'
+    CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly'));
+
+ALTER TABLE subscriptions ADD COLUMN billing_anchor_day SMALLINT NOT NULL DEFAULT 1
+    CHECK (billing_anchor_day BETWEEN 1 AND 31);
@@ -0,0 +1,11 @@
+CREATE TABLE reminders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    days_before INTEGER NOT NULL CHECK (days_before BETWEEN 0 AND 365),
+    message TEXT NOT NULL DEFAULT '',
+    channel TEXT NOT NULL,
+    last_sent_for TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_reminders_subscription_id ON reminders (subscription_id);
@@ -0,0 +1,17 @@
+CREATE TABLE mail_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    template TEXT NOT NULL,
+    recipients TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    html_body TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_mail_deliveries_due ON mail_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_mail_deliveries_created_at ON mail_deliveries (created_at);
@@ -0,0 +1,34 @@
+-- Publishes row changes made outside the API (manual SQL, other tools) on the
+-- subscription_changes channel. The API connects with application_name
+-- 'subscriptions-api' and records its own changes, so those are skipped.
+CREATE OR REPLACE FUNCTION notify_subscription_change() RETURNS trigger AS $$
+DECLARE
+    payload TEXT;
+BEGIN
+    IF current_setting('application_name', true) = 'subscriptions-api' THEN
+        RETURN NULL;
+    END IF;
+
+    payload := json_build_object(
+        'op', TG_OP,
+        'id', CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END,
+        'old', CASE WHEN TG_OP = 'INSERT' THEN NULL ELSE row_to_json(OLD) END,
+        'new', CASE WHEN TG_OP = 'DELETE' THEN NULL ELSE row_to_json(NEW) END
+    )::text;
+
+    -- NOTIFY payloads are limited to 8000 bytes; fall back to the bare change.
+    IF octet_length(payload) > 7900 THEN
+        payload := json_build_object(
+            'op', TG_OP,
+            'id', CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END
+        )::text;
+    END IF;
+
+    PERFORM pg_notify('subscription_changes', payload);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscriptions_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION notify_subscription_change();
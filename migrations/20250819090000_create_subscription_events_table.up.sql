@@ -0,0 +1,10 @@
+CREATE TABLE subscription_events (
+    position BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    version INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    state JSONB NOT NULL,
+    actor TEXT NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (subscription_id, version)
+);
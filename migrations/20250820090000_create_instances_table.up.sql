@@ -0,0 +1,11 @@
+CREATE TABLE instances (
+    id UUID PRIMARY KEY,
+    hostname TEXT NOT NULL,
+    version TEXT NOT NULL,
+    commit TEXT NOT NULL,
+    leader BOOLEAN NOT NULL DEFAULT false,
+    started_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_instances_last_seen_at ON instances (last_seen_at);
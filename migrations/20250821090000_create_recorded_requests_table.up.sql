@@ -0,0 +1,21 @@
+CREATE TABLE recorded_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    capture_id UUID NOT NULL,
+    principal TEXT NOT NULL,
+    user_id UUID,
+    method TEXT NOT NULL,
+    route TEXT NOT NULL,
+    url TEXT NOT NULL,
+    request_headers JSONB NOT NULL,
+    request_body TEXT NOT NULL DEFAULT '',
+    request_truncated BOOLEAN NOT NULL DEFAULT false,
+    status INTEGER NOT NULL,
+    response_headers JSONB NOT NULL,
+    response_body TEXT NOT NULL DEFAULT '',
+    response_truncated BOOLEAN NOT NULL DEFAULT false,
+    duration_ms BIGINT NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_recorded_requests_capture_id ON recorded_requests (capture_id, recorded_at);
+CREATE INDEX idx_recorded_requests_recorded_at ON recorded_requests (recorded_at);
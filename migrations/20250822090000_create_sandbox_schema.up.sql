@@ -0,0 +1,19 @@
+-- Sandbox clients work on copies of the tables their requests write to; the other
+-- tables are shared with production through the search_path of the sandbox
+-- connections. Migrations changing these tables must change the copies too.
+CREATE SCHEMA IF NOT EXISTS sandbox;
+
+CREATE TABLE sandbox.subscriptions (LIKE public.subscriptions INCLUDING ALL);
+
+CREATE TABLE sandbox.reminders (LIKE public.reminders INCLUDING ALL);
+ALTER TABLE sandbox.reminders
+    ADD FOREIGN KEY (subscription_id) REFERENCES sandbox.subscriptions (id) ON DELETE CASCADE;
+
+CREATE TABLE sandbox.subscription_events (LIKE public.subscription_events INCLUDING ALL);
+
+CREATE TABLE sandbox.audit_log (LIKE public.audit_log INCLUDING ALL);
+
+CREATE TABLE sandbox.resets (
+    reset_at TIMESTAMPTZ PRIMARY KEY,
+    subscriptions BIGINT NOT NULL
+);
@@ -0,0 +1,4 @@
+ALTER TABLE sandbox.subscriptions DROP COLUMN IF EXISTS custom_fields;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS custom_fields;
+
+DROP TABLE IF EXISTS custom_fields;
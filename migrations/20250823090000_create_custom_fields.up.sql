@@ -0,0 +1,14 @@
+CREATE TABLE custom_fields (
+    tenant TEXT NOT NULL,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('string', 'number', 'boolean', 'date')),
+    required BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant, name)
+);
+
+ALTER TABLE subscriptions ADD COLUMN custom_fields JSONB;
+CREATE INDEX idx_subscriptions_custom_fields ON subscriptions USING GIN (custom_fields jsonb_path_ops);
+
+ALTER TABLE sandbox.subscriptions ADD COLUMN custom_fields JSONB;
+CREATE INDEX idx_sandbox_subscriptions_custom_fields ON sandbox.subscriptions USING GIN (custom_fields jsonb_path_ops);
@@ -0,0 +1,5 @@
+ALTER TABLE sandbox.subscriptions DROP COLUMN IF EXISTS category;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS category;
+
+DROP TABLE IF EXISTS service_catalog;
+DROP TABLE IF EXISTS categories;
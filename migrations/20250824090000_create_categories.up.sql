@@ -0,0 +1,33 @@
+CREATE TABLE categories (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO categories (slug, name) VALUES
+    ('streaming', 'Streaming'),
+    ('music', 'Music'),
+    ('software', 'Software'),
+    ('cloud', 'Cloud storage'),
+    ('utilities', 'Utilities'),
+    ('fitness', 'Fitness'),
+    ('news', 'News'),
+    ('gaming', 'Gaming'),
+    ('education', 'Education');
+
+-- The service catalog gives the category of subscriptions without one of their own.
+-- Service names are stored trimmed and lower-cased.
+CREATE TABLE service_catalog (
+    service_name TEXT PRIMARY KEY,
+    category TEXT NOT NULL REFERENCES categories (slug) ON UPDATE CASCADE ON DELETE CASCADE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE subscriptions
+    ADD COLUMN category TEXT REFERENCES categories (slug) ON UPDATE CASCADE ON DELETE SET NULL;
+CREATE INDEX idx_subscriptions_category ON subscriptions (category);
+
+ALTER TABLE sandbox.subscriptions
+    ADD COLUMN category TEXT REFERENCES categories (slug) ON UPDATE CASCADE ON DELETE SET NULL;
+CREATE INDEX idx_sandbox_subscriptions_category ON sandbox.subscriptions (category);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS sandbox.anomalies;
+DROP TABLE IF EXISTS anomalies;
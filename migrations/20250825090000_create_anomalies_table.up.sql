@@ -0,0 +1,18 @@
+CREATE TABLE anomalies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    related_subscription_id UUID REFERENCES subscriptions (id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('new_expensive', 'price_jump', 'duplicate_charge')),
+    service_name TEXT NOT NULL,
+    monthly_cost BIGINT NOT NULL,
+    baseline BIGINT,
+    key TEXT NOT NULL UNIQUE,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_anomalies_user_id_detected_at ON anomalies (user_id, detected_at DESC);
+
+-- Only production subscriptions are scanned. The empty copy keeps sandbox clients
+-- from reading production anomalies through the search_path.
+CREATE TABLE sandbox.anomalies (LIKE public.anomalies INCLUDING ALL);
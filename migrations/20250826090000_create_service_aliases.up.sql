@@ -0,0 +1,8 @@
+-- Service aliases map misspelled or legacy service names onto their canonical
+-- spelling; the repair command renames subscriptions accordingly. Aliases are stored
+-- trimmed and lower-cased, like service catalog keys.
+CREATE TABLE service_aliases (
+    alias TEXT PRIMARY KEY,
+    service_name TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
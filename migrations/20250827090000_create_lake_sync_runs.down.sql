@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS lake_sync_runs;
+DROP TRIGGER IF EXISTS subscriptions_touch_updated_at ON sandbox.subscriptions;
+DROP TRIGGER IF EXISTS subscriptions_touch_updated_at ON subscriptions;
+DROP FUNCTION IF EXISTS touch_subscription_updated_at();
+ALTER TABLE sandbox.subscriptions DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,39 @@
+-- updated_at lets the data lake sync pick up the rows changed since its last run.
+-- It is kept by a trigger so that changes made outside the API are picked up too.
+ALTER TABLE subscriptions ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+CREATE INDEX idx_subscriptions_updated_at ON subscriptions (updated_at);
+
+ALTER TABLE sandbox.subscriptions ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+CREATE INDEX idx_sandbox_subscriptions_updated_at ON sandbox.subscriptions (updated_at);
+
+CREATE OR REPLACE FUNCTION touch_subscription_updated_at() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at := now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscriptions_touch_updated_at
+    BEFORE UPDATE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION touch_subscription_updated_at();
+
+CREATE TRIGGER subscriptions_touch_updated_at
+    BEFORE UPDATE ON sandbox.subscriptions
+    FOR EACH ROW EXECUTE FUNCTION touch_subscription_updated_at();
+
+-- One row per sync of a destination. The until of its last succeeded run is where
+-- the next run of a destination starts.
+CREATE TABLE lake_sync_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    destination TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('running', 'succeeded', 'failed')),
+    since TIMESTAMPTZ NOT NULL,
+    until TIMESTAMPTZ NOT NULL,
+    row_count BIGINT NOT NULL DEFAULT 0,
+    object_key TEXT,
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_lake_sync_runs_destination_started_at ON lake_sync_runs (destination, started_at DESC);
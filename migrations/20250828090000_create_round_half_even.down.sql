@@ -0,0 +1 @@
+DROP FUNCTION IF EXISTS round_half_even(numeric, integer);
@@ -0,0 +1,9 @@
+-- Banker's rounding for ROUNDING_MODE=half_even: halves go to the nearest even digit.
+-- round on numeric rounds them away from zero.
+CREATE OR REPLACE FUNCTION round_half_even(x numeric, places integer) RETURNS numeric AS $$
+    SELECT CASE
+        WHEN abs(x * 10::numeric ^ places - trunc(x * 10::numeric ^ places)) = 0.5
+            THEN 2 * round(x * 10::numeric ^ places / 2) / 10::numeric ^ places
+        ELSE round(x, places)
+    END
+$$ LANGUAGE sql IMMUTABLE STRICT;
@@ -0,0 +1,5 @@
+SELECT for_each_tenant_schema('DROP SCHEMA IF EXISTS %1$I CASCADE');
+
+DROP FUNCTION IF EXISTS for_each_tenant_schema(text);
+DROP FUNCTION IF EXISTS provision_tenant_schema(text);
+DROP TABLE IF EXISTS tenants;
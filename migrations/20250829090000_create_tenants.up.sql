@@ -0,0 +1,48 @@
+-- Tenants whose data is kept in a schema of their own with TENANT_STORAGE=schema.
+CREATE TABLE tenants (
+    name TEXT PRIMARY KEY,
+    schema_name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- A tenant schema holds copies of the tables the API writes to, like the sandbox
+-- schema; every other table is shared through the search_path of the tenant's
+-- connections. Migrations changing these tables must change the copies in every
+-- tenant schema with for_each_tenant_schema, and keep this function in step.
+CREATE OR REPLACE FUNCTION provision_tenant_schema(tenant_schema text) RETURNS void AS $$
+BEGIN
+    EXECUTE format('CREATE SCHEMA %I', tenant_schema);
+
+    EXECUTE format('CREATE TABLE %I.subscriptions (LIKE public.subscriptions INCLUDING ALL)', tenant_schema);
+    EXECUTE format('ALTER TABLE %I.subscriptions
+        ADD FOREIGN KEY (category) REFERENCES public.categories (slug) ON UPDATE CASCADE ON DELETE SET NULL', tenant_schema);
+    EXECUTE format('CREATE TRIGGER subscriptions_touch_updated_at
+        BEFORE UPDATE ON %I.subscriptions
+        FOR EACH ROW EXECUTE FUNCTION touch_subscription_updated_at()', tenant_schema);
+
+    EXECUTE format('CREATE TABLE %I.reminders (LIKE public.reminders INCLUDING ALL)', tenant_schema);
+    EXECUTE format('ALTER TABLE %1$I.reminders
+        ADD FOREIGN KEY (subscription_id) REFERENCES %1$I.subscriptions (id) ON DELETE CASCADE', tenant_schema);
+
+    EXECUTE format('CREATE TABLE %I.subscription_events (LIKE public.subscription_events INCLUDING ALL)', tenant_schema);
+    EXECUTE format('CREATE TABLE %I.audit_log (LIKE public.audit_log INCLUDING ALL)', tenant_schema);
+
+    EXECUTE format('CREATE TABLE %I.anomalies (LIKE public.anomalies INCLUDING ALL)', tenant_schema);
+    EXECUTE format('ALTER TABLE %1$I.anomalies
+        ADD FOREIGN KEY (subscription_id) REFERENCES %1$I.subscriptions (id) ON DELETE CASCADE,
+        ADD FOREIGN KEY (related_subscription_id) REFERENCES %1$I.subscriptions (id) ON DELETE CASCADE', tenant_schema);
+END;
+$$ LANGUAGE plpgsql;
+
+-- for_each_tenant_schema runs statement in every tenant schema, with %1$I in it
+-- standing for the schema, e.g.
+-- SELECT for_each_tenant_schema('ALTER TABLE %1$I.subscriptions ADD COLUMN note TEXT');
+CREATE OR REPLACE FUNCTION for_each_tenant_schema(statement text) RETURNS void AS $$
+DECLARE
+    tenant_schema text;
+BEGIN
+    FOR tenant_schema IN SELECT schema_name FROM tenants ORDER BY name LOOP
+        EXECUTE format(statement, tenant_schema);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
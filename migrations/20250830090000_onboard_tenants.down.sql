@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS tenant_webhooks;
+DROP TABLE IF EXISTS tenant_api_keys;
+DROP TABLE IF EXISTS tenant_settings;
+DROP FUNCTION IF EXISTS drop_tenant_schema(text);
+
+DELETE FROM tenants WHERE schema_name IS NULL;
+
+CREATE OR REPLACE FUNCTION for_each_tenant_schema(statement text) RETURNS void AS $$
+DECLARE
+    tenant_schema text;
+BEGIN
+    FOR tenant_schema IN SELECT schema_name FROM tenants ORDER BY name LOOP
+        EXECUTE format(statement, tenant_schema);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE tenants
+    DROP COLUMN suspended_at,
+    DROP COLUMN status,
+    ALTER COLUMN schema_name SET NOT NULL;
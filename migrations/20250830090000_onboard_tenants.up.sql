@@ -0,0 +1,50 @@
+-- Tenants are now onboarded through the admin API whether or not they are isolated;
+-- only isolated tenants have a schema.
+ALTER TABLE tenants
+    ALTER COLUMN schema_name DROP NOT NULL,
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended')),
+    ADD COLUMN suspended_at TIMESTAMPTZ;
+
+CREATE OR REPLACE FUNCTION for_each_tenant_schema(statement text) RETURNS void AS $$
+DECLARE
+    tenant_schema text;
+BEGIN
+    FOR tenant_schema IN SELECT schema_name FROM tenants WHERE schema_name IS NOT NULL ORDER BY name LOOP
+        EXECUTE format(statement, tenant_schema);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION drop_tenant_schema(tenant_schema text) RETURNS void AS $$
+BEGIN
+    EXECUTE format('DROP SCHEMA IF EXISTS %I CASCADE', tenant_schema);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Monthly quota limits of a tenant, overriding QUOTA_TENANT_MONTHLY_*; zero means
+-- unlimited.
+CREATE TABLE tenant_settings (
+    tenant TEXT PRIMARY KEY REFERENCES tenants (name) ON DELETE CASCADE,
+    monthly_requests BIGINT NOT NULL DEFAULT 0 CHECK (monthly_requests >= 0),
+    monthly_creates BIGINT NOT NULL DEFAULT 0 CHECK (monthly_creates >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- HMAC clients issued to tenants, next to the ones in HMAC_CLIENTS. Like those, only
+-- the SHA-256 of the shared secret is stored.
+CREATE TABLE tenant_api_keys (
+    client_id TEXT PRIMARY KEY,
+    tenant TEXT NOT NULL REFERENCES tenants (name) ON DELETE CASCADE,
+    secret_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_tenant_api_keys_tenant ON tenant_api_keys (tenant);
+
+CREATE TABLE tenant_webhooks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant TEXT NOT NULL REFERENCES tenants (name) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_tenant_webhooks_tenant ON tenant_webhooks (tenant);
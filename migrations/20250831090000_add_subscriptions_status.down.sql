@@ -0,0 +1,3 @@
+SELECT for_each_tenant_schema('ALTER TABLE %1$I.subscriptions DROP COLUMN IF EXISTS status');
+ALTER TABLE sandbox.subscriptions DROP COLUMN IF EXISTS status;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS status;
@@ -0,0 +1,13 @@
+-- Scheduled subscriptions start in a future month and are left out of aggregations
+-- until the scheduler activates them.
+ALTER TABLE subscriptions
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'scheduled'));
+CREATE INDEX idx_subscriptions_scheduled ON subscriptions (start_date) WHERE status = 'scheduled';
+
+ALTER TABLE sandbox.subscriptions
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'scheduled'));
+CREATE INDEX idx_sandbox_subscriptions_scheduled ON sandbox.subscriptions (start_date) WHERE status = 'scheduled';
+
+SELECT for_each_tenant_schema('ALTER TABLE %1$I.subscriptions
+    ADD COLUMN status TEXT NOT NULL DEFAULT ''active'' CHECK (status IN (''active'', ''scheduled''))');
+SELECT for_each_tenant_schema('CREATE INDEX ON %1$I.subscriptions (start_date) WHERE status = ''scheduled''');
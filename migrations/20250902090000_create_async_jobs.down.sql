@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS async_job_files;
+DROP TABLE IF EXISTS async_jobs;
@@ -0,0 +1,25 @@
+CREATE TABLE async_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('running', 'succeeded', 'failed')),
+    actor TEXT NOT NULL DEFAULT '',
+    unit TEXT NOT NULL,
+    progress BIGINT NOT NULL DEFAULT 0,
+    total BIGINT,
+    result JSONB,
+    error TEXT,
+    file_name TEXT,
+    content_type TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    heartbeat_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_async_jobs_running ON async_jobs (heartbeat_at) WHERE status = 'running';
+CREATE INDEX idx_async_jobs_finished_at ON async_jobs (finished_at);
+
+-- Files produced by jobs, such as exports, kept apart so polling a job stays cheap.
+CREATE TABLE async_job_files (
+    job_id UUID PRIMARY KEY REFERENCES async_jobs (id) ON DELETE CASCADE,
+    content BYTEA NOT NULL
+);
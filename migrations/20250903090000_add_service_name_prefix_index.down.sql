@@ -0,0 +1,3 @@
+SELECT for_each_tenant_schema('DROP INDEX IF EXISTS %1$I.idx_subscriptions_service_name_prefix');
+DROP INDEX IF EXISTS sandbox.idx_sandbox_subscriptions_service_name_prefix;
+DROP INDEX IF EXISTS idx_subscriptions_service_name_prefix;
@@ -0,0 +1,6 @@
+-- Service name autocompletion matches lower-cased prefixes, which the trigram index
+-- cannot serve for prefixes shorter than three characters.
+CREATE INDEX idx_subscriptions_service_name_prefix ON subscriptions (lower(service_name) text_pattern_ops);
+CREATE INDEX idx_sandbox_subscriptions_service_name_prefix ON sandbox.subscriptions (lower(service_name) text_pattern_ops);
+
+SELECT for_each_tenant_schema('CREATE INDEX idx_subscriptions_service_name_prefix ON %1$I.subscriptions (lower(service_name) text_pattern_ops)');
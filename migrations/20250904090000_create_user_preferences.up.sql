@@ -0,0 +1,12 @@
+-- Defaults users set for their own subscriptions, per tenant like custom fields. Empty
+-- strings and NULL leave the deployment's behaviour unchanged.
+CREATE TABLE user_preferences (
+    tenant TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    currency TEXT NOT NULL DEFAULT '',
+    timezone TEXT NOT NULL DEFAULT '',
+    notification_channels JSONB NOT NULL DEFAULT '[]',
+    reminder_days_before INTEGER CHECK (reminder_days_before BETWEEN 0 AND 365),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant, user_id)
+);
@@ -0,0 +1,3 @@
+-- Encrypted keys cannot be decrypted here; clients issued or used since the up
+-- migration have to be issued again after rolling back.
+ALTER TABLE tenant_api_keys RENAME COLUMN signing_key TO secret_hash;
@@ -0,0 +1,4 @@
+-- The SHA-256 of an issued client's secret is its HMAC key, so storing it is no safer
+-- than storing the secret. Keys are now stored encrypted with SECRET_ENCRYPTION_KEY;
+-- the plaintext ones issued before are encrypted by the application on first use.
+ALTER TABLE tenant_api_keys RENAME COLUMN secret_hash TO signing_key;
@@ -0,0 +1,63 @@
+-- Changes to the subscriptions of tenant schemas are published too, with the schema
+-- they were made in so the listener records them for the right tenant.
+CREATE OR REPLACE FUNCTION notify_subscription_change() RETURNS trigger AS $$
+DECLARE
+    payload TEXT;
+BEGIN
+    IF current_setting('application_name', true) = 'subscriptions-api' THEN
+        RETURN NULL;
+    END IF;
+
+    payload := json_build_object(
+        'op', TG_OP,
+        'schema', TG_TABLE_SCHEMA,
+        'id', CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END,
+        'old', CASE WHEN TG_OP = 'INSERT' THEN NULL ELSE row_to_json(OLD) END,
+        'new', CASE WHEN TG_OP = 'DELETE' THEN NULL ELSE row_to_json(NEW) END
+    )::text;
+
+    -- NOTIFY payloads are limited to 8000 bytes; fall back to the bare change.
+    IF octet_length(payload) > 7900 THEN
+        payload := json_build_object(
+            'op', TG_OP,
+            'schema', TG_TABLE_SCHEMA,
+            'id', CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END
+        )::text;
+    END IF;
+
+    PERFORM pg_notify('subscription_changes', payload);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION provision_tenant_schema(tenant_schema text) RETURNS void AS $$
+BEGIN
+    EXECUTE format('CREATE SCHEMA %I', tenant_schema);
+
+    EXECUTE format('CREATE TABLE %I.subscriptions (LIKE public.subscriptions INCLUDING ALL)', tenant_schema);
+    EXECUTE format('ALTER TABLE %I.subscriptions
+        ADD FOREIGN KEY (category) REFERENCES public.categories (slug) ON UPDATE CASCADE ON DELETE SET NULL', tenant_schema);
+    EXECUTE format('CREATE TRIGGER subscriptions_touch_updated_at
+        BEFORE UPDATE ON %I.subscriptions
+        FOR EACH ROW EXECUTE FUNCTION touch_subscription_updated_at()', tenant_schema);
+    EXECUTE format('CREATE TRIGGER subscriptions_notify_change
+        AFTER INSERT OR UPDATE OR DELETE ON %I.subscriptions
+        FOR EACH ROW EXECUTE FUNCTION notify_subscription_change()', tenant_schema);
+
+    EXECUTE format('CREATE TABLE %I.reminders (LIKE public.reminders INCLUDING ALL)', tenant_schema);
+    EXECUTE format('ALTER TABLE %1$I.reminders
+        ADD FOREIGN KEY (subscription_id) REFERENCES %1$I.subscriptions (id) ON DELETE CASCADE', tenant_schema);
+
+    EXECUTE format('CREATE TABLE %I.subscription_events (LIKE public.subscription_events INCLUDING ALL)', tenant_schema);
+    EXECUTE format('CREATE TABLE %I.audit_log (LIKE public.audit_log INCLUDING ALL)', tenant_schema);
+
+    EXECUTE format('CREATE TABLE %I.anomalies (LIKE public.anomalies INCLUDING ALL)', tenant_schema);
+    EXECUTE format('ALTER TABLE %1$I.anomalies
+        ADD FOREIGN KEY (subscription_id) REFERENCES %1$I.subscriptions (id) ON DELETE CASCADE,
+        ADD FOREIGN KEY (related_subscription_id) REFERENCES %1$I.subscriptions (id) ON DELETE CASCADE', tenant_schema);
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT for_each_tenant_schema('CREATE TRIGGER subscriptions_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON %1$I.subscriptions
+    FOR EACH ROW EXECUTE FUNCTION notify_subscription_change()');